@@ -204,12 +204,31 @@ func testEmail(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.MapToJson(m)))
 }
 
+// isComplianceEnabled checks that compliance reports are turned on and that there's an exporter to run them. The
+// built in exporter doesn't need a license but any other implementation does.
+func isComplianceEnabled() bool {
+	if !*utils.Cfg.ComplianceSettings.Enable {
+		return false
+	}
+
+	complianceI := einterfaces.GetComplianceInterface()
+	if complianceI == nil {
+		return false
+	}
+
+	if _, ok := complianceI.(*ComplianceExporter); ok {
+		return true
+	}
+
+	return utils.IsLicensed && *utils.License.Features.Compliance
+}
+
 func getComplianceReports(c *Context, w http.ResponseWriter, r *http.Request) {
 	if !c.HasSystemAdminPermissions("getComplianceReports") {
 		return
 	}
 
-	if !*utils.Cfg.ComplianceSettings.Enable || !utils.IsLicensed || !*utils.License.Features.Compliance {
+	if !isComplianceEnabled() {
 		c.Err = model.NewLocAppError("getComplianceReports", "ent.compliance.licence_disable.app_error", nil, "")
 		return
 	}
@@ -228,7 +247,7 @@ func saveComplianceReport(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !*utils.Cfg.ComplianceSettings.Enable || !utils.IsLicensed || !*utils.License.Features.Compliance || einterfaces.GetComplianceInterface() == nil {
+	if !isComplianceEnabled() {
 		c.Err = model.NewLocAppError("saveComplianceReport", "ent.compliance.licence_disable.app_error", nil, "")
 		return
 	}
@@ -258,7 +277,7 @@ func downloadComplianceReport(c *Context, w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if !*utils.Cfg.ComplianceSettings.Enable || !utils.IsLicensed || !*utils.License.Features.Compliance || einterfaces.GetComplianceInterface() == nil {
+	if !isComplianceEnabled() {
 		c.Err = model.NewLocAppError("downloadComplianceReport", "ent.compliance.licence_disable.app_error", nil, "")
 		return
 	}
@@ -433,6 +452,29 @@ func getAnalytics(c *Context, w http.ResponseWriter, r *http.Request) {
 			rows[5].Value = float64(r.Data.(int64))
 		}
 
+		w.Write([]byte(rows.ToJson()))
+	} else if name == "storage_counts" {
+		var rows model.AnalyticsRows = make([]*model.AnalyticsRow, 2)
+		rows[0] = &model.AnalyticsRow{"file_count", 0}
+		rows[1] = &model.AnalyticsRow{"storage_used", 0}
+
+		fileChan := Srv.Store.FileInfo().AnalyticsFileCount(teamId)
+		storageChan := Srv.Store.FileInfo().AnalyticsStorageUsed(teamId)
+
+		if r := <-fileChan; r.Err != nil {
+			c.Err = r.Err
+			return
+		} else {
+			rows[0].Value = float64(r.Data.(int64))
+		}
+
+		if r := <-storageChan; r.Err != nil {
+			c.Err = r.Err
+			return
+		} else {
+			rows[1].Value = float64(r.Data.(int64))
+		}
+
 		w.Write([]byte(rows.ToJson()))
 	} else {
 		c.SetInvalidParam("getAnalytics", "name")
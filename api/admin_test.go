@@ -427,6 +427,37 @@ func TestGetTeamAnalyticsExtra(t *testing.T) {
 	}
 }
 
+func TestGetAnalyticsStorage(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+
+	if _, err := th.BasicClient.GetTeamAnalytics(th.BasicTeam.Id, "storage_counts"); err == nil {
+		t.Fatal("Shouldn't have permissions")
+	}
+
+	if result, err := th.SystemAdminClient.GetTeamAnalytics(th.BasicTeam.Id, "storage_counts"); err != nil {
+		t.Fatal(err)
+	} else {
+		rows := result.Data.(model.AnalyticsRows)
+
+		if rows[0].Name != "file_count" || rows[0].Value != 0 {
+			t.Log(rows.ToJson())
+			t.Fatal()
+		}
+
+		if rows[1].Name != "storage_used" || rows[1].Value != 0 {
+			t.Log(rows.ToJson())
+			t.Fatal()
+		}
+	}
+
+	if result, err := th.SystemAdminClient.GetSystemAnalytics("storage_counts"); err != nil {
+		t.Fatal(err)
+	} else if rows := result.Data.(model.AnalyticsRows); rows[0].Name != "file_count" || rows[1].Name != "storage_used" {
+		t.Log(rows.ToJson())
+		t.Fatal()
+	}
+}
+
 func TestAdminResetMfa(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 
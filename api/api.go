@@ -77,11 +77,14 @@ func InitApi() {
 	BaseRoutes.Emoji = BaseRoutes.ApiRoot.PathPrefix("/emoji").Subrouter()
 
 	InitUser()
+	InitUserAccessToken()
+	InitBot()
 	InitTeam()
 	InitChannel()
 	InitPost()
 	InitWebSocket()
 	InitFile()
+	InitUploadSession()
 	InitCommand()
 	InitAdmin()
 	InitGeneral()
@@ -90,6 +93,8 @@ func InitApi() {
 	InitPreference()
 	InitLicense()
 	InitEmoji()
+	InitReaction()
+	InitSaml()
 
 	// 404 on any api route before web.go has a chance to serve it
 	Srv.Router.Handle("/api/{anything:.*}", http.HandlerFunc(Handle404))
@@ -130,6 +130,12 @@ func checkUserNotDisabled(user *model.User) *model.AppError {
 }
 
 func authenticateUser(user *model.User, password, mfaToken string) (*model.User, *model.AppError) {
+	if user.IsBot {
+		err := model.NewLocAppError("login", "api.user.login.bot.app_error", nil, "user_id="+user.Id)
+		err.StatusCode = http.StatusUnauthorized
+		return user, err
+	}
+
 	ldapAvailable := *utils.Cfg.LdapSettings.Enable && einterfaces.GetLdapInterface() != nil && utils.IsLicensed && *utils.License.Features.LDAP
 
 	if user.AuthService == model.USER_AUTH_SERVICE_LDAP {
@@ -0,0 +1,156 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitBot() {
+	l4g.Debug(utils.T("api.bot.init.debug"))
+
+	BaseRoutes.Users.Handle("/bots", ApiUserRequired(getBots)).Methods("GET")
+	BaseRoutes.Users.Handle("/bots/create", ApiUserRequired(createBot)).Methods("POST")
+}
+
+func createBot(c *Context, w http.ResponseWriter, r *http.Request) {
+	if *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations && !c.IsSystemAdmin() {
+		c.Err = model.NewLocAppError("createBot", "api.command.admin_only.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+
+	bot := &model.User{
+		Username:   props["username"],
+		Nickname:   props["display_name"],
+		IsBot:      true,
+		BotOwnerId: c.Session.UserId,
+	}
+
+	teamId := props["team_id"]
+	if len(teamId) > 0 && !c.HasPermissionsToTeam(teamId, "createBot") {
+		return
+	}
+
+	ruser, err := CreateUser(bot)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if len(teamId) > 0 {
+		if err := JoinUserToTeamById(teamId, ruser); err != nil {
+			c.Err = err
+			return
+		}
+	}
+
+	c.LogAudit("bot_id=" + ruser.Id + " username=" + ruser.Username)
+
+	w.Write([]byte(ruser.ToJson()))
+}
+
+// getBots returns the bots owned by the current user, or every bot for a system admin
+func getBots(c *Context, w http.ResponseWriter, r *http.Request) {
+	ownerId := c.Session.UserId
+	if c.IsSystemAdmin() {
+		ownerId = ""
+	}
+
+	if result := <-Srv.Store.User().GetBots(ownerId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		bots := make(map[string]*model.User)
+		for _, bot := range result.Data.([]*model.User) {
+			bots[bot.Id] = bot
+		}
+
+		w.Write([]byte(model.UserMapToJson(bots)))
+	}
+}
+
+// hasPermissionsToUserOrBot is like HasPermissionsToUser but also lets the owner of a bot act on its behalf
+func hasPermissionsToUserOrBot(c *Context, userId string, where string) bool {
+	if c.Session.UserId == userId || c.IsSystemAdmin() {
+		return true
+	}
+
+	if result := <-Srv.Store.User().Get(userId); result.Err == nil {
+		if user := result.Data.(*model.User); user.IsBot && user.BotOwnerId == c.Session.UserId {
+			return true
+		}
+	}
+
+	return c.HasPermissionsToUser(userId, where)
+}
+
+// checkIntegrationBot makes sure that a bot that an integration is going to post as exists and belongs to the
+// current user. Integrations without a bot are always allowed.
+func checkIntegrationBot(c *Context, botUserId string, where string) bool {
+	if len(botUserId) == 0 {
+		return true
+	}
+
+	var bot *model.User
+	if result := <-Srv.Store.User().Get(botUserId); result.Err != nil {
+		c.Err = model.NewLocAppError(where, "api.bot.invalid_bot.app_error", nil, "bot_user_id="+botUserId)
+		c.Err.StatusCode = http.StatusBadRequest
+		return false
+	} else {
+		bot = result.Data.(*model.User)
+	}
+
+	if !bot.IsBot || bot.DeleteAt != 0 {
+		c.Err = model.NewLocAppError(where, "api.bot.invalid_bot.app_error", nil, "bot_user_id="+botUserId)
+		c.Err.StatusCode = http.StatusBadRequest
+		return false
+	}
+
+	if bot.BotOwnerId != c.Session.UserId && !c.IsSystemAdmin() {
+		c.Err = model.NewLocAppError(where, "api.bot.permissions.app_error", nil, "bot_user_id="+botUserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return false
+	}
+
+	return true
+}
+
+// newIntegrationContext copies the context with a mock session for the user that an integration posts as
+func newIntegrationContext(c *Context, userId string, teamId string) *Context {
+	mockSession := model.Session{
+		UserId:      userId,
+		TeamMembers: []*model.TeamMember{{TeamId: teamId, UserId: userId}},
+		IsOAuth:     false,
+	}
+
+	return &Context{
+		Session:      mockSession,
+		RequestId:    model.NewId(),
+		IpAddress:    "",
+		Path:         c.Path,
+		Err:          nil,
+		teamURLValid: c.teamURLValid,
+		teamURL:      c.teamURL,
+		siteURL:      c.siteURL,
+		T:            c.T,
+		Locale:       c.Locale,
+		TeamId:       teamId,
+	}
+}
+
+// integrationUserId returns the user that an integration posts as, which is its bot if it has one
+func integrationUserId(botUserId string, creatorId string) string {
+	if len(botUserId) > 0 {
+		return botUserId
+	}
+
+	return creatorId
+}
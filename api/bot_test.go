@@ -0,0 +1,177 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestCreateBot(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	bot, err := Client.CreateBot("bot"+model.NewId(), "Test Bot", th.BasicTeam.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bot.IsBot || bot.BotOwnerId != th.BasicUser.Id {
+		t.Fatal("should've created a bot owned by the user")
+	}
+
+	if bots, err := Client.GetBots(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := bots[bot.Id]; !ok || len(bots) != 1 {
+		t.Fatal("should've returned the user's bot")
+	}
+
+	th.LoginBasic2()
+	if bots, err := Client.GetBots(); err != nil {
+		t.Fatal(err)
+	} else if len(bots) != 0 {
+		t.Fatal("shouldn't return another user's bots")
+	}
+
+	if _, err := Client.CreateBot("bot"+model.NewId(), "", th.BasicTeam.Id+"x"); err == nil {
+		t.Fatal("shouldn't be able to add a bot to a team the user isn't on")
+	}
+
+	if _, err := Client.LoginById(bot.Id, "passwd"); err == nil || err.Id != "api.user.login.bot.app_error" {
+		t.Fatal("bots shouldn't be able to log in")
+	}
+}
+
+func createTestBot(t *testing.T, Client *model.Client, teamId string) *model.User {
+	bot, err := Client.CreateBot("bot"+model.NewId(), "", teamId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bot
+}
+
+func TestBotUserAccessToken(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableUserAccessTokens := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enableUserAccessTokens
+	}()
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+
+	bot := createTestBot(t, Client, th.BasicTeam.Id)
+
+	token, err := Client.CreateUserAccessToken(bot.Id, "bot token")
+	if err != nil {
+		t.Fatal("owner should be able to create a token for their bot", err)
+	}
+
+	BotClient := th.CreateClient()
+	BotClient.AuthToken = token.Token
+	BotClient.AuthType = model.HEADER_BEARER
+	BotClient.SetTeamId(th.BasicTeam.Id)
+
+	if me, err := BotClient.GetMe(""); err != nil {
+		t.Fatal(err)
+	} else if me.Data.(*model.User).Id != bot.Id {
+		t.Fatal("should've authenticated as the bot")
+	}
+
+	if _, err := BotClient.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "beep"}); err == nil {
+		t.Fatal("bot shouldn't be able to post in a channel it isn't in")
+	}
+
+	th.LoginBasic2()
+	if _, err := Client.CreateUserAccessToken(bot.Id, "stolen"); err == nil {
+		t.Fatal("shouldn't be able to create a token for another user's bot")
+	}
+}
+
+func TestIncomingWebhookAsBot(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableIncomingHooks := utils.Cfg.ServiceSettings.EnableIncomingWebhooks
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableIncomingWebhooks = enableIncomingHooks
+	}()
+	utils.Cfg.ServiceSettings.EnableIncomingWebhooks = true
+
+	bot := createTestBot(t, Client, th.BasicTeam.Id)
+
+	th.LoginBasic2()
+	if _, err := Client.CreateIncomingWebhook(&model.IncomingWebhook{ChannelId: th.BasicChannel.Id, BotUserId: bot.Id}); err == nil {
+		t.Fatal("shouldn't be able to post as another user's bot")
+	}
+
+	th.LoginBasic()
+	if _, err := Client.CreateIncomingWebhook(&model.IncomingWebhook{ChannelId: th.BasicChannel.Id, BotUserId: th.BasicUser2.Id}); err == nil {
+		t.Fatal("should only be able to post as a bot")
+	}
+
+	hook := Client.Must(Client.CreateIncomingWebhook(&model.IncomingWebhook{ChannelId: th.BasicChannel.Id, BotUserId: bot.Id})).Data.(*model.IncomingWebhook)
+
+	if _, err := Client.DoPost("/hooks/"+hook.Id, "{\"text\":\"from the bot\"}", "application/json"); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := Client.Must(Client.GetPosts(th.BasicChannel.Id, 0, 1, "")).Data.(*model.PostList)
+	if post := posts.Posts[posts.Order[0]]; post.Message != "from the bot" || post.UserId != bot.Id {
+		t.Fatal("should've posted as the bot")
+	}
+}
+
+func TestOAuthClientCredentialsAsBot(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableOAuth := utils.Cfg.ServiceSettings.EnableOAuthServiceProvider
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = enableOAuth
+	}()
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+
+	bot := createTestBot(t, Client, th.BasicTeam.Id)
+
+	app := &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", CallbackUrls: []string{"https://nowhere.com"}}
+	app = Client.Must(Client.RegisterApp(app)).Data.(*model.OAuthApp)
+
+	data := url.Values{"grant_type": {model.CLIENT_CREDENTIALS_GRANT_TYPE}, "client_id": {app.Id}, "client_secret": {app.ClientSecret}}
+	if _, err := Client.GetAccessToken(data); err == nil {
+		t.Fatal("should've failed for an app without a bot")
+	}
+
+	app = &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", CallbackUrls: []string{"https://nowhere.com"}, BotUserId: bot.Id}
+	app = Client.Must(Client.RegisterApp(app)).Data.(*model.OAuthApp)
+
+	data = url.Values{"grant_type": {model.CLIENT_CREDENTIALS_GRANT_TYPE}, "client_id": {app.Id}, "client_secret": {"wrong"}}
+	if _, err := Client.GetAccessToken(data); err == nil {
+		t.Fatal("should've failed with the wrong secret")
+	}
+
+	data.Set("client_secret", app.ClientSecret)
+	rsp := Client.Must(Client.GetAccessToken(data)).Data.(*model.AccessResponse)
+
+	BotClient := th.CreateClient()
+	BotClient.AuthToken = rsp.AccessToken
+	BotClient.AuthType = model.HEADER_BEARER
+
+	if me, err := BotClient.GetMe(""); err != nil {
+		t.Fatal(err)
+	} else if me.Data.(*model.User).Id != bot.Id {
+		t.Fatal("should've authenticated as the bot")
+	}
+
+	app.BotUserId = createTestBot(t, Client, th.BasicTeam.Id).Id
+	Client.Must(Client.UpdateOAuthApp(app))
+
+	if _, err := BotClient.GetMe(""); err == nil {
+		t.Fatal("token for the old bot should've been revoked when the app's bot changed")
+	}
+}
@@ -0,0 +1,576 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	BULK_IMPORT_MAX_LINE_SIZE = 1024 * 1024
+)
+
+// BulkImportFileOpener opens an attachment given by its path in a bulk import file
+type BulkImportFileOpener func(path string) (io.ReadCloser, error)
+
+// bulkImporter keeps track of the teams, channels and users that are referred to by name in a bulk import
+type bulkImporter struct {
+	files    BulkImportFileOpener
+	teams    map[string]*model.Team    // by name
+	channels map[string]*model.Channel // by team name and channel name
+	users    map[string]*model.User    // by username
+	direct   map[string]*model.Channel // by the names of the two members
+}
+
+func newBulkImporter(files BulkImportFileOpener) *bulkImporter {
+	return &bulkImporter{
+		files:    files,
+		teams:    map[string]*model.Team{},
+		channels: map[string]*model.Channel{},
+		users:    map[string]*model.User{},
+		direct:   map[string]*model.Channel{},
+	}
+}
+
+// BulkImportFromReader imports either a bulk import file on its own or a zip archive that contains one along with
+// the attachments that it refers to
+func BulkImportFromReader(r io.ReaderAt, size int64, dryRun bool) (*model.AppError, *model.ImportReport) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return BulkImport(io.NewSectionReader(r, 0, size), nil, dryRun)
+	}
+
+	var data *zip.File
+	files := map[string]*zip.File{}
+
+	for _, file := range zipReader.File {
+		if data == nil && strings.HasSuffix(file.Name, ".jsonl") {
+			data = file
+		} else {
+			files[file.Name] = file
+		}
+	}
+
+	if data == nil {
+		return model.NewLocAppError("BulkImportFromReader", "api.bulk_import.missing_file.app_error", nil, ""), nil
+	}
+
+	reader, err := data.Open()
+	if err != nil {
+		return model.NewLocAppError("BulkImportFromReader", "api.bulk_import.open.app_error", map[string]interface{}{"Filename": data.Name}, err.Error()), nil
+	}
+	defer reader.Close()
+
+	return BulkImport(reader, func(path string) (io.ReadCloser, error) {
+		if file := files[filepath.ToSlash(filepath.Clean(path))]; file != nil {
+			return file.Open()
+		}
+
+		return nil, fmt.Errorf("%v isn't in the archive", path)
+	}, dryRun)
+}
+
+// BulkImport reads a bulk import file, checks every line of it and then imports it. Nothing is imported if any line
+// is invalid or if dryRun is set.
+func BulkImport(data io.Reader, files BulkImportFileOpener, dryRun bool) (*model.AppError, *model.ImportReport) {
+	lines, err := BulkImportParse(data)
+	if err != nil {
+		return err, nil
+	}
+
+	return BulkImportLines(lines, files, dryRun)
+}
+
+// BulkImportParse reads each line of a bulk import file
+func BulkImportParse(data io.Reader) ([]*model.BulkImportLine, *model.AppError) {
+	lines := []*model.BulkImportLine{}
+
+	scanner := bufio.NewScanner(data)
+	scanner.Buffer(make([]byte, 64*1024), BULK_IMPORT_MAX_LINE_SIZE)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		text := scanner.Bytes()
+		if len(strings.TrimSpace(string(text))) == 0 {
+			continue
+		}
+
+		var line model.BulkImportLine
+		if err := json.Unmarshal(text, &line); err != nil {
+			return nil, model.NewLocAppError("BulkImportParse", "api.bulk_import.parse.app_error", map[string]interface{}{"Line": lineNumber}, err.Error())
+		}
+
+		lines = append(lines, &line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, model.NewLocAppError("BulkImportParse", "api.bulk_import.parse.app_error", map[string]interface{}{"Line": lineNumber + 1}, err.Error())
+	}
+
+	return lines, nil
+}
+
+// BulkImportLines imports lines that have already been read from a bulk import file or converted from another
+// archive's format
+func BulkImportLines(lines []*model.BulkImportLine, files BulkImportFileOpener, dryRun bool) (*model.AppError, *model.ImportReport) {
+	importer := newBulkImporter(files)
+
+	if err := importer.validate(lines); err != nil {
+		return err, nil
+	}
+
+	report := model.NewImportReport()
+
+	if dryRun {
+		report.Notes = append(report.Notes, utils.T("api.bulk_import.dry_run", map[string]interface{}{"Count": len(lines)}))
+		return nil, report
+	}
+
+	for _, line := range lines {
+		switch line.Type {
+		case model.BULK_IMPORT_TYPE_TEAM:
+			importer.importTeam(line.Team, report)
+		case model.BULK_IMPORT_TYPE_CHANNEL:
+			importer.importChannel(line.Channel, report)
+		case model.BULK_IMPORT_TYPE_USER:
+			importer.importUser(line.User, report)
+		case model.BULK_IMPORT_TYPE_POST:
+			importer.importPost(line.Post, report)
+		case model.BULK_IMPORT_TYPE_DIRECT_POST:
+			importer.importDirectPost(line.DirectPost, report)
+		}
+	}
+
+	return nil, report
+}
+
+// validate checks every line before anything is imported. Lines may only refer to teams, channels and users that
+// already exist or that are added by an earlier line.
+func (bi *bulkImporter) validate(lines []*model.BulkImportLine) *model.AppError {
+	if len(lines) == 0 || lines[0].Type != model.BULK_IMPORT_TYPE_VERSION {
+		return model.NewLocAppError("BulkImport", "api.bulk_import.version.app_error", nil, "")
+	}
+
+	teams := map[string]bool{}
+	channels := map[string]bool{}
+	users := map[string]bool{}
+
+	for i, line := range lines {
+		lineNumber := i + 1
+
+		if err := line.IsValid(); err != nil {
+			return bulkImportLineError(lineNumber, err)
+		}
+
+		if line.Type == model.BULK_IMPORT_TYPE_VERSION && i != 0 {
+			return bulkImportLineError(lineNumber, model.NewLocAppError("BulkImport", "api.bulk_import.version.app_error", nil, ""))
+		}
+
+		var missing string
+		var attachments []*model.BulkImportAttachment
+
+		switch line.Type {
+		case model.BULK_IMPORT_TYPE_TEAM:
+			teams[line.Team.Name] = true
+		case model.BULK_IMPORT_TYPE_CHANNEL:
+			if !teams[line.Channel.Team] && bi.getTeam(line.Channel.Team) == nil {
+				missing = line.Channel.Team
+			}
+			channels[line.Channel.Team+"/"+line.Channel.Name] = true
+		case model.BULK_IMPORT_TYPE_USER:
+			for _, member := range line.User.Teams {
+				if !teams[member.Name] && bi.getTeam(member.Name) == nil {
+					missing = member.Name
+				}
+
+				for _, channelMember := range member.Channels {
+					if !channels[member.Name+"/"+channelMember.Name] && bi.getChannel(member.Name, channelMember.Name) == nil {
+						missing = member.Name + "/" + channelMember.Name
+					}
+				}
+			}
+			users[strings.ToLower(line.User.Username)] = true
+		case model.BULK_IMPORT_TYPE_POST:
+			post := line.Post
+			if !channels[post.Team+"/"+post.Channel] && bi.getChannel(post.Team, post.Channel) == nil {
+				missing = post.Team + "/" + post.Channel
+			}
+
+			usernames := []string{post.User}
+			attachments = append(attachments, post.Attachments...)
+			for _, reply := range post.Replies {
+				usernames = append(usernames, reply.User)
+				attachments = append(attachments, reply.Attachments...)
+			}
+
+			for _, username := range usernames {
+				if !users[strings.ToLower(username)] && bi.getUser(username) == nil {
+					missing = username
+				}
+			}
+		case model.BULK_IMPORT_TYPE_DIRECT_POST:
+			for _, username := range line.DirectPost.Members {
+				if !users[strings.ToLower(username)] && bi.getUser(username) == nil {
+					missing = username
+				}
+			}
+			attachments = line.DirectPost.Attachments
+		}
+
+		if len(missing) > 0 {
+			return bulkImportLineError(lineNumber, model.NewLocAppError("BulkImport", "api.bulk_import.missing.app_error", map[string]interface{}{"Name": missing}, ""))
+		}
+
+		for _, attachment := range attachments {
+			if err := bi.checkAttachment(attachment.Path); err != nil {
+				return bulkImportLineError(lineNumber, model.NewLocAppError("BulkImport", "api.bulk_import.attachment.app_error", map[string]interface{}{"Path": attachment.Path}, err.Error()))
+			}
+		}
+	}
+
+	return nil
+}
+
+func bulkImportLineError(lineNumber int, err *model.AppError) *model.AppError {
+	return model.NewLocAppError("BulkImport", "api.bulk_import.line.app_error", map[string]interface{}{"Line": lineNumber, "Error": err.SystemMessage(utils.T)}, err.DetailedError)
+}
+
+func (bi *bulkImporter) checkAttachment(path string) error {
+	if bi.files == nil {
+		return fmt.Errorf("attachments can only be imported from an archive")
+	}
+
+	reader, err := bi.files(path)
+	if err != nil {
+		return err
+	}
+
+	return reader.Close()
+}
+
+// getTeam returns the team with the given name if it exists
+func (bi *bulkImporter) getTeam(name string) *model.Team {
+	if team, ok := bi.teams[name]; ok {
+		return team
+	}
+
+	if result := <-Srv.Store.Team().GetByName(name); result.Err == nil {
+		bi.teams[name] = result.Data.(*model.Team)
+		return bi.teams[name]
+	}
+
+	return nil
+}
+
+// getChannel returns the channel with the given name if it and its team exist
+func (bi *bulkImporter) getChannel(teamName string, name string) *model.Channel {
+	if channel, ok := bi.channels[teamName+"/"+name]; ok {
+		return channel
+	}
+
+	team := bi.getTeam(teamName)
+	if team == nil {
+		return nil
+	}
+
+	if result := <-Srv.Store.Channel().GetByName(team.Id, name); result.Err == nil {
+		bi.channels[teamName+"/"+name] = result.Data.(*model.Channel)
+		return bi.channels[teamName+"/"+name]
+	}
+
+	return nil
+}
+
+// getUser returns the user with the given username if they exist
+func (bi *bulkImporter) getUser(username string) *model.User {
+	username = strings.ToLower(username)
+
+	if user, ok := bi.users[username]; ok {
+		return user
+	}
+
+	if result := <-Srv.Store.User().GetByUsername(username); result.Err == nil {
+		bi.users[username] = result.Data.(*model.User)
+		return bi.users[username]
+	}
+
+	return nil
+}
+
+func (bi *bulkImporter) importTeam(data *model.BulkImportTeam, report *model.ImportReport) {
+	item := &model.ImportReportItem{Type: model.IMPORT_ITEM_TEAM, Name: data.Name}
+
+	if team := bi.getTeam(data.Name); team != nil {
+		item.Result = model.IMPORT_RESULT_MERGED
+		item.Id = team.Id
+	} else if result := <-Srv.Store.Team().Save(data.ToTeam()); result.Err != nil {
+		l4g.Debug(utils.T("api.bulk_import.import_team.debug"), data.Name, result.Err)
+		item.Result = model.IMPORT_RESULT_FAILED
+		item.Message = result.Err.Error()
+	} else {
+		team := result.Data.(*model.Team)
+		bi.teams[data.Name] = team
+
+		item.Result = model.IMPORT_RESULT_CREATED
+		item.Id = team.Id
+	}
+
+	report.Add(item)
+}
+
+func (bi *bulkImporter) importChannel(data *model.BulkImportChannel, report *model.ImportReport) {
+	item := &model.ImportReportItem{Type: model.IMPORT_ITEM_CHANNEL, Name: data.Team + "/" + data.Name}
+
+	if channel := bi.getChannel(data.Team, data.Name); channel != nil {
+		item.Result = model.IMPORT_RESULT_MERGED
+		item.Id = channel.Id
+	} else if team := bi.getTeam(data.Team); team == nil {
+		item.Result = model.IMPORT_RESULT_FAILED
+		item.Message = utils.T("api.bulk_import.missing.app_error", map[string]interface{}{"Name": data.Team})
+	} else {
+		newChannel := data.ToChannel()
+		newChannel.TeamId = team.Id
+
+		if channel := ImportChannel(newChannel); channel == nil {
+			item.Result = model.IMPORT_RESULT_FAILED
+		} else {
+			bi.channels[data.Team+"/"+data.Name] = channel
+
+			item.Result = model.IMPORT_RESULT_CREATED
+			item.Id = channel.Id
+		}
+	}
+
+	report.Add(item)
+}
+
+func (bi *bulkImporter) importUser(data *model.BulkImportUser, report *model.ImportReport) {
+	item := &model.ImportReportItem{Type: model.IMPORT_ITEM_USER, Name: data.Username}
+
+	user := bi.getUser(data.Username)
+	if user == nil {
+		if result := <-Srv.Store.User().GetByEmail(strings.ToLower(data.Email)); result.Err == nil {
+			user = result.Data.(*model.User)
+			bi.users[strings.ToLower(data.Username)] = user
+		}
+	}
+
+	if user != nil {
+		item.Result = model.IMPORT_RESULT_MERGED
+		item.Id = user.Id
+		item.Message = utils.T("api.bulk_import.import_user.merge", map[string]interface{}{"Username": user.Username})
+	} else {
+		newUser := data.ToUser()
+		newUser.EmailVerified = true
+
+		generated := false
+		if len(newUser.Password) == 0 && newUser.AuthData == nil {
+			newUser.Password = model.NewId()
+			generated = true
+		}
+		password := newUser.Password
+
+		newUser.MakeNonNil()
+
+		if result := <-Srv.Store.User().Save(newUser); result.Err != nil {
+			l4g.Debug(utils.T("api.bulk_import.import_user.debug"), data.Username, result.Err)
+			item.Result = model.IMPORT_RESULT_FAILED
+			item.Message = result.Err.Error()
+			report.Add(item)
+			return
+		} else {
+			user = result.Data.(*model.User)
+			bi.users[user.Username] = user
+		}
+
+		item.Result = model.IMPORT_RESULT_CREATED
+		item.Id = user.Id
+		if generated {
+			item.Message = utils.T("api.bulk_import.import_user.email_pwd", map[string]interface{}{"Email": user.Email, "Password": password})
+		}
+	}
+
+	for _, member := range data.Teams {
+		bi.importTeamMember(user, member, report)
+	}
+
+	if len(data.Preferences) > 0 {
+		preferences := model.Preferences{}
+		for _, preference := range data.Preferences {
+			preferences = append(preferences, model.Preference{UserId: user.Id, Category: preference.Category, Name: preference.Name, Value: preference.Value})
+		}
+
+		if result := <-Srv.Store.Preference().Save(&preferences); result.Err != nil {
+			report.Notes = append(report.Notes, utils.T("api.bulk_import.import_user.preferences", map[string]interface{}{"Username": user.Username}))
+		}
+	}
+
+	report.Add(item)
+}
+
+func (bi *bulkImporter) importTeamMember(user *model.User, member *model.BulkImportTeamMember, report *model.ImportReport) {
+	team := bi.getTeam(member.Name)
+	if team == nil {
+		report.Notes = append(report.Notes, utils.T("api.bulk_import.import_user.join_team", map[string]interface{}{"Username": user.Username, "Team": member.Name}))
+		return
+	}
+
+	if err := JoinUserToTeam(team, user); err != nil {
+		report.Notes = append(report.Notes, utils.T("api.bulk_import.import_user.join_team", map[string]interface{}{"Username": user.Username, "Team": member.Name}))
+		return
+	}
+
+	if len(member.Roles) > 0 {
+		if result := <-Srv.Store.Team().UpdateMember(&model.TeamMember{TeamId: team.Id, UserId: user.Id, Roles: member.Roles}); result.Err != nil {
+			l4g.Debug(utils.T("api.bulk_import.import_user.roles.debug"), user.Username, result.Err)
+		}
+	}
+
+	for _, channelMember := range member.Channels {
+		channel := bi.getChannel(member.Name, channelMember.Name)
+		if channel == nil {
+			report.Notes = append(report.Notes, utils.T("api.bulk_import.import_user.join_channel", map[string]interface{}{"Username": user.Username, "Channel": channelMember.Name}))
+			continue
+		}
+
+		if cm, err := AddUserToChannel(user, channel); err != nil {
+			report.Notes = append(report.Notes, utils.T("api.bulk_import.import_user.join_channel", map[string]interface{}{"Username": user.Username, "Channel": channel.Name}))
+		} else if len(channelMember.Roles) > 0 {
+			cm.Roles = channelMember.Roles
+			if result := <-Srv.Store.Channel().UpdateMember(cm); result.Err != nil {
+				l4g.Debug(utils.T("api.bulk_import.import_user.roles.debug"), user.Username, result.Err)
+			}
+		}
+	}
+}
+
+func (bi *bulkImporter) importPost(data *model.BulkImportPost, report *model.ImportReport) {
+	channel := bi.getChannel(data.Team, data.Channel)
+	if channel == nil {
+		report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_FAILED)
+		return
+	}
+
+	root := bi.savePost(channel, channel.TeamId, data.User, data.Message, data.CreateAt, data.Attachments, nil, report)
+	if root == nil {
+		return
+	}
+
+	for _, reply := range data.Replies {
+		bi.savePost(channel, channel.TeamId, reply.User, reply.Message, reply.CreateAt, reply.Attachments, root, report)
+	}
+}
+
+func (bi *bulkImporter) importDirectPost(data *model.BulkImportDirectPost, report *model.ImportReport) {
+	key := strings.ToLower(data.Members[0]) + "/" + strings.ToLower(data.Members[1])
+
+	channel := bi.direct[key]
+	if channel == nil {
+		user1 := bi.getUser(data.Members[0])
+		user2 := bi.getUser(data.Members[1])
+		if user1 == nil || user2 == nil {
+			report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_FAILED)
+			return
+		}
+
+		var err *model.AppError
+		if channel, err = CreateDirectChannel(user1.Id, user2.Id); err != nil {
+			report.Add(&model.ImportReportItem{Type: model.IMPORT_ITEM_CHANNEL, Name: key, Result: model.IMPORT_RESULT_FAILED, Message: err.Error()})
+			report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_FAILED)
+			return
+		}
+
+		bi.direct[key] = channel
+	}
+
+	// direct channels don't belong to a team, so their files are stored under one of the sender's teams
+	teamId := ""
+	if user := bi.getUser(data.User); user != nil {
+		if result := <-Srv.Store.Team().GetTeamsForUser(user.Id); result.Err == nil {
+			if members := result.Data.([]*model.TeamMember); len(members) > 0 {
+				teamId = members[0].TeamId
+			}
+		}
+	}
+
+	bi.savePost(channel, teamId, data.User, data.Message, data.CreateAt, data.Attachments, nil, report)
+}
+
+func (bi *bulkImporter) savePost(channel *model.Channel, teamId string, username string, message string, createAt int64, attachments []*model.BulkImportAttachment, root *model.Post, report *model.ImportReport) *model.Post {
+	user := bi.getUser(username)
+	if user == nil {
+		report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_FAILED)
+		return nil
+	}
+
+	post := &model.Post{
+		ChannelId: channel.Id,
+		UserId:    user.Id,
+		Message:   message,
+		CreateAt:  createAt,
+	}
+
+	if root != nil {
+		post.RootId = root.Id
+		post.ParentId = root.Id
+	}
+
+	for _, attachment := range attachments {
+		if info := bi.importAttachment(teamId, channel, user, attachment.Path, report); info != nil {
+			post.FileIds = append(post.FileIds, info.Id)
+		}
+	}
+
+	if rpost := ImportPost(post); rpost == nil {
+		report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_FAILED)
+		return nil
+	} else {
+		report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_CREATED)
+		return rpost
+	}
+}
+
+func (bi *bulkImporter) importAttachment(teamId string, channel *model.Channel, user *model.User, path string, report *model.ImportReport) *model.FileInfo {
+	item := &model.ImportReportItem{Type: model.IMPORT_ITEM_FILE, Name: path}
+
+	reader, err := bi.files(path)
+	if err != nil {
+		item.Result = model.IMPORT_RESULT_FAILED
+		item.Message = err.Error()
+		report.Add(item)
+		return nil
+	}
+	defer reader.Close()
+
+	info, job, appErr := uploadFileStream(teamId, channel.Id, user.Id, filepath.Base(path), reader)
+	if appErr != nil {
+		item.Result = model.IMPORT_RESULT_FAILED
+		item.Message = appErr.Error()
+		report.Add(item)
+		return nil
+	}
+
+	if job != nil {
+		handleImages([]*imageJob{job})
+	}
+	handleContentExtraction([]*model.FileInfo{info})
+
+	item.Result = model.IMPORT_RESULT_CREATED
+	item.Id = info.Id
+	report.Add(item)
+
+	return info
+}
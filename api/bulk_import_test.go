@@ -0,0 +1,170 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestBulkImportParse(t *testing.T) {
+	lines, err := BulkImportParse(strings.NewReader(`{"type": "version", "version": 1}
+
+{"type": "team", "team": {"name": "bulk", "display_name": "Bulk", "type": "O"}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(lines) != 2 {
+		t.Fatal("should've skipped the blank line")
+	} else if lines[1].Team == nil || lines[1].Team.Name != "bulk" {
+		t.Fatal("should've read the team")
+	}
+
+	if _, err := BulkImportParse(strings.NewReader("{\"type\": \"version\", \"version\": 1}\n{\"type\": ")); err == nil {
+		t.Fatal("should've failed to read the second line")
+	}
+
+	if err, _ := BulkImport(strings.NewReader(`{"type": "team", "team": {"name": "bulk", "display_name": "Bulk", "type": "O"}}`), nil, true); err == nil {
+		t.Fatal("should've required the version first")
+	}
+}
+
+func TestBulkImport(t *testing.T) {
+	th := Setup().InitBasic()
+
+	teamName := "bulk" + model.NewId()[:10]
+	username := "bulk" + model.NewId()[:10]
+
+	user2 := th.BasicUser2.Username
+
+	data := strings.Join([]string{
+		`{"type": "version", "version": 1}`,
+		`{"type": "team", "team": {"name": "` + teamName + `", "display_name": "Bulk Team", "type": "O"}}`,
+		`{"type": "channel", "channel": {"team": "` + teamName + `", "name": "imported", "display_name": "Imported", "type": "P"}}`,
+		`{"type": "user", "user": {"username": "` + username + `", "email": "success+` + username + `@simulator.amazonses.com", ` +
+			`"teams": [{"name": "` + teamName + `", "roles": "admin", "channels": [{"name": "imported", "roles": "admin"}]}], ` +
+			`"preferences": [{"category": "display_settings", "name": "use_military_time", "value": "true"}]}}`,
+		`{"type": "user", "user": {"username": "` + user2 + `", "email": "` + th.BasicUser2.Email + `", ` +
+			`"teams": [{"name": "` + teamName + `", "channels": [{"name": "imported"}]}]}}`,
+		`{"type": "post", "post": {"team": "` + teamName + `", "channel": "imported", "user": "` + username + `", "message": "root", "create_at": 1475000000000, ` +
+			`"replies": [{"user": "` + user2 + `", "message": "reply", "create_at": 1475000001000}]}}`,
+		`{"type": "direct_post", "direct_post": {"members": ["` + username + `", "` + user2 + `"], "user": "` + username + `", "message": "direct", "create_at": 1475000002000}}`,
+	}, "\n")
+
+	if err, report := BulkImport(strings.NewReader(data), nil, true); err != nil {
+		t.Fatal(err)
+	} else if len(report.Items) != 0 {
+		t.Fatal("dry run shouldn't have imported anything")
+	}
+
+	if result := <-Srv.Store.Team().GetByName(teamName); result.Err == nil {
+		t.Fatal("dry run shouldn't have created the team")
+	}
+
+	invalid := strings.Replace(data, `"create_at": 1475000002000`, `"create_at": 0`, 1)
+	if err, _ := BulkImport(strings.NewReader(invalid), nil, false); err == nil {
+		t.Fatal("should've failed to validate the last line")
+	} else if result := <-Srv.Store.Team().GetByName(teamName); result.Err == nil {
+		t.Fatal("nothing should be imported when a line is invalid")
+	}
+
+	err, report := BulkImport(strings.NewReader(data), nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Counts[model.IMPORT_ITEM_TEAM][model.IMPORT_RESULT_CREATED] != 1 {
+		t.Fatal("should've created the team", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_CHANNEL][model.IMPORT_RESULT_CREATED] != 1 {
+		t.Fatal("should've created the channel", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_USER][model.IMPORT_RESULT_CREATED] != 1 {
+		t.Fatal("should've created the new user", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_USER][model.IMPORT_RESULT_MERGED] != 1 {
+		t.Fatal("should've merged the existing user", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_POST][model.IMPORT_RESULT_CREATED] != 3 {
+		t.Fatal("should've imported the posts", report.ToJson())
+	}
+
+	var team *model.Team
+	if result := <-Srv.Store.Team().GetByName(teamName); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		team = result.Data.(*model.Team)
+	}
+
+	var channel *model.Channel
+	if result := <-Srv.Store.Channel().GetByName(team.Id, "imported"); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		channel = result.Data.(*model.Channel)
+	}
+
+	var user *model.User
+	if result := <-Srv.Store.User().GetByUsername(username); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	if result := <-Srv.Store.Team().GetMember(team.Id, user.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(model.TeamMember).Roles != model.ROLE_TEAM_ADMIN {
+		t.Fatal("should've been made a team admin")
+	}
+
+	if result := <-Srv.Store.Channel().GetMember(channel.Id, user.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(model.ChannelMember).Roles != model.CHANNEL_ROLE_ADMIN {
+		t.Fatal("should've been made a channel admin")
+	}
+
+	if result := <-Srv.Store.Preference().Get(user.Id, model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, "use_military_time"); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-Srv.Store.Post().GetPosts(channel.Id, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		postList := result.Data.(*model.PostList)
+
+		var root, reply *model.Post
+		for _, post := range postList.Posts {
+			if post.Message == "root" {
+				root = post
+			} else if post.Message == "reply" {
+				reply = post
+			}
+		}
+
+		if root == nil || reply == nil {
+			t.Fatal("should've imported the post and its reply")
+		} else if reply.RootId != root.Id || reply.UserId != th.BasicUser2.Id {
+			t.Fatal("reply was imported incorrectly")
+		} else if root.CreateAt != 1475000000000 {
+			t.Fatal("should've kept the time the post was made")
+		}
+	}
+
+	// importing the same file again merges everything that already exists
+	if err, report := BulkImport(strings.NewReader(data), nil, false); err != nil {
+		t.Fatal(err)
+	} else if report.Counts[model.IMPORT_ITEM_TEAM][model.IMPORT_RESULT_MERGED] != 1 || report.Counts[model.IMPORT_ITEM_USER][model.IMPORT_RESULT_MERGED] != 2 {
+		t.Fatal("should've merged with the existing team and users", report.ToJson())
+	}
+
+	attachment := `{"type": "post", "post": {"team": "` + teamName + `", "channel": "imported", "user": "` + username + `", "message": "file", "create_at": 1475000000000, "attachments": [{"path": "test.txt"}]}}`
+	if err, _ := BulkImport(strings.NewReader(`{"type": "version", "version": 1}`+"\n"+attachment), nil, true); err == nil {
+		t.Fatal("attachments can't be found without an archive")
+	}
+
+	if err, _ := BulkImport(strings.NewReader(`{"type": "version", "version": 1}`+"\n"+attachment), func(path string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("data")), nil
+	}, true); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	CLUSTER_MESSAGE_PATH    = "/cluster/message"
+	CLUSTER_SEND_QUEUE_SIZE = 1024
+	CLUSTER_SEND_TIMEOUT    = 10 * time.Second
+	CLUSTER_MAX_BODY_SIZE   = 10 * 1024 * 1024
+
+	HEADER_CLUSTER_SIGNATURE = "X-Cluster-Signature"
+)
+
+// HttpCluster is the built-in cluster transport. Each node listens on
+// ClusterSettings.InterNodeListenAddress and relays websocket events and cache
+// invalidations to every url in ClusterSettings.InterNodeUrls. Every message is
+// signed with ClusterSettings.InterNodeSecret and unsigned ones are refused.
+type HttpCluster struct {
+	hub           *Hub
+	listenAddress string
+	listener      net.Listener
+	peers         []*clusterPeer
+	secret        []byte
+}
+
+type clusterPeer struct {
+	url    string
+	send   chan *model.ClusterMessage
+	stop   chan bool
+	secret []byte
+}
+
+func NewHttpCluster(h *Hub, listenAddress string, peerUrls []string, secret string) *HttpCluster {
+	hc := &HttpCluster{
+		hub:           h,
+		listenAddress: listenAddress,
+		peers:         make([]*clusterPeer, 0, len(peerUrls)),
+		secret:        []byte(secret),
+	}
+
+	for _, url := range peerUrls {
+		hc.peers = append(hc.peers, &clusterPeer{
+			url:    strings.TrimRight(url, "/"),
+			send:   make(chan *model.ClusterMessage, CLUSTER_SEND_QUEUE_SIZE),
+			stop:   make(chan bool),
+			secret: hc.secret,
+		})
+	}
+
+	return hc
+}
+
+func (hc *HttpCluster) StartInterNodeCommunication() {
+	l4g.Info(utils.T("api.cluster.start.listening.info"), hc.listenAddress)
+
+	listener, err := net.Listen("tcp", hc.listenAddress)
+	if err != nil {
+		l4g.Error(utils.T("api.cluster.start.listen.error"), hc.listenAddress, err)
+		return
+	}
+	hc.listener = listener
+
+	router := mux.NewRouter()
+	router.Handle(CLUSTER_MESSAGE_PATH, http.HandlerFunc(hc.receive)).Methods("POST")
+
+	go http.Serve(listener, router)
+
+	for _, peer := range hc.peers {
+		go peer.sendPump()
+	}
+}
+
+func (hc *HttpCluster) StopInterNodeCommunication() {
+	l4g.Info(utils.T("api.cluster.stop.info"))
+
+	if hc.listener != nil {
+		hc.listener.Close()
+		hc.listener = nil
+	}
+
+	for _, peer := range hc.peers {
+		close(peer.stop)
+	}
+}
+
+func (hc *HttpCluster) Publish(message *model.Message) {
+	if message != nil {
+		hc.sendToPeers(&model.ClusterMessage{Event: model.CLUSTER_EVENT_PUBLISH, Data: message.ToJson()})
+	}
+}
+
+func (hc *HttpCluster) InvalidateCacheForUser(userId string) {
+	hc.sendToPeers(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER, Data: userId})
+}
+
+func (hc *HttpCluster) InvalidateCacheForChannel(channelId string) {
+	hc.sendToPeers(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL, Data: channelId})
+}
+
+func (hc *HttpCluster) RemoveUserAccessTokenFromCache(tokenId string) {
+	hc.sendToPeers(&model.ClusterMessage{Event: model.CLUSTER_EVENT_REMOVE_USER_ACCESS_TOKEN_FROM_CACHE, Data: tokenId})
+}
+
+func (hc *HttpCluster) sendToPeers(msg *model.ClusterMessage) {
+	for _, peer := range hc.peers {
+		select {
+		case peer.send <- msg:
+		default:
+			l4g.Warn(utils.T("api.cluster.send.queue_full.warn"), peer.url, msg.Event)
+		}
+	}
+}
+
+// signClusterMessage returns the signature that goes with a message's body
+func signClusterMessage(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (hc *HttpCluster) receive(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, CLUSTER_MAX_BODY_SIZE))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if len(hc.secret) == 0 || !hmac.Equal([]byte(r.Header.Get(HEADER_CLUSTER_SIGNATURE)), []byte(signClusterMessage(hc.secret, body))) {
+		l4g.Warn(utils.T("api.cluster.receive.signature.warn"), r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	msg := model.ClusterMessageFromJson(bytes.NewReader(body))
+	if msg == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch msg.Event {
+	case model.CLUSTER_EVENT_PUBLISH:
+		if message := model.MessageFromJson(strings.NewReader(msg.Data)); message != nil {
+			hc.hub.Broadcast(message)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	case model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER:
+		hc.hub.InvalidateUser(msg.Data)
+	case model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL:
+		hc.hub.InvalidateChannel(msg.Data)
+	case model.CLUSTER_EVENT_REMOVE_USER_ACCESS_TOKEN_FROM_CACHE:
+		removeUserAccessTokenFromLocalCache(msg.Data)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ReturnStatusOK(w)
+}
+
+func (p *clusterPeer) sendPump() {
+	client := &http.Client{Timeout: CLUSTER_SEND_TIMEOUT}
+
+	for {
+		select {
+		case msg := <-p.send:
+			body := []byte(msg.ToJson())
+
+			req, _ := http.NewRequest("POST", p.url+CLUSTER_MESSAGE_PATH, bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(HEADER_CLUSTER_SIGNATURE, signClusterMessage(p.secret, body))
+
+			// Events are sent one at a time so that each node sees them in the order they were published
+			if resp, err := client.Do(req); err != nil {
+				l4g.Error(utils.T("api.cluster.send.error"), p.url, err)
+			} else {
+				if resp.StatusCode != http.StatusOK {
+					l4g.Error(utils.T("api.cluster.send.error"), p.url, resp.Status)
+				}
+				resp.Body.Close()
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
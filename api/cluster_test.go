@@ -0,0 +1,120 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// newTestClusterWebConn registers a connection with permission to the given channels already cached. The hub's
+// goroutine owns the cache once the connection is registered, so the test only reads it again after receiving an
+// event that the hub sent after handling whatever changed it.
+func newTestClusterWebConn(h *Hub, channelIds ...string) *WebConn {
+	wc := &WebConn{
+		Send:                    make(chan *model.Message, 64),
+		UserId:                  model.NewId(),
+		hasPermissionsToChannel: make(map[string]bool),
+		hasPermissionsToTeam:    make(map[string]bool),
+	}
+
+	for _, channelId := range channelIds {
+		wc.hasPermissionsToChannel[channelId] = true
+	}
+
+	h.Register(wc)
+	return wc
+}
+
+func waitForClusterMessage(t *testing.T, wc *WebConn, action string) *model.Message {
+	select {
+	case msg := <-wc.Send:
+		if msg.Action != action {
+			t.Fatalf("expected %v event but got %v", action, msg.Action)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %v event", action)
+	}
+
+	return nil
+}
+
+func TestHttpCluster(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	hub1 := NewWebHub()
+	hub1.Start()
+	hub2 := NewWebHub()
+	hub2.Start()
+
+	secret := model.NewRandomString(32)
+	cluster1 := NewHttpCluster(hub1, "localhost:18075", []string{"http://localhost:18076"}, secret)
+	cluster2 := NewHttpCluster(hub2, "localhost:18076", []string{"http://localhost:18075/"}, secret)
+	cluster1.StartInterNodeCommunication()
+	defer cluster1.StopInterNodeCommunication()
+	cluster2.StartInterNodeCommunication()
+	defer cluster2.StopInterNodeCommunication()
+
+	channelId := model.NewId()
+
+	wc1 := newTestClusterWebConn(hub1)
+	wc2 := newTestClusterWebConn(hub2, channelId)
+
+	message := model.NewMessage("", "", model.NewId(), model.ACTION_POSTED)
+	message.Add("post", "hello")
+	hub1.Broadcast(message)
+	cluster1.Publish(message)
+
+	waitForClusterMessage(t, wc1, model.ACTION_POSTED)
+	if msg := waitForClusterMessage(t, wc2, model.ACTION_POSTED); msg.Props["post"] != "hello" {
+		t.Fatal("props were not relayed to the other node")
+	}
+
+	message = model.NewMessage("", "", model.NewId(), model.ACTION_TYPING)
+	hub2.Broadcast(message)
+	cluster2.Publish(message)
+
+	waitForClusterMessage(t, wc1, model.ACTION_TYPING)
+	waitForClusterMessage(t, wc2, model.ACTION_TYPING)
+
+	cluster1.InvalidateCacheForChannel(channelId)
+
+	// events to a node are delivered in order so the invalidation has been handled once this arrives
+	cluster1.Publish(model.NewMessage("", "", model.NewId(), model.ACTION_POSTED))
+	waitForClusterMessage(t, wc2, model.ACTION_POSTED)
+
+	if _, ok := wc2.hasPermissionsToChannel[channelId]; ok {
+		t.Fatal("channel permissions should have been invalidated on the other node")
+	}
+
+	tokenId := model.NewId()
+	sessionCache.Add(tokenId, &model.Session{Id: tokenId, Props: model.StringMap{model.SESSION_PROP_USER_ACCESS_TOKEN_ID: tokenId}})
+	cluster1.RemoveUserAccessTokenFromCache(tokenId)
+
+	cluster1.Publish(model.NewMessage("", "", model.NewId(), model.ACTION_POSTED))
+	waitForClusterMessage(t, wc2, model.ACTION_POSTED)
+
+	if _, ok := sessionCache.Get(tokenId); ok {
+		t.Fatal("revoked token should have been removed from the session cache by the other node")
+	}
+
+	body := (&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER, Data: model.NewId()}).ToJson()
+	for _, signature := range []string{"", signClusterMessage([]byte(model.NewRandomString(32)), []byte(body))} {
+		req, _ := http.NewRequest("POST", "http://localhost:18076"+CLUSTER_MESSAGE_PATH, strings.NewReader(body))
+		req.Header.Set(HEADER_CLUSTER_SIGNATURE, signature)
+
+		if resp, err := http.DefaultClient.Do(req); err != nil {
+			t.Fatal(err)
+		} else if resp.Body.Close(); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatal("should've refused a message that wasn't signed with the secret", resp.StatusCode)
+		}
+	}
+}
@@ -246,7 +246,13 @@ func handleResponse(c *Context, w http.ResponseWriter, response *model.CommandRe
 
 	if response.ResponseType == model.COMMAND_RESPONSE_TYPE_IN_CHANNEL {
 		post.Message = response.Text
-		if _, err := CreatePost(c, post, true); err != nil {
+
+		postContext := c
+		if len(cmd.BotUserId) > 0 {
+			postContext = newIntegrationContext(c, cmd.BotUserId, c.TeamId)
+		}
+
+		if _, err := CreatePost(postContext, post, true); err != nil {
 			c.Err = model.NewLocAppError("command", "api.command.execute_command.save.app_error", nil, "")
 		}
 	} else if response.ResponseType == model.COMMAND_RESPONSE_TYPE_EPHEMERAL {
@@ -289,6 +295,10 @@ func createCommand(c *Context, w http.ResponseWriter, r *http.Request) {
 	cmd.CreatorId = c.Session.UserId
 	cmd.TeamId = c.TeamId
 
+	if !checkIntegrationBot(c, cmd.BotUserId, "createCommand") {
+		return
+	}
+
 	if result := <-Srv.Store.Command().GetByTeam(c.TeamId); result.Err != nil {
 		c.Err = result.Err
 		return
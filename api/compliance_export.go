@@ -0,0 +1,459 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	COMPLIANCE_DAILY_JOB_NAME     = "Compliance Daily"
+	COMPLIANCE_DAILY_JOB_INTERVAL = 24 * time.Hour
+	COMPLIANCE_DAILY_JOB_LEASE    = COMPLIANCE_DAILY_JOB_INTERVAL * 9 / 10 // how long after the last run that any server can run the job again
+	COMPLIANCE_EXPORT_BATCH_SIZE  = 1000
+	COMPLIANCE_EXPORT_FOLDER      = "compliance/"
+)
+
+// ComplianceFormatter writes a compliance export into an archive in a format that an archiving tool can ingest. The
+// export's conversations are passed to the ComplianceConversationWriter that it returns one at a time so that the
+// whole export never has to be held in memory.
+type ComplianceFormatter interface {
+	NewWriter(writer ExportWriter, export *model.ComplianceExport) (ComplianceConversationWriter, *model.AppError)
+}
+
+type ComplianceConversationWriter interface {
+	Write(conversation *model.ComplianceConversation) *model.AppError
+	// Close finishes writing the export once every conversation has been written
+	Close() *model.AppError
+}
+
+var complianceFormatters = map[string]ComplianceFormatter{
+	model.COMPLIANCE_EXPORT_FORMAT_CSV:         &CsvComplianceFormatter{},
+	model.COMPLIANCE_EXPORT_FORMAT_ACTIANCE:    &ActianceComplianceFormatter{},
+	model.COMPLIANCE_EXPORT_FORMAT_GLOBALRELAY: &GlobalRelayComplianceFormatter{},
+}
+
+// RegisterComplianceFormatter makes a formatter available to be chosen by ComplianceSettings.ExportFormat
+func RegisterComplianceFormatter(name string, formatter ComplianceFormatter) {
+	complianceFormatters[name] = formatter
+}
+
+func GetComplianceFormatter(name string) ComplianceFormatter {
+	return complianceFormatters[name]
+}
+
+// ComplianceExporter is the built in implementation of einterfaces.ComplianceInterface. It's used when no other
+// implementation has been registered.
+type ComplianceExporter struct{}
+
+func NewComplianceExporter() *ComplianceExporter {
+	return &ComplianceExporter{}
+}
+
+func (me *ComplianceExporter) StartComplianceDailyJob() {
+	model.CreateRecurringTask(COMPLIANCE_DAILY_JOB_NAME, me.runDailyJob, COMPLIANCE_DAILY_JOB_INTERVAL)
+}
+
+// runDailyJob runs the daily export unless another server has already run it during the last day
+func (me *ComplianceExporter) runDailyJob() {
+	if !*utils.Cfg.ComplianceSettings.Enable || !*utils.Cfg.ComplianceSettings.EnableDaily {
+		return
+	}
+
+	ranBefore := model.GetMillis() - int64(COMPLIANCE_DAILY_JOB_LEASE/time.Millisecond)
+	if _, err := runLeasedTask(COMPLIANCE_DAILY_JOB_NAME, ranBefore, me.runDailyExport); err != nil {
+		l4g.Error(utils.T("api.compliance_export.daily.error"), err)
+	}
+}
+
+// runDailyExport exports everything since the end of the last daily job, or for the last day if there hasn't been one
+func (me *ComplianceExporter) runDailyExport() {
+	endAt := model.GetMillis()
+	startAt := endAt - int64(COMPLIANCE_DAILY_JOB_INTERVAL/time.Millisecond)
+
+	if result := <-Srv.Store.Compliance().GetAll(); result.Err != nil {
+		l4g.Error(utils.T("api.compliance_export.daily.error"), result.Err)
+		return
+	} else {
+		// the jobs are returned newest first
+		for _, job := range result.Data.(model.Compliances) {
+			if job.Type == model.COMPLIANCE_TYPE_DAILY && job.Status == model.COMPLIANCE_STATUS_FINISHED {
+				startAt = job.EndAt
+				break
+			}
+		}
+	}
+
+	job := &model.Compliance{
+		Desc:    time.Unix(0, endAt*int64(time.Millisecond)).UTC().Format("2006-01-02"),
+		Type:    model.COMPLIANCE_TYPE_DAILY,
+		StartAt: startAt,
+		EndAt:   endAt,
+	}
+
+	if result := <-Srv.Store.Compliance().Save(job); result.Err != nil {
+		l4g.Error(utils.T("api.compliance_export.daily.error"), result.Err)
+		return
+	}
+
+	if err := me.RunComplianceJob(job); err != nil {
+		l4g.Error(utils.T("api.compliance_export.daily.error"), err)
+	}
+}
+
+// RunComplianceJob writes everything that happened during the job's period to a zip in the compliance directory
+// using the formatter chosen by ComplianceSettings.ExportFormat
+func (me *ComplianceExporter) RunComplianceJob(job *model.Compliance) *model.AppError {
+	job.Status = model.COMPLIANCE_STATUS_RUNNING
+	if result := <-Srv.Store.Compliance().Update(job); result.Err != nil {
+		return result.Err
+	}
+
+	count, err := writeComplianceExport(job)
+
+	if err != nil {
+		job.Status = model.COMPLIANCE_STATUS_FAILED
+	} else {
+		job.Status = model.COMPLIANCE_STATUS_FINISHED
+		job.Count = count
+	}
+
+	if result := <-Srv.Store.Compliance().Update(job); result.Err != nil {
+		return result.Err
+	}
+
+	return err
+}
+
+func writeComplianceExport(job *model.Compliance) (int, *model.AppError) {
+	formatter := GetComplianceFormatter(*utils.Cfg.ComplianceSettings.ExportFormat)
+	if formatter == nil {
+		return 0, model.NewLocAppError("writeComplianceExport", "api.compliance_export.format.app_error", nil, "format="+*utils.Cfg.ComplianceSettings.ExportFormat)
+	}
+
+	path := *utils.Cfg.ComplianceSettings.Directory + COMPLIANCE_EXPORT_FOLDER + job.JobName() + ".zip"
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return 0, model.NewLocAppError("writeComplianceExport", "api.compliance_export.write.app_error", nil, err.Error())
+	}
+
+	file, openErr := os.Create(path)
+	if openErr != nil {
+		return 0, model.NewLocAppError("writeComplianceExport", "api.compliance_export.write.app_error", nil, openErr.Error())
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+
+	writer, err := formatter.NewWriter(zipWriter, &model.ComplianceExport{StartAt: job.StartAt, EndAt: job.EndAt})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	if err := BuildComplianceExport(job.StartAt, job.EndAt, job.Keywords, job.Emails, func(conversation *model.ComplianceConversation) *model.AppError {
+		for _, event := range conversation.Events {
+			if event.Type == model.COMPLIANCE_EVENT_MESSAGE {
+				count++
+			}
+		}
+
+		return writer.Write(conversation)
+	}); err != nil {
+		return 0, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return 0, model.NewLocAppError("writeComplianceExport", "api.compliance_export.write.app_error", nil, err.Error())
+	}
+
+	return count, nil
+}
+
+// BuildComplianceExport collects the messages that were posted, edited or deleted and the users that joined or left
+// channels after startAt and at or before endAt. Keywords and emails are comma or space separated, and when given
+// only the messages containing one of the keywords or the events from one of the users are included. The posts are
+// read in batches a channel at a time, and each channel's conversation is passed to write as soon as it's complete.
+func BuildComplianceExport(startAt int64, endAt int64, keywords string, emails string, write func(conversation *model.ComplianceConversation) *model.AppError) *model.AppError {
+	builder := &complianceExportBuilder{
+		startAt:  startAt,
+		endAt:    endAt,
+		keywords: strings.Fields(strings.ToLower(strings.Replace(keywords, ",", " ", -1))),
+		emails:   make(map[string]bool),
+	}
+
+	for _, email := range strings.Fields(strings.ToLower(strings.Replace(emails, ",", " ", -1))) {
+		builder.emails[email] = true
+	}
+
+	history := make(map[string][]*model.ChannelMemberHistoryResult)
+	var historyChannelIds []string
+
+	if result := <-Srv.Store.ChannelMemberHistory().GetEventsDuring(startAt, endAt); result.Err != nil {
+		return result.Err
+	} else {
+		for _, entry := range result.Data.([]*model.ChannelMemberHistoryResult) {
+			if _, ok := history[entry.ChannelId]; !ok {
+				historyChannelIds = append(historyChannelIds, entry.ChannelId)
+			}
+			history[entry.ChannelId] = append(history[entry.ChannelId], entry)
+		}
+	}
+
+	writeChannel := func(channelId string, posts []*model.CompliancePost) *model.AppError {
+		conversation := builder.build(posts, history[channelId])
+		delete(history, channelId)
+
+		if conversation == nil {
+			return nil
+		}
+
+		return write(conversation)
+	}
+
+	var posts []*model.CompliancePost // the posts from the channel that's currently being read
+	afterChannelId := ""
+	afterId := ""
+
+	for {
+		var batch []*model.CompliancePost
+		if result := <-Srv.Store.Compliance().MessageExport(startAt, endAt, afterChannelId, afterId, COMPLIANCE_EXPORT_BATCH_SIZE); result.Err != nil {
+			return result.Err
+		} else {
+			batch = result.Data.([]*model.CompliancePost)
+		}
+
+		for _, post := range batch {
+			if len(posts) > 0 && post.ChannelId != posts[0].ChannelId {
+				if err := writeChannel(posts[0].ChannelId, posts); err != nil {
+					return err
+				}
+				posts = nil
+			}
+
+			posts = append(posts, post)
+		}
+
+		if len(batch) < COMPLIANCE_EXPORT_BATCH_SIZE {
+			break
+		}
+
+		afterChannelId = batch[len(batch)-1].ChannelId
+		afterId = batch[len(batch)-1].PostId
+	}
+
+	if len(posts) > 0 {
+		if err := writeChannel(posts[0].ChannelId, posts); err != nil {
+			return err
+		}
+	}
+
+	// channels that users joined or left without anything being posted
+	for _, channelId := range historyChannelIds {
+		if _, ok := history[channelId]; ok {
+			if err := writeChannel(channelId, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type complianceExportBuilder struct {
+	startAt      int64
+	endAt        int64
+	keywords     []string
+	emails       map[string]bool
+	conversation *model.ComplianceConversation // the conversation of the channel being built
+}
+
+// build returns the conversation made up of a channel's posts and member history, or nil if none of it is exported
+func (b *complianceExportBuilder) build(posts []*model.CompliancePost, history []*model.ChannelMemberHistoryResult) *model.ComplianceConversation {
+	b.conversation = nil
+
+	b.addPosts(posts)
+	b.addMemberHistory(history)
+
+	if b.conversation != nil {
+		sort.Stable(complianceEventsByTime(b.conversation.Events))
+	}
+
+	return b.conversation
+}
+
+func (b *complianceExportBuilder) inRange(time int64) bool {
+	return time > b.startAt && time <= b.endAt
+}
+
+func (b *complianceExportBuilder) add(conversation *model.ComplianceConversation, event *model.ComplianceEvent) {
+	if len(b.emails) > 0 && !b.emails[strings.ToLower(event.UserEmail)] {
+		return
+	}
+
+	if len(b.keywords) > 0 {
+		text := strings.ToLower(event.PostMessage + " " + event.PostPreviousMessage)
+
+		found := false
+		for _, keyword := range b.keywords {
+			if strings.Contains(text, keyword) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return
+		}
+	}
+
+	if b.conversation == nil {
+		b.conversation = conversation
+	}
+
+	b.conversation.Events = append(b.conversation.Events, event)
+}
+
+// addPosts turns the versions of each post into events. The earlier versions of an edited post are deleted copies of
+// it with their OriginalId set to the post's id and their DeleteAt set to when they were replaced.
+func (b *complianceExportBuilder) addPosts(posts []*model.CompliancePost) {
+	versions := make(map[string][]*model.CompliancePost)
+	var ids []string
+
+	for _, post := range posts {
+		id := post.PostId
+		if post.PostOriginalId != "" {
+			id = post.PostOriginalId
+		}
+
+		if _, ok := versions[id]; !ok {
+			ids = append(ids, id)
+		}
+		versions[id] = append(versions[id], post)
+	}
+
+	for _, id := range ids {
+		postVersions := versions[id]
+		sort.Stable(compliancePostVersions(postVersions))
+
+		first := postVersions[0]
+		conversation := &model.ComplianceConversation{
+			TeamName:           first.TeamName,
+			TeamDisplayName:    first.TeamDisplayName,
+			ChannelId:          first.ChannelId,
+			ChannelName:        first.ChannelName,
+			ChannelDisplayName: first.ChannelDisplayName,
+			ChannelType:        first.ChannelType,
+		}
+
+		if b.inRange(first.PostCreateAt) {
+			b.add(conversation, newCompliancePostEvent(model.COMPLIANCE_EVENT_MESSAGE, first.PostCreateAt, id, first))
+		}
+
+		for i, version := range postVersions {
+			if version.PostOriginalId != "" && b.inRange(version.PostDeleteAt) && i+1 < len(postVersions) {
+				event := newCompliancePostEvent(model.COMPLIANCE_EVENT_EDITED, version.PostDeleteAt, id, postVersions[i+1])
+				event.PostPreviousMessage = version.PostMessage
+				b.add(conversation, event)
+			} else if version.PostOriginalId == "" && version.PostDeleteAt != 0 && b.inRange(version.PostDeleteAt) {
+				b.add(conversation, newCompliancePostEvent(model.COMPLIANCE_EVENT_DELETED, version.PostDeleteAt, id, version))
+			}
+		}
+	}
+}
+
+func (b *complianceExportBuilder) addMemberHistory(history []*model.ChannelMemberHistoryResult) {
+	for _, entry := range history {
+		conversation := &model.ComplianceConversation{
+			TeamName:           entry.TeamName,
+			TeamDisplayName:    entry.TeamDisplayName,
+			ChannelId:          entry.ChannelId,
+			ChannelName:        entry.ChannelName,
+			ChannelDisplayName: entry.ChannelDisplayName,
+			ChannelType:        entry.ChannelType,
+		}
+
+		for _, event := range []*model.ComplianceEvent{
+			{Type: model.COMPLIANCE_EVENT_JOIN, Time: entry.JoinTime},
+			{Type: model.COMPLIANCE_EVENT_LEAVE, Time: entry.LeaveTime},
+		} {
+			if !b.inRange(event.Time) || len(b.keywords) > 0 {
+				continue
+			}
+
+			event.UserId = entry.UserId
+			event.UserUsername = entry.UserUsername
+			event.UserEmail = entry.UserEmail
+			event.UserNickname = entry.UserNickname
+
+			b.add(conversation, event)
+		}
+	}
+}
+
+func newCompliancePostEvent(eventType string, time int64, postId string, post *model.CompliancePost) *model.ComplianceEvent {
+	return &model.ComplianceEvent{
+		Type:         eventType,
+		Time:         time,
+		UserId:       post.UserId,
+		UserUsername: post.UserUsername,
+		UserEmail:    post.UserEmail,
+		UserNickname: post.UserNickname,
+		PostId:       postId,
+		PostRootId:   post.PostRootId,
+		PostMessage:  post.PostMessage,
+		PostType:     post.PostType,
+		PostFiles:    getCompliancePostFiles(post),
+	}
+}
+
+// getCompliancePostFiles returns the names of the files attached to a post, or their ids when they've been deleted
+func getCompliancePostFiles(post *model.CompliancePost) []string {
+	var files []string
+
+	for _, filename := range model.ArrayFromJson(strings.NewReader(post.PostFilenames)) {
+		files = append(files, filepath.Base(filename))
+	}
+
+	for _, fileId := range model.ArrayFromJson(strings.NewReader(post.PostFileIds)) {
+		if result := <-Srv.Store.FileInfo().Get(fileId); result.Err != nil {
+			files = append(files, fileId)
+		} else {
+			files = append(files, result.Data.(*model.FileInfo).Filename)
+		}
+	}
+
+	return files
+}
+
+// compliancePostVersions sorts the versions of a post from the first to the current one
+type compliancePostVersions []*model.CompliancePost
+
+func (s compliancePostVersions) Len() int      { return len(s) }
+func (s compliancePostVersions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s compliancePostVersions) Less(i, j int) bool {
+	if (s[i].PostOriginalId == "") != (s[j].PostOriginalId == "") {
+		return s[j].PostOriginalId == ""
+	}
+
+	return s[i].PostDeleteAt < s[j].PostDeleteAt
+}
+
+type complianceEventsByTime []*model.ComplianceEvent
+
+func (s complianceEventsByTime) Len() int           { return len(s) }
+func (s complianceEventsByTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s complianceEventsByTime) Less(i, j int) bool { return s[i].Time < s[j].Time }
@@ -0,0 +1,263 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func testComplianceConversations() []*model.ComplianceConversation {
+	return []*model.ComplianceConversation{
+		{
+			TeamName:           "team",
+			TeamDisplayName:    "Team",
+			ChannelId:          "channelid",
+			ChannelName:        "channel",
+			ChannelDisplayName: "Channel",
+			ChannelType:        model.CHANNEL_OPEN,
+			Events: []*model.ComplianceEvent{
+				{Type: model.COMPLIANCE_EVENT_JOIN, Time: 2000, UserId: "user1", UserUsername: "user1", UserEmail: "user1@example.com"},
+				{Type: model.COMPLIANCE_EVENT_MESSAGE, Time: 3000, UserId: "user1", UserUsername: "user1", UserEmail: "user1@example.com", PostId: "post1", PostMessage: "first", PostFiles: []string{"report.pdf"}},
+				{Type: model.COMPLIANCE_EVENT_EDITED, Time: 4000, UserId: "user1", UserUsername: "user1", UserEmail: "user1@example.com", PostId: "post1", PostMessage: "second", PostPreviousMessage: "first"},
+				{Type: model.COMPLIANCE_EVENT_LEAVE, Time: 5000, UserId: "user1", UserUsername: "user1", UserEmail: "user1@example.com"},
+			},
+		},
+		{
+			TeamName:           "team",
+			TeamDisplayName:    "Team",
+			ChannelId:          "otherchannelid",
+			ChannelName:        "other",
+			ChannelDisplayName: "Other",
+			ChannelType:        model.CHANNEL_OPEN,
+			Events: []*model.ComplianceEvent{
+				{Type: model.COMPLIANCE_EVENT_MESSAGE, Time: 6000, UserId: "user1", UserUsername: "user1", UserEmail: "user1@example.com", PostId: "post2", PostMessage: "third"},
+			},
+		},
+	}
+}
+
+func formatComplianceExport(t *testing.T, format string) map[string]string {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+
+	writer, appErr := GetComplianceFormatter(format).NewWriter(zipWriter, &model.ComplianceExport{StartAt: 1000, EndAt: 100000})
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	for _, conversation := range testComplianceConversations() {
+		if err := writer.Write(conversation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipWriter.Close()
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	for _, file := range zipReader.File {
+		reader, _ := file.Open()
+		data, _ := ioutil.ReadAll(reader)
+		reader.Close()
+
+		files[file.Name] = string(data)
+	}
+
+	return files
+}
+
+func TestComplianceFormatters(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	if GetComplianceFormatter("unknown") != nil {
+		t.Fatal("shouldn't have found a formatter")
+	}
+
+	files := formatComplianceExport(t, model.COMPLIANCE_EXPORT_FORMAT_CSV)
+	if rows, err := csv.NewReader(strings.NewReader(files[COMPLIANCE_CSV_FILE])).ReadAll(); err != nil {
+		t.Fatal(err)
+	} else if len(rows) != 6 {
+		t.Fatal("should've written a header and a row for each event", len(rows))
+	} else if rows[3][1] != model.COMPLIANCE_EVENT_EDITED || rows[3][15] != "second" || rows[3][16] != "first" {
+		t.Fatal("should've written the edit", rows[3])
+	} else if rows[2][17] != "report.pdf" {
+		t.Fatal("should've written the files", rows[2])
+	}
+
+	files = formatComplianceExport(t, model.COMPLIANCE_EXPORT_FORMAT_ACTIANCE)
+	var dump struct {
+		Conversations []struct {
+			Entered  []string `xml:"ParticipantEntered>LoginName"`
+			Messages []string `xml:"Message>Content"`
+			Left     []string `xml:"ParticipantLeft>LoginName"`
+		} `xml:"Conversation"`
+	}
+	if err := xml.Unmarshal([]byte(files[COMPLIANCE_ACTIANCE_FILE]), &dump); err != nil {
+		t.Fatal(err)
+	} else if len(dump.Conversations) != 2 {
+		t.Fatal("should've written each conversation", len(dump.Conversations))
+	} else if conversation := dump.Conversations[0]; len(conversation.Entered) != 1 || len(conversation.Left) != 1 {
+		t.Fatal("should've written the participants entering and leaving")
+	} else if len(conversation.Messages) != 2 || conversation.Messages[1] != "second" {
+		t.Fatal("should've written the messages", conversation.Messages)
+	}
+
+	files = formatComplianceExport(t, model.COMPLIANCE_EXPORT_FORMAT_GLOBALRELAY)
+	if email, ok := files["channelid"+COMPLIANCE_EML_EXTENSION]; !ok {
+		t.Fatal("should've written an email for the conversation")
+	} else if !strings.Contains(email, "To: \"user1\" <user1@example.com>\r\n") {
+		t.Fatal("should've addressed the email to the participants", email)
+	} else if !strings.Contains(email, "X-GlobalRelay-MsgType: Mattermost\r\n") {
+		t.Fatal("should've included the GlobalRelay headers", email)
+	} else if !strings.Contains(email, "\r\n\r\n") || !strings.Contains(email, "report.pdf") {
+		t.Fatal("should've included the transcript", email)
+	} else if _, ok := files["otherchannelid"+COMPLIANCE_EML_EXTENSION]; !ok {
+		t.Fatal("should've written an email for each conversation")
+	}
+}
+
+func buildTestComplianceExport(startAt int64, endAt int64, keywords string, emails string) ([]*model.ComplianceConversation, *model.AppError) {
+	var conversations []*model.ComplianceConversation
+	seen := make(map[string]bool)
+
+	err := BuildComplianceExport(startAt, endAt, keywords, emails, func(conversation *model.ComplianceConversation) *model.AppError {
+		if seen[conversation.ChannelId] {
+			return model.NewLocAppError("buildTestComplianceExport", "", nil, "channel was written twice, channel_id="+conversation.ChannelId)
+		}
+		seen[conversation.ChannelId] = true
+
+		conversations = append(conversations, conversation)
+		return nil
+	})
+
+	return conversations, err
+}
+
+func TestBuildComplianceExport(t *testing.T) {
+	th := Setup().InitBasic()
+
+	startAt := model.GetMillis()
+	time.Sleep(2 * time.Millisecond)
+
+	channel := th.CreateChannel(th.BasicClient, th.BasicTeam)
+
+	post := store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: th.BasicUser.Id, Message: "original keyword"})).(*model.Post)
+	time.Sleep(2 * time.Millisecond)
+
+	old := *post
+	store.Must(Srv.Store.Post().Update(&old, "edited keyword", ""))
+	time.Sleep(2 * time.Millisecond)
+
+	store.Must(Srv.Store.Post().Delete(post.Id, model.GetMillis()))
+	time.Sleep(2 * time.Millisecond)
+
+	endAt := model.GetMillis()
+
+	conversations, err := buildTestComplianceExport(startAt, endAt, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var conversation *model.ComplianceConversation
+	for _, c := range conversations {
+		if c.ChannelId == channel.Id {
+			conversation = c
+		}
+	}
+
+	if conversation == nil {
+		t.Fatal("should've exported the channel")
+	}
+
+	var types []string
+	for _, event := range conversation.Events {
+		types = append(types, event.Type)
+	}
+
+	if strings.Join(types, ",") != "join,message,edited,deleted" {
+		t.Fatal("wrong events", types)
+	}
+
+	if conversation.Events[1].PostMessage != "original keyword" {
+		t.Fatal("should've exported the message as it was posted", conversation.Events[1].PostMessage)
+	} else if conversation.Events[2].PostMessage != "edited keyword" || conversation.Events[2].PostPreviousMessage != "original keyword" {
+		t.Fatal("should've exported the edit")
+	}
+
+	if conversations, err := buildTestComplianceExport(startAt, endAt, "keyword", th.BasicUser.Email); err != nil {
+		t.Fatal(err)
+	} else {
+		for _, c := range conversations {
+			if c.ChannelId == channel.Id && len(c.Events) != 3 {
+				t.Fatal("should've only exported the messages with the keyword", len(c.Events))
+			}
+		}
+	}
+
+	if conversations, err := buildTestComplianceExport(startAt, endAt, "", th.BasicUser2.Email); err != nil {
+		t.Fatal(err)
+	} else {
+		for _, c := range conversations {
+			if c.ChannelId == channel.Id {
+				t.Fatal("shouldn't have exported events from other users")
+			}
+		}
+	}
+}
+
+func TestRunComplianceJob(t *testing.T) {
+	th := Setup().InitBasic()
+
+	directory := *utils.Cfg.ComplianceSettings.Directory
+	format := *utils.Cfg.ComplianceSettings.ExportFormat
+	defer func() {
+		*utils.Cfg.ComplianceSettings.Directory = directory
+		*utils.Cfg.ComplianceSettings.ExportFormat = format
+	}()
+
+	dir, _ := ioutil.TempDir("", "compliance")
+	defer os.RemoveAll(dir)
+	*utils.Cfg.ComplianceSettings.Directory = dir + "/"
+	*utils.Cfg.ComplianceSettings.ExportFormat = model.COMPLIANCE_EXPORT_FORMAT_ACTIANCE
+
+	job := &model.Compliance{Desc: "test", UserId: th.BasicUser.Id, Type: model.COMPLIANCE_TYPE_ADHOC, StartAt: th.BasicPost.CreateAt - 1, EndAt: model.GetMillis()}
+	store.Must(Srv.Store.Compliance().Save(job))
+
+	if err := NewComplianceExporter().RunComplianceJob(job); err != nil {
+		t.Fatal(err)
+	} else if job.Status != model.COMPLIANCE_STATUS_FINISHED || job.Count == 0 {
+		t.Fatal("should've finished the job", job.Status, job.Count)
+	}
+
+	if _, err := zip.OpenReader(dir + "/" + COMPLIANCE_EXPORT_FOLDER + job.JobName() + ".zip"); err != nil {
+		t.Fatal("should've written the export", err)
+	}
+
+	*utils.Cfg.ComplianceSettings.ExportFormat = "unknown"
+	if err := NewComplianceExporter().RunComplianceJob(job); err == nil {
+		t.Fatal("should've failed with an unknown format")
+	} else if job.Status != model.COMPLIANCE_STATUS_FAILED {
+		t.Fatal("should've marked the job as failed")
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	COMPLIANCE_CSV_FILE      = "compliance.csv"
+	COMPLIANCE_ACTIANCE_FILE = "actiance_export.xml"
+	COMPLIANCE_EML_EXTENSION = ".eml"
+)
+
+func complianceTime(millis int64) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond)).UTC()
+}
+
+func complianceWriteError(err error) *model.AppError {
+	return model.NewLocAppError("ComplianceFormatter.Format", "api.compliance_export.write.app_error", nil, err.Error())
+}
+
+// CsvComplianceFormatter writes every event in the export as a row of a single CSV file
+type CsvComplianceFormatter struct{}
+
+type csvComplianceWriter struct {
+	csvWriter *csv.Writer
+}
+
+func (f *CsvComplianceFormatter) NewWriter(writer ExportWriter, export *model.ComplianceExport) (ComplianceConversationWriter, *model.AppError) {
+	file, err := writer.Create(COMPLIANCE_CSV_FILE)
+	if err != nil {
+		return nil, complianceWriteError(err)
+	}
+
+	csvWriter := csv.NewWriter(file)
+	csvWriter.Write([]string{
+		"Time",
+		"Event",
+		"TeamName",
+		"TeamDisplayName",
+		"ChannelId",
+		"ChannelName",
+		"ChannelDisplayName",
+		"ChannelType",
+		"UserId",
+		"UserUsername",
+		"UserEmail",
+		"UserNickname",
+		"PostId",
+		"PostRootId",
+		"PostType",
+		"PostMessage",
+		"PostPreviousMessage",
+		"PostFiles",
+	})
+
+	return &csvComplianceWriter{csvWriter: csvWriter}, nil
+}
+
+func (w *csvComplianceWriter) Write(conversation *model.ComplianceConversation) *model.AppError {
+	for _, event := range conversation.Events {
+		w.csvWriter.Write([]string{
+			complianceTime(event.Time).Format(time.RFC3339),
+			event.Type,
+			conversation.TeamName,
+			conversation.TeamDisplayName,
+			conversation.ChannelId,
+			conversation.ChannelName,
+			conversation.ChannelDisplayName,
+			conversation.ChannelType,
+			event.UserId,
+			event.UserUsername,
+			event.UserEmail,
+			event.UserNickname,
+			event.PostId,
+			event.PostRootId,
+			event.PostType,
+			event.PostMessage,
+			event.PostPreviousMessage,
+			strings.Join(event.PostFiles, ","),
+		})
+	}
+
+	w.csvWriter.Flush()
+	if err := w.csvWriter.Error(); err != nil {
+		return complianceWriteError(err)
+	}
+
+	return nil
+}
+
+func (w *csvComplianceWriter) Close() *model.AppError {
+	w.csvWriter.Flush()
+	if err := w.csvWriter.Error(); err != nil {
+		return complianceWriteError(err)
+	}
+
+	return nil
+}
+
+// ActianceComplianceFormatter writes the export as a single Actiance XML file with a conversation for each channel
+type ActianceComplianceFormatter struct{}
+
+type actianceConversation struct {
+	Perspective  string        `xml:"Perspective,attr"`
+	RoomId       string        `xml:"RoomID"`
+	StartTimeUTC int64         `xml:"StartTimeUTC"`
+	Events       []interface{} // actianceParticipantEvents and actianceMessages in the order they happened
+	EndTimeUTC   int64         `xml:"EndTimeUTC"`
+}
+
+type actianceParticipantEvent struct {
+	XMLName          xml.Name
+	LoginName        string `xml:"LoginName"`
+	UserType         string `xml:"UserType"`
+	DateTimeUTC      int64  `xml:"DateTimeUTC"`
+	CorporateEmailId string `xml:"CorporateEmailID"`
+}
+
+type actianceMessage struct {
+	XMLName          xml.Name `xml:"Message"`
+	LoginName        string   `xml:"LoginName"`
+	UserType         string   `xml:"UserType"`
+	DateTimeUTC      int64    `xml:"DateTimeUTC"`
+	CorporateEmailId string   `xml:"CorporateEmailID"`
+	Content          string   `xml:"Content"`
+	PreviousContent  string   `xml:"PreviousContent,omitempty"`
+	Action           string   `xml:"Action,omitempty"`
+	Files            []string `xml:"File,omitempty"`
+}
+
+// actianceComplianceWriter writes the conversations inside of the FileDump element one at a time
+type actianceComplianceWriter struct {
+	encoder *xml.Encoder
+}
+
+var actianceFileDump = xml.StartElement{Name: xml.Name{Local: "FileDump"}}
+
+func (f *ActianceComplianceFormatter) NewWriter(writer ExportWriter, export *model.ComplianceExport) (ComplianceConversationWriter, *model.AppError) {
+	file, err := writer.Create(COMPLIANCE_ACTIANCE_FILE)
+	if err != nil {
+		return nil, complianceWriteError(err)
+	}
+
+	if _, err := io.WriteString(file, xml.Header); err != nil {
+		return nil, complianceWriteError(err)
+	}
+
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.EncodeToken(actianceFileDump); err != nil {
+		return nil, complianceWriteError(err)
+	}
+
+	return &actianceComplianceWriter{encoder: encoder}, nil
+}
+
+func (w *actianceComplianceWriter) Write(conversation *model.ComplianceConversation) *model.AppError {
+	actianceConv := &actianceConversation{
+		Perspective:  conversation.ChannelDisplayName,
+		RoomId:       conversation.ChannelType + " - " + conversation.ChannelId,
+		StartTimeUTC: conversation.StartTime() / 1000,
+		EndTimeUTC:   conversation.EndTime() / 1000,
+	}
+
+	for _, event := range conversation.Events {
+		switch event.Type {
+		case model.COMPLIANCE_EVENT_JOIN, model.COMPLIANCE_EVENT_LEAVE:
+			name := "ParticipantEntered"
+			if event.Type == model.COMPLIANCE_EVENT_LEAVE {
+				name = "ParticipantLeft"
+			}
+
+			actianceConv.Events = append(actianceConv.Events, &actianceParticipantEvent{
+				XMLName:          xml.Name{Local: name},
+				LoginName:        event.UserUsername,
+				UserType:         "user",
+				DateTimeUTC:      event.Time / 1000,
+				CorporateEmailId: event.UserEmail,
+			})
+		default:
+			message := &actianceMessage{
+				LoginName:        event.UserUsername,
+				UserType:         "user",
+				DateTimeUTC:      event.Time / 1000,
+				CorporateEmailId: event.UserEmail,
+				Content:          event.PostMessage,
+				PreviousContent:  event.PostPreviousMessage,
+				Files:            event.PostFiles,
+			}
+
+			if event.Type != model.COMPLIANCE_EVENT_MESSAGE {
+				message.Action = event.Type
+			}
+
+			actianceConv.Events = append(actianceConv.Events, message)
+		}
+	}
+
+	if err := w.encoder.EncodeElement(actianceConv, xml.StartElement{Name: xml.Name{Local: "Conversation"}}); err != nil {
+		return complianceWriteError(err)
+	}
+
+	return nil
+}
+
+func (w *actianceComplianceWriter) Close() *model.AppError {
+	if err := w.encoder.EncodeToken(actianceFileDump.End()); err != nil {
+		return complianceWriteError(err)
+	}
+
+	if err := w.encoder.Flush(); err != nil {
+		return complianceWriteError(err)
+	}
+
+	return nil
+}
+
+// GlobalRelayComplianceFormatter writes each conversation as a separate RFC 5322 email containing its transcript
+type GlobalRelayComplianceFormatter struct{}
+
+type globalRelayComplianceWriter struct {
+	writer ExportWriter
+	export *model.ComplianceExport
+}
+
+func (f *GlobalRelayComplianceFormatter) NewWriter(writer ExportWriter, export *model.ComplianceExport) (ComplianceConversationWriter, *model.AppError) {
+	return &globalRelayComplianceWriter{writer: writer, export: export}, nil
+}
+
+func (w *globalRelayComplianceWriter) Write(conversation *model.ComplianceConversation) *model.AppError {
+	file, err := w.writer.Create(conversation.ChannelId + COMPLIANCE_EML_EXTENSION)
+	if err != nil {
+		return complianceWriteError(err)
+	}
+
+	if err := writeGlobalRelayEmail(file, w.export, conversation); err != nil {
+		return complianceWriteError(err)
+	}
+
+	return nil
+}
+
+func (w *globalRelayComplianceWriter) Close() *model.AppError {
+	return nil
+}
+
+func complianceAddress(name string, email string) string {
+	return (&mail.Address{Name: name, Address: email}).String()
+}
+
+func writeGlobalRelayEmail(w io.Writer, export *model.ComplianceExport, conversation *model.ComplianceConversation) error {
+	participants := conversation.Participants()
+
+	var to []string
+	for _, participant := range participants {
+		to = append(to, complianceAddress(participant.UserUsername, participant.UserEmail))
+	}
+
+	from := utils.Cfg.EmailSettings.FeedbackEmail
+	if len(participants) > 0 {
+		from = participants[0].UserEmail
+	}
+
+	subject := utils.T("api.compliance_export.globalrelay.subject", map[string]interface{}{
+		"ChannelName": conversation.ChannelDisplayName,
+		"TeamName":    conversation.TeamDisplayName,
+		"Count":       len(conversation.Events),
+	})
+
+	headers := []string{
+		"From: " + complianceAddress(utils.Cfg.EmailSettings.FeedbackName, from),
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject),
+		"Date: " + complianceTime(conversation.EndTime()).Format(time.RFC1123Z),
+		"Message-ID: <" + conversation.ChannelId + "." + strconv.FormatInt(export.EndAt, 10) + "@mattermost>",
+		"MIME-Version: 1.0",
+		"X-Mattermost-ChannelType: " + conversation.ChannelType,
+		"X-GlobalRelay-MsgType: Mattermost",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: quoted-printable",
+	}
+
+	if _, err := io.WriteString(w, strings.Join(headers, "\r\n")+"\r\n\r\n"); err != nil {
+		return err
+	}
+
+	body := quotedprintable.NewWriter(w)
+
+	for _, event := range conversation.Events {
+		line := utils.T("api.compliance_export.globalrelay."+event.Type, map[string]interface{}{
+			"Time":            complianceTime(event.Time).Format(time.RFC3339),
+			"Username":        event.UserUsername,
+			"Email":           event.UserEmail,
+			"Message":         event.PostMessage,
+			"PreviousMessage": event.PostPreviousMessage,
+		})
+
+		if len(event.PostFiles) > 0 {
+			line += " " + utils.T("api.compliance_export.globalrelay.files", map[string]interface{}{
+				"Files": strings.Join(event.PostFiles, ", "),
+			})
+		}
+
+		if _, err := fmt.Fprint(body, line+"\r\n"); err != nil {
+			return err
+		}
+	}
+
+	return body.Close()
+}
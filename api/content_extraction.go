@@ -0,0 +1,96 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	CONTENT_JOB_QUEUE_SIZE           = 1000
+	CONTENT_EXTRACTION_MAX_FILE_SIZE = 50 * 1024 * 1024
+)
+
+var contentJobQueue chan *model.FileInfo
+var contentWorkersStop chan bool
+
+// StartContentWorkers starts the pool of workers that extract the text from uploaded documents so that they can
+// be found by searching
+func StartContentWorkers() {
+	if contentJobQueue != nil || !*utils.Cfg.FileSettings.EnableContentExtraction {
+		return
+	}
+
+	queue := make(chan *model.FileInfo, CONTENT_JOB_QUEUE_SIZE)
+	stop := make(chan bool)
+	contentJobQueue = queue
+	contentWorkersStop = stop
+
+	for i := 0; i < *utils.Cfg.FileSettings.MaxContentWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case info := <-queue:
+					extractContent(info)
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+}
+
+func StopContentWorkers() {
+	if contentWorkersStop != nil {
+		close(contentWorkersStop)
+		contentWorkersStop = nil
+		contentJobQueue = nil
+	}
+}
+
+// handleContentExtraction queues the text to be extracted from each uploaded file that it can be read from.
+// Unlike images, files are skipped instead of making the upload wait when the workers fall behind.
+func handleContentExtraction(infos []*model.FileInfo) {
+	queue := contentJobQueue
+	if queue == nil {
+		return
+	}
+
+	for _, info := range infos {
+		if !utils.CanExtractText(info.Filename) || info.Size > CONTENT_EXTRACTION_MAX_FILE_SIZE {
+			continue
+		}
+
+		select {
+		case queue <- info:
+		default:
+			l4g.Warn(utils.T("api.file.extract_content.queue_full.warn"), info.Id)
+		}
+	}
+}
+
+// extractContent reads an uploaded file back from storage and saves the text in it alongside the file's info
+func extractContent(info *model.FileInfo) {
+	data, err := ReadFile(info.Path)
+	if err != nil {
+		l4g.Error(utils.T("api.file.extract_content.read.error"), info.Id, err)
+		return
+	}
+
+	text, extractErr := utils.ExtractText(info.Filename, data, *utils.Cfg.FileSettings.MaxContentExtractionSize)
+	if extractErr != nil {
+		l4g.Warn(utils.T("api.file.extract_content.extract.warn"), info.Id, extractErr)
+		return
+	}
+
+	// the info is still shared with the upload that queued it, so it's left alone
+	extracted := &model.FileInfo{}
+	extracted.SetContent(text)
+
+	if result := <-Srv.Store.FileInfo().UpdateContent(info.Id, extracted.Content); result.Err != nil {
+		l4g.Error(utils.T("api.file.extract_content.save.error"), info.Id, result.Err)
+	}
+}
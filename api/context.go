@@ -158,7 +158,12 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(token) != 0 {
-		session := GetSession(token)
+		var session *model.Session
+		if model.IsUserAccessToken(token) {
+			session = GetSessionForUserAccessToken(token)
+		} else {
+			session = GetSession(token)
+		}
 
 		if session == nil || session.IsExpired() {
 			c.RemoveSessionCookie(w, r)
@@ -195,6 +200,14 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		c.HasPermissionsToTeam(c.TeamId, "TeamRoute")
 	}
 
+	if c.Err == nil && c.Session.IsOAuth {
+		c.OAuthScopeRequired(r)
+	}
+
+	if c.Err == nil && c.Session.IsUserAccessToken() {
+		updateUserAccessTokenLastUsedAt(c.Session.Id)
+	}
+
 	if c.Err == nil && h.isUserActivity && token != "" && len(c.Session.UserId) > 0 {
 		go func() {
 			if err := (<-Srv.Store.User().UpdateUserAndSessionActivity(c.Session.UserId, c.Session.Id, model.GetMillis())).Err; err != nil {
@@ -303,6 +316,20 @@ func (c *Context) SystemAdminRequired() {
 	}
 }
 
+// OAuthScopeRequired makes sure that an OAuth session has been given the scope needed for the request. Anything
+// other than reading needs the write scope and admin actions are limited by IsSystemAdmin and IsTeamAdmin.
+func (c *Context) OAuthScopeRequired(r *http.Request) {
+	scope := model.OAUTH_SCOPE_WRITE
+	if r.Method == "GET" || r.Method == "HEAD" {
+		scope = model.OAUTH_SCOPE_READ
+	}
+
+	if !c.Session.HasOAuthScope(scope) {
+		c.Err = model.NewLocAppError("OAuthScopeRequired", "api.context.oauth_scope.app_error", map[string]interface{}{"Scope": scope}, "userId="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+	}
+}
+
 func (c *Context) HasPermissionsToUser(userId string, where string) bool {
 
 	// You are the user
@@ -360,7 +387,7 @@ func (c *Context) HasSystemAdminPermissions(where string) bool {
 }
 
 func (c *Context) IsSystemAdmin() bool {
-	if model.IsInRole(c.Session.Roles, model.ROLE_SYSTEM_ADMIN) {
+	if model.IsInRole(c.Session.Roles, model.ROLE_SYSTEM_ADMIN) && c.Session.HasOAuthScope(model.OAUTH_SCOPE_ADMIN) {
 		return true
 	}
 	return false
@@ -372,6 +399,10 @@ func (c *Context) IsTeamAdmin() bool {
 		return true
 	}
 
+	if !c.Session.HasOAuthScope(model.OAUTH_SCOPE_ADMIN) {
+		return false
+	}
+
 	teamMember := c.Session.GetTeamByTeamId(c.TeamId)
 	if teamMember == nil {
 		return false
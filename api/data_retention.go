@@ -0,0 +1,285 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	DATA_RETENTION_JOB_NAME       = "Data Retention"
+	DATA_RETENTION_JOB_INTERVAL   = 24 * time.Hour
+	DATA_RETENTION_JOB_LEASE      = DATA_RETENTION_JOB_INTERVAL * 9 / 10 // how long after the last run that any server can run the job again
+	DATA_RETENTION_AUDIT_ACTION   = "data_retention"
+	DATA_RETENTION_MILLIS_PER_DAY = 24 * 60 * 60 * 1000
+)
+
+type DataRetentionResult struct {
+	DryRun bool
+	Posts  int64
+	Files  int64
+	Audits int64
+}
+
+func (r *DataRetentionResult) String() string {
+	return fmt.Sprintf("dry_run=%v posts=%v files=%v audits=%v", r.DryRun, r.Posts, r.Files, r.Audits)
+}
+
+func StartDataRetentionJob() {
+	model.CreateRecurringTask(DATA_RETENTION_JOB_NAME, doDataRetention, DATA_RETENTION_JOB_INTERVAL)
+}
+
+// doDataRetention runs the data retention job unless another server has already run it during the last day
+func doDataRetention() {
+	if !*utils.Cfg.DataRetentionSettings.Enable {
+		return
+	}
+
+	ranBefore := model.GetMillis() - int64(DATA_RETENTION_JOB_LEASE/time.Millisecond)
+	if _, err := runLeasedTask(DATA_RETENTION_JOB_NAME, ranBefore, func() {
+		if result, err := RunDataRetention(*utils.Cfg.DataRetentionSettings.DryRun); err != nil {
+			l4g.Error(utils.T("api.data_retention.run.error"), err)
+		} else {
+			l4g.Info(utils.T("api.data_retention.run.info"), result.String())
+		}
+	}); err != nil {
+		l4g.Error(utils.T("api.data_retention.run.error"), err)
+	}
+}
+
+// RunDataRetention permanently deletes posts and audits that are older than the retention periods
+// in DataRetentionSettings, along with every reply to a deleted post. A dry run only counts what would
+// have been deleted. Every run is recorded in the audit log.
+func RunDataRetention(dryRun bool) (*DataRetentionResult, *model.AppError) {
+	result := &DataRetentionResult{DryRun: dryRun}
+
+	err := purgeOldPosts(result)
+	if err == nil {
+		err = purgeOldAudits(result)
+	}
+
+	extraInfo := result.String()
+	if err != nil {
+		extraInfo += " error=" + err.Error()
+	}
+
+	audit := &model.Audit{Action: DATA_RETENTION_AUDIT_ACTION, ExtraInfo: extraInfo}
+	if r := <-Srv.Store.Audit().Save(audit); r.Err != nil {
+		l4g.Error(utils.T("api.data_retention.audit.error"), r.Err)
+	}
+
+	return result, err
+}
+
+// getMessageRetentionDays returns how many days of messages to keep in a channel with zero
+// meaning forever. Channel policies take precedence over team policies.
+func getMessageRetentionDays(channel *model.Channel) int {
+	settings := utils.Cfg.DataRetentionSettings
+
+	if days, ok := settings.ChannelMessageRetentionDays[channel.Id]; ok {
+		return days
+	}
+
+	if days, ok := settings.TeamMessageRetentionDays[channel.TeamId]; ok && len(channel.TeamId) > 0 {
+		return days
+	}
+
+	return *settings.MessageRetentionDays
+}
+
+func purgeOldPosts(result *DataRetentionResult) *model.AppError {
+	var channels []*model.Channel
+	if r := <-Srv.Store.Channel().GetAll(); r.Err != nil {
+		return r.Err
+	} else {
+		channels = r.Data.([]*model.Channel)
+	}
+
+	now := model.GetMillis()
+
+	for _, channel := range channels {
+		days := getMessageRetentionDays(channel)
+		if days == 0 {
+			continue
+		}
+
+		if err := purgeOldChannelPosts(channel, now-int64(days)*DATA_RETENTION_MILLIS_PER_DAY, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func purgeOldChannelPosts(channel *model.Channel, endTime int64, result *DataRetentionResult) *model.AppError {
+	batchSize := *utils.Cfg.DataRetentionSettings.BatchSize
+	offset := 0
+
+	for {
+		var posts []*model.Post
+		if r := <-Srv.Store.Post().GetPostsBatchForRetention(channel.Id, endTime, offset, batchSize); r.Err != nil {
+			return r.Err
+		} else {
+			posts = r.Data.([]*model.Post)
+		}
+
+		postIds := make([]string, len(posts))
+		for i, post := range posts {
+			postIds[i] = post.Id
+		}
+
+		paths, files, err := getRetentionFilePaths(channel, posts)
+		if err != nil {
+			return err
+		}
+
+		result.Files += files
+
+		if result.DryRun {
+			// nothing is deleted so the next batch is further along
+			offset += len(posts)
+		} else {
+			for path := range paths {
+				// files in direct and group channels are looked for in each of the poster's teams so most won't exist
+				if err := RemoveFile(path); err != nil && len(channel.TeamId) > 0 {
+					l4g.Warn(utils.T("api.data_retention.remove_file.warn"), path, err)
+				}
+			}
+
+			for _, post := range posts {
+				deletePostFromSearch(post)
+			}
+
+			if r := <-Srv.Store.Reaction().PermanentDeleteByPosts(postIds); r.Err != nil {
+				return r.Err
+			}
+
+			if r := <-Srv.Store.Thread().PermanentDeleteByPosts(postIds); r.Err != nil {
+				return r.Err
+			}
+
+			if r := <-Srv.Store.FileInfo().PermanentDeleteByPosts(postIds); r.Err != nil {
+				return r.Err
+			}
+
+			if r := <-Srv.Store.Post().PermanentDeleteByIds(postIds); r.Err != nil {
+				return r.Err
+			}
+		}
+
+		result.Posts += int64(len(posts))
+
+		if len(posts) < batchSize {
+			return nil
+		}
+	}
+}
+
+// getRetentionFilePaths returns the paths of every file, thumbnail and preview that belong to the posts along with
+// the number of files. Files attached before FileInfos existed are stored under a team's folder, so for direct and
+// group channels, which don't have a team, the file could be under any of the poster's teams.
+func getRetentionFilePaths(channel *model.Channel, posts []*model.Post) (map[string]bool, int64, *model.AppError) {
+	paths := map[string]bool{}
+	if len(posts) == 0 {
+		return paths, 0, nil
+	}
+
+	postIds := make([]string, len(posts))
+	for i, post := range posts {
+		postIds[i] = post.Id
+	}
+
+	var infos []*model.FileInfo
+	if r := <-Srv.Store.FileInfo().GetAllForPosts(postIds); r.Err != nil {
+		return nil, 0, r.Err
+	} else {
+		infos = r.Data.([]*model.FileInfo)
+	}
+
+	addPaths := func(info *model.FileInfo) {
+		for _, path := range []string{info.Path, info.ThumbnailPath, info.PreviewPath} {
+			if len(path) > 0 {
+				paths[path] = true
+			}
+		}
+	}
+
+	for _, info := range infos {
+		addPaths(info)
+	}
+
+	files := int64(len(infos))
+
+	for _, post := range posts {
+		if len(post.Filenames) == 0 {
+			continue
+		}
+
+		if len(post.FileIds) == 0 {
+			// the files haven't been migrated to FileInfos so they weren't counted above
+			files += int64(len(post.Filenames))
+		}
+
+		teamIds := []string{channel.TeamId}
+		if len(channel.TeamId) == 0 {
+			if r := <-Srv.Store.Team().GetTeamsByUserId(post.UserId); r.Err != nil {
+				return nil, 0, r.Err
+			} else {
+				teamIds = []string{}
+				for _, team := range r.Data.([]*model.Team) {
+					teamIds = append(teamIds, team.Id)
+				}
+			}
+		}
+
+		for _, filename := range post.Filenames {
+			for _, teamId := range teamIds {
+				if info := model.GetInfoForFilename(filename, teamId); info != nil {
+					addPaths(info)
+				}
+			}
+		}
+	}
+
+	return paths, files, nil
+}
+
+func purgeOldAudits(result *DataRetentionResult) *model.AppError {
+	days := *utils.Cfg.DataRetentionSettings.AuditRetentionDays
+	if days == 0 {
+		return nil
+	}
+
+	endTime := model.GetMillis() - int64(days)*DATA_RETENTION_MILLIS_PER_DAY
+
+	if result.DryRun {
+		if r := <-Srv.Store.Audit().GetCountBefore(endTime); r.Err != nil {
+			return r.Err
+		} else {
+			result.Audits = r.Data.(int64)
+		}
+
+		return nil
+	}
+
+	batchSize := *utils.Cfg.DataRetentionSettings.BatchSize
+
+	for {
+		if r := <-Srv.Store.Audit().PermanentDeleteBatch(endTime, batchSize); r.Err != nil {
+			return r.Err
+		} else {
+			deleted := r.Data.(int64)
+			result.Audits += deleted
+
+			if deleted < int64(batchSize) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestRunDataRetention(t *testing.T) {
+	th := Setup().InitBasic()
+
+	channel1 := th.BasicChannel
+	channel2 := th.CreateChannel(th.BasicClient, th.BasicTeam)
+
+	oldTime := model.GetMillis() - 10*DATA_RETENTION_MILLIS_PER_DAY
+
+	oldPost1 := store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel1.Id, UserId: th.BasicUser.Id, Message: "old", CreateAt: oldTime})).(*model.Post)
+	oldPost2 := store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel2.Id, UserId: th.BasicUser.Id, Message: "old", CreateAt: oldTime})).(*model.Post)
+	newPost := store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel1.Id, UserId: th.BasicUser.Id, Message: "new"})).(*model.Post)
+	newReply := store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: channel1.Id, UserId: th.BasicUser.Id, Message: "new reply", RootId: oldPost1.Id, ParentId: oldPost1.Id})).(*model.Post)
+	store.Must(Srv.Store.Reaction().Save(&model.Reaction{UserId: th.BasicUser.Id, PostId: oldPost1.Id, EmojiName: "smile"}))
+
+	messageRetentionDays := *utils.Cfg.DataRetentionSettings.MessageRetentionDays
+	channelMessageRetentionDays := utils.Cfg.DataRetentionSettings.ChannelMessageRetentionDays
+	defer func() {
+		*utils.Cfg.DataRetentionSettings.MessageRetentionDays = messageRetentionDays
+		utils.Cfg.DataRetentionSettings.ChannelMessageRetentionDays = channelMessageRetentionDays
+	}()
+
+	*utils.Cfg.DataRetentionSettings.MessageRetentionDays = 5
+	utils.Cfg.DataRetentionSettings.ChannelMessageRetentionDays = map[string]int{channel2.Id: 30}
+
+	if result, err := RunDataRetention(true); err != nil {
+		t.Fatal(err)
+	} else if !result.DryRun || result.Posts < 1 {
+		t.Fatal("dry run should have counted the old post")
+	}
+
+	if r := <-Srv.Store.Post().Get(oldPost1.Id); r.Err != nil {
+		t.Fatal("dry run shouldn't have deleted anything")
+	}
+
+	if _, err := RunDataRetention(false); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := <-Srv.Store.Post().Get(oldPost1.Id); r.Err == nil {
+		t.Fatal("old post should have been deleted")
+	}
+
+	if r := <-Srv.Store.Post().Get(newReply.Id); r.Err == nil {
+		t.Fatal("newer reply to the old post should have been deleted with it")
+	}
+
+	if reactions := store.Must(Srv.Store.Reaction().GetForPost(oldPost1.Id)).([]*model.Reaction); len(reactions) != 0 {
+		t.Fatal("reactions to the old post should have been deleted")
+	}
+
+	if r := <-Srv.Store.Post().Get(oldPost2.Id); r.Err != nil {
+		t.Fatal("channel policy should have kept the post")
+	}
+
+	if r := <-Srv.Store.Post().Get(newPost.Id); r.Err != nil {
+		t.Fatal("new post should have been kept")
+	}
+
+	audits := store.Must(Srv.Store.Audit().Get("", 10)).(model.Audits)
+	found := false
+	for _, audit := range audits {
+		if audit.Action == DATA_RETENTION_AUDIT_ACTION {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("runs should have been written to the audit log")
+	}
+}
+
+func TestRunDataRetentionFiles(t *testing.T) {
+	th := Setup().InitBasic()
+
+	backend := NewMemoryFileBackend()
+	SetFileBackend(backend)
+	defer SetFileBackend(nil)
+
+	messageRetentionDays := *utils.Cfg.DataRetentionSettings.MessageRetentionDays
+	defer func() {
+		*utils.Cfg.DataRetentionSettings.MessageRetentionDays = messageRetentionDays
+	}()
+	*utils.Cfg.DataRetentionSettings.MessageRetentionDays = 5
+
+	oldTime := model.GetMillis() - 10*DATA_RETENTION_MILLIS_PER_DAY
+
+	post := store.Must(Srv.Store.Post().Save(&model.Post{ChannelId: th.BasicChannel.Id, UserId: th.BasicUser.Id, Message: "old", CreateAt: oldTime})).(*model.Post)
+	info := &model.FileInfo{CreatorId: th.BasicUser.Id, ChannelId: th.BasicChannel.Id, PostId: post.Id, Filename: "file.png", Path: "files/" + model.NewId() + "/file.png", Size: 4}
+	info.ThumbnailPath = info.Path + "_thumb.jpg"
+	info = store.Must(Srv.Store.FileInfo().Save(info)).(*model.FileInfo)
+
+	// files in direct channels from before FileInfos existed are stored under one of the poster's teams
+	directChannel, err := CreateDirectChannel(th.BasicUser.Id, th.BasicUser2.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileId := model.NewId()
+	directPath := "teams/" + th.BasicTeam.Id + "/channels/" + directChannel.Id + "/users/" + th.BasicUser.Id + "/" + fileId + "/file.txt"
+	directPost := store.Must(Srv.Store.Post().Save(&model.Post{
+		ChannelId: directChannel.Id,
+		UserId:    th.BasicUser.Id,
+		CreateAt:  oldTime,
+		Filenames: []string{"/" + directChannel.Id + "/" + th.BasicUser.Id + "/" + fileId + "/file.txt"},
+	})).(*model.Post)
+
+	for _, path := range []string{info.Path, info.ThumbnailPath, directPath} {
+		if err := WriteFile([]byte("data"), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if result, err := RunDataRetention(false); err != nil {
+		t.Fatal(err)
+	} else if result.Files < 2 {
+		t.Fatal("should have counted the files", result.String())
+	}
+
+	for _, path := range []string{info.Path, info.ThumbnailPath, directPath} {
+		if _, err := backend.Read(path); err == nil {
+			t.Fatal("should have removed " + path)
+		}
+	}
+
+	if r := <-Srv.Store.FileInfo().Get(info.Id); r.Err == nil {
+		t.Fatal("should have deleted the FileInfo")
+	}
+
+	if r := <-Srv.Store.Post().Get(directPost.Id); r.Err == nil {
+		t.Fatal("should have deleted the post in the direct channel")
+	}
+}
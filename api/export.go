@@ -7,66 +7,44 @@ import (
 	"archive/zip"
 	"encoding/json"
 	"github.com/mattermost/platform/model"
-	"github.com/mattermost/platform/utils"
 	"io"
-	"os"
+	"strings"
 )
 
 const (
 	EXPORT_PATH                   = "export/"
-	EXPORT_FILENAME               = "MattermostExport.zip"
+	EXPORT_FILENAME_PREFIX        = "MattermostExport-"
 	EXPORT_OPTIONS_FILE           = "options.json"
+	EXPORT_MANIFEST_FILE          = "manifest.json"
 	EXPORT_TEAMS_FOLDER           = "teams"
 	EXPORT_CHANNELS_FOLDER        = "channels"
 	EXPORT_CHANNEL_MEMBERS_FOLDER = "members"
 	EXPORT_POSTS_FOLDER           = "posts"
 	EXPORT_USERS_FOLDER           = "users"
 	EXPORT_LOCAL_STORAGE_FOLDER   = "files"
+	EXPORT_POST_BATCH_SIZE        = 1000
 )
 
 type ExportWriter interface {
 	Create(name string) (io.Writer, error)
 }
 
-type ExportOptions struct {
-	TeamsToExport      []string `json:"teams"`
-	ChannelsToExport   []string `json:"channels"`
-	UsersToExport      []string `json:"users"`
-	ExportLocalStorage bool     `json:"export_local_storage"`
-}
-
-func (options *ExportOptions) ToJson() string {
-	b, err := json.Marshal(options)
-	if err != nil {
-		return ""
-	} else {
-		return string(b)
+// ExportToWriter writes a zip of everything selected by the options to w. The manifest is filled in with the range
+// of posts exported from each channel and written into the zip along with them.
+func ExportToWriter(w io.Writer, options *model.ExportOptions, manifest *model.ExportManifest) *model.AppError {
+	manifest.Version = model.EXPORT_MANIFEST_VERSION
+	if manifest.CreateAt == 0 {
+		manifest.CreateAt = model.GetMillis()
 	}
-}
-
-func ExportOptionsFromJson(data io.Reader) *ExportOptions {
-	decoder := json.NewDecoder(data)
-	var o ExportOptions
-	decoder.Decode(&o)
-	return &o
-}
-
-func ExportToFile(options *ExportOptions) (link string, err *model.AppError) {
-	// Open file for export
-	if file, err := openFileWriteStream(EXPORT_PATH + EXPORT_FILENAME); err != nil {
-		return "", err
-	} else {
-		defer closeFileWriteStream(file)
-		ExportToWriter(file, options)
+	manifest.Since = options.Since
+	manifest.Until = options.Until
+	if manifest.Until == 0 {
+		manifest.Until = manifest.CreateAt
 	}
+	manifest.Channels = make(map[string]*model.ExportManifestChannel)
 
-	return model.API_URL_SUFFIX + "/files/get_export", nil
-}
-
-func ExportToWriter(w io.Writer, options *ExportOptions) *model.AppError {
 	// Open a writer to write to zip file
 	zipWriter := zip.NewWriter(w)
-	defer zipWriter.Close()
 
 	// Write our options to file
 	if optionsFile, err := zipWriter.Create(EXPORT_OPTIONS_FILE); err != nil {
@@ -78,12 +56,25 @@ func ExportToWriter(w io.Writer, options *ExportOptions) *model.AppError {
 	}
 
 	// Export Teams
-	ExportTeams(zipWriter, options)
+	if err := ExportTeams(zipWriter, options, manifest); err != nil {
+		return err
+	}
+
+	// The manifest goes last since it's only complete once everything else has been written
+	if manifestFile, err := zipWriter.Create(EXPORT_MANIFEST_FILE); err != nil {
+		return model.NewLocAppError("ExportToWriter", "api.export.open_file.app_error", nil, err.Error())
+	} else if _, err := manifestFile.Write([]byte(manifest.ToJson())); err != nil {
+		return model.NewLocAppError("ExportToWriter", "api.export.write_file.app_error", nil, err.Error())
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return model.NewLocAppError("ExportToWriter", "api.export.write_file.app_error", nil, err.Error())
+	}
 
 	return nil
 }
 
-func ExportTeams(writer ExportWriter, options *ExportOptions) *model.AppError {
+func ExportTeams(writer ExportWriter, options *model.ExportOptions, manifest *model.ExportManifest) *model.AppError {
 	// Get the teams
 	var teams []*model.Team
 	if len(options.TeamsToExport) == 0 {
@@ -120,7 +111,7 @@ func ExportTeams(writer ExportWriter, options *ExportOptions) *model.AppError {
 
 	// Export the channels, local storage and users
 	for _, team := range teams {
-		if err := ExportChannels(writer, options, team.Id); err != nil {
+		if err := ExportChannels(writer, options, manifest, team.Id); err != nil {
 			return err
 		}
 		if err := ExportUsers(writer, options, team.Id); err != nil {
@@ -134,7 +125,7 @@ func ExportTeams(writer ExportWriter, options *ExportOptions) *model.AppError {
 	return nil
 }
 
-func ExportChannels(writer ExportWriter, options *ExportOptions, teamId string) *model.AppError {
+func ExportChannels(writer ExportWriter, options *model.ExportOptions, manifest *model.ExportManifest, teamId string) *model.AppError {
 	// Get the channels
 	var channels []*model.Channel
 	if len(options.ChannelsToExport) == 0 {
@@ -148,8 +139,10 @@ func ExportChannels(writer ExportWriter, options *ExportOptions, teamId string)
 			if result := <-Srv.Store.Channel().Get(channelId); result.Err != nil {
 				return result.Err
 			} else {
-				channel := result.Data.(*model.Channel)
-				channels = append(channels, channel)
+				// only the selected channels that belong to this team are exported with it
+				if channel := result.Data.(*model.Channel); channel.TeamId == teamId {
+					channels = append(channels, channel)
+				}
 			}
 		}
 	}
@@ -190,7 +183,7 @@ func ExportChannels(writer ExportWriter, options *ExportOptions, teamId string)
 	}
 
 	for _, channel := range channels {
-		if err := ExportPosts(writer, options, channel.Id); err != nil {
+		if err := ExportPosts(writer, options, manifest, channel.Id); err != nil {
 			return err
 		}
 	}
@@ -198,32 +191,89 @@ func ExportChannels(writer ExportWriter, options *ExportOptions, teamId string)
 	return nil
 }
 
-func ExportPosts(writer ExportWriter, options *ExportOptions, channelId string) *model.AppError {
-	// Get the posts
-	var posts []*model.Post
-	if result := <-Srv.Store.Post().GetForExport(channelId); result.Err != nil {
+// ExportPosts writes the posts made in a channel within the range selected for it. The posts are read and written a
+// batch at a time so that the whole channel is never held in memory.
+func ExportPosts(writer ExportWriter, options *model.ExportOptions, manifest *model.ExportManifest, channelId string) *model.AppError {
+	since, until := options.GetRange(channelId)
+	if until == 0 {
+		until = manifest.Until
+	}
+
+	exported := &model.ExportManifestChannel{Since: since, Until: until}
+	manifest.Channels[channelId] = exported
+
+	postsFile, err := writer.Create(EXPORT_POSTS_FOLDER + "/" + channelId + "_posts.json")
+	if err != nil {
+		return model.NewLocAppError("ExportPosts", "api.export.open_file.app_error", nil, err.Error())
+	}
+
+	// Export the posts as a single json array
+	if _, err := postsFile.Write([]byte("[")); err != nil {
+		return model.NewLocAppError("ExportPosts", "api.export.write_file.app_error", nil, err.Error())
+	}
+
+	after, afterId := since, ""
+	for {
+		var posts []*model.Post
+		if result := <-Srv.Store.Post().GetForExport(channelId, after, afterId, until, EXPORT_POST_BATCH_SIZE); result.Err != nil {
+			return result.Err
+		} else {
+			posts = result.Data.([]*model.Post)
+		}
+
+		for _, post := range posts {
+			b, err := json.Marshal(post)
+			if err != nil {
+				return model.NewLocAppError("ExportPosts", "api.export.json.app_error", nil, err.Error())
+			}
+
+			if exported.PostCount > 0 {
+				b = append([]byte(","), b...)
+			}
+
+			if _, err := postsFile.Write(b); err != nil {
+				return model.NewLocAppError("ExportPosts", "api.export.write_file.app_error", nil, err.Error())
+			}
+
+			exported.PostCount++
+		}
+
+		if len(posts) < EXPORT_POST_BATCH_SIZE {
+			break
+		}
+
+		after, afterId = posts[len(posts)-1].CreateAt, posts[len(posts)-1].Id
+	}
+
+	if _, err := postsFile.Write([]byte("]")); err != nil {
+		return model.NewLocAppError("ExportPosts", "api.export.write_file.app_error", nil, err.Error())
+	}
+
+	// Get the reactions to the posts
+	var reactions []*model.Reaction
+	if result := <-Srv.Store.Reaction().GetForExport(channelId, since, until); result.Err != nil {
 		return result.Err
 	} else {
-		posts = result.Data.([]*model.Post)
+		reactions = result.Data.([]*model.Reaction)
 	}
 
-	// Export the posts
-	if postsFile, err := writer.Create(EXPORT_POSTS_FOLDER + "/" + channelId + "_posts.json"); err != nil {
+	// Export the reactions
+	if reactionsFile, err := writer.Create(EXPORT_POSTS_FOLDER + "/" + channelId + "_reactions.json"); err != nil {
 		return model.NewLocAppError("ExportPosts", "api.export.open_file.app_error", nil, err.Error())
 	} else {
-		result, err2 := json.Marshal(posts)
+		result, err2 := json.Marshal(reactions)
 		if err2 != nil {
-			return model.NewLocAppError("ExportPosts", "api.export.json.app_error", nil, err.Error())
+			return model.NewLocAppError("ExportPosts", "api.export.json.app_error", nil, err2.Error())
 		}
-		if _, err3 := postsFile.Write([]byte(result)); err3 != nil {
-			return model.NewLocAppError("ExportPosts", "api.export.write_file.app_error", nil, err.Error())
+		if _, err3 := reactionsFile.Write([]byte(result)); err3 != nil {
+			return model.NewLocAppError("ExportPosts", "api.export.write_file.app_error", nil, err3.Error())
 		}
 	}
 
 	return nil
 }
 
-func ExportUsers(writer ExportWriter, options *ExportOptions, teamId string) *model.AppError {
+func ExportUsers(writer ExportWriter, options *model.ExportOptions, teamId string) *model.AppError {
 	// Get the users
 	var users []*model.User
 	if result := <-Srv.Store.User().GetForExport(teamId); result.Err != nil {
@@ -247,44 +297,27 @@ func ExportUsers(writer ExportWriter, options *ExportOptions, teamId string) *mo
 	return nil
 }
 
-func copyDirToExportWriter(writer ExportWriter, inPath string, outPath string) *model.AppError {
-	dir, err := os.Open(inPath)
+func ExportLocalStorage(writer ExportWriter, options *model.ExportOptions, teamId string) *model.AppError {
+	backend, err := GetFileBackend()
 	if err != nil {
-		return model.NewLocAppError("copyDirToExportWriter", "api.export.open_dir.app_error", nil, err.Error())
+		return err
 	}
 
-	fileInfoList, err := dir.Readdir(0)
-	if err != nil {
-		return model.NewLocAppError("copyDirToExportWriter", "api.export.read_dir.app_error", nil, err.Error())
-	}
+	teamDir := "teams/" + teamId
 
-	for _, fileInfo := range fileInfoList {
-		if fileInfo.IsDir() {
-			copyDirToExportWriter(writer, inPath+"/"+fileInfo.Name(), outPath+"/"+fileInfo.Name())
-		} else {
-			if toFile, err := writer.Create(outPath + "/" + fileInfo.Name()); err != nil {
-				return model.NewLocAppError("copyDirToExportWriter", "api.export.open_file.app_error", nil, err.Error())
-			} else {
-				fromFile, err := os.Open(inPath + "/" + fileInfo.Name())
-				if err != nil {
-					return model.NewLocAppError("copyDirToExportWriter", "api.export.open.app_error", nil, err.Error())
-				}
-				io.Copy(toFile, fromFile)
-			}
-		}
+	paths, err := backend.List(teamDir)
+	if err != nil {
+		return err
 	}
 
-	return nil
-}
-
-func ExportLocalStorage(writer ExportWriter, options *ExportOptions, teamId string) *model.AppError {
-	teamDir := utils.Cfg.FileSettings.Directory + "teams/" + teamId
-
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		return model.NewLocAppError("ExportLocalStorage", "api.export.s3.app_error", nil, "")
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := copyDirToExportWriter(writer, teamDir, EXPORT_LOCAL_STORAGE_FOLDER); err != nil {
+	for _, path := range paths {
+		if toFile, err := writer.Create(EXPORT_LOCAL_STORAGE_FOLDER + strings.TrimPrefix(path, teamDir)); err != nil {
+			return model.NewLocAppError("ExportLocalStorage", "api.export.open_file.app_error", nil, err.Error())
+		} else if fromFile, err := backend.Reader(path); err != nil {
 			return err
+		} else {
+			io.Copy(toFile, fromFile)
+			fromFile.Close()
 		}
 	}
 
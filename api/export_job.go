@@ -0,0 +1,213 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	EXPORT_JOB_MAX_ERROR_LENGTH = 1024
+
+	// a running job is taken over by another server if the one running it hasn't sent a heartbeat in this long
+	EXPORT_JOB_HEARTBEAT_INTERVAL = 1 * time.Minute
+	EXPORT_JOB_HEARTBEAT_TIMEOUT  = 5 * time.Minute
+)
+
+var exportWorkerWake chan bool
+var exportWorkerStop chan bool
+
+// exportWorkerId identifies this server as the one running the export jobs and leased tasks that it has claimed
+var exportWorkerId = model.NewId()
+
+// StartExportWorker starts the worker that runs export jobs in the background. Any jobs that were interrupted the
+// last time a server stopped are run again from the start once their heartbeat has timed out.
+func StartExportWorker() {
+	if exportWorkerStop != nil {
+		return
+	}
+
+	wake := make(chan bool, 1)
+	stop := make(chan bool)
+	exportWorkerWake = wake
+	exportWorkerStop = stop
+
+	go func() {
+		for {
+			runUnfinishedExportJobs(stop)
+
+			select {
+			case <-wake:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func StopExportWorker() {
+	if exportWorkerStop != nil {
+		close(exportWorkerStop)
+		exportWorkerStop = nil
+		exportWorkerWake = nil
+	}
+}
+
+// CreateExportJob saves a new export job and lets the worker know that there's something for it to do
+func CreateExportJob(job *model.ExportJob) (*model.ExportJob, *model.AppError) {
+	if result := <-Srv.Store.ExportJob().Save(job); result.Err != nil {
+		return nil, result.Err
+	} else {
+		job = result.Data.(*model.ExportJob)
+	}
+
+	if wake := exportWorkerWake; wake != nil {
+		select {
+		case wake <- true:
+		default:
+			// the worker is already going to check for new jobs
+		}
+	}
+
+	return job, nil
+}
+
+func runUnfinishedExportJobs(stop chan bool) {
+	var jobs []*model.ExportJob
+	if result := <-Srv.Store.ExportJob().GetUnfinished(); result.Err != nil {
+		l4g.Error(utils.T("api.export.job.get_unfinished.error"), result.Err)
+		return
+	} else {
+		jobs = result.Data.([]*model.ExportJob)
+	}
+
+	for _, job := range jobs {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		if err := RunExportJob(job); err != nil {
+			l4g.Error(utils.T("api.export.job.run.error"), job.Id, err)
+		}
+	}
+}
+
+// RunExportJob writes the export described by a job to a timestamped archive in file storage, updating the job as
+// it goes. The end of the range is fixed when the job first starts, and an incremental job without a start picks up
+// where the last successful export for the same team left off. Nothing is done if another server is already running
+// the job.
+func RunExportJob(job *model.ExportJob) *model.AppError {
+	staleBefore := model.GetMillis() - int64(EXPORT_JOB_HEARTBEAT_TIMEOUT/time.Millisecond)
+	if result := <-Srv.Store.ExportJob().Claim(job, exportWorkerId, staleBefore); result.Err != nil {
+		return result.Err
+	} else if !result.Data.(bool) {
+		return nil
+	}
+
+	if job.StartAt == 0 {
+		job.StartAt = model.GetMillis()
+	}
+
+	if job.Options.Until == 0 {
+		job.Options.Until = job.StartAt
+	}
+
+	if job.Options.Incremental && job.Options.Since == 0 {
+		if result := <-Srv.Store.ExportJob().GetLastSuccessful(job.TeamId); result.Err == nil {
+			if last := result.Data.(*model.ExportJob); last.Options.Until < job.Options.Until {
+				job.Options.Since = last.Options.Until
+			}
+		}
+	}
+
+	if job.Path == "" {
+		job.Path = EXPORT_PATH + EXPORT_FILENAME_PREFIX + time.Unix(0, job.StartAt*int64(time.Millisecond)).UTC().Format("20060102-150405") + "-" + job.Id + ".zip"
+	}
+
+	if result := <-Srv.Store.ExportJob().Update(job); result.Err != nil {
+		return result.Err
+	}
+
+	manifest := &model.ExportManifest{
+		JobId:    job.Id,
+		CreateAt: job.StartAt,
+	}
+
+	stopHeartbeat := startExportJobHeartbeat(job.Id)
+	err := writeExport(job, manifest)
+	close(stopHeartbeat)
+
+	job.FinishAt = model.GetMillis()
+	if err != nil {
+		job.Status = model.EXPORT_JOB_STATUS_FAILED
+		job.Error = err.SystemMessage(utils.T)
+		if len(job.Error) > EXPORT_JOB_MAX_ERROR_LENGTH {
+			job.Error = job.Error[:EXPORT_JOB_MAX_ERROR_LENGTH]
+		}
+	} else {
+		job.Status = model.EXPORT_JOB_STATUS_SUCCESS
+		job.PostCount = 0
+		for _, channel := range manifest.Channels {
+			job.PostCount += channel.PostCount
+		}
+	}
+
+	if result := <-Srv.Store.ExportJob().Update(job); result.Err != nil {
+		return result.Err
+	}
+
+	return err
+}
+
+// startExportJobHeartbeat keeps the job's heartbeat up to date until the returned channel is closed
+func startExportJobHeartbeat(jobId string) chan bool {
+	stop := make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(EXPORT_JOB_HEARTBEAT_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if result := <-Srv.Store.ExportJob().Heartbeat(jobId, exportWorkerId); result.Err != nil {
+					l4g.Error(utils.T("api.export.job.heartbeat.error"), jobId, result.Err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop
+}
+
+func writeExport(job *model.ExportJob, manifest *model.ExportManifest) *model.AppError {
+	backend, err := GetFileBackend()
+	if err != nil {
+		return err
+	}
+
+	file, err := backend.Writer(job.Path)
+	if err != nil {
+		return err
+	}
+
+	if err := ExportToWriter(file, &job.Options, manifest); err != nil {
+		file.Close()
+		return err
+	}
+
+	if closeErr := file.Close(); closeErr != nil {
+		return model.NewLocAppError("writeExport", "api.export.write_file.app_error", nil, closeErr.Error())
+	}
+
+	return nil
+}
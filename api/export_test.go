@@ -0,0 +1,161 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestExportToWriter(t *testing.T) {
+	th := Setup().InitBasic()
+
+	channel := th.BasicChannel
+	channel2 := th.CreateChannel(th.BasicClient, th.BasicTeam)
+
+	old := &model.Post{ChannelId: channel.Id, UserId: th.BasicUser.Id, Message: "old", CreateAt: 1000}
+	store.Must(Srv.Store.Post().Save(old))
+	recent := &model.Post{ChannelId: channel.Id, UserId: th.BasicUser.Id, Message: "recent", CreateAt: 2000}
+	store.Must(Srv.Store.Post().Save(recent))
+	other := &model.Post{ChannelId: channel2.Id, UserId: th.BasicUser.Id, Message: "other", CreateAt: 1000}
+	store.Must(Srv.Store.Post().Save(other))
+
+	options := &model.ExportOptions{
+		TeamsToExport: []string{th.BasicTeam.Id},
+		Since:         1500,
+		ChannelRanges: map[string]*model.ExportRange{channel2.Id: {Since: 500, Until: 1500}},
+	}
+	manifest := &model.ExportManifest{}
+
+	var buf bytes.Buffer
+	if err := ExportToWriter(&buf, options, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, appErr := MattermostParseArchive(zipReader)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if posts := archive.Posts[channel.Id]; len(posts) != 1 || posts[0].Id != recent.Id {
+		t.Fatal("should've only exported the posts since the start of the range", len(posts))
+	}
+
+	if posts := archive.Posts[channel2.Id]; len(posts) != 1 || posts[0].Id != other.Id {
+		t.Fatal("should've used the channel's own range", len(posts))
+	}
+
+	if manifest.Since != 1500 || manifest.Until == 0 {
+		t.Fatal("should've filled in the range of the export", manifest.Since, manifest.Until)
+	} else if exported := manifest.Channels[channel.Id]; exported == nil || exported.PostCount != 1 || exported.Since != 1500 {
+		t.Fatal("should've recorded what was exported from the channel")
+	} else if exported := manifest.Channels[channel2.Id]; exported == nil || exported.Since != 500 || exported.Until != 1500 {
+		t.Fatal("should've recorded the channel's range")
+	}
+
+	found := false
+	for _, file := range zipReader.File {
+		if file.Name == EXPORT_MANIFEST_FILE {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("should've written the manifest into the archive")
+	}
+}
+
+func TestExportJobs(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Log("skipping because no file driver is enabled")
+		return
+	}
+
+	if _, err := Client.StartExport(&model.ExportOptions{}); err == nil {
+		t.Fatal("only team admins should be able to export")
+	}
+
+	UpdateUserToTeamAdmin(th.BasicUser, th.BasicTeam)
+	Client.Logout()
+	Client.Login(th.BasicUser.Email, th.BasicUser.Password)
+	Client.SetTeamId(th.BasicTeam.Id)
+
+	if _, err := Client.StartExport(&model.ExportOptions{Since: 10, Until: 5}); err == nil {
+		t.Fatal("should've rejected an invalid range")
+	}
+
+	waitForJob := func(job *model.ExportJob) *model.ExportJob {
+		for i := 0; i < 50 && !job.IsFinished(); i++ {
+			time.Sleep(100 * time.Millisecond)
+
+			var err *model.AppError
+			if job, err = Client.GetExportJob(job.Id); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if job.Status != model.EXPORT_JOB_STATUS_SUCCESS {
+			t.Fatal("export should've succeeded", job.Status, job.Error)
+		}
+
+		return job
+	}
+
+	job, err := Client.StartExport(&model.ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job = waitForJob(job)
+	if job.TeamId != th.BasicTeam.Id || job.PostCount == 0 {
+		t.Fatal("should've exported the team's posts", job.PostCount)
+	}
+
+	if reader, err := Client.DownloadExport(job.Id); err != nil {
+		t.Fatal(err)
+	} else {
+		data, _ := ioutil.ReadAll(reader)
+		reader.Close()
+
+		if _, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+			t.Fatal("should've downloaded the archive", err)
+		}
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "since the last export"}))
+
+	incremental, err := Client.StartExport(&model.ExportOptions{Incremental: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	incremental = waitForJob(incremental)
+	if incremental.Options.Since != job.Options.Until {
+		t.Fatal("should've started where the last export finished")
+	} else if incremental.PostCount != 1 {
+		t.Fatal("should've only exported the new post", incremental.PostCount)
+	} else if incremental.Path == job.Path {
+		t.Fatal("each export should be written to its own archive")
+	}
+
+	th.LoginBasic2()
+	if _, err := Client.GetExportJob(job.Id); err == nil {
+		t.Fatal("only team admins should be able to see export jobs")
+	}
+}
@@ -4,27 +4,27 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"image"
-	"image/color"
-	"image/draw"
 	_ "image/gif"
-	"image/jpeg"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	l4g "github.com/alecthomas/log4go"
-	"github.com/disintegration/imaging"
-	"github.com/goamz/goamz/aws"
-	"github.com/goamz/goamz/s3"
 	"github.com/gorilla/mux"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
@@ -56,6 +56,11 @@ const (
 	MaxImageSize = 6048 * 4032 // 24 megapixels, roughly 36MB as a raw image
 )
 
+const (
+	IMAGE_HEADER_SIZE     = 128 * 1024 // enough to hold the dimensions and exif data at the start of an image
+	UPLOAD_FIELD_MAX_SIZE = 1024
+)
+
 var fileInfoCache *utils.Cache = utils.NewLru(1000)
 
 func InitFile() {
@@ -66,6 +71,10 @@ func InitFile() {
 	BaseRoutes.Files.Handle("/get_info/{channel_id:[A-Za-z0-9]+}/{user_id:[A-Za-z0-9]+}/{filename:([A-Za-z0-9]+/)?.+(\\.[A-Za-z0-9]{3,})?}", ApiUserRequired(getFileInfo)).Methods("GET")
 	BaseRoutes.Files.Handle("/get_public_link", ApiUserRequired(getPublicLink)).Methods("POST")
 	BaseRoutes.Files.Handle("/get_export", ApiUserRequired(getExport)).Methods("GET")
+	BaseRoutes.Files.Handle("/export", ApiUserRequired(startExport)).Methods("POST")
+	BaseRoutes.Files.Handle("/export/{job_id:[A-Za-z0-9]+}", ApiUserRequired(getExportJob)).Methods("GET")
+	BaseRoutes.Files.Handle("/export/{job_id:[A-Za-z0-9]+}/download", ApiUserRequired(downloadExport)).Methods("GET")
+	BaseRoutes.Files.Handle("/storage", ApiUserRequired(getStorageUsage)).Methods("GET")
 
 	BaseRoutes.Public.Handle("/files/get/{team_id:[A-Za-z0-9]+}/{channel_id:[A-Za-z0-9]+}/{user_id:[A-Za-z0-9]+}/{filename:([A-Za-z0-9]+/)?.+(\\.[A-Za-z0-9]{3,})?}", ApiAppHandlerTrustRequesterIndependent(getPublicFile)).Methods("GET")
 }
@@ -83,189 +92,362 @@ func uploadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := r.ParseMultipartForm(*utils.Cfg.FileSettings.MaxFileSize)
+	reader, err := r.MultipartReader()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	m := r.MultipartForm
+	resStruct := &model.FileUploadResponse{
+		Filenames: []string{},
+		FileInfos: []*model.FileInfo{},
+		ClientIds: []string{},
+	}
 
-	props := m.Value
+	imageJobs := []*imageJob{}
 
-	if len(props["channel_id"]) == 0 {
-		c.SetInvalidParam("uploadFile", "channel_id")
-		return
+	// Files are streamed straight to storage once the channel is known. Any that are sent before the channel_id
+	// field are spooled to temporary files on disk until then.
+	channelId := ""
+	spooled := []*spooledUpload{}
+	succeeded := false
+	defer func() {
+		for _, upload := range spooled {
+			upload.Remove()
+		}
+
+		// nothing is kept from a request that failed part way through
+		if !succeeded {
+			removeUploadedFiles(resStruct.FileInfos)
+		}
+	}()
+
+	upload := func(filename string, data io.Reader) bool {
+		info, job, err := uploadFileStream(c.TeamId, channelId, c.Session.UserId, filename, data)
+		if err != nil {
+			c.Err = err
+			return false
+		}
+
+		resStruct.Filenames = append(resStruct.Filenames, "/"+channelId+"/"+c.Session.UserId+"/"+info.Id+"/"+utils.UrlEncode(info.Filename))
+		resStruct.FileInfos = append(resStruct.FileInfos, info)
+		if job != nil {
+			imageJobs = append(imageJobs, job)
+		}
+
+		return true
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch part.FormName() {
+		case "channel_id":
+			if channelId != "" {
+				continue
+			}
+
+			channelId = readUploadField(part)
+			if len(channelId) != 26 {
+				c.SetInvalidParam("uploadFile", "channel_id")
+				return
+			}
+
+			cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+			if !c.HasPermissionsToChannel(cchan, "uploadFile") {
+				return
+			}
+
+			for _, s := range spooled {
+				if !upload(s.Filename, s.File) {
+					return
+				}
+			}
+		case "client_ids":
+			resStruct.ClientIds = append(resStruct.ClientIds, readUploadField(part))
+		case "files":
+			filename := filepath.Base(part.FileName())
+
+			if channelId != "" {
+				if !upload(filename, part) {
+					return
+				}
+			} else if s, err := spoolUpload(filename, part); err != nil {
+				c.Err = err
+				return
+			} else {
+				spooled = append(spooled, s)
+			}
+		}
+
+		part.Close()
 	}
-	channelId := props["channel_id"][0]
-	if len(channelId) == 0 {
+
+	if channelId == "" {
 		c.SetInvalidParam("uploadFile", "channel_id")
 		return
 	}
 
-	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	succeeded = true
 
-	files := m.File["files"]
+	handleImages(imageJobs)
+	handleContentExtraction(resStruct.FileInfos)
 
-	resStruct := &model.FileUploadResponse{
-		Filenames: []string{},
-		ClientIds: []string{},
+	w.Write([]byte(resStruct.ToJson()))
+}
+
+// uploadFileStream writes a single uploaded file to storage, working out its size and hash as it goes, and saves its
+// FileInfo. Space is reserved against the user's and team's storage quotas before anything is written so the file
+// is cut off as soon as it goes over. An image job is returned for images so that their thumbnail and preview can be
+// generated afterwards.
+func uploadFileStream(teamId string, channelId string, userId string, filename string, data io.Reader) (*model.FileInfo, *imageJob, *model.AppError) {
+	reservation, err := reserveStorageQuota(userId, teamId, *utils.Cfg.FileSettings.MaxFileSize)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer reservation.Release()
 
-	imageNameList := []string{}
-	imageDataList := [][]byte{}
+	info := model.GetInfoForPath(filename)
+	info.Id = model.NewId()
+	info.CreatorId = userId
+	info.ChannelId = channelId
+	info.Path = "teams/" + teamId + "/channels/" + channelId + "/users/" + userId + "/" + info.Id + "/" + filename
 
-	if !c.HasPermissionsToChannel(cchan, "uploadFile") {
-		return
-	}
+	var job *imageJob
+	if model.IsFileExtImage(filepath.Ext(filename)) {
+		// Only the start of the image is needed to check its dimensions and orientation before it's stored
+		header := make([]byte, IMAGE_HEADER_SIZE)
+		n, _ := io.ReadFull(data, header)
+		header = header[:n]
+		data = io.MultiReader(bytes.NewReader(header), data)
 
-	for i := range files {
-		file, err := files[i].Open()
-		defer file.Close()
+		config, _, err := image.DecodeConfig(bytes.NewReader(header))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return nil, nil, model.NewLocAppError("uploadFile", "api.file.upload_file.image.app_error", nil, err.Error())
+		} else if config.Width*config.Height > MaxImageSize {
+			return nil, nil, model.NewLocAppError("uploadFile", "api.file.upload_file.large_image.app_error", nil, utils.T("api.file.file_upload.exceeds"))
 		}
 
-		buf := bytes.NewBuffer(nil)
-		io.Copy(buf, file)
+		// Get the image's orientation and ignore any errors since not all images will have orientation data
+		orientation, _ := getImageOrientation(header)
 
-		filename := filepath.Base(files[i].Filename)
+		// store the dimensions of the image as it's displayed once its orientation has been corrected
+		if orientation >= RotatedCWMirrored {
+			info.Width = config.Height
+			info.Height = config.Width
+		} else {
+			info.Width = config.Width
+			info.Height = config.Height
+		}
+		info.SetImagePaths()
 
-		uid := model.NewId()
+		job = &imageJob{info: info, orientation: orientation}
+	}
 
-		if model.IsFileExtImage(filepath.Ext(files[i].Filename)) {
-			imageNameList = append(imageNameList, uid+"/"+filename)
-			imageDataList = append(imageDataList, buf.Bytes())
+	var frames chan int
+	var framesWriter *io.PipeWriter
+	if info.MimeType == "image/gif" {
+		// animated gifs are shown as they are instead of with a preview image
+		pr, pw := io.Pipe()
+		data = io.TeeReader(data, pw)
+		framesWriter = pw
 
-			// Decode image config first to check dimensions before loading the whole thing into memory later on
-			config, _, err := image.DecodeConfig(bytes.NewReader(buf.Bytes()))
-			if err != nil {
-				c.Err = model.NewLocAppError("uploadFile", "api.file.upload_file.image.app_error", nil, err.Error())
-				return
-			} else if config.Width*config.Height > MaxImageSize {
-				c.Err = model.NewLocAppError("uploadFile", "api.file.upload_file.large_image.app_error", nil, c.T("api.file.file_upload.exceeds"))
-				return
-			}
+		frames = make(chan int, 1)
+		go func() {
+			count, _ := countGifFrames(pr)
+			io.Copy(ioutil.Discard, pr)
+			frames <- count
+		}()
+	}
+
+	err = writeFileStream(info, data, reservation.Size)
+
+	if frames != nil {
+		framesWriter.Close()
+		info.HasPreviewImage = <-frames == 1
+	}
+
+	if err != nil {
+		if err.StatusCode == http.StatusRequestEntityTooLarge && reservation.Err != nil {
+			return nil, nil, reservation.Err
 		}
 
-		path := "teams/" + c.TeamId + "/channels/" + channelId + "/users/" + c.Session.UserId + "/" + uid + "/" + filename
+		return nil, nil, err
+	}
 
-		if err := WriteFile(buf.Bytes(), path); err != nil {
-			c.Err = err
-			return
+	if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
+		RemoveFile(info.Path)
+		return nil, nil, result.Err
+	}
+
+	return info, job, nil
+}
+
+// removeUploadedFiles deletes files that were uploaded as part of a request that later failed, along with their
+// FileInfos
+func removeUploadedFiles(infos []*model.FileInfo) {
+	for _, info := range infos {
+		if result := <-Srv.Store.FileInfo().PermanentDeleteOrphan(info.Id); result.Err != nil {
+			l4g.Error(utils.T("api.file.upload_file.remove.error"), info.Id, result.Err)
+			continue
 		}
 
-		encName := utils.UrlEncode(filename)
+		if err := RemoveFile(info.Path); err != nil {
+			l4g.Error(utils.T("api.file.upload_file.remove.error"), info.Id, err)
+		}
+	}
+}
 
-		fileUrl := "/" + channelId + "/" + c.Session.UserId + "/" + uid + "/" + encName
-		resStruct.Filenames = append(resStruct.Filenames, fileUrl)
+// writeFileStream copies a file of up to maxFileSize bytes to storage and sets its size and hash. Nothing is left in
+// storage if it fails.
+func writeFileStream(info *model.FileInfo, data io.Reader, maxFileSize int64) *model.AppError {
+	backend, err := GetFileBackend()
+	if err != nil {
+		return err
 	}
 
-	for _, clientId := range props["client_ids"] {
-		resStruct.ClientIds = append(resStruct.ClientIds, clientId)
+	writer, err := backend.Writer(info.Path)
+	if err != nil {
+		return err
 	}
 
-	go handleImages(imageNameList, imageDataList, c.TeamId, channelId, c.Session.UserId)
+	hash := sha256.New()
 
-	w.Write([]byte(resStruct.ToJson()))
+	size, copyErr := io.Copy(io.MultiWriter(writer, hash), io.LimitReader(data, maxFileSize+1))
+	closeErr := writer.Close()
+
+	if copyErr != nil || closeErr != nil || size > maxFileSize {
+		backend.Remove(info.Path)
+
+		if size > maxFileSize {
+			err := model.NewLocAppError("uploadFile", "api.file.upload_file.too_large.app_error", nil, "")
+			err.StatusCode = http.StatusRequestEntityTooLarge
+			return err
+		} else if copyErr != nil {
+			return model.NewLocAppError("uploadFile", "api.file.upload_file.write.app_error", nil, copyErr.Error())
+		} else {
+			return model.NewLocAppError("uploadFile", "api.file.upload_file.write.app_error", nil, closeErr.Error())
+		}
+	}
+
+	info.Size = size
+	info.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	return nil
 }
 
-func handleImages(filenames []string, fileData [][]byte, teamId, channelId, userId string) {
-	dest := "teams/" + teamId + "/channels/" + channelId + "/users/" + userId + "/"
+func readUploadField(part *multipart.Part) string {
+	value, _ := ioutil.ReadAll(io.LimitReader(part, UPLOAD_FIELD_MAX_SIZE))
+	return string(value)
+}
 
-	for i, filename := range filenames {
-		name := filename[:strings.LastIndex(filename, ".")]
-		go func() {
-			// Decode image bytes into Image object
-			img, imgType, err := image.Decode(bytes.NewReader(fileData[i]))
-			if err != nil {
-				l4g.Error(utils.T("api.file.handle_images_forget.decode.error"), channelId, userId, filename, err)
-				return
-			}
+type spooledUpload struct {
+	Filename string
+	File     *os.File
+}
 
-			width := img.Bounds().Dx()
-			height := img.Bounds().Dy()
+// spoolUpload copies a file to a temporary file on disk so that it can be uploaded later without holding it in memory
+func spoolUpload(filename string, data io.Reader) (*spooledUpload, *model.AppError) {
+	file, err := ioutil.TempFile("", "upload")
+	if err != nil {
+		return nil, model.NewLocAppError("uploadFile", "api.file.upload_file.spool.app_error", nil, err.Error())
+	}
 
-			// Get the image's orientation and ignore any errors since not all images will have orientation data
-			orientation, _ := getImageOrientation(fileData[i])
+	upload := &spooledUpload{Filename: filename, File: file}
 
-			if imgType == "png" {
-				dst := image.NewRGBA(img.Bounds())
-				draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
-				draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
-				img = dst
-			}
+	maxFileSize := *utils.Cfg.FileSettings.MaxFileSize
+	if size, err := io.Copy(file, io.LimitReader(data, maxFileSize+1)); err != nil {
+		upload.Remove()
+		return nil, model.NewLocAppError("uploadFile", "api.file.upload_file.spool.app_error", nil, err.Error())
+	} else if size > maxFileSize {
+		upload.Remove()
+		err := model.NewLocAppError("uploadFile", "api.file.upload_file.too_large.app_error", nil, "")
+		err.StatusCode = http.StatusRequestEntityTooLarge
+		return nil, err
+	}
 
-			switch orientation {
-			case UprightMirrored:
-				img = imaging.FlipH(img)
-			case UpsideDown:
-				img = imaging.Rotate180(img)
-			case UpsideDownMirrored:
-				img = imaging.FlipV(img)
-			case RotatedCWMirrored:
-				img = imaging.Transpose(img)
-			case RotatedCCW:
-				img = imaging.Rotate270(img)
-			case RotatedCCWMirrored:
-				img = imaging.Transverse(img)
-			case RotatedCW:
-				img = imaging.Rotate90(img)
-			}
+	if _, err := file.Seek(0, 0); err != nil {
+		upload.Remove()
+		return nil, model.NewLocAppError("uploadFile", "api.file.upload_file.spool.app_error", nil, err.Error())
+	}
 
-			// Create thumbnail
-			go func() {
-				thumbWidth := float64(utils.Cfg.FileSettings.ThumbnailWidth)
-				thumbHeight := float64(utils.Cfg.FileSettings.ThumbnailHeight)
-				imgWidth := float64(width)
-				imgHeight := float64(height)
-
-				var thumbnail image.Image
-				if imgHeight < thumbHeight && imgWidth < thumbWidth {
-					thumbnail = img
-				} else if imgHeight/imgWidth < thumbHeight/thumbWidth {
-					thumbnail = imaging.Resize(img, 0, utils.Cfg.FileSettings.ThumbnailHeight, imaging.Lanczos)
-				} else {
-					thumbnail = imaging.Resize(img, utils.Cfg.FileSettings.ThumbnailWidth, 0, imaging.Lanczos)
-				}
+	return upload, nil
+}
 
-				buf := new(bytes.Buffer)
-				err = jpeg.Encode(buf, thumbnail, &jpeg.Options{Quality: 90})
-				if err != nil {
-					l4g.Error(utils.T("api.file.handle_images_forget.encode_jpeg.error"), channelId, userId, filename, err)
-					return
-				}
+func (upload *spooledUpload) Remove() {
+	upload.File.Close()
+	os.Remove(upload.File.Name())
+}
 
-				if err := WriteFile(buf.Bytes(), dest+name+"_thumb.jpg"); err != nil {
-					l4g.Error(utils.T("api.file.handle_images_forget.upload_thumb.error"), channelId, userId, filename, err)
-					return
-				}
-			}()
-
-			// Create preview
-			go func() {
-				var preview image.Image
-				if width > int(utils.Cfg.FileSettings.PreviewWidth) {
-					preview = imaging.Resize(img, utils.Cfg.FileSettings.PreviewWidth, utils.Cfg.FileSettings.PreviewHeight, imaging.Lanczos)
-				} else {
-					preview = img
-				}
+// countGifFrames reads through a gif and returns the number of images in it without decoding any of them
+func countGifFrames(r io.Reader) (int, error) {
+	reader := bufio.NewReader(r)
 
-				buf := new(bytes.Buffer)
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, err
+	}
 
-				err = jpeg.Encode(buf, preview, &jpeg.Options{Quality: 90})
-				if err != nil {
-					l4g.Error(utils.T("api.file.handle_images_forget.encode_preview.error"), channelId, userId, filename, err)
-					return
-				}
+	// skip the global color table
+	if header[10]&0x80 != 0 {
+		if _, err := reader.Discard(3 * (1 << (uint(header[10]&0x07) + 1))); err != nil {
+			return 0, err
+		}
+	}
 
-				if err := WriteFile(buf.Bytes(), dest+name+"_preview.jpg"); err != nil {
-					l4g.Error(utils.T("api.file.handle_images_forget.upload_preview.error"), channelId, userId, filename, err)
-					return
-				}
-			}()
-		}()
+	frames := 0
+	for {
+		block, err := reader.ReadByte()
+		if err != nil {
+			return frames, err
+		}
+
+		switch block {
+		case 0x21: // extension
+			if _, err := reader.Discard(1); err != nil {
+				return frames, err
+			}
+		case 0x2C: // image descriptor
+			frames++
+
+			descriptor := make([]byte, 9)
+			if _, err := io.ReadFull(reader, descriptor); err != nil {
+				return frames, err
+			}
+
+			// skip the local color table and the LZW minimum code size
+			skip := 1
+			if descriptor[8]&0x80 != 0 {
+				skip += 3 * (1 << (uint(descriptor[8]&0x07) + 1))
+			}
+			if _, err := reader.Discard(skip); err != nil {
+				return frames, err
+			}
+		case 0x3B: // trailer
+			return frames, nil
+		default:
+			return frames, errors.New("invalid gif block")
+		}
+
+		// skip the data sub-blocks that follow
+		for {
+			size, err := reader.ReadByte()
+			if err != nil {
+				return frames, err
+			} else if size == 0 {
+				break
+			}
+
+			if _, err := reader.Discard(int(size)); err != nil {
+				return frames, err
+			}
+		}
 	}
 }
 
@@ -325,18 +507,12 @@ func getFileInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	if cached, ok := fileInfoCache.Get(path); ok {
 		info = cached.(*model.FileInfo)
+	} else if newInfo, err := getFileInfoForPath(path, channelId, userId, filename); err != nil {
+		c.Err = err
+		return
 	} else {
-		fileData := make(chan []byte)
-		go readFile(path, fileData)
-
-		newInfo, err := model.GetInfoForBytes(filename, <-fileData)
-		if err != nil {
-			c.Err = err
-			return
-		} else {
-			fileInfoCache.Add(path, newInfo)
-			info = newInfo
-		}
+		fileInfoCache.Add(path, newInfo)
+		info = newInfo
 	}
 
 	if !c.HasPermissionsToChannel(cchan, "getFileInfo") {
@@ -348,6 +524,60 @@ func getFileInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(info.ToJson()))
 }
 
+// getFileInfoForPath returns the stored FileInfo for a file. Anything that couldn't be worked out when the FileInfo was
+// migrated from a post's Filenames is filled in from the file itself, as is a FileInfo for a file that doesn't have one.
+func getFileInfoForPath(path, channelId, userId, filename string) (*model.FileInfo, *model.AppError) {
+	var info *model.FileInfo
+	if result := <-Srv.Store.FileInfo().GetByPath(path); result.Err == nil {
+		info = result.Data.(*model.FileInfo)
+	} else if split := strings.SplitN(filename, "/", 2); len(split) == 2 && len(split[0]) == 26 {
+		// files in direct channels are migrated without a path since they don't belong to a team
+		if result := <-Srv.Store.FileInfo().Get(split[0]); result.Err == nil {
+			if found := result.Data.(*model.FileInfo); found.Path == "" && found.ChannelId == channelId && found.CreatorId == userId {
+				info = found
+			}
+		}
+	}
+
+	// the migration from post filenames only fills in what can be found without reading the file
+	if info != nil && info.Path != "" && info.Size > 0 && (!info.IsImage() || info.Width > 0) {
+		return info, nil
+	}
+
+	data, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	newInfo, err := model.GetInfoForBytes(filepath.Base(path), data)
+	if err != nil {
+		return nil, err
+	}
+
+	if info == nil {
+		newInfo.Path = path
+		return newInfo, nil
+	}
+
+	info.Path = path
+	info.Size = newInfo.Size
+	info.HasPreviewImage = newInfo.HasPreviewImage
+	if info.IsImage() {
+		info.SetImagePaths()
+
+		if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			info.Width = config.Width
+			info.Height = config.Height
+		}
+	}
+
+	if result := <-Srv.Store.FileInfo().Update(info); result.Err != nil {
+		l4g.Error(utils.T("api.file.get_file_info.update.error"), info.Id, result.Err)
+	}
+
+	return info, nil
+}
+
 func getFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -528,181 +758,120 @@ func getPublicLink(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.StringToJson(url)))
 }
 
-func getExport(c *Context, w http.ResponseWriter, r *http.Request) {
+func canExport(c *Context, where string) bool {
 	if !c.HasPermissionsToTeam(c.TeamId, "export") || !c.IsTeamAdmin() {
-		c.Err = model.NewLocAppError("getExport", "api.file.get_export.team_admin.app_error", nil, "userId="+c.Session.UserId)
+		c.Err = model.NewLocAppError(where, "api.file.get_export.team_admin.app_error", nil, "userId="+c.Session.UserId)
 		c.Err.StatusCode = http.StatusForbidden
-		return
-	}
-	data, err := ReadFile(EXPORT_PATH + EXPORT_FILENAME)
-	if err != nil {
-		c.Err = model.NewLocAppError("getExport", "api.file.get_export.retrieve.app_error", nil, err.Error())
-		return
+		return false
 	}
 
-	w.Header().Set("Content-Disposition", "attachment; filename="+EXPORT_FILENAME)
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Write(data)
+	return true
 }
 
-func WriteFile(f []byte, path string) *model.AppError {
-
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		var auth aws.Auth
-		auth.AccessKey = utils.Cfg.FileSettings.AmazonS3AccessKeyId
-		auth.SecretKey = utils.Cfg.FileSettings.AmazonS3SecretAccessKey
-
-		s := s3.New(auth, awsRegion())
-		bucket := s.Bucket(utils.Cfg.FileSettings.AmazonS3Bucket)
-
-		ext := filepath.Ext(path)
-
-		var err error
-		if model.IsFileExtImage(ext) {
-			options := s3.Options{}
-			err = bucket.Put(path, f, model.GetImageMimeType(ext), s3.Private, options)
-
-		} else {
-			options := s3.Options{}
-			err = bucket.Put(path, f, "binary/octet-stream", s3.Private, options)
-		}
+// getExport downloads the team's most recent successful export
+func getExport(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !canExport(c, "getExport") {
+		return
+	}
 
-		if err != nil {
-			return model.NewLocAppError("WriteFile", "api.file.write_file.s3.app_error", nil, err.Error())
-		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := WriteFileLocally(f, utils.Cfg.FileSettings.Directory+path); err != nil {
-			return err
-		}
+	if result := <-Srv.Store.ExportJob().GetLastSuccessful(c.TeamId); result.Err != nil {
+		c.Err = model.NewLocAppError("getExport", "api.file.get_export.retrieve.app_error", nil, result.Err.Error())
+		c.Err.StatusCode = http.StatusNotFound
 	} else {
-		return model.NewLocAppError("WriteFile", "api.file.write_file.configured.app_error", nil, "")
+		writeExportFile(c, w, result.Data.(*model.ExportJob))
 	}
-
-	return nil
 }
 
-func MoveFile(oldPath, newPath string) *model.AppError {
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		fileData := make(chan []byte)
-		go readFile(oldPath, fileData)
-		fileBytes := <-fileData
-
-		if fileBytes == nil {
-			return model.NewLocAppError("moveFile", "api.file.move_file.get_from_s3.app_error", nil, "")
-		}
-
-		var auth aws.Auth
-		auth.AccessKey = utils.Cfg.FileSettings.AmazonS3AccessKeyId
-		auth.SecretKey = utils.Cfg.FileSettings.AmazonS3SecretAccessKey
+// startExport queues an export of the team to be run in the background. The returned job can be polled until it's
+// finished and then downloaded.
+func startExport(c *Context, w http.ResponseWriter, r *http.Request) {
+	if len(utils.Cfg.FileSettings.DriverName) == 0 {
+		c.Err = model.NewLocAppError("startExport", "api.file.start_export.storage.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
 
-		s := s3.New(auth, awsRegion())
-		bucket := s.Bucket(utils.Cfg.FileSettings.AmazonS3Bucket)
+	if !canExport(c, "startExport") {
+		return
+	}
 
-		if err := bucket.Del(oldPath); err != nil {
-			return model.NewLocAppError("moveFile", "api.file.move_file.delete_from_s3.app_error", nil, err.Error())
-		}
+	options := model.ExportOptionsFromJson(r.Body)
+	options.TeamsToExport = []string{c.TeamId}
 
-		if err := WriteFile(fileBytes, newPath); err != nil {
-			return err
-		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
+	if err := options.IsValid(); err != nil {
+		c.Err = err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
 
-		if err := os.MkdirAll(filepath.Dir(utils.Cfg.FileSettings.Directory+newPath), 0774); err != nil {
-			return model.NewLocAppError("moveFile", "api.file.move_file.rename.app_error", nil, err.Error())
-		}
+	job := &model.ExportJob{
+		CreatorId: c.Session.UserId,
+		TeamId:    c.TeamId,
+		Options:   *options,
+	}
 
-		if err := os.Rename(utils.Cfg.FileSettings.Directory+oldPath, utils.Cfg.FileSettings.Directory+newPath); err != nil {
-			return model.NewLocAppError("moveFile", "api.file.move_file.rename.app_error", nil, err.Error())
-		}
+	if job, err := CreateExportJob(job); err != nil {
+		c.Err = err
 	} else {
-		return model.NewLocAppError("moveFile", "api.file.move_file.configured.app_error", nil, "")
+		c.LogAudit("job_id=" + job.Id)
+		w.Write([]byte(job.ToJson()))
 	}
-
-	return nil
 }
 
-func WriteFileLocally(f []byte, path string) *model.AppError {
-	if err := os.MkdirAll(filepath.Dir(path), 0774); err != nil {
-		directory, _ := filepath.Abs(filepath.Dir(path))
-		return model.NewLocAppError("WriteFile", "api.file.write_file_locally.create_dir.app_error", nil, "directory="+directory+", err="+err.Error())
+func getExportJobForTeam(c *Context, r *http.Request, where string) *model.ExportJob {
+	if !canExport(c, where) {
+		return nil
 	}
 
-	if err := ioutil.WriteFile(path, f, 0644); err != nil {
-		return model.NewLocAppError("WriteFile", "api.file.write_file_locally.writing.app_error", nil, err.Error())
+	jobId := mux.Vars(r)["job_id"]
+	if len(jobId) != 26 {
+		c.SetInvalidParam(where, "job_id")
+		return nil
 	}
 
-	return nil
-}
-
-func ReadFile(path string) ([]byte, *model.AppError) {
-
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		var auth aws.Auth
-		auth.AccessKey = utils.Cfg.FileSettings.AmazonS3AccessKeyId
-		auth.SecretKey = utils.Cfg.FileSettings.AmazonS3SecretAccessKey
-
-		s := s3.New(auth, awsRegion())
-		bucket := s.Bucket(utils.Cfg.FileSettings.AmazonS3Bucket)
-
-		// try to get the file from S3 with some basic retry logic
-		tries := 0
-		for {
-			tries++
-
-			f, err := bucket.Get(path)
-
-			if f != nil {
-				return f, nil
-			} else if tries >= 3 {
-				return nil, model.NewLocAppError("ReadFile", "api.file.read_file.get.app_error", nil, "path="+path+", err="+err.Error())
-			}
-			time.Sleep(3000 * time.Millisecond)
-		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if f, err := ioutil.ReadFile(utils.Cfg.FileSettings.Directory + path); err != nil {
-			return nil, model.NewLocAppError("ReadFile", "api.file.read_file.reading_local.app_error", nil, err.Error())
-		} else {
-			return f, nil
-		}
+	if result := <-Srv.Store.ExportJob().Get(jobId); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusNotFound
+		return nil
+	} else if job := result.Data.(*model.ExportJob); job.TeamId != c.TeamId {
+		c.Err = model.NewLocAppError(where, "api.file.get_export_job.team.app_error", nil, "job_id="+jobId)
+		c.Err.StatusCode = http.StatusNotFound
+		return nil
 	} else {
-		return nil, model.NewLocAppError("ReadFile", "api.file.read_file.configured.app_error", nil, "")
+		return job
 	}
 }
 
-func openFileWriteStream(path string) (io.Writer, *model.AppError) {
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		return nil, model.NewLocAppError("openFileWriteStream", "api.file.open_file_write_stream.s3.app_error", nil, "")
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := os.MkdirAll(filepath.Dir(utils.Cfg.FileSettings.Directory+path), 0774); err != nil {
-			return nil, model.NewLocAppError("openFileWriteStream", "api.file.open_file_write_stream.creating_dir.app_error", nil, err.Error())
-		}
-
-		if fileHandle, err := os.Create(utils.Cfg.FileSettings.Directory + path); err != nil {
-			return nil, model.NewLocAppError("openFileWriteStream", "api.file.open_file_write_stream.local_server.app_error", nil, err.Error())
-		} else {
-			fileHandle.Chmod(0644)
-			return fileHandle, nil
-		}
+func getExportJob(c *Context, w http.ResponseWriter, r *http.Request) {
+	if job := getExportJobForTeam(c, r, "getExportJob"); job != nil {
+		w.Write([]byte(job.ToJson()))
+	}
+}
 
+func downloadExport(c *Context, w http.ResponseWriter, r *http.Request) {
+	job := getExportJobForTeam(c, r, "downloadExport")
+	if job == nil {
+		return
 	}
 
-	return nil, model.NewLocAppError("openFileWriteStream", "api.file.open_file_write_stream.configured.app_error", nil, "")
-}
+	if job.Status != model.EXPORT_JOB_STATUS_SUCCESS {
+		c.Err = model.NewLocAppError("downloadExport", "api.file.download_export.unfinished.app_error", nil, "job_id="+job.Id+", status="+job.Status)
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
 
-func closeFileWriteStream(file io.Writer) {
-	file.(*os.File).Close()
+	writeExportFile(c, w, job)
 }
 
-func awsRegion() aws.Region {
-	if region, ok := aws.Regions[utils.Cfg.FileSettings.AmazonS3Region]; ok {
-		return region
+func writeExportFile(c *Context, w http.ResponseWriter, job *model.ExportJob) {
+	reader, err := ReadFileStream(job.Path)
+	if err != nil {
+		c.Err = model.NewLocAppError("writeExportFile", "api.file.get_export.retrieve.app_error", nil, err.Error())
+		return
 	}
+	defer reader.Close()
 
-	return aws.Region{
-		Name:                 utils.Cfg.FileSettings.AmazonS3Region,
-		S3Endpoint:           utils.Cfg.FileSettings.AmazonS3Endpoint,
-		S3BucketEndpoint:     utils.Cfg.FileSettings.AmazonS3BucketEndpoint,
-		S3LocationConstraint: *utils.Cfg.FileSettings.AmazonS3LocationConstraint,
-		S3LowercaseBucket:    *utils.Cfg.FileSettings.AmazonS3LowercaseBucket,
-	}
+	w.Header().Set("Content-Disposition", "attachment; filename="+filepath.Base(job.Path))
+	w.Header().Set("Content-Type", "application/zip")
+	io.Copy(w, reader)
 }
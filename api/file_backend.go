@@ -0,0 +1,103 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// FileBackend stores files under slash separated paths such as teams/{team_id}/channels/{channel_id}/...
+type FileBackend interface {
+	Read(path string) ([]byte, *model.AppError)
+	Reader(path string) (io.ReadCloser, *model.AppError)
+	// Size returns the size of the file in bytes without reading it
+	Size(path string) (int64, *model.AppError)
+	Write(data []byte, path string) *model.AppError
+	Writer(path string) (io.WriteCloser, *model.AppError)
+	Move(oldPath, newPath string) *model.AppError
+	Remove(path string) *model.AppError
+	// List returns the paths of all of the files under the given directory
+	List(path string) ([]string, *model.AppError)
+}
+
+type FileBackendFactory func(settings *model.FileSettings) FileBackend
+
+var fileBackendFactories = map[string]FileBackendFactory{
+	model.IMAGE_DRIVER_LOCAL: func(settings *model.FileSettings) FileBackend {
+		return NewLocalFileBackend(settings.Directory)
+	},
+	model.IMAGE_DRIVER_S3: func(settings *model.FileSettings) FileBackend {
+		return NewS3FileBackend(settings)
+	},
+}
+
+var fileBackendOverride FileBackend
+
+// RegisterFileBackend makes a storage backend available under the given FileSettings.DriverName
+func RegisterFileBackend(driverName string, factory FileBackendFactory) {
+	fileBackendFactories[driverName] = factory
+}
+
+// SetFileBackend replaces the configured backend with the given one until it's called again with nil
+func SetFileBackend(backend FileBackend) {
+	fileBackendOverride = backend
+}
+
+func GetFileBackend() (FileBackend, *model.AppError) {
+	if fileBackendOverride != nil {
+		return fileBackendOverride, nil
+	}
+
+	if factory, ok := fileBackendFactories[utils.Cfg.FileSettings.DriverName]; ok {
+		return factory(&utils.Cfg.FileSettings), nil
+	}
+
+	err := model.NewLocAppError("GetFileBackend", "api.file.file_backend.configured.app_error", nil, "driver_name="+utils.Cfg.FileSettings.DriverName)
+	err.StatusCode = http.StatusNotImplemented
+	return nil, err
+}
+
+func WriteFile(f []byte, path string) *model.AppError {
+	if backend, err := GetFileBackend(); err != nil {
+		return err
+	} else {
+		return backend.Write(f, path)
+	}
+}
+
+func ReadFile(path string) ([]byte, *model.AppError) {
+	if backend, err := GetFileBackend(); err != nil {
+		return nil, err
+	} else {
+		return backend.Read(path)
+	}
+}
+
+func ReadFileStream(path string) (io.ReadCloser, *model.AppError) {
+	if backend, err := GetFileBackend(); err != nil {
+		return nil, err
+	} else {
+		return backend.Reader(path)
+	}
+}
+
+func MoveFile(oldPath, newPath string) *model.AppError {
+	if backend, err := GetFileBackend(); err != nil {
+		return err
+	} else {
+		return backend.Move(oldPath, newPath)
+	}
+}
+
+func RemoveFile(path string) *model.AppError {
+	if backend, err := GetFileBackend(); err != nil {
+		return err
+	} else {
+		return backend.Remove(path)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+type LocalFileBackend struct {
+	directory string
+}
+
+func NewLocalFileBackend(directory string) *LocalFileBackend {
+	return &LocalFileBackend{directory: directory}
+}
+
+// fullPath returns where a file is stored on disk, making sure that the path doesn't lead out of the directory
+func (b *LocalFileBackend) fullPath(path string) (string, *model.AppError) {
+	directory := filepath.Clean(b.directory)
+	fullPath := filepath.Join(directory, filepath.FromSlash(path))
+
+	if rel, err := filepath.Rel(directory, fullPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", model.NewLocAppError("LocalFileBackend.fullPath", "api.file.local.invalid_path.app_error", nil, "path="+path)
+	}
+
+	return fullPath, nil
+}
+
+func (b *LocalFileBackend) Read(path string) ([]byte, *model.AppError) {
+	fullPath, appErr := b.fullPath(path)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if f, err := ioutil.ReadFile(fullPath); err != nil {
+		return nil, model.NewLocAppError("ReadFile", "api.file.read_file.reading_local.app_error", nil, err.Error())
+	} else {
+		return f, nil
+	}
+}
+
+func (b *LocalFileBackend) Reader(path string) (io.ReadCloser, *model.AppError) {
+	fullPath, appErr := b.fullPath(path)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if f, err := os.Open(fullPath); err != nil {
+		return nil, model.NewLocAppError("Reader", "api.file.read_file.reading_local.app_error", nil, err.Error())
+	} else {
+		return f, nil
+	}
+}
+
+func (b *LocalFileBackend) Size(path string) (int64, *model.AppError) {
+	fullPath, appErr := b.fullPath(path)
+	if appErr != nil {
+		return 0, appErr
+	}
+
+	if info, err := os.Stat(fullPath); err != nil {
+		return 0, model.NewLocAppError("Size", "api.file.read_file.reading_local.app_error", nil, err.Error())
+	} else {
+		return info.Size(), nil
+	}
+}
+
+func (b *LocalFileBackend) Write(data []byte, path string) *model.AppError {
+	fullPath, appErr := b.fullPath(path)
+	if appErr != nil {
+		return appErr
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0774); err != nil {
+		directory, _ := filepath.Abs(filepath.Dir(fullPath))
+		return model.NewLocAppError("WriteFile", "api.file.write_file_locally.create_dir.app_error", nil, "directory="+directory+", err="+err.Error())
+	}
+
+	if err := ioutil.WriteFile(fullPath, data, 0644); err != nil {
+		return model.NewLocAppError("WriteFile", "api.file.write_file_locally.writing.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+func (b *LocalFileBackend) Writer(path string) (io.WriteCloser, *model.AppError) {
+	fullPath, appErr := b.fullPath(path)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0774); err != nil {
+		return nil, model.NewLocAppError("Writer", "api.file.open_file_write_stream.creating_dir.app_error", nil, err.Error())
+	}
+
+	if fileHandle, err := os.Create(fullPath); err != nil {
+		return nil, model.NewLocAppError("Writer", "api.file.open_file_write_stream.local_server.app_error", nil, err.Error())
+	} else {
+		fileHandle.Chmod(0644)
+		return fileHandle, nil
+	}
+}
+
+func (b *LocalFileBackend) Move(oldPath, newPath string) *model.AppError {
+	oldFullPath, appErr := b.fullPath(oldPath)
+	if appErr != nil {
+		return appErr
+	}
+
+	newFullPath, appErr := b.fullPath(newPath)
+	if appErr != nil {
+		return appErr
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newFullPath), 0774); err != nil {
+		return model.NewLocAppError("moveFile", "api.file.move_file.rename.app_error", nil, err.Error())
+	}
+
+	if err := os.Rename(oldFullPath, newFullPath); err != nil {
+		return model.NewLocAppError("moveFile", "api.file.move_file.rename.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+func (b *LocalFileBackend) Remove(path string) *model.AppError {
+	fullPath, appErr := b.fullPath(path)
+	if appErr != nil {
+		return appErr
+	}
+
+	if err := os.Remove(fullPath); err != nil {
+		return model.NewLocAppError("RemoveFile", "api.file.remove_file.local.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+func (b *LocalFileBackend) List(path string) ([]string, *model.AppError) {
+	paths := []string{}
+
+	root, appErr := b.fullPath(path)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return paths, nil
+	}
+
+	err := filepath.Walk(root, func(fullPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			if rel, err := filepath.Rel(b.directory, fullPath); err != nil {
+				return err
+			} else {
+				paths = append(paths, filepath.ToSlash(rel))
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, model.NewLocAppError("ListFiles", "api.file.list_files.local.app_error", nil, err.Error())
+	}
+
+	return paths, nil
+}
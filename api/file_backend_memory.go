@@ -0,0 +1,135 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/platform/model"
+)
+
+// MemoryFileBackend keeps files in memory so that tests can run without a disk or S3
+type MemoryFileBackend struct {
+	mutex sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMemoryFileBackend() *MemoryFileBackend {
+	return &MemoryFileBackend{files: make(map[string][]byte)}
+}
+
+func cleanMemoryPath(path string) string {
+	return strings.TrimPrefix(path, "/")
+}
+
+func (b *MemoryFileBackend) Read(path string) ([]byte, *model.AppError) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if data, ok := b.files[cleanMemoryPath(path)]; !ok {
+		return nil, model.NewLocAppError("ReadFile", "api.file.read_file.memory.app_error", nil, "path="+path)
+	} else {
+		return append([]byte(nil), data...), nil
+	}
+}
+
+func (b *MemoryFileBackend) Reader(path string) (io.ReadCloser, *model.AppError) {
+	if data, err := b.Read(path); err != nil {
+		return nil, err
+	} else {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+func (b *MemoryFileBackend) Size(path string) (int64, *model.AppError) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if data, ok := b.files[cleanMemoryPath(path)]; !ok {
+		return 0, model.NewLocAppError("Size", "api.file.read_file.memory.app_error", nil, "path="+path)
+	} else {
+		return int64(len(data)), nil
+	}
+}
+
+func (b *MemoryFileBackend) Write(data []byte, path string) *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.files[cleanMemoryPath(path)] = append([]byte(nil), data...)
+
+	return nil
+}
+
+func (b *MemoryFileBackend) Writer(path string) (io.WriteCloser, *model.AppError) {
+	return &memoryWriter{backend: b, path: path}, nil
+}
+
+func (b *MemoryFileBackend) Move(oldPath, newPath string) *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if data, ok := b.files[cleanMemoryPath(oldPath)]; !ok {
+		return model.NewLocAppError("moveFile", "api.file.read_file.memory.app_error", nil, "path="+oldPath)
+	} else {
+		delete(b.files, cleanMemoryPath(oldPath))
+		b.files[cleanMemoryPath(newPath)] = data
+	}
+
+	return nil
+}
+
+func (b *MemoryFileBackend) Remove(path string) *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.files[cleanMemoryPath(path)]; !ok {
+		return model.NewLocAppError("RemoveFile", "api.file.read_file.memory.app_error", nil, "path="+path)
+	}
+
+	delete(b.files, cleanMemoryPath(path))
+
+	return nil
+}
+
+func (b *MemoryFileBackend) List(path string) ([]string, *model.AppError) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	prefix := strings.TrimSuffix(cleanMemoryPath(path), "/") + "/"
+
+	paths := []string{}
+	for name := range b.files {
+		if strings.HasPrefix(name, prefix) {
+			paths = append(paths, name)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+type memoryWriter struct {
+	backend *MemoryFileBackend
+	path    string
+	buf     bytes.Buffer
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *memoryWriter) Close() error {
+	if err := w.backend.Write(w.buf.Bytes(), w.path); err != nil {
+		return err
+	}
+
+	return nil
+}
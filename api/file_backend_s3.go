@@ -0,0 +1,222 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/s3"
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	S3_READ_ATTEMPTS   = 3
+	S3_LIST_BATCH_SIZE = 1000
+	S3_PART_SIZE       = 5 * 1024 * 1024 // the smallest part S3 allows in a multipart upload
+)
+
+type S3FileBackend struct {
+	bucket *s3.Bucket
+}
+
+func NewS3FileBackend(settings *model.FileSettings) *S3FileBackend {
+	var auth aws.Auth
+	auth.AccessKey = settings.AmazonS3AccessKeyId
+	auth.SecretKey = settings.AmazonS3SecretAccessKey
+
+	s := s3.New(auth, awsRegion(settings))
+
+	return &S3FileBackend{bucket: s.Bucket(settings.AmazonS3Bucket)}
+}
+
+func awsRegion(settings *model.FileSettings) aws.Region {
+	if region, ok := aws.Regions[settings.AmazonS3Region]; ok {
+		return region
+	}
+
+	return aws.Region{
+		Name:                 settings.AmazonS3Region,
+		S3Endpoint:           settings.AmazonS3Endpoint,
+		S3BucketEndpoint:     settings.AmazonS3BucketEndpoint,
+		S3LocationConstraint: *settings.AmazonS3LocationConstraint,
+		S3LowercaseBucket:    *settings.AmazonS3LowercaseBucket,
+	}
+}
+
+func s3ContentType(path string) string {
+	if ext := filepath.Ext(path); model.IsFileExtImage(ext) {
+		return model.GetImageMimeType(ext)
+	}
+
+	return "binary/octet-stream"
+}
+
+func (b *S3FileBackend) Read(path string) ([]byte, *model.AppError) {
+	// try to get the file from S3 with some basic retry logic
+	tries := 0
+	for {
+		tries++
+
+		f, err := b.bucket.Get(path)
+
+		if f != nil {
+			return f, nil
+		} else if tries >= S3_READ_ATTEMPTS {
+			return nil, model.NewLocAppError("ReadFile", "api.file.read_file.get.app_error", nil, "path="+path+", err="+err.Error())
+		}
+		time.Sleep(3000 * time.Millisecond)
+	}
+}
+
+func (b *S3FileBackend) Reader(path string) (io.ReadCloser, *model.AppError) {
+	if reader, err := b.bucket.GetReader(path); err != nil {
+		return nil, model.NewLocAppError("Reader", "api.file.read_file.get.app_error", nil, "path="+path+", err="+err.Error())
+	} else {
+		return reader, nil
+	}
+}
+
+func (b *S3FileBackend) Size(path string) (int64, *model.AppError) {
+	if resp, err := b.bucket.Head(path, nil); err != nil {
+		return 0, model.NewLocAppError("Size", "api.file.read_file.get.app_error", nil, "path="+path+", err="+err.Error())
+	} else {
+		resp.Body.Close()
+		return resp.ContentLength, nil
+	}
+}
+
+func (b *S3FileBackend) Write(data []byte, path string) *model.AppError {
+	if err := b.bucket.Put(path, data, s3ContentType(path), s3.Private, s3.Options{}); err != nil {
+		return model.NewLocAppError("WriteFile", "api.file.write_file.s3.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+func (b *S3FileBackend) Writer(path string) (io.WriteCloser, *model.AppError) {
+	return &s3Writer{backend: b, path: path}, nil
+}
+
+func (b *S3FileBackend) Move(oldPath, newPath string) *model.AppError {
+	if _, err := b.bucket.PutCopy(newPath, s3.Private, s3.CopyOptions{ContentType: s3ContentType(newPath)}, s3CopySource(b.bucket.Name, oldPath)); err != nil {
+		return model.NewLocAppError("moveFile", "api.file.move_file.copy_within_s3.app_error", nil, err.Error())
+	}
+
+	if err := b.bucket.Del(oldPath); err != nil {
+		return model.NewLocAppError("moveFile", "api.file.move_file.delete_from_s3.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+// s3CopySource returns the url encoded bucket and key that S3 expects for the file being copied
+func s3CopySource(bucket, path string) string {
+	segments := strings.Split(bucket+"/"+path, "/")
+	for i, segment := range segments {
+		segments[i] = strings.Replace(url.QueryEscape(segment), "+", "%20", -1)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func (b *S3FileBackend) Remove(path string) *model.AppError {
+	if err := b.bucket.Del(path); err != nil {
+		return model.NewLocAppError("RemoveFile", "api.file.remove_file.s3.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+func (b *S3FileBackend) List(path string) ([]string, *model.AppError) {
+	paths := []string{}
+
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	marker := ""
+	for {
+		result, err := b.bucket.List(prefix, "", marker, S3_LIST_BATCH_SIZE)
+		if err != nil {
+			return nil, model.NewLocAppError("ListFiles", "api.file.list_files.s3.app_error", nil, err.Error())
+		}
+
+		for _, key := range result.Contents {
+			paths = append(paths, key.Key)
+		}
+
+		if !result.IsTruncated || len(result.Contents) == 0 {
+			return paths, nil
+		}
+
+		marker = result.Contents[len(result.Contents)-1].Key
+	}
+}
+
+// s3Writer uploads everything written to it as a multipart upload once there's enough data to fill a part.
+// Small files are uploaded in a single request when the writer is closed.
+type s3Writer struct {
+	backend *S3FileBackend
+	path    string
+	buf     bytes.Buffer
+	multi   *s3.Multi
+	parts   []s3.Part
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	n, _ := w.buf.Write(p)
+
+	for w.buf.Len() >= S3_PART_SIZE {
+		if err := w.putPart(w.buf.Next(S3_PART_SIZE)); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+func (w *s3Writer) putPart(data []byte) error {
+	if w.multi == nil {
+		if multi, err := w.backend.bucket.InitMulti(w.path, s3ContentType(w.path), s3.Private); err != nil {
+			return err
+		} else {
+			w.multi = multi
+		}
+	}
+
+	if part, err := w.multi.PutPart(len(w.parts)+1, bytes.NewReader(data)); err != nil {
+		w.multi.Abort()
+		return err
+	} else {
+		w.parts = append(w.parts, part)
+	}
+
+	return nil
+}
+
+func (w *s3Writer) Close() error {
+	if w.multi == nil {
+		if err := w.backend.Write(w.buf.Bytes(), w.path); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	if w.buf.Len() > 0 {
+		if err := w.putPart(w.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if err := w.multi.Complete(w.parts); err != nil {
+		w.multi.Abort()
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestLocalFileBackend(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	dir, err := ioutil.TempDir("", "files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testFileBackend(t, NewLocalFileBackend(dir+"/"))
+}
+
+func TestLocalFileBackendPaths(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	dir, err := ioutil.TempDir("", "filebackend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend := NewLocalFileBackend(dir + "/data/")
+
+	if err := backend.Write([]byte("hello"), "../escaped.txt"); err == nil {
+		t.Fatal("shouldn't be able to write outside of the directory")
+	} else if _, statErr := os.Stat(dir + "/escaped.txt"); statErr == nil {
+		t.Fatal("file shouldn't have been written")
+	}
+
+	if _, err := backend.Read("teams/../../data/../../etc/passwd"); err == nil {
+		t.Fatal("shouldn't be able to read outside of the directory")
+	}
+
+	if err := backend.Write([]byte("hello"), "teams/../file.txt"); err != nil {
+		t.Fatal("should be able to use a path that stays inside the directory", err)
+	}
+}
+
+func TestS3CopySource(t *testing.T) {
+	if source := s3CopySource("bucket", "teams/a b/c+d%e.txt"); source != "bucket/teams/a%20b/c%2Bd%25e.txt" {
+		t.Fatal("should've encoded the copy source", source)
+	}
+}
+
+func TestMemoryFileBackend(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	testFileBackend(t, NewMemoryFileBackend())
+}
+
+func testFileBackend(t *testing.T, backend FileBackend) {
+	path := "teams/" + model.NewId() + "/file.txt"
+
+	if err := backend.Write([]byte("hello"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := backend.Read(path); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "hello" {
+		t.Fatal("read the wrong data " + string(data))
+	}
+
+	if reader, err := backend.Reader(path); err != nil {
+		t.Fatal(err)
+	} else if data, err := ioutil.ReadAll(reader); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "hello" {
+		t.Fatal("read the wrong data " + string(data))
+	} else {
+		reader.Close()
+	}
+
+	if size, err := backend.Size(path); err != nil {
+		t.Fatal(err)
+	} else if size != 5 {
+		t.Fatal("got the wrong size", size)
+	}
+
+	streamPath := "teams/" + model.NewId() + "/stream.txt"
+	if writer, err := backend.Writer(streamPath); err != nil {
+		t.Fatal(err)
+	} else {
+		writer.Write([]byte("hello "))
+		writer.Write([]byte("world"))
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if data, err := backend.Read(streamPath); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "hello world" {
+		t.Fatal("streamed the wrong data " + string(data))
+	}
+
+	dir := "teams/" + model.NewId()
+	movedPath := dir + "/channels/moved.txt"
+	if err := backend.Move(path, movedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := backend.Read(path); err == nil {
+		t.Fatal("file should have been moved")
+	}
+
+	if data, err := backend.Read(movedPath); err != nil || string(data) != "hello" {
+		t.Fatal("file should have been moved", err)
+	}
+
+	backend.Write([]byte("other"), dir+"/other.txt")
+
+	if paths, err := backend.List(dir); err != nil {
+		t.Fatal(err)
+	} else if len(paths) != 2 {
+		t.Fatal("should have listed both files", paths)
+	} else {
+		for _, listed := range paths {
+			if listed != movedPath && listed != dir+"/other.txt" {
+				t.Fatal("listed the wrong path " + listed)
+			}
+		}
+	}
+
+	if paths, err := backend.List("teams/" + model.NewId()); err != nil {
+		t.Fatal(err)
+	} else if len(paths) != 0 {
+		t.Fatal("missing directory should be empty")
+	}
+
+	if err := backend.Remove(movedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := backend.Read(movedPath); err == nil {
+		t.Fatal("file should have been removed")
+	}
+
+	if err := backend.Remove(movedPath); err == nil {
+		t.Fatal("removing a missing file should fail")
+	}
+}
+
+func TestGetFileBackend(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	driverName := utils.Cfg.FileSettings.DriverName
+	defer func() {
+		utils.Cfg.FileSettings.DriverName = driverName
+	}()
+
+	utils.Cfg.FileSettings.DriverName = model.IMAGE_DRIVER_LOCAL
+	if backend, err := GetFileBackend(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := backend.(*LocalFileBackend); !ok {
+		t.Fatal("should have used the local backend")
+	}
+
+	utils.Cfg.FileSettings.DriverName = ""
+	if _, err := GetFileBackend(); err == nil {
+		t.Fatal("should have failed without a driver")
+	}
+
+	memory := NewMemoryFileBackend()
+	SetFileBackend(memory)
+	defer SetFileBackend(nil)
+
+	if err := WriteFile([]byte("data"), "test/file.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := memory.Read("test/file.txt"); err != nil || string(data) != "data" {
+		t.Fatal("should have written to the memory backend")
+	}
+}
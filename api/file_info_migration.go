@@ -0,0 +1,92 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	FILE_INFO_MIGRATION_TASK           = "MigrateFilenamesToFileInfos"
+	FILE_INFO_MIGRATION_RETRY_INTERVAL = 5 * time.Minute
+)
+
+var fileInfoMigrationStop chan bool
+
+// StartFileInfoMigration migrates the files attached to posts by their filenames to file infos in the background.
+// Only one server in a cluster runs the migration, and the others keep checking until it's done in case that server
+// stops before it finishes.
+func StartFileInfoMigration() {
+	if fileInfoMigrationStop != nil {
+		return
+	}
+
+	stop := make(chan bool)
+	fileInfoMigrationStop = stop
+
+	go func() {
+		for !migrateFilenamesToFileInfos() {
+			select {
+			case <-time.After(FILE_INFO_MIGRATION_RETRY_INTERVAL):
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func StopFileInfoMigration() {
+	if fileInfoMigrationStop != nil {
+		close(fileInfoMigrationStop)
+		fileInfoMigrationStop = nil
+	}
+}
+
+// migrateFilenamesToFileInfos runs the migration unless it's already done or another server is running it and
+// returns whether it's done
+func migrateFilenamesToFileInfos() bool {
+	if result := <-Srv.Store.System().Get(); result.Err != nil {
+		l4g.Error(utils.T("api.file.migrate_filenames.error"), result.Err)
+		return false
+	} else if _, ok := result.Data.(model.StringMap)[store.MIGRATION_KEY_FILE_INFOS]; ok {
+		return true
+	}
+
+	done := false
+	if _, err := runLeasedTask(FILE_INFO_MIGRATION_TASK, model.GetMillis()+1, func() {
+		if result := <-Srv.Store.FileInfo().MigrateFilenamesToFileInfos(fileInfoMigrationSize()); result.Err != nil {
+			l4g.Error(utils.T("api.file.migrate_filenames.error"), result.Err)
+		} else {
+			done = true
+		}
+	}); err != nil {
+		l4g.Error(utils.T("api.file.migrate_filenames.error"), err)
+	}
+
+	return done
+}
+
+// fileInfoMigrationSize returns a function that looks up the size of a migrated file, leaving it empty to be filled
+// in when the file is first requested if it can't be found
+func fileInfoMigrationSize() func(info *model.FileInfo) int64 {
+	backend, err := GetFileBackend()
+	if err != nil {
+		return func(info *model.FileInfo) int64 {
+			return 0
+		}
+	}
+
+	return func(info *model.FileInfo) int64 {
+		if size, err := backend.Size(info.Path); err != nil {
+			return 0
+		} else {
+			return size
+		}
+	}
+}
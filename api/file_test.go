@@ -130,6 +130,41 @@ func TestUploadFile(t *testing.T) {
 	}
 }
 
+func TestUploadFileCleanup(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Log("skipping because no file driver is enabled")
+		return
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("channel_id", th.BasicChannel.Id)
+	if part, err := writer.CreateFormFile("files", "test.txt"); err != nil {
+		t.Fatal(err)
+	} else {
+		part.Write([]byte("some text"))
+	}
+	if part, err := writer.CreateFormFile("files", "test.png"); err != nil {
+		t.Fatal(err)
+	} else {
+		part.Write([]byte("not an image"))
+	}
+	writer.Close()
+
+	if _, err := Client.UploadPostAttachment(body.Bytes(), writer.FormDataContentType()); err == nil {
+		t.Fatal("should've failed to upload an invalid image")
+	}
+
+	if result := <-Srv.Store.FileInfo().GetStorageUsedByUser(th.BasicUser.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if used := result.Data.(int64); used != 0 {
+		t.Fatal("files uploaded before the failure should've been removed", used)
+	}
+}
+
 func TestGetFile(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -487,3 +522,150 @@ func cleanupTestFile(fullFilename, teamId, channelId, userId string) error {
 
 	return nil
 }
+
+func TestCountGifFrames(t *testing.T) {
+	// base 64 encoded version of handtinywhite.gif from http://probablyprogramming.com/2009/03/15/the-tiniest-gif-ever
+	file, _ := base64.StdEncoding.DecodeString("R0lGODlhAQABAIABAP///wAAACwAAAAAAQABAAACAkQBADs=")
+	if frames, err := countGifFrames(bytes.NewReader(file)); err != nil {
+		t.Fatal(err)
+	} else if frames != 1 {
+		t.Fatal("should have counted a single frame", frames)
+	}
+
+	animated, err := os.Open(utils.FindDir("tests") + "/testgif.gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer animated.Close()
+
+	if frames, err := countGifFrames(animated); err != nil {
+		t.Fatal(err)
+	} else if frames < 2 {
+		t.Fatal("should have counted every frame", frames)
+	}
+
+	if _, err := countGifFrames(bytes.NewReader(file[:20])); err == nil {
+		t.Fatal("should have failed on a truncated gif")
+	}
+}
+
+func TestWriteFileStream(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	backend := NewMemoryFileBackend()
+	SetFileBackend(backend)
+	defer SetFileBackend(nil)
+
+	info := &model.FileInfo{Path: "teams/" + model.NewId() + "/file.txt"}
+	if err := writeFileStream(info, strings.NewReader("hello"), 10); err != nil {
+		t.Fatal(err)
+	} else if info.Size != 5 {
+		t.Fatal("should have counted the bytes written", info.Size)
+	} else if info.Hash != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatal("bad hash " + info.Hash)
+	}
+
+	if data, err := backend.Read(info.Path); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "hello" {
+		t.Fatal("should have stored the file")
+	}
+
+	tooLarge := &model.FileInfo{Path: "teams/" + model.NewId() + "/file.txt"}
+	if err := writeFileStream(tooLarge, strings.NewReader("hello world"), 10); err == nil {
+		t.Fatal("should have failed on a file that's too large")
+	} else if err.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatal("wrong status code", err.StatusCode)
+	}
+
+	if _, err := backend.Read(tooLarge.Path); err == nil {
+		t.Fatal("shouldn't have left part of the file in storage")
+	}
+}
+
+func TestStorageQuota(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Log("skipping because no file driver is enabled")
+		return
+	}
+
+	maxStoragePerUser := *utils.Cfg.FileSettings.MaxStoragePerUser
+	defer func() {
+		*utils.Cfg.FileSettings.MaxStoragePerUser = maxStoragePerUser
+	}()
+	*utils.Cfg.FileSettings.MaxStoragePerUser = 15
+
+	upload := func(data string) (*model.FileInfo, *model.AppError) {
+		session, err := Client.CreateUploadSession(channel.Id, "file.txt", int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := Client.UploadChunk(session.Id, 0, []byte(data)); err != nil {
+			return nil, err
+		}
+
+		if resp, err := Client.FinishUploadSession(session.Id); err != nil {
+			return nil, err
+		} else {
+			return resp.FileInfos[0], nil
+		}
+	}
+
+	info, err := upload("0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if usage, err := Client.GetStorageUsage(); err != nil {
+		t.Fatal(err)
+	} else if usage.UserUsed != 10 || usage.UserQuota != 15 || usage.TeamUsed != 10 {
+		t.Fatal("should've counted the uploaded file", usage)
+	}
+
+	if reservation, err := reserveStorageQuota(th.BasicUser.Id, th.BasicTeam.Id, 100); err != nil {
+		t.Fatal(err)
+	} else if reservation.Size != 5 || reservation.Err == nil {
+		t.Fatal("reservation should've been limited to the space left", reservation.Size)
+	} else {
+		if err := checkStorageQuota(th.BasicUser.Id, th.BasicTeam.Id, 1); err == nil {
+			t.Fatal("space reserved for another upload should count towards the quota")
+		}
+
+		reservation.Release()
+	}
+
+	if _, err := upload("0123456789"); err == nil {
+		t.Fatal("should've failed to upload past the quota")
+	} else if err.Id != "api.file.storage_quota.user.app_error" {
+		t.Fatal("wrong error", err.Id)
+	}
+
+	post := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "with file", FileIds: []string{info.Id}})).Data.(*model.Post)
+	Client.Must(Client.DeletePost(channel.Id, post.Id))
+
+	// files are deleted in the background
+	for i := 0; i < 20; i++ {
+		if usage, err := Client.GetStorageUsage(); err != nil {
+			t.Fatal(err)
+		} else if usage.UserUsed == 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if usage, err := Client.GetStorageUsage(); err != nil {
+		t.Fatal(err)
+	} else if usage.UserUsed != 0 || usage.TeamUsed != 0 {
+		t.Fatal("deleted files shouldn't count towards the quota", usage)
+	}
+
+	if _, err := upload("0123456789"); err != nil {
+		t.Fatal("should be able to upload again once files have been deleted", err)
+	}
+}
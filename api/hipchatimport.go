@@ -0,0 +1,489 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+type HipChatUser struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	MentionName string `json:"mention_name"`
+	Email       string `json:"email"`
+	IsDeleted   bool   `json:"is_deleted"`
+}
+
+type HipChatRoom struct {
+	Id           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Topic        string  `json:"topic"`
+	Privacy      string  `json:"privacy"`
+	Members      []int64 `json:"members"`
+	Participants []int64 `json:"participants"`
+}
+
+type HipChatMessage struct {
+	Id         string             `json:"id"`
+	Message    string             `json:"message"`
+	Sender     HipChatSender      `json:"sender"`
+	Receiver   HipChatSender      `json:"receiver"`
+	Timestamp  string             `json:"timestamp"`
+	Attachment *HipChatAttachment `json:"attachment"`
+}
+
+type HipChatSender struct {
+	Id int64 `json:"id"`
+}
+
+type HipChatAttachment struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Url  string `json:"url"`
+}
+
+const (
+	HIPCHAT_MAX_JSON_SIZE = 256 * 1024 * 1024 // each room's history is exported as a single file
+)
+
+// hipChatArchive holds the files from an export, which can either be a zip or a tar.gz
+type hipChatArchive struct {
+	files map[string]func() (io.ReadCloser, error)
+	spool *os.File // the entries of a tar.gz are copied here since a tar can only be read in order
+}
+
+type hipChatEntryReader struct {
+	io.Reader
+	io.Closer
+}
+
+func (a *hipChatArchive) has(name string) bool {
+	_, ok := a.files[path.Clean(name)]
+	return ok
+}
+
+func (a *hipChatArchive) open(name string) (io.ReadCloser, error) {
+	if open := a.files[path.Clean(name)]; open != nil {
+		return open()
+	}
+
+	return nil, fmt.Errorf("%v isn't in the archive", name)
+}
+
+func (a *hipChatArchive) Close() {
+	if a.spool != nil {
+		a.spool.Close()
+		os.Remove(a.spool.Name())
+	}
+}
+
+func (a *hipChatArchive) decode(name string, v interface{}) error {
+	reader, err := a.open(name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return json.NewDecoder(reader).Decode(v)
+}
+
+// HipChatImport imports the users, rooms and messages from a HipChat export into a team by converting them into
+// the bulk import format. The export needs to have been decrypted first.
+func HipChatImport(r io.ReaderAt, size int64, teamId string, dryRun bool) (*model.AppError, *model.ImportReport) {
+	archive, err := hipChatReadArchive(r, size)
+	if err != nil {
+		return model.NewLocAppError("HipChatImport", "api.hipchatimport.archive.app_error", nil, err.Error()), nil
+	}
+	defer archive.Close()
+
+	var team *model.Team
+	if result := <-Srv.Store.Team().Get(teamId); result.Err != nil {
+		return result.Err, nil
+	} else {
+		team = result.Data.(*model.Team)
+	}
+
+	lines, skipped, err := HipChatConvert(archive, team.Name)
+	if err != nil {
+		return model.NewLocAppError("HipChatImport", "api.hipchatimport.convert.app_error", nil, err.Error()), nil
+	}
+
+	appErr, report := BulkImportLines(lines, archive.open, dryRun)
+	if appErr != nil {
+		return appErr, nil
+	}
+
+	if skipped > 0 {
+		report.Notes = append(report.Notes, utils.T("api.hipchatimport.skipped", map[string]interface{}{"Count": skipped}))
+	}
+
+	return nil, report
+}
+
+// hipChatMaxEntrySize returns the largest that a file in an export can be. Anything other than the json files is
+// an attachment.
+func hipChatMaxEntrySize(name string) int64 {
+	if strings.HasSuffix(name, ".json") {
+		return HIPCHAT_MAX_JSON_SIZE
+	}
+
+	return *utils.Cfg.FileSettings.MaxFileSize
+}
+
+func hipChatReadArchive(r io.ReaderAt, size int64) (*hipChatArchive, error) {
+	archive := &hipChatArchive{files: map[string]func() (io.ReadCloser, error){}}
+
+	if zipReader, err := zip.NewReader(r, size); err == nil {
+		for _, file := range zipReader.File {
+			file := file
+			limit := hipChatMaxEntrySize(file.Name)
+
+			if file.UncompressedSize64 > uint64(limit) {
+				return nil, fmt.Errorf("%v is larger than the maximum size of %v bytes", file.Name, limit)
+			}
+
+			archive.files[path.Clean(file.Name)] = func() (io.ReadCloser, error) {
+				reader, err := file.Open()
+				if err != nil {
+					return nil, err
+				}
+
+				// the size in a zip's header can't be trusted
+				return hipChatEntryReader{io.LimitReader(reader, limit), reader}, nil
+			}
+		}
+
+		return archive, nil
+	}
+
+	gzipReader, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("the export must be a zip or a decrypted tar.gz file")
+	}
+	defer gzipReader.Close()
+
+	if archive.spool, err = ioutil.TempFile("", "hipchat"); err != nil {
+		return nil, err
+	}
+
+	var offset int64
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			archive.Close()
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		limit := hipChatMaxEntrySize(header.Name)
+		if header.Size > limit {
+			archive.Close()
+			return nil, fmt.Errorf("%v is larger than the maximum size of %v bytes", header.Name, limit)
+		}
+
+		written, err := io.Copy(archive.spool, io.LimitReader(tarReader, limit+1))
+		if err != nil {
+			archive.Close()
+			return nil, err
+		} else if written > limit {
+			archive.Close()
+			return nil, fmt.Errorf("%v is larger than the maximum size of %v bytes", header.Name, limit)
+		}
+
+		section := io.NewSectionReader(archive.spool, offset, written)
+		archive.files[path.Clean(header.Name)] = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(io.NewSectionReader(section, 0, section.Size())), nil
+		}
+
+		offset += written
+	}
+
+	return archive, nil
+}
+
+// HipChatConvert converts the contents of a HipChat export into lines of a bulk import into the given team. It
+// also returns the number of messages that couldn't be converted.
+func HipChatConvert(archive *hipChatArchive, teamName string) ([]*model.BulkImportLine, int, error) {
+	var users []struct {
+		User HipChatUser `json:"User"`
+	}
+	if err := archive.decode("users.json", &users); err != nil {
+		return nil, 0, err
+	}
+
+	var rooms []struct {
+		Room HipChatRoom `json:"Room"`
+	}
+	if err := archive.decode("rooms.json", &rooms); err != nil {
+		return nil, 0, err
+	}
+
+	lines := []*model.BulkImportLine{{Type: model.BULK_IMPORT_TYPE_VERSION, Version: model.BULK_IMPORT_VERSION}}
+	skipped := 0
+
+	usernames := map[int64]string{}
+	mentions := map[string]string{}
+	taken := map[string]bool{}
+	for _, u := range users {
+		if !model.IsValidEmail(strings.ToLower(u.User.Email)) {
+			continue
+		}
+
+		username := model.CleanUsername(u.User.MentionName)
+		if taken[username] {
+			username = model.CleanUsername(fmt.Sprintf("%v-%v", u.User.MentionName, u.User.Id))
+		}
+		taken[username] = true
+
+		usernames[u.User.Id] = username
+		mentions[strings.ToLower(u.User.MentionName)] = username
+	}
+
+	channelNames := map[int64]string{}
+	memberships := map[int64][]*model.BulkImportChannelMember{}
+	used := map[string]bool{}
+
+	for _, r := range rooms {
+		room := r.Room
+
+		name := hipChatChannelName(room.Name)
+		for i := 2; used[name]; i++ {
+			name = hipChatChannelName(room.Name + "-" + strconv.Itoa(i))
+		}
+		used[name] = true
+		channelNames[room.Id] = name
+
+		channelType := model.CHANNEL_OPEN
+		if room.Privacy == "private" {
+			channelType = model.CHANNEL_PRIVATE
+		}
+
+		lines = append(lines, &model.BulkImportLine{
+			Type: model.BULK_IMPORT_TYPE_CHANNEL,
+			Channel: &model.BulkImportChannel{
+				Team:        teamName,
+				Name:        name,
+				DisplayName: hipChatTruncate(room.Name, 64),
+				Type:        channelType,
+				Header:      hipChatTruncate(room.Topic, 1024),
+			},
+		})
+
+		joined := map[int64]bool{}
+		for _, userId := range append(room.Members, room.Participants...) {
+			if !joined[userId] {
+				joined[userId] = true
+				memberships[userId] = append(memberships[userId], &model.BulkImportChannelMember{Name: name})
+			}
+		}
+	}
+
+	for _, u := range users {
+		username, ok := usernames[u.User.Id]
+		if !ok {
+			continue
+		}
+
+		firstName, lastName := u.User.Name, ""
+		if i := strings.Index(u.User.Name, " "); i != -1 {
+			firstName, lastName = u.User.Name[:i], u.User.Name[i+1:]
+		}
+
+		lines = append(lines, &model.BulkImportLine{
+			Type: model.BULK_IMPORT_TYPE_USER,
+			User: &model.BulkImportUser{
+				Username:  username,
+				Email:     strings.ToLower(u.User.Email),
+				FirstName: hipChatTruncate(firstName, 64),
+				LastName:  hipChatTruncate(lastName, 64),
+				Teams:     []*model.BulkImportTeamMember{{Name: teamName, Channels: memberships[u.User.Id]}},
+			},
+		})
+	}
+
+	for _, r := range rooms {
+		var history []map[string]json.RawMessage
+		if err := archive.decode(fmt.Sprintf("rooms/%v/history.json", r.Room.Id), &history); err != nil {
+			continue
+		}
+
+		folder := fmt.Sprintf("rooms/%v/files", r.Room.Id)
+
+		for _, entry := range history {
+			var message HipChatMessage
+			if data, ok := entry["UserMessage"]; !ok || json.Unmarshal(data, &message) != nil {
+				// notifications from integrations and topic changes aren't imported
+				skipped++
+				continue
+			}
+
+			username := usernames[message.Sender.Id]
+			text, createAt, attachments := hipChatConvertMessage(archive, folder, message, mentions)
+			if len(username) == 0 || createAt == 0 || (len(text) == 0 && len(attachments) == 0) {
+				skipped++
+				continue
+			}
+
+			lines = append(lines, &model.BulkImportLine{
+				Type: model.BULK_IMPORT_TYPE_POST,
+				Post: &model.BulkImportPost{
+					Team:        teamName,
+					Channel:     channelNames[r.Room.Id],
+					User:        username,
+					Message:     text,
+					CreateAt:    createAt,
+					Attachments: attachments,
+				},
+			})
+		}
+	}
+
+	// private messages are in the history of both the sender and the receiver
+	seen := map[string]bool{}
+
+	userIds := make([]int64, 0, len(users))
+	for _, u := range users {
+		userIds = append(userIds, u.User.Id)
+	}
+	sort.Sort(hipChatIds(userIds))
+
+	for _, userId := range userIds {
+		var history []map[string]json.RawMessage
+		if err := archive.decode(fmt.Sprintf("users/%v/history.json", userId), &history); err != nil {
+			continue
+		}
+
+		for _, entry := range history {
+			var message HipChatMessage
+			if data, ok := entry["PrivateUserMessage"]; !ok || json.Unmarshal(data, &message) != nil || seen[message.Id] {
+				continue
+			}
+			seen[message.Id] = true
+
+			sender := usernames[message.Sender.Id]
+			receiver := usernames[message.Receiver.Id]
+			text, createAt, attachments := hipChatConvertMessage(archive, "users/files", message, mentions)
+			if len(sender) == 0 || len(receiver) == 0 || sender == receiver || createAt == 0 || (len(text) == 0 && len(attachments) == 0) {
+				skipped++
+				continue
+			}
+
+			lines = append(lines, &model.BulkImportLine{
+				Type: model.BULK_IMPORT_TYPE_DIRECT_POST,
+				DirectPost: &model.BulkImportDirectPost{
+					Members:     []string{sender, receiver},
+					User:        sender,
+					Message:     text,
+					CreateAt:    createAt,
+					Attachments: attachments,
+				},
+			})
+		}
+	}
+
+	return lines, skipped, nil
+}
+
+var hipChatMention = regexp.MustCompile(`@(\w+)`)
+
+// hipChatConvertMessage returns the text of a message with its mentions changed to the imported usernames, when it
+// was sent and the attachment that was sent with it. Attachments that aren't in the export are linked to instead.
+func hipChatConvertMessage(archive *hipChatArchive, folder string, message HipChatMessage, mentions map[string]string) (string, int64, []*model.BulkImportAttachment) {
+	text := hipChatMention.ReplaceAllStringFunc(message.Message, func(mention string) string {
+		if username, ok := mentions[strings.ToLower(mention[1:])]; ok {
+			return "@" + username
+		}
+		return mention
+	})
+
+	var attachments []*model.BulkImportAttachment
+	if message.Attachment != nil {
+		found := false
+		for _, name := range []string{message.Attachment.Path, path.Join(folder, message.Attachment.Path)} {
+			if archive.has(name) && len(message.Attachment.Path) > 0 {
+				attachments = append(attachments, &model.BulkImportAttachment{Path: path.Clean(name)})
+				found = true
+				break
+			}
+		}
+
+		if !found && len(message.Attachment.Url) > 0 {
+			text = strings.TrimSpace(text + "\n" + message.Attachment.Url)
+		}
+	}
+
+	return hipChatTruncate(text, 4000), hipChatConvertTimestamp(message.Timestamp), attachments
+}
+
+// hipChatConvertTimestamp converts timestamps like "2016-03-10T20:24:55Z 123456", where the microseconds are
+// written separately, into milliseconds
+func hipChatConvertTimestamp(timestamp string) int64 {
+	parts := strings.SplitN(timestamp, " ", 2)
+
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return 0
+	}
+
+	if len(parts) == 2 {
+		if micro, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			t = t.Add(time.Duration(micro) * time.Microsecond)
+		}
+	}
+
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+var hipChatInvalidChannelChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func hipChatChannelName(roomName string) string {
+	name := strings.Trim(hipChatInvalidChannelChars.ReplaceAllString(strings.ToLower(roomName), "-"), "-")
+
+	if len(name) > 64 {
+		name = strings.Trim(name[:64], "-")
+	}
+
+	if len(name) < 2 {
+		name = "hipchat-" + model.NewId()[:10]
+	}
+
+	return name
+}
+
+func hipChatTruncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	return string([]rune(s)[:max])
+}
+
+type hipChatIds []int64
+
+func (p hipChatIds) Len() int           { return len(p) }
+func (p hipChatIds) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p hipChatIds) Less(i, j int) bool { return p[i] < p[j] }
@@ -0,0 +1,165 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestHipChatConvertTimestamp(t *testing.T) {
+	if ts := hipChatConvertTimestamp("2016-03-10T20:24:55Z 123456"); ts != 1457641495123 {
+		t.Fatal("converted to the wrong time", ts)
+	}
+
+	if ts := hipChatConvertTimestamp("2016-03-10T20:24:55.5+00:00"); ts != 1457641495500 {
+		t.Fatal("converted to the wrong time", ts)
+	}
+
+	if ts := hipChatConvertTimestamp("yesterday"); ts != 0 {
+		t.Fatal("shouldn't have converted an invalid time")
+	}
+}
+
+func TestHipChatChannelName(t *testing.T) {
+	if name := hipChatChannelName("Dev Team: Backend!"); name != "dev-team-backend" {
+		t.Fatal("got the wrong name", name)
+	}
+
+	if name := hipChatChannelName("?"); !model.IsValidChannelIdentifier(name) {
+		t.Fatal("should've made up a valid name", name)
+	}
+}
+
+func TestHipChatConvert(t *testing.T) {
+	utils.LoadConfig("config.json")
+
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, file := range []struct{ name, contents string }{
+		{"users.json", `[
+			{"User": {"id": 1, "name": "Ada Lovelace", "mention_name": "Ada", "email": "ada@example.com"}},
+			{"User": {"id": 2, "name": "Charles", "mention_name": "Charles", "email": "charles@example.com"}},
+			{"User": {"id": 3, "name": "No Email", "mention_name": "noemail", "email": ""}}
+		]`},
+		{"rooms.json", `[
+			{"Room": {"id": 10, "name": "Engines", "topic": "Analytical", "privacy": "private", "members": [1, 2], "participants": [1]}},
+			{"Room": {"id": 11, "name": "engines", "privacy": "public", "members": [], "participants": [2]}}
+		]`},
+		{"rooms/10/history.json", `[
+			{"UserMessage": {"id": "a", "message": "hi @charles", "sender": {"id": 1}, "timestamp": "2016-03-10T20:24:55Z 000000", "attachment": null}},
+			{"UserMessage": {"id": "b", "message": "", "sender": {"id": 2}, "timestamp": "2016-03-10T20:25:55Z 000000",
+				"attachment": {"name": "plan.txt", "path": "abc/plan.txt", "url": "https://example.com/plan.txt"}}},
+			{"UserMessage": {"id": "c", "message": "link", "sender": {"id": 2}, "timestamp": "2016-03-10T20:26:55Z 000000",
+				"attachment": {"name": "gone.txt", "path": "def/gone.txt", "url": "https://example.com/gone.txt"}}},
+			{"NotificationMessage": {"id": "d", "message": "build passed", "sender": "CI", "timestamp": "2016-03-10T20:27:55Z 000000"}},
+			{"UserMessage": {"id": "e", "message": "who am i", "sender": {"id": 3}, "timestamp": "2016-03-10T20:28:55Z 000000"}}
+		]`},
+		{"rooms/10/files/abc/plan.txt", "the plan"},
+		{"users/1/history.json", `[
+			{"PrivateUserMessage": {"id": "p", "message": "private", "sender": {"id": 1}, "receiver": {"id": 2}, "timestamp": "2016-03-10T20:24:55Z 000000"}}
+		]`},
+		{"users/2/history.json", `[
+			{"PrivateUserMessage": {"id": "p", "message": "private", "sender": {"id": 1}, "receiver": {"id": 2}, "timestamp": "2016-03-10T20:24:55Z 000000"}}
+		]`},
+	} {
+		tarWriter.WriteHeader(&tar.Header{Name: file.name, Mode: 0600, Size: int64(len(file.contents)), Typeflag: tar.TypeReg})
+		tarWriter.Write([]byte(file.contents))
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+
+	archive, err := hipChatReadArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	lines, skipped, err := HipChatConvert(archive, "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if skipped != 2 {
+		t.Fatal("should've skipped the notification and the message from the user without an email", skipped)
+	}
+
+	counts := map[string]int{}
+	for i, line := range lines {
+		counts[line.Type]++
+
+		if err := line.IsValid(); err != nil {
+			t.Fatal("line should be valid", i, err)
+		}
+	}
+
+	if counts[model.BULK_IMPORT_TYPE_VERSION] != 1 || counts[model.BULK_IMPORT_TYPE_CHANNEL] != 2 || counts[model.BULK_IMPORT_TYPE_USER] != 2 {
+		t.Fatal("converted the wrong number of rooms and users", counts)
+	} else if counts[model.BULK_IMPORT_TYPE_POST] != 3 {
+		t.Fatal("should've converted the room messages", counts)
+	} else if counts[model.BULK_IMPORT_TYPE_DIRECT_POST] != 1 {
+		t.Fatal("should've only converted the private message once", counts)
+	}
+
+	if lines[1].Channel.Name != "engines" || lines[1].Channel.Type != model.CHANNEL_PRIVATE || lines[1].Channel.Header != "Analytical" {
+		t.Fatal("converted the room incorrectly")
+	} else if lines[2].Channel.Name != "engines-2" {
+		t.Fatal("rooms with the same name should get different channel names", lines[2].Channel.Name)
+	}
+
+	ada := lines[3].User
+	if ada.Username != "ada" || ada.FirstName != "Ada" || ada.LastName != "Lovelace" {
+		t.Fatal("converted the user incorrectly")
+	} else if len(ada.Teams) != 1 || len(ada.Teams[0].Channels) != 1 || ada.Teams[0].Channels[0].Name != "engines" {
+		t.Fatal("should've added the user to the rooms they were in")
+	}
+
+	if lines[5].Post.Message != "hi @charles" || lines[5].Post.User != "ada" {
+		t.Fatal("converted the message incorrectly")
+	} else if len(lines[6].Post.Attachments) != 1 || lines[6].Post.Attachments[0].Path != "rooms/10/files/abc/plan.txt" {
+		t.Fatal("should've found the attachment in the archive")
+	} else if lines[7].Post.Message != "link\nhttps://example.com/gone.txt" || len(lines[7].Post.Attachments) != 0 {
+		t.Fatal("should've linked to the missing attachment", lines[7].Post.Message)
+	}
+
+	if reader, err := archive.open(lines[6].Post.Attachments[0].Path); err != nil {
+		t.Fatal(err)
+	} else if data, _ := ioutil.ReadAll(reader); string(data) != "the plan" {
+		t.Fatal("read the wrong attachment", string(data))
+	} else {
+		reader.Close()
+	}
+}
+
+func TestHipChatReadArchiveTooLarge(t *testing.T) {
+	utils.LoadConfig("config.json")
+
+	maxFileSize := *utils.Cfg.FileSettings.MaxFileSize
+	defer func() {
+		*utils.Cfg.FileSettings.MaxFileSize = maxFileSize
+	}()
+	*utils.Cfg.FileSettings.MaxFileSize = 4
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	tarWriter.WriteHeader(&tar.Header{Name: "users.json", Mode: 0600, Size: 2, Typeflag: tar.TypeReg})
+	tarWriter.Write([]byte("[]"))
+	tarWriter.WriteHeader(&tar.Header{Name: "rooms/10/files/abc/plan.txt", Mode: 0600, Size: 8, Typeflag: tar.TypeReg})
+	tarWriter.Write([]byte("the plan"))
+	tarWriter.Close()
+	gzipWriter.Close()
+
+	if _, err := hipChatReadArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err == nil {
+		t.Fatal("should've rejected an attachment over the maximum file size")
+	}
+}
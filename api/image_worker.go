@@ -0,0 +1,187 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"sync"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/disintegration/imaging"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	IMAGE_JOB_QUEUE_SIZE = 1000
+)
+
+type imageJob struct {
+	info        *model.FileInfo
+	orientation int
+}
+
+var imageJobQueue chan *imageJob
+var imageWorkersStop chan bool
+var imageWorkersMutex sync.Mutex
+
+// StartImageWorkers starts the pool of workers that generate the thumbnail and preview images for uploaded images
+func StartImageWorkers() {
+	imageWorkersMutex.Lock()
+	defer imageWorkersMutex.Unlock()
+
+	if imageJobQueue != nil {
+		return
+	}
+
+	queue := make(chan *imageJob, IMAGE_JOB_QUEUE_SIZE)
+	stop := make(chan bool)
+	imageJobQueue = queue
+	imageWorkersStop = stop
+
+	for i := 0; i < *utils.Cfg.FileSettings.MaxImageWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case job := <-queue:
+					generateImages(job)
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+}
+
+func StopImageWorkers() {
+	imageWorkersMutex.Lock()
+	defer imageWorkersMutex.Unlock()
+
+	if imageWorkersStop != nil {
+		close(imageWorkersStop)
+		imageWorkersStop = nil
+		imageJobQueue = nil
+	}
+}
+
+// handleImages queues the thumbnail and preview images to be generated for each uploaded image. Uploads wait
+// for room in the queue when the workers fall behind.
+func handleImages(jobs []*imageJob) {
+	imageWorkersMutex.Lock()
+	queue := imageJobQueue
+	stop := imageWorkersStop
+	imageWorkersMutex.Unlock()
+
+	for _, job := range jobs {
+		if queue == nil {
+			go generateImages(job)
+			continue
+		}
+
+		select {
+		case queue <- job:
+		case <-stop:
+			// the workers were stopped while waiting for room in the queue
+			go generateImages(job)
+		}
+	}
+}
+
+// generateImages reads an uploaded image back from storage and writes its thumbnail and preview images
+func generateImages(job *imageJob) {
+	info := job.info
+
+	reader, err := ReadFileStream(info.Path)
+	if err != nil {
+		l4g.Error(utils.T("api.file.handle_images_forget.read.error"), info.ChannelId, info.CreatorId, info.Filename, err)
+		return
+	}
+
+	// Decode image bytes into Image object
+	img, imgType, decodeErr := image.Decode(reader)
+	reader.Close()
+	if decodeErr != nil {
+		l4g.Error(utils.T("api.file.handle_images_forget.decode.error"), info.ChannelId, info.CreatorId, info.Filename, decodeErr)
+		return
+	}
+
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+
+	if imgType == "png" {
+		dst := image.NewRGBA(img.Bounds())
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+		img = dst
+	}
+
+	switch job.orientation {
+	case UprightMirrored:
+		img = imaging.FlipH(img)
+	case UpsideDown:
+		img = imaging.Rotate180(img)
+	case UpsideDownMirrored:
+		img = imaging.FlipV(img)
+	case RotatedCWMirrored:
+		img = imaging.Transpose(img)
+	case RotatedCCW:
+		img = imaging.Rotate270(img)
+	case RotatedCCWMirrored:
+		img = imaging.Transverse(img)
+	case RotatedCW:
+		img = imaging.Rotate90(img)
+	}
+
+	generateThumbnailImage(info, img, width, height)
+	generatePreviewImage(info, img, width)
+}
+
+func generateThumbnailImage(info *model.FileInfo, img image.Image, width int, height int) {
+	thumbWidth := float64(utils.Cfg.FileSettings.ThumbnailWidth)
+	thumbHeight := float64(utils.Cfg.FileSettings.ThumbnailHeight)
+	imgWidth := float64(width)
+	imgHeight := float64(height)
+
+	var thumbnail image.Image
+	if imgHeight < thumbHeight && imgWidth < thumbWidth {
+		thumbnail = img
+	} else if imgHeight/imgWidth < thumbHeight/thumbWidth {
+		thumbnail = imaging.Resize(img, 0, utils.Cfg.FileSettings.ThumbnailHeight, imaging.Lanczos)
+	} else {
+		thumbnail = imaging.Resize(img, utils.Cfg.FileSettings.ThumbnailWidth, 0, imaging.Lanczos)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, thumbnail, &jpeg.Options{Quality: 90}); err != nil {
+		l4g.Error(utils.T("api.file.handle_images_forget.encode_jpeg.error"), info.ChannelId, info.CreatorId, info.Filename, err)
+		return
+	}
+
+	if err := WriteFile(buf.Bytes(), info.ThumbnailPath); err != nil {
+		l4g.Error(utils.T("api.file.handle_images_forget.upload_thumb.error"), info.ChannelId, info.CreatorId, info.Filename, err)
+	}
+}
+
+func generatePreviewImage(info *model.FileInfo, img image.Image, width int) {
+	var preview image.Image
+	if width > int(utils.Cfg.FileSettings.PreviewWidth) {
+		preview = imaging.Resize(img, utils.Cfg.FileSettings.PreviewWidth, utils.Cfg.FileSettings.PreviewHeight, imaging.Lanczos)
+	} else {
+		preview = img
+	}
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, preview, &jpeg.Options{Quality: 90}); err != nil {
+		l4g.Error(utils.T("api.file.handle_images_forget.encode_preview.error"), info.ChannelId, info.CreatorId, info.Filename, err)
+		return
+	}
+
+	if err := WriteFile(buf.Bytes(), info.PreviewPath); err != nil {
+		l4g.Error(utils.T("api.file.handle_images_forget.upload_preview.error"), info.ChannelId, info.CreatorId, info.Filename, err)
+	}
+}
@@ -14,14 +14,60 @@ import (
 // some of the usual checks. (IsValid is still run)
 //
 
-func ImportPost(post *model.Post) {
+func ImportPost(post *model.Post) *model.Post {
 	post.Hashtags, _ = model.ParseHashtags(post.Message)
 
+	if len(post.Filenames) > 0 && len(post.FileIds) == 0 {
+		post.FileIds = ImportFileInfos(post)
+	}
+
 	if result := <-Srv.Store.Post().Save(post); result.Err != nil {
 		l4g.Debug(utils.T("api.import.import_post.saving.debug"), post.UserId, post.Message)
+		return nil
+	} else {
+		rpost := result.Data.(*model.Post)
+
+		for _, fileId := range rpost.FileIds {
+			if result := <-Srv.Store.FileInfo().AttachToPost(fileId, rpost.Id); result.Err != nil {
+				l4g.Debug(utils.T("api.import.import_post.attach_file.debug"), rpost.Id, fileId, result.Err)
+			}
+		}
+
+		indexPostForSearch(rpost)
+
+		return rpost
 	}
 }
 
+// ImportFileInfos saves a FileInfo for each of the files in an imported post's Filenames and returns their ids
+func ImportFileInfos(post *model.Post) []string {
+	teamId := ""
+	if result := <-Srv.Store.Channel().Get(post.ChannelId); result.Err == nil {
+		teamId = result.Data.(*model.Channel).TeamId
+	}
+
+	fileIds := []string{}
+	for _, filename := range post.Filenames {
+		if len(fileIds) == model.POST_MAX_FILE_IDS {
+			break
+		}
+
+		info := model.GetInfoForFilename(filename, teamId)
+		if info == nil {
+			continue
+		}
+		info.CreateAt = post.CreateAt
+
+		if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
+			l4g.Debug(utils.T("api.import.import_file_infos.saving.debug"), filename, result.Err)
+		} else {
+			fileIds = append(fileIds, info.Id)
+		}
+	}
+
+	return fileIds
+}
+
 func ImportUser(team *model.Team, user *model.User) *model.User {
 	user.MakeNonNil()
 
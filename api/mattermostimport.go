@@ -0,0 +1,561 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// MattermostArchive holds the parsed contents of a zip written by ExportToWriter
+type MattermostArchive struct {
+	Options   *model.ExportOptions
+	Teams     []*model.Team
+	Channels  []*model.Channel
+	Members   map[string][]model.ChannelMember // by channel id
+	Posts     map[string][]*model.Post         // by channel id
+	Reactions map[string][]*model.Reaction     // by channel id
+	Users     map[string][]*model.User         // by team id
+	Files     []*zip.File
+}
+
+// mattermostImportMaps tracks the ids from the archive and the objects they were imported as
+type mattermostImportMaps struct {
+	teams    map[string]*model.Team
+	channels map[string]*model.Channel
+	users    map[string]*model.User
+	posts    map[string]string
+}
+
+func ImportFromReader(r io.ReaderAt, size int64, options *model.ExportOptions, teamId string) (*model.AppError, *bytes.Buffer) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil || zipReader.File == nil {
+		details := ""
+		if err != nil {
+			details = err.Error()
+		}
+		return model.NewLocAppError("ImportFromReader", "api.mattermostimport.zip.app_error", nil, details), nil
+	}
+
+	log := bytes.NewBufferString(utils.T("api.mattermostimport.log"))
+
+	archive, appErr := MattermostParseArchive(zipReader)
+	if appErr != nil {
+		return appErr, log
+	}
+
+	if options == nil {
+		options = archive.Options
+	}
+
+	maps := &mattermostImportMaps{
+		teams:    make(map[string]*model.Team),
+		channels: make(map[string]*model.Channel),
+		users:    make(map[string]*model.User),
+		posts:    make(map[string]string),
+	}
+
+	if appErr := mattermostAddTeams(archive, options, teamId, maps, log); appErr != nil {
+		return appErr, log
+	}
+
+	mattermostAddUsers(archive, options, maps, log)
+	mattermostAddChannels(archive, options, maps, log)
+	mattermostAddPosts(archive, maps, log)
+
+	if options.ExportLocalStorage {
+		mattermostAddFiles(archive, maps, log)
+	}
+
+	log.WriteString(utils.T("api.mattermostimport.notes"))
+	log.WriteString("=======\r\n\r\n")
+
+	log.WriteString(utils.T("api.mattermostimport.note1"))
+	log.WriteString(utils.T("api.mattermostimport.note2"))
+
+	return nil, log
+}
+
+func MattermostParseArchive(zipReader *zip.Reader) (*MattermostArchive, *model.AppError) {
+	archive := &MattermostArchive{
+		Options:   &model.ExportOptions{},
+		Members:   make(map[string][]model.ChannelMember),
+		Posts:     make(map[string][]*model.Post),
+		Reactions: make(map[string][]*model.Reaction),
+		Users:     make(map[string][]*model.User),
+	}
+
+	for _, file := range zipReader.File {
+		if strings.HasPrefix(file.Name, EXPORT_LOCAL_STORAGE_FOLDER+"/") {
+			archive.Files = append(archive.Files, file)
+			continue
+		}
+
+		if !strings.HasSuffix(file.Name, ".json") {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return nil, model.NewLocAppError("MattermostParseArchive", "api.mattermostimport.open.app_error", map[string]interface{}{"Filename": file.Name}, err.Error())
+		}
+
+		decodeErr := mattermostParseArchiveFile(archive, file.Name, reader)
+		reader.Close()
+
+		if decodeErr != nil {
+			return nil, model.NewLocAppError("MattermostParseArchive", "api.mattermostimport.parse.app_error", map[string]interface{}{"Filename": file.Name}, decodeErr.Error())
+		}
+	}
+
+	return archive, nil
+}
+
+func mattermostParseArchiveFile(archive *MattermostArchive, name string, reader io.Reader) error {
+	decoder := json.NewDecoder(reader)
+	base := strings.TrimSuffix(name[strings.LastIndex(name, "/")+1:], ".json")
+
+	switch {
+	case name == EXPORT_OPTIONS_FILE:
+		return decoder.Decode(archive.Options)
+	case strings.HasPrefix(name, EXPORT_TEAMS_FOLDER+"/"):
+		var team model.Team
+		if err := decoder.Decode(&team); err != nil {
+			return err
+		}
+		archive.Teams = append(archive.Teams, &team)
+	case strings.HasPrefix(name, EXPORT_CHANNELS_FOLDER+"/") && strings.HasSuffix(base, "_members"):
+		var members []model.ChannelMember
+		if err := decoder.Decode(&members); err != nil {
+			return err
+		}
+		archive.Members[strings.TrimSuffix(base, "_members")] = members
+	case strings.HasPrefix(name, EXPORT_CHANNELS_FOLDER+"/"):
+		var channel model.Channel
+		if err := decoder.Decode(&channel); err != nil {
+			return err
+		}
+		archive.Channels = append(archive.Channels, &channel)
+	case strings.HasPrefix(name, EXPORT_POSTS_FOLDER+"/") && strings.HasSuffix(base, "_reactions"):
+		var reactions []*model.Reaction
+		if err := decoder.Decode(&reactions); err != nil {
+			return err
+		}
+		channelId := strings.TrimSuffix(base, "_reactions")
+		archive.Reactions[channelId] = append(archive.Reactions[channelId], reactions...)
+	case strings.HasPrefix(name, EXPORT_POSTS_FOLDER+"/"):
+		var posts []*model.Post
+		if err := decoder.Decode(&posts); err != nil {
+			return err
+		}
+		channelId := strings.TrimSuffix(base, "_posts")
+		archive.Posts[channelId] = append(archive.Posts[channelId], posts...)
+	case strings.HasPrefix(name, EXPORT_USERS_FOLDER+"/"):
+		var users []*model.User
+		if err := decoder.Decode(&users); err != nil {
+			return err
+		}
+		teamId := strings.TrimSuffix(base, "_users")
+		archive.Users[teamId] = append(archive.Users[teamId], users...)
+	}
+
+	return nil
+}
+
+func isSelectedForImport(id string, selected []string) bool {
+	if len(selected) == 0 {
+		return true
+	}
+
+	for _, s := range selected {
+		if s == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mattermostAddTeams(archive *MattermostArchive, options *model.ExportOptions, teamId string, maps *mattermostImportMaps, log *bytes.Buffer) *model.AppError {
+	log.WriteString(utils.T("api.mattermostimport.add_teams.added"))
+	log.WriteString("=================\r\n\r\n")
+
+	var target *model.Team
+	if len(teamId) > 0 {
+		if result := <-Srv.Store.Team().Get(teamId); result.Err != nil {
+			log.WriteString(utils.T("api.mattermostimport.team_fail"))
+			return result.Err
+		} else {
+			target = result.Data.(*model.Team)
+		}
+
+		// merging several teams into one would also merge any of their channels that share a name
+		selected := 0
+		for _, aTeam := range archive.Teams {
+			if isSelectedForImport(aTeam.Id, options.TeamsToExport) {
+				selected++
+			}
+		}
+
+		if selected > 1 {
+			log.WriteString(utils.T("api.mattermostimport.team_fail"))
+			return model.NewLocAppError("mattermostAddTeams", "api.mattermostimport.add_teams.multiple_teams.app_error", nil, "")
+		}
+	}
+
+	for _, aTeam := range archive.Teams {
+		if !isSelectedForImport(aTeam.Id, options.TeamsToExport) {
+			continue
+		}
+
+		if target != nil {
+			maps.teams[aTeam.Id] = target
+			log.WriteString(utils.T("api.mattermostimport.add_teams.merge", map[string]interface{}{"DisplayName": aTeam.DisplayName, "Target": target.DisplayName}))
+			continue
+		}
+
+		if result := <-Srv.Store.Team().GetByName(aTeam.Name); result.Err == nil {
+			existing := result.Data.(*model.Team)
+			maps.teams[aTeam.Id] = existing
+			log.WriteString(utils.T("api.mattermostimport.add_teams.merge", map[string]interface{}{"DisplayName": aTeam.DisplayName, "Target": existing.DisplayName}))
+			continue
+		}
+
+		newTeam := *aTeam
+		newTeam.Id = ""
+		newTeam.InviteId = ""
+
+		if result := <-Srv.Store.Team().Save(&newTeam); result.Err != nil {
+			l4g.Debug(utils.T("api.mattermostimport.add_teams.import_failed.debug"), aTeam.Name, result.Err)
+			log.WriteString(utils.T("api.mattermostimport.add_teams.import_failed", map[string]interface{}{"DisplayName": aTeam.DisplayName}))
+			continue
+		} else {
+			maps.teams[aTeam.Id] = result.Data.(*model.Team)
+			log.WriteString(aTeam.DisplayName + "\r\n")
+		}
+	}
+
+	return nil
+}
+
+func mattermostAddUsers(archive *MattermostArchive, options *model.ExportOptions, maps *mattermostImportMaps, log *bytes.Buffer) {
+	log.WriteString(utils.T("api.mattermostimport.add_users.created"))
+	log.WriteString("===============\r\n\r\n")
+
+	for oldTeamId, users := range archive.Users {
+		team := maps.teams[oldTeamId]
+		if team == nil {
+			continue
+		}
+
+		for _, aUser := range users {
+			if !isSelectedForImport(aUser.Id, options.UsersToExport) {
+				continue
+			}
+
+			if user, ok := maps.users[aUser.Id]; ok {
+				if err := JoinUserToTeam(team, user); err != nil {
+					log.WriteString(utils.T("api.mattermostimport.add_users.join_team", map[string]interface{}{"Username": user.Username}))
+				}
+				continue
+			}
+
+			if existing := mattermostFindExistingUser(aUser); existing != nil {
+				maps.users[aUser.Id] = existing
+				log.WriteString(utils.T("api.mattermostimport.add_users.merge", map[string]interface{}{"Username": aUser.Username, "Existing": existing.Username}))
+
+				if err := JoinUserToTeam(team, existing); err != nil {
+					log.WriteString(utils.T("api.mattermostimport.add_users.join_team", map[string]interface{}{"Username": existing.Username}))
+				}
+				continue
+			}
+
+			password := model.NewId()
+
+			// Passwords and external auth data are never exported so imported users sign in with a generated password
+			newUser := *aUser
+			newUser.Id = ""
+			newUser.Password = password
+			newUser.AuthData = nil
+			newUser.AuthService = ""
+			newUser.Roles = ""
+			newUser.MfaActive = false
+			newUser.MfaSecret = ""
+			newUser.Username = mattermostUniqueUsername(aUser.Username)
+
+			if newUser.Username != aUser.Username {
+				log.WriteString(utils.T("api.mattermostimport.add_users.renamed", map[string]interface{}{"Username": aUser.Username, "NewUsername": newUser.Username}))
+			}
+
+			if mUser := ImportUser(team, &newUser); mUser != nil {
+				maps.users[aUser.Id] = mUser
+				log.WriteString(utils.T("api.mattermostimport.add_users.email_pwd", map[string]interface{}{"Email": newUser.Email, "Password": password}))
+			} else {
+				log.WriteString(utils.T("api.mattermostimport.add_users.unable_import", map[string]interface{}{"Username": aUser.Username}))
+			}
+		}
+	}
+}
+
+// mattermostFindExistingUser only matches on email since a matching username could belong to someone else entirely
+func mattermostFindExistingUser(aUser *model.User) *model.User {
+	if len(aUser.Email) > 0 {
+		if result := <-Srv.Store.User().GetByEmail(aUser.Email); result.Err == nil {
+			return result.Data.(*model.User)
+		}
+	}
+
+	return nil
+}
+
+// mattermostUniqueUsername returns the given username or, if it's already taken, a copy of it with a random suffix
+func mattermostUniqueUsername(username string) string {
+	if result := <-Srv.Store.User().GetByUsername(username); result.Err != nil {
+		return username
+	}
+
+	suffix := "-" + model.NewId()[:8]
+	if len(username)+len(suffix) > 64 {
+		username = username[:64-len(suffix)]
+	}
+
+	return username + suffix
+}
+
+func mattermostAddChannels(archive *MattermostArchive, options *model.ExportOptions, maps *mattermostImportMaps, log *bytes.Buffer) {
+	log.WriteString(utils.T("api.mattermostimport.add_channels.added"))
+	log.WriteString("=================\r\n\r\n")
+
+	for _, aChannel := range archive.Channels {
+		if !isSelectedForImport(aChannel.Id, options.ChannelsToExport) {
+			continue
+		}
+
+		team := maps.teams[aChannel.TeamId]
+		if team == nil {
+			continue
+		}
+
+		if aChannel.Type != model.CHANNEL_OPEN && aChannel.Type != model.CHANNEL_PRIVATE {
+			log.WriteString(utils.T("api.mattermostimport.add_channels.unsupported_type", map[string]interface{}{"DisplayName": aChannel.DisplayName}))
+			continue
+		}
+
+		newChannel := *aChannel
+		newChannel.Id = ""
+		newChannel.TeamId = team.Id
+		newChannel.ExtraUpdateAt = 0
+
+		mChannel := ImportChannel(&newChannel)
+		if mChannel == nil {
+			// Maybe it already exists?
+			if result := <-Srv.Store.Channel().GetByName(team.Id, aChannel.Name); result.Err != nil {
+				l4g.Debug(utils.T("api.mattermostimport.add_channels.import_failed.debug"), aChannel.Name)
+				log.WriteString(utils.T("api.mattermostimport.add_channels.import_failed", map[string]interface{}{"DisplayName": aChannel.DisplayName}))
+				continue
+			} else {
+				mChannel = result.Data.(*model.Channel)
+				log.WriteString(utils.T("api.mattermostimport.add_channels.merge", map[string]interface{}{"DisplayName": aChannel.DisplayName}))
+			}
+		} else {
+			log.WriteString(aChannel.DisplayName + "\r\n")
+		}
+
+		maps.channels[aChannel.Id] = mChannel
+
+		for _, member := range archive.Members[aChannel.Id] {
+			if user, ok := maps.users[member.UserId]; !ok {
+				log.WriteString(utils.T("api.mattermostimport.add_channels.failed_to_add_user", map[string]interface{}{"Username": member.UserId}))
+			} else if _, err := AddUserToChannel(user, mChannel); err != nil {
+				log.WriteString(utils.T("api.mattermostimport.add_channels.failed_to_add_user", map[string]interface{}{"Username": user.Username}))
+			}
+		}
+	}
+}
+
+func mattermostAddPosts(archive *MattermostArchive, maps *mattermostImportMaps, log *bytes.Buffer) {
+	skipped := 0
+
+	for oldChannelId, posts := range archive.Posts {
+		channel := maps.channels[oldChannelId]
+		if channel == nil {
+			continue
+		}
+
+		// Import the oldest posts first so that a reply's root post has already been remapped
+		sort.Sort(postsByCreateAt(posts))
+
+		for _, aPost := range posts {
+			user := maps.users[aPost.UserId]
+			if user == nil {
+				l4g.Debug(utils.T("api.mattermostimport.add_posts.user_no_exists.debug"), aPost.UserId)
+				skipped++
+				continue
+			}
+
+			newPost := *aPost
+			newPost.Id = ""
+			newPost.UserId = user.Id
+			newPost.ChannelId = channel.Id
+			newPost.RootId = maps.posts[aPost.RootId]
+			newPost.ParentId = maps.posts[aPost.ParentId]
+
+			if len(newPost.RootId) == 0 && len(aPost.RootId) > 0 {
+				// The thread this post belongs to wasn't imported so keep the post on its own
+				newPost.ParentId = ""
+			}
+
+			newPost.Filenames = make([]string, 0, len(aPost.Filenames))
+			for _, filename := range aPost.Filenames {
+				newPost.Filenames = append(newPost.Filenames, mattermostRemapFilename(filename, maps))
+			}
+			// the file infos are recreated from the filenames since the ones in the archive belong to the other server
+			newPost.FileIds = nil
+
+			if mPost := ImportPost(&newPost); mPost != nil {
+				maps.posts[aPost.Id] = mPost.Id
+			} else {
+				skipped++
+			}
+		}
+	}
+
+	if skipped > 0 {
+		log.WriteString(utils.T("api.mattermostimport.add_posts.skipped", map[string]interface{}{"Count": skipped}))
+	}
+
+	for oldChannelId, reactions := range archive.Reactions {
+		if maps.channels[oldChannelId] == nil {
+			continue
+		}
+
+		for _, aReaction := range reactions {
+			user := maps.users[aReaction.UserId]
+			postId := maps.posts[aReaction.PostId]
+			if user == nil || len(postId) == 0 {
+				continue
+			}
+
+			reaction := &model.Reaction{UserId: user.Id, PostId: postId, EmojiName: aReaction.EmojiName, CreateAt: aReaction.CreateAt}
+			if result := <-Srv.Store.Reaction().Save(reaction); result.Err != nil {
+				l4g.Debug(utils.T("api.mattermostimport.add_posts.reaction.debug"), aReaction.PostId, result.Err)
+			}
+		}
+	}
+}
+
+// mattermostRemapFilename rewrites a Post.Filenames entry of the form /channel_id/user_id/file_id/name
+func mattermostRemapFilename(filename string, maps *mattermostImportMaps) string {
+	parts := strings.SplitN(strings.TrimPrefix(filename, "/"), "/", 3)
+	if len(parts) != 3 {
+		return filename
+	}
+
+	if channel, ok := maps.channels[parts[0]]; ok {
+		parts[0] = channel.Id
+	}
+
+	if user, ok := maps.users[parts[1]]; ok {
+		parts[1] = user.Id
+	}
+
+	return "/" + strings.Join(parts, "/")
+}
+
+func mattermostAddFiles(archive *MattermostArchive, maps *mattermostImportMaps, log *bytes.Buffer) {
+	if len(archive.Files) == 0 {
+		return
+	}
+
+	if len(utils.Cfg.FileSettings.DriverName) == 0 {
+		log.WriteString(utils.T("api.mattermostimport.add_files.storage"))
+		return
+	}
+
+	log.WriteString(utils.T("api.mattermostimport.add_files.added"))
+	log.WriteString("==============\r\n\r\n")
+
+	maxFileSize := *utils.Cfg.FileSettings.MaxFileSize
+
+	for _, file := range archive.Files {
+		// Files are exported as files/channels/{channel_id}/users/{user_id}/{file_id}/{name}
+		parts := strings.SplitN(strings.TrimPrefix(file.Name, EXPORT_LOCAL_STORAGE_FOLDER+"/"), "/", 5)
+		if len(parts) != 5 || parts[0] != "channels" || parts[2] != "users" {
+			continue
+		}
+
+		channel := maps.channels[parts[1]]
+		user := maps.users[parts[3]]
+		if channel == nil || user == nil {
+			continue
+		}
+
+		if file.UncompressedSize64 > uint64(maxFileSize) {
+			log.WriteString(utils.T("api.mattermostimport.add_files.too_large", map[string]interface{}{"Filename": file.Name}))
+			continue
+		}
+
+		if !mattermostIsSafeFilePath(parts[4]) {
+			log.WriteString(utils.T("api.mattermostimport.add_files.failed", map[string]interface{}{"Filename": file.Name}))
+			continue
+		}
+
+		path := "teams/" + channel.TeamId + "/channels/" + channel.Id + "/users/" + user.Id + "/" + parts[4]
+
+		reader, err := file.Open()
+		if err != nil {
+			log.WriteString(utils.T("api.mattermostimport.add_files.failed", map[string]interface{}{"Filename": file.Name}))
+			continue
+		}
+
+		// the header's size can't be trusted so the entry is read through a limit and rejected if it runs past it
+		data, err := ioutil.ReadAll(io.LimitReader(reader, maxFileSize+1))
+		reader.Close()
+		if err != nil {
+			log.WriteString(utils.T("api.mattermostimport.add_files.failed", map[string]interface{}{"Filename": file.Name}))
+			continue
+		} else if int64(len(data)) > maxFileSize {
+			log.WriteString(utils.T("api.mattermostimport.add_files.too_large", map[string]interface{}{"Filename": file.Name}))
+			continue
+		}
+
+		if err := WriteFile(data, path); err != nil {
+			log.WriteString(utils.T("api.mattermostimport.add_files.failed", map[string]interface{}{"Filename": file.Name}))
+			continue
+		}
+
+		log.WriteString(parts[4] + "\r\n")
+	}
+}
+
+// mattermostIsSafeFilePath checks that a path from an archive stays inside the folder that it's written to
+func mattermostIsSafeFilePath(path string) bool {
+	if len(path) == 0 || filepath.IsAbs(path) || strings.HasPrefix(path, "/") || strings.HasPrefix(path, "\\") {
+		return false
+	}
+
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == ".." {
+			return false
+		}
+	}
+
+	return true
+}
+
+type postsByCreateAt []*model.Post
+
+func (p postsByCreateAt) Len() int           { return len(p) }
+func (p postsByCreateAt) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p postsByCreateAt) Less(i, j int) bool { return p[i].CreateAt < p[j].CreateAt }
@@ -0,0 +1,114 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestImportFromReader(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+
+	reply := &model.Post{ChannelId: th.BasicChannel.Id, Message: "a reply " + model.NewId(), RootId: th.BasicPost.Id, ParentId: th.BasicPost.Id}
+	reply = th.BasicClient.Must(th.BasicClient.CreatePost(reply)).Data.(*model.Post)
+
+	options := &model.ExportOptions{TeamsToExport: []string{th.BasicTeam.Id}}
+
+	var buf bytes.Buffer
+	if err := ExportToWriter(&buf, options, &model.ExportManifest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err, log := ImportFromReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), nil, th.SystemAdminTeam.Id); err != nil {
+		t.Fatal(err)
+	} else if log == nil || log.Len() == 0 {
+		t.Fatal("should have written an import log")
+	}
+
+	var channel *model.Channel
+	if result := <-Srv.Store.Channel().GetByName(th.SystemAdminTeam.Id, th.BasicChannel.Name); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		channel = result.Data.(*model.Channel)
+	}
+
+	if channel.Id == th.BasicChannel.Id {
+		t.Fatal("imported channel should have a new id")
+	}
+
+	if result := <-Srv.Store.Channel().GetMember(channel.Id, th.BasicUser.Id); result.Err != nil {
+		t.Fatal("existing user should have been added to the imported channel")
+	}
+
+	var postList *model.PostList
+	if result := <-Srv.Store.Post().GetPosts(channel.Id, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		postList = result.Data.(*model.PostList)
+	}
+
+	var root, imported *model.Post
+	for _, post := range postList.Posts {
+		if post.Message == th.BasicPost.Message {
+			root = post
+		} else if post.Message == reply.Message {
+			imported = post
+		}
+	}
+
+	if root == nil || imported == nil {
+		t.Fatal("posts should have been imported")
+	}
+
+	if imported.RootId != root.Id || imported.ParentId != root.Id {
+		t.Fatal("reply should point at the imported root post")
+	}
+
+	if imported.UserId != th.BasicUser.Id {
+		t.Fatal("post should belong to the existing user")
+	}
+
+	if err, _ := ImportFromReader(bytes.NewReader([]byte("not a zip")), 9, nil, th.SystemAdminTeam.Id); err == nil {
+		t.Fatal("should have failed to read a bad archive")
+	}
+
+	buf.Reset()
+	options = &model.ExportOptions{TeamsToExport: []string{th.BasicTeam.Id, th.SystemAdminTeam.Id}}
+	if err := ExportToWriter(&buf, options, &model.ExportManifest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err, _ := ImportFromReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), nil, th.SystemAdminTeam.Id); err == nil {
+		t.Fatal("shouldn't have merged several teams into one")
+	}
+}
+
+func TestMattermostUniqueUsername(t *testing.T) {
+	th := Setup().InitBasic()
+
+	if username := mattermostUniqueUsername("unused" + model.NewId()[:8]); len(username) != 14 {
+		t.Fatal("should've kept an unused username")
+	}
+
+	if username := mattermostUniqueUsername(th.BasicUser.Username); username == th.BasicUser.Username || !model.IsValidUsername(username) {
+		t.Fatal("should've renamed a taken username", username)
+	}
+}
+
+func TestMattermostIsSafeFilePath(t *testing.T) {
+	for _, path := range []string{"file.png", "dir/file.png", "a..b/file.png"} {
+		if !mattermostIsSafeFilePath(path) {
+			t.Fatal("should've allowed " + path)
+		}
+	}
+
+	for _, path := range []string{"", "/etc/passwd", "\\windows\\file", "../file.png", "dir/../../file.png", "dir\\..\\file.png"} {
+		if mattermostIsSafeFilePath(path) {
+			t.Fatal("shouldn't have allowed " + path)
+		}
+	}
+}
@@ -15,8 +15,8 @@ import (
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/gorilla/mux"
-	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
 )
 
@@ -24,6 +24,12 @@ func InitOAuth() {
 	l4g.Debug(utils.T("api.oauth.init.debug"))
 
 	BaseRoutes.OAuth.Handle("/register", ApiUserRequired(registerOAuthApp)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/list", ApiUserRequired(getOAuthApps)).Methods("GET")
+	BaseRoutes.OAuth.Handle("/authorized", ApiUserRequired(getAuthorizedOAuthApps)).Methods("GET")
+	BaseRoutes.OAuth.Handle("/{client_id:[A-Za-z0-9]+}/update", ApiUserRequired(updateOAuthApp)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/{client_id:[A-Za-z0-9]+}/delete", ApiUserRequired(deleteOAuthApp)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/{client_id:[A-Za-z0-9]+}/regen_secret", ApiUserRequired(regenerateOAuthAppSecret)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/{client_id:[A-Za-z0-9]+}/deauthorize", ApiUserRequired(deauthorizeOAuthApp)).Methods("POST")
 	BaseRoutes.OAuth.Handle("/allow", ApiUserRequired(allowOAuth)).Methods("GET")
 	BaseRoutes.OAuth.Handle("/{service:[A-Za-z]+}/complete", AppHandlerIndependent(completeOAuth)).Methods("GET")
 	BaseRoutes.OAuth.Handle("/{service:[A-Za-z]+}/login", AppHandlerIndependent(loginWithOAuth)).Methods("GET")
@@ -59,6 +65,10 @@ func registerOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
 	app.ClientSecret = secret
 	app.CreatorId = c.Session.UserId
 
+	if !checkIntegrationBot(c, app.BotUserId, "registerOAuthApp") {
+		return
+	}
+
 	if result := <-Srv.Store.OAuth().SaveApp(app); result.Err != nil {
 		c.Err = result.Err
 		return
@@ -74,6 +84,229 @@ func registerOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
 
 }
 
+func checkOAuthServiceProviderEnabled(c *Context, where string) bool {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError(where, "api.oauth.register_oauth_app.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return false
+	}
+
+	return true
+}
+
+// getOAuthAppForEditing gets the app from the request's url if the current user created it or is a system admin
+func getOAuthAppForEditing(c *Context, r *http.Request, where string) *model.OAuthApp {
+	clientId := mux.Vars(r)["client_id"]
+	if len(clientId) != 26 {
+		c.SetInvalidParam(where, "client_id")
+		return nil
+	}
+
+	var app *model.OAuthApp
+	if result := <-Srv.Store.OAuth().GetApp(clientId); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusNotFound
+		return nil
+	} else {
+		app = result.Data.(*model.OAuthApp)
+	}
+
+	if app.CreatorId != c.Session.UserId && !c.IsSystemAdmin() {
+		c.LogAudit("fail - inappropriate permissions client_id=" + clientId)
+		c.Err = model.NewLocAppError(where, "api.oauth.permissions.app_error", nil, "client_id="+clientId)
+		c.Err.StatusCode = http.StatusForbidden
+		return nil
+	}
+
+	return app
+}
+
+// getOAuthApps returns the apps registered by the current user, or every app for a system admin
+func getOAuthApps(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkOAuthServiceProviderEnabled(c, "getOAuthApps") {
+		return
+	}
+
+	var schan store.StoreChannel
+	if c.IsSystemAdmin() {
+		schan = Srv.Store.OAuth().GetApps()
+	} else {
+		schan = Srv.Store.OAuth().GetAppByUser(c.Session.UserId)
+	}
+
+	if result := <-schan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		apps := result.Data.([]*model.OAuthApp)
+		for _, app := range apps {
+			app.Sanitize()
+		}
+
+		w.Write([]byte(model.OAuthAppListToJson(apps)))
+	}
+}
+
+func updateOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkOAuthServiceProviderEnabled(c, "updateOAuthApp") {
+		return
+	}
+
+	oldApp := getOAuthAppForEditing(c, r, "updateOAuthApp")
+	if oldApp == nil {
+		return
+	}
+
+	app := model.OAuthAppFromJson(r.Body)
+	if app == nil {
+		c.SetInvalidParam("updateOAuthApp", "app")
+		return
+	}
+
+	app.Id = oldApp.Id
+	app.CreatorId = oldApp.CreatorId
+	app.ClientSecret = oldApp.ClientSecret
+	app.CreateAt = oldApp.CreateAt
+
+	if app.BotUserId != oldApp.BotUserId && !checkIntegrationBot(c, app.BotUserId, "updateOAuthApp") {
+		return
+	}
+
+	if result := <-Srv.Store.OAuth().UpdateApp(app); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		app = result.Data.([2]*model.OAuthApp)[0]
+	}
+
+	// tokens that were given out before the app's scope was reduced can't be trusted with more than the app has now
+	if !model.OAuthScopeIsSubset(oldApp.Scope, app.Scope) {
+		if err := revokeOAuthAppAccess(app.Id, ""); err != nil {
+			c.Err = err
+			return
+		}
+	} else if oldApp.BotUserId != "" && app.BotUserId != oldApp.BotUserId {
+		// the app shouldn't be able to keep acting as a bot that it's no longer linked to
+		if err := revokeOAuthAppAccess(app.Id, oldApp.BotUserId); err != nil {
+			c.Err = err
+			return
+		}
+	}
+
+	c.LogAudit("client_id=" + app.Id)
+
+	app.Sanitize()
+	w.Write([]byte(app.ToJson()))
+}
+
+func deleteOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkOAuthServiceProviderEnabled(c, "deleteOAuthApp") {
+		return
+	}
+
+	app := getOAuthAppForEditing(c, r, "deleteOAuthApp")
+	if app == nil {
+		return
+	}
+
+	if err := revokeOAuthAppAccess(app.Id, ""); err != nil {
+		c.Err = err
+		return
+	}
+
+	if result := <-Srv.Store.OAuth().DeleteApp(app.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	c.LogAudit("client_id=" + app.Id)
+
+	w.Write([]byte(model.MapToJson(map[string]string{"id": app.Id})))
+}
+
+// regenerateOAuthAppSecret gives an app a new secret. This is the only time that the new secret is sent back.
+func regenerateOAuthAppSecret(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkOAuthServiceProviderEnabled(c, "regenerateOAuthAppSecret") {
+		return
+	}
+
+	app := getOAuthAppForEditing(c, r, "regenerateOAuthAppSecret")
+	if app == nil {
+		return
+	}
+
+	secret := model.NewId()
+
+	if result := <-Srv.Store.OAuth().UpdateAppSecret(app.Id, secret); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	c.LogAudit("client_id=" + app.Id)
+
+	app.ClientSecret = secret
+	w.Write([]byte(app.ToJson()))
+}
+
+// getAuthorizedOAuthApps returns the apps that the current user has given access to their account
+func getAuthorizedOAuthApps(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkOAuthServiceProviderEnabled(c, "getAuthorizedOAuthApps") {
+		return
+	}
+
+	if result := <-Srv.Store.OAuth().GetAuthorizedApps(c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		apps := result.Data.([]*model.OAuthApp)
+		for _, app := range apps {
+			app.Sanitize()
+		}
+
+		w.Write([]byte(model.OAuthAppListToJson(apps)))
+	}
+}
+
+// deauthorizeOAuthApp revokes every token that the current user has given to an app
+func deauthorizeOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkOAuthServiceProviderEnabled(c, "deauthorizeOAuthApp") {
+		return
+	}
+
+	clientId := mux.Vars(r)["client_id"]
+	if len(clientId) != 26 {
+		c.SetInvalidParam("deauthorizeOAuthApp", "client_id")
+		return
+	}
+
+	if err := revokeOAuthAppAccess(clientId, c.Session.UserId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("client_id=" + clientId)
+
+	w.Write([]byte(model.MapToJson(map[string]string{"id": clientId})))
+}
+
+// revokeOAuthAppAccess revokes the tokens that a user has given to an app, or all of the app's tokens if userId is empty
+func revokeOAuthAppAccess(clientId string, userId string) *model.AppError {
+	var accessData []*model.AccessData
+	if result := <-Srv.Store.OAuth().GetAccessDataForApp(clientId, userId); result.Err != nil {
+		return result.Err
+	} else {
+		accessData = result.Data.([]*model.AccessData)
+	}
+
+	for _, ad := range accessData {
+		if err := RevokeAccessToken(ad.Token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func allowOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
 		c.Err = model.NewLocAppError("allowOAuth", "api.oauth.allow_oauth.turn_off.app_error", nil, "")
@@ -127,6 +360,15 @@ func allowOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// an app can ask for less than it was registered with but never for more
+	if scope = model.KnownOAuthScopes(scope); len(scope) == 0 {
+		scope = app.Scope
+	} else if !model.OAuthScopeIsSubset(scope, app.Scope) {
+		responseData["redirect"] = redirectUri + "?error=invalid_scope&state=" + state
+		w.Write([]byte(model.MapToJson(responseData)))
+		return
+	}
+
 	authData := &model.AuthData{UserId: c.Session.UserId, ClientId: clientId, CreateAt: model.GetMillis(), RedirectUri: redirectUri, State: state, Scope: scope}
 	authData.Code = model.HashPassword(fmt.Sprintf("%v:%v:%v:%v", clientId, redirectUri, authData.CreateAt, c.Session.UserId))
 
@@ -191,7 +433,7 @@ func completeOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	uri := c.GetSiteURL() + "/signup/" + service + "/complete"
 
-	if body, teamId, props, err := AuthorizeOAuthUser(service, code, state, uri); err != nil {
+	if body, teamId, props, err := AuthorizeOAuthUser(w, r, service, code, state, uri); err != nil {
 		c.Err = err
 		return
 	} else {
@@ -260,6 +502,13 @@ func authorizeOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 		app = result.Data.(*model.OAuthApp)
 	}
 
+	if scope = model.KnownOAuthScopes(scope); len(scope) == 0 {
+		scope = app.Scope
+	} else if !model.OAuthScopeIsSubset(scope, app.Scope) {
+		c.Err = model.NewLocAppError("authorizeOAuth", "web.authorize_oauth.invalid_scope.app_error", nil, "scope="+scope)
+		return
+	}
+
 	var team *model.Team
 	if result := <-Srv.Store.Team().Get(c.TeamId); result.Err != nil {
 		c.Err = result.Err
@@ -294,7 +543,13 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 
 	grantType := r.FormValue("grant_type")
-	if grantType != model.ACCESS_TOKEN_GRANT_TYPE {
+	if grantType == model.CLIENT_CREDENTIALS_GRANT_TYPE {
+		getClientCredentialsAccessToken(c, w, r)
+		return
+	} else if grantType == model.REFRESH_TOKEN_GRANT_TYPE {
+		refreshAccessToken(c, w, r)
+		return
+	} else if grantType != model.ACCESS_TOKEN_GRANT_TYPE {
 		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.bad_grant.app_error", nil, "")
 		return
 	}
@@ -393,35 +648,199 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 		user = result.Data.(*model.User)
 	}
 
+	scope := authData.Scope
+	if len(scope) == 0 {
+		scope = app.Scope
+	}
+
+	accessData := &model.AccessData{ClientId: app.Id, UserId: user.Id, AuthCode: authData.Code, RefreshToken: model.NewId(), RedirectUri: callback, Scope: scope}
+	if c.Err = saveOAuthAccessData(user, accessData); c.Err != nil {
+		return
+	}
+
+	c.LogAuditWithUserId(user.Id, "success")
+
+	writeAccessResponse(w, accessData)
+}
+
+// saveOAuthAccessData creates a session that's limited to the access data's scope and saves the session's token with
+// the access data
+func saveOAuthAccessData(user *model.User, accessData *model.AccessData) *model.AppError {
 	session := &model.Session{UserId: user.Id, Roles: user.Roles, IsOAuth: true}
+	session.AddProp(model.SESSION_PROP_OAUTH_SCOPE, accessData.Scope)
+	session.SetExpireInDays(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays)
 
 	if result := <-Srv.Store.Session().Save(session); result.Err != nil {
-		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_session.app_error", nil, "")
-		return
+		return model.NewLocAppError("getAccessToken", "web.get_access_token.internal_session.app_error", nil, "")
 	} else {
 		session = result.Data.(*model.Session)
 		AddSessionToCache(session)
 	}
 
-	accessData := &model.AccessData{AuthCode: authData.Code, Token: session.Token, RedirectUri: callback}
+	accessData.Token = session.Token
 
 	if result := <-Srv.Store.OAuth().SaveAccessData(accessData); result.Err != nil {
 		l4g.Error(result.Err)
-		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_saving.app_error", nil, "")
-		return
+		return model.NewLocAppError("getAccessToken", "web.get_access_token.internal_saving.app_error", nil, "")
 	}
 
-	accessRsp := &model.AccessResponse{AccessToken: session.Token, TokenType: model.ACCESS_TOKEN_TYPE, ExpiresIn: int32(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays * 60 * 60 * 24)}
+	return nil
+}
+
+func writeAccessResponse(w http.ResponseWriter, accessData *model.AccessData) {
+	accessRsp := &model.AccessResponse{
+		AccessToken:  accessData.Token,
+		TokenType:    model.ACCESS_TOKEN_TYPE,
+		ExpiresIn:    int32(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays * 60 * 60 * 24),
+		Scope:        accessData.Scope,
+		RefreshToken: accessData.RefreshToken,
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("Pragma", "no-cache")
 
-	c.LogAuditWithUserId(user.Id, "success")
-
 	w.Write([]byte(accessRsp.ToJson()))
 }
 
+// getOAuthAppWithSecret looks up the app making a token request and checks its secret
+func getOAuthAppWithSecret(c *Context, r *http.Request) *model.OAuthApp {
+	clientId := r.FormValue("client_id")
+	if len(clientId) != 26 {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.bad_client_id.app_error", nil, "")
+		return nil
+	}
+
+	secret := r.FormValue("client_secret")
+	if len(secret) == 0 {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.bad_client_secret.app_error", nil, "")
+		return nil
+	}
+
+	var app *model.OAuthApp
+	if result := <-Srv.Store.OAuth().GetApp(clientId); result.Err != nil {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.credentials.app_error", nil, "")
+		return nil
+	} else {
+		app = result.Data.(*model.OAuthApp)
+	}
+
+	if !model.ComparePassword(app.ClientSecret, secret) {
+		c.LogAudit("fail - invalid client credentials")
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.credentials.app_error", nil, "")
+		return nil
+	}
+
+	return app
+}
+
+// refreshAccessToken replaces an access token with a new one. The refresh token can only be used once so a new one is
+// given out with the new access token.
+func refreshAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	app := getOAuthAppWithSecret(c, r)
+	if app == nil {
+		return
+	}
+
+	refreshToken := r.FormValue("refresh_token")
+	if len(refreshToken) != 26 {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.missing_refresh_token.app_error", nil, "")
+		return
+	}
+
+	var oldAccessData *model.AccessData
+	if result := <-Srv.Store.OAuth().GetAccessDataByRefreshToken(refreshToken); result.Err != nil {
+		c.LogAudit("fail - invalid refresh token")
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.refresh_token.app_error", nil, "")
+		return
+	} else {
+		oldAccessData = result.Data.(*model.AccessData)
+	}
+
+	if oldAccessData.ClientId != app.Id {
+		c.LogAudit("fail - refresh token belongs to another app")
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.refresh_token.app_error", nil, "")
+		return
+	}
+
+	var user *model.User
+	if result := <-Srv.Store.User().Get(oldAccessData.UserId); result.Err != nil {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_user.app_error", nil, "")
+		return
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	// the refresh token is consumed before anything else so that only one of several requests using it can succeed,
+	// and the auth code is kept so that it can't be exchanged again
+	if result := <-Srv.Store.OAuth().RemoveAccessDataByRefreshToken(oldAccessData.Token, refreshToken); result.Err != nil {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_saving.app_error", nil, "")
+		return
+	} else if !result.Data.(bool) {
+		c.LogAudit("fail - refresh token already used")
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.refresh_token.app_error", nil, "")
+		return
+	}
+
+	sessionCache.Remove(oldAccessData.Token)
+	if result := <-Srv.Store.Session().Remove(oldAccessData.Token); result.Err != nil {
+		l4g.Error(utils.T("web.get_access_token.revoking.error") + result.Err.Message)
+	}
+
+	if user.DeleteAt != 0 {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.refresh_token.app_error", nil, "user_id="+user.Id)
+		return
+	}
+
+	accessData := &model.AccessData{
+		ClientId:     app.Id,
+		UserId:       user.Id,
+		AuthCode:     oldAccessData.AuthCode,
+		RefreshToken: model.NewId(),
+		RedirectUri:  oldAccessData.RedirectUri,
+		Scope:        oldAccessData.Scope,
+	}
+	if c.Err = saveOAuthAccessData(user, accessData); c.Err != nil {
+		return
+	}
+
+	c.LogAuditWithUserId(user.Id, "success - refreshed client_id="+app.Id)
+
+	writeAccessResponse(w, accessData)
+}
+
+// getClientCredentialsAccessToken gives an app a session for its bot in exchange for the app's id and secret
+func getClientCredentialsAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	app := getOAuthAppWithSecret(c, r)
+	if app == nil {
+		return
+	}
+
+	if len(app.BotUserId) == 0 {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.no_bot.app_error", nil, "client_id="+app.Id)
+		return
+	}
+
+	var bot *model.User
+	if result := <-Srv.Store.User().Get(app.BotUserId); result.Err != nil {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_user.app_error", nil, "")
+		return
+	} else if bot = result.Data.(*model.User); !bot.IsBot || bot.DeleteAt != 0 {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.no_bot.app_error", nil, "client_id="+app.Id)
+		return
+	}
+
+	// the app can get a new token with its credentials at any time so it isn't given a refresh token
+	accessData := &model.AccessData{ClientId: app.Id, UserId: bot.Id, Scope: app.Scope}
+	if c.Err = saveOAuthAccessData(bot, accessData); c.Err != nil {
+		return
+	}
+
+	c.LogAuditWithUserId(bot.Id, "success - client_id="+app.Id)
+
+	writeAccessResponse(w, accessData)
+}
+
 func loginWithOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	service := params["service"]
@@ -439,7 +858,7 @@ func loginWithOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 		stateProps["team_id"] = teamId
 	}
 
-	if authUrl, err := GetAuthorizationCode(c, service, stateProps, loginHint); err != nil {
+	if authUrl, err := GetAuthorizationCode(c, w, r, service, stateProps, loginHint); err != nil {
 		c.Err = err
 		return
 	} else {
@@ -499,7 +918,7 @@ func signupWithOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 		stateProps["team_id"] = teamId
 	}
 
-	if authUrl, err := GetAuthorizationCode(c, service, stateProps, ""); err != nil {
+	if authUrl, err := GetAuthorizationCode(c, w, r, service, stateProps, ""); err != nil {
 		c.Err = err
 		return
 	} else {
@@ -507,10 +926,13 @@ func signupWithOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func GetAuthorizationCode(c *Context, service string, props map[string]string, loginHint string) (string, *model.AppError) {
+func GetAuthorizationCode(c *Context, w http.ResponseWriter, r *http.Request, service string, props map[string]string, loginHint string) (string, *model.AppError) {
+	if provider := getOpenIdProvider(service); provider != nil {
+		return getOpenIdAuthorizationCode(c, w, r, provider, service, props, loginHint)
+	}
 
 	sso := utils.Cfg.GetSSOService(service)
-	if sso != nil && !sso.Enable {
+	if sso == nil || !sso.Enable {
 		return "", model.NewLocAppError("GetAuthorizationCode", "api.user.get_authorization_code.unsupported.app_error", nil, "service="+service)
 	}
 
@@ -536,23 +958,38 @@ func GetAuthorizationCode(c *Context, service string, props map[string]string, l
 	return authUrl, nil
 }
 
-func AuthorizeOAuthUser(service, code, state, redirectUri string) (io.ReadCloser, string, map[string]string, *model.AppError) {
-	sso := utils.Cfg.GetSSOService(service)
-	if sso == nil || !sso.Enable {
-		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.unsupported.app_error", nil, "service="+service)
-	}
-
+// decodeOAuthState returns the props that were passed through the OAuth service in the state, making sure that they
+// were meant for the given client
+func decodeOAuthState(where string, state string, clientId string) (map[string]string, *model.AppError) {
 	stateStr := ""
 	if b, err := b64.StdEncoding.DecodeString(state); err != nil {
-		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.invalid_state.app_error", nil, err.Error())
+		return nil, model.NewLocAppError(where, "api.user.authorize_oauth_user.invalid_state.app_error", nil, err.Error())
 	} else {
 		stateStr = string(b)
 	}
 
 	stateProps := model.MapFromJson(strings.NewReader(stateStr))
 
-	if !model.ComparePassword(stateProps["hash"], sso.Id) {
-		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.invalid_state.app_error", nil, "")
+	if !model.ComparePassword(stateProps["hash"], clientId) {
+		return nil, model.NewLocAppError(where, "api.user.authorize_oauth_user.invalid_state.app_error", nil, "")
+	}
+
+	return stateProps, nil
+}
+
+func AuthorizeOAuthUser(w http.ResponseWriter, r *http.Request, service, code, state, redirectUri string) (io.ReadCloser, string, map[string]string, *model.AppError) {
+	if provider := getOpenIdProvider(service); provider != nil {
+		return authorizeOpenIdUser(w, r, provider, code, state, redirectUri)
+	}
+
+	sso := utils.Cfg.GetSSOService(service)
+	if sso == nil || !sso.Enable {
+		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.unsupported.app_error", nil, "service="+service)
+	}
+
+	stateProps, err := decodeOAuthState("AuthorizeOAuthUser", state, sso.Id)
+	if err != nil {
+		return nil, "", nil, err
 	}
 
 	teamId := stateProps["team_id"]
@@ -611,7 +1048,7 @@ func AuthorizeOAuthUser(service, code, state, redirectUri string) (io.ReadCloser
 func CompleteSwitchWithOAuth(c *Context, w http.ResponseWriter, r *http.Request, service string, userData io.ReadCloser, email string) {
 	authData := ""
 	ssoEmail := ""
-	provider := einterfaces.GetOauthProvider(service)
+	provider := getOauthProvider(service)
 	if provider == nil {
 		c.Err = model.NewLocAppError("CompleteClaimWithOAuth", "api.user.complete_switch_with_oauth.unavailable.app_error",
 			map[string]interface{}{"Service": service}, "")
@@ -631,6 +1068,16 @@ func CompleteSwitchWithOAuth(c *Context, w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	serviceName := strings.Title(service)
+	if settings := utils.Cfg.GetOpenIdService(service); settings != nil {
+		serviceName = settings.ButtonText
+	}
+
+	completeSwitchWithSSO(c, service, serviceName+" SSO", authData, ssoEmail, email)
+}
+
+// completeSwitchWithSSO moves the user with the given email over to signing in with an SSO service
+func completeSwitchWithSSO(c *Context, service string, method string, authData string, ssoEmail string, email string) {
 	if len(email) == 0 {
 		c.Err = model.NewLocAppError("CompleteClaimWithOAuth", "api.user.complete_switch_with_oauth.blank_email.app_error", nil, "")
 		return
@@ -654,5 +1101,5 @@ func CompleteSwitchWithOAuth(c *Context, w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	go sendSignInChangeEmail(c, user.Email, c.GetSiteURL(), strings.Title(service)+" SSO")
+	go sendSignInChangeEmail(c, user.Email, c.GetSiteURL(), method)
 }
@@ -139,3 +139,158 @@ func TestAllowOAuth(t *testing.T) {
 		}
 	}
 }
+
+func getTestOAuthCode(t *testing.T, Client *model.Client, app *model.OAuthApp, scope string) string {
+	result := Client.Must(Client.AllowOAuth(model.AUTHCODE_RESPONSE_TYPE, app.Id, app.CallbackUrls[0], scope, "123")).Data.(map[string]string)
+
+	ru, _ := url.Parse(result["redirect"])
+	if ru == nil {
+		t.Fatal("redirect url unparseable")
+	}
+
+	return ru.Query().Get("code")
+}
+
+func TestOAuthScopesAndRefresh(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableOAuth := utils.Cfg.ServiceSettings.EnableOAuthServiceProvider
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = enableOAuth
+	}()
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+
+	app := &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", CallbackUrls: []string{"https://nowhere.com"}, Scope: model.OAUTH_SCOPE_READ}
+	app = Client.Must(Client.RegisterApp(app)).Data.(*model.OAuthApp)
+
+	if result, err := Client.AllowOAuth(model.AUTHCODE_RESPONSE_TYPE, app.Id, app.CallbackUrls[0], "read write", "123"); err != nil {
+		t.Fatal(err)
+	} else if ru, _ := url.Parse(result.Data.(map[string]string)["redirect"]); ru.Query().Get("error") != "invalid_scope" {
+		t.Fatal("shouldn't be able to ask for more than the app's scope")
+	}
+
+	data := url.Values{
+		"grant_type":    {model.ACCESS_TOKEN_GRANT_TYPE},
+		"client_id":     {app.Id},
+		"client_secret": {app.ClientSecret},
+		"code":          {getTestOAuthCode(t, Client, app, "")},
+		"redirect_uri":  {app.CallbackUrls[0]},
+	}
+
+	rsp := Client.Must(Client.GetAccessToken(data)).Data.(*model.AccessResponse)
+	if rsp.Scope != model.OAUTH_SCOPE_READ || len(rsp.RefreshToken) == 0 {
+		t.Fatal("should've returned the scope and a refresh token")
+	}
+
+	AppClient := th.CreateClient()
+	AppClient.AuthToken = rsp.AccessToken
+	AppClient.AuthType = model.HEADER_BEARER
+	AppClient.SetTeamId(th.BasicTeam.Id)
+
+	if _, err := AppClient.GetMe(""); err != nil {
+		t.Fatal("should be able to read with the read scope", err)
+	}
+
+	if _, err := AppClient.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "test"}); err == nil || err.Id != "api.context.oauth_scope.app_error" {
+		t.Fatal("shouldn't be able to write with only the read scope")
+	}
+
+	refresh := url.Values{
+		"grant_type":    {model.REFRESH_TOKEN_GRANT_TYPE},
+		"client_id":     {app.Id},
+		"client_secret": {app.ClientSecret},
+		"refresh_token": {"12345678901234567890123456"},
+	}
+	if _, err := Client.GetAccessToken(refresh); err == nil {
+		t.Fatal("should've failed with a bad refresh token")
+	}
+
+	refresh.Set("refresh_token", rsp.RefreshToken)
+	newRsp := Client.Must(Client.GetAccessToken(refresh)).Data.(*model.AccessResponse)
+	if newRsp.AccessToken == rsp.AccessToken || newRsp.RefreshToken == rsp.RefreshToken || newRsp.Scope != rsp.Scope {
+		t.Fatal("should've been given new tokens with the same scope")
+	}
+
+	if _, err := AppClient.GetMe(""); err == nil {
+		t.Fatal("old access token should've been revoked")
+	}
+
+	if _, err := Client.GetAccessToken(refresh); err == nil {
+		t.Fatal("refresh token should only work once")
+	}
+
+	AppClient.AuthToken = newRsp.AccessToken
+	if _, err := AppClient.GetMe(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if apps := Client.Must(Client.GetAuthorizedOAuthApps()).Data.([]*model.OAuthApp); len(apps) != 1 || apps[0].Id != app.Id {
+		t.Fatal("should've listed the authorized app")
+	}
+
+	Client.Must(Client.DeauthorizeOAuthApp(app.Id))
+
+	if _, err := AppClient.GetMe(""); err == nil {
+		t.Fatal("access should've been revoked")
+	}
+
+	if apps := Client.Must(Client.GetAuthorizedOAuthApps()).Data.([]*model.OAuthApp); len(apps) != 0 {
+		t.Fatal("app should no longer be authorized")
+	}
+}
+
+func TestOAuthAppManagement(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableOAuth := utils.Cfg.ServiceSettings.EnableOAuthServiceProvider
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = enableOAuth
+	}()
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+
+	app := &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", CallbackUrls: []string{"https://nowhere.com"}}
+	app = Client.Must(Client.RegisterApp(app)).Data.(*model.OAuthApp)
+	secret := app.ClientSecret
+
+	if apps := Client.Must(Client.GetOAuthApps()).Data.([]*model.OAuthApp); len(apps) != 1 || apps[0].Id != app.Id || apps[0].ClientSecret != "" {
+		t.Fatal("should've listed the user's app without its secret")
+	}
+
+	app.Name = "NewName"
+	app.Scope = "read admin"
+	if updated := Client.Must(Client.UpdateOAuthApp(app)).Data.(*model.OAuthApp); updated.Name != "NewName" || updated.Scope != "read admin" {
+		t.Fatal("should've updated the app")
+	}
+
+	app.Scope = "everything"
+	if _, err := Client.UpdateOAuthApp(app); err == nil {
+		t.Fatal("should've failed with an invalid scope")
+	}
+
+	th.LoginBasic2()
+	if apps := Client.Must(Client.GetOAuthApps()).Data.([]*model.OAuthApp); len(apps) != 0 {
+		t.Fatal("shouldn't list another user's apps")
+	}
+
+	if _, err := Client.RegenerateOAuthAppSecret(app.Id); err == nil {
+		t.Fatal("shouldn't be able to change another user's app")
+	}
+
+	if _, err := Client.DeleteOAuthApp(app.Id); err == nil {
+		t.Fatal("shouldn't be able to delete another user's app")
+	}
+
+	th.LoginBasic()
+	regenerated := Client.Must(Client.RegenerateOAuthAppSecret(app.Id)).Data.(*model.OAuthApp)
+	if regenerated.ClientSecret == "" || regenerated.ClientSecret == secret {
+		t.Fatal("should've returned a new secret")
+	}
+
+	Client.Must(Client.DeleteOAuthApp(app.Id))
+
+	if apps := Client.Must(Client.GetOAuthApps()).Data.([]*model.OAuthApp); len(apps) != 0 {
+		t.Fatal("should've deleted the app")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"crypto/tls"
+	b64 "encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	oauthopenid "github.com/mattermost/platform/model/openid"
+	"github.com/mattermost/platform/utils"
+)
+
+type cachedOpenIdProvider struct {
+	provider *oauthopenid.Provider
+	settings model.OpenIdSettings
+	insecure bool
+}
+
+// openIdProviders keeps the providers for the configured OpenID Connect IdPs so that their discovery documents and
+// keys aren't fetched for every login
+var openIdProviders = make(map[string]*cachedOpenIdProvider)
+var openIdProvidersMutex sync.Mutex
+
+// getOpenIdProvider returns the provider for an enabled OpenID Connect IdP, or nil if the service isn't one. The
+// provider is created again whenever the IdP's settings change.
+func getOpenIdProvider(service string) *oauthopenid.Provider {
+	settings := utils.Cfg.GetOpenIdService(service)
+	if settings == nil || !settings.Enable {
+		return nil
+	}
+
+	insecure := *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections
+
+	openIdProvidersMutex.Lock()
+	defer openIdProvidersMutex.Unlock()
+
+	if cached, ok := openIdProviders[service]; ok && cached.settings == *settings && cached.insecure == insecure {
+		return cached.provider
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+		},
+		Timeout: 30 * time.Second,
+	}
+
+	provider := oauthopenid.NewProvider(*settings, client)
+	openIdProviders[service] = &cachedOpenIdProvider{provider, *settings, insecure}
+
+	return provider
+}
+
+// getOauthProvider returns the provider that turns what a service says about a user into a user, which is either
+// one of the configured OpenID Connect IdPs or one of the registered OAuth providers
+func getOauthProvider(service string) einterfaces.OauthProvider {
+	if provider := getOpenIdProvider(service); provider != nil {
+		return provider
+	}
+
+	return einterfaces.GetOauthProvider(service)
+}
+
+// getOpenIdAuthorizationCode returns the url that starts a sign in with the IdP. The nonce is also set in a cookie so
+// that the sign in can only be completed by the browser that started it.
+func getOpenIdAuthorizationCode(c *Context, w http.ResponseWriter, r *http.Request, provider *oauthopenid.Provider, service string, props map[string]string, loginHint string) (string, *model.AppError) {
+	props["hash"] = model.HashPassword(provider.Settings.ClientId)
+	props["nonce"] = model.NewId()
+	state := b64.StdEncoding.EncodeToString([]byte(model.MapToJson(props)))
+
+	redirectUri := c.GetSiteURL() + "/signup/" + service + "/complete"
+
+	authUrl, err := provider.GetAuthorizationUrl(redirectUri, state, props["nonce"], loginHint)
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthopenid.STATE_COOKIE,
+		Value:    props["nonce"],
+		Path:     "/",
+		MaxAge:   int(oauthopenid.STATE_EXPIRY / time.Second),
+		HttpOnly: true,
+		Secure:   GetProtocol(r) == "https",
+	})
+
+	return authUrl, nil
+}
+
+// authorizeOpenIdUser exchanges the code for an ID token and returns its claims in place of the user data that the
+// other OAuth services return
+func authorizeOpenIdUser(w http.ResponseWriter, r *http.Request, provider *oauthopenid.Provider, code, state, redirectUri string) (io.ReadCloser, string, map[string]string, *model.AppError) {
+	stateProps, err := decodeOAuthState("authorizeOpenIdUser", state, provider.Settings.ClientId)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if len(stateProps["nonce"]) == 0 {
+		return nil, "", nil, model.NewLocAppError("authorizeOpenIdUser", "api.user.authorize_oauth_user.invalid_state.app_error", nil, "missing nonce")
+	}
+
+	cookie, cookieErr := r.Cookie(oauthopenid.STATE_COOKIE)
+	if cookieErr != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(stateProps["nonce"])) != 1 {
+		return nil, "", nil, model.NewLocAppError("authorizeOpenIdUser", "api.user.authorize_oauth_user.invalid_state.app_error", nil, "state wasn't issued to this browser")
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthopenid.STATE_COOKIE,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	claims, err := provider.Authorize(code, redirectUri, stateProps["nonce"])
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(claims)), stateProps["team_id"], stateProps, nil
+}
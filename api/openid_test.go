@@ -0,0 +1,156 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/model/openid/openidtest"
+	"github.com/mattermost/platform/utils"
+)
+
+// signInWithOpenId follows the redirects from the login url through the IdP and back, returning the final response
+func signInWithOpenId(t *testing.T, Client *model.Client, service string) *http.Response {
+	resp, _ := followOpenIdRedirects(t, newOpenIdBrowser(), Client.ApiUrl+"/oauth/"+service+"/login", 3)
+	return resp
+}
+
+// newOpenIdBrowser returns a client that keeps cookies like a browser but doesn't follow redirects on its own
+func newOpenIdBrowser() *http.Client {
+	jar, _ := cookiejar.New(nil)
+
+	return &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// followOpenIdRedirects makes up to count requests, following redirects, and returns the last response along with
+// where it redirects to
+func followOpenIdRedirects(t *testing.T, client *http.Client, location string, count int) (*http.Response, string) {
+	var resp *http.Response
+	for i := 0; i < count; i++ {
+		var err error
+		if resp, err = client.Get(location); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusFound {
+			break
+		}
+
+		location = resp.Header.Get("Location")
+	}
+
+	return resp, location
+}
+
+func getSessionToken(resp *http.Response) string {
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == model.SESSION_COOKIE_TOKEN {
+			return cookie.Value
+		}
+	}
+
+	return ""
+}
+
+func TestOpenIdLogin(t *testing.T) {
+	th := Setup().InitBasic()
+
+	keycloak := openidtest.NewFakeIdP("mattermost", "secret")
+	defer keycloak.Close()
+
+	corp := openidtest.NewFakeIdP("mattermost-corp", "othersecret")
+	defer corp.Close()
+
+	openIdSettings := utils.Cfg.OpenIdSettings
+	defer func() {
+		utils.Cfg.OpenIdSettings = openIdSettings
+	}()
+	utils.Cfg.OpenIdSettings = []*model.OpenIdSettings{
+		{Name: "keycloak", Enable: true, DiscoveryEndpoint: keycloak.Issuer(), ClientId: keycloak.ClientId, Secret: keycloak.ClientSecret},
+		{Name: "corp", Enable: true, DiscoveryEndpoint: corp.Issuer(), ClientId: corp.ClientId, Secret: corp.ClientSecret, UsernameClaim: "login", EmailClaim: "mail"},
+		{Name: "disabled", Enable: false, DiscoveryEndpoint: corp.Issuer(), ClientId: corp.ClientId},
+	}
+	for _, settings := range utils.Cfg.OpenIdSettings {
+		settings.SetDefaults()
+	}
+
+	id := model.NewId()[:10]
+	keycloak.Claims = map[string]interface{}{
+		"sub":                model.NewId(),
+		"preferred_username": "oidc" + id,
+		"email":              "success+oidc" + id + "@simulator.amazonses.com",
+		"given_name":         "Open",
+		"family_name":        "Id",
+	}
+
+	resp := signInWithOpenId(t, th.BasicClient, "keycloak")
+	token := getSessionToken(resp)
+	if len(token) == 0 {
+		t.Fatal("should've signed in", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	Client := th.CreateClient()
+	Client.AuthToken = token
+	Client.AuthType = model.HEADER_BEARER
+
+	user := Client.Must(Client.GetMe("")).Data.(*model.User)
+	if user.Username != "oidc"+id || user.Email != "success+oidc"+id+"@simulator.amazonses.com" || user.FirstName != "Open" || user.LastName != "Id" {
+		t.Fatal("should've created the user from the IdP's claims")
+	}
+
+	if user.AuthService != "keycloak" || !user.IsOAuthUser() {
+		t.Fatal("should've created an OpenID Connect user")
+	}
+
+	resp = signInWithOpenId(t, th.BasicClient, "keycloak")
+	if token := getSessionToken(resp); len(token) == 0 {
+		t.Fatal("should've signed in again")
+	} else {
+		Client.AuthToken = token
+		if me := Client.Must(Client.GetMe("")).Data.(*model.User); me.Id != user.Id {
+			t.Fatal("should've signed in as the same user")
+		}
+	}
+
+	corp.Claims = map[string]interface{}{
+		"sub":   model.NewId(),
+		"login": "corp" + id,
+		"mail":  "success+corp" + id + "@simulator.amazonses.com",
+	}
+
+	resp = signInWithOpenId(t, th.BasicClient, "corp")
+	if token := getSessionToken(resp); len(token) == 0 {
+		t.Fatal("should've signed in with the second IdP")
+	} else {
+		Client.AuthToken = token
+		if me := Client.Must(Client.GetMe("")).Data.(*model.User); me.Username != "corp"+id || me.AuthService != "corp" {
+			t.Fatal("should've used the second IdP's claims")
+		}
+	}
+
+	if resp := signInWithOpenId(t, th.BasicClient, "disabled"); len(getSessionToken(resp)) != 0 {
+		t.Fatal("shouldn't be able to sign in with a disabled IdP")
+	}
+
+	// a sign in that was started in another browser can't be completed in this one
+	_, callback := followOpenIdRedirects(t, newOpenIdBrowser(), th.BasicClient.ApiUrl+"/oauth/keycloak/login", 2)
+	if resp, _ := followOpenIdRedirects(t, newOpenIdBrowser(), callback, 1); len(getSessionToken(resp)) != 0 {
+		t.Fatal("shouldn't be able to complete a sign in without the state cookie")
+	}
+
+	corp.Claims["sub"] = model.NewId()
+	corp.Claims["mail"] = user.Email
+	if resp := signInWithOpenId(t, th.BasicClient, "corp"); len(getSessionToken(resp)) != 0 {
+		t.Fatal("shouldn't be able to take over an account with the same email")
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"crypto/tls"
 	"fmt"
 	"html/template"
-	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path/filepath"
@@ -20,6 +18,7 @@ import (
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
@@ -29,6 +28,7 @@ func InitPost() {
 	l4g.Debug(utils.T("api.post.init.debug"))
 
 	BaseRoutes.NeedTeam.Handle("/posts/search", ApiUserRequired(searchPosts)).Methods("POST")
+	BaseRoutes.NeedTeam.Handle("/posts/threads", ApiUserRequired(getFollowedThreads)).Methods("GET")
 	BaseRoutes.NeedTeam.Handle("/posts/{post_id}", ApiUserRequired(getPostById)).Methods("GET")
 	BaseRoutes.NeedTeam.Handle("/pltmp/{post_id}", ApiUserRequired(getPermalinkTmp)).Methods("GET")
 
@@ -37,10 +37,22 @@ func InitPost() {
 	BaseRoutes.Posts.Handle("/page/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequiredActivity(getPosts, false)).Methods("GET")
 	BaseRoutes.Posts.Handle("/since/{time:[0-9]+}", ApiUserRequiredActivity(getPostsSince, false)).Methods("GET")
 
+	BaseRoutes.NeedChannel.Handle("/pinned", ApiUserRequired(getPinnedPosts)).Methods("GET")
+
+	BaseRoutes.Users.Handle("/flagged_posts/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequired(getFlaggedPosts)).Methods("GET")
+
 	BaseRoutes.NeedPost.Handle("/get", ApiUserRequired(getPost)).Methods("GET")
 	BaseRoutes.NeedPost.Handle("/delete", ApiUserRequired(deletePost)).Methods("POST")
 	BaseRoutes.NeedPost.Handle("/before/{offset:[0-9]+}/{num_posts:[0-9]+}", ApiUserRequired(getPostsBefore)).Methods("GET")
 	BaseRoutes.NeedPost.Handle("/after/{offset:[0-9]+}/{num_posts:[0-9]+}", ApiUserRequired(getPostsAfter)).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/pin", ApiUserRequired(pinPost)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/unpin", ApiUserRequired(unpinPost)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/thread", ApiUserRequired(getPostThread)).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/thread/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequired(getPostThread)).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/thread/follow", ApiUserRequired(followThread)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/thread/unfollow", ApiUserRequired(unfollowThread)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/thread/view", ApiUserRequired(viewThread)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/get_file_infos", ApiUserRequired(getFileInfosForPost)).Methods("GET")
 }
 
 func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -144,18 +156,75 @@ func CreatePost(c *Context, post *model.Post, triggerWebhooks bool) (*model.Post
 		}
 	}
 
+	if len(post.Filenames) > 0 || len(post.FileIds) > 0 {
+		post.FileIds = getFileIdsForNewPost(post)
+	}
+
 	var rpost *model.Post
 	if result := <-Srv.Store.Post().Save(post); result.Err != nil {
 		return nil, result.Err
 	} else {
 		rpost = result.Data.(*model.Post)
 
+		for _, fileId := range rpost.FileIds {
+			if result := <-Srv.Store.FileInfo().AttachToPost(fileId, rpost.Id); result.Err != nil {
+				l4g.Error(utils.T("api.post.create_post.attach_files.error"), rpost.Id, fileId, result.Err)
+			}
+		}
+
 		go handlePostEvents(c, rpost, triggerWebhooks)
 	}
 
 	return rpost, nil
 }
 
+// getFileIdsForNewPost returns the ids of the uploaded files that a new post is attached to, whether they were sent
+// as FileIds or as Filenames. Files that were only sent by id are added to Filenames for clients that don't use FileIds.
+func getFileIdsForNewPost(post *model.Post) []string {
+	fileIds := []string{}
+	seen := make(map[string]bool)
+	inFilenames := make(map[string]bool)
+
+	candidates := []string{}
+	for _, filename := range post.Filenames {
+		if info := model.GetInfoForFilename(filename, ""); info != nil && info.Id != "" {
+			candidates = append(candidates, info.Id)
+			inFilenames[info.Id] = true
+		}
+	}
+	candidates = append(candidates, post.FileIds...)
+
+	for _, fileId := range candidates {
+		if len(fileIds) == model.POST_MAX_FILE_IDS {
+			break
+		} else if seen[fileId] {
+			continue
+		}
+		seen[fileId] = true
+
+		var info *model.FileInfo
+		if result := <-Srv.Store.FileInfo().Get(fileId); result.Err != nil {
+			l4g.Error(utils.T("api.post.create_post.bad_file_id.error"), fileId)
+			continue
+		} else {
+			info = result.Data.(*model.FileInfo)
+		}
+
+		if info.CreatorId != post.UserId || info.ChannelId != post.ChannelId || info.PostId != "" {
+			l4g.Error(utils.T("api.post.create_post.bad_file_id.error"), fileId)
+			continue
+		}
+
+		fileIds = append(fileIds, fileId)
+
+		if !inFilenames[fileId] {
+			post.Filenames = append(post.Filenames, "/"+info.ChannelId+"/"+info.CreatorId+"/"+info.Id+"/"+utils.UrlEncode(info.Filename))
+		}
+	}
+
+	return fileIds
+}
+
 func CreateWebhookPost(c *Context, channelId, text, overrideUsername, overrideIconUrl string, props model.StringInterface, postType string) (*model.Post, *model.AppError) {
 	// parse links into Markdown format
 	linkWithTextRegex := regexp.MustCompile(`<([^<\|]+)\|([^>]+)>`)
@@ -230,6 +299,8 @@ func CreateWebhookPost(c *Context, channelId, text, overrideUsername, overrideIc
 }
 
 func handlePostEvents(c *Context, post *model.Post, triggerWebhooks bool) {
+	go indexPostForSearch(post)
+
 	tchan := Srv.Store.Team().Get(c.TeamId)
 	cchan := Srv.Store.Channel().Get(post.ChannelId)
 	uchan := Srv.Store.User().Get(post.UserId)
@@ -279,6 +350,9 @@ func handlePostEvents(c *Context, post *model.Post, triggerWebhooks bool) {
 		members = result.Data.([]model.ChannelMember)
 	}
 
+	// followers need to be up to date before anyone is notified about the reply
+	updateThreadMembers(post)
+
 	go sendNotifications(c, post, team, channel, profiles, members)
 	go checkForOutOfChannelMentions(c, post, channel, profiles, members)
 
@@ -393,79 +467,46 @@ func handleWebhookEvents(c *Context, post *model.Post, team *model.Team, channel
 	}
 
 	for _, hook := range relevantHooks {
-		go func(hook *model.OutgoingWebhook) {
-			payload := &model.OutgoingWebhookPayload{
-				Token:       hook.Token,
+		payload := &model.OutgoingWebhookPayload{
+			Token:       hook.Token,
+			TeamId:      hook.TeamId,
+			TeamDomain:  team.Name,
+			ChannelId:   post.ChannelId,
+			ChannelName: channel.Name,
+			Timestamp:   post.CreateAt,
+			UserId:      post.UserId,
+			UserName:    user.Username,
+			PostId:      post.Id,
+			Text:        post.Message,
+			TriggerWord: firstWord,
+		}
+
+		var body string
+		var contentType string
+		if hook.ContentType == "application/json" {
+			body = payload.ToJSON()
+			contentType = "application/json"
+		} else {
+			body = payload.ToFormValues()
+			contentType = "application/x-www-form-urlencoded"
+		}
+
+		for _, url := range hook.CallbackURLs {
+			delivery := &model.OutgoingWebhookDelivery{
+				HookId:      hook.Id,
 				TeamId:      hook.TeamId,
-				TeamDomain:  team.Name,
-				ChannelId:   post.ChannelId,
-				ChannelName: channel.Name,
-				Timestamp:   post.CreateAt,
-				UserId:      post.UserId,
-				UserName:    user.Username,
 				PostId:      post.Id,
-				Text:        post.Message,
-				TriggerWord: firstWord,
-			}
-			var body io.Reader
-			var contentType string
-			if hook.ContentType == "application/json" {
-				body = strings.NewReader(payload.ToJSON())
-				contentType = "application/json"
-			} else {
-				body = strings.NewReader(payload.ToFormValues())
-				contentType = "application/x-www-form-urlencoded"
-			}
-			tr := &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+				Url:         url,
+				ContentType: contentType,
+				Payload:     body,
 			}
-			client := &http.Client{Transport: tr}
-
-			for _, url := range hook.CallbackURLs {
-				go func(url string) {
-					req, _ := http.NewRequest("POST", url, body)
-					req.Header.Set("Content-Type", contentType)
-					req.Header.Set("Accept", "application/json")
-					if resp, err := client.Do(req); err != nil {
-						l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.event_post.error"), err.Error())
-					} else {
-						defer func() {
-							ioutil.ReadAll(resp.Body)
-							resp.Body.Close()
-						}()
-						respProps := model.MapFromJson(resp.Body)
-
-						// copy the context and create a mock session for posting the message
-						mockSession := model.Session{
-							UserId:      hook.CreatorId,
-							TeamMembers: []*model.TeamMember{{TeamId: hook.TeamId, UserId: hook.CreatorId}},
-							IsOAuth:     false,
-						}
-
-						newContext := &Context{
-							Session:      mockSession,
-							RequestId:    model.NewId(),
-							IpAddress:    "",
-							Path:         c.Path,
-							Err:          nil,
-							teamURLValid: c.teamURLValid,
-							teamURL:      c.teamURL,
-							siteURL:      c.siteURL,
-							T:            c.T,
-							Locale:       c.Locale,
-							TeamId:       hook.TeamId,
-						}
 
-						if text, ok := respProps["text"]; ok {
-							if _, err := CreateWebhookPost(newContext, post.ChannelId, text, respProps["username"], respProps["icon_url"], post.Props, post.Type); err != nil {
-								l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.create_post.error"), err)
-							}
-						}
-					}
-				}(url)
+			if result := <-Srv.Store.Webhook().SaveOutgoingDelivery(delivery); result.Err != nil {
+				l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.event_post.error"), result.Err.Error())
+			} else {
+				go deliverOutgoingWebhook(c, result.Data.(*model.OutgoingWebhookDelivery))
 			}
-
-		}(hook)
+		}
 	}
 }
 
@@ -591,6 +632,8 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 		mentionedUsersList = append(mentionedUsersList, id)
 	}
 
+	threadFollowerIds := getThreadFollowersToNotify(post, profileMap, mentionedUserIds)
+
 	if utils.Cfg.EmailSettings.SendEmailNotifications {
 		for _, id := range mentionedUsersList {
 			userAllowsEmails := profileMap[id].NotifyProps["email"] != "false"
@@ -599,6 +642,14 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 				sendNotificationEmail(c, post, profileMap[id], channel, team, senderName)
 			}
 		}
+
+		for _, id := range threadFollowerIds {
+			userAllowsEmails := profileMap[id].NotifyProps["email"] != "false"
+
+			if userAllowsEmails && (profileMap[id].IsAway() || profileMap[id].IsOffline()) {
+				sendNotificationEmail(c, post, profileMap[id], channel, team, senderName)
+			}
+		}
 	}
 
 	sendPushNotifications := false
@@ -618,9 +669,16 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 				sendPushNotification(post, profileMap[id], channel, senderName, true)
 			}
 		}
+		alwaysNotified := make(map[string]bool)
 		for _, id := range alwaysNotifyUserIds {
 			if _, ok := mentionedUserIds[id]; !ok {
 				sendPushNotification(post, profileMap[id], channel, senderName, false)
+				alwaysNotified[id] = true
+			}
+		}
+		for _, id := range threadFollowerIds {
+			if !alwaysNotified[id] && profileMap[id].NotifyProps["push"] != "none" {
+				sendPushNotification(post, profileMap[id], channel, senderName, false)
 			}
 		}
 	}
@@ -648,6 +706,10 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 		message.Add("mentions", model.ArrayToJson(mentionedUsersList))
 	}
 
+	if len(threadFollowerIds) != 0 {
+		message.Add("followers", model.ArrayToJson(threadFollowerIds))
+	}
+
 	go Publish(message)
 }
 
@@ -966,11 +1028,154 @@ func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
 		message.Add("post", rpost.ToJson())
 
 		go Publish(message)
+		go indexPostForSearch(rpost)
 
 		w.Write([]byte(rpost.ToJson()))
 	}
 }
 
+func pinPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	updatePinned(c, w, r, true, "pinPost")
+}
+
+func unpinPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	updatePinned(c, w, r, false, "unpinPost")
+}
+
+func updatePinned(c *Context, w http.ResponseWriter, r *http.Request, isPinned bool, where string) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam(where, "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam(where, "postId")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().GetPostsByIds([]string{postId})
+
+	if !c.HasPermissionsToChannel(cchan, where) {
+		return
+	}
+
+	var post *model.Post
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if posts := result.Data.([]*model.Post); len(posts) != 1 || posts[0].ChannelId != channelId {
+		c.Err = model.NewLocAppError(where, "api.post.update_pinned.find.app_error", nil, "id="+postId)
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	} else {
+		post = posts[0]
+	}
+
+	if post.IsPinned == isPinned {
+		w.Write([]byte(post.ToJson()))
+		return
+	}
+
+	if result := <-Srv.Store.Post().UpdatePinned(postId, isPinned); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		rpost := result.Data.(*model.Post)
+
+		message := model.NewMessage(c.TeamId, rpost.ChannelId, c.Session.UserId, model.ACTION_POST_EDITED)
+		message.Add("post", rpost.ToJson())
+
+		go Publish(message)
+		go PostPinChangeMessage(c, rpost)
+
+		w.Write([]byte(rpost.ToJson()))
+	}
+}
+
+func PostPinChangeMessage(c *Context, post *model.Post) {
+	uc := Srv.Store.User().Get(c.Session.UserId)
+
+	if uresult := <-uc; uresult.Err != nil {
+		l4g.Error(utils.T("api.post.post_pin_change_message.retrieve_user.error"), uresult.Err)
+		return
+	} else {
+		user := uresult.Data.(*model.User)
+
+		var message string
+		if post.IsPinned {
+			message = fmt.Sprintf(utils.T("api.post.post_pin_change_message.pinned"), user.Username)
+		} else {
+			message = fmt.Sprintf(utils.T("api.post.post_pin_change_message.unpinned"), user.Username)
+		}
+
+		pinPost := &model.Post{
+			ChannelId: post.ChannelId,
+			Message:   message,
+			Type:      model.POST_PIN_CHANGE,
+			Props:     model.StringInterface{"pinned_post_id": post.Id},
+		}
+		if _, err := CreatePost(c, pinPost, false); err != nil {
+			l4g.Error(utils.T("api.post.post_pin_change_message.create_post.error"), err)
+		}
+	}
+}
+
+func getPinnedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("getPinnedPosts", "channelId")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().GetPinnedPosts(channelId)
+
+	if !c.HasPermissionsToChannel(cchan, "getPinnedPosts") {
+		return
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		list := result.Data.(*model.PostList)
+
+		w.Write([]byte(list.ToJson()))
+	}
+}
+
+func getFlaggedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getFlaggedPosts", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil {
+		c.SetInvalidParam("getFlaggedPosts", "limit")
+		return
+	}
+
+	if result := <-Srv.Store.Post().GetFlaggedPosts(c.Session.UserId, offset, limit); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		list := result.Data.(*model.PostList)
+
+		w.Write([]byte(list.ToJson()))
+	}
+}
+
 func getPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -1230,7 +1435,14 @@ func deletePost(c *Context, w http.ResponseWriter, r *http.Request) {
 		message.Add("post", post.ToJson())
 
 		go Publish(message)
-		go DeletePostFiles(c.TeamId, post)
+
+		// replies are deleted along with the post so their files need to go and they need to leave the index too
+		for _, p := range result.Data.(*model.PostList).Posts {
+			if p.Id == postId || p.RootId == postId || p.ParentId == postId {
+				go DeletePostFiles(c.TeamId, p)
+				go deletePostFromSearch(p)
+			}
+		}
 
 		result := make(map[string]string)
 		result["id"] = postId
@@ -1238,7 +1450,13 @@ func deletePost(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// DeletePostFiles removes the files attached to a deleted post. Their infos are marked as deleted too so that they
+// stop counting towards the storage quotas straight away.
 func DeletePostFiles(teamId string, post *model.Post) {
+	if len(post.FileIds) > 0 || len(post.Filenames) > 0 {
+		deletePostFileInfos(post.Id)
+	}
+
 	if len(post.Filenames) == 0 {
 		return
 	}
@@ -1252,6 +1470,12 @@ func DeletePostFiles(teamId string, post *model.Post) {
 	}
 }
 
+func deletePostFileInfos(postId string) {
+	if result := <-Srv.Store.FileInfo().DeleteForPost(postId); result.Err != nil {
+		l4g.Error(utils.T("api.post.delete_post_file_infos.error"), postId, result.Err)
+	}
+}
+
 func getPostsBefore(c *Context, w http.ResponseWriter, r *http.Request) {
 	getPostsBeforeOrAfter(c, w, r, true)
 }
@@ -1332,28 +1556,138 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		isOrSearch = val.(bool)
 	}
 
+	page := 0
+	if val, ok := props["page"].(float64); ok && val > 0 {
+		page = int(val)
+	}
+
+	perPage := SEARCH_RESULTS_PER_PAGE
+	if val, ok := props["per_page"].(float64); ok && val > 0 && val <= SEARCH_RESULTS_MAX_PER_PAGE {
+		perPage = int(val)
+	}
+
+	// the search engine only indexes messages, so searches for attachments always go to the database
+	searchFiles := false
+
 	paramsList := model.ParseSearchParams(terms)
+	for _, params := range paramsList {
+		params.OrTerms = isOrSearch
+		searchFiles = searchFiles || params.HasFileFilters()
+	}
+
+	var results *model.PostSearchResults
+	var err *model.AppError
+	if engine := einterfaces.GetSearchEngineInterface(); engine != nil && *utils.Cfg.BleveSettings.EnableSearching && !searchFiles {
+		results, err = searchPostsWithEngine(engine, c.TeamId, c.Session.UserId, paramsList, page, perPage)
+	} else {
+		results, err = searchPostsInDatabase(c.TeamId, c.Session.UserId, paramsList, page, perPage)
+	}
+
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(results.ToJson()))
+}
+
+func searchPostsInDatabase(teamId string, userId string, paramsList []*model.SearchParams, page, perPage int) (*model.PostSearchResults, *model.AppError) {
 	channels := []store.StoreChannel{}
+	searched := []*model.SearchParams{}
 
 	for _, params := range paramsList {
-		params.OrTerms = isOrSearch
 		// don't allow users to search for everything
 		if params.Terms != "*" {
-			channels = append(channels, Srv.Store.Post().Search(c.TeamId, c.Session.UserId, params))
+			searched = append(searched, params)
 		}
 	}
 
-	posts := &model.PostList{}
-	for _, channel := range channels {
+	// the results of each set of params are merged before paging them, so every set has to return everything up to
+	// the end of the requested page
+	searchPage, searchPerPage := page, perPage
+	if len(searched) > 1 {
+		searchPage, searchPerPage = 0, (page+1)*perPage
+	}
+
+	for _, params := range searched {
+		channels = append(channels, Srv.Store.Post().Search(teamId, userId, params, searchPage, searchPerPage))
+	}
+
+	posts := []*model.Post{}
+	matches := map[string][]model.SearchMatch{}
+	for i, channel := range channels {
 		if result := <-channel; result.Err != nil {
-			c.Err = result.Err
-			return
+			return nil, result.Err
 		} else {
-			data := result.Data.(*model.PostList)
-			posts.Extend(data)
+			terms := searched[i].GetTermsList()
+
+			list := result.Data.(*model.PostList)
+			for _, postId := range list.Order {
+				if _, ok := matches[postId]; !ok {
+					post := list.Posts[postId]
+					posts = append(posts, post)
+					matches[postId] = model.FindSearchMatches(post.Message, terms)
+				}
+			}
 		}
 	}
 
-	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-	w.Write([]byte(posts.ToJson()))
+	if len(searched) > 1 {
+		sort.Stable(sort.Reverse(postsByCreateAt(posts)))
+
+		if start := page * perPage; start >= len(posts) {
+			posts = nil
+		} else if end := start + perPage; end < len(posts) {
+			posts = posts[start:end]
+		} else {
+			posts = posts[start:]
+		}
+	}
+
+	results := model.NewPostSearchResults()
+	for _, post := range posts {
+		results.AddResult(post, 0, matches[post.Id])
+	}
+
+	return results, nil
+}
+
+func getFileInfosForPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("getFileInfosForPost", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("getFileInfosForPost", "postId")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().Get(postId)
+	fchan := Srv.Store.FileInfo().GetForPost(postId)
+
+	if !c.HasPermissionsToChannel(cchan, "getFileInfosForPost") {
+		return
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if post := result.Data.(*model.PostList).Posts[postId]; post == nil || post.ChannelId != channelId {
+		c.SetInvalidParam("getFileInfosForPost", "postId")
+		return
+	}
+
+	if result := <-fchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.FileInfosToJson(result.Data.([]*model.FileInfo))))
+	}
 }
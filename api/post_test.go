@@ -539,6 +539,73 @@ func TestSearchPosts(t *testing.T) {
 	}
 }
 
+func TestSearchPostsWithPaging(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel1 := th.BasicChannel
+
+	post1 := &model.Post{ChannelId: channel1.Id, Message: "outage incident report"}
+	post1 = Client.Must(Client.CreatePost(post1)).Data.(*model.Post)
+
+	post2 := &model.Post{ChannelId: channel1.Id, Message: "second outage"}
+	post2 = Client.Must(Client.CreatePost(post2)).Data.(*model.Post)
+
+	post3 := &model.Post{ChannelId: channel1.Id, Message: "third outage"}
+	post3 = Client.Must(Client.CreatePost(post3)).Data.(*model.Post)
+
+	page0, err := Client.SearchPostsWithPaging("outage", false, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(page0.Order) != 2 || page0.Order[0] != post3.Id || page0.Order[1] != post2.Id {
+		t.Fatal("first page should have the two newest posts")
+	}
+
+	if page1, err := Client.SearchPostsWithPaging("outage", false, 1, 2); err != nil {
+		t.Fatal(err)
+	} else if len(page1.Order) != 1 || page1.Order[0] != post1.Id {
+		t.Fatal("second page should have the oldest post")
+	}
+
+	if matches := page0.Matches[post2.Id]; len(matches) != 1 || post2.Message[matches[0].Start:matches[0].End] != "outage" {
+		t.Fatal("should have returned where the term matched")
+	}
+
+	if results, err := Client.SearchPostsWithPaging("outage -incident", false, 0, 10); err != nil {
+		t.Fatal(err)
+	} else if len(results.Order) != 2 || results.Posts[post1.Id] != nil {
+		t.Fatal("should have left out the excluded term")
+	}
+
+	if results, err := Client.SearchPostsWithPaging("outage before:2000-01-01", false, 0, 10); err != nil {
+		t.Fatal(err)
+	} else if len(results.Order) != 0 {
+		t.Fatal("shouldn't have found posts from the future")
+	}
+
+	today := time.Now().UTC().Format(model.SEARCH_DATE_FORMAT)
+	if results, err := Client.SearchPostsWithPaging("outage on:"+today, false, 0, 10); err != nil {
+		t.Fatal(err)
+	} else if len(results.Order) != 3 {
+		t.Fatal("should have found today's posts")
+	}
+
+	// hashtags and terms are searched separately and then merged before paging
+	post4 := &model.Post{ChannelId: channel1.Id, Message: "#pagingtag"}
+	post4 = Client.Must(Client.CreatePost(post4)).Data.(*model.Post)
+
+	if results, err := Client.SearchPostsWithPaging("outage #pagingtag", false, 0, 2); err != nil {
+		t.Fatal(err)
+	} else if len(results.Order) != 2 || results.Order[0] != post4.Id || results.Order[1] != post3.Id {
+		t.Fatal("first page should have the two newest posts from both searches", results.Order)
+	}
+
+	if results, err := Client.SearchPostsWithPaging("outage #pagingtag", false, 1, 2); err != nil {
+		t.Fatal(err)
+	} else if len(results.Order) != 2 || results.Order[0] != post2.Id || results.Order[1] != post1.Id {
+		t.Fatal("second page should continue where the first one stopped", results.Order)
+	}
+}
+
 func TestSearchHashtagPosts(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -696,6 +763,48 @@ func TestSearchPostsFromUser(t *testing.T) {
 	}
 }
 
+func TestSearchPostsByFile(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Log("skipping because no file driver is enabled")
+		return
+	}
+
+	data := []byte("the quarterly zanzibar figures")
+	session := Client.MustGeneric(Client.CreateUploadSession(channel.Id, "notes.txt", int64(len(data)))).(*model.UploadSession)
+	Client.MustGeneric(Client.UploadChunk(session.Id, 0, data))
+	info := Client.MustGeneric(Client.FinishUploadSession(session.Id)).(*model.FileUploadResponse).FileInfos[0]
+
+	post := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "see attached", FileIds: []string{info.Id}})).Data.(*model.Post)
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "no attachments here"}))
+
+	// extract the text straight away instead of waiting for the workers
+	if result := <-Srv.Store.FileInfo().Get(info.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		extractContent(result.Data.(*model.FileInfo))
+	}
+
+	if r := Client.Must(Client.SearchPosts("zanzibar", false)).Data.(*model.PostList); len(r.Order) != 1 || r.Order[0] != post.Id {
+		t.Fatal("should've found the post by the contents of its attachment")
+	}
+
+	if r := Client.Must(Client.SearchPosts("ext:txt", false)).Data.(*model.PostList); len(r.Order) != 1 || r.Order[0] != post.Id {
+		t.Fatal("should've found the post by the extension of its attachment")
+	}
+
+	if r := Client.Must(Client.SearchPosts("file:notes", false)).Data.(*model.PostList); len(r.Order) != 1 || r.Order[0] != post.Id {
+		t.Fatal("should've found the post by the name of its attachment")
+	}
+
+	if r := Client.Must(Client.SearchPosts("attachments ext:pdf", false)).Data.(*model.PostList); len(r.Order) != 0 {
+		t.Fatal("shouldn't have found posts without a matching attachment")
+	}
+}
+
 func TestGetPostsCache(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -783,6 +892,130 @@ func TestDeletePosts(t *testing.T) {
 	Client.Must(Client.DeletePost(channel1.Id, post4.Id))
 }
 
+func TestPinPost(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel1 := th.BasicChannel
+	post1 := th.BasicPost
+
+	time.Sleep(10 * time.Millisecond)
+	post2 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}
+	post2 = Client.Must(Client.CreatePost(post2)).Data.(*model.Post)
+
+	if post, err := Client.PinPost(channel1.Id, post1.Id); err != nil {
+		t.Fatal(err)
+	} else if !post.IsPinned {
+		t.Fatal("should have pinned the post")
+	}
+
+	// pinning twice is allowed
+	Client.PinPost(channel1.Id, post1.Id)
+	Client.PinPost(channel1.Id, post2.Id)
+
+	if list, err := Client.GetPinnedPosts(channel1.Id); err != nil {
+		t.Fatal(err)
+	} else if len(list.Order) != 2 || list.Order[0] != post2.Id || list.Order[1] != post1.Id {
+		t.Fatal("should have returned the pinned posts")
+	}
+
+	if post, err := Client.UnpinPost(channel1.Id, post2.Id); err != nil {
+		t.Fatal(err)
+	} else if post.IsPinned {
+		t.Fatal("should have unpinned the post")
+	}
+
+	if list, err := Client.GetPinnedPosts(channel1.Id); err != nil {
+		t.Fatal(err)
+	} else if len(list.Order) != 1 || list.Order[0] != post1.Id {
+		t.Fatal("should have only returned the pinned post")
+	}
+
+	time.Sleep(time.Second)
+
+	list := Client.Must(Client.GetPosts(channel1.Id, 0, 10, "")).Data.(*model.PostList)
+	pinChanges := 0
+	for _, post := range list.Posts {
+		if post.Type == model.POST_PIN_CHANGE {
+			pinChanges++
+		}
+	}
+
+	if pinChanges != 3 {
+		t.Fatal("should have posted a system message for each change", pinChanges)
+	}
+
+	channel2 := th.CreateChannel(Client, th.BasicTeam)
+	if _, err := Client.PinPost(channel2.Id, post1.Id); err == nil {
+		t.Fatal("should have failed - post in a different channel")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.PinPost(channel1.Id, post2.Id); err == nil {
+		t.Fatal("should have failed - not a member of the channel")
+	}
+
+	if _, err := Client.GetPinnedPosts(channel1.Id); err == nil {
+		t.Fatal("should have failed - not a member of the channel")
+	}
+}
+
+func TestGetFlaggedPosts(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	user1 := th.BasicUser
+	post1 := th.BasicPost
+
+	time.Sleep(10 * time.Millisecond)
+	post2 := &model.Post{ChannelId: th.BasicChannel.Id, Message: "a" + model.NewId() + "a"}
+	post2 = Client.Must(Client.CreatePost(post2)).Data.(*model.Post)
+
+	channel2 := th.CreateChannel(Client, th.BasicTeam)
+	post3 := th.CreatePost(Client, channel2)
+
+	preferences := &model.Preferences{}
+	for _, post := range []*model.Post{post1, post2, post3} {
+		*preferences = append(*preferences, model.Preference{
+			UserId:   user1.Id,
+			Category: model.PREFERENCE_CATEGORY_FLAGGED_POST,
+			Name:     post.Id,
+			Value:    "true",
+		})
+	}
+	Client.Must(Client.SetPreferences(preferences))
+
+	if list, err := Client.GetFlaggedPosts(0, 10); err != nil {
+		t.Fatal(err)
+	} else if len(list.Order) != 3 || list.Order[2] != post1.Id {
+		t.Fatal("should have returned the flagged posts")
+	}
+
+	Client.Must(Client.LeaveChannel(channel2.Id))
+
+	if list, err := Client.GetFlaggedPosts(0, 10); err != nil {
+		t.Fatal(err)
+	} else if len(list.Order) != 2 || list.Order[0] != post2.Id {
+		t.Fatal("shouldn't have returned posts from channels the user left")
+	}
+
+	(*preferences)[1].Value = "false"
+	Client.Must(Client.SetPreferences(&model.Preferences{(*preferences)[1]}))
+
+	if list, err := Client.GetFlaggedPosts(0, 10); err != nil {
+		t.Fatal(err)
+	} else if len(list.Order) != 1 || list.Order[0] != post1.Id {
+		t.Fatal("shouldn't have returned the unflagged post")
+	}
+
+	th.LoginBasic2()
+
+	if list, err := Client.GetFlaggedPosts(0, 10); err != nil {
+		t.Fatal(err)
+	} else if len(list.Order) != 0 {
+		t.Fatal("flagged posts should be per user")
+	}
+}
+
 func TestEmailMention(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -924,3 +1157,43 @@ func TestGetOutOfChannelMentions(t *testing.T) {
 		t.Fatalf("getOutOfChannelMentions returned %v when two users on a different team were mentioned", mentioned)
 	}
 }
+
+func TestGetFileInfosForPost(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		return
+	}
+
+	filenames, err := uploadTestFile(Client, channel.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupTestFile(filenames[0], th.BasicTeam.Id, channel.Id, th.BasicUser.Id)
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "with files", Filenames: filenames})).Data.(*model.Post)
+	if len(post1.FileIds) != 1 {
+		t.Fatal("should have attached the uploaded file")
+	}
+
+	if infos, err := Client.GetFileInfosForPost(channel.Id, post1.Id); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 || infos[0].Id != post1.FileIds[0] || infos[0].PostId != post1.Id {
+		t.Fatal("should have returned the file attached to the post")
+	} else if infos[0].Size == 0 || infos[0].Width != 1 || infos[0].Height != 1 {
+		t.Fatal("should have stored the size and dimensions of the file")
+	}
+
+	post2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "same files", FileIds: post1.FileIds})).Data.(*model.Post)
+	if len(post2.FileIds) != 0 {
+		t.Fatal("shouldn't be able to attach a file that belongs to another post")
+	}
+
+	if infos, err := Client.GetFileInfosForPost(channel.Id, th.BasicPost.Id); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 0 {
+		t.Fatal("post without files shouldn't have any file infos")
+	}
+}
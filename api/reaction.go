@@ -0,0 +1,209 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitReaction() {
+	l4g.Debug(utils.T("api.reaction.init.debug"))
+
+	BaseRoutes.NeedPost.Handle("/reactions/save", ApiUserRequired(saveReaction)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/reactions/delete", ApiUserRequired(deleteReaction)).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/reactions", ApiUserRequired(listReactions)).Methods("GET")
+}
+
+func saveReaction(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("saveReaction", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("saveReaction", "postId")
+		return
+	}
+
+	reaction := model.ReactionFromJson(r.Body)
+	if reaction == nil {
+		c.SetInvalidParam("saveReaction", "reaction")
+		return
+	}
+
+	if reaction.UserId != c.Session.UserId {
+		c.Err = model.NewLocAppError("saveReaction", "api.reaction.save_reaction.user_id.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	if reaction.PostId != postId {
+		c.SetInvalidParam("saveReaction", "reaction.postId")
+		return
+	}
+
+	pchan := Srv.Store.Post().Get(postId)
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+
+	if !c.HasPermissionsToChannel(cchan, "saveReaction") {
+		return
+	}
+
+	if !checkReactionPost(c, pchan, channelId, postId, "saveReaction") {
+		return
+	}
+
+	if err := checkReactionEmojiName(reaction.EmojiName); err != nil {
+		c.Err = err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	// the creation time can't be set by the client
+	reaction.CreateAt = 0
+
+	if result := <-Srv.Store.Reaction().Save(reaction); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		reaction := result.Data.(*model.Reaction)
+
+		sendReactionEvent(model.ACTION_REACTION_ADDED, c.TeamId, channelId, reaction)
+
+		w.Write([]byte(reaction.ToJson()))
+	}
+}
+
+func deleteReaction(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("deleteReaction", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("deleteReaction", "postId")
+		return
+	}
+
+	reaction := model.ReactionFromJson(r.Body)
+	if reaction == nil {
+		c.SetInvalidParam("deleteReaction", "reaction")
+		return
+	}
+
+	if reaction.UserId != c.Session.UserId {
+		c.Err = model.NewLocAppError("deleteReaction", "api.reaction.delete_reaction.user_id.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	if reaction.PostId != postId {
+		c.SetInvalidParam("deleteReaction", "reaction.postId")
+		return
+	}
+
+	pchan := Srv.Store.Post().Get(postId)
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+
+	if !c.HasPermissionsToChannel(cchan, "deleteReaction") {
+		return
+	}
+
+	if !checkReactionPost(c, pchan, channelId, postId, "deleteReaction") {
+		return
+	}
+
+	if result := <-Srv.Store.Reaction().Delete(reaction); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		sendReactionEvent(model.ACTION_REACTION_REMOVED, c.TeamId, channelId, reaction)
+
+		ReturnStatusOK(w)
+	}
+}
+
+func listReactions(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("listReactions", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("listReactions", "postId")
+		return
+	}
+
+	pchan := Srv.Store.Post().Get(postId)
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+
+	if !c.HasPermissionsToChannel(cchan, "listReactions") {
+		return
+	}
+
+	if !checkReactionPost(c, pchan, channelId, postId, "listReactions") {
+		return
+	}
+
+	if result := <-Srv.Store.Reaction().GetForPost(postId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		reactions := result.Data.([]*model.Reaction)
+
+		w.Write([]byte(model.ReactionsToJson(reactions)))
+	}
+}
+
+func checkReactionPost(c *Context, pchan store.StoreChannel, channelId string, postId string, where string) bool {
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return false
+	} else if post := result.Data.(*model.PostList).Posts[postId]; post == nil || post.ChannelId != channelId {
+		c.Err = model.NewLocAppError(where, "api.reaction.post.app_error", nil, "post_id="+postId)
+		c.Err.StatusCode = http.StatusBadRequest
+		return false
+	}
+
+	return true
+}
+
+func checkReactionEmojiName(emojiName string) *model.AppError {
+	if model.SystemEmojis[emojiName] {
+		return nil
+	}
+
+	if *utils.Cfg.ServiceSettings.EnableCustomEmoji {
+		if result := <-Srv.Store.Emoji().GetByName(emojiName); result.Err == nil {
+			return nil
+		}
+	}
+
+	return model.NewLocAppError("checkReactionEmojiName", "api.reaction.emoji_name.app_error", nil, "emoji_name="+emojiName)
+}
+
+func sendReactionEvent(action string, teamId string, channelId string, reaction *model.Reaction) {
+	message := model.NewMessage(teamId, channelId, reaction.UserId, action)
+	message.Add("reaction", reaction.ToJson())
+
+	go Publish(message)
+}
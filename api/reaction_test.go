@@ -0,0 +1,118 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestSaveReaction(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	user := th.BasicUser
+	channel := th.BasicChannel
+	post := th.BasicPost
+
+	reaction := &model.Reaction{
+		UserId:    user.Id,
+		PostId:    post.Id,
+		EmojiName: "smile",
+	}
+
+	if returned, err := Client.SaveReaction(channel.Id, reaction); err != nil {
+		t.Fatal(err)
+	} else if returned.UserId != reaction.UserId || returned.PostId != reaction.PostId || returned.EmojiName != reaction.EmojiName {
+		t.Fatal("should've returned reaction")
+	}
+
+	if reactions, err := Client.ListReactions(channel.Id, post.Id); err != nil {
+		t.Fatal(err)
+	} else if len(reactions) != 1 || reactions[0].EmojiName != "smile" {
+		t.Fatal("should've saved reaction")
+	}
+
+	// saving a duplicate reaction is allowed
+	if _, err := Client.SaveReaction(channel.Id, reaction); err != nil {
+		t.Fatal(err)
+	}
+
+	if reactions, err := Client.ListReactions(channel.Id, post.Id); err != nil {
+		t.Fatal(err)
+	} else if len(reactions) != 1 {
+		t.Fatal("shouldn't have saved duplicate reaction")
+	}
+
+	reaction.EmojiName = "not_a_real_emoji_" + model.NewId()
+	if _, err := Client.SaveReaction(channel.Id, reaction); err == nil {
+		t.Fatal("should have failed - unknown emoji")
+	}
+
+	reaction.EmojiName = "+1"
+	reaction.UserId = th.BasicUser2.Id
+	if _, err := Client.SaveReaction(channel.Id, reaction); err == nil {
+		t.Fatal("should have failed - reacting as another user")
+	}
+
+	reaction.UserId = user.Id
+	otherChannel := th.CreateChannel(Client, th.BasicTeam)
+	if _, err := Client.SaveReaction(otherChannel.Id, reaction); err == nil {
+		t.Fatal("should have failed - post in a different channel")
+	}
+
+	enableCustomEmoji := *utils.Cfg.ServiceSettings.EnableCustomEmoji
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableCustomEmoji = enableCustomEmoji
+	}()
+	*utils.Cfg.ServiceSettings.EnableCustomEmoji = true
+
+	emoji := &model.Emoji{CreatorId: user.Id, Name: model.NewId()}
+	if result := <-Srv.Store.Emoji().Save(emoji); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	reaction.EmojiName = emoji.Name
+	if _, err := Client.SaveReaction(channel.Id, reaction); err != nil {
+		t.Fatal(err)
+	}
+
+	*utils.Cfg.ServiceSettings.EnableCustomEmoji = false
+
+	reaction.PostId = th.CreatePost(Client, channel).Id
+	if _, err := Client.SaveReaction(channel.Id, reaction); err == nil {
+		t.Fatal("should have failed - custom emoji disabled")
+	}
+}
+
+func TestDeleteReaction(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	user := th.BasicUser
+	channel := th.BasicChannel
+	post := th.BasicPost
+
+	reaction1 := &model.Reaction{UserId: user.Id, PostId: post.Id, EmojiName: "smile"}
+	reaction2 := &model.Reaction{UserId: user.Id, PostId: post.Id, EmojiName: "+1"}
+	Client.SaveReaction(channel.Id, reaction1)
+	Client.SaveReaction(channel.Id, reaction2)
+
+	if deleted, err := Client.DeleteReaction(channel.Id, reaction1); err != nil {
+		t.Fatal(err)
+	} else if !deleted {
+		t.Fatal("should have returned ok")
+	}
+
+	if reactions, err := Client.ListReactions(channel.Id, post.Id); err != nil {
+		t.Fatal(err)
+	} else if len(reactions) != 1 || reactions[0].EmojiName != reaction2.EmojiName {
+		t.Fatal("should've deleted only the one reaction")
+	}
+
+	reaction2.UserId = th.BasicUser2.Id
+	if _, err := Client.DeleteReaction(channel.Id, reaction2); err == nil {
+		t.Fatal("should have failed - deleting another user's reaction")
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/model/saml"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	// SAML_RELAY_STATE_EXPIRY is how long a user has to sign in at the IdP before the relay state is refused
+	SAML_RELAY_STATE_EXPIRY = 30 * time.Minute
+)
+
+func InitSaml() {
+	l4g.Debug(utils.T("api.saml.init.debug"))
+
+	BaseRoutes.Root.Handle("/login/sso/saml", AppHandlerIndependent(loginWithSaml)).Methods("GET")
+	BaseRoutes.Root.Handle("/login/sso/saml", AppHandlerIndependent(completeSaml)).Methods("POST")
+
+	BaseRoutes.ApiRoot.Handle("/saml/metadata", AppHandlerIndependent(samlMetadata)).Methods("GET")
+}
+
+// SamlServiceProvider is the built in implementation of einterfaces.SamlInterface. It's used when no other
+// implementation has been registered and reads its certificates from the files named in SamlSettings.
+type SamlServiceProvider struct {
+	mutex    sync.Mutex
+	sp       *saml.ServiceProvider
+	settings string
+}
+
+func NewSamlServiceProvider() *SamlServiceProvider {
+	return &SamlServiceProvider{}
+}
+
+func (me *SamlServiceProvider) ConfigureSP() *model.AppError {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+
+	_, err := me.configure()
+	return err
+}
+
+func (me *SamlServiceProvider) configure() (*saml.ServiceProvider, *model.AppError) {
+	settings := utils.Cfg.SamlSettings
+	if !*settings.Enable {
+		err := model.NewLocAppError("SamlServiceProvider.ConfigureSP", "api.saml.disabled.app_error", nil, "")
+		err.StatusCode = http.StatusNotImplemented
+		return nil, err
+	}
+
+	var files [][]byte
+	for _, name := range []string{*settings.IdpCertificateFile, *settings.PublicCertificateFile, *settings.PrivateKeyFile} {
+		if data, err := ioutil.ReadFile(utils.FindConfigFile(name)); err != nil {
+			return nil, model.NewLocAppError("SamlServiceProvider.ConfigureSP", "api.saml.read_file.app_error", map[string]interface{}{"Filename": name}, err.Error())
+		} else {
+			files = append(files, data)
+		}
+	}
+
+	sp, err := saml.NewServiceProvider(settings, files[0], files[1], files[2])
+	if err != nil {
+		return nil, err
+	}
+
+	me.sp = sp
+	me.settings = samlSettingsKey(settings)
+
+	return sp, nil
+}
+
+func samlSettingsKey(settings model.SamlSettings) string {
+	data, _ := json.Marshal(settings)
+	return string(data)
+}
+
+// getServiceProvider returns the service provider for the current settings, setting it up again if they've changed
+func (me *SamlServiceProvider) getServiceProvider() (*saml.ServiceProvider, *model.AppError) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+
+	if me.sp != nil && *utils.Cfg.SamlSettings.Enable && me.settings == samlSettingsKey(utils.Cfg.SamlSettings) {
+		return me.sp, nil
+	}
+
+	return me.configure()
+}
+
+func (me *SamlServiceProvider) BuildRequest(requestId, relayState string) (string, *model.AppError) {
+	sp, err := me.getServiceProvider()
+	if err != nil {
+		return "", err
+	}
+
+	return sp.BuildRequestUrl(requestId, relayState)
+}
+
+func (me *SamlServiceProvider) GetUserFromResponse(encodedXML, requestId string) (*model.User, *model.AppError) {
+	sp, err := me.getServiceProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, err := sp.ParseResponse(encodedXML, requestId)
+	if err != nil {
+		err.StatusCode = http.StatusUnauthorized
+		return nil, err
+	}
+
+	return sp.GetUser(assertion)
+}
+
+func (me *SamlServiceProvider) GetMetadata() (string, *model.AppError) {
+	sp, err := me.getServiceProvider()
+	if err != nil {
+		return "", err
+	}
+
+	return sp.Metadata(), nil
+}
+
+func getSamlInterface(where string) (einterfaces.SamlInterface, *model.AppError) {
+	if samlInterface := einterfaces.GetSamlInterface(); samlInterface != nil && *utils.Cfg.SamlSettings.Enable {
+		return samlInterface, nil
+	}
+
+	err := model.NewLocAppError(where, "api.saml.disabled.app_error", nil, "")
+	err.StatusCode = http.StatusNotImplemented
+	return nil, err
+}
+
+func samlRelayStateMac(data string) string {
+	mac := hmac.New(sha256.New, []byte(*utils.Cfg.SamlSettings.RelayStateSalt))
+	mac.Write([]byte(data))
+	return b64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encodeSamlRelayState turns the props into a relay state that's signed so that the IdP, or anyone else, can't
+// change what happens once the user has signed in
+func encodeSamlRelayState(props map[string]string) string {
+	props["time"] = strconv.FormatInt(model.GetMillis(), 10)
+
+	data := b64.RawURLEncoding.EncodeToString([]byte(model.MapToJson(props)))
+	return data + "." + samlRelayStateMac(data)
+}
+
+// buildSamlRequest starts a sign in with the IdP. The id of the request is kept in the signed relay state so that
+// whichever server receives the response can check that it's answering this request.
+func buildSamlRequest(samlInterface einterfaces.SamlInterface, relayProps map[string]string) (string, *model.AppError) {
+	requestId := saml.NewRequestId()
+	relayProps["request_id"] = requestId
+
+	return samlInterface.BuildRequest(requestId, encodeSamlRelayState(relayProps))
+}
+
+func decodeSamlRelayState(relayState string) (map[string]string, *model.AppError) {
+	parts := strings.Split(relayState, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(samlRelayStateMac(parts[0]))) {
+		return nil, model.NewLocAppError("decodeSamlRelayState", "api.saml.invalid_relay_state.app_error", nil, "")
+	}
+
+	data, err := b64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, model.NewLocAppError("decodeSamlRelayState", "api.saml.invalid_relay_state.app_error", nil, err.Error())
+	}
+
+	props := model.MapFromJson(strings.NewReader(string(data)))
+
+	t, err := strconv.ParseInt(props["time"], 10, 64)
+	if err != nil || model.GetMillis()-t > int64(SAML_RELAY_STATE_EXPIRY/time.Millisecond) {
+		return nil, model.NewLocAppError("decodeSamlRelayState", "api.saml.expired_relay_state.app_error", nil, "")
+	}
+
+	return props, nil
+}
+
+func loginWithSaml(c *Context, w http.ResponseWriter, r *http.Request) {
+	samlInterface, err := getSamlInterface("loginWithSaml")
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	teamId, err := getTeamIdFromQuery(r.URL.Query())
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	relayProps := map[string]string{}
+	relayProps["action"] = model.OAUTH_ACTION_LOGIN
+	if r.URL.Query().Get("action") == model.OAUTH_ACTION_SIGNUP {
+		if !utils.Cfg.TeamSettings.EnableUserCreation {
+			c.Err = model.NewLocAppError("loginWithSaml", "web.singup_with_oauth.disabled.app_error", nil, "")
+			c.Err.StatusCode = http.StatusNotImplemented
+			return
+		}
+
+		relayProps["action"] = model.OAUTH_ACTION_SIGNUP
+	}
+
+	if len(teamId) != 0 {
+		relayProps["team_id"] = teamId
+	}
+
+	if requestUrl, err := buildSamlRequest(samlInterface, relayProps); err != nil {
+		c.Err = err
+		return
+	} else {
+		http.Redirect(w, r, requestUrl, http.StatusFound)
+	}
+}
+
+// completeSaml is the assertion consumer service that the IdP posts its response to
+func completeSaml(c *Context, w http.ResponseWriter, r *http.Request) {
+	samlInterface, err := getSamlInterface("completeSaml")
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	r.ParseForm()
+
+	relayProps, err := decodeSamlRelayState(r.FormValue("RelayState"))
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	user, err := samlInterface.GetUserFromResponse(r.FormValue("SAMLResponse"), relayProps["request_id"])
+	if err != nil {
+		c.LogAudit("fail - invalid response")
+		c.Err = err
+		return
+	}
+
+	switch relayProps["action"] {
+	case model.OAUTH_ACTION_EMAIL_TO_SSO:
+		completeSwitchWithSSO(c, model.USER_AUTH_SERVICE_SAML, "SAML SSO", *user.AuthData, user.Email, relayProps["email"])
+		if c.Err == nil {
+			http.Redirect(w, r, GetProtocol(r)+"://"+r.Host+"/login?extra=signin_change", http.StatusFound)
+		}
+	default:
+		loginBySaml(c, w, r, user, relayProps["team_id"])
+		if c.Err == nil {
+			http.Redirect(w, r, GetProtocol(r)+"://"+r.Host, http.StatusFound)
+		}
+	}
+}
+
+// loginBySaml signs in the user that the IdP vouched for, creating them the first time that they sign in
+func loginBySaml(c *Context, w http.ResponseWriter, r *http.Request, samlUser *model.User, teamId string) *model.User {
+	var user *model.User
+	if result := <-Srv.Store.User().GetByAuth(samlUser.AuthData, model.USER_AUTH_SERVICE_SAML); result.Err != nil {
+		if result.Err.Id == store.MISSING_AUTH_ACCOUNT_ERROR {
+			return createSSOUser(c, w, r, samlUser, model.USER_AUTH_SERVICE_SAML, teamId)
+		}
+		c.Err = result.Err
+		return nil
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	doLogin(c, w, r, user, "")
+	if c.Err != nil {
+		return nil
+	}
+
+	if len(teamId) > 0 {
+		c.Err = JoinUserToTeamById(teamId, user)
+	}
+
+	return user
+}
+
+func samlMetadata(c *Context, w http.ResponseWriter, r *http.Request) {
+	samlInterface, err := getSamlInterface("samlMetadata")
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if metadata, err := samlInterface.GetMetadata(); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Header().Set("Content-Type", "application/samlmetadata+xml")
+		w.Write([]byte(metadata))
+	}
+}
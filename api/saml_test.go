@@ -0,0 +1,275 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/model/saml/samltest"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+// setupSaml points the SAML settings at a fake IdP and returns it along with the SP's certificate and a function
+// that puts the settings back
+func setupSaml(t *testing.T, Client *model.Client) (*samltest.FakeIdP, []byte, func()) {
+	dir, err := ioutil.TempDir("", "samltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acsUrl := Client.Url + "/login/sso/saml"
+	idp := samltest.NewFakeIdP("https://idp.example.com/metadata", acsUrl)
+	certificate, privateKey := samltest.GenerateCertificate("mattermost")
+
+	for name, data := range map[string][]byte{"idp.crt": idp.Certificate, "sp.crt": certificate, "sp.key": privateKey} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := model.Config{}
+	config.SetDefaults()
+	settings := config.SamlSettings
+	*settings.Enable = true
+	*settings.IdpUrl = "https://idp.example.com/sso"
+	*settings.IdpDescriptorUrl = idp.EntityId
+	*settings.AssertionConsumerServiceURL = acsUrl
+	*settings.IdpCertificateFile = filepath.Join(dir, "idp.crt")
+	*settings.PublicCertificateFile = filepath.Join(dir, "sp.crt")
+	*settings.PrivateKeyFile = filepath.Join(dir, "sp.key")
+	*settings.EmailAttribute = "Email"
+	*settings.UsernameAttribute = "Username"
+	*settings.FirstNameAttribute = "FirstName"
+
+	samlSettings := utils.Cfg.SamlSettings
+	utils.Cfg.SamlSettings = settings
+
+	return idp, certificate, func() {
+		utils.Cfg.SamlSettings = samlSettings
+		os.RemoveAll(dir)
+	}
+}
+
+func noRedirectClient() *http.Client {
+	return &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// postSamlResponse posts a response from the IdP to the assertion consumer service
+func postSamlResponse(t *testing.T, Client *model.Client, response, relayState string) *http.Response {
+	resp, err := noRedirectClient().PostForm(Client.Url+"/login/sso/saml", url.Values{"SAMLResponse": {response}, "RelayState": {relayState}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp
+}
+
+// getSamlRelayState follows a request url to the IdP and returns the relay state that it'll post back
+func getSamlRelayState(t *testing.T, idp *samltest.FakeIdP, requestUrl string, certificate []byte) string {
+	_, relayState, err := idp.ParseRequest(requestUrl, certificate)
+	if err != nil {
+		t.Fatal("should've sent a signed request", err)
+	}
+
+	return relayState
+}
+
+// signInWithSaml starts signing in, then has the IdP vouch for the user and returns the final response
+func signInWithSaml(t *testing.T, Client *model.Client, idp *samltest.FakeIdP, certificate []byte, nameId string, attributes map[string]string) *http.Response {
+	resp, err := noRedirectClient().Get(Client.Url + "/login/sso/saml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatal("should've redirected to the IdP", resp.StatusCode)
+	}
+
+	relayState := getSamlRelayState(t, idp, resp.Header.Get("Location"), certificate)
+
+	return postSamlResponse(t, Client, idp.Response(nameId, attributes), relayState)
+}
+
+func TestSamlMetadata(t *testing.T) {
+	th := Setup().InitBasic()
+
+	if resp, err := http.Get(th.BasicClient.ApiUrl + "/saml/metadata"); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusNotImplemented {
+		t.Fatal("shouldn't have metadata when SAML is disabled", resp.StatusCode)
+	}
+
+	_, _, restore := setupSaml(t, th.BasicClient)
+	defer restore()
+
+	resp, err := http.Get(th.BasicClient.ApiUrl + "/saml/metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `entityID="`+th.BasicClient.Url+`/login/sso/saml"`) {
+		t.Fatal("should've returned the SP's metadata", resp.StatusCode, string(body))
+	}
+}
+
+func TestSamlLogin(t *testing.T) {
+	th := Setup().InitBasic()
+
+	idp, certificate, restore := setupSaml(t, th.BasicClient)
+	defer restore()
+
+	id := model.NewId()[:10]
+	nameId := model.NewId()
+	attributes := map[string]string{
+		"Email":     "success+saml" + id + "@simulator.amazonses.com",
+		"Username":  "saml" + id,
+		"FirstName": "Sam",
+	}
+
+	resp := signInWithSaml(t, th.BasicClient, idp, certificate, nameId, attributes)
+	token := getSessionToken(resp)
+	if len(token) == 0 {
+		t.Fatal("should've signed in", resp.StatusCode)
+	}
+
+	Client := th.CreateClient()
+	Client.AuthToken = token
+	Client.AuthType = model.HEADER_BEARER
+
+	user := Client.Must(Client.GetMe("")).Data.(*model.User)
+	if user.Username != "saml"+id || user.Email != attributes["Email"] || user.FirstName != "Sam" || user.AuthService != model.USER_AUTH_SERVICE_SAML {
+		t.Fatal("should've created the user from the assertion")
+	}
+
+	resp = signInWithSaml(t, th.BasicClient, idp, certificate, nameId, attributes)
+	if token := getSessionToken(resp); len(token) == 0 {
+		t.Fatal("should've signed in again")
+	} else {
+		Client.AuthToken = token
+		if me := Client.Must(Client.GetMe("")).Data.(*model.User); me.Id != user.Id {
+			t.Fatal("should've signed in as the same user")
+		}
+	}
+
+	loginResp, _ := noRedirectClient().Get(th.BasicClient.Url + "/login/sso/saml")
+	loginResp.Body.Close()
+	relayState := getSamlRelayState(t, idp, loginResp.Header.Get("Location"), certificate)
+
+	response := idp.Response(nameId, attributes)
+	if resp := postSamlResponse(t, th.BasicClient, response, relayState); len(getSessionToken(resp)) == 0 {
+		t.Fatal("should've signed in")
+	}
+
+	if resp := postSamlResponse(t, th.BasicClient, response, relayState); len(getSessionToken(resp)) != 0 {
+		t.Fatal("shouldn't accept the same response twice")
+	}
+
+	if resp := postSamlResponse(t, th.BasicClient, idp.Response(nameId, attributes), relayState+"x"); len(getSessionToken(resp)) != 0 {
+		t.Fatal("shouldn't accept a relay state that was changed")
+	}
+
+	attributes["Email"] = th.BasicUser.Email
+	if resp := signInWithSaml(t, th.BasicClient, idp, certificate, model.NewId(), attributes); len(getSessionToken(resp)) != 0 {
+		t.Fatal("shouldn't be able to take over an account with the same email")
+	}
+
+	*utils.Cfg.SamlSettings.Enable = false
+	if resp, _ := noRedirectClient().Get(th.BasicClient.Url + "/login/sso/saml"); resp.StatusCode == http.StatusFound {
+		t.Fatal("shouldn't be able to sign in when SAML is disabled")
+	}
+}
+
+func TestEmailToSaml(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	m := map[string]string{}
+	if _, err := Client.EmailToSaml(m); err == nil {
+		t.Fatal("should have failed - empty data")
+	}
+
+	m["password"] = "pwd"
+	m["email"] = th.BasicUser.Email
+	if _, err := Client.EmailToSaml(m); err == nil {
+		t.Fatal("should have failed - saml is disabled")
+	}
+
+	idp, certificate, restore := setupSaml(t, Client)
+	defer restore()
+
+	m["password"] = "junk"
+	if _, err := Client.EmailToSaml(m); err == nil {
+		t.Fatal("should have failed - bad password")
+	}
+
+	m["password"] = th.BasicUser.Password
+	result := Client.Must(Client.EmailToSaml(m)).Data.(map[string]string)
+	relayState := getSamlRelayState(t, idp, result["follow_link"], certificate)
+
+	nameId := model.NewId()
+	resp := postSamlResponse(t, Client, idp.Response(nameId, map[string]string{"Email": th.BasicUser.Email}), relayState)
+	if resp.StatusCode != http.StatusFound || !strings.HasSuffix(resp.Header.Get("Location"), "/login?extra=signin_change") {
+		t.Fatal("should've switched the account", resp.StatusCode)
+	}
+
+	user := store.Must(Srv.Store.User().Get(th.BasicUser.Id)).(*model.User)
+	if user.AuthService != model.USER_AUTH_SERVICE_SAML || *user.AuthData != nameId {
+		t.Fatal("should've switched the account to SAML")
+	}
+
+	resp = signInWithSaml(t, Client, idp, certificate, nameId, map[string]string{"Email": th.BasicUser.Email})
+	if len(getSessionToken(resp)) == 0 {
+		t.Fatal("should be able to sign in with SAML")
+	}
+}
+
+func TestSamlToEmail(t *testing.T) {
+	th := Setup().InitBasic()
+
+	idp, certificate, restore := setupSaml(t, th.BasicClient)
+	defer restore()
+
+	email := "success+saml" + model.NewId()[:10] + "@simulator.amazonses.com"
+	resp := signInWithSaml(t, th.BasicClient, idp, certificate, model.NewId(), map[string]string{"Email": email})
+
+	Client := th.CreateClient()
+	Client.AuthToken = getSessionToken(resp)
+	Client.AuthType = model.HEADER_BEARER
+
+	m := map[string]string{"password": "newpwd", "email": th.BasicUser.Email}
+	if _, err := Client.SamlToEmail(m); err == nil {
+		t.Fatal("should have failed - another user's email")
+	}
+
+	if _, err := th.BasicClient.SamlToEmail(m); err == nil || err.Id != "api.user.saml_to_email.not_saml_user.app_error" {
+		t.Fatal("should have failed - not a saml user")
+	}
+
+	m["email"] = email
+	if result, err := Client.SamlToEmail(m); err != nil {
+		t.Fatal(err)
+	} else if result.Data.(map[string]string)["follow_link"] != "/login?extra=signin_change" {
+		t.Fatal("should've sent the user to sign in again")
+	}
+
+	if _, err := th.CreateClient().Login(email, "newpwd"); err != nil {
+		t.Fatal("should be able to sign in with a password", err)
+	}
+}
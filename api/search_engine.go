@@ -0,0 +1,494 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/tokenizer/whitespace"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	SEARCH_RESULTS_PER_PAGE           = 100
+	SEARCH_RESULTS_MAX_PER_PAGE       = 200
+	SEARCH_ENGINE_INDEXING_BATCH_SIZE = 1000
+
+	BLEVE_POSTS_INDEX_NAME  = "posts.bleve"
+	BLEVE_HASHTAGS_ANALYZER = "hashtags"
+)
+
+// BleveEngine is the built-in search engine. It keeps an index of posts on disk in
+// BleveSettings.IndexDir so searching doesn't need the database's full text support.
+// The index only sees the posts saved by this server, so it's for single server installs.
+type BleveEngine struct {
+	indexDir string
+	index    bleve.Index
+	mutex    sync.RWMutex
+}
+
+type bleveIndexedPost struct {
+	Id        string `json:"id"`
+	ChannelId string `json:"channel_id"`
+	UserId    string `json:"user_id"`
+	CreateAt  int64  `json:"create_at"`
+	Message   string `json:"message"`
+	Hashtags  string `json:"hashtags"`
+}
+
+func NewBleveEngine(indexDir string) *BleveEngine {
+	return &BleveEngine{indexDir: indexDir}
+}
+
+func newBleveIndexMapping() (mapping.IndexMapping, error) {
+	indexMapping := bleve.NewIndexMapping()
+
+	if err := indexMapping.AddCustomAnalyzer(BLEVE_HASHTAGS_ANALYZER, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     whitespace.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		return nil, err
+	}
+
+	keywordField := bleve.NewTextFieldMapping()
+	keywordField.Analyzer = keyword.Name
+	keywordField.IncludeInAll = false
+
+	messageField := bleve.NewTextFieldMapping()
+	messageField.Analyzer = standard.Name
+	messageField.Store = true
+
+	hashtagsField := bleve.NewTextFieldMapping()
+	hashtagsField.Analyzer = BLEVE_HASHTAGS_ANALYZER
+	hashtagsField.Store = true
+
+	createAtField := bleve.NewNumericFieldMapping()
+	createAtField.IncludeInAll = false
+
+	postMapping := bleve.NewDocumentStaticMapping()
+	postMapping.AddFieldMappingsAt("id", keywordField)
+	postMapping.AddFieldMappingsAt("channel_id", keywordField)
+	postMapping.AddFieldMappingsAt("user_id", keywordField)
+	postMapping.AddFieldMappingsAt("create_at", createAtField)
+	postMapping.AddFieldMappingsAt("message", messageField)
+	postMapping.AddFieldMappingsAt("hashtags", hashtagsField)
+
+	indexMapping.DefaultMapping = postMapping
+	indexMapping.DefaultAnalyzer = standard.Name
+
+	return indexMapping, nil
+}
+
+func (b *BleveEngine) indexPath() string {
+	return filepath.Join(b.indexDir, BLEVE_POSTS_INDEX_NAME)
+}
+
+func (b *BleveEngine) openIndex() *model.AppError {
+	if err := os.MkdirAll(b.indexDir, 0774); err != nil {
+		return model.NewLocAppError("BleveEngine.Start", "api.search_engine.bleve.open.app_error", nil, err.Error())
+	}
+
+	var index bleve.Index
+	var err error
+	if _, statErr := os.Stat(b.indexPath()); os.IsNotExist(statErr) {
+		var indexMapping mapping.IndexMapping
+		if indexMapping, err = newBleveIndexMapping(); err == nil {
+			index, err = bleve.New(b.indexPath(), indexMapping)
+		}
+	} else {
+		index, err = bleve.Open(b.indexPath())
+	}
+
+	if err != nil {
+		return model.NewLocAppError("BleveEngine.Start", "api.search_engine.bleve.open.app_error", nil, err.Error())
+	}
+
+	b.index = index
+	return nil
+}
+
+func (b *BleveEngine) Start() *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.index != nil {
+		return nil
+	}
+
+	l4g.Info(utils.T("api.search_engine.bleve.start.info"), b.indexPath())
+
+	return b.openIndex()
+}
+
+func (b *BleveEngine) Stop() *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.index == nil {
+		return nil
+	}
+
+	err := b.index.Close()
+	b.index = nil
+
+	if err != nil {
+		return model.NewLocAppError("BleveEngine.Stop", "api.search_engine.bleve.close.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+func (b *BleveEngine) IndexPost(post *model.Post) *model.AppError {
+	if post.DeleteAt != 0 {
+		return b.DeletePost(post)
+	}
+
+	if strings.HasPrefix(post.Type, model.POST_SYSTEM_MESSAGE_PREFIX) {
+		return nil
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.index == nil {
+		return model.NewLocAppError("BleveEngine.IndexPost", "api.search_engine.bleve.not_started.app_error", nil, "")
+	}
+
+	doc := &bleveIndexedPost{
+		Id:        post.Id,
+		ChannelId: post.ChannelId,
+		UserId:    post.UserId,
+		CreateAt:  post.CreateAt,
+		Message:   post.Message,
+		Hashtags:  post.Hashtags,
+	}
+
+	if err := b.index.Index(post.Id, doc); err != nil {
+		return model.NewLocAppError("BleveEngine.IndexPost", "api.search_engine.bleve.index.app_error", nil, "post_id="+post.Id+", err="+err.Error())
+	}
+
+	return nil
+}
+
+func (b *BleveEngine) DeletePost(post *model.Post) *model.AppError {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.index == nil {
+		return model.NewLocAppError("BleveEngine.DeletePost", "api.search_engine.bleve.not_started.app_error", nil, "")
+	}
+
+	if err := b.index.Delete(post.Id); err != nil {
+		return model.NewLocAppError("BleveEngine.DeletePost", "api.search_engine.bleve.delete.app_error", nil, "post_id="+post.Id+", err="+err.Error())
+	}
+
+	return nil
+}
+
+func (b *BleveEngine) PurgeIndex() *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.index != nil {
+		b.index.Close()
+		b.index = nil
+	}
+
+	if err := os.RemoveAll(b.indexPath()); err != nil {
+		return model.NewLocAppError("BleveEngine.PurgeIndex", "api.search_engine.bleve.purge.app_error", nil, err.Error())
+	}
+
+	return b.openIndex()
+}
+
+func (b *BleveEngine) SearchPosts(channelIds []string, userIds []string, params *model.SearchParams, page, perPage int) ([]*model.PostSearchHit, *model.AppError) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.index == nil {
+		return nil, model.NewLocAppError("BleveEngine.SearchPosts", "api.search_engine.bleve.not_started.app_error", nil, "")
+	}
+
+	if len(channelIds) == 0 {
+		return []*model.PostSearchHit{}, nil
+	}
+
+	filters := []query.Query{bleveFieldQuery("channel_id", channelIds)}
+	if len(userIds) > 0 {
+		filters = append(filters, bleveFieldQuery("user_id", userIds))
+	}
+
+	if start, end := params.GetCreateAtRange(); start != 0 || end != 0 {
+		var min, max *float64
+		if start != 0 {
+			min = new(float64)
+			*min = float64(start)
+		}
+		if end != 0 {
+			max = new(float64)
+			*max = float64(end)
+		}
+
+		inclusive := true
+		q := bleve.NewNumericRangeInclusiveQuery(min, max, &inclusive, &inclusive)
+		q.SetField("create_at")
+		filters = append(filters, q)
+	}
+
+	if termQueries := b.termQueries(params); len(termQueries) > 0 {
+		if params.OrTerms {
+			filters = append(filters, bleve.NewDisjunctionQuery(termQueries...))
+		} else {
+			filters = append(filters, bleve.NewConjunctionQuery(termQueries...))
+		}
+	} else if len(params.Terms) > 0 {
+		// every term was a word that isn't indexed so nothing can match
+		return []*model.PostSearchHit{}, nil
+	}
+
+	searchQuery := bleve.NewBooleanQuery()
+	searchQuery.AddMust(filters...)
+
+	for _, term := range strings.Fields(params.ExcludedTerms) {
+		q := bleve.NewMatchQuery(term)
+		q.SetField("message")
+		searchQuery.AddMustNot(q)
+	}
+
+	for _, hashtag := range strings.Fields(params.ExcludedHashtags) {
+		q := bleve.NewTermQuery(strings.ToLower(hashtag))
+		q.SetField("hashtags")
+		searchQuery.AddMustNot(q)
+	}
+
+	request := bleve.NewSearchRequestOptions(searchQuery, perPage, page*perPage, false)
+	request.Fields = []string{"message", "hashtags"}
+	request.IncludeLocations = true
+	request.SortBy([]string{"-_score", "-create_at"})
+
+	searchResult, err := b.index.Search(request)
+	if err != nil {
+		return nil, model.NewLocAppError("BleveEngine.SearchPosts", "api.search_engine.bleve.search.app_error", nil, err.Error())
+	}
+
+	hits := make([]*model.PostSearchHit, 0, len(searchResult.Hits))
+	for _, match := range searchResult.Hits {
+		hit := &model.PostSearchHit{Id: match.ID, Score: match.Score, Matches: []string{}}
+
+		seen := map[string]bool{}
+		for field, terms := range match.Locations {
+			text, _ := match.Fields[field].(string)
+
+			for _, locations := range terms {
+				for _, location := range locations {
+					if location.End > uint64(len(text)) || location.Start >= location.End {
+						continue
+					}
+
+					if word := text[location.Start:location.End]; !seen[word] {
+						seen[word] = true
+						hit.Matches = append(hit.Matches, word)
+					}
+				}
+			}
+		}
+
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
+
+// termQueries turns the search terms into one query per word or quoted phrase. Words
+// that the analyzer drops, like "the" or "a", are skipped since they're never indexed.
+func (b *BleveEngine) termQueries(params *model.SearchParams) []query.Query {
+	queries := []query.Query{}
+	analyzer := b.index.Mapping().AnalyzerNamed(standard.Name)
+
+	for _, term := range params.GetTermsList() {
+		if params.IsHashtag {
+			q := bleve.NewTermQuery(strings.ToLower(term))
+			q.SetField("hashtags")
+			queries = append(queries, q)
+		} else if strings.HasPrefix(term, "\"") {
+			phrase := strings.Trim(term, "\"")
+			if len(analyzer.Analyze([]byte(phrase))) == 0 {
+				continue
+			}
+
+			q := bleve.NewMatchPhraseQuery(phrase)
+			q.SetField("message")
+			queries = append(queries, q)
+		} else if strings.HasSuffix(term, "*") {
+			prefix := strings.ToLower(strings.TrimRight(term, "*"))
+			if len(prefix) == 0 {
+				continue
+			}
+
+			q := bleve.NewPrefixQuery(prefix)
+			q.SetField("message")
+			queries = append(queries, q)
+		} else {
+			if len(analyzer.Analyze([]byte(term))) == 0 {
+				continue
+			}
+
+			q := bleve.NewMatchQuery(term)
+			q.SetField("message")
+			queries = append(queries, q)
+		}
+	}
+
+	return queries
+}
+
+func bleveFieldQuery(field string, values []string) query.Query {
+	queries := make([]query.Query, len(values))
+	for i, value := range values {
+		q := bleve.NewTermQuery(value)
+		q.SetField(field)
+		queries[i] = q
+	}
+
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+func indexPostForSearch(post *model.Post) {
+	if engine := einterfaces.GetSearchEngineInterface(); engine != nil {
+		if err := engine.IndexPost(post); err != nil {
+			l4g.Error(utils.T("api.search_engine.index_post.error"), post.Id, err)
+		}
+	}
+}
+
+func deletePostFromSearch(post *model.Post) {
+	if engine := einterfaces.GetSearchEngineInterface(); engine != nil {
+		if err := engine.DeletePost(post); err != nil {
+			l4g.Error(utils.T("api.search_engine.delete_post.error"), post.Id, err)
+		}
+	}
+}
+
+func searchPostsWithEngine(engine einterfaces.SearchEngineInterface, teamId string, userId string, paramsList []*model.SearchParams, page, perPage int) (*model.PostSearchResults, *model.AppError) {
+	results := model.NewPostSearchResults()
+
+	var channels []*model.Channel
+	if result := <-Srv.Store.Channel().GetChannels(teamId, userId); result.Err != nil {
+		// users without any channels have nothing to search
+		return results, nil
+	} else {
+		channels = result.Data.(*model.ChannelList).Channels
+	}
+
+	for _, params := range paramsList {
+		// don't allow users to search for everything
+		if params.Terms == "*" || (params.Terms == "" && !params.HasFilters()) {
+			continue
+		}
+
+		inChannels := map[string]bool{}
+		for _, name := range params.InChannels {
+			inChannels[name] = true
+		}
+
+		channelIds := []string{}
+		for _, channel := range channels {
+			if len(inChannels) == 0 || inChannels[channel.Name] {
+				channelIds = append(channelIds, channel.Id)
+			}
+		}
+
+		userIds := []string{}
+		for _, username := range params.FromUsers {
+			if result := <-Srv.Store.User().GetByUsername(username); result.Err == nil {
+				userIds = append(userIds, result.Data.(*model.User).Id)
+			}
+		}
+
+		if len(channelIds) == 0 || (len(params.FromUsers) > 0 && len(userIds) == 0) {
+			continue
+		}
+
+		hits, err := engine.SearchPosts(channelIds, userIds, params, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+
+		postIds := make([]string, len(hits))
+		for i, hit := range hits {
+			postIds[i] = hit.Id
+		}
+
+		posts := map[string]*model.Post{}
+		if result := <-Srv.Store.Post().GetPostsByIds(postIds); result.Err != nil {
+			return nil, result.Err
+		} else {
+			for _, post := range result.Data.([]*model.Post) {
+				posts[post.Id] = post
+			}
+		}
+
+		for _, hit := range hits {
+			// the index can briefly hold posts that have since been deleted
+			if post, ok := posts[hit.Id]; ok && results.Posts[hit.Id] == nil {
+				results.AddResult(post, hit.Score, model.FindSearchMatches(post.Message, hit.Matches))
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// RebuildSearchIndex empties the search engine's index and indexes every post in the database again
+func RebuildSearchIndex() (int, *model.AppError) {
+	engine := einterfaces.GetSearchEngineInterface()
+	if engine == nil {
+		return 0, model.NewLocAppError("RebuildSearchIndex", "api.search_engine.rebuild.disabled.app_error", nil, "")
+	}
+
+	if err := engine.PurgeIndex(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var startTime int64 = 0
+	startId := ""
+
+	for {
+		var posts []*model.Post
+		if result := <-Srv.Store.Post().GetPostsBatchForIndexing(startTime, startId, SEARCH_ENGINE_INDEXING_BATCH_SIZE); result.Err != nil {
+			return count, result.Err
+		} else {
+			posts = result.Data.([]*model.Post)
+		}
+
+		for _, post := range posts {
+			if err := engine.IndexPost(post); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if len(posts) < SEARCH_ENGINE_INDEXING_BATCH_SIZE {
+			return count, nil
+		}
+
+		startTime = posts[len(posts)-1].CreateAt
+		startId = posts[len(posts)-1].Id
+	}
+}
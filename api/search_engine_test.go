@@ -0,0 +1,220 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestBleveEngine(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	dir, err := ioutil.TempDir("", "bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	engine := NewBleveEngine(dir)
+	if err := engine.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Stop()
+
+	channelId := model.NewId()
+	otherChannelId := model.NewId()
+	userId := model.NewId()
+
+	post1 := &model.Post{Id: model.NewId(), ChannelId: channelId, UserId: userId, CreateAt: 1, Message: "The quick brown fox jumps"}
+	post2 := &model.Post{Id: model.NewId(), ChannelId: channelId, UserId: model.NewId(), CreateAt: 2, Message: "brown bears and a brown fox", Hashtags: "#Bears"}
+	post3 := &model.Post{Id: model.NewId(), ChannelId: otherChannelId, UserId: userId, CreateAt: 3, Message: "another brown fox"}
+	post4 := &model.Post{Id: model.NewId(), ChannelId: channelId, UserId: userId, CreateAt: 4, Message: "brown joined the channel", Type: model.POST_JOIN_LEAVE}
+
+	for _, post := range []*model.Post{post1, post2, post3, post4} {
+		if err := engine.IndexPost(post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(terms string, isOrSearch bool, userIds []string, page, perPage int) []*model.PostSearchHit {
+		params := model.ParseSearchParams(terms)[0]
+		params.OrTerms = isOrSearch
+
+		hits, err := engine.SearchPosts([]string{channelId}, userIds, params, page, perPage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hits
+	}
+
+	if hits := search("brown", false, nil, 0, 10); len(hits) != 2 {
+		t.Fatal("should have found both posts in the channel but not the system message", len(hits))
+	} else if hits[0].Id != post2.Id {
+		t.Fatal("post mentioning brown twice should score highest")
+	} else if hits[0].Score < hits[1].Score {
+		t.Fatal("hits should be ordered by score")
+	}
+
+	if hits := search("QUICK fox", false, nil, 0, 10); len(hits) != 1 || hits[0].Id != post1.Id {
+		t.Fatal("should have matched all of the terms")
+	} else if len(hits[0].Matches) != 2 || hits[0].Matches[0] == hits[0].Matches[1] {
+		t.Fatal("should have returned the matched words", hits[0].Matches)
+	} else {
+		for _, match := range hits[0].Matches {
+			if match != "quick" && match != "fox" {
+				t.Fatal("bad match " + match)
+			}
+		}
+	}
+
+	if hits := search("quick bears", true, nil, 0, 10); len(hits) != 2 {
+		t.Fatal("should have matched any of the terms")
+	}
+
+	if hits := search("\"brown fox\"", false, nil, 0, 10); len(hits) != 2 {
+		t.Fatal("should have matched the phrase")
+	}
+
+	if hits := search("\"fox brown\"", false, nil, 0, 10); len(hits) != 0 {
+		t.Fatal("should have matched the words in order")
+	}
+
+	if hits := search("jum*", false, nil, 0, 10); len(hits) != 1 || hits[0].Id != post1.Id {
+		t.Fatal("should have matched the prefix")
+	} else if len(hits[0].Matches) != 1 || hits[0].Matches[0] != "jumps" {
+		t.Fatal("should have returned the whole word", hits[0].Matches)
+	}
+
+	if hits := search("#bears", false, nil, 0, 10); len(hits) != 1 || hits[0].Id != post2.Id {
+		t.Fatal("should have matched the hashtag")
+	} else if len(hits[0].Matches) != 1 || hits[0].Matches[0] != "#Bears" {
+		t.Fatal("should have returned the hashtag", hits[0].Matches)
+	}
+
+	if hits := search("the", false, nil, 0, 10); len(hits) != 0 {
+		t.Fatal("stop words shouldn't match anything")
+	}
+
+	if hits := search("the quick", false, nil, 0, 10); len(hits) != 1 {
+		t.Fatal("stop words should be ignored")
+	}
+
+	if hits := search("fox", false, []string{userId}, 0, 10); len(hits) != 1 || hits[0].Id != post1.Id {
+		t.Fatal("should have only matched posts by the user")
+	}
+
+	if hits := search("brown -bears", false, nil, 0, 10); len(hits) != 1 || hits[0].Id != post1.Id {
+		t.Fatal("should have left out the excluded term")
+	}
+
+	if hits := search("brown -#bears", false, nil, 0, 10); len(hits) != 1 || hits[0].Id != post1.Id {
+		t.Fatal("should have left out the excluded hashtag")
+	}
+
+	if hits := search("brown on:1970-01-01", false, nil, 0, 10); len(hits) != 2 {
+		t.Fatal("should have found posts on the day")
+	}
+
+	if hits := search("brown after:1970-01-01", false, nil, 0, 10); len(hits) != 0 {
+		t.Fatal("shouldn't have found posts before the date")
+	}
+
+	page0 := search("fox", false, nil, 0, 1)
+	page1 := search("fox", false, nil, 1, 1)
+	if len(page0) != 1 || len(page1) != 1 || page0[0].Id == page1[0].Id {
+		t.Fatal("pages should hold different posts")
+	}
+
+	if err := engine.DeletePost(post1); err != nil {
+		t.Fatal(err)
+	}
+
+	if hits := search("quick", false, nil, 0, 10); len(hits) != 0 {
+		t.Fatal("deleted post shouldn't be found")
+	}
+
+	if err := engine.PurgeIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	if hits := search("brown", false, nil, 0, 10); len(hits) != 0 {
+		t.Fatal("index should be empty")
+	}
+}
+
+func TestSearchPostsWithEngine(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	dir, err := ioutil.TempDir("", "bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	engine := NewBleveEngine(dir)
+	if err := engine.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Stop()
+
+	enableSearching := *utils.Cfg.BleveSettings.EnableSearching
+	defer func() {
+		einterfaces.RegisterSearchEngineInterface(nil)
+		*utils.Cfg.BleveSettings.EnableSearching = enableSearching
+	}()
+	einterfaces.RegisterSearchEngineInterface(engine)
+	*utils.Cfg.BleveSettings.EnableSearching = true
+
+	channel2 := th.CreateChannel(Client, th.BasicTeam)
+
+	post1 := &model.Post{ChannelId: th.BasicChannel.Id, Message: "zebra crossing"}
+	post1 = Client.Must(Client.CreatePost(post1)).Data.(*model.Post)
+
+	post2 := &model.Post{ChannelId: th.BasicChannel.Id, Message: "zebra stripes"}
+	post2 = Client.Must(Client.CreatePost(post2)).Data.(*model.Post)
+
+	// index everything up front instead of waiting on the posts being indexed in the background
+	if _, err := RebuildSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := Client.Must(Client.SearchPosts("zebra", false)).Data.(*model.PostList); len(r.Order) != 2 {
+		t.Fatal("wrong search")
+	}
+
+	if r := Client.Must(Client.SearchPosts("stripes in:"+th.BasicChannel.Name, false)).Data.(*model.PostList); len(r.Order) != 1 || r.Order[0] != post2.Id {
+		t.Fatal("wrong search")
+	}
+
+	if r := Client.Must(Client.SearchPosts("zebra in:"+channel2.Name, false)).Data.(*model.PostList); len(r.Order) != 0 {
+		t.Fatal("wrong search")
+	}
+
+	Client.Must(Client.DeletePost(post1.ChannelId, post1.Id))
+
+	if r := Client.Must(Client.SearchPosts("crossing", false)).Data.(*model.PostList); len(r.Order) != 0 {
+		t.Fatal("deleted post shouldn't be found")
+	}
+
+	if imported := ImportPost(&model.Post{ChannelId: th.BasicChannel.Id, UserId: th.BasicUser.Id, Message: "imported okapi"}); imported == nil {
+		t.Fatal("should've imported the post")
+	} else if r := Client.Must(Client.SearchPosts("okapi", false)).Data.(*model.PostList); len(r.Order) != 1 || r.Order[0] != imported.Id {
+		t.Fatal("imported post should've been indexed")
+	}
+
+	Client.Logout()
+	th.LoginBasic2()
+
+	if r := Client.Must(Client.SearchPosts("zebra", false)).Data.(*model.PostList); len(r.Order) != 0 {
+		t.Fatal("shouldn't find posts in channels the user isn't a member of")
+	}
+}
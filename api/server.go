@@ -8,6 +8,7 @@ import (
 	"github.com/braintree/manners"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
 	"gopkg.in/throttled/throttled.v1"
@@ -37,6 +38,22 @@ func NewServer() {
 
 	Srv.Router = mux.NewRouter()
 	Srv.Router.NotFoundHandler = http.HandlerFunc(Handle404)
+
+	if *utils.Cfg.ClusterSettings.Enable && einterfaces.GetClusterInterface() == nil {
+		einterfaces.RegisterClusterInterface(NewHttpCluster(hub, *utils.Cfg.ClusterSettings.InterNodeListenAddress, utils.Cfg.ClusterSettings.InterNodeUrls, *utils.Cfg.ClusterSettings.InterNodeSecret))
+	}
+
+	if *utils.Cfg.BleveSettings.EnableIndexing && einterfaces.GetSearchEngineInterface() == nil {
+		einterfaces.RegisterSearchEngineInterface(NewBleveEngine(*utils.Cfg.BleveSettings.IndexDir))
+	}
+
+	if *utils.Cfg.ComplianceSettings.Enable && einterfaces.GetComplianceInterface() == nil {
+		einterfaces.RegisterComplianceInterface(NewComplianceExporter())
+	}
+
+	if einterfaces.GetSamlInterface() == nil {
+		einterfaces.RegisterSamlInterface(NewSamlServiceProvider())
+	}
 }
 
 func StartServer() {
@@ -80,12 +97,46 @@ func StartServer() {
 			time.Sleep(time.Second)
 		}
 	}()
+
+	if clusterI := einterfaces.GetClusterInterface(); clusterI != nil {
+		clusterI.StartInterNodeCommunication()
+	}
+
+	if searchEngine := einterfaces.GetSearchEngineInterface(); searchEngine != nil {
+		if err := searchEngine.Start(); err != nil {
+			l4g.Error(utils.T("api.server.start_server.search_engine.error"), err)
+		}
+	}
+
+	StartOutgoingWebhookDeliveryWorker()
+	StartImageWorkers()
+	StartContentWorkers()
+	StartUploadSessionCleanupJob()
+	StartExportWorker()
+	StartFileInfoMigration()
 }
 
 func StopServer() {
 
 	l4g.Info(utils.T("api.server.stop_server.stopping.info"))
 
+	StopOutgoingWebhookDeliveryWorker()
+	StopImageWorkers()
+	StopContentWorkers()
+	StopUploadSessionCleanupJob()
+	StopExportWorker()
+	StopFileInfoMigration()
+
+	if clusterI := einterfaces.GetClusterInterface(); clusterI != nil {
+		clusterI.StopInterNodeCommunication()
+	}
+
+	if searchEngine := einterfaces.GetSearchEngineInterface(); searchEngine != nil {
+		if err := searchEngine.Stop(); err != nil {
+			l4g.Error(utils.T("api.server.stop_server.search_engine.error"), err)
+		}
+	}
+
 	manners.Close()
 	Srv.Store.Close()
 	hub.Stop()
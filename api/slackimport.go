@@ -5,22 +5,41 @@ package api
 
 import (
 	"archive/zip"
-	"bytes"
 	"encoding/json"
-	l4g "github.com/alecthomas/log4go"
-	"github.com/mattermost/platform/model"
-	"github.com/mattermost/platform/utils"
+	"fmt"
+	"html"
 	"io"
 	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	SLACK_UPLOADS_FOLDER  = "__uploads"
+	SLACK_FILE_HOST       = "files.slack.com"
+	SLACK_FILE_DL_TIMEOUT = 30 * time.Second
 )
 
 type SlackChannel struct {
-	Id      string            `json:"id"`
-	Name    string            `json:"name"`
-	Members []string          `json:"members"`
-	Topic   map[string]string `json:"topic"`
+	Id      string          `json:"id"`
+	Name    string          `json:"name"`
+	Members []string        `json:"members"`
+	Topic   SlackChannelSub `json:"topic"`
+	Purpose SlackChannelSub `json:"purpose"`
+}
+
+type SlackChannelSub struct {
+	Value string `json:"value"`
 }
 
 type SlackUser struct {
@@ -30,14 +49,51 @@ type SlackUser struct {
 }
 
 type SlackPost struct {
-	User        string            `json:"user"`
-	BotId       string            `json:"bot_id"`
-	BotUsername string            `json:"username"`
-	Text        string            `json:"text"`
-	TimeStamp   string            `json:"ts"`
-	Type        string            `json:"type"`
-	SubType     string            `json:"subtype"`
-	Comment     map[string]string `json:"comment"`
+	User            string                   `json:"user"`
+	BotId           string                   `json:"bot_id"`
+	BotUsername     string                   `json:"username"`
+	Text            string                   `json:"text"`
+	TimeStamp       string                   `json:"ts"`
+	ThreadTimeStamp string                   `json:"thread_ts"`
+	Type            string                   `json:"type"`
+	SubType         string                   `json:"subtype"`
+	Comment         *SlackComment            `json:"comment"`
+	Reactions       []SlackReaction          `json:"reactions"`
+	File            *SlackFile               `json:"file"`
+	Files           []*SlackFile             `json:"files"`
+	Attachments     []map[string]interface{} `json:"attachments"`
+	Edited          *SlackEdited             `json:"edited"`
+	Message         *SlackPost               `json:"message"` // the new version of an edited message
+}
+
+type SlackEdited struct {
+	User      string `json:"user"`
+	TimeStamp string `json:"ts"`
+}
+
+type SlackComment struct {
+	User    string `json:"user"`
+	Comment string `json:"comment"`
+}
+
+type SlackReaction struct {
+	Name  string   `json:"name"`
+	Users []string `json:"users"`
+}
+
+type SlackFile struct {
+	Id                 string `json:"id"`
+	Name               string `json:"name"`
+	Title              string `json:"title"`
+	UrlPrivateDownload string `json:"url_private_download"`
+}
+
+// slackImportMaps tracks the ids from the export and the objects they were imported as
+type slackImportMaps struct {
+	users    map[string]*model.User    // by slack user id
+	created  map[string]bool           // slack ids of the users that were created by this import instead of merged
+	channels map[string]*model.Channel // by slack channel id
+	uploads  map[string]*zip.File      // files included in the export, by slack file id
 }
 
 func SlackConvertTimeStamp(ts string) int64 {
@@ -60,6 +116,53 @@ func SlackConvertChannelName(channelName string) string {
 	return newName
 }
 
+var slackMarkup = regexp.MustCompile(`<([@#!]?)([^>|]+)(?:\|([^>]*))?>`)
+
+// SlackConvertMarkup rewrites Slack's <@U123>, <#C123>, <!channel> and <http://...|label> markup into the mentions
+// and Markdown links that we use
+func SlackConvertMarkup(text string, maps *slackImportMaps) string {
+	text = slackMarkup.ReplaceAllStringFunc(text, func(markup string) string {
+		parts := slackMarkup.FindStringSubmatch(markup)
+		prefix, value, label := parts[1], parts[2], parts[3]
+
+		switch prefix {
+		case "@":
+			if user := maps.users[value]; user != nil {
+				return "@" + user.Username
+			} else if label != "" {
+				return "@" + strings.TrimPrefix(label, "@")
+			}
+		case "#":
+			if channel := maps.channels[value]; channel != nil {
+				return "~" + channel.Name
+			} else if label != "" {
+				return "~" + label
+			}
+		case "!":
+			switch value {
+			case "channel", "here":
+				return "@" + value
+			case "everyone":
+				return "@all"
+			}
+
+			if label != "" {
+				return label
+			}
+		default:
+			if label != "" && label != value {
+				return "[" + label + "](" + value + ")"
+			}
+			return value
+		}
+
+		return markup
+	})
+
+	// Slack escapes these characters in the text of messages
+	return html.UnescapeString(text)
+}
+
 func SlackParseChannels(data io.Reader) []SlackChannel {
 	decoder := json.NewDecoder(data)
 
@@ -93,18 +196,13 @@ func SlackParsePosts(data io.Reader) []SlackPost {
 	return posts
 }
 
-func SlackAddUsers(teamId string, slackusers []SlackUser, log *bytes.Buffer) map[string]*model.User {
-	// Log header
-	log.WriteString(utils.T("api.slackimport.slack_add_users.created"))
-	log.WriteString("===============\r\n\r\n")
-
-	addedUsers := make(map[string]*model.User)
-
+// SlackAddUsers creates the users from users.json, or merges them with the existing users that have the same email
+func SlackAddUsers(teamId string, slackusers []SlackUser, maps *slackImportMaps, report *model.ImportReport) {
 	// Need the team
 	var team *model.Team
 	if result := <-Srv.Store.Team().Get(teamId); result.Err != nil {
-		log.WriteString(utils.T("api.slackimport.slack_import.team_fail"))
-		return addedUsers
+		report.Notes = append(report.Notes, utils.T("api.slackimport.slack_import.team_fail"))
+		return
 	} else {
 		team = result.Data.(*model.Team)
 	}
@@ -129,151 +227,523 @@ func SlackAddUsers(teamId string, slackusers []SlackUser, log *bytes.Buffer) map
 			Password:  password,
 		}
 
+		item := &model.ImportReportItem{Type: model.IMPORT_ITEM_USER, SourceId: sUser.Id, Name: sUser.Username}
+
 		if mUser := ImportUser(team, &newUser); mUser != nil {
-			addedUsers[sUser.Id] = mUser
-			log.WriteString(utils.T("api.slackimport.slack_add_users.email_pwd", map[string]interface{}{"Email": newUser.Email, "Password": password}))
+			maps.users[sUser.Id] = mUser
+			maps.created[sUser.Id] = true
+			item.Result = model.IMPORT_RESULT_CREATED
+			item.Id = mUser.Id
+			item.Message = utils.T("api.slackimport.slack_add_users.email_pwd", map[string]interface{}{"Email": newUser.Email, "Password": password})
+		} else if result := <-Srv.Store.User().GetByEmail(newUser.Email); len(newUser.Email) > 0 && result.Err == nil {
+			// the user already has an account so their posts are imported as them
+			mUser := result.Data.(*model.User)
+			JoinUserToTeam(team, mUser)
+
+			maps.users[sUser.Id] = mUser
+			item.Result = model.IMPORT_RESULT_MERGED
+			item.Id = mUser.Id
+			item.Message = utils.T("api.slackimport.slack_add_users.merge", map[string]interface{}{"Username": mUser.Username})
 		} else {
-			log.WriteString(utils.T("api.slackimport.slack_add_users.unable_import", map[string]interface{}{"Username": sUser.Username}))
+			item.Result = model.IMPORT_RESULT_FAILED
+			item.Message = utils.T("api.slackimport.slack_add_users.unable_import", map[string]interface{}{"Username": sUser.Username})
 		}
+
+		report.Add(item)
 	}
+}
 
-	return addedUsers
+func addSlackUsersToChannel(members []string, users map[string]*model.User, channel *model.Channel, report *model.ImportReport) {
+	for _, member := range members {
+		if user, ok := users[member]; !ok {
+			report.Notes = append(report.Notes, utils.T("api.slackimport.slack_add_channels.failed_to_add_user", map[string]interface{}{"Username": member, "Channel": channel.Name}))
+		} else {
+			if _, err := AddUserToChannel(user, channel); err != nil {
+				report.Notes = append(report.Notes, utils.T("api.slackimport.slack_add_channels.failed_to_add_user", map[string]interface{}{"Username": user.Username, "Channel": channel.Name}))
+			}
+		}
+	}
 }
 
-func SlackAddPosts(channel *model.Channel, posts []SlackPost, users map[string]*model.User) {
+// SlackAddChannels imports the public channels from channels.json or the private groups from groups.json
+func SlackAddChannels(teamId string, channelType string, slackchannels []SlackChannel, maps *slackImportMaps, report *model.ImportReport) {
+	for _, sChannel := range slackchannels {
+		newChannel := model.Channel{
+			TeamId:      teamId,
+			Type:        channelType,
+			DisplayName: sChannel.Name,
+			Name:        SlackConvertChannelName(sChannel.Name),
+			Purpose:     sChannel.Purpose.Value,
+			Header:      sChannel.Topic.Value,
+		}
+
+		item := &model.ImportReportItem{Type: model.IMPORT_ITEM_CHANNEL, SourceId: sChannel.Id, Name: sChannel.Name, Result: model.IMPORT_RESULT_CREATED}
+
+		mChannel := ImportChannel(&newChannel)
+		if mChannel == nil {
+			// Maybe it already exists?
+			if result := <-Srv.Store.Channel().GetByName(teamId, newChannel.Name); result.Err != nil {
+				l4g.Debug(utils.T("api.slackimport.slack_add_channels.import_failed.debug"), newChannel.DisplayName)
+				item.Result = model.IMPORT_RESULT_FAILED
+				item.Message = utils.T("api.slackimport.slack_add_channels.import_failed", map[string]interface{}{"DisplayName": newChannel.DisplayName})
+				report.Add(item)
+				continue
+			} else if existing := result.Data.(*model.Channel); existing.Type == channelType {
+				mChannel = existing
+				item.Result = model.IMPORT_RESULT_MERGED
+				item.Message = utils.T("api.slackimport.slack_add_channels.merge", map[string]interface{}{"DisplayName": newChannel.DisplayName})
+			} else {
+				// a private group is never merged into a public channel, or the other way around, since that would
+				// change who can read its messages
+				newChannel.Id = ""
+				newChannel.Name = slackUniqueChannelName(newChannel.Name)
+
+				if mChannel = ImportChannel(&newChannel); mChannel == nil {
+					l4g.Debug(utils.T("api.slackimport.slack_add_channels.import_failed.debug"), newChannel.DisplayName)
+					item.Result = model.IMPORT_RESULT_FAILED
+					item.Message = utils.T("api.slackimport.slack_add_channels.import_failed", map[string]interface{}{"DisplayName": newChannel.DisplayName})
+					report.Add(item)
+					continue
+				}
+
+				item.Message = utils.T("api.slackimport.slack_add_channels.renamed", map[string]interface{}{"DisplayName": newChannel.DisplayName, "Name": newChannel.Name})
+			}
+		}
+
+		addSlackUsersToChannel(sChannel.Members, maps.users, mChannel, report)
+
+		item.Id = mChannel.Id
+		report.Add(item)
+		maps.channels[sChannel.Id] = mChannel
+	}
+}
+
+// slackUniqueChannelName adds a random suffix to the name of a channel that conflicts with an existing one
+func slackUniqueChannelName(name string) string {
+	suffix := "-" + model.NewId()[:8]
+	if len(name)+len(suffix) > 64 {
+		name = name[:64-len(suffix)]
+	}
+
+	return name + suffix
+}
+
+// SlackAddDirectChannels imports the direct message channels from dms.json. Only channels between users that were
+// created by this import are imported since anyone can put an existing user's email in an export and would otherwise
+// be able to add to their private history.
+func SlackAddDirectChannels(slackchannels []SlackChannel, maps *slackImportMaps, report *model.ImportReport) {
+	for _, sChannel := range slackchannels {
+		item := &model.ImportReportItem{Type: model.IMPORT_ITEM_CHANNEL, SourceId: sChannel.Id}
+
+		if len(sChannel.Members) != 2 || maps.users[sChannel.Members[0]] == nil || maps.users[sChannel.Members[1]] == nil || sChannel.Members[0] == sChannel.Members[1] {
+			item.Result = model.IMPORT_RESULT_SKIPPED
+			item.Message = utils.T("api.slackimport.slack_add_direct_channels.members")
+			report.Add(item)
+			continue
+		}
+
+		if !maps.created[sChannel.Members[0]] || !maps.created[sChannel.Members[1]] {
+			item.Result = model.IMPORT_RESULT_SKIPPED
+			item.Message = utils.T("api.slackimport.slack_add_direct_channels.existing_user")
+			report.Add(item)
+			continue
+		}
+
+		user1 := maps.users[sChannel.Members[0]]
+		user2 := maps.users[sChannel.Members[1]]
+		item.Name = user1.Username + ", " + user2.Username
+
+		if channel, err := CreateDirectChannel(user1.Id, user2.Id); err != nil {
+			item.Result = model.IMPORT_RESULT_FAILED
+			item.Message = err.Error()
+		} else {
+			item.Result = model.IMPORT_RESULT_CREATED
+			item.Id = channel.Id
+			maps.channels[sChannel.Id] = channel
+		}
+
+		report.Add(item)
+	}
+}
+
+// SlackAddPosts imports the messages from one channel's folder in the export along with their files and reactions
+func SlackAddPosts(teamId string, channel *model.Channel, posts []SlackPost, maps *slackImportMaps, report *model.ImportReport) {
+	// Import the oldest posts first so that a reply's root post has already been imported
+	sort.Stable(slackPostsByTimeStamp(posts))
+
+	postIds := map[string]string{} // by slack timestamp
+
 	for _, sPost := range posts {
+		var user *model.User
+		message := sPost.Text
+
 		switch {
-		case sPost.Type == "message" && (sPost.SubType == "" || sPost.SubType == "file_share"):
+		case sPost.Type == "message" && (sPost.SubType == "" || sPost.SubType == "file_share" || sPost.SubType == "thread_broadcast" || sPost.SubType == "me_message"):
 			if sPost.User == "" {
 				l4g.Debug(utils.T("api.slackimport.slack_add_posts.without_user.debug"))
-				continue
-			} else if users[sPost.User] == nil {
-				l4g.Debug(utils.T("api.slackimport.slack_add_posts.user_no_exists.debug"), sPost.User)
+				slackSkipPost(sPost, utils.T("api.slackimport.slack_add_posts.without_user.debug"), report)
 				continue
 			}
-			newPost := model.Post{
-				UserId:    users[sPost.User].Id,
-				ChannelId: channel.Id,
-				Message:   sPost.Text,
-				CreateAt:  SlackConvertTimeStamp(sPost.TimeStamp),
-			}
-			ImportPost(&newPost)
+			user = maps.users[sPost.User]
 		case sPost.Type == "message" && sPost.SubType == "file_comment":
-			if sPost.Comment["user"] == "" {
+			if sPost.Comment == nil || sPost.Comment.User == "" {
 				l4g.Debug(utils.T("api.slackimport.slack_add_posts.msg_no_usr.debug"))
+				slackSkipPost(sPost, utils.T("api.slackimport.slack_add_posts.msg_no_usr.debug"), report)
 				continue
-			} else if users[sPost.Comment["user"]] == nil {
-				l4g.Debug(utils.T("api.slackimport.slack_add_posts.user_no_exists.debug"), sPost.User)
-				continue
-			}
-			newPost := model.Post{
-				UserId:    users[sPost.Comment["user"]].Id,
-				ChannelId: channel.Id,
-				Message:   sPost.Comment["comment"],
-				CreateAt:  SlackConvertTimeStamp(sPost.TimeStamp),
 			}
-			ImportPost(&newPost)
+			user = maps.users[sPost.Comment.User]
+			message = sPost.Comment.Comment
+		case sPost.Type == "message" && sPost.SubType == "message_changed":
+			slackEditPost(sPost, postIds, maps, report)
+			continue
 		case sPost.Type == "message" && sPost.SubType == "bot_message":
 			// In the future this will use the "Action Post" spec to post
 			// a message without using a username. For now we just warn that we don't handle this case
 			l4g.Warn(utils.T("api.slackimport.slack_add_posts.bot.warn"))
+			slackSkipPost(sPost, utils.T("api.slackimport.slack_add_posts.bot.warn"), report)
+			continue
 		default:
 			l4g.Warn(utils.T("api.slackimport.slack_add_posts.unsupported.warn"), sPost.Type, sPost.SubType)
+			slackSkipPost(sPost, fmt.Sprintf(utils.T("api.slackimport.slack_add_posts.unsupported.warn"), sPost.Type, sPost.SubType), report)
+			continue
+		}
+
+		if user == nil {
+			l4g.Debug(utils.T("api.slackimport.slack_add_posts.user_no_exists.debug"), sPost.User)
+			slackSkipPost(sPost, fmt.Sprintf(utils.T("api.slackimport.slack_add_posts.user_no_exists.debug"), sPost.User), report)
+			continue
+		}
+
+		newPost := model.Post{
+			UserId:    user.Id,
+			ChannelId: channel.Id,
+			Message:   SlackConvertMarkup(message, maps),
+			CreateAt:  SlackConvertTimeStamp(sPost.TimeStamp),
+		}
+
+		if sPost.ThreadTimeStamp != "" && sPost.ThreadTimeStamp != sPost.TimeStamp {
+			// replies whose thread wasn't imported are kept on their own
+			if rootId := postIds[sPost.ThreadTimeStamp]; rootId != "" {
+				newPost.RootId = rootId
+				newPost.ParentId = rootId
+			}
+		}
+
+		if len(sPost.Attachments) > 0 {
+			newPost.AddProp("attachments", slackConvertAttachments(sPost.Attachments, maps))
+		}
+
+		files := sPost.Files
+		if sPost.File != nil {
+			files = append(files, sPost.File)
+		}
+		for _, sFile := range files {
+			if len(newPost.FileIds) == model.POST_MAX_FILE_IDS {
+				break
+			}
+
+			if info := slackAddFile(teamId, channel, user, sFile, maps, report); info != nil {
+				newPost.FileIds = append(newPost.FileIds, info.Id)
+			}
+		}
+
+		if mPost := ImportPost(&newPost); mPost == nil {
+			report.Add(&model.ImportReportItem{
+				Type:     model.IMPORT_ITEM_POST,
+				SourceId: channel.Name + "/" + sPost.TimeStamp,
+				Result:   model.IMPORT_RESULT_FAILED,
+				Message:  utils.T("api.slackimport.slack_add_posts.failed"),
+			})
+		} else {
+			report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_CREATED)
+			postIds[sPost.TimeStamp] = mPost.Id
+
+			slackAddReactions(mPost, sPost.Reactions, maps, report)
 		}
 	}
 }
 
-func addSlackUsersToChannel(members []string, users map[string]*model.User, channel *model.Channel, log *bytes.Buffer) {
-	for _, member := range members {
-		if user, ok := users[member]; !ok {
-			log.WriteString(utils.T("api.slackimport.slack_add_channels.failed_to_add_user", map[string]interface{}{"Username": "?"}))
-		} else {
-			if _, err := AddUserToChannel(user, channel); err != nil {
-				log.WriteString(utils.T("api.slackimport.slack_add_channels.failed_to_add_user", map[string]interface{}{"Username": user.Username}))
+// slackEditPost applies a message_changed event to the post that it edits. Messages exported with an edited field
+// already have their latest text so only these separate events need to be applied.
+func slackEditPost(sPost SlackPost, postIds map[string]string, maps *slackImportMaps, report *model.ImportReport) {
+	if sPost.Message == nil {
+		slackSkipPost(sPost, utils.T("api.slackimport.slack_add_posts.edit_missing"), report)
+		return
+	}
+
+	postId := postIds[sPost.Message.TimeStamp]
+	if postId == "" {
+		slackSkipPost(sPost, utils.T("api.slackimport.slack_add_posts.edit_missing"), report)
+		return
+	}
+
+	var oldPost *model.Post
+	if result := <-Srv.Store.Post().Get(postId); result.Err != nil {
+		slackSkipPost(sPost, utils.T("api.slackimport.slack_add_posts.edit_missing"), report)
+		return
+	} else {
+		oldPost = result.Data.(*model.PostList).Posts[postId]
+	}
+
+	message := SlackConvertMarkup(sPost.Message.Text, maps)
+	if message == oldPost.Message {
+		// Slack also sends these when a link preview is added to a message
+		report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_SKIPPED)
+		return
+	}
+
+	hashtags, _ := model.ParseHashtags(message)
+
+	if result := <-Srv.Store.Post().Update(oldPost, message, hashtags); result.Err != nil {
+		l4g.Debug(utils.T("api.slackimport.slack_add_posts.edit.debug"), postId, result.Err)
+		report.Add(&model.ImportReportItem{
+			Type:     model.IMPORT_ITEM_POST,
+			SourceId: sPost.TimeStamp,
+			Result:   model.IMPORT_RESULT_FAILED,
+			Message:  utils.T("api.slackimport.slack_add_posts.failed"),
+		})
+	} else {
+		report.Count(model.IMPORT_ITEM_POST, model.IMPORT_RESULT_UPDATED)
+		indexPostForSearch(result.Data.(*model.Post))
+	}
+}
+
+func slackSkipPost(sPost SlackPost, message string, report *model.ImportReport) {
+	report.Add(&model.ImportReportItem{
+		Type:     model.IMPORT_ITEM_POST,
+		SourceId: sPost.TimeStamp,
+		Result:   model.IMPORT_RESULT_SKIPPED,
+		Message:  message,
+	})
+}
+
+// slackConvertAttachments converts the markup in the text of message attachments from integrations
+func slackConvertAttachments(attachments []map[string]interface{}, maps *slackImportMaps) []interface{} {
+	converted := make([]interface{}, 0, len(attachments))
+
+	for _, attachment := range attachments {
+		for _, key := range []string{"text", "pretext", "fallback"} {
+			if text, ok := attachment[key].(string); ok {
+				attachment[key] = SlackConvertMarkup(text, maps)
 			}
 		}
+
+		converted = append(converted, attachment)
 	}
+
+	return converted
 }
 
-func SlackAddChannels(teamId string, slackchannels []SlackChannel, posts map[string][]SlackPost, users map[string]*model.User, log *bytes.Buffer) map[string]*model.Channel {
-	// Write Header
-	log.WriteString(utils.T("api.slackimport.slack_add_channels.added"))
-	log.WriteString("=================\r\n\r\n")
+func slackAddReactions(post *model.Post, reactions []SlackReaction, maps *slackImportMaps, report *model.ImportReport) {
+	for _, sReaction := range reactions {
+		// skin tones are stored as part of the name, like thumbsup::skin-tone-2
+		emojiName := strings.SplitN(sReaction.Name, "::", 2)[0]
 
-	addedChannels := make(map[string]*model.Channel)
-	for _, sChannel := range slackchannels {
-		newChannel := model.Channel{
-			TeamId:      teamId,
-			Type:        model.CHANNEL_OPEN,
-			DisplayName: sChannel.Name,
-			Name:        SlackConvertChannelName(sChannel.Name),
-			Purpose:     sChannel.Topic["value"],
-		}
-		mChannel := ImportChannel(&newChannel)
-		if mChannel == nil {
-			// Maybe it already exists?
-			if result := <-Srv.Store.Channel().GetByName(teamId, sChannel.Name); result.Err != nil {
-				l4g.Debug(utils.T("api.slackimport.slack_add_channels.import_failed.debug"), newChannel.DisplayName)
-				log.WriteString(utils.T("api.slackimport.slack_add_channels.import_failed", map[string]interface{}{"DisplayName": newChannel.DisplayName}))
+		for _, userId := range sReaction.Users {
+			user := maps.users[userId]
+			if user == nil {
+				report.Count(model.IMPORT_ITEM_REACTION, model.IMPORT_RESULT_SKIPPED)
 				continue
+			}
+
+			reaction := &model.Reaction{UserId: user.Id, PostId: post.Id, EmojiName: emojiName, CreateAt: post.CreateAt}
+			if result := <-Srv.Store.Reaction().Save(reaction); result.Err != nil {
+				l4g.Debug(utils.T("api.slackimport.slack_add_posts.reaction.debug"), post.Id, result.Err)
+				report.Count(model.IMPORT_ITEM_REACTION, model.IMPORT_RESULT_FAILED)
 			} else {
-				mChannel = result.Data.(*model.Channel)
-				log.WriteString(utils.T("api.slackimport.slack_add_channels.merge", map[string]interface{}{"DisplayName": newChannel.DisplayName}))
+				report.Count(model.IMPORT_ITEM_REACTION, model.IMPORT_RESULT_CREATED)
 			}
 		}
-		addSlackUsersToChannel(sChannel.Members, users, mChannel, log)
-		log.WriteString(newChannel.DisplayName + "\r\n")
-		addedChannels[sChannel.Id] = mChannel
-		SlackAddPosts(mChannel, posts[sChannel.Name], users)
+	}
+}
+
+// slackAddFile stores a file shared in Slack. Files are read from the __uploads folder of the export when they're
+// included in it and are otherwise downloaded from Slack.
+func slackAddFile(teamId string, channel *model.Channel, user *model.User, sFile *SlackFile, maps *slackImportMaps, report *model.ImportReport) *model.FileInfo {
+	filename := filepath.Base(sFile.Name)
+	if sFile.Name == "" {
+		filename = sFile.Id
 	}
 
-	return addedChannels
+	item := &model.ImportReportItem{Type: model.IMPORT_ITEM_FILE, SourceId: sFile.Id, Name: filename}
+
+	var reader io.ReadCloser
+	if file := maps.uploads[sFile.Id]; file != nil {
+		if r, err := file.Open(); err != nil {
+			item.Result = model.IMPORT_RESULT_FAILED
+			item.Message = err.Error()
+		} else {
+			reader = r
+		}
+	} else if sFile.UrlPrivateDownload != "" {
+		if r, err := slackDownloadFile(sFile.UrlPrivateDownload); err != nil {
+			item.Result = model.IMPORT_RESULT_FAILED
+			item.Message = utils.T("api.slackimport.slack_add_files.download", map[string]interface{}{"Error": err.Error()})
+		} else {
+			reader = r
+		}
+	} else {
+		item.Result = model.IMPORT_RESULT_SKIPPED
+		item.Message = utils.T("api.slackimport.slack_add_files.missing")
+	}
+
+	if reader == nil {
+		report.Add(item)
+		return nil
+	}
+	defer reader.Close()
+
+	info, job, err := uploadFileStream(teamId, channel.Id, user.Id, filename, reader)
+	if err != nil {
+		item.Result = model.IMPORT_RESULT_FAILED
+		item.Message = err.Error()
+		report.Add(item)
+		return nil
+	}
+
+	if job != nil {
+		handleImages([]*imageJob{job})
+	}
+	handleContentExtraction([]*model.FileInfo{info})
+
+	item.Result = model.IMPORT_RESULT_CREATED
+	item.Id = info.Id
+	report.Add(item)
+
+	return info
+}
+
+// slackDownloadFile fetches a file from Slack's file server. Only that server is allowed so that an export can't be
+// used to make the server request arbitrary urls.
+func slackDownloadFile(fileUrl string) (io.ReadCloser, error) {
+	if parsed, err := url.Parse(fileUrl); err != nil {
+		return nil, err
+	} else if err := slackCheckFileUrl(parsed); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Timeout: SLACK_FILE_DL_TIMEOUT,
+		// redirects are checked too since otherwise Slack's file server isn't the only one that can be reached
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+
+			return slackCheckFileUrl(req.URL)
+		},
+	}
+
+	resp, err := client.Get(fileUrl)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %v", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+func slackCheckFileUrl(fileUrl *url.URL) error {
+	if fileUrl.Scheme != "https" || fileUrl.Host != SLACK_FILE_HOST {
+		return fmt.Errorf("files can only be downloaded from https://%v", SLACK_FILE_HOST)
+	}
+
+	return nil
 }
 
-func SlackImport(fileData multipart.File, fileSize int64, teamID string) (*model.AppError, *bytes.Buffer) {
+func SlackImport(fileData multipart.File, fileSize int64, teamID string) (*model.AppError, *model.ImportReport) {
 	zipreader, err := zip.NewReader(fileData, fileSize)
 	if err != nil || zipreader.File == nil {
-		return model.NewLocAppError("SlackImport", "api.slackimport.slack_import.zip.app_error", nil, err.Error()), nil
+		details := ""
+		if err != nil {
+			details = err.Error()
+		}
+		return model.NewLocAppError("SlackImport", "api.slackimport.slack_import.zip.app_error", nil, details), nil
 	}
 
-	// Create log file
-	log := bytes.NewBufferString(utils.T("api.slackimport.slack_import.log"))
+	report := model.NewImportReport()
 
 	var channels []SlackChannel
+	var groups []SlackChannel
+	var dms []SlackChannel
 	var users []SlackUser
 	posts := make(map[string][]SlackPost)
+
+	maps := &slackImportMaps{
+		users:    map[string]*model.User{},
+		created:  map[string]bool{},
+		channels: map[string]*model.Channel{},
+		uploads:  map[string]*zip.File{},
+	}
+
 	for _, file := range zipreader.File {
+		spl := strings.Split(file.Name, "/")
+
+		if len(spl) == 3 && spl[0] == SLACK_UPLOADS_FOLDER {
+			// files are included in the export as __uploads/{file_id}/{name}
+			maps.uploads[spl[1]] = file
+			continue
+		}
+
 		reader, err := file.Open()
 		if err != nil {
-			return model.NewLocAppError("SlackImport", "api.slackimport.slack_import.open.app_error", map[string]interface{}{"Filename": file.Name}, err.Error()), log
+			return model.NewLocAppError("SlackImport", "api.slackimport.slack_import.open.app_error", map[string]interface{}{"Filename": file.Name}, err.Error()), report
 		}
+
 		if file.Name == "channels.json" {
 			channels = SlackParseChannels(reader)
+		} else if file.Name == "groups.json" {
+			groups = SlackParseChannels(reader)
+		} else if file.Name == "dms.json" {
+			dms = SlackParseChannels(reader)
 		} else if file.Name == "users.json" {
 			users = SlackParseUsers(reader)
-		} else {
-			spl := strings.Split(file.Name, "/")
-			if len(spl) == 2 && strings.HasSuffix(spl[1], ".json") {
-				newposts := SlackParsePosts(reader)
-				channel := spl[0]
-				if _, ok := posts[channel]; ok == false {
-					posts[channel] = newposts
-				} else {
-					posts[channel] = append(posts[channel], newposts...)
-				}
+		} else if len(spl) == 2 && strings.HasSuffix(spl[1], ".json") {
+			// messages are stored in a folder named after the channel, or after the id for direct messages
+			posts[spl[0]] = append(posts[spl[0]], SlackParsePosts(reader)...)
+		}
+
+		reader.Close()
+	}
+
+	SlackAddUsers(teamID, users, maps, report)
+
+	// every channel is created before any posts so that links between channels can be converted
+	SlackAddChannels(teamID, model.CHANNEL_OPEN, channels, maps, report)
+	SlackAddChannels(teamID, model.CHANNEL_PRIVATE, groups, maps, report)
+	SlackAddDirectChannels(dms, maps, report)
+
+	if len(maps.uploads) > 0 && len(utils.Cfg.FileSettings.DriverName) == 0 {
+		report.Notes = append(report.Notes, utils.T("api.slackimport.slack_import.storage"))
+	}
+
+	for _, sChannels := range [][]SlackChannel{channels, groups} {
+		for _, sChannel := range sChannels {
+			if channel := maps.channels[sChannel.Id]; channel != nil {
+				SlackAddPosts(teamID, channel, posts[sChannel.Name], maps, report)
 			}
+		}
+	}
 
+	for _, sChannel := range dms {
+		if channel := maps.channels[sChannel.Id]; channel != nil {
+			SlackAddPosts(teamID, channel, posts[sChannel.Id], maps, report)
 		}
 	}
 
-	addedUsers := SlackAddUsers(teamID, users, log)
-	SlackAddChannels(teamID, channels, posts, addedUsers, log)
+	report.Notes = append(report.Notes, utils.T("api.slackimport.slack_import.note1"), utils.T("api.slackimport.slack_import.note2"))
 
-	log.WriteString(utils.T("api.slackimport.slack_import.notes"))
-	log.WriteString("=======\r\n\r\n")
+	return nil, report
+}
 
-	log.WriteString(utils.T("api.slackimport.slack_import.note1"))
-	log.WriteString(utils.T("api.slackimport.slack_import.note2"))
+type slackPostsByTimeStamp []SlackPost
 
-	return nil, log
+func (p slackPostsByTimeStamp) Len() int      { return len(p) }
+func (p slackPostsByTimeStamp) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p slackPostsByTimeStamp) Less(i, j int) bool {
+	a, _ := strconv.ParseFloat(p[i].TimeStamp, 64)
+	b, _ := strconv.ParseFloat(p[j].TimeStamp, 64)
+	return a < b
 }
@@ -0,0 +1,193 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestSlackConvertMarkup(t *testing.T) {
+	maps := &slackImportMaps{
+		users:    map[string]*model.User{"U1": {Username: "alice"}},
+		channels: map[string]*model.Channel{"C1": {Name: "town-square"}},
+	}
+
+	tests := map[string]string{
+		"hi <@U1>":                               "hi @alice",
+		"hi <@U2|bob>":                           "hi @bob",
+		"see <#C1>":                              "see ~town-square",
+		"see <#C2|random>":                       "see ~random",
+		"<!channel> <!here> <!everyone>":         "@channel @here @all",
+		"<https://example.com>":                  "https://example.com",
+		"<https://example.com|example>":          "[example](https://example.com)",
+		"1 &lt; 2 &amp;&amp; 3 &gt; 2":           "1 < 2 && 3 > 2",
+		"<mailto:a@example.com|a@example.com> x": "[a@example.com](mailto:a@example.com) x",
+	}
+
+	for text, expected := range tests {
+		if converted := SlackConvertMarkup(text, maps); converted != expected {
+			t.Fatalf("converted %q to %q instead of %q", text, converted, expected)
+		}
+	}
+}
+
+func TestSlackParsePosts(t *testing.T) {
+	posts := SlackParsePosts(strings.NewReader(`[
+		{"type": "message", "user": "U1", "text": "reply", "ts": "1475000010.000002", "thread_ts": "1475000000.000001"},
+		{"type": "message", "subtype": "file_share", "user": "U1", "ts": "1475000000.000001",
+			"file": {"id": "F1", "name": "notes.txt"}, "reactions": [{"name": "+1::skin-tone-2", "users": ["U1"], "count": 1}]}
+	]`))
+
+	if len(posts) != 2 {
+		t.Fatal("should've parsed both posts")
+	}
+
+	sortedPosts := append([]SlackPost{}, posts...)
+	sort.Stable(slackPostsByTimeStamp(sortedPosts))
+	if sortedPosts[0].TimeStamp != "1475000000.000001" {
+		t.Fatal("posts should be sorted oldest first")
+	}
+
+	if posts[0].ThreadTimeStamp != "1475000000.000001" {
+		t.Fatal("should've read the thread timestamp")
+	} else if posts[1].File == nil || posts[1].File.Id != "F1" {
+		t.Fatal("should've read the shared file")
+	} else if len(posts[1].Reactions) != 1 || posts[1].Reactions[0].Users[0] != "U1" {
+		t.Fatal("should've read the reactions")
+	}
+}
+
+func TestSlackImport(t *testing.T) {
+	th := Setup().InitBasic()
+
+	user := th.BasicUser
+	channelName := "slack-" + model.NewId()[:10]
+
+	file, err := ioutil.TempFile("", "slack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for name, contents := range map[string]string{
+		"users.json": `[
+			{"id": "U1", "name": "` + user.Username + `", "profile": {"email": "` + user.Email + `"}},
+			{"id": "U2", "name": "slack` + model.NewId()[:10] + `", "profile": {"email": "success+` + model.NewId() + `@simulator.amazonses.com"}},
+			{"id": "U3", "name": "slack` + model.NewId()[:10] + `", "profile": {"email": "success+` + model.NewId() + `@simulator.amazonses.com"}}
+		]`,
+		"channels.json": `[{"id": "C1", "name": "` + channelName + `", "members": ["U1", "U2"], "purpose": {"value": "testing", "last_set": 0}}]`,
+		"groups.json":   `[{"id": "G1", "name": "` + channelName + `-private", "members": ["U1", "U2"]}, {"id": "G2", "name": "` + channelName + `", "members": ["U2"]}]`,
+		"dms.json":      `[{"id": "D1", "members": ["U1", "U2"]}, {"id": "D2", "members": ["U2", "U3"]}]`,
+		channelName + "/2016-10-01.json": `[
+			{"type": "message", "user": "U1", "text": "hello <@U2>", "ts": "1475000000.000001",
+				"reactions": [{"name": "smile", "users": ["U1", "U2"], "count": 2}]},
+			{"type": "message", "user": "U2", "text": "reply", "ts": "1475000010.000002", "thread_ts": "1475000000.000001"},
+			{"type": "message", "subtype": "bot_message", "text": "beep", "ts": "1475000020.000003"},
+			{"type": "message", "subtype": "file_share", "user": "U2", "text": "notes", "ts": "1475000030.000004",
+				"file": {"id": "F1", "name": "notes.txt"}},
+			{"type": "message", "subtype": "message_changed", "ts": "1475000040.000005",
+				"message": {"type": "message", "user": "U2", "text": "reply (edited)", "ts": "1475000010.000002"}}
+		]`,
+		"__uploads/F1/notes.txt":                 "some notes",
+		channelName + "-private/2016-10-01.json": `[{"type": "message", "user": "U2", "text": "private", "ts": "1475000000.000001"}]`,
+		"D1/2016-10-01.json":                     `[{"type": "message", "user": "U2", "text": "forged", "ts": "1475000000.000001"}]`,
+		"D2/2016-10-01.json":                     `[{"type": "message", "user": "U2", "text": "direct", "ts": "1475000000.000001"}]`,
+	} {
+		writer, _ := archive.Create(name)
+		writer.Write([]byte(contents))
+	}
+	archive.Close()
+
+	info, _ := file.Stat()
+	appErr, report := SlackImport(file, info.Size(), th.BasicTeam.Id)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if report.Counts[model.IMPORT_ITEM_USER][model.IMPORT_RESULT_MERGED] != 1 {
+		t.Fatal("existing user should've been merged", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_USER][model.IMPORT_RESULT_CREATED] != 2 {
+		t.Fatal("new users should've been created", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_CHANNEL][model.IMPORT_RESULT_CREATED] != 4 {
+		t.Fatal("should've created the public, private and direct channels", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_CHANNEL][model.IMPORT_RESULT_MERGED] != 0 {
+		t.Fatal("shouldn't have merged a private group into a public channel", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_CHANNEL][model.IMPORT_RESULT_SKIPPED] != 1 {
+		t.Fatal("shouldn't have imported direct messages with an existing user", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_POST][model.IMPORT_RESULT_CREATED] != 5 {
+		t.Fatal("should've imported the posts", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_POST][model.IMPORT_RESULT_SKIPPED] != 1 {
+		t.Fatal("should've skipped the bot post", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_POST][model.IMPORT_RESULT_UPDATED] != 1 {
+		t.Fatal("should've applied the edit", report.ToJson())
+	} else if report.Counts[model.IMPORT_ITEM_REACTION][model.IMPORT_RESULT_CREATED] != 2 {
+		t.Fatal("should've imported the reactions", report.ToJson())
+	}
+
+	var channel *model.Channel
+	if result := <-Srv.Store.Channel().GetByName(th.BasicTeam.Id, channelName); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		channel = result.Data.(*model.Channel)
+	}
+
+	if channel.Purpose != "testing" {
+		t.Fatal("should've imported the channel's purpose")
+	}
+
+	if result := <-Srv.Store.Channel().GetByName(th.BasicTeam.Id, channelName+"-private"); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.Channel).Type != model.CHANNEL_PRIVATE {
+		t.Fatal("group should've been imported as a private channel")
+	}
+
+	var postList *model.PostList
+	if result := <-Srv.Store.Post().GetPosts(channel.Id, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		postList = result.Data.(*model.PostList)
+	}
+
+	var root, reply *model.Post
+	for _, post := range postList.Posts {
+		if post.Message == "reply (edited)" {
+			reply = post
+		} else if strings.HasPrefix(post.Message, "hello") {
+			root = post
+		}
+	}
+
+	if root == nil || reply == nil {
+		t.Fatal("should've imported both posts")
+	} else if !strings.HasPrefix(root.Message, "hello @slack") {
+		t.Fatal("should've converted the mention", root.Message)
+	} else if reply.RootId != root.Id || reply.ParentId != root.Id {
+		t.Fatal("reply should've been imported into the thread")
+	}
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Log("skipping file import because no file driver is enabled")
+		return
+	}
+
+	if report.Counts[model.IMPORT_ITEM_FILE][model.IMPORT_RESULT_CREATED] != 1 {
+		t.Fatal("should've imported the file from the export", report.ToJson())
+	}
+
+	for _, post := range postList.Posts {
+		if post.Message == "notes" && len(post.FileIds) != 1 {
+			t.Fatal("file should've been attached to the post")
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// storageReservation is space set aside for an upload that's still being written so that several uploads happening
+// at once, possibly on different servers, can't each fit under a quota on their own but go over it together
+type storageReservation struct {
+	id string
+
+	// Size is the largest file that can be written using the reservation
+	Size int64
+
+	// Err is the quota error to return if the file turns out to be larger than Size, or nil if Size isn't limited by
+	// a quota
+	Err *model.AppError
+}
+
+func getStorageUsage(c *Context, w http.ResponseWriter, r *http.Request) {
+	if usage, err := loadStorageUsage(c.Session.UserId, c.TeamId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.Write([]byte(usage.ToJson()))
+	}
+}
+
+// loadStorageUsage works out how much a user has uploaded in total and how much has been uploaded to a team
+func loadStorageUsage(userId string, teamId string) (*model.StorageUsage, *model.AppError) {
+	usage := &model.StorageUsage{
+		UserQuota: *utils.Cfg.FileSettings.MaxStoragePerUser,
+		TeamQuota: *utils.Cfg.FileSettings.MaxStoragePerTeam,
+	}
+
+	uchan := Srv.Store.FileInfo().GetStorageUsedByUser(userId)
+	tchan := Srv.Store.FileInfo().AnalyticsStorageUsed(teamId)
+
+	if result := <-uchan; result.Err != nil {
+		return nil, result.Err
+	} else {
+		usage.UserUsed = result.Data.(int64)
+	}
+
+	if result := <-tchan; result.Err != nil {
+		return nil, result.Err
+	} else {
+		usage.TeamUsed = result.Data.(int64)
+	}
+
+	return usage, nil
+}
+
+func storageQuotasEnabled() bool {
+	return *utils.Cfg.FileSettings.MaxStoragePerUser > 0 || *utils.Cfg.FileSettings.MaxStoragePerTeam > 0
+}
+
+// loadStorageRemaining returns how many more bytes the user and team can store once the space reserved for uploads
+// in progress is taken into account, or -1 for either of them if they don't have a quota
+func loadStorageRemaining(userId string, teamId string) (int64, int64, *model.StorageUsage, *model.AppError) {
+	rchan := Srv.Store.StorageReservation().GetReserved(userId, teamId, model.GetMillis()-model.STORAGE_RESERVATION_EXPIRY)
+
+	usage, err := loadStorageUsage(userId, teamId)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	var reserved []int64
+	if result := <-rchan; result.Err != nil {
+		return 0, 0, nil, result.Err
+	} else {
+		reserved = result.Data.([]int64)
+	}
+
+	userRemaining := int64(-1)
+	if usage.UserQuota > 0 {
+		userRemaining = usage.UserQuota - usage.UserUsed - reserved[0]
+	}
+
+	teamRemaining := int64(-1)
+	if usage.TeamQuota > 0 {
+		teamRemaining = usage.TeamQuota - usage.TeamUsed - reserved[1]
+	}
+
+	return userRemaining, teamRemaining, usage, nil
+}
+
+func newStorageQuotaError(id string, details string) *model.AppError {
+	err := model.NewLocAppError("checkStorageQuota", id, nil, details)
+	err.StatusCode = http.StatusRequestEntityTooLarge
+	return err
+}
+
+// checkStorageQuota returns an error if storing another size bytes would put the user or team over their quota
+func checkStorageQuota(userId string, teamId string, size int64) *model.AppError {
+	if !storageQuotasEnabled() {
+		return nil
+	}
+
+	userRemaining, teamRemaining, usage, err := loadStorageRemaining(userId, teamId)
+	if err != nil {
+		return err
+	}
+
+	if userRemaining >= 0 && size > userRemaining {
+		return newStorageQuotaError("api.file.storage_quota.user.app_error", fmt.Sprintf("user_id=%v, used=%v, size=%v, quota=%v", userId, usage.UserUsed, size, usage.UserQuota))
+	} else if teamRemaining >= 0 && size > teamRemaining {
+		return newStorageQuotaError("api.file.storage_quota.team.app_error", fmt.Sprintf("team_id=%v, used=%v, size=%v, quota=%v", teamId, usage.TeamUsed, size, usage.TeamQuota))
+	}
+
+	return nil
+}
+
+// reserveStorageQuota sets aside space for a file of up to size bytes before it's written. The reservation is smaller
+// than size if the user or team is close to their quota, and an error is returned if there's no space left at all.
+// It must be released once the file's FileInfo has been saved or the upload has failed.
+//
+// The reservation is saved before the quota is checked again so that of two uploads racing each other, the one that
+// checks last always sees the other's reservation. At worst both fail, but they can never both go over the quota.
+func reserveStorageQuota(userId string, teamId string, size int64) (*storageReservation, *model.AppError) {
+	reservation := &storageReservation{Size: size}
+
+	if !storageQuotasEnabled() {
+		return reservation, nil
+	}
+
+	userRemaining, teamRemaining, usage, err := loadStorageRemaining(userId, teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	if userRemaining >= 0 && userRemaining < reservation.Size {
+		reservation.Size = userRemaining
+		reservation.Err = newStorageQuotaError("api.file.storage_quota.user.app_error", fmt.Sprintf("user_id=%v, used=%v, quota=%v", userId, usage.UserUsed, usage.UserQuota))
+	}
+
+	if teamRemaining >= 0 && teamRemaining < reservation.Size {
+		reservation.Size = teamRemaining
+		reservation.Err = newStorageQuotaError("api.file.storage_quota.team.app_error", fmt.Sprintf("team_id=%v, used=%v, quota=%v", teamId, usage.TeamUsed, usage.TeamQuota))
+	}
+
+	if reservation.Size <= 0 && reservation.Err != nil {
+		return nil, reservation.Err
+	}
+
+	if result := <-Srv.Store.StorageReservation().Save(&model.StorageReservation{UserId: userId, TeamId: teamId, Size: reservation.Size}); result.Err != nil {
+		return nil, result.Err
+	} else {
+		reservation.id = result.Data.(*model.StorageReservation).Id
+	}
+
+	// the reservation is counted now so there's only space left if nothing else was reserved in the meantime
+	userRemaining, teamRemaining, usage, err = loadStorageRemaining(userId, teamId)
+	if err != nil {
+		reservation.Release()
+		return nil, err
+	}
+
+	if userRemaining < 0 && usage.UserQuota > 0 {
+		reservation.Release()
+		return nil, newStorageQuotaError("api.file.storage_quota.user.app_error", fmt.Sprintf("user_id=%v, used=%v, quota=%v", userId, usage.UserUsed, usage.UserQuota))
+	} else if teamRemaining < 0 && usage.TeamQuota > 0 {
+		reservation.Release()
+		return nil, newStorageQuotaError("api.file.storage_quota.team.app_error", fmt.Sprintf("team_id=%v, used=%v, quota=%v", teamId, usage.TeamUsed, usage.TeamQuota))
+	}
+
+	return reservation, nil
+}
+
+func (reservation *storageReservation) Release() {
+	if len(reservation.id) == 0 {
+		return
+	}
+
+	if result := <-Srv.Store.StorageReservation().Delete(reservation.id); result.Err != nil {
+		// the reservation stops counting once it expires anyway
+		l4g.Error(utils.T("api.file.storage_quota.release.error"), reservation.id, result.Err)
+	}
+
+	reservation.id = ""
+}
+
+// cleanupExpiredStorageReservations deletes the reservations left behind by uploads that were interrupted before they
+// could release them
+func cleanupExpiredStorageReservations() {
+	if result := <-Srv.Store.StorageReservation().PermanentDeleteBefore(model.GetMillis() - model.STORAGE_RESERVATION_EXPIRY); result.Err != nil {
+		l4g.Error(utils.T("api.file.storage_quota.cleanup.error"), result.Err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// runLeasedTask runs a background task that only one server in a cluster should run at a time. Nothing is done if
+// another server is running the task or it has already been run since ranBefore, and the result is whether the task
+// was run by this server. The lease is taken over by another server if this one stops sending heartbeats the same
+// way as an export job is.
+func runLeasedTask(name string, ranBefore int64, task func()) (bool, *model.AppError) {
+	staleBefore := model.GetMillis() - int64(EXPORT_JOB_HEARTBEAT_TIMEOUT/time.Millisecond)
+	if result := <-Srv.Store.TaskLease().Claim(name, exportWorkerId, staleBefore, ranBefore); result.Err != nil {
+		return false, result.Err
+	} else if !result.Data.(bool) {
+		return false, nil
+	}
+
+	stopHeartbeat := startTaskLeaseHeartbeat(name)
+	defer func() {
+		close(stopHeartbeat)
+
+		if result := <-Srv.Store.TaskLease().Release(name, exportWorkerId); result.Err != nil {
+			l4g.Error(utils.T("api.task_lease.release.error"), name, result.Err)
+		}
+	}()
+
+	task()
+
+	return true, nil
+}
+
+// startTaskLeaseHeartbeat keeps the lease's heartbeat up to date until the returned channel is closed
+func startTaskLeaseHeartbeat(name string) chan bool {
+	stop := make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(EXPORT_JOB_HEARTBEAT_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if result := <-Srv.Store.TaskLease().Heartbeat(name, exportWorkerId); result.Err != nil {
+					l4g.Error(utils.T("api.task_lease.heartbeat.error"), name, result.Err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop
+}
@@ -711,6 +711,19 @@ func importTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 	importFromArray, ok := r.MultipartForm.Value["importFrom"]
 	importFrom := importFromArray[0]
 
+	// bulk imports can create teams and set anyone's roles, while hipchat and mattermost imports merge with and post
+	// as existing users from anywhere on the server
+	if (importFrom == "bulk" || importFrom == "hipchat" || importFrom == "mattermost") && !c.IsSystemAdmin() {
+		c.Err = model.NewLocAppError("importTeam", "api.team.import_team.system_admin.app_error", nil, "userId="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	dryRun := false
+	if dryRunArray, ok := r.MultipartForm.Value["dryRun"]; ok && len(dryRunArray) > 0 {
+		dryRun = dryRunArray[0] == "true"
+	}
+
 	fileSizeStr, ok := r.MultipartForm.Value["filesize"]
 	if !ok {
 		c.Err = model.NewLocAppError("importTeam", "api.team.import_team.unavailable.app_error", nil, "")
@@ -748,19 +761,50 @@ func importTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var log *bytes.Buffer
 	switch importFrom {
 	case "slack":
+		err, report := SlackImport(fileData, fileSize, c.TeamId)
+		if err != nil {
+			c.Err = err
+			c.Err.StatusCode = http.StatusBadRequest
+			return
+		}
+
+		w.Header().Set("Content-Disposition", "attachment; filename=SlackImportReport.json")
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeContent(w, r, "SlackImportReport.json", time.Now(), strings.NewReader(report.ToJson()))
+	case "bulk", "hipchat":
 		var err *model.AppError
-		if err, log = SlackImport(fileData, fileSize, c.TeamId); err != nil {
+		var report *model.ImportReport
+		if importFrom == "bulk" {
+			err, report = BulkImportFromReader(fileData, fileSize, dryRun)
+		} else {
+			err, report = HipChatImport(fileData, fileSize, c.TeamId, dryRun)
+		}
+
+		if err != nil {
 			c.Err = err
 			c.Err.StatusCode = http.StatusBadRequest
+			return
+		}
+
+		w.Header().Set("Content-Disposition", "attachment; filename=ImportReport.json")
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeContent(w, r, "ImportReport.json", time.Now(), strings.NewReader(report.ToJson()))
+	case "mattermost":
+		err, log := ImportFromReader(fileData, fileSize, nil, c.TeamId)
+		if err != nil {
+			c.Err = err
+			c.Err.StatusCode = http.StatusBadRequest
+			return
 		}
-	}
 
-	w.Header().Set("Content-Disposition", "attachment; filename=MattermostImportLog.txt")
-	w.Header().Set("Content-Type", "application/octet-stream")
-	http.ServeContent(w, r, "MattermostImportLog.txt", time.Now(), bytes.NewReader(log.Bytes()))
+		w.Header().Set("Content-Disposition", "attachment; filename=MattermostImportLog.txt")
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "MattermostImportLog.txt", time.Now(), bytes.NewReader(log.Bytes()))
+	default:
+		c.SetInvalidParam("importTeam", "importFrom")
+	}
 }
 
 func getInviteInfo(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,218 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	THREAD_POSTS_PER_PAGE     = 60
+	THREAD_POSTS_MAX_PER_PAGE = 200
+)
+
+// getPostThread returns a page of the thread containing the post, newest first. The first page is returned when
+// no offset and limit are given, and the limit is capped at THREAD_POSTS_MAX_PER_PAGE.
+func getPostThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset := 0
+	limit := THREAD_POSTS_PER_PAGE
+
+	if val, ok := params["offset"]; ok {
+		var err error
+		if offset, err = strconv.Atoi(val); err != nil || offset < 0 {
+			c.SetInvalidParam("getPostThread", "offset")
+			return
+		}
+	}
+
+	if val, ok := params["limit"]; ok {
+		var err error
+		if limit, err = strconv.Atoi(val); err != nil || limit <= 0 {
+			c.SetInvalidParam("getPostThread", "limit")
+			return
+		}
+	}
+
+	if limit > THREAD_POSTS_MAX_PER_PAGE {
+		limit = THREAD_POSTS_MAX_PER_PAGE
+	}
+
+	rootId := getThreadRootId(c, r, "getPostThread")
+	if c.Err != nil {
+		return
+	}
+
+	if result := <-Srv.Store.Post().GetPostThread(rootId, offset, limit); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if list := result.Data.(*model.PostList); HandleEtag(list.Etag(), w, r) {
+		return
+	} else {
+		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
+		w.Write([]byte(list.ToJson()))
+	}
+}
+
+func followThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	setThreadFollowing(c, w, r, true, "followThread")
+}
+
+func unfollowThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	setThreadFollowing(c, w, r, false, "unfollowThread")
+}
+
+func setThreadFollowing(c *Context, w http.ResponseWriter, r *http.Request, following bool, where string) {
+	rootId := getThreadRootId(c, r, where)
+	if c.Err != nil {
+		return
+	}
+
+	member := &model.ThreadMember{PostId: rootId, UserId: c.Session.UserId}
+	if result := <-Srv.Store.Thread().GetMember(rootId, c.Session.UserId); result.Err == nil {
+		member = result.Data.(*model.ThreadMember)
+	} else {
+		// only replies made after the user started following the thread are unread
+		member.LastViewedAt = model.GetMillis()
+	}
+
+	member.Following = following
+
+	if result := <-Srv.Store.Thread().SaveMember(member); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.ThreadMember).ToJson()))
+	}
+}
+
+func viewThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	rootId := getThreadRootId(c, r, "viewThread")
+	if c.Err != nil {
+		return
+	}
+
+	if result := <-Srv.Store.Thread().UpdateLastViewedAt(rootId, c.Session.UserId, model.GetMillis()); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
+
+func getFollowedThreads(c *Context, w http.ResponseWriter, r *http.Request) {
+	if result := <-Srv.Store.Thread().GetFollowedThreads(c.TeamId, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.FollowedThreadsToJson(result.Data.([]*model.FollowedThread))))
+	}
+}
+
+// getThreadRootId checks that the user can see the post from the request and returns the id of the
+// root post of its thread. Errors are set on the context.
+func getThreadRootId(c *Context, r *http.Request, where string) string {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam(where, "channelId")
+		return ""
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam(where, "postId")
+		return ""
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().GetPostsByIds([]string{postId})
+
+	if !c.HasPermissionsToChannel(cchan, where) {
+		return ""
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return ""
+	} else if posts := result.Data.([]*model.Post); len(posts) != 1 || posts[0].ChannelId != channelId {
+		c.Err = model.NewLocAppError(where, "api.thread.post.app_error", nil, "post_id="+postId)
+		c.Err.StatusCode = http.StatusBadRequest
+		return ""
+	} else if len(posts[0].RootId) > 0 {
+		return posts[0].RootId
+	} else {
+		return posts[0].Id
+	}
+}
+
+// updateThreadMembers has the author of a reply follow the thread and read everything up to their reply.
+// The author of the root post starts following the thread on its first reply unless they chose not to.
+func updateThreadMembers(post *model.Post) {
+	if len(post.RootId) == 0 || post.IsSystemMessage() {
+		return
+	}
+
+	member := &model.ThreadMember{PostId: post.RootId, UserId: post.UserId, Following: true, LastViewedAt: post.CreateAt}
+	if result := <-Srv.Store.Thread().SaveMember(member); result.Err != nil {
+		l4g.Error(utils.T("api.thread.update_thread_members.save.error"), post.RootId, post.UserId, result.Err)
+	}
+
+	var root *model.Post
+	if result := <-Srv.Store.Post().GetPostsByIds([]string{post.RootId}); result.Err != nil {
+		l4g.Error(utils.T("api.thread.update_thread_members.root.error"), post.RootId, result.Err)
+		return
+	} else if posts := result.Data.([]*model.Post); len(posts) == 0 {
+		return
+	} else {
+		root = posts[0]
+	}
+
+	if root.UserId == post.UserId {
+		return
+	}
+
+	if result := <-Srv.Store.Thread().GetMember(root.Id, root.UserId); result.Err == nil {
+		return
+	}
+
+	member = &model.ThreadMember{PostId: root.Id, UserId: root.UserId, Following: true, LastViewedAt: root.CreateAt}
+	if result := <-Srv.Store.Thread().SaveMember(member); result.Err != nil {
+		l4g.Error(utils.T("api.thread.update_thread_members.save.error"), root.Id, root.UserId, result.Err)
+	}
+}
+
+// getThreadFollowersToNotify returns the users following the reply's thread who should be notified about it
+// even though they weren't mentioned
+func getThreadFollowersToNotify(post *model.Post, profileMap map[string]*model.User, mentionedUserIds map[string]bool) []string {
+	followerIds := []string{}
+
+	if len(post.RootId) == 0 || post.IsSystemMessage() {
+		return followerIds
+	}
+
+	if result := <-Srv.Store.Thread().GetFollowers(post.RootId); result.Err != nil {
+		l4g.Error(utils.T("api.thread.get_followers.error"), post.RootId, result.Err)
+	} else {
+		for _, id := range result.Data.([]string) {
+			if id == post.UserId || mentionedUserIds[id] {
+				continue
+			}
+
+			if _, ok := profileMap[id]; ok {
+				followerIds = append(followerIds, id)
+			}
+		}
+	}
+
+	return followerIds
+}
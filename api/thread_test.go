@@ -0,0 +1,143 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestGetPostThread(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+	root := th.BasicPost
+
+	time.Sleep(10 * time.Millisecond)
+	reply1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, RootId: root.Id, ParentId: root.Id, Message: "reply1"})).Data.(*model.Post)
+	time.Sleep(10 * time.Millisecond)
+	reply2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, RootId: root.Id, ParentId: reply1.Id, Message: "reply2"})).Data.(*model.Post)
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "unrelated"}))
+
+	r := Client.Must(Client.GetPostThread(channel.Id, reply1.Id, ""))
+	if list := r.Data.(*model.PostList); len(list.Order) != 3 {
+		t.Fatal("should have returned the whole thread")
+	} else if list.Order[0] != reply2.Id || list.Order[2] != root.Id {
+		t.Fatal("thread should be ordered newest first")
+	}
+
+	if cached := Client.Must(Client.GetPostThread(channel.Id, root.Id, r.Etag)); cached.Data.(*model.PostList) != nil {
+		t.Fatal("should have hit the cache")
+	}
+
+	if list := Client.Must(Client.GetPostThreadPage(channel.Id, root.Id, 0, 2, "")).Data.(*model.PostList); len(list.Order) != 2 || list.Order[0] != reply2.Id {
+		t.Fatal("should have returned the first page of the thread", list.Order)
+	} else if _, ok := list.Posts[root.Id]; !ok {
+		t.Fatal("should have included the root post with the page")
+	}
+
+	if list := Client.Must(Client.GetPostThreadPage(channel.Id, root.Id, 2, 2, "")).Data.(*model.PostList); len(list.Order) != 1 || list.Order[0] != root.Id {
+		t.Fatal("should have returned the root post on the last page", list.Order)
+	}
+
+	if _, err := Client.GetPostThread(th.CreateChannel(Client, th.BasicTeam).Id, root.Id, ""); err == nil {
+		t.Fatal("should have failed - post in a different channel")
+	}
+
+	Client.Logout()
+	th.LoginBasic2()
+
+	if _, err := Client.GetPostThread(channel.Id, root.Id, ""); err == nil {
+		t.Fatal("should have failed - not a member of the channel")
+	}
+}
+
+func TestFollowThread(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+	root := th.BasicPost
+
+	if threads, err := Client.GetFollowedThreads(); err != nil {
+		t.Fatal(err)
+	} else if len(threads) != 0 {
+		t.Fatal("shouldn't be following any threads")
+	}
+
+	if member, err := Client.FollowThread(channel.Id, root.Id); err != nil {
+		t.Fatal(err)
+	} else if !member.Following || member.PostId != root.Id || member.UserId != th.BasicUser.Id {
+		t.Fatal("should be following the thread")
+	}
+
+	Client.Logout()
+	th.LoginBasic2()
+	Client.Must(Client.JoinChannel(channel.Id))
+
+	reply := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, RootId: root.Id, ParentId: root.Id, Message: "reply"})).Data.(*model.Post)
+
+	// replying follows the thread in the background
+	time.Sleep(time.Second)
+
+	if threads, err := Client.GetFollowedThreads(); err != nil {
+		t.Fatal(err)
+	} else if len(threads) != 1 || threads[0].PostId != root.Id {
+		t.Fatal("replying should have followed the thread")
+	} else if threads[0].UnreadReplies != 0 {
+		t.Fatal("user's own reply shouldn't be unread")
+	}
+
+	if member, err := Client.UnfollowThread(channel.Id, reply.Id); err != nil {
+		t.Fatal(err)
+	} else if member.Following || member.PostId != root.Id {
+		t.Fatal("should have stopped following the thread")
+	}
+
+	if threads, err := Client.GetFollowedThreads(); err != nil {
+		t.Fatal(err)
+	} else if len(threads) != 0 {
+		t.Fatal("shouldn't be following the thread")
+	}
+
+	Client.Logout()
+	th.LoginBasic()
+
+	if threads, err := Client.GetFollowedThreads(); err != nil {
+		t.Fatal(err)
+	} else if len(threads) != 1 || threads[0].ReplyCount != 1 || threads[0].UnreadReplies != 1 {
+		t.Fatal("reply should be unread")
+	}
+
+	if ok, err := Client.ViewThread(channel.Id, root.Id); err != nil || !ok {
+		t.Fatal("should have viewed the thread", err)
+	}
+
+	if threads, err := Client.GetFollowedThreads(); err != nil {
+		t.Fatal(err)
+	} else if len(threads) != 1 || threads[0].UnreadReplies != 0 {
+		t.Fatal("reply should have been read")
+	}
+}
+
+func TestThreadRootAuthorFollows(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+	root := th.BasicPost
+
+	Client.Logout()
+	th.LoginBasic2()
+	Client.Must(Client.JoinChannel(channel.Id))
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel.Id, RootId: root.Id, ParentId: root.Id, Message: "reply"}))
+
+	time.Sleep(time.Second)
+
+	if result := <-Srv.Store.Thread().GetMember(root.Id, th.BasicUser.Id); result.Err != nil {
+		t.Fatal("author of the root post should be following the thread")
+	} else if !result.Data.(*model.ThreadMember).Following {
+		t.Fatal("author of the root post should be following the thread")
+	}
+}
@@ -0,0 +1,420 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	UPLOAD_SESSION_CLEANUP_INTERVAL   = 60 * time.Minute
+	UPLOAD_SESSION_CLEANUP_BATCH_SIZE = 100
+)
+
+var uploadSessionCleanupStop chan bool
+
+func InitUploadSession() {
+	l4g.Debug(utils.T("api.upload_session.init.debug"))
+
+	BaseRoutes.Files.Handle("/uploads/create", ApiUserRequired(createUploadSession)).Methods("POST")
+	BaseRoutes.Files.Handle("/uploads/{upload_id:[A-Za-z0-9]+}", ApiUserRequired(getUploadSession)).Methods("GET")
+	BaseRoutes.Files.Handle("/uploads/{upload_id:[A-Za-z0-9]+}", ApiUserRequired(uploadChunk)).Methods("PUT")
+	BaseRoutes.Files.Handle("/uploads/{upload_id:[A-Za-z0-9]+}/finish", ApiUserRequired(finishUploadSession)).Methods("POST")
+}
+
+func createUploadSession(c *Context, w http.ResponseWriter, r *http.Request) {
+	if len(utils.Cfg.FileSettings.DriverName) == 0 {
+		c.Err = model.NewLocAppError("createUploadSession", "api.file.upload_file.storage.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	props := model.UploadSessionFromJson(r.Body)
+	if props == nil {
+		c.SetInvalidParam("createUploadSession", "upload_session")
+		return
+	}
+
+	if len(props.ChannelId) != 26 {
+		c.SetInvalidParam("createUploadSession", "channel_id")
+		return
+	}
+
+	if props.FileSize > *utils.Cfg.FileSettings.MaxFileSize {
+		c.Err = model.NewLocAppError("createUploadSession", "api.file.upload_file.too_large.app_error", nil, "")
+		c.Err.StatusCode = http.StatusRequestEntityTooLarge
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, props.ChannelId, c.Session.UserId)
+	if !c.HasPermissionsToChannel(cchan, "createUploadSession") {
+		return
+	}
+
+	// the quota is checked again once the upload is finished, but there's no point starting one that can't fit
+	if err := checkStorageQuota(c.Session.UserId, c.TeamId, props.FileSize); err != nil {
+		c.Err = err
+		return
+	}
+
+	session := &model.UploadSession{
+		UserId:    c.Session.UserId,
+		TeamId:    c.TeamId,
+		ChannelId: props.ChannelId,
+		Filename:  filepath.Base(props.Filename),
+		FileSize:  props.FileSize,
+	}
+
+	if result := <-Srv.Store.UploadSession().Save(session); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.UploadSession).ToJson()))
+	}
+}
+
+func getUploadSession(c *Context, w http.ResponseWriter, r *http.Request) {
+	if session := getUploadSessionForUser(c, mux.Vars(r)["upload_id"]); session != nil {
+		w.Write([]byte(session.ToJson()))
+	}
+}
+
+// uploadChunk stores the request body as the next part of the file. The offset it's uploaded at must match the
+// session's offset so that a client that lost track of a chunk can get the session and carry on from there.
+func uploadChunk(c *Context, w http.ResponseWriter, r *http.Request) {
+	session := getUploadSessionForUser(c, mux.Vars(r)["upload_id"])
+	if session == nil {
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil {
+		c.SetInvalidParam("uploadChunk", "offset")
+		return
+	}
+
+	if offset != session.FileOffset {
+		c.Err = model.NewLocAppError("uploadChunk", "api.upload_session.upload_chunk.offset.app_error", map[string]interface{}{"Offset": session.FileOffset}, fmt.Sprintf("offset=%v", offset))
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	backend, appErr := GetFileBackend()
+	if appErr != nil {
+		c.Err = appErr
+		return
+	}
+
+	// the chunk is written somewhere of its own until the offset is claimed so that a request that loses the race
+	// for the offset can't overwrite the chunk of the one that won
+	path := getUploadSessionTempPath(session)
+
+	writer, appErr := backend.Writer(path)
+	if appErr != nil {
+		c.Err = appErr
+		return
+	}
+
+	remaining := session.FileSize - session.FileOffset
+	size, copyErr := io.Copy(writer, io.LimitReader(r.Body, remaining+1))
+	closeErr := writer.Close()
+
+	if copyErr != nil || closeErr != nil || size > remaining || size == 0 {
+		backend.Remove(path)
+
+		if size > remaining {
+			c.Err = model.NewLocAppError("uploadChunk", "api.upload_session.upload_chunk.too_large.app_error", nil, "")
+			c.Err.StatusCode = http.StatusRequestEntityTooLarge
+		} else if size == 0 && copyErr == nil && closeErr == nil {
+			c.SetInvalidParam("uploadChunk", "body")
+		} else if copyErr != nil {
+			c.Err = model.NewLocAppError("uploadChunk", "api.upload_session.upload_chunk.write.app_error", nil, copyErr.Error())
+		} else {
+			c.Err = model.NewLocAppError("uploadChunk", "api.upload_session.upload_chunk.write.app_error", nil, closeErr.Error())
+		}
+		return
+	}
+
+	expireAt := model.GetMillis() + model.UPLOAD_SESSION_EXPIRY
+	if result := <-Srv.Store.UploadSession().UpdateOffset(session.Id, offset, offset+size, expireAt); result.Err != nil {
+		backend.Remove(path)
+		c.Err = result.Err
+		return
+	} else if !result.Data.(bool) {
+		// another request uploaded a chunk at the same offset first
+		backend.Remove(path)
+		c.Err = model.NewLocAppError("uploadChunk", "api.upload_session.upload_chunk.offset.app_error", map[string]interface{}{"Offset": offset}, "")
+		c.Err.StatusCode = http.StatusConflict
+		return
+	}
+
+	if err := backend.Move(path, getUploadSessionChunkPath(session, offset)); err != nil {
+		// the offset has already moved on so the upload can't be finished and will have to be started again
+		backend.Remove(path)
+		c.Err = err
+		return
+	}
+
+	session.FileOffset = offset + size
+	session.ExpireAt = expireAt
+
+	w.Write([]byte(session.ToJson()))
+}
+
+// finishUploadSession joins the uploaded chunks together into the file and responds the same way as uploadFile
+func finishUploadSession(c *Context, w http.ResponseWriter, r *http.Request) {
+	session := getUploadSessionForUser(c, mux.Vars(r)["upload_id"])
+	if session == nil {
+		return
+	}
+
+	if !session.IsComplete() {
+		c.Err = model.NewLocAppError("finishUploadSession", "api.upload_session.finish.incomplete.app_error", nil, fmt.Sprintf("offset=%v, size=%v", session.FileOffset, session.FileSize))
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, session.ChannelId, c.Session.UserId)
+	if !c.HasPermissionsToChannel(cchan, "finishUploadSession") {
+		return
+	}
+
+	backend, err := GetFileBackend()
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	chunks, err := getUploadSessionChunks(backend, session)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	reader := &chunkReader{backend: backend, paths: chunks, remaining: session.FileSize}
+	info, job, err := uploadFileStream(session.TeamId, session.ChannelId, session.UserId, session.Filename, reader)
+	reader.Close()
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if job != nil {
+		handleImages([]*imageJob{job})
+	}
+	handleContentExtraction([]*model.FileInfo{info})
+
+	deleteUploadSession(backend, session)
+
+	resStruct := &model.FileUploadResponse{
+		Filenames: []string{"/" + session.ChannelId + "/" + session.UserId + "/" + info.Id + "/" + utils.UrlEncode(info.Filename)},
+		FileInfos: []*model.FileInfo{info},
+		ClientIds: []string{},
+	}
+
+	w.Write([]byte(resStruct.ToJson()))
+}
+
+func getUploadSessionForUser(c *Context, uploadId string) *model.UploadSession {
+	if len(uploadId) != 26 {
+		c.SetInvalidParam("getUploadSession", "upload_id")
+		return nil
+	}
+
+	if result := <-Srv.Store.UploadSession().Get(uploadId); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusNotFound
+		return nil
+	} else if session := result.Data.(*model.UploadSession); session.UserId != c.Session.UserId || session.TeamId != c.TeamId {
+		c.Err = model.NewLocAppError("getUploadSession", "api.upload_session.get.permissions.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return nil
+	} else {
+		return session
+	}
+}
+
+func getUploadSessionDir(session *model.UploadSession) string {
+	return "teams/" + session.TeamId + "/channels/" + session.ChannelId + "/users/" + session.UserId + "/uploads/" + session.Id + "/"
+}
+
+func getUploadSessionChunkPath(session *model.UploadSession, offset int64) string {
+	// the offset is padded so that the chunks are listed in order
+	return getUploadSessionDir(session) + "chunks/" + fmt.Sprintf("%020d", offset)
+}
+
+func getUploadSessionTempPath(session *model.UploadSession) string {
+	return getUploadSessionDir(session) + "tmp/" + model.NewId()
+}
+
+func getUploadSessionChunks(backend FileBackend, session *model.UploadSession) ([]string, *model.AppError) {
+	paths, err := backend.List(getUploadSessionDir(session) + "chunks/")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// deleteUploadSession removes the session's chunks and then the session itself. The session is kept if any of its
+// chunks couldn't be removed so that they aren't left in storage without anything pointing at them, and the cleanup
+// job will try again once it's expired.
+func deleteUploadSession(backend FileBackend, session *model.UploadSession) *model.AppError {
+	// this includes any chunks that were left behind by requests that failed part way through
+	if chunks, err := backend.List(getUploadSessionDir(session)); err != nil {
+		l4g.Error(utils.T("api.upload_session.delete.chunks.error"), session.Id, err)
+		return err
+	} else {
+		for _, path := range chunks {
+			if err := backend.Remove(path); err != nil {
+				l4g.Error(utils.T("api.upload_session.delete.chunks.error"), session.Id, err)
+				return err
+			}
+		}
+	}
+
+	if result := <-Srv.Store.UploadSession().Delete(session.Id); result.Err != nil {
+		l4g.Error(utils.T("api.upload_session.delete.error"), session.Id, result.Err)
+		return result.Err
+	}
+
+	return nil
+}
+
+// chunkReader reads each of the chunks of an upload one after the other without loading them into memory. It fails
+// if the chunks don't add up to the remaining number of bytes.
+type chunkReader struct {
+	backend   FileBackend
+	paths     []string
+	current   io.ReadCloser
+	remaining int64
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.paths) == 0 {
+				if r.remaining != 0 {
+					return 0, fmt.Errorf("upload is %v bytes short of its size", r.remaining)
+				}
+
+				return 0, io.EOF
+			}
+
+			reader, err := r.backend.Reader(r.paths[0])
+			if err != nil {
+				return 0, err
+			}
+
+			r.current = reader
+			r.paths = r.paths[1:]
+		}
+
+		n, err := r.current.Read(p)
+
+		r.remaining -= int64(n)
+		if r.remaining < 0 {
+			return n, fmt.Errorf("upload is %v bytes larger than its size", -r.remaining)
+		}
+
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+
+			if n == 0 {
+				continue
+			}
+
+			err = nil
+		}
+
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+
+	return nil
+}
+
+func StartUploadSessionCleanupJob() {
+	if uploadSessionCleanupStop != nil {
+		return
+	}
+
+	stop := make(chan bool)
+	uploadSessionCleanupStop = stop
+
+	go func() {
+		ticker := time.NewTicker(UPLOAD_SESSION_CLEANUP_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				cleanupExpiredUploadSessions()
+				cleanupExpiredStorageReservations()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func StopUploadSessionCleanupJob() {
+	if uploadSessionCleanupStop != nil {
+		close(uploadSessionCleanupStop)
+		uploadSessionCleanupStop = nil
+	}
+}
+
+// cleanupExpiredUploadSessions deletes the sessions that haven't had a chunk uploaded in a while along with their chunks
+func cleanupExpiredUploadSessions() {
+	if len(utils.Cfg.FileSettings.DriverName) == 0 {
+		return
+	}
+
+	backend, err := GetFileBackend()
+	if err != nil {
+		l4g.Error(utils.T("api.upload_session.cleanup.error"), err)
+		return
+	}
+
+	for {
+		var sessions []*model.UploadSession
+		if result := <-Srv.Store.UploadSession().GetExpired(model.GetMillis(), UPLOAD_SESSION_CLEANUP_BATCH_SIZE); result.Err != nil {
+			l4g.Error(utils.T("api.upload_session.cleanup.error"), result.Err)
+			return
+		} else {
+			sessions = result.Data.([]*model.UploadSession)
+		}
+
+		deleted := 0
+		for _, session := range sessions {
+			if err := deleteUploadSession(backend, session); err == nil {
+				deleted++
+			}
+		}
+
+		// sessions that couldn't be deleted would keep coming back so try them again next time
+		if len(sessions) < UPLOAD_SESSION_CLEANUP_BATCH_SIZE || deleted == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestUploadSession(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Log("skipping because no file driver is enabled")
+		return
+	}
+
+	data := []byte("0123456789abcdefghij")
+
+	if _, err := Client.CreateUploadSession(model.NewId(), "test.txt", int64(len(data))); err == nil {
+		t.Fatal("should've failed to create an upload session for a channel the user isn't in")
+	}
+
+	session, err := Client.CreateUploadSession(channel.Id, "../test.txt", int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	} else if session.Filename != "test.txt" {
+		t.Fatal("relative path should have been sanitized out")
+	} else if session.FileOffset != 0 {
+		t.Fatal("upload should start at the beginning of the file")
+	}
+
+	if _, err := Client.UploadChunk(session.Id, 5, data[5:10]); err == nil {
+		t.Fatal("should've failed to upload a chunk at the wrong offset")
+	}
+
+	if _, err := Client.FinishUploadSession(session.Id); err == nil {
+		t.Fatal("should've failed to finish an incomplete upload")
+	}
+
+	if updated, err := Client.UploadChunk(session.Id, 0, data[:10]); err != nil {
+		t.Fatal(err)
+	} else if updated.FileOffset != 10 {
+		t.Fatal("offset should've moved past the uploaded chunk")
+	}
+
+	if got, err := Client.GetUploadSession(session.Id); err != nil {
+		t.Fatal(err)
+	} else if got.FileOffset != 10 {
+		t.Fatal("offset should've been saved")
+	}
+
+	if _, err := Client.UploadChunk(session.Id, 10, append(data[10:], 'x')); err == nil {
+		t.Fatal("should've failed to upload past the end of the file")
+	}
+
+	if _, err := Client.UploadChunk(session.Id, 10, data[10:]); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := Client.FinishUploadSession(session.Id)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(resp.FileInfos) != 1 {
+		t.Fatal("should've returned the uploaded file")
+	}
+
+	var info *model.FileInfo
+	if result := <-Srv.Store.FileInfo().Get(resp.FileInfos[0].Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		info = result.Data.(*model.FileInfo)
+	}
+
+	if info.Filename != "test.txt" || info.Size != int64(len(data)) {
+		t.Fatal("file info should match the uploaded file")
+	}
+
+	if readData, err := ReadFile(info.Path); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(readData, data) {
+		t.Fatal("uploaded file should match the chunks that were sent")
+	}
+
+	if _, err := Client.GetUploadSession(session.Id); err == nil {
+		t.Fatal("upload session should've been deleted once finished")
+	}
+
+	other := Client.MustGeneric(Client.CreateUploadSession(channel.Id, "test.txt", int64(len(data)))).(*model.UploadSession)
+
+	th.LoginBasic2()
+	if _, err := Client.GetUploadSession(other.Id); err == nil {
+		t.Fatal("shouldn't be able to access another user's upload")
+	}
+}
+
+func TestChunkReader(t *testing.T) {
+	backend := NewMemoryFileBackend()
+	backend.Write([]byte("01234"), "chunks/0")
+	backend.Write([]byte("56789"), "chunks/5")
+
+	reader := &chunkReader{backend: backend, paths: []string{"chunks/0", "chunks/5"}, remaining: 10}
+	if data, err := ioutil.ReadAll(reader); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "0123456789" {
+		t.Fatal("should've read the chunks in order", string(data))
+	}
+
+	reader = &chunkReader{backend: backend, paths: []string{"chunks/0"}, remaining: 10}
+	if _, err := ioutil.ReadAll(reader); err == nil {
+		t.Fatal("should've failed when the chunks are smaller than the upload")
+	}
+
+	reader = &chunkReader{backend: backend, paths: []string{"chunks/0", "chunks/5"}, remaining: 8}
+	if _, err := ioutil.ReadAll(reader); err == nil {
+		t.Fatal("should've failed when the chunks are larger than the upload")
+	}
+}
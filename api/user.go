@@ -65,6 +65,8 @@ func InitUser() {
 	BaseRoutes.Users.Handle("/claim/email_to_oauth", ApiAppHandler(emailToOAuth)).Methods("POST")
 	BaseRoutes.Users.Handle("/claim/oauth_to_email", ApiUserRequired(oauthToEmail)).Methods("POST")
 	BaseRoutes.Users.Handle("/claim/email_to_ldap", ApiAppHandler(emailToLdap)).Methods("POST")
+	BaseRoutes.Users.Handle("/claim/email_to_saml", ApiAppHandler(emailToSaml)).Methods("POST")
+	BaseRoutes.Users.Handle("/claim/saml_to_email", ApiUserRequired(samlToEmail)).Methods("POST")
 	BaseRoutes.Users.Handle("/claim/ldap_to_email", ApiAppHandler(ldapToEmail)).Methods("POST")
 
 	BaseRoutes.NeedUser.Handle("/get", ApiUserRequired(getUser)).Methods("GET")
@@ -93,6 +95,10 @@ func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
 	shouldSendWelcomeEmail := true
 	user.EmailVerified = false
 
+	// bots can only be created by their owners
+	user.IsBot = false
+	user.BotOwnerId = ""
+
 	if len(hash) > 0 {
 		data := r.URL.Query().Get("d")
 		props := model.MapFromJson(strings.NewReader(data))
@@ -269,7 +275,7 @@ func CreateUser(user *model.User) (*model.User, *model.AppError) {
 
 func CreateOAuthUser(c *Context, w http.ResponseWriter, r *http.Request, service string, userData io.Reader, teamId string) *model.User {
 	var user *model.User
-	provider := einterfaces.GetOauthProvider(service)
+	provider := getOauthProvider(service)
 	if provider == nil {
 		c.Err = model.NewLocAppError("CreateOAuthUser", "api.user.create_oauth_user.not_available.app_error", map[string]interface{}{"Service": service}, "")
 		return nil
@@ -282,6 +288,12 @@ func CreateOAuthUser(c *Context, w http.ResponseWriter, r *http.Request, service
 		return nil
 	}
 
+	return createSSOUser(c, w, r, user, service, teamId)
+}
+
+// createSSOUser creates and signs in a user that an SSO service vouched for, as long as nobody has signed up with
+// the same account or email already
+func createSSOUser(c *Context, w http.ResponseWriter, r *http.Request, user *model.User, service string, teamId string) *model.User {
 	suchan := Srv.Store.User().GetByAuth(user.AuthData, service)
 	euchan := Srv.Store.User().GetByEmail(user.Email)
 
@@ -511,7 +523,7 @@ func LoginByOAuth(c *Context, w http.ResponseWriter, r *http.Request, service st
 	buf.ReadFrom(userData)
 
 	authData := ""
-	provider := einterfaces.GetOauthProvider(service)
+	provider := getOauthProvider(service)
 	if provider == nil {
 		c.Err = model.NewLocAppError("LoginByOAuth", "api.user.login_by_oauth.not_available.app_error",
 			map[string]interface{}{"Service": service}, "")
@@ -1564,6 +1576,9 @@ func UpdateActive(c *Context, user *model.User, active bool) *model.User {
 
 		if user.DeleteAt > 0 {
 			RevokeAllSession(c, user.Id)
+
+			// user access tokens aren't stored as sessions so they only need to be dropped from the cache
+			RemoveAllSessionsForUserId(user.Id)
 		}
 
 		if extra := <-Srv.Store.Channel().ExtraUpdateByUser(user.Id, model.GetMillis()); extra.Err != nil {
@@ -1593,6 +1608,10 @@ func PermanentDeleteUser(c *Context, user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.UserAccessToken().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.OAuth().PermanentDeleteAuthDataByUser(user.Id); result.Err != nil {
 		return result.Err
 	}
@@ -1613,6 +1632,14 @@ func PermanentDeleteUser(c *Context, user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.Reaction().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.Thread().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.Channel().PermanentDeleteMembersByUser(user.Id); result.Err != nil {
 		return result.Err
 	}
@@ -2001,7 +2028,7 @@ func emailToOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 	stateProps["email"] = email
 
 	m := map[string]string{}
-	if authUrl, err := GetAuthorizationCode(c, service, stateProps, ""); err != nil {
+	if authUrl, err := GetAuthorizationCode(c, w, r, service, stateProps, ""); err != nil {
 		c.LogAuditWithUserId(user.Id, "fail - oauth issue")
 		c.Err = err
 		return
@@ -2067,6 +2094,122 @@ func oauthToEmail(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.MapToJson(m)))
 }
 
+func emailToSaml(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	password := props["password"]
+	if len(password) == 0 {
+		c.SetInvalidParam("emailToSaml", "password")
+		return
+	}
+
+	email := props["email"]
+	if len(email) == 0 {
+		c.SetInvalidParam("emailToSaml", "email")
+		return
+	}
+
+	samlInterface, err := getSamlInterface("emailToSaml")
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	var user *model.User
+	if result := <-Srv.Store.User().GetByEmail(email); result.Err != nil {
+		c.LogAudit("fail - couldn't get user")
+		c.Err = result.Err
+		return
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	if err := checkPasswordAndAllCriteria(user, password, ""); err != nil {
+		c.LogAuditWithUserId(user.Id, "failed - bad authentication")
+		c.Err = err
+		return
+	}
+
+	relayProps := map[string]string{}
+	relayProps["action"] = model.OAUTH_ACTION_EMAIL_TO_SSO
+	relayProps["email"] = email
+
+	m := map[string]string{}
+	if requestUrl, err := buildSamlRequest(samlInterface, relayProps); err != nil {
+		c.LogAuditWithUserId(user.Id, "fail - saml issue")
+		c.Err = err
+		return
+	} else {
+		m["follow_link"] = requestUrl
+	}
+
+	c.LogAuditWithUserId(user.Id, "success")
+	w.Write([]byte(model.MapToJson(m)))
+}
+
+func samlToEmail(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	password := props["password"]
+	if len(password) == 0 {
+		c.SetInvalidParam("samlToEmail", "password")
+		return
+	}
+
+	email := props["email"]
+	if len(email) == 0 {
+		c.SetInvalidParam("samlToEmail", "email")
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	var user *model.User
+	if result := <-Srv.Store.User().GetByEmail(email); result.Err != nil {
+		c.LogAudit("fail - couldn't get user")
+		c.Err = result.Err
+		return
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	if user.Id != c.Session.UserId {
+		c.LogAudit("fail - user ids didn't match")
+		c.Err = model.NewLocAppError("samlToEmail", "api.user.saml_to_email.context.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	if !user.IsSAMLUser() {
+		c.LogAudit("fail - not a saml user")
+		c.Err = model.NewLocAppError("samlToEmail", "api.user.saml_to_email.not_saml_user.app_error", nil, "")
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	if result := <-Srv.Store.User().UpdatePassword(c.Session.UserId, model.HashPassword(password)); result.Err != nil {
+		c.LogAudit("fail - database issue")
+		c.Err = result.Err
+		return
+	}
+
+	go sendSignInChangeEmail(c, user.Email, c.GetSiteURL(), c.T("api.templates.signin_change_email.body.method_email"))
+
+	RevokeAllSession(c, c.Session.UserId)
+	c.RemoveSessionCookie(w, r)
+	if c.Err != nil {
+		return
+	}
+
+	m := map[string]string{}
+	m["follow_link"] = "/login?extra=signin_change"
+
+	c.LogAudit("success")
+	w.Write([]byte(model.MapToJson(m)))
+}
+
 func emailToLdap(c *Context, w http.ResponseWriter, r *http.Request) {
 	props := model.MapFromJson(r.Body)
 
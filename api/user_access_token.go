@@ -0,0 +1,209 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	// a token's LastUsedAt is only written at most this often so that busy integrations don't write on every request
+	USER_ACCESS_TOKEN_LAST_USED_UPDATE_INTERVAL = 5 * time.Minute
+)
+
+// userAccessTokenLastUsedUpdates holds the ids of the tokens whose LastUsedAt was written recently
+var userAccessTokenLastUsedUpdates *utils.Cache = utils.NewLru(model.SESSION_CACHE_SIZE)
+
+func InitUserAccessToken() {
+	l4g.Debug(utils.T("api.user_access_token.init.debug"))
+
+	BaseRoutes.Users.Handle("/tokens/revoke", ApiUserRequired(revokeUserAccessToken)).Methods("POST")
+	BaseRoutes.NeedUser.Handle("/tokens", ApiUserRequired(getUserAccessTokens)).Methods("GET")
+	BaseRoutes.NeedUser.Handle("/tokens/create", ApiUserRequired(createUserAccessToken)).Methods("POST")
+}
+
+func checkUserAccessTokensEnabled(c *Context, where string) bool {
+	if !*utils.Cfg.ServiceSettings.EnableUserAccessTokens {
+		c.Err = model.NewLocAppError(where, "api.user_access_token.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return false
+	}
+
+	return true
+}
+
+func createUserAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkUserAccessTokensEnabled(c, "createUserAccessToken") {
+		return
+	}
+
+	userId := mux.Vars(r)["user_id"]
+	if !hasPermissionsToUserOrBot(c, userId, "createUserAccessToken") {
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+
+	token := &model.UserAccessToken{
+		UserId:      userId,
+		Description: props["description"],
+	}
+
+	if result := <-Srv.Store.UserAccessToken().Save(token); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		token = result.Data.(*model.UserAccessToken)
+	}
+
+	c.LogAuditWithUserId(userId, "token_id="+token.Id+" description="+token.Description)
+
+	// this is the only time that the token itself is sent back
+	w.Write([]byte(token.ToJson()))
+}
+
+func getUserAccessTokens(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkUserAccessTokensEnabled(c, "getUserAccessTokens") {
+		return
+	}
+
+	userId := mux.Vars(r)["user_id"]
+	if !hasPermissionsToUserOrBot(c, userId, "getUserAccessTokens") {
+		return
+	}
+
+	if result := <-Srv.Store.UserAccessToken().GetByUser(userId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.UserAccessTokenListToJson(result.Data.([]*model.UserAccessToken))))
+	}
+}
+
+func revokeUserAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	tokenId := props["token_id"]
+	if len(tokenId) != 26 {
+		c.SetInvalidParam("revokeUserAccessToken", "token_id")
+		return
+	}
+
+	var token *model.UserAccessToken
+	if result := <-Srv.Store.UserAccessToken().Get(tokenId); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusNotFound
+		return
+	} else {
+		token = result.Data.(*model.UserAccessToken)
+	}
+
+	if !hasPermissionsToUserOrBot(c, token.UserId, "revokeUserAccessToken") {
+		return
+	}
+
+	if result := <-Srv.Store.UserAccessToken().Delete(token.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	RemoveUserAccessTokenFromCache(token.Id)
+
+	c.LogAuditWithUserId(token.UserId, "token_id="+token.Id)
+
+	w.Write([]byte(model.MapToJson(props)))
+}
+
+// GetSessionForUserAccessToken creates a session for a request authenticated with a user access token. The session's
+// id is the token's id so that audits of anything done with the token can be traced back to it.
+func GetSessionForUserAccessToken(token string) *model.Session {
+	if !*utils.Cfg.ServiceSettings.EnableUserAccessTokens {
+		return nil
+	}
+
+	if ts, ok := sessionCache.Get(token); ok {
+		return ts.(*model.Session)
+	}
+
+	var accessToken *model.UserAccessToken
+	if result := <-Srv.Store.UserAccessToken().GetByToken(model.HashUserAccessToken(token)); result.Err != nil {
+		l4g.Error(utils.T("api.user_access_token.invalid_token.error"), result.Err.DetailedError)
+		return nil
+	} else {
+		accessToken = result.Data.(*model.UserAccessToken)
+	}
+
+	tchan := Srv.Store.Team().GetTeamsForUser(accessToken.UserId)
+
+	var user *model.User
+	if result := <-Srv.Store.User().Get(accessToken.UserId); result.Err != nil {
+		l4g.Error(utils.T("api.user_access_token.invalid_token.error"), result.Err.DetailedError)
+		return nil
+	} else if user = result.Data.(*model.User); user.DeleteAt != 0 {
+		return nil
+	}
+
+	session := &model.Session{
+		Id:             accessToken.Id,
+		Token:          token,
+		CreateAt:       accessToken.CreateAt,
+		LastActivityAt: accessToken.LastUsedAt,
+		UserId:         user.Id,
+		Roles:          user.Roles,
+		Props:          model.StringMap{model.SESSION_PROP_USER_ACCESS_TOKEN_ID: accessToken.Id},
+	}
+
+	if result := <-tchan; result.Err != nil {
+		l4g.Error(utils.T("api.user_access_token.invalid_token.error"), result.Err.DetailedError)
+		return nil
+	} else {
+		session.TeamMembers = result.Data.([]*model.TeamMember)
+	}
+
+	AddSessionToCache(session)
+
+	return session
+}
+
+// RemoveUserAccessTokenFromCache makes sure that a revoked token can't be used again on this server or any of the
+// others in the cluster
+func RemoveUserAccessTokenFromCache(tokenId string) {
+	removeUserAccessTokenFromLocalCache(tokenId)
+
+	if clusterI := einterfaces.GetClusterInterface(); clusterI != nil {
+		clusterI.RemoveUserAccessTokenFromCache(tokenId)
+	}
+}
+
+func removeUserAccessTokenFromLocalCache(tokenId string) {
+	for _, key := range sessionCache.Keys() {
+		if ts, ok := sessionCache.Get(key); ok {
+			if session := ts.(*model.Session); session.IsUserAccessToken() && session.Id == tokenId {
+				sessionCache.Remove(key)
+			}
+		}
+	}
+}
+
+// updateUserAccessTokenLastUsedAt records that a token was used in the background unless that was already done
+// recently
+func updateUserAccessTokenLastUsedAt(tokenId string) {
+	if _, ok := userAccessTokenLastUsedUpdates.Get(tokenId); ok {
+		return
+	}
+	userAccessTokenLastUsedUpdates.AddWithExpiresInSecs(tokenId, true, int64(USER_ACCESS_TOKEN_LAST_USED_UPDATE_INTERVAL/time.Second))
+
+	go func() {
+		if result := <-Srv.Store.UserAccessToken().UpdateLastUsedAt(tokenId, model.GetMillis()); result.Err != nil {
+			l4g.Error(utils.T("api.user_access_token.last_used_at.error"), tokenId, result.Err)
+		}
+	}()
+}
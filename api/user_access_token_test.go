@@ -0,0 +1,88 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestUserAccessTokens(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableUserAccessTokens := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enableUserAccessTokens
+	}()
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = false
+	if _, err := Client.CreateUserAccessToken(th.BasicUser.Id, "test"); err == nil {
+		t.Fatal("should've failed when user access tokens are disabled")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+
+	if _, err := Client.CreateUserAccessToken(th.BasicUser2.Id, "test"); err == nil {
+		t.Fatal("shouldn't be able to create a token for another user")
+	}
+
+	if _, err := Client.CreateUserAccessToken(th.BasicUser.Id, ""); err == nil {
+		t.Fatal("should've required a description")
+	}
+
+	token, err := Client.CreateUserAccessToken(th.BasicUser.Id, "deploy script")
+	if err != nil {
+		t.Fatal(err)
+	} else if !model.IsUserAccessToken(token.Token) {
+		t.Fatal("should've returned the token")
+	}
+
+	if tokens, err := Client.GetUserAccessTokens(th.BasicUser.Id); err != nil {
+		t.Fatal(err)
+	} else if len(tokens) != 1 || tokens[0].Id != token.Id || tokens[0].Token != "" {
+		t.Fatal("should've listed the token without including it")
+	}
+
+	TokenClient := th.CreateClient()
+	TokenClient.AuthToken = token.Token
+	TokenClient.AuthType = model.HEADER_BEARER
+
+	if tokens, err := TokenClient.GetUserAccessTokens(th.BasicUser.Id); err != nil {
+		t.Fatal("should've been able to use the token", err)
+	} else if len(tokens) != 1 {
+		t.Fatal("should've authenticated as the user")
+	}
+
+	if _, err := TokenClient.GetUserAccessTokens(th.BasicUser2.Id); err == nil {
+		t.Fatal("the token should only have the user's permissions")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if tokens, _ := Client.GetUserAccessTokens(th.BasicUser.Id); tokens[0].LastUsedAt == 0 {
+		t.Fatal("should've recorded the token being used")
+	}
+
+	if audits := Client.Must(Client.GetAudits(th.BasicUser.Id, "")).Data.(model.Audits); !strings.Contains(audits.ToJson(), token.Id) {
+		t.Fatal("should've audited the token being created")
+	}
+
+	th.LoginBasic2()
+	if _, err := Client.RevokeUserAccessToken(token.Id); err == nil {
+		t.Fatal("shouldn't be able to revoke another user's token")
+	}
+
+	th.LoginBasic()
+	if _, err := Client.RevokeUserAccessToken(token.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := TokenClient.GetUserAccessTokens(th.BasicUser.Id); err == nil {
+		t.Fatal("shouldn't be able to use a revoked token")
+	}
+}
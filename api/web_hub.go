@@ -5,6 +5,7 @@ package api
 
 import (
 	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 )
@@ -19,26 +20,42 @@ type Hub struct {
 	invalidateChannel chan string
 }
 
-var hub = &Hub{
-	register:          make(chan *WebConn),
-	unregister:        make(chan *WebConn),
-	connections:       make(map[*WebConn]bool),
-	broadcast:         make(chan *model.Message),
-	stop:              make(chan string),
-	invalidateUser:    make(chan string),
-	invalidateChannel: make(chan string),
+var hub = NewWebHub()
+
+func NewWebHub() *Hub {
+	return &Hub{
+		register:          make(chan *WebConn),
+		unregister:        make(chan *WebConn),
+		connections:       make(map[*WebConn]bool),
+		broadcast:         make(chan *model.Message),
+		stop:              make(chan string),
+		invalidateUser:    make(chan string),
+		invalidateChannel: make(chan string),
+	}
 }
 
 func Publish(message *model.Message) {
 	hub.Broadcast(message)
+
+	if clusterI := einterfaces.GetClusterInterface(); clusterI != nil {
+		clusterI.Publish(message)
+	}
 }
 
 func InvalidateCacheForUser(userId string) {
-	hub.invalidateUser <- userId
+	hub.InvalidateUser(userId)
+
+	if clusterI := einterfaces.GetClusterInterface(); clusterI != nil {
+		clusterI.InvalidateCacheForUser(userId)
+	}
 }
 
 func InvalidateCacheForChannel(channelId string) {
-	hub.invalidateChannel <- channelId
+	hub.InvalidateChannel(channelId)
+
+	if clusterI := einterfaces.GetClusterInterface(); clusterI != nil {
+		clusterI.InvalidateCacheForChannel(channelId)
+	}
 }
 
 func (h *Hub) Register(webConn *WebConn) {
@@ -55,6 +72,14 @@ func (h *Hub) Broadcast(message *model.Message) {
 	}
 }
 
+func (h *Hub) InvalidateUser(userId string) {
+	h.invalidateUser <- userId
+}
+
+func (h *Hub) InvalidateChannel(channelId string) {
+	h.invalidateChannel <- channelId
+}
+
 func (h *Hub) Stop() {
 	h.stop <- "all"
 }
@@ -26,6 +26,8 @@ func InitWebhook() {
 	BaseRoutes.Hooks.Handle("/outgoing/regen_token", ApiUserRequired(regenOutgoingHookToken)).Methods("POST")
 	BaseRoutes.Hooks.Handle("/outgoing/delete", ApiUserRequired(deleteOutgoingHook)).Methods("POST")
 	BaseRoutes.Hooks.Handle("/outgoing/list", ApiUserRequired(getOutgoingHooks)).Methods("GET")
+	BaseRoutes.Hooks.Handle("/outgoing/{id:[A-Za-z0-9]+}/deliveries", ApiUserRequired(getOutgoingHookDeliveries)).Methods("GET")
+	BaseRoutes.Hooks.Handle("/outgoing/{id:[A-Za-z0-9]+}/deliveries/{delivery_id:[A-Za-z0-9]+}/redeliver", ApiUserRequired(redeliverOutgoingHook)).Methods("POST")
 
 	BaseRoutes.Hooks.Handle("/{id:[A-Za-z0-9]+}", ApiAppHandler(incomingWebhook)).Methods("POST")
 
@@ -64,6 +66,10 @@ func createIncomingHook(c *Context, w http.ResponseWriter, r *http.Request) {
 	hook.UserId = c.Session.UserId
 	hook.TeamId = c.TeamId
 
+	if !checkIntegrationBot(c, hook.BotUserId, "createIncomingHook") {
+		return
+	}
+
 	var channel *model.Channel
 	if result := <-cchan; result.Err != nil {
 		c.Err = result.Err
@@ -185,6 +191,10 @@ func createOutgoingHook(c *Context, w http.ResponseWriter, r *http.Request) {
 	hook.CreatorId = c.Session.UserId
 	hook.TeamId = c.TeamId
 
+	if !checkIntegrationBot(c, hook.BotUserId, "createOutgoingHook") {
+		return
+	}
+
 	if len(hook.ChannelId) != 0 {
 		cchan := Srv.Store.Channel().Get(hook.ChannelId)
 		pchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, hook.ChannelId, c.Session.UserId)
@@ -360,6 +370,108 @@ func regenOutgoingHookToken(c *Context, w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func getOutgoingHookDeliveries(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
+		c.Err = model.NewLocAppError("getOutgoingHookDeliveries", "api.webhook.get_outgoing_deliveries.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	if *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations {
+		if !(c.IsSystemAdmin() || c.IsTeamAdmin()) {
+			c.Err = model.NewLocAppError("getOutgoingHookDeliveries", "api.command.admin_only.app_error", nil, "")
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+	}
+
+	id := mux.Vars(r)["id"]
+
+	if result := <-Srv.Store.Webhook().GetOutgoing(id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if hook := result.Data.(*model.OutgoingWebhook); hook.TeamId != c.TeamId || (c.Session.UserId != hook.CreatorId && !c.IsTeamAdmin()) {
+		c.Err = model.NewLocAppError("getOutgoingHookDeliveries", "api.webhook.get_outgoing_deliveries.permissions.app_error", nil, "user_id="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	if result := <-Srv.Store.Webhook().GetOutgoingDeliveriesByHook(id, 0, OUTGOING_WEBHOOK_DELIVERIES_LIST_SIZE); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		deliveries := result.Data.([]*model.OutgoingWebhookDelivery)
+		for _, delivery := range deliveries {
+			delivery.Sanitize()
+		}
+		w.Write([]byte(model.OutgoingWebhookDeliveryListToJson(deliveries)))
+	}
+}
+
+func redeliverOutgoingHook(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
+		c.Err = model.NewLocAppError("redeliverOutgoingHook", "api.webhook.redeliver_outgoing.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	if *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations {
+		if !(c.IsSystemAdmin() || c.IsTeamAdmin()) {
+			c.Err = model.NewLocAppError("redeliverOutgoingHook", "api.command.admin_only.app_error", nil, "")
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+	}
+
+	c.LogAudit("attempt")
+
+	params := mux.Vars(r)
+	id := params["id"]
+	deliveryId := params["delivery_id"]
+
+	if result := <-Srv.Store.Webhook().GetOutgoing(id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		hook := result.Data.(*model.OutgoingWebhook)
+
+		if c.TeamId != hook.TeamId || (c.Session.UserId != hook.CreatorId && !c.IsTeamAdmin()) {
+			c.LogAudit("fail - inappropriate permissions")
+			c.Err = model.NewLocAppError("redeliverOutgoingHook", "api.webhook.redeliver_outgoing.permissions.app_error", nil, "user_id="+c.Session.UserId)
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+	}
+
+	var delivery *model.OutgoingWebhookDelivery
+	if result := <-Srv.Store.Webhook().GetOutgoingDelivery(deliveryId); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusNotFound
+		return
+	} else {
+		delivery = result.Data.(*model.OutgoingWebhookDelivery)
+	}
+
+	if delivery.HookId != id {
+		c.SetInvalidParam("redeliverOutgoingHook", "delivery_id")
+		return
+	}
+
+	if result := <-Srv.Store.Webhook().SaveOutgoingDelivery(delivery.Redeliver()); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		redelivery := result.Data.(*model.OutgoingWebhookDelivery)
+		go deliverOutgoingWebhook(c, redelivery)
+
+		c.LogAudit("success")
+
+		sanitized := *redelivery
+		sanitized.Sanitize()
+		w.Write([]byte(sanitized.ToJson()))
+	}
+}
+
 func incomingWebhook(c *Context, w http.ResponseWriter, r *http.Request) {
 	if !utils.Cfg.ServiceSettings.EnableIncomingWebhooks {
 		c.Err = model.NewLocAppError("incomingWebhook", "web.incoming_webhook.disabled.app_error", nil, "")
@@ -441,7 +553,7 @@ func incomingWebhook(c *Context, w http.ResponseWriter, r *http.Request) {
 				c.Err = model.NewLocAppError("incomingWebhook", "web.incoming_webhook.user.app_error", nil, "err="+result.Err.Message)
 				return
 			} else {
-				channelName = model.GetDMNameFromIds(result.Data.(*model.User).Id, hook.UserId)
+				channelName = model.GetDMNameFromIds(result.Data.(*model.User).Id, integrationUserId(hook.BotUserId, hook.UserId))
 			}
 		} else if channelName[0] == '#' {
 			channelName = channelName[1:]
@@ -462,12 +574,13 @@ func incomingWebhook(c *Context, w http.ResponseWriter, r *http.Request) {
 		channel = result.Data.(*model.Channel)
 	}
 
-	pchan := Srv.Store.Channel().CheckPermissionsTo(hook.TeamId, channel.Id, hook.UserId)
+	userId := integrationUserId(hook.BotUserId, hook.UserId)
+	pchan := Srv.Store.Channel().CheckPermissionsTo(hook.TeamId, channel.Id, userId)
 
 	// create a mock session
 	c.Session = model.Session{
-		UserId:      hook.UserId,
-		TeamMembers: []*model.TeamMember{{TeamId: hook.TeamId, UserId: hook.UserId}},
+		UserId:      userId,
+		TeamMembers: []*model.TeamMember{{TeamId: hook.TeamId, UserId: userId}},
 		IsOAuth:     false,
 	}
 
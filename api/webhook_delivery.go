@@ -0,0 +1,177 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	OUTGOING_WEBHOOK_DELIVERY_POLL_INTERVAL = 10 * time.Second
+	OUTGOING_WEBHOOK_DELIVERY_TIMEOUT       = 30 * time.Second
+	OUTGOING_WEBHOOK_DELIVERY_STALE_TIME    = 5 * 60 * 1000 // milliseconds before a delivery stuck sending is attempted again
+	OUTGOING_WEBHOOK_DELIVERY_BATCH_SIZE    = 100
+	OUTGOING_WEBHOOK_DELIVERIES_LIST_SIZE   = 50
+)
+
+var outgoingWebhookDeliveryStop chan bool
+
+func StartOutgoingWebhookDeliveryWorker() {
+	if outgoingWebhookDeliveryStop != nil {
+		return
+	}
+
+	stop := make(chan bool)
+	outgoingWebhookDeliveryStop = stop
+
+	go func() {
+		ticker := time.NewTicker(OUTGOING_WEBHOOK_DELIVERY_POLL_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
+					deliverPendingOutgoingWebhooks()
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func StopOutgoingWebhookDeliveryWorker() {
+	if outgoingWebhookDeliveryStop != nil {
+		close(outgoingWebhookDeliveryStop)
+		outgoingWebhookDeliveryStop = nil
+	}
+}
+
+func deliverPendingOutgoingWebhooks() {
+	now := model.GetMillis()
+
+	result := <-Srv.Store.Webhook().GetPendingOutgoingDeliveries(now, now-OUTGOING_WEBHOOK_DELIVERY_STALE_TIME, OUTGOING_WEBHOOK_DELIVERY_BATCH_SIZE)
+	if result.Err != nil {
+		l4g.Error(utils.T("api.webhook.deliver_pending.error"), result.Err)
+		return
+	}
+
+	c := &Context{
+		RequestId: model.NewId(),
+		T:         utils.TfuncWithFallback(model.DEFAULT_LOCALE),
+		Locale:    model.DEFAULT_LOCALE,
+	}
+
+	for _, delivery := range result.Data.([]*model.OutgoingWebhookDelivery) {
+		go deliverOutgoingWebhook(c, delivery)
+	}
+}
+
+// deliverOutgoingWebhook makes one attempt at a delivery and queues the next attempt if it fails.
+// The context is only used as a template for the one that posts the hook's response.
+func deliverOutgoingWebhook(c *Context, delivery *model.OutgoingWebhookDelivery) {
+	staleTime := model.GetMillis() - OUTGOING_WEBHOOK_DELIVERY_STALE_TIME
+	if result := <-Srv.Store.Webhook().ClaimOutgoingDelivery(delivery.Id, staleTime); result.Err != nil {
+		l4g.Error(utils.T("api.webhook.deliver.claim.error"), delivery.Id, result.Err)
+		return
+	} else if !result.Data.(bool) {
+		// Another server or an earlier attempt already has this delivery
+		return
+	}
+
+	var hook *model.OutgoingWebhook
+	if result := <-Srv.Store.Webhook().GetOutgoing(delivery.HookId); result.Err != nil {
+		delivery.Status = model.OUTGOING_WEBHOOK_DELIVERY_FAILED
+		delivery.Error = result.Err.Message
+		saveOutgoingWebhookDelivery(delivery)
+		return
+	} else {
+		hook = result.Data.(*model.OutgoingWebhook)
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+	}
+	client := &http.Client{Transport: tr, Timeout: OUTGOING_WEBHOOK_DELIVERY_TIMEOUT}
+
+	var respProps map[string]string
+
+	req, err := http.NewRequest("POST", delivery.Url, strings.NewReader(delivery.Payload))
+	if err == nil {
+		req.Header.Set("Content-Type", delivery.ContentType)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set(model.HEADER_WEBHOOK_SIGNATURE, model.SignOutgoingWebhookPayload(hook.Token, delivery.Payload))
+
+		start := time.Now()
+		var resp *http.Response
+		resp, err = client.Do(req)
+		delivery.Latency = int64(time.Since(start) / time.Millisecond)
+
+		if err == nil {
+			delivery.StatusCode = resp.StatusCode
+			if delivery.IsSuccessStatusCode() {
+				respProps = model.MapFromJson(resp.Body)
+			} else {
+				delivery.Error = resp.Status
+			}
+
+			ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+	}
+
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if err == nil && delivery.IsSuccessStatusCode() {
+		delivery.Status = model.OUTGOING_WEBHOOK_DELIVERY_SUCCESS
+	} else {
+		l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.event_post.error"), delivery.Error)
+		delivery.Status = model.OUTGOING_WEBHOOK_DELIVERY_FAILED
+
+		if retry := delivery.Retry(); retry != nil {
+			if result := <-Srv.Store.Webhook().SaveOutgoingDelivery(retry); result.Err != nil {
+				l4g.Error(utils.T("api.webhook.deliver.retry.error"), delivery.Id, result.Err)
+			}
+		}
+	}
+
+	saveOutgoingWebhookDelivery(delivery)
+
+	if text, ok := respProps["text"]; ok {
+		createOutgoingWebhookResponsePost(c, hook, delivery, text, respProps)
+	}
+}
+
+func saveOutgoingWebhookDelivery(delivery *model.OutgoingWebhookDelivery) {
+	if result := <-Srv.Store.Webhook().UpdateOutgoingDelivery(delivery); result.Err != nil {
+		l4g.Error(utils.T("api.webhook.deliver.save.error"), delivery.Id, result.Err)
+	}
+}
+
+func createOutgoingWebhookResponsePost(c *Context, hook *model.OutgoingWebhook, delivery *model.OutgoingWebhookDelivery, text string, respProps map[string]string) {
+	var post *model.Post
+	if result := <-Srv.Store.Post().Get(delivery.PostId); result.Err != nil {
+		l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.create_post.error"), result.Err)
+		return
+	} else {
+		post = result.Data.(*model.PostList).Posts[delivery.PostId]
+	}
+
+	newContext := newIntegrationContext(c, integrationUserId(hook.BotUserId, hook.CreatorId), hook.TeamId)
+
+	if _, err := CreateWebhookPost(newContext, post.ChannelId, text, respProps["username"], respProps["icon_url"], post.Props, post.Type); err != nil {
+		l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.create_post.error"), err)
+	}
+}
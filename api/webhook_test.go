@@ -7,6 +7,10 @@ import (
 	"fmt"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -523,6 +527,112 @@ func TestRegenOutgoingHookToken(t *testing.T) {
 	}
 }
 
+func TestOutgoingHookDeliveries(t *testing.T) {
+	th := Setup().InitSystemAdmin()
+	Client := th.SystemAdminClient
+	team := th.SystemAdminTeam
+	channel1 := th.CreateChannel(Client, team)
+
+	enableOutgoingHooks := utils.Cfg.ServiceSettings.EnableOutgoingWebhooks
+	enableAdminOnlyHooks := utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOutgoingWebhooks = enableOutgoingHooks
+		utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = enableAdminOnlyHooks
+	}()
+	utils.Cfg.ServiceSettings.EnableOutgoingWebhooks = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = false
+
+	signatures := make(chan string, 10)
+	bodies := make(chan string, 10)
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		signatures <- r.Header.Get(model.HEADER_WEBHOOK_SIGNATURE)
+		bodies <- string(body)
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	hook := &model.OutgoingWebhook{ChannelId: channel1.Id, CallbackURLs: []string{server.URL}, TriggerWords: []string{"trigger"}}
+	hook = Client.Must(Client.CreateOutgoingWebhook(hook)).Data.(*model.OutgoingWebhook)
+
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "trigger delivery"}))
+
+	select {
+	case signature := <-signatures:
+		if body := <-bodies; signature != model.SignOutgoingWebhookPayload(hook.Token, body) {
+			t.Fatal("bad signature")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("hook was never called")
+	}
+
+	var deliveries []*model.OutgoingWebhookDelivery
+	for i := 0; i < 50; i++ {
+		deliveries = Client.Must(Client.ListOutgoingWebhookDeliveries(hook.Id)).Data.([]*model.OutgoingWebhookDelivery)
+		if len(deliveries) == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if len(deliveries) != 2 {
+		t.Fatal("failed attempt should have queued a retry")
+	}
+
+	retry, failed := deliveries[0], deliveries[1]
+	if len(failed.Payload) == 0 || strings.Contains(failed.Payload, hook.Token) {
+		t.Fatal("should've removed the hook's token from the payload")
+	}
+
+	if failed.Status != model.OUTGOING_WEBHOOK_DELIVERY_FAILED || failed.StatusCode != http.StatusInternalServerError || failed.Attempt != 1 {
+		t.Fatal("failed attempt wasn't recorded")
+	}
+
+	if retry.Status != model.OUTGOING_WEBHOOK_DELIVERY_PENDING || retry.Attempt != 2 || retry.NextAttemptAt <= failed.CreateAt {
+		t.Fatal("retry wasn't queued for later")
+	}
+
+	fail = false
+
+	if result, err := Client.RedeliverOutgoingWebhook(hook.Id, failed.Id); err != nil {
+		t.Fatal(err)
+	} else if result.Data.(*model.OutgoingWebhookDelivery).Payload != failed.Payload {
+		t.Fatal("redelivery should have the same payload")
+	}
+
+	select {
+	case <-signatures:
+	case <-time.After(5 * time.Second):
+		t.Fatal("hook was never called again")
+	}
+
+	if _, err := Client.RedeliverOutgoingWebhook(hook.Id, "junk"); err == nil {
+		t.Fatal("should have failed - bad delivery id")
+	}
+
+	if _, err := Client.ListOutgoingWebhookDeliveries("junk"); err == nil {
+		t.Fatal("should have failed - bad hook id")
+	}
+
+	user2 := th.CreateUser(Client)
+	LinkUserToTeam(user2, team)
+	Client.Must(Client.LoginById(user2.Id, user2.Password))
+	Client.SetTeamId(team.Id)
+
+	if _, err := Client.ListOutgoingWebhookDeliveries(hook.Id); err == nil {
+		t.Fatal("should have failed - not the hook's creator or a team admin")
+	}
+
+	utils.Cfg.ServiceSettings.EnableOutgoingWebhooks = false
+
+	if _, err := Client.ListOutgoingWebhookDeliveries(hook.Id); err == nil {
+		t.Fatal("should have errored - webhooks turned off")
+	}
+}
+
 func TestIncomingWebhooks(t *testing.T) {
 	th := Setup().InitSystemAdmin()
 	Client := th.SystemAdminClient
@@ -0,0 +1,27 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SearchEngineInterface interface {
+	Start() *model.AppError
+	Stop() *model.AppError
+	IndexPost(post *model.Post) *model.AppError
+	DeletePost(post *model.Post) *model.AppError
+	SearchPosts(channelIds []string, userIds []string, params *model.SearchParams, page, perPage int) ([]*model.PostSearchHit, *model.AppError)
+	PurgeIndex() *model.AppError
+}
+
+var theSearchEngineInterface SearchEngineInterface
+
+func RegisterSearchEngineInterface(newInterface SearchEngineInterface) {
+	theSearchEngineInterface = newInterface
+}
+
+func GetSearchEngineInterface() SearchEngineInterface {
+	return theSearchEngineInterface
+}
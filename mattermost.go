@@ -8,12 +8,14 @@ import (
 	"flag"
 	"fmt"
 	"html/template"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -58,14 +60,22 @@ var flagCmdResetDatabase bool
 var flagCmdRunLdapSync bool
 var flagUsername string
 var flagCmdUploadLicense bool
+var flagCmdImportMattermost bool
+var flagCmdImportBulk bool
+var flagCmdImportHipChat bool
+var flagCmdExport bool
+var flagCmdRebuildSearchIndex bool
 var flagConfigFile string
 var flagLicenseFile string
+var flagImportFile string
 var flagEmail string
 var flagPassword string
 var flagTeamName string
 var flagSiteURL string
 var flagConfirmBackup string
 var flagRole string
+var flagDryRun bool
+var flagIncremental bool
 var flagRunCmds bool
 
 func doLoadConfig(filename string) (err string) {
@@ -136,6 +146,8 @@ func main() {
 			ldapI.StartLdapSyncJob()
 		}
 
+		api.StartDataRetentionJob()
+
 		// wait for kill signal before attempting to gracefully shutdown
 		// the running service
 		c := make(chan os.Signal)
@@ -260,12 +272,15 @@ func parseCmds() {
 	flag.StringVar(&flagConfigFile, "config", "config.json", "")
 	flag.StringVar(&flagUsername, "username", "", "")
 	flag.StringVar(&flagLicenseFile, "license", "", "")
+	flag.StringVar(&flagImportFile, "import_file", "", "")
 	flag.StringVar(&flagEmail, "email", "", "")
 	flag.StringVar(&flagPassword, "password", "", "")
 	flag.StringVar(&flagTeamName, "team_name", "", "")
 	flag.StringVar(&flagSiteURL, "site_url", "", "")
 	flag.StringVar(&flagConfirmBackup, "confirm_backup", "", "")
 	flag.StringVar(&flagRole, "role", "", "")
+	flag.BoolVar(&flagDryRun, "dry_run", false, "")
+	flag.BoolVar(&flagIncremental, "incremental", false, "")
 
 	flag.BoolVar(&flagCmdUpdateDb30, "upgrade_db_30", false, "")
 	flag.BoolVar(&flagCmdCreateTeam, "create_team", false, "")
@@ -284,6 +299,11 @@ func parseCmds() {
 	flag.BoolVar(&flagCmdResetDatabase, "reset_database", false, "")
 	flag.BoolVar(&flagCmdRunLdapSync, "ldap_sync", false, "")
 	flag.BoolVar(&flagCmdUploadLicense, "upload_license", false, "")
+	flag.BoolVar(&flagCmdImportMattermost, "import_mattermost", false, "")
+	flag.BoolVar(&flagCmdImportBulk, "import_bulk", false, "")
+	flag.BoolVar(&flagCmdImportHipChat, "import_hipchat", false, "")
+	flag.BoolVar(&flagCmdExport, "export", false, "")
+	flag.BoolVar(&flagCmdRebuildSearchIndex, "rebuild_search_index", false, "")
 
 	flag.Parse()
 
@@ -302,7 +322,12 @@ func parseCmds() {
 		flagCmdPermanentDeleteAllUsers ||
 		flagCmdResetDatabase ||
 		flagCmdRunLdapSync ||
-		flagCmdUploadLicense)
+		flagCmdUploadLicense ||
+		flagCmdImportMattermost ||
+		flagCmdImportBulk ||
+		flagCmdImportHipChat ||
+		flagCmdExport ||
+		flagCmdRebuildSearchIndex)
 }
 
 func runCmds() {
@@ -321,6 +346,11 @@ func runCmds() {
 	cmdResetDatabase()
 	cmdUploadLicense()
 	cmdRunLdapSync()
+	cmdImportMattermost()
+	cmdImportBulk()
+	cmdImportHipChat()
+	cmdExport()
+	cmdRebuildSearchIndex()
 }
 
 type TeamForUpgrade struct {
@@ -1215,6 +1245,195 @@ func cmdUploadLicense() {
 	}
 }
 
+func cmdImportMattermost() {
+	if flagCmdImportMattermost {
+		if len(flagImportFile) == 0 {
+			fmt.Fprintln(os.Stderr, "flag needs an argument: -import_file")
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		var teamId string
+		if len(flagTeamName) > 0 {
+			if result := <-api.Srv.Store.Team().GetByName(flagTeamName); result.Err != nil {
+				l4g.Error("%v", result.Err)
+				flushLogAndExit(1)
+			} else {
+				teamId = result.Data.(*model.Team).Id
+			}
+		}
+
+		file, err := os.Open(flagImportFile)
+		if err != nil {
+			l4g.Error("%v", err)
+			flushLogAndExit(1)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			l4g.Error("%v", err)
+			flushLogAndExit(1)
+		}
+
+		appErr, log := api.ImportFromReader(file, info.Size(), nil, teamId)
+		if log != nil {
+			fmt.Print(log.String())
+		}
+
+		if appErr != nil {
+			l4g.Error("%v", appErr)
+			flushLogAndExit(1)
+		}
+
+		flushLogAndExit(0)
+	}
+}
+
+func cmdImportBulk() {
+	if flagCmdImportBulk {
+		if len(flagImportFile) == 0 {
+			fmt.Fprintln(os.Stderr, "flag needs an argument: -import_file")
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		file, err := os.Open(flagImportFile)
+		if err != nil {
+			l4g.Error("%v", err)
+			flushLogAndExit(1)
+		}
+		defer file.Close()
+
+		var appErr *model.AppError
+		var report *model.ImportReport
+		if strings.HasSuffix(flagImportFile, ".jsonl") {
+			// attachments are read from paths relative to the import file
+			dir := filepath.Dir(flagImportFile)
+			appErr, report = api.BulkImport(file, func(path string) (io.ReadCloser, error) {
+				return os.Open(filepath.Join(dir, path))
+			}, flagDryRun)
+		} else {
+			info, err := file.Stat()
+			if err != nil {
+				l4g.Error("%v", err)
+				flushLogAndExit(1)
+			}
+
+			appErr, report = api.BulkImportFromReader(file, info.Size(), flagDryRun)
+		}
+
+		printImportResult(appErr, report)
+	}
+}
+
+func cmdImportHipChat() {
+	if flagCmdImportHipChat {
+		if len(flagImportFile) == 0 {
+			fmt.Fprintln(os.Stderr, "flag needs an argument: -import_file")
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		if len(flagTeamName) == 0 {
+			fmt.Fprintln(os.Stderr, "flag needs an argument: -team_name")
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		var team *model.Team
+		if result := <-api.Srv.Store.Team().GetByName(flagTeamName); result.Err != nil {
+			l4g.Error("%v", result.Err)
+			flushLogAndExit(1)
+		} else {
+			team = result.Data.(*model.Team)
+		}
+
+		file, err := os.Open(flagImportFile)
+		if err != nil {
+			l4g.Error("%v", err)
+			flushLogAndExit(1)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			l4g.Error("%v", err)
+			flushLogAndExit(1)
+		}
+
+		printImportResult(api.HipChatImport(file, info.Size(), team.Id, flagDryRun))
+	}
+}
+
+func cmdExport() {
+	if flagCmdExport {
+		job := &model.ExportJob{
+			Options: model.ExportOptions{Incremental: flagIncremental},
+		}
+
+		if len(flagTeamName) > 0 {
+			if result := <-api.Srv.Store.Team().GetByName(flagTeamName); result.Err != nil {
+				l4g.Error("%v", result.Err)
+				flushLogAndExit(1)
+			} else {
+				job.TeamId = result.Data.(*model.Team).Id
+				job.Options.TeamsToExport = []string{job.TeamId}
+			}
+		}
+
+		if result := <-api.Srv.Store.ExportJob().Save(job); result.Err != nil {
+			l4g.Error("%v", result.Err.SystemMessage(utils.T))
+			flushLogAndExit(1)
+		}
+
+		if err := api.RunExportJob(job); err != nil {
+			l4g.Error("%v", err.SystemMessage(utils.T))
+			flushLogAndExit(1)
+		}
+
+		fmt.Println(job.ToJson())
+		flushLogAndExit(0)
+	}
+}
+
+func printImportResult(appErr *model.AppError, report *model.ImportReport) {
+	if appErr != nil {
+		l4g.Error("%v", appErr.SystemMessage(utils.T))
+		flushLogAndExit(1)
+	}
+
+	fmt.Println(report.ToJson())
+	flushLogAndExit(0)
+}
+
+func cmdRebuildSearchIndex() {
+	if flagCmdRebuildSearchIndex {
+		searchEngine := einterfaces.GetSearchEngineInterface()
+		if searchEngine == nil {
+			fmt.Fprintln(os.Stderr, "search indexing is disabled, set BleveSettings.EnableIndexing to true")
+			os.Exit(1)
+		}
+
+		if err := searchEngine.Start(); err != nil {
+			l4g.Error("%v", err)
+			flushLogAndExit(1)
+		}
+
+		count, err := api.RebuildSearchIndex()
+		searchEngine.Stop()
+
+		if err != nil {
+			fmt.Println("ERROR: Rebuilding the search index failed")
+			l4g.Error("%v", err)
+			flushLogAndExit(1)
+		}
+
+		fmt.Printf("SUCCESS: Indexed %v posts\n", count)
+		flushLogAndExit(0)
+	}
+}
+
 func flushLogAndExit(code int) {
 	l4g.Close()
 	time.Sleep(time.Second)
@@ -1245,6 +1464,8 @@ FLAGS:
 
     -license="ex.mattermost-license"  Path to your license file
 
+    -import_file="export.zip"         Path to the file used by the import commands
+
     -email="user@example.com"         Email address used in other commands
 
     -password="mypassword"            Password used in other commands
@@ -1332,6 +1553,40 @@ COMMANDS:
         Example:
             platform -upload_license -license="/path/to/license/example.mattermost-license"
 
+    -import_mattermost                Imports teams, channels, users, posts and files from a
+                                      MattermostExport.zip.  It requires the -import_file flag.
+                                      If -team_name is given everything is imported into that
+                                      existing team instead of the teams in the export.
+        Example:
+            platform -import_mattermost -import_file="/path/to/MattermostExport.zip"
+
+    -import_bulk                      Imports teams, channels, users, posts and attachments from a
+                                      bulk import file with one JSON object on each line, or from a
+                                      zip containing one.  It requires the -import_file flag.  The
+                                      whole file is checked before anything is imported, and with
+                                      -dry_run nothing is imported.
+        Example:
+            platform -import_bulk -import_file="/path/to/data.jsonl" -dry_run
+
+    -import_hipchat                   Imports users, rooms and messages from a decrypted HipChat
+                                      export into a team.  It requires the -import_file and
+                                      -team_name flags, and also accepts -dry_run.
+        Example:
+            platform -import_hipchat -import_file="/path/to/export.tar.gz" -team_name="name"
+
+    -export                           Exports teams, channels, users and posts to a timestamped zip
+                                      in the export folder of the file storage.  With -team_name
+                                      only that team is exported, and with -incremental only the
+                                      posts made since the last export of the same team are.
+        Example:
+            platform -export -team_name="name" -incremental
+
+    -rebuild_search_index             Deletes the search index in BleveSettings.IndexDir and indexes
+                                      every post in the database again.  Search indexing must be
+                                      enabled in the config.
+        Example:
+            platform -rebuild_search_index
+
     -upgrade_db_30                   Upgrades the database from a version 2.x schema to version 3 see
                                       http://www.mattermost.org/upgrading-to-mattermost-3-0/
 
@@ -12,13 +12,19 @@ const (
 	ACCESS_TOKEN_GRANT_TYPE  = "authorization_code"
 	ACCESS_TOKEN_TYPE        = "bearer"
 	REFRESH_TOKEN_GRANT_TYPE = "refresh_token"
+
+	// Apps with a bot can use their own credentials to get a token for the bot without a user authorizing them
+	CLIENT_CREDENTIALS_GRANT_TYPE = "client_credentials"
 )
 
 type AccessData struct {
+	ClientId     string `json:"client_id"`
+	UserId       string `json:"user_id"`
 	AuthCode     string `json:"auth_code"`
 	Token        string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
 	RedirectUri  string `json:"redirect_uri"`
+	Scope        string `json:"scope"`
 }
 
 type AccessResponse struct {
@@ -33,7 +39,16 @@ type AccessResponse struct {
 // correctly.
 func (ad *AccessData) IsValid() *AppError {
 
-	if len(ad.AuthCode) == 0 || len(ad.AuthCode) > 128 {
+	if len(ad.ClientId) != 26 {
+		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.client_id.app_error", nil, "")
+	}
+
+	if len(ad.UserId) != 26 {
+		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.user_id.app_error", nil, "")
+	}
+
+	// tokens given to apps for their bots aren't authorized by a user so they don't have an auth code
+	if len(ad.AuthCode) > 128 {
 		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.auth_code.app_error", nil, "")
 	}
 
@@ -49,6 +64,10 @@ func (ad *AccessData) IsValid() *AppError {
 		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.redirect_uri.app_error", nil, "")
 	}
 
+	if len(ad.Scope) > 128 {
+		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.scope.app_error", nil, "")
+	}
+
 	return nil
 }
 
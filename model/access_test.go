@@ -29,6 +29,16 @@ func TestAccessIsValid(t *testing.T) {
 		t.Fatal("should have failed")
 	}
 
+	ad.ClientId = NewId()
+	if err := ad.IsValid(); err == nil {
+		t.Fatal("should have failed")
+	}
+
+	ad.UserId = NewId()
+	if err := ad.IsValid(); err == nil {
+		t.Fatal("should have failed")
+	}
+
 	ad.AuthCode = NewId()
 	if err := ad.IsValid(); err == nil {
 		t.Fatal("should have failed")
@@ -38,4 +48,9 @@ func TestAccessIsValid(t *testing.T) {
 	if err := ad.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	ad.Scope = strings.Repeat("read ", 30)
+	if err := ad.IsValid(); err == nil {
+		t.Fatal("should have failed")
+	}
 }
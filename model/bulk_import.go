@@ -0,0 +1,347 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	BULK_IMPORT_VERSION = 1
+
+	BULK_IMPORT_TYPE_VERSION     = "version"
+	BULK_IMPORT_TYPE_TEAM        = "team"
+	BULK_IMPORT_TYPE_CHANNEL     = "channel"
+	BULK_IMPORT_TYPE_USER        = "user"
+	BULK_IMPORT_TYPE_POST        = "post"
+	BULK_IMPORT_TYPE_DIRECT_POST = "direct_post"
+)
+
+// BulkImportLine is one line of a bulk import file. The first line of a file gives its version and every other line
+// holds one team, channel, user or post in the field named by its type. Anything referred to by name must either
+// already exist or be on an earlier line.
+type BulkImportLine struct {
+	Type       string                `json:"type"`
+	Version    int                   `json:"version,omitempty"`
+	Team       *BulkImportTeam       `json:"team,omitempty"`
+	Channel    *BulkImportChannel    `json:"channel,omitempty"`
+	User       *BulkImportUser       `json:"user,omitempty"`
+	Post       *BulkImportPost       `json:"post,omitempty"`
+	DirectPost *BulkImportDirectPost `json:"direct_post,omitempty"`
+}
+
+type BulkImportTeam struct {
+	Name            string `json:"name"`
+	DisplayName     string `json:"display_name"`
+	Type            string `json:"type"`
+	AllowOpenInvite bool   `json:"allow_open_invite,omitempty"`
+}
+
+type BulkImportChannel struct {
+	Team        string `json:"team"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+	Header      string `json:"header,omitempty"`
+	Purpose     string `json:"purpose,omitempty"`
+}
+
+type BulkImportUser struct {
+	Username    string                  `json:"username"`
+	Email       string                  `json:"email"`
+	Password    string                  `json:"password,omitempty"`
+	AuthService string                  `json:"auth_service,omitempty"`
+	AuthData    string                  `json:"auth_data,omitempty"`
+	Nickname    string                  `json:"nickname,omitempty"`
+	FirstName   string                  `json:"first_name,omitempty"`
+	LastName    string                  `json:"last_name,omitempty"`
+	Roles       string                  `json:"roles,omitempty"`
+	Locale      string                  `json:"locale,omitempty"`
+	Teams       []*BulkImportTeamMember `json:"teams,omitempty"`
+	Preferences []*BulkImportPreference `json:"preferences,omitempty"`
+}
+
+type BulkImportTeamMember struct {
+	Name     string                     `json:"name"`
+	Roles    string                     `json:"roles,omitempty"`
+	Channels []*BulkImportChannelMember `json:"channels,omitempty"`
+}
+
+type BulkImportChannelMember struct {
+	Name  string `json:"name"`
+	Roles string `json:"roles,omitempty"`
+}
+
+type BulkImportPreference struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+}
+
+type BulkImportPost struct {
+	Team        string                  `json:"team"`
+	Channel     string                  `json:"channel"`
+	User        string                  `json:"user"`
+	Message     string                  `json:"message"`
+	CreateAt    int64                   `json:"create_at"`
+	Attachments []*BulkImportAttachment `json:"attachments,omitempty"`
+	Replies     []*BulkImportReply      `json:"replies,omitempty"`
+}
+
+type BulkImportReply struct {
+	User        string                  `json:"user"`
+	Message     string                  `json:"message"`
+	CreateAt    int64                   `json:"create_at"`
+	Attachments []*BulkImportAttachment `json:"attachments,omitempty"`
+}
+
+// BulkImportDirectPost is a message between two users, which is added to the direct channel between them
+type BulkImportDirectPost struct {
+	Members     []string                `json:"members"`
+	User        string                  `json:"user"`
+	Message     string                  `json:"message"`
+	CreateAt    int64                   `json:"create_at"`
+	Attachments []*BulkImportAttachment `json:"attachments,omitempty"`
+}
+
+// BulkImportAttachment is a file to attach to a post, given by its path relative to the import file
+type BulkImportAttachment struct {
+	Path string `json:"path"`
+}
+
+func (o *BulkImportLine) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func BulkImportLineFromJson(data io.Reader) *BulkImportLine {
+	decoder := json.NewDecoder(data)
+	var o BulkImportLine
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+// IsValid checks the contents of the line. It doesn't check whether the things that the line refers to exist.
+func (o *BulkImportLine) IsValid() *AppError {
+	switch o.Type {
+	case BULK_IMPORT_TYPE_VERSION:
+		if o.Version != BULK_IMPORT_VERSION {
+			return NewLocAppError("BulkImportLine.IsValid", "model.bulk_import.is_valid.version.app_error", map[string]interface{}{"Version": BULK_IMPORT_VERSION}, "")
+		}
+		return nil
+	case BULK_IMPORT_TYPE_TEAM:
+		if o.Team != nil {
+			return o.Team.IsValid()
+		}
+	case BULK_IMPORT_TYPE_CHANNEL:
+		if o.Channel != nil {
+			return o.Channel.IsValid()
+		}
+	case BULK_IMPORT_TYPE_USER:
+		if o.User != nil {
+			return o.User.IsValid()
+		}
+	case BULK_IMPORT_TYPE_POST:
+		if o.Post != nil {
+			return o.Post.IsValid()
+		}
+	case BULK_IMPORT_TYPE_DIRECT_POST:
+		if o.DirectPost != nil {
+			return o.DirectPost.IsValid()
+		}
+	default:
+		return NewLocAppError("BulkImportLine.IsValid", "model.bulk_import.is_valid.type.app_error", nil, "type="+o.Type)
+	}
+
+	return NewLocAppError("BulkImportLine.IsValid", "model.bulk_import.is_valid.missing.app_error", map[string]interface{}{"Type": o.Type}, "")
+}
+
+func (o *BulkImportTeam) ToTeam() *Team {
+	return &Team{
+		Name:            o.Name,
+		DisplayName:     o.DisplayName,
+		Type:            o.Type,
+		AllowOpenInvite: o.AllowOpenInvite,
+	}
+}
+
+func (o *BulkImportTeam) IsValid() *AppError {
+	team := o.ToTeam()
+	team.Id = NewId()
+	team.CreateAt = 1
+	team.UpdateAt = 1
+
+	return team.IsValid(false)
+}
+
+func (o *BulkImportChannel) ToChannel() *Channel {
+	return &Channel{
+		Name:        o.Name,
+		DisplayName: o.DisplayName,
+		Type:        o.Type,
+		Header:      o.Header,
+		Purpose:     o.Purpose,
+	}
+}
+
+func (o *BulkImportChannel) IsValid() *AppError {
+	if !IsValidTeamName(o.Team) {
+		return NewLocAppError("BulkImportChannel.IsValid", "model.bulk_import.is_valid.team.app_error", nil, "team="+o.Team)
+	}
+
+	if o.Type == CHANNEL_DIRECT {
+		return NewLocAppError("BulkImportChannel.IsValid", "model.bulk_import.is_valid.channel_type.app_error", nil, "name="+o.Name)
+	}
+
+	channel := o.ToChannel()
+	channel.Id = NewId()
+	channel.CreateAt = 1
+	channel.UpdateAt = 1
+
+	return channel.IsValid()
+}
+
+func (o *BulkImportUser) ToUser() *User {
+	user := &User{
+		Username:    strings.ToLower(o.Username),
+		Email:       strings.ToLower(o.Email),
+		Password:    o.Password,
+		AuthService: o.AuthService,
+		Nickname:    o.Nickname,
+		FirstName:   o.FirstName,
+		LastName:    o.LastName,
+		Roles:       o.Roles,
+		Locale:      o.Locale,
+	}
+
+	if len(o.AuthData) > 0 {
+		user.AuthData = new(string)
+		*user.AuthData = o.AuthData
+	}
+
+	return user
+}
+
+func (o *BulkImportUser) IsValid() *AppError {
+	user := o.ToUser()
+	user.Id = NewId()
+	user.CreateAt = 1
+	user.UpdateAt = 1
+
+	if err := user.IsValid(); err != nil {
+		return err
+	}
+
+	if !IsValidEmail(user.Email) {
+		return NewLocAppError("BulkImportUser.IsValid", "model.user.is_valid.email.app_error", nil, "username="+o.Username)
+	}
+
+	if len(o.Roles) > 0 && !IsValidUserRoles(o.Roles) {
+		return NewLocAppError("BulkImportUser.IsValid", "model.bulk_import.is_valid.roles.app_error", nil, "roles="+o.Roles)
+	}
+
+	for _, member := range o.Teams {
+		if !IsValidTeamName(member.Name) {
+			return NewLocAppError("BulkImportUser.IsValid", "model.bulk_import.is_valid.team.app_error", nil, "team="+member.Name)
+		}
+
+		if len(member.Roles) > 0 && !IsValidTeamRoles(member.Roles) {
+			return NewLocAppError("BulkImportUser.IsValid", "model.bulk_import.is_valid.roles.app_error", nil, "roles="+member.Roles)
+		}
+
+		for _, channelMember := range member.Channels {
+			if !IsValidChannelIdentifier(channelMember.Name) {
+				return NewLocAppError("BulkImportUser.IsValid", "model.bulk_import.is_valid.channel.app_error", nil, "channel="+channelMember.Name)
+			}
+
+			if !(channelMember.Roles == "" || channelMember.Roles == CHANNEL_ROLE_ADMIN) {
+				return NewLocAppError("BulkImportUser.IsValid", "model.bulk_import.is_valid.roles.app_error", nil, "roles="+channelMember.Roles)
+			}
+		}
+	}
+
+	for _, preference := range o.Preferences {
+		p := &Preference{UserId: user.Id, Category: preference.Category, Name: preference.Name, Value: preference.Value}
+		if err := p.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *BulkImportPost) IsValid() *AppError {
+	if !IsValidTeamName(o.Team) {
+		return NewLocAppError("BulkImportPost.IsValid", "model.bulk_import.is_valid.team.app_error", nil, "team="+o.Team)
+	}
+
+	if !IsValidChannelIdentifier(o.Channel) {
+		return NewLocAppError("BulkImportPost.IsValid", "model.bulk_import.is_valid.channel.app_error", nil, "channel="+o.Channel)
+	}
+
+	if err := isValidBulkImportMessage(o.User, o.Message, o.CreateAt, o.Attachments); err != nil {
+		return err
+	}
+
+	for _, reply := range o.Replies {
+		if err := isValidBulkImportMessage(reply.User, reply.Message, reply.CreateAt, reply.Attachments); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *BulkImportDirectPost) IsValid() *AppError {
+	if len(o.Members) != 2 || o.Members[0] == o.Members[1] {
+		return NewLocAppError("BulkImportDirectPost.IsValid", "model.bulk_import.is_valid.members.app_error", nil, "")
+	}
+
+	if o.User != o.Members[0] && o.User != o.Members[1] {
+		return NewLocAppError("BulkImportDirectPost.IsValid", "model.bulk_import.is_valid.members.app_error", nil, "user="+o.User)
+	}
+
+	return isValidBulkImportMessage(o.User, o.Message, o.CreateAt, o.Attachments)
+}
+
+func isValidBulkImportMessage(username string, message string, createAt int64, attachments []*BulkImportAttachment) *AppError {
+	if !IsValidUsername(strings.ToLower(username)) {
+		return NewLocAppError("BulkImportPost.IsValid", "model.bulk_import.is_valid.user.app_error", nil, "user="+username)
+	}
+
+	if len(message) == 0 && len(attachments) == 0 {
+		return NewLocAppError("BulkImportPost.IsValid", "model.bulk_import.is_valid.message.app_error", nil, "")
+	}
+
+	if utf8.RuneCountInString(message) > 4000 {
+		return NewLocAppError("BulkImportPost.IsValid", "model.post.is_valid.msg.app_error", nil, "")
+	}
+
+	if createAt <= 0 {
+		return NewLocAppError("BulkImportPost.IsValid", "model.bulk_import.is_valid.create_at.app_error", nil, "")
+	}
+
+	if len(attachments) > POST_MAX_FILE_IDS {
+		return NewLocAppError("BulkImportPost.IsValid", "model.bulk_import.is_valid.attachments.app_error", map[string]interface{}{"Max": POST_MAX_FILE_IDS}, "")
+	}
+
+	for _, attachment := range attachments {
+		if len(attachment.Path) == 0 {
+			return NewLocAppError("BulkImportPost.IsValid", "model.bulk_import.is_valid.attachment_path.app_error", nil, "")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBulkImportLineJson(t *testing.T) {
+	o := BulkImportLine{Type: BULK_IMPORT_TYPE_TEAM, Team: &BulkImportTeam{Name: "name", DisplayName: "Name", Type: TEAM_OPEN}}
+	json := o.ToJson()
+	ro := BulkImportLineFromJson(strings.NewReader(json))
+
+	if ro.Type != o.Type || ro.Team == nil || ro.Team.Name != o.Team.Name {
+		t.Fatal("lines do not match")
+	} else if ro.User != nil {
+		t.Fatal("should only have a team")
+	}
+}
+
+func TestBulkImportLineIsValid(t *testing.T) {
+	o := BulkImportLine{Type: BULK_IMPORT_TYPE_VERSION, Version: 2}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Version = BULK_IMPORT_VERSION
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o = BulkImportLine{Type: BULK_IMPORT_TYPE_TEAM}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a team")
+	}
+
+	o = BulkImportLine{Type: "emoji"}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestBulkImportTeamIsValid(t *testing.T) {
+	o := BulkImportTeam{Name: "bulk-team", DisplayName: "Bulk Team", Type: TEAM_OPEN}
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Type = "x"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Type = TEAM_INVITE
+	o.Name = "Not Valid"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestBulkImportChannelIsValid(t *testing.T) {
+	o := BulkImportChannel{Team: "bulk-team", Name: "bulk-channel", DisplayName: "Bulk Channel", Type: CHANNEL_PRIVATE}
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Type = CHANNEL_DIRECT
+	if err := o.IsValid(); err == nil {
+		t.Fatal("direct channels can't be imported this way")
+	}
+
+	o.Type = CHANNEL_OPEN
+	o.Team = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a team")
+	}
+
+	o.Team = "bulk-team"
+	o.Purpose = strings.Repeat("a", 129)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestBulkImportUserIsValid(t *testing.T) {
+	o := BulkImportUser{
+		Username: "Bulk.User",
+		Email:    "bulk@example.com",
+		Teams: []*BulkImportTeamMember{
+			{Name: "bulk-team", Roles: ROLE_TEAM_ADMIN, Channels: []*BulkImportChannelMember{{Name: "town-square"}}},
+		},
+		Preferences: []*BulkImportPreference{{Category: PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: "use_military_time", Value: "true"}},
+	}
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.ToUser().Username != "bulk.user" {
+		t.Fatal("username should be lowercase")
+	}
+
+	o.Email = "bulk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Email = "bulk@example.com"
+	o.Teams[0].Roles = "owner"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Teams[0].Roles = ""
+	o.Teams[0].Channels[0].Roles = "owner"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Teams[0].Channels[0].Roles = CHANNEL_ROLE_ADMIN
+	o.Preferences[0].Name = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestBulkImportPostIsValid(t *testing.T) {
+	o := BulkImportPost{
+		Team:        "bulk-team",
+		Channel:     "town-square",
+		User:        "bulk.user",
+		Message:     "hello",
+		CreateAt:    GetMillis(),
+		Attachments: []*BulkImportAttachment{{Path: "files/hello.txt"}},
+		Replies:     []*BulkImportReply{{User: "bulk.user", Message: "reply", CreateAt: GetMillis()}},
+	}
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Replies[0].CreateAt = 0
+	if err := o.IsValid(); err == nil {
+		t.Fatal("reply should be invalid")
+	}
+
+	o.Replies = nil
+	o.Attachments[0].Path = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("attachment should be invalid")
+	}
+
+	o.Attachments = nil
+	o.Message = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a message or attachments")
+	}
+
+	d := BulkImportDirectPost{Members: []string{"bulk.user", "other.user"}, User: "other.user", Message: "hi", CreateAt: GetMillis()}
+	if err := d.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	d.User = "third.user"
+	if err := d.IsValid(); err == nil {
+		t.Fatal("should be invalid when posted by someone outside the channel")
+	}
+
+	d.User = "bulk.user"
+	d.Members = []string{"bulk.user", "bulk.user"}
+	if err := d.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+// ChannelMemberHistory records when a user joined a channel and, once they've left it, when they left
+type ChannelMemberHistory struct {
+	ChannelId string `json:"channel_id"`
+	UserId    string `json:"user_id"`
+	JoinTime  int64  `json:"join_time"`
+	LeaveTime int64  `json:"leave_time"`
+}
+
+// ChannelMemberHistoryResult is a ChannelMemberHistory along with the details of the user and channel that
+// compliance exports need
+type ChannelMemberHistoryResult struct {
+	ChannelId string
+	UserId    string
+	JoinTime  int64
+	LeaveTime int64
+
+	TeamName           string
+	TeamDisplayName    string
+	ChannelName        string
+	ChannelDisplayName string
+	ChannelType        string
+	UserUsername       string
+	UserEmail          string
+	UserNickname       string
+}
@@ -30,6 +30,7 @@ const (
 	HEADER_AUTH               = "Authorization"
 	HEADER_REQUESTED_WITH     = "X-Requested-With"
 	HEADER_REQUESTED_WITH_XML = "XMLHttpRequest"
+	HEADER_WEBHOOK_SIGNATURE  = "X-Mattermost-Signature"
 	STATUS                    = "status"
 	STATUS_OK                 = "OK"
 
@@ -609,6 +610,70 @@ func (c *Client) GetSessions(id string) (*Result, *AppError) {
 	}
 }
 
+// CreateUserAccessToken creates a personal access token for the user. The token itself is only returned this once.
+func (c *Client) CreateUserAccessToken(userId string, description string) (*UserAccessToken, *AppError) {
+	m := make(map[string]string)
+	m["description"] = description
+
+	if r, err := c.DoApiPost("/users/"+userId+"/tokens/create", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return UserAccessTokenFromJson(r.Body), nil
+	}
+}
+
+func (c *Client) GetUserAccessTokens(userId string) ([]*UserAccessToken, *AppError) {
+	if r, err := c.DoApiGet("/users/"+userId+"/tokens", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return UserAccessTokenListFromJson(r.Body), nil
+	}
+}
+
+func (c *Client) RevokeUserAccessToken(tokenId string) (bool, *AppError) {
+	m := make(map[string]string)
+	m["token_id"] = tokenId
+
+	if r, err := c.DoApiPost("/users/tokens/revoke", MapToJson(m)); err != nil {
+		return false, err
+	} else {
+		closeBody(r)
+		c.fillInExtraProperties(r)
+		return true, nil
+	}
+}
+
+// CreateBot creates a bot owned by the current user. If teamId is set the bot is also added to that team.
+func (c *Client) CreateBot(username string, displayName string, teamId string) (*User, *AppError) {
+	m := make(map[string]string)
+	m["username"] = username
+	m["display_name"] = displayName
+	m["team_id"] = teamId
+
+	if r, err := c.DoApiPost("/users/bots/create", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return UserFromJson(r.Body), nil
+	}
+}
+
+// GetBots returns the bots owned by the current user, or every bot if the current user is a system admin
+func (c *Client) GetBots() (map[string]*User, *AppError) {
+	if r, err := c.DoApiGet("/users/bots", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return UserMapFromJson(r.Body), nil
+	}
+}
+
 func (c *Client) EmailToOAuth(m map[string]string) (*Result, *AppError) {
 	if r, err := c.DoApiPost("/users/claim/email_to_sso", MapToJson(m)); err != nil {
 		return nil, err
@@ -629,6 +694,26 @@ func (c *Client) OAuthToEmail(m map[string]string) (*Result, *AppError) {
 	}
 }
 
+func (c *Client) EmailToSaml(m map[string]string) (*Result, *AppError) {
+	if r, err := c.DoApiPost("/users/claim/email_to_saml", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) SamlToEmail(m map[string]string) (*Result, *AppError) {
+	if r, err := c.DoApiPost("/users/claim/saml_to_email", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) LDAPToEmail(m map[string]string) (*Result, *AppError) {
 	if r, err := c.DoApiPost("/users/claim/ldap_to_email", MapToJson(m)); err != nil {
 		return nil, err
@@ -1132,6 +1217,152 @@ func (c *Client) SearchPosts(terms string, isOrSearch bool) (*Result, *AppError)
 	}
 }
 
+// SearchPostsWithPaging returns a page of posts matching the terms along with where the terms
+// matched in each post's message.
+func (c *Client) SearchPostsWithPaging(terms string, isOrSearch bool, page int, perPage int) (*PostSearchResults, *AppError) {
+	data := map[string]interface{}{}
+	data["terms"] = terms
+	data["is_or_search"] = isOrSearch
+	data["page"] = page
+	data["per_page"] = perPage
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/posts/search", StringInterfaceToJson(data)); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return PostSearchResultsFromJson(r.Body), nil
+	}
+}
+
+// PinPost pins a post in the given channel. Returns the pinned post if successful, otherwise an error
+// will be returned.
+func (c *Client) PinPost(channelId string, postId string) (*Post, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/pin", postId), ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return PostFromJson(r.Body), nil
+	}
+}
+
+// UnpinPost unpins a post in the given channel. Returns the unpinned post if successful, otherwise an error
+// will be returned.
+func (c *Client) UnpinPost(channelId string, postId string) (*Post, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/unpin", postId), ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return PostFromJson(r.Body), nil
+	}
+}
+
+// GetPinnedPosts returns the posts pinned in the given channel ordered from newest to oldest.
+func (c *Client) GetPinnedPosts(channelId string) (*PostList, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+"/pinned", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return PostListFromJson(r.Body), nil
+	}
+}
+
+// GetFileInfosForPost returns the info of each file attached to the given post.
+func (c *Client) GetFileInfosForPost(channelId string, postId string) ([]*FileInfo, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/get_file_infos", postId), "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return FileInfosFromJson(r.Body), nil
+	}
+}
+
+// GetFlaggedPosts returns a page of the posts that the current user has flagged, newest first. Posts are
+// flagged by saving a preference in the flagged_post category with the post id as the name.
+func (c *Client) GetFlaggedPosts(offset int, limit int) (*PostList, *AppError) {
+	if r, err := c.DoApiGet(fmt.Sprintf("/users/flagged_posts/%v/%v", offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return PostListFromJson(r.Body), nil
+	}
+}
+
+// GetPostThread returns the first page of the thread containing the given post, ordered from newest to
+// oldest. The root post is always included in the list's posts.
+func (c *Client) GetPostThread(channelId string, postId string, etag string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/thread", postId), "", etag); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+// GetPostThreadPage returns limit posts from the thread containing the given post after skipping the
+// newest offset of them, ordered from newest to oldest. The root post is always included in the list's posts.
+func (c *Client) GetPostThreadPage(channelId string, postId string, offset int, limit int, etag string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/thread/%v/%v", postId, offset, limit), "", etag); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+// FollowThread has the current user follow the thread containing the given post so that they're notified
+// about new replies. Returns the user's thread membership if successful, otherwise an error will be returned.
+func (c *Client) FollowThread(channelId string, postId string) (*ThreadMember, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/thread/follow", postId), ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return ThreadMemberFromJson(r.Body), nil
+	}
+}
+
+// UnfollowThread stops the current user from following the thread containing the given post. Returns the
+// user's thread membership if successful, otherwise an error will be returned.
+func (c *Client) UnfollowThread(channelId string, postId string) (*ThreadMember, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/thread/unfollow", postId), ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return ThreadMemberFromJson(r.Body), nil
+	}
+}
+
+// ViewThread marks all of the replies in the thread containing the given post as read by the current user.
+// Returns true if successful, otherwise an error will be returned.
+func (c *Client) ViewThread(channelId string, postId string) (bool, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/thread/view", postId), ""); err != nil {
+		return false, err
+	} else {
+		c.fillInExtraProperties(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
+// GetFollowedThreads returns the threads on the current team that the current user follows along with
+// how many of their replies are unread, most recently active first.
+func (c *Client) GetFollowedThreads() ([]*FollowedThread, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/posts/threads", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return FollowedThreadsFromJson(r.Body), nil
+	}
+}
+
 func (c *Client) UploadProfileFile(data []byte, contentType string) (*Result, *AppError) {
 	return c.uploadFile(c.ApiUrl+"/users/newimage", data, contentType)
 }
@@ -1159,6 +1390,106 @@ func (c *Client) uploadFile(url string, data []byte, contentType string) (*Resul
 	}
 }
 
+// GetStorageUsage returns how much file storage the current user has used and how much has been used by the
+// current team, along with their quotas.
+func (c *Client) GetStorageUsage() (*StorageUsage, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/files/storage", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return StorageUsageFromJson(r.Body), nil
+	}
+}
+
+// CreateUploadSession starts a resumable upload of a file with the given size to a channel.
+func (c *Client) CreateUploadSession(channelId string, filename string, fileSize int64) (*UploadSession, *AppError) {
+	session := &UploadSession{ChannelId: channelId, Filename: filename, FileSize: fileSize}
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/files/uploads/create", session.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return UploadSessionFromJson(r.Body), nil
+	}
+}
+
+// GetUploadSession returns an upload session so that an interrupted upload can carry on from its offset.
+func (c *Client) GetUploadSession(uploadId string) (*UploadSession, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/files/uploads/"+uploadId, "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return UploadSessionFromJson(r.Body), nil
+	}
+}
+
+// UploadChunk uploads the next part of the file at the session's current offset and returns the updated session.
+func (c *Client) UploadChunk(uploadId string, offset int64, data []byte) (*UploadSession, *AppError) {
+	url := c.ApiUrl + c.GetTeamRoute() + fmt.Sprintf("/files/uploads/%v?offset=%v", uploadId, offset)
+	rq, _ := http.NewRequest("PUT", url, bytes.NewReader(data))
+
+	if len(c.AuthToken) > 0 {
+		rq.Header.Set(HEADER_AUTH, c.AuthType+" "+c.AuthToken)
+	}
+
+	if rp, err := c.HttpClient.Do(rq); err != nil {
+		return nil, NewLocAppError(url, "model.client.connecting.app_error", nil, err.Error())
+	} else if rp.StatusCode >= 300 {
+		defer closeBody(rp)
+		return nil, AppErrorFromJson(rp.Body)
+	} else {
+		defer closeBody(rp)
+		c.fillInExtraProperties(rp)
+		return UploadSessionFromJson(rp.Body), nil
+	}
+}
+
+// FinishUploadSession completes an upload once all of its chunks have been uploaded.
+func (c *Client) FinishUploadSession(uploadId string) (*FileUploadResponse, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/files/uploads/"+uploadId+"/finish", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return FileUploadResponseFromJson(r.Body), nil
+	}
+}
+
+// StartExport queues an export of the current team to be run in the background. The returned job can be polled with
+// GetExportJob until it's finished.
+func (c *Client) StartExport(options *ExportOptions) (*ExportJob, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/files/export", options.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return ExportJobFromJson(r.Body), nil
+	}
+}
+
+// GetExportJob returns the status of an export started by StartExport.
+func (c *Client) GetExportJob(jobId string) (*ExportJob, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/files/export/"+jobId, "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return ExportJobFromJson(r.Body), nil
+	}
+}
+
+// DownloadExport returns the archive written by a successful export job. The caller is responsible for closing it.
+func (c *Client) DownloadExport(jobId string) (io.ReadCloser, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/files/export/"+jobId+"/download", "", ""); err != nil {
+		return nil, err
+	} else {
+		c.fillInExtraProperties(r)
+		return r.Body, nil
+	}
+}
+
 func (c *Client) GetFile(url string, isFullUrl bool) (*Result, *AppError) {
 	var rq *http.Request
 	if isFullUrl {
@@ -1359,6 +1690,70 @@ func (c *Client) RegisterApp(app *OAuthApp) (*Result, *AppError) {
 	}
 }
 
+// GetOAuthApps returns the apps registered by the current user, or every app if the current user is a system admin
+func (c *Client) GetOAuthApps() (*Result, *AppError) {
+	if r, err := c.DoApiGet("/oauth/list", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UpdateOAuthApp(app *OAuthApp) (*Result, *AppError) {
+	if r, err := c.DoApiPost("/oauth/"+app.Id+"/update", app.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) DeleteOAuthApp(id string) (*Result, *AppError) {
+	if r, err := c.DoApiPost("/oauth/"+id+"/delete", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+// RegenerateOAuthAppSecret gives an app a new secret which is only returned this once
+func (c *Client) RegenerateOAuthAppSecret(id string) (*Result, *AppError) {
+	if r, err := c.DoApiPost("/oauth/"+id+"/regen_secret", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppFromJson(r.Body)}, nil
+	}
+}
+
+// GetAuthorizedOAuthApps returns the apps that the current user has given access to their account
+func (c *Client) GetAuthorizedOAuthApps() (*Result, *AppError) {
+	if r, err := c.DoApiGet("/oauth/authorized", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppListFromJson(r.Body)}, nil
+	}
+}
+
+// DeauthorizeOAuthApp revokes the access that the current user has given to an app
+func (c *Client) DeauthorizeOAuthApp(id string) (*Result, *AppError) {
+	if r, err := c.DoApiPost("/oauth/"+id+"/deauthorize", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) AllowOAuth(rspType, clientId, redirect, scope, state string) (*Result, *AppError) {
 	if r, err := c.DoApiGet("/oauth/allow?response_type="+rspType+"&client_id="+clientId+"&redirect_uri="+url.QueryEscape(redirect)+"&scope="+scope+"&state="+url.QueryEscape(state), "", ""); err != nil {
 		return nil, err
@@ -1502,6 +1897,26 @@ func (c *Client) RegenOutgoingWebhookToken(id string) (*Result, *AppError) {
 	}
 }
 
+func (c *Client) ListOutgoingWebhookDeliveries(hookId string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/hooks/outgoing/"+hookId+"/deliveries", "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OutgoingWebhookDeliveryListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) RedeliverOutgoingWebhook(hookId string, deliveryId string) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/hooks/outgoing/"+hookId+"/deliveries/"+deliveryId+"/redeliver", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OutgoingWebhookDeliveryFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) MockSession(sessionToken string) {
 	c.AuthToken = sessionToken
 	c.AuthType = HEADER_BEARER
@@ -1597,3 +2012,37 @@ func (c *Client) DeleteEmoji(id string) (bool, *AppError) {
 func (c *Client) GetCustomEmojiImageUrl(id string) string {
 	return c.GetEmojiRoute() + "/" + id
 }
+
+// SaveReaction saves an emoji reaction for a post in the given channel. Returns the saved reaction if successful,
+// otherwise an error will be returned.
+func (c *Client) SaveReaction(channelId string, reaction *Reaction) (*Reaction, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/reactions/save", reaction.PostId), reaction.ToJson()); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return ReactionFromJson(r.Body), nil
+	}
+}
+
+// DeleteReaction removes an emoji reaction for a post in the given channel. Returns true if successful,
+// otherwise an error will be returned.
+func (c *Client) DeleteReaction(channelId string, reaction *Reaction) (bool, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/reactions/delete", reaction.PostId), reaction.ToJson()); err != nil {
+		return false, err
+	} else {
+		c.fillInExtraProperties(r)
+		return c.CheckStatusOK(r), nil
+	}
+}
+
+// ListReactions returns a list of all emoji reactions for a post in the given channel.
+func (c *Client) ListReactions(channelId string, postId string) ([]*Reaction, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/reactions", postId), "", ""); err != nil {
+		return nil, err
+	} else {
+		defer closeBody(r)
+		c.fillInExtraProperties(r)
+		return ReactionsFromJson(r.Body), nil
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	CLUSTER_EVENT_PUBLISH                             = "publish"
+	CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER           = "invalidate_cache_for_user"
+	CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL        = "invalidate_cache_for_channel"
+	CLUSTER_EVENT_REMOVE_USER_ACCESS_TOKEN_FROM_CACHE = "remove_user_access_token_from_cache"
+)
+
+type ClusterMessage struct {
+	Event string `json:"event"`
+	Data  string `json:"data"`
+}
+
+func (o *ClusterMessage) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ClusterMessageFromJson(data io.Reader) *ClusterMessage {
+	decoder := json.NewDecoder(data)
+	var o ClusterMessage
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
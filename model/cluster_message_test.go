@@ -0,0 +1,25 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterMessageJson(t *testing.T) {
+	m := NewMessage(NewId(), NewId(), NewId(), ACTION_POSTED)
+	cm := &ClusterMessage{Event: CLUSTER_EVENT_PUBLISH, Data: m.ToJson()}
+	json := cm.ToJson()
+	result := ClusterMessageFromJson(strings.NewReader(json))
+
+	if cm.Event != result.Event {
+		t.Fatal("Events do not match")
+	}
+
+	rm := MessageFromJson(strings.NewReader(result.Data))
+	if rm == nil || rm.ChannelId != m.ChannelId {
+		t.Fatal("Messages do not match")
+	}
+}
@@ -23,6 +23,7 @@ type Command struct {
 	UpdateAt         int64  `json:"update_at"`
 	DeleteAt         int64  `json:"delete_at"`
 	CreatorId        string `json:"creator_id"`
+	BotUserId        string `json:"bot_user_id"`
 	TeamId           string `json:"team_id"`
 	Trigger          string `json:"trigger"`
 	Method           string `json:"method"`
@@ -98,6 +99,10 @@ func (o *Command) IsValid() *AppError {
 		return NewLocAppError("Command.IsValid", "model.command.is_valid.user_id.app_error", nil, "")
 	}
 
+	if len(o.BotUserId) != 0 && len(o.BotUserId) != 26 {
+		return NewLocAppError("Command.IsValid", "model.command.is_valid.bot_user_id.app_error", nil, "")
+	}
+
 	if len(o.TeamId) != 26 {
 		return NewLocAppError("Command.IsValid", "model.command.is_valid.team_id.app_error", nil, "")
 	}
@@ -149,6 +154,7 @@ func (o *Command) PreUpdate() {
 func (o *Command) Sanitize() {
 	o.Token = ""
 	o.CreatorId = ""
+	o.BotUserId = ""
 	o.Method = ""
 	o.URL = ""
 	o.Username = ""
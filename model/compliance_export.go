@@ -0,0 +1,110 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+const (
+	COMPLIANCE_EXPORT_FORMAT_CSV         = "csv"
+	COMPLIANCE_EXPORT_FORMAT_ACTIANCE    = "actiance"
+	COMPLIANCE_EXPORT_FORMAT_GLOBALRELAY = "globalrelay"
+
+	COMPLIANCE_EVENT_MESSAGE = "message"
+	COMPLIANCE_EVENT_EDITED  = "edited"
+	COMPLIANCE_EVENT_DELETED = "deleted"
+	COMPLIANCE_EVENT_JOIN    = "join"
+	COMPLIANCE_EVENT_LEAVE   = "leave"
+)
+
+// ComplianceExport is the period covered by a compliance job. What happened in each channel during it is written a
+// ComplianceConversation at a time.
+type ComplianceExport struct {
+	StartAt int64
+	EndAt   int64
+}
+
+// ComplianceConversation is the history of a single channel during a compliance export, ordered by time
+type ComplianceConversation struct {
+	TeamName           string
+	TeamDisplayName    string
+	ChannelId          string
+	ChannelName        string
+	ChannelDisplayName string
+	ChannelType        string
+	Events             []*ComplianceEvent
+}
+
+// ComplianceEvent is a message being posted, edited or deleted, or a user joining or leaving a channel. For edits
+// the message is the new text of the post and the previous message is what it was changed from.
+type ComplianceEvent struct {
+	Type string
+	Time int64
+
+	UserId       string
+	UserUsername string
+	UserEmail    string
+	UserNickname string
+
+	PostId              string
+	PostRootId          string
+	PostMessage         string
+	PostPreviousMessage string
+	PostType            string
+	PostFiles           []string
+}
+
+type ComplianceParticipant struct {
+	UserId       string
+	UserUsername string
+	UserEmail    string
+	UserNickname string
+	JoinTime     int64
+	LeaveTime    int64
+}
+
+// Participants returns the users that took part in the conversation in the order that they first did so. A
+// participant's leave time is 0 if they were still in the channel at the end of the export.
+func (o *ComplianceConversation) Participants() []*ComplianceParticipant {
+	var participants []*ComplianceParticipant
+	byId := make(map[string]*ComplianceParticipant)
+
+	for _, event := range o.Events {
+		participant, ok := byId[event.UserId]
+		if !ok {
+			participant = &ComplianceParticipant{
+				UserId:       event.UserId,
+				UserUsername: event.UserUsername,
+				UserEmail:    event.UserEmail,
+				UserNickname: event.UserNickname,
+				JoinTime:     event.Time,
+			}
+			byId[event.UserId] = participant
+			participants = append(participants, participant)
+		}
+
+		if event.Type == COMPLIANCE_EVENT_LEAVE {
+			participant.LeaveTime = event.Time
+		} else if event.Type == COMPLIANCE_EVENT_JOIN {
+			participant.LeaveTime = 0
+		}
+	}
+
+	return participants
+}
+
+// StartTime returns the time of the first event in the conversation
+func (o *ComplianceConversation) StartTime() int64 {
+	if len(o.Events) == 0 {
+		return 0
+	}
+
+	return o.Events[0].Time
+}
+
+// EndTime returns the time of the last event in the conversation
+func (o *ComplianceConversation) EndTime() int64 {
+	if len(o.Events) == 0 {
+		return 0
+	}
+
+	return o.Events[len(o.Events)-1].Time
+}
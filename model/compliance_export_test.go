@@ -0,0 +1,42 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+)
+
+func TestComplianceConversationParticipants(t *testing.T) {
+	o := ComplianceConversation{
+		Events: []*ComplianceEvent{
+			{Type: COMPLIANCE_EVENT_JOIN, Time: 1, UserId: "user1"},
+			{Type: COMPLIANCE_EVENT_MESSAGE, Time: 2, UserId: "user2"},
+			{Type: COMPLIANCE_EVENT_LEAVE, Time: 3, UserId: "user1"},
+			{Type: COMPLIANCE_EVENT_LEAVE, Time: 4, UserId: "user2"},
+			{Type: COMPLIANCE_EVENT_JOIN, Time: 5, UserId: "user2"},
+		},
+	}
+
+	participants := o.Participants()
+	if len(participants) != 2 {
+		t.Fatal("should've had 2 participants")
+	}
+
+	if participants[0].UserId != "user1" || participants[0].JoinTime != 1 || participants[0].LeaveTime != 3 {
+		t.Fatal("first participant is wrong")
+	}
+
+	if participants[1].UserId != "user2" || participants[1].JoinTime != 2 || participants[1].LeaveTime != 0 {
+		t.Fatal("second participant should still be in the channel")
+	}
+
+	if o.StartTime() != 1 || o.EndTime() != 5 {
+		t.Fatal("wrong start or end time")
+	}
+
+	empty := ComplianceConversation{}
+	if len(empty.Participants()) != 0 || empty.StartTime() != 0 || empty.EndTime() != 0 {
+		t.Fatal("empty conversation should have no participants")
+	}
+}
@@ -14,10 +14,13 @@ type CompliancePost struct {
 	TeamDisplayName string
 
 	// From Channel
+	ChannelId          string
 	ChannelName        string
 	ChannelDisplayName string
+	ChannelType        string
 
 	// From User
+	UserId       string
 	UserUsername string
 	UserEmail    string
 	UserNickname string
@@ -35,6 +38,7 @@ type CompliancePost struct {
 	PostProps      string
 	PostHashtags   string
 	PostFilenames  string
+	PostFileIds    string
 }
 
 func CompliancePostHeader() []string {
@@ -6,6 +6,8 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"net"
+	"regexp"
 )
 
 const (
@@ -22,6 +24,13 @@ const (
 	SERVICE_GITLAB = "gitlab"
 	SERVICE_GOOGLE = "google"
 
+	OPENID_SETTINGS_DEFAULT_SCOPE            = "openid profile email"
+	OPENID_SETTINGS_DEFAULT_ID_CLAIM         = "sub"
+	OPENID_SETTINGS_DEFAULT_USERNAME_CLAIM   = "preferred_username"
+	OPENID_SETTINGS_DEFAULT_EMAIL_CLAIM      = "email"
+	OPENID_SETTINGS_DEFAULT_FIRST_NAME_CLAIM = "given_name"
+	OPENID_SETTINGS_DEFAULT_LAST_NAME_CLAIM  = "family_name"
+
 	WEBSERVER_MODE_REGULAR  = "regular"
 	WEBSERVER_MODE_GZIP     = "gzip"
 	WEBSERVER_MODE_DISABLED = "disabled"
@@ -65,6 +74,7 @@ type ServiceSettings struct {
 	WebserverMode                     *string
 	EnableCustomEmoji                 *bool
 	RestrictCustomEmojiCreation       *string
+	EnableUserAccessTokens            *bool
 }
 
 type SSOSettings struct {
@@ -77,6 +87,51 @@ type SSOSettings struct {
 	UserApiEndpoint string
 }
 
+type SamlSettings struct {
+	// Basic
+	Enable                      *bool
+	IdpUrl                      *string
+	IdpDescriptorUrl            *string
+	AssertionConsumerServiceURL *string
+
+	// Certificates
+	IdpCertificateFile    *string
+	PublicCertificateFile *string
+	PrivateKeyFile        *string
+
+	// User Mapping
+	IdAttribute        *string
+	EmailAttribute     *string
+	UsernameAttribute  *string
+	FirstNameAttribute *string
+	LastNameAttribute  *string
+	NicknameAttribute  *string
+	LocaleAttribute    *string
+
+	// Customization
+	LoginButtonText *string
+
+	// Security
+	RelayStateSalt *string
+}
+
+// OpenIdSettings configures an OpenID Connect identity provider. Its Name is used in the login urls and as the
+// AuthService of the users that sign in with it, so several providers can be configured side by side.
+type OpenIdSettings struct {
+	Name              string
+	Enable            bool
+	ButtonText        string
+	DiscoveryEndpoint string
+	ClientId          string
+	Secret            string
+	Scope             string
+	IdClaim           string
+	UsernameClaim     string
+	EmailClaim        string
+	FirstNameClaim    string
+	LastNameClaim     string
+}
+
 type SqlSettings struct {
 	DriverName         string
 	DataSource         string
@@ -118,6 +173,12 @@ type FileSettings struct {
 	AmazonS3BucketEndpoint     string
 	AmazonS3LocationConstraint *bool
 	AmazonS3LowercaseBucket    *bool
+	MaxImageWorkers            *int
+	EnableContentExtraction    *bool
+	MaxContentWorkers          *int
+	MaxContentExtractionSize   *int64
+	MaxStoragePerUser          *int64
+	MaxStoragePerTeam          *int64
 }
 
 type EmailSettings struct {
@@ -208,9 +269,10 @@ type LdapSettings struct {
 }
 
 type ComplianceSettings struct {
-	Enable      *bool
-	Directory   *string
-	EnableDaily *bool
+	Enable       *bool
+	Directory    *string
+	EnableDaily  *bool
+	ExportFormat *string
 }
 
 type LocalizationSettings struct {
@@ -219,21 +281,51 @@ type LocalizationSettings struct {
 	AvailableLocales    *string
 }
 
+type ClusterSettings struct {
+	Enable                 *bool
+	InterNodeListenAddress *string
+	InterNodeUrls          []string
+	InterNodeSecret        *string
+}
+
+// BleveSettings configures the built-in search index. Each server keeps its own index of the posts that it saved, so
+// it can only be used by a single server and not when clustering is enabled.
+type BleveSettings struct {
+	IndexDir        *string
+	EnableIndexing  *bool
+	EnableSearching *bool
+}
+
+type DataRetentionSettings struct {
+	Enable                      *bool
+	DryRun                      *bool
+	MessageRetentionDays        *int
+	TeamMessageRetentionDays    map[string]int
+	ChannelMessageRetentionDays map[string]int
+	AuditRetentionDays          *int
+	BatchSize                   *int
+}
+
 type Config struct {
-	ServiceSettings      ServiceSettings
-	TeamSettings         TeamSettings
-	SqlSettings          SqlSettings
-	LogSettings          LogSettings
-	FileSettings         FileSettings
-	EmailSettings        EmailSettings
-	RateLimitSettings    RateLimitSettings
-	PrivacySettings      PrivacySettings
-	SupportSettings      SupportSettings
-	GitLabSettings       SSOSettings
-	GoogleSettings       SSOSettings
-	LdapSettings         LdapSettings
-	ComplianceSettings   ComplianceSettings
-	LocalizationSettings LocalizationSettings
+	ServiceSettings       ServiceSettings
+	TeamSettings          TeamSettings
+	SqlSettings           SqlSettings
+	LogSettings           LogSettings
+	FileSettings          FileSettings
+	EmailSettings         EmailSettings
+	RateLimitSettings     RateLimitSettings
+	PrivacySettings       PrivacySettings
+	SupportSettings       SupportSettings
+	GitLabSettings        SSOSettings
+	GoogleSettings        SSOSettings
+	OpenIdSettings        []*OpenIdSettings
+	LdapSettings          LdapSettings
+	SamlSettings          SamlSettings
+	ComplianceSettings    ComplianceSettings
+	LocalizationSettings  LocalizationSettings
+	ClusterSettings       ClusterSettings
+	BleveSettings         BleveSettings
+	DataRetentionSettings DataRetentionSettings
 }
 
 func (o *Config) ToJson() string {
@@ -256,6 +348,17 @@ func (o *Config) GetSSOService(service string) *SSOSettings {
 	return nil
 }
 
+// GetOpenIdService returns the settings for the OpenID Connect provider with the given name, if there is one
+func (o *Config) GetOpenIdService(service string) *OpenIdSettings {
+	for _, settings := range o.OpenIdSettings {
+		if settings.Name == service {
+			return settings
+		}
+	}
+
+	return nil
+}
+
 func ConfigFromJson(data io.Reader) *Config {
 	decoder := json.NewDecoder(data)
 	var o Config
@@ -292,6 +395,36 @@ func (o *Config) SetDefaults() {
 		*o.FileSettings.AmazonS3LowercaseBucket = false
 	}
 
+	if o.FileSettings.MaxImageWorkers == nil {
+		o.FileSettings.MaxImageWorkers = new(int)
+		*o.FileSettings.MaxImageWorkers = 4
+	}
+
+	if o.FileSettings.EnableContentExtraction == nil {
+		o.FileSettings.EnableContentExtraction = new(bool)
+		*o.FileSettings.EnableContentExtraction = true
+	}
+
+	if o.FileSettings.MaxContentWorkers == nil {
+		o.FileSettings.MaxContentWorkers = new(int)
+		*o.FileSettings.MaxContentWorkers = 2
+	}
+
+	if o.FileSettings.MaxContentExtractionSize == nil {
+		o.FileSettings.MaxContentExtractionSize = new(int64)
+		*o.FileSettings.MaxContentExtractionSize = 10485760
+	}
+
+	if o.FileSettings.MaxStoragePerUser == nil {
+		o.FileSettings.MaxStoragePerUser = new(int64)
+		*o.FileSettings.MaxStoragePerUser = 0
+	}
+
+	if o.FileSettings.MaxStoragePerTeam == nil {
+		o.FileSettings.MaxStoragePerTeam = new(int64)
+		*o.FileSettings.MaxStoragePerTeam = 0
+	}
+
 	if len(o.EmailSettings.InviteSalt) == 0 {
 		o.EmailSettings.InviteSalt = NewRandomString(32)
 	}
@@ -570,6 +703,11 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.RestrictCustomEmojiCreation = RESTRICT_EMOJI_CREATION_ALL
 	}
 
+	if o.ServiceSettings.EnableUserAccessTokens == nil {
+		o.ServiceSettings.EnableUserAccessTokens = new(bool)
+		*o.ServiceSettings.EnableUserAccessTokens = false
+	}
+
 	if o.ComplianceSettings.Enable == nil {
 		o.ComplianceSettings.Enable = new(bool)
 		*o.ComplianceSettings.Enable = false
@@ -585,6 +723,11 @@ func (o *Config) SetDefaults() {
 		*o.ComplianceSettings.EnableDaily = false
 	}
 
+	if o.ComplianceSettings.ExportFormat == nil {
+		o.ComplianceSettings.ExportFormat = new(string)
+		*o.ComplianceSettings.ExportFormat = COMPLIANCE_EXPORT_FORMAT_CSV
+	}
+
 	if o.LocalizationSettings.DefaultServerLocale == nil {
 		o.LocalizationSettings.DefaultServerLocale = new(string)
 		*o.LocalizationSettings.DefaultServerLocale = DEFAULT_LOCALE
@@ -599,6 +742,191 @@ func (o *Config) SetDefaults() {
 		o.LocalizationSettings.AvailableLocales = new(string)
 		*o.LocalizationSettings.AvailableLocales = ""
 	}
+
+	if o.ClusterSettings.Enable == nil {
+		o.ClusterSettings.Enable = new(bool)
+		*o.ClusterSettings.Enable = false
+	}
+
+	if o.ClusterSettings.InterNodeListenAddress == nil {
+		o.ClusterSettings.InterNodeListenAddress = new(string)
+		*o.ClusterSettings.InterNodeListenAddress = "127.0.0.1:8075"
+	}
+
+	if o.ClusterSettings.InterNodeUrls == nil {
+		o.ClusterSettings.InterNodeUrls = []string{}
+	}
+
+	if o.ClusterSettings.InterNodeSecret == nil {
+		o.ClusterSettings.InterNodeSecret = new(string)
+		*o.ClusterSettings.InterNodeSecret = ""
+	}
+
+	if o.BleveSettings.IndexDir == nil {
+		o.BleveSettings.IndexDir = new(string)
+		*o.BleveSettings.IndexDir = "./data/bleve/"
+	}
+
+	if o.BleveSettings.EnableIndexing == nil {
+		o.BleveSettings.EnableIndexing = new(bool)
+		*o.BleveSettings.EnableIndexing = false
+	}
+
+	if o.BleveSettings.EnableSearching == nil {
+		o.BleveSettings.EnableSearching = new(bool)
+		*o.BleveSettings.EnableSearching = false
+	}
+
+	if o.DataRetentionSettings.Enable == nil {
+		o.DataRetentionSettings.Enable = new(bool)
+		*o.DataRetentionSettings.Enable = false
+	}
+
+	if o.DataRetentionSettings.DryRun == nil {
+		o.DataRetentionSettings.DryRun = new(bool)
+		*o.DataRetentionSettings.DryRun = true
+	}
+
+	if o.DataRetentionSettings.MessageRetentionDays == nil {
+		o.DataRetentionSettings.MessageRetentionDays = new(int)
+		*o.DataRetentionSettings.MessageRetentionDays = 0
+	}
+
+	if o.DataRetentionSettings.TeamMessageRetentionDays == nil {
+		o.DataRetentionSettings.TeamMessageRetentionDays = map[string]int{}
+	}
+
+	if o.DataRetentionSettings.ChannelMessageRetentionDays == nil {
+		o.DataRetentionSettings.ChannelMessageRetentionDays = map[string]int{}
+	}
+
+	if o.DataRetentionSettings.AuditRetentionDays == nil {
+		o.DataRetentionSettings.AuditRetentionDays = new(int)
+		*o.DataRetentionSettings.AuditRetentionDays = 0
+	}
+
+	if o.DataRetentionSettings.BatchSize == nil {
+		o.DataRetentionSettings.BatchSize = new(int)
+		*o.DataRetentionSettings.BatchSize = 1000
+	}
+
+	if o.SamlSettings.Enable == nil {
+		o.SamlSettings.Enable = new(bool)
+		*o.SamlSettings.Enable = false
+	}
+
+	if o.SamlSettings.IdpUrl == nil {
+		o.SamlSettings.IdpUrl = new(string)
+		*o.SamlSettings.IdpUrl = ""
+	}
+
+	if o.SamlSettings.IdpDescriptorUrl == nil {
+		o.SamlSettings.IdpDescriptorUrl = new(string)
+		*o.SamlSettings.IdpDescriptorUrl = ""
+	}
+
+	if o.SamlSettings.AssertionConsumerServiceURL == nil {
+		o.SamlSettings.AssertionConsumerServiceURL = new(string)
+		*o.SamlSettings.AssertionConsumerServiceURL = ""
+	}
+
+	if o.SamlSettings.IdpCertificateFile == nil {
+		o.SamlSettings.IdpCertificateFile = new(string)
+		*o.SamlSettings.IdpCertificateFile = ""
+	}
+
+	if o.SamlSettings.PublicCertificateFile == nil {
+		o.SamlSettings.PublicCertificateFile = new(string)
+		*o.SamlSettings.PublicCertificateFile = ""
+	}
+
+	if o.SamlSettings.PrivateKeyFile == nil {
+		o.SamlSettings.PrivateKeyFile = new(string)
+		*o.SamlSettings.PrivateKeyFile = ""
+	}
+
+	if o.SamlSettings.IdAttribute == nil {
+		o.SamlSettings.IdAttribute = new(string)
+		*o.SamlSettings.IdAttribute = ""
+	}
+
+	if o.SamlSettings.EmailAttribute == nil {
+		o.SamlSettings.EmailAttribute = new(string)
+		*o.SamlSettings.EmailAttribute = ""
+	}
+
+	if o.SamlSettings.UsernameAttribute == nil {
+		o.SamlSettings.UsernameAttribute = new(string)
+		*o.SamlSettings.UsernameAttribute = ""
+	}
+
+	if o.SamlSettings.FirstNameAttribute == nil {
+		o.SamlSettings.FirstNameAttribute = new(string)
+		*o.SamlSettings.FirstNameAttribute = ""
+	}
+
+	if o.SamlSettings.LastNameAttribute == nil {
+		o.SamlSettings.LastNameAttribute = new(string)
+		*o.SamlSettings.LastNameAttribute = ""
+	}
+
+	if o.SamlSettings.NicknameAttribute == nil {
+		o.SamlSettings.NicknameAttribute = new(string)
+		*o.SamlSettings.NicknameAttribute = ""
+	}
+
+	if o.SamlSettings.LocaleAttribute == nil {
+		o.SamlSettings.LocaleAttribute = new(string)
+		*o.SamlSettings.LocaleAttribute = ""
+	}
+
+	if o.SamlSettings.LoginButtonText == nil {
+		o.SamlSettings.LoginButtonText = new(string)
+		*o.SamlSettings.LoginButtonText = USER_AUTH_SERVICE_SAML_TEXT
+	}
+
+	if o.SamlSettings.RelayStateSalt == nil || len(*o.SamlSettings.RelayStateSalt) == 0 {
+		o.SamlSettings.RelayStateSalt = new(string)
+		*o.SamlSettings.RelayStateSalt = NewRandomString(32)
+	}
+
+	if o.OpenIdSettings == nil {
+		o.OpenIdSettings = []*OpenIdSettings{}
+	}
+
+	for _, settings := range o.OpenIdSettings {
+		settings.SetDefaults()
+	}
+}
+
+func (s *OpenIdSettings) SetDefaults() {
+	if len(s.Scope) == 0 {
+		s.Scope = OPENID_SETTINGS_DEFAULT_SCOPE
+	}
+
+	if len(s.IdClaim) == 0 {
+		s.IdClaim = OPENID_SETTINGS_DEFAULT_ID_CLAIM
+	}
+
+	if len(s.UsernameClaim) == 0 {
+		s.UsernameClaim = OPENID_SETTINGS_DEFAULT_USERNAME_CLAIM
+	}
+
+	if len(s.EmailClaim) == 0 {
+		s.EmailClaim = OPENID_SETTINGS_DEFAULT_EMAIL_CLAIM
+	}
+
+	if len(s.FirstNameClaim) == 0 {
+		s.FirstNameClaim = OPENID_SETTINGS_DEFAULT_FIRST_NAME_CLAIM
+	}
+
+	if len(s.LastNameClaim) == 0 {
+		s.LastNameClaim = OPENID_SETTINGS_DEFAULT_LAST_NAME_CLAIM
+	}
+
+	if len(s.ButtonText) == 0 {
+		s.ButtonText = s.Name
+	}
 }
 
 func (o *Config) IsValid() *AppError {
@@ -643,6 +971,26 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_file_size.app_error", nil, "")
 	}
 
+	if *o.FileSettings.MaxImageWorkers <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_image_workers.app_error", nil, "")
+	}
+
+	if *o.FileSettings.MaxContentWorkers <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_content_workers.app_error", nil, "")
+	}
+
+	if *o.FileSettings.MaxContentExtractionSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_content_extraction_size.app_error", nil, "")
+	}
+
+	if *o.FileSettings.MaxStoragePerUser < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_storage_per_user.app_error", nil, "")
+	}
+
+	if *o.FileSettings.MaxStoragePerTeam < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_storage_per_team.app_error", nil, "")
+	}
+
 	if !(o.FileSettings.DriverName == IMAGE_DRIVER_LOCAL || o.FileSettings.DriverName == IMAGE_DRIVER_S3) {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_driver.app_error", nil, "")
 	}
@@ -703,6 +1051,125 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.ldap_sync_interval.app_error", nil, "")
 	}
 
+	if *o.ClusterSettings.Enable {
+		// the address has to name an interface so that the cluster isn't reachable from everywhere by accident
+		if host, _, err := net.SplitHostPort(*o.ClusterSettings.InterNodeListenAddress); err != nil || len(host) == 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_listen_address.app_error", nil, "")
+		}
+
+		if len(*o.ClusterSettings.InterNodeSecret) < 32 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_secret.app_error", nil, "")
+		}
+	}
+
+	if *o.BleveSettings.EnableIndexing && len(*o.BleveSettings.IndexDir) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.bleve_index_dir.app_error", nil, "")
+	}
+
+	if *o.BleveSettings.EnableSearching && !*o.BleveSettings.EnableIndexing {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.bleve_search.app_error", nil, "")
+	}
+
+	if *o.BleveSettings.EnableIndexing && *o.ClusterSettings.Enable {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.bleve_cluster.app_error", nil, "")
+	}
+
+	if *o.DataRetentionSettings.MessageRetentionDays < 0 || *o.DataRetentionSettings.AuditRetentionDays < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention_days.app_error", nil, "")
+	}
+
+	for _, days := range o.DataRetentionSettings.TeamMessageRetentionDays {
+		if days < 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention_days.app_error", nil, "")
+		}
+	}
+
+	for _, days := range o.DataRetentionSettings.ChannelMessageRetentionDays {
+		if days < 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention_days.app_error", nil, "")
+		}
+	}
+
+	if *o.DataRetentionSettings.BatchSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention_batch_size.app_error", nil, "")
+	}
+
+	if *o.SamlSettings.Enable {
+		if len(*o.SamlSettings.IdpUrl) == 0 || !IsValidHttpUrl(*o.SamlSettings.IdpUrl) {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.saml_idp_url.app_error", nil, "")
+		}
+
+		if len(*o.SamlSettings.IdpDescriptorUrl) == 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.saml_idp_descriptor_url.app_error", nil, "")
+		}
+
+		if len(*o.SamlSettings.AssertionConsumerServiceURL) == 0 || !IsValidHttpUrl(*o.SamlSettings.AssertionConsumerServiceURL) {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.saml_assertion_consumer_service_url.app_error", nil, "")
+		}
+
+		if len(*o.SamlSettings.IdpCertificateFile) == 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.saml_idp_cert.app_error", nil, "")
+		}
+
+		if len(*o.SamlSettings.PublicCertificateFile) == 0 || len(*o.SamlSettings.PrivateKeyFile) == 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.saml_sp_cert.app_error", nil, "")
+		}
+
+		if len(*o.SamlSettings.EmailAttribute) == 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.saml_email_attribute.app_error", nil, "")
+		}
+	}
+
+	if len(*o.SamlSettings.RelayStateSalt) < 32 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.saml_relay_state_salt.app_error", nil, "")
+	}
+
+	names := make(map[string]bool)
+	for _, settings := range o.OpenIdSettings {
+		if err := settings.isValid(); err != nil {
+			return err
+		}
+
+		if names[settings.Name] {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.openid_name.app_error", nil, "name="+settings.Name)
+		}
+		names[settings.Name] = true
+	}
+
+	return nil
+}
+
+var validOpenIdName = regexp.MustCompile(`^[a-z]{1,32}$`)
+
+// reservedOpenIdNames are the auth services that an OpenID Connect provider's name can't clash with
+var reservedOpenIdNames = []string{
+	USER_AUTH_SERVICE_EMAIL,
+	USER_AUTH_SERVICE_USERNAME,
+	USER_AUTH_SERVICE_LDAP,
+	USER_AUTH_SERVICE_SAML,
+	SERVICE_GITLAB,
+	SERVICE_GOOGLE,
+}
+
+func (s *OpenIdSettings) isValid() *AppError {
+	if !validOpenIdName.MatchString(s.Name) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.openid_name.app_error", nil, "name="+s.Name)
+	}
+
+	for _, reserved := range reservedOpenIdNames {
+		if s.Name == reserved {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.openid_name.app_error", nil, "name="+s.Name)
+		}
+	}
+
+	if s.Enable && len(s.DiscoveryEndpoint) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.openid_discovery_endpoint.app_error", nil, "name="+s.Name)
+	}
+
+	if s.Enable && len(s.ClientId) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.openid_client_id.app_error", nil, "name="+s.Name)
+	}
+
 	return nil
 }
 
@@ -726,6 +1193,9 @@ func (o *Config) Sanitize() {
 
 	o.EmailSettings.InviteSalt = FAKE_SETTING
 	o.EmailSettings.PasswordResetSalt = FAKE_SETTING
+	if o.SamlSettings.RelayStateSalt != nil {
+		*o.SamlSettings.RelayStateSalt = FAKE_SETTING
+	}
 	if len(o.EmailSettings.SMTPPassword) > 0 {
 		o.EmailSettings.SMTPPassword = FAKE_SETTING
 	}
@@ -734,9 +1204,19 @@ func (o *Config) Sanitize() {
 		o.GitLabSettings.Secret = FAKE_SETTING
 	}
 
+	for _, settings := range o.OpenIdSettings {
+		if len(settings.Secret) > 0 {
+			settings.Secret = FAKE_SETTING
+		}
+	}
+
 	o.SqlSettings.DataSource = FAKE_SETTING
 	o.SqlSettings.AtRestEncryptKey = FAKE_SETTING
 
+	if o.ClusterSettings.InterNodeSecret != nil && len(*o.ClusterSettings.InterNodeSecret) > 0 {
+		*o.ClusterSettings.InterNodeSecret = FAKE_SETTING
+	}
+
 	for i := range o.SqlSettings.DataSourceReplicas {
 		o.SqlSettings.DataSourceReplicas[i] = FAKE_SETTING
 	}
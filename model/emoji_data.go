@@ -0,0 +1,912 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+// SystemEmojis holds the names of the emoji built into the web app, from webapp/utils/emoji.json
+var SystemEmojis = map[string]bool{
+	"+1":                              true,
+	"-1":                              true,
+	"100":                             true,
+	"1234":                            true,
+	"8ball":                           true,
+	"a":                               true,
+	"ab":                              true,
+	"abc":                             true,
+	"abcd":                            true,
+	"accept":                          true,
+	"aerial_tramway":                  true,
+	"airplane":                        true,
+	"alarm_clock":                     true,
+	"alien":                           true,
+	"ambulance":                       true,
+	"anchor":                          true,
+	"angel":                           true,
+	"anger":                           true,
+	"angry":                           true,
+	"anguished":                       true,
+	"ant":                             true,
+	"apple":                           true,
+	"aquarius":                        true,
+	"aries":                           true,
+	"arrow_backward":                  true,
+	"arrow_double_down":               true,
+	"arrow_double_up":                 true,
+	"arrow_down":                      true,
+	"arrow_down_small":                true,
+	"arrow_forward":                   true,
+	"arrow_heading_down":              true,
+	"arrow_heading_up":                true,
+	"arrow_left":                      true,
+	"arrow_lower_left":                true,
+	"arrow_lower_right":               true,
+	"arrow_right":                     true,
+	"arrow_right_hook":                true,
+	"arrow_up":                        true,
+	"arrow_up_down":                   true,
+	"arrow_up_small":                  true,
+	"arrow_upper_left":                true,
+	"arrow_upper_right":               true,
+	"arrows_clockwise":                true,
+	"arrows_counterclockwise":         true,
+	"art":                             true,
+	"articulated_lorry":               true,
+	"astonished":                      true,
+	"athletic_shoe":                   true,
+	"atm":                             true,
+	"b":                               true,
+	"baby":                            true,
+	"baby_bottle":                     true,
+	"baby_chick":                      true,
+	"baby_symbol":                     true,
+	"back":                            true,
+	"baggage_claim":                   true,
+	"balloon":                         true,
+	"ballot_box_with_check":           true,
+	"bamboo":                          true,
+	"banana":                          true,
+	"bangbang":                        true,
+	"bank":                            true,
+	"bar_chart":                       true,
+	"barber":                          true,
+	"baseball":                        true,
+	"basecamp":                        true,
+	"basecampy":                       true,
+	"basketball":                      true,
+	"bath":                            true,
+	"bathtub":                         true,
+	"battery":                         true,
+	"bear":                            true,
+	"bee":                             true,
+	"beer":                            true,
+	"beers":                           true,
+	"beetle":                          true,
+	"beginner":                        true,
+	"bell":                            true,
+	"bento":                           true,
+	"bicyclist":                       true,
+	"bike":                            true,
+	"bikini":                          true,
+	"bird":                            true,
+	"birthday":                        true,
+	"black_circle":                    true,
+	"black_joker":                     true,
+	"black_large_square":              true,
+	"black_medium_small_square":       true,
+	"black_medium_square":             true,
+	"black_nib":                       true,
+	"black_small_square":              true,
+	"black_square_button":             true,
+	"blossom":                         true,
+	"blowfish":                        true,
+	"blue_book":                       true,
+	"blue_car":                        true,
+	"blue_heart":                      true,
+	"blush":                           true,
+	"boar":                            true,
+	"boat":                            true,
+	"bomb":                            true,
+	"book":                            true,
+	"bookmark":                        true,
+	"bookmark_tabs":                   true,
+	"books":                           true,
+	"boom":                            true,
+	"boot":                            true,
+	"bouquet":                         true,
+	"bow":                             true,
+	"bowling":                         true,
+	"bowtie":                          true,
+	"boy":                             true,
+	"bread":                           true,
+	"bride_with_veil":                 true,
+	"bridge_at_night":                 true,
+	"briefcase":                       true,
+	"broken_heart":                    true,
+	"bug":                             true,
+	"bulb":                            true,
+	"bullettrain_front":               true,
+	"bullettrain_side":                true,
+	"bus":                             true,
+	"busstop":                         true,
+	"bust_in_silhouette":              true,
+	"busts_in_silhouette":             true,
+	"ca":                              true,
+	"cactus":                          true,
+	"cake":                            true,
+	"calendar":                        true,
+	"calling":                         true,
+	"camel":                           true,
+	"camera":                          true,
+	"cancer":                          true,
+	"candy":                           true,
+	"capital_abcd":                    true,
+	"capricorn":                       true,
+	"car":                             true,
+	"card_index":                      true,
+	"carousel_horse":                  true,
+	"cat":                             true,
+	"cat2":                            true,
+	"cd":                              true,
+	"chart":                           true,
+	"chart_with_downwards_trend":      true,
+	"chart_with_upwards_trend":        true,
+	"checkered_flag":                  true,
+	"cherries":                        true,
+	"cherry_blossom":                  true,
+	"chestnut":                        true,
+	"chicken":                         true,
+	"children_crossing":               true,
+	"chocolate_bar":                   true,
+	"christmas_tree":                  true,
+	"church":                          true,
+	"cinema":                          true,
+	"circus_tent":                     true,
+	"city_sunrise":                    true,
+	"city_sunset":                     true,
+	"cl":                              true,
+	"clap":                            true,
+	"clapper":                         true,
+	"clipboard":                       true,
+	"clock1":                          true,
+	"clock10":                         true,
+	"clock1030":                       true,
+	"clock11":                         true,
+	"clock1130":                       true,
+	"clock12":                         true,
+	"clock1230":                       true,
+	"clock130":                        true,
+	"clock2":                          true,
+	"clock230":                        true,
+	"clock3":                          true,
+	"clock330":                        true,
+	"clock4":                          true,
+	"clock430":                        true,
+	"clock5":                          true,
+	"clock530":                        true,
+	"clock6":                          true,
+	"clock630":                        true,
+	"clock7":                          true,
+	"clock730":                        true,
+	"clock8":                          true,
+	"clock830":                        true,
+	"clock9":                          true,
+	"clock930":                        true,
+	"closed_book":                     true,
+	"closed_lock_with_key":            true,
+	"closed_umbrella":                 true,
+	"cloud":                           true,
+	"clubs":                           true,
+	"cn":                              true,
+	"cocktail":                        true,
+	"coffee":                          true,
+	"cold_sweat":                      true,
+	"collision":                       true,
+	"computer":                        true,
+	"confetti_ball":                   true,
+	"confounded":                      true,
+	"confused":                        true,
+	"congratulations":                 true,
+	"construction":                    true,
+	"construction_worker":             true,
+	"convenience_store":               true,
+	"cookie":                          true,
+	"cool":                            true,
+	"cop":                             true,
+	"copyright":                       true,
+	"corn":                            true,
+	"couple":                          true,
+	"couple_with_heart":               true,
+	"couplekiss":                      true,
+	"cow":                             true,
+	"cow2":                            true,
+	"credit_card":                     true,
+	"crescent_moon":                   true,
+	"crocodile":                       true,
+	"crossed_flags":                   true,
+	"crown":                           true,
+	"cry":                             true,
+	"crying_cat_face":                 true,
+	"crystal_ball":                    true,
+	"cupid":                           true,
+	"curly_loop":                      true,
+	"currency_exchange":               true,
+	"curry":                           true,
+	"custard":                         true,
+	"customs":                         true,
+	"cyclone":                         true,
+	"dancer":                          true,
+	"dancers":                         true,
+	"dango":                           true,
+	"dart":                            true,
+	"dash":                            true,
+	"date":                            true,
+	"de":                              true,
+	"deciduous_tree":                  true,
+	"department_store":                true,
+	"diamond_shape_with_a_dot_inside": true,
+	"diamonds":                        true,
+	"disappointed":                    true,
+	"disappointed_relieved":           true,
+	"dizzy":                           true,
+	"dizzy_face":                      true,
+	"do_not_litter":                   true,
+	"dog":                             true,
+	"dog2":                            true,
+	"dollar":                          true,
+	"dolls":                           true,
+	"dolphin":                         true,
+	"door":                            true,
+	"doughnut":                        true,
+	"dragon":                          true,
+	"dragon_face":                     true,
+	"dress":                           true,
+	"dromedary_camel":                 true,
+	"droplet":                         true,
+	"dvd":                             true,
+	"e-mail":                          true,
+	"ear":                             true,
+	"ear_of_rice":                     true,
+	"earth_africa":                    true,
+	"earth_americas":                  true,
+	"earth_asia":                      true,
+	"egg":                             true,
+	"eggplant":                        true,
+	"eh":                              true,
+	"eight":                           true,
+	"eight_pointed_black_star":        true,
+	"eight_spoked_asterisk":           true,
+	"electric_plug":                   true,
+	"elephant":                        true,
+	"email":                           true,
+	"end":                             true,
+	"envelope":                        true,
+	"envelope_with_arrow":             true,
+	"es":                              true,
+	"euro":                            true,
+	"european_castle":                 true,
+	"european_post_office":            true,
+	"evergreen_tree":                  true,
+	"exclamation":                     true,
+	"expressionless":                  true,
+	"eyeglasses":                      true,
+	"eyes":                            true,
+	"facepunch":                       true,
+	"factory":                         true,
+	"fallen_leaf":                     true,
+	"family":                          true,
+	"fast_forward":                    true,
+	"fax":                             true,
+	"fearful":                         true,
+	"feelsgood":                       true,
+	"feet":                            true,
+	"ferris_wheel":                    true,
+	"file_folder":                     true,
+	"finnadie":                        true,
+	"fire":                            true,
+	"fire_engine":                     true,
+	"fireworks":                       true,
+	"first_quarter_moon":              true,
+	"first_quarter_moon_with_face":    true,
+	"fish":                            true,
+	"fish_cake":                       true,
+	"fishing_pole_and_fish":           true,
+	"fist":                            true,
+	"five":                            true,
+	"flags":                           true,
+	"flashlight":                      true,
+	"flipper":                         true,
+	"floppy_disk":                     true,
+	"flower_playing_cards":            true,
+	"flushed":                         true,
+	"foggy":                           true,
+	"football":                        true,
+	"footprints":                      true,
+	"fork_and_knife":                  true,
+	"fountain":                        true,
+	"four":                            true,
+	"four_leaf_clover":                true,
+	"fr":                              true,
+	"free":                            true,
+	"fried_shrimp":                    true,
+	"fries":                           true,
+	"frog":                            true,
+	"frowning":                        true,
+	"fu":                              true,
+	"fuelpump":                        true,
+	"full_moon":                       true,
+	"full_moon_with_face":             true,
+	"game_die":                        true,
+	"gb":                              true,
+	"gem":                             true,
+	"gemini":                          true,
+	"ghost":                           true,
+	"gift":                            true,
+	"gift_heart":                      true,
+	"girl":                            true,
+	"globe_with_meridians":            true,
+	"goat":                            true,
+	"goberserk":                       true,
+	"godmode":                         true,
+	"golf":                            true,
+	"grapes":                          true,
+	"green_apple":                     true,
+	"green_book":                      true,
+	"green_heart":                     true,
+	"grey_exclamation":                true,
+	"grey_question":                   true,
+	"grimacing":                       true,
+	"grin":                            true,
+	"grinning":                        true,
+	"guardsman":                       true,
+	"guitar":                          true,
+	"gun":                             true,
+	"haircut":                         true,
+	"hamburger":                       true,
+	"hammer":                          true,
+	"hamster":                         true,
+	"hand":                            true,
+	"handbag":                         true,
+	"hankey":                          true,
+	"hash":                            true,
+	"hatched_chick":                   true,
+	"hatching_chick":                  true,
+	"headphones":                      true,
+	"hear_no_evil":                    true,
+	"heart":                           true,
+	"heart_decoration":                true,
+	"heart_eyes":                      true,
+	"heart_eyes_cat":                  true,
+	"heartbeat":                       true,
+	"heartpulse":                      true,
+	"hearts":                          true,
+	"heavy_check_mark":                true,
+	"heavy_division_sign":             true,
+	"heavy_dollar_sign":               true,
+	"heavy_exclamation_mark":          true,
+	"heavy_minus_sign":                true,
+	"heavy_multiplication_x":          true,
+	"heavy_plus_sign":                 true,
+	"helicopter":                      true,
+	"herb":                            true,
+	"hibiscus":                        true,
+	"high_brightness":                 true,
+	"high_heel":                       true,
+	"hocho":                           true,
+	"honey_pot":                       true,
+	"honeybee":                        true,
+	"horse":                           true,
+	"horse_racing":                    true,
+	"hospital":                        true,
+	"hotel":                           true,
+	"hotsprings":                      true,
+	"hourglass":                       true,
+	"hourglass_flowing_sand":          true,
+	"house":                           true,
+	"house_with_garden":               true,
+	"hurtrealbad":                     true,
+	"hushed":                          true,
+	"ice_cream":                       true,
+	"icecream":                        true,
+	"id":                              true,
+	"ideograph_advantage":             true,
+	"imp":                             true,
+	"inbox_tray":                      true,
+	"incoming_envelope":               true,
+	"information_desk_person":         true,
+	"information_source":              true,
+	"innocent":                        true,
+	"interrobang":                     true,
+	"iphone":                          true,
+	"it":                              true,
+	"izakaya_lantern":                 true,
+	"jack_o_lantern":                  true,
+	"japan":                           true,
+	"japanese_castle":                 true,
+	"japanese_goblin":                 true,
+	"japanese_ogre":                   true,
+	"jeans":                           true,
+	"joy":                             true,
+	"joy_cat":                         true,
+	"jp":                              true,
+	"key":                             true,
+	"keycap_ten":                      true,
+	"kimono":                          true,
+	"kiss":                            true,
+	"kissing":                         true,
+	"kissing_cat":                     true,
+	"kissing_closed_eyes":             true,
+	"kissing_heart":                   true,
+	"kissing_smiling_eyes":            true,
+	"knife":                           true,
+	"koala":                           true,
+	"koko":                            true,
+	"kr":                              true,
+	"lantern":                         true,
+	"large_blue_circle":               true,
+	"large_blue_diamond":              true,
+	"large_orange_diamond":            true,
+	"last_quarter_moon":               true,
+	"last_quarter_moon_with_face":     true,
+	"laughing":                        true,
+	"leaves":                          true,
+	"ledger":                          true,
+	"left_luggage":                    true,
+	"left_right_arrow":                true,
+	"leftwards_arrow_with_hook":       true,
+	"lemon":                           true,
+	"leo":                             true,
+	"leopard":                         true,
+	"libra":                           true,
+	"light_rail":                      true,
+	"link":                            true,
+	"lips":                            true,
+	"lipstick":                        true,
+	"lock":                            true,
+	"lock_with_ink_pen":               true,
+	"lollipop":                        true,
+	"loop":                            true,
+	"loud_sound":                      true,
+	"loudspeaker":                     true,
+	"love_hotel":                      true,
+	"love_letter":                     true,
+	"low_brightness":                  true,
+	"m":                               true,
+	"mag":                             true,
+	"mag_right":                       true,
+	"mahjong":                         true,
+	"mailbox":                         true,
+	"mailbox_closed":                  true,
+	"mailbox_with_mail":               true,
+	"mailbox_with_no_mail":            true,
+	"man":                             true,
+	"man_with_gua_pi_mao":             true,
+	"man_with_turban":                 true,
+	"mandarin":                        true,
+	"mans_shoe":                       true,
+	"maple_leaf":                      true,
+	"mask":                            true,
+	"massage":                         true,
+	"mattermost":                      true,
+	"meat_on_bone":                    true,
+	"mega":                            true,
+	"melon":                           true,
+	"memo":                            true,
+	"mens":                            true,
+	"metal":                           true,
+	"metro":                           true,
+	"microphone":                      true,
+	"microscope":                      true,
+	"milky_way":                       true,
+	"minibus":                         true,
+	"minidisc":                        true,
+	"mm":                              true,
+	"mobile_phone_off":                true,
+	"money_with_wings":                true,
+	"moneybag":                        true,
+	"monkey":                          true,
+	"monkey_face":                     true,
+	"monorail":                        true,
+	"moon":                            true,
+	"mortar_board":                    true,
+	"mount_fuji":                      true,
+	"mountain_bicyclist":              true,
+	"mountain_cableway":               true,
+	"mountain_railway":                true,
+	"mouse":                           true,
+	"mouse2":                          true,
+	"movie_camera":                    true,
+	"moyai":                           true,
+	"muscle":                          true,
+	"mushroom":                        true,
+	"musical_keyboard":                true,
+	"musical_note":                    true,
+	"musical_score":                   true,
+	"mute":                            true,
+	"nail_care":                       true,
+	"name_badge":                      true,
+	"neckbeard":                       true,
+	"necktie":                         true,
+	"negative_squared_cross_mark":     true,
+	"neutral_face":                    true,
+	"new":                             true,
+	"new_moon":                        true,
+	"new_moon_with_face":              true,
+	"newspaper":                       true,
+	"ng":                              true,
+	"ng_woman":                        true,
+	"night_with_stars":                true,
+	"nine":                            true,
+	"no_bell":                         true,
+	"no_bicycles":                     true,
+	"no_entry":                        true,
+	"no_entry_sign":                   true,
+	"no_good":                         true,
+	"no_mobile_phones":                true,
+	"no_mouth":                        true,
+	"no_pedestrians":                  true,
+	"no_smoking":                      true,
+	"non-potable_water":               true,
+	"nose":                            true,
+	"notebook":                        true,
+	"notebook_with_decorative_cover":  true,
+	"notes":                           true,
+	"nut_and_bolt":                    true,
+	"o":                               true,
+	"o2":                              true,
+	"ocean":                           true,
+	"octocat":                         true,
+	"octopus":                         true,
+	"oden":                            true,
+	"office":                          true,
+	"ok":                              true,
+	"ok_hand":                         true,
+	"ok_woman":                        true,
+	"older_man":                       true,
+	"older_woman":                     true,
+	"on":                              true,
+	"oncoming_automobile":             true,
+	"oncoming_bus":                    true,
+	"oncoming_police_car":             true,
+	"oncoming_taxi":                   true,
+	"one":                             true,
+	"open_book":                       true,
+	"open_file_folder":                true,
+	"open_hands":                      true,
+	"open_mouth":                      true,
+	"ophiuchus":                       true,
+	"orange":                          true,
+	"orange_book":                     true,
+	"outbox_tray":                     true,
+	"ox":                              true,
+	"package":                         true,
+	"page_facing_up":                  true,
+	"page_with_curl":                  true,
+	"pager":                           true,
+	"palm_tree":                       true,
+	"panda_face":                      true,
+	"paperclip":                       true,
+	"parking":                         true,
+	"part_alternation_mark":           true,
+	"partly_sunny":                    true,
+	"passport_control":                true,
+	"paw_prints":                      true,
+	"peach":                           true,
+	"pear":                            true,
+	"pencil":                          true,
+	"pencil2":                         true,
+	"penguin":                         true,
+	"pensive":                         true,
+	"performing_arts":                 true,
+	"persevere":                       true,
+	"person_frowning":                 true,
+	"person_with_blond_hair":          true,
+	"person_with_pouting_face":        true,
+	"phone":                           true,
+	"pig":                             true,
+	"pig2":                            true,
+	"pig_nose":                        true,
+	"pill":                            true,
+	"pineapple":                       true,
+	"pisces":                          true,
+	"pizza":                           true,
+	"pk":                              true,
+	"point_down":                      true,
+	"point_left":                      true,
+	"point_right":                     true,
+	"point_up":                        true,
+	"point_up_2":                      true,
+	"police_car":                      true,
+	"poodle":                          true,
+	"poop":                            true,
+	"post_office":                     true,
+	"postal_horn":                     true,
+	"postbox":                         true,
+	"potable_water":                   true,
+	"pouch":                           true,
+	"poultry_leg":                     true,
+	"pound":                           true,
+	"pout":                            true,
+	"pouting_cat":                     true,
+	"pray":                            true,
+	"princess":                        true,
+	"punch":                           true,
+	"purple_heart":                    true,
+	"purse":                           true,
+	"pushpin":                         true,
+	"put_litter_in_its_place":         true,
+	"question":                        true,
+	"rabbit":                          true,
+	"rabbit2":                         true,
+	"racehorse":                       true,
+	"radio":                           true,
+	"radio_button":                    true,
+	"rage":                            true,
+	"rage1":                           true,
+	"rage2":                           true,
+	"rage3":                           true,
+	"rage4":                           true,
+	"railway_car":                     true,
+	"rainbow":                         true,
+	"raised_hand":                     true,
+	"raised_hands":                    true,
+	"raising_hand":                    true,
+	"ram":                             true,
+	"ramen":                           true,
+	"rat":                             true,
+	"recycle":                         true,
+	"red_car":                         true,
+	"red_circle":                      true,
+	"registered":                      true,
+	"relaxed":                         true,
+	"relieved":                        true,
+	"repeat":                          true,
+	"repeat_one":                      true,
+	"restroom":                        true,
+	"revolving_hearts":                true,
+	"rewind":                          true,
+	"ribbon":                          true,
+	"rice":                            true,
+	"rice_ball":                       true,
+	"rice_cracker":                    true,
+	"rice_scene":                      true,
+	"ring":                            true,
+	"rocket":                          true,
+	"roller_coaster":                  true,
+	"rooster":                         true,
+	"rose":                            true,
+	"rotating_light":                  true,
+	"round_pushpin":                   true,
+	"rowboat":                         true,
+	"ru":                              true,
+	"rugby_football":                  true,
+	"runner":                          true,
+	"running":                         true,
+	"running_shirt_with_sash":         true,
+	"sa":                              true,
+	"sagittarius":                     true,
+	"sailboat":                        true,
+	"sake":                            true,
+	"sandal":                          true,
+	"santa":                           true,
+	"satellite":                       true,
+	"satisfied":                       true,
+	"saxophone":                       true,
+	"school":                          true,
+	"school_satchel":                  true,
+	"scissors":                        true,
+	"scorpius":                        true,
+	"scream":                          true,
+	"scream_cat":                      true,
+	"scroll":                          true,
+	"seat":                            true,
+	"secret":                          true,
+	"see_no_evil":                     true,
+	"seedling":                        true,
+	"seven":                           true,
+	"shaved_ice":                      true,
+	"sheep":                           true,
+	"shell":                           true,
+	"ship":                            true,
+	"shipit":                          true,
+	"shirt":                           true,
+	"shit":                            true,
+	"shoe":                            true,
+	"shower":                          true,
+	"signal_strength":                 true,
+	"six":                             true,
+	"six_pointed_star":                true,
+	"ski":                             true,
+	"skull":                           true,
+	"sleeping":                        true,
+	"sleepy":                          true,
+	"slightly_frowning_face":          true,
+	"slightly_smiling_face":           true,
+	"slot_machine":                    true,
+	"small_blue_diamond":              true,
+	"small_orange_diamond":            true,
+	"small_red_triangle":              true,
+	"small_red_triangle_down":         true,
+	"smile":                           true,
+	"smile_cat":                       true,
+	"smiley":                          true,
+	"smiley_cat":                      true,
+	"smiling_imp":                     true,
+	"smirk":                           true,
+	"smirk_cat":                       true,
+	"smoking":                         true,
+	"snail":                           true,
+	"snake":                           true,
+	"snowboarder":                     true,
+	"snowflake":                       true,
+	"snowman":                         true,
+	"sob":                             true,
+	"soccer":                          true,
+	"soon":                            true,
+	"sos":                             true,
+	"sound":                           true,
+	"space_invader":                   true,
+	"spades":                          true,
+	"spaghetti":                       true,
+	"sparkle":                         true,
+	"sparkler":                        true,
+	"sparkles":                        true,
+	"sparkling_heart":                 true,
+	"speak_no_evil":                   true,
+	"speaker":                         true,
+	"speech_balloon":                  true,
+	"speedboat":                       true,
+	"squirrel":                        true,
+	"star":                            true,
+	"star2":                           true,
+	"stars":                           true,
+	"station":                         true,
+	"statue_of_liberty":               true,
+	"steam_locomotive":                true,
+	"stew":                            true,
+	"straight_ruler":                  true,
+	"strawberry":                      true,
+	"stuck_out_tongue":                true,
+	"stuck_out_tongue_closed_eyes":    true,
+	"stuck_out_tongue_winking_eye":    true,
+	"sun_with_face":                   true,
+	"sunflower":                       true,
+	"sunglasses":                      true,
+	"sunny":                           true,
+	"sunrise":                         true,
+	"sunrise_over_mountains":          true,
+	"surfer":                          true,
+	"sushi":                           true,
+	"suspect":                         true,
+	"suspension_railway":              true,
+	"sweat":                           true,
+	"sweat_drops":                     true,
+	"sweat_smile":                     true,
+	"sweet_potato":                    true,
+	"swimmer":                         true,
+	"symbols":                         true,
+	"syringe":                         true,
+	"taco":                            true,
+	"tada":                            true,
+	"tanabata_tree":                   true,
+	"tangerine":                       true,
+	"taurus":                          true,
+	"taxi":                            true,
+	"tea":                             true,
+	"telephone":                       true,
+	"telephone_receiver":              true,
+	"telescope":                       true,
+	"tennis":                          true,
+	"tent":                            true,
+	"thought_balloon":                 true,
+	"three":                           true,
+	"thumbsdown":                      true,
+	"thumbsup":                        true,
+	"ticket":                          true,
+	"tiger":                           true,
+	"tiger2":                          true,
+	"tired_face":                      true,
+	"tm":                              true,
+	"toilet":                          true,
+	"tokyo_tower":                     true,
+	"tomato":                          true,
+	"tongue":                          true,
+	"top":                             true,
+	"tophat":                          true,
+	"tractor":                         true,
+	"traffic_light":                   true,
+	"train":                           true,
+	"train2":                          true,
+	"tram":                            true,
+	"triangular_flag_on_post":         true,
+	"triangular_ruler":                true,
+	"trident":                         true,
+	"triumph":                         true,
+	"trolleybus":                      true,
+	"trollface":                       true,
+	"trophy":                          true,
+	"tropical_drink":                  true,
+	"tropical_fish":                   true,
+	"truck":                           true,
+	"trumpet":                         true,
+	"tshirt":                          true,
+	"tulip":                           true,
+	"turtle":                          true,
+	"tv":                              true,
+	"twisted_rightwards_arrows":       true,
+	"two":                             true,
+	"two_hearts":                      true,
+	"two_men_holding_hands":           true,
+	"two_women_holding_hands":         true,
+	"u5272":                           true,
+	"u5408":                           true,
+	"u55b6":                           true,
+	"u6307":                           true,
+	"u6708":                           true,
+	"u6709":                           true,
+	"u6e80":                           true,
+	"u7121":                           true,
+	"u7533":                           true,
+	"u7981":                           true,
+	"u7a7a":                           true,
+	"uk":                              true,
+	"umbrella":                        true,
+	"unamused":                        true,
+	"underage":                        true,
+	"unlock":                          true,
+	"up":                              true,
+	"upside_down_face":                true,
+	"us":                              true,
+	"v":                               true,
+	"vertical_traffic_light":          true,
+	"vhs":                             true,
+	"vibration_mode":                  true,
+	"video_camera":                    true,
+	"video_game":                      true,
+	"violin":                          true,
+	"virgo":                           true,
+	"volcano":                         true,
+	"vs":                              true,
+	"walking":                         true,
+	"waning_crescent_moon":            true,
+	"waning_gibbous_moon":             true,
+	"warning":                         true,
+	"watch":                           true,
+	"water_buffalo":                   true,
+	"watermelon":                      true,
+	"wave":                            true,
+	"wavy_dash":                       true,
+	"waxing_crescent_moon":            true,
+	"waxing_gibbous_moon":             true,
+	"wc":                              true,
+	"weary":                           true,
+	"wedding":                         true,
+	"whale":                           true,
+	"whale2":                          true,
+	"wheelchair":                      true,
+	"white_check_mark":                true,
+	"white_circle":                    true,
+	"white_flower":                    true,
+	"white_large_square":              true,
+	"white_medium_small_square":       true,
+	"white_medium_square":             true,
+	"white_small_square":              true,
+	"white_square_button":             true,
+	"wind_chime":                      true,
+	"wine_glass":                      true,
+	"wink":                            true,
+	"wolf":                            true,
+	"woman":                           true,
+	"womans_clothes":                  true,
+	"womans_hat":                      true,
+	"womens":                          true,
+	"worried":                         true,
+	"wrench":                          true,
+	"x":                               true,
+	"yellow_heart":                    true,
+	"yen":                             true,
+	"yum":                             true,
+	"za":                              true,
+	"zap":                             true,
+	"zero":                            true,
+	"zzz":                             true,
+}
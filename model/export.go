@@ -0,0 +1,207 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	EXPORT_JOB_STATUS_PENDING = "pending"
+	EXPORT_JOB_STATUS_RUNNING = "running"
+	EXPORT_JOB_STATUS_SUCCESS = "success"
+	EXPORT_JOB_STATUS_FAILED  = "failed"
+
+	EXPORT_MANIFEST_VERSION = 1
+)
+
+// ExportRange limits an export to the posts made at or after Since and before Until. Either can be 0 to leave that
+// end of the range open.
+type ExportRange struct {
+	Since int64 `json:"since"`
+	Until int64 `json:"until"`
+}
+
+type ExportOptions struct {
+	TeamsToExport      []string                `json:"teams"`
+	ChannelsToExport   []string                `json:"channels"`
+	UsersToExport      []string                `json:"users"`
+	ExportLocalStorage bool                    `json:"export_local_storage"`
+	Since              int64                   `json:"since,omitempty"`
+	Until              int64                   `json:"until,omitempty"`
+	ChannelRanges      map[string]*ExportRange `json:"channel_ranges,omitempty"` // by channel id, replacing Since and Until
+	Incremental        bool                    `json:"incremental,omitempty"`    // start from the end of the last export when Since isn't set
+}
+
+// ExportJob is an export that's run in the background and written to a timestamped archive in file storage. A
+// running job belongs to the server named by WorkerId for as long as that server keeps HeartbeatAt up to date.
+type ExportJob struct {
+	Id          string        `json:"id"`
+	CreateAt    int64         `json:"create_at"`
+	StartAt     int64         `json:"start_at"`
+	FinishAt    int64         `json:"finish_at"`
+	CreatorId   string        `json:"creator_id"`
+	TeamId      string        `json:"team_id"`
+	Status      string        `json:"status"`
+	Options     ExportOptions `json:"options"`
+	Path        string        `json:"path"`
+	PostCount   int64         `json:"post_count"`
+	Error       string        `json:"error,omitempty"`
+	WorkerId    string        `json:"worker_id,omitempty"`
+	HeartbeatAt int64         `json:"heartbeat_at,omitempty"`
+}
+
+// ExportManifest describes what's in an export archive so that a series of incremental exports can be put back
+// together
+type ExportManifest struct {
+	Version  int                               `json:"version"`
+	JobId    string                            `json:"job_id"`
+	CreateAt int64                             `json:"create_at"`
+	Since    int64                             `json:"since"`
+	Until    int64                             `json:"until"`
+	Channels map[string]*ExportManifestChannel `json:"channels"` // by channel id
+}
+
+type ExportManifestChannel struct {
+	Since     int64 `json:"since"`
+	Until     int64 `json:"until"`
+	PostCount int64 `json:"post_count"`
+}
+
+func (o *ExportOptions) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ExportOptionsFromJson(data io.Reader) *ExportOptions {
+	decoder := json.NewDecoder(data)
+	var o ExportOptions
+	decoder.Decode(&o)
+	return &o
+}
+
+// GetRange returns the range of posts to export from a channel
+func (o *ExportOptions) GetRange(channelId string) (int64, int64) {
+	if r, ok := o.ChannelRanges[channelId]; ok && r != nil {
+		return r.Since, r.Until
+	}
+
+	return o.Since, o.Until
+}
+
+func (o *ExportOptions) IsValid() *AppError {
+	if !isValidExportRange(o.Since, o.Until) {
+		return NewLocAppError("ExportOptions.IsValid", "model.export_options.is_valid.range.app_error", nil, "")
+	}
+
+	for channelId, r := range o.ChannelRanges {
+		if len(channelId) != 26 || r == nil || !isValidExportRange(r.Since, r.Until) {
+			return NewLocAppError("ExportOptions.IsValid", "model.export_options.is_valid.channel_range.app_error", nil, "channel_id="+channelId)
+		}
+	}
+
+	return nil
+}
+
+func isValidExportRange(since int64, until int64) bool {
+	return since >= 0 && until >= 0 && (until == 0 || until > since)
+}
+
+func (o *ExportJob) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ExportJobFromJson(data io.Reader) *ExportJob {
+	decoder := json.NewDecoder(data)
+	var o ExportJob
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *ExportJob) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+
+	if o.Status == "" {
+		o.Status = EXPORT_JOB_STATUS_PENDING
+	}
+}
+
+func (o *ExportJob) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("ExportJob.IsValid", "model.export_job.is_valid.id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("ExportJob.IsValid", "model.export_job.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.CreatorId) > 26 {
+		return NewLocAppError("ExportJob.IsValid", "model.export_job.is_valid.creator_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.TeamId) > 26 {
+		return NewLocAppError("ExportJob.IsValid", "model.export_job.is_valid.team_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.WorkerId) > 26 {
+		return NewLocAppError("ExportJob.IsValid", "model.export_job.is_valid.worker_id.app_error", nil, "id="+o.Id)
+	}
+
+	switch o.Status {
+	case EXPORT_JOB_STATUS_PENDING, EXPORT_JOB_STATUS_RUNNING, EXPORT_JOB_STATUS_SUCCESS, EXPORT_JOB_STATUS_FAILED:
+	default:
+		return NewLocAppError("ExportJob.IsValid", "model.export_job.is_valid.status.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.Path) > 512 {
+		return NewLocAppError("ExportJob.IsValid", "model.export_job.is_valid.path.app_error", nil, "id="+o.Id)
+	}
+
+	return o.Options.IsValid()
+}
+
+// IsFinished returns true if the job has either succeeded or failed
+func (o *ExportJob) IsFinished() bool {
+	return o.Status == EXPORT_JOB_STATUS_SUCCESS || o.Status == EXPORT_JOB_STATUS_FAILED
+}
+
+func (o *ExportManifest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ExportManifestFromJson(data io.Reader) *ExportManifest {
+	decoder := json.NewDecoder(data)
+	var o ExportManifest
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
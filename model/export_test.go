@@ -0,0 +1,74 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportJobJson(t *testing.T) {
+	channelId := NewId()
+
+	o := ExportJob{Id: NewId(), Options: ExportOptions{Since: 10, ChannelRanges: map[string]*ExportRange{channelId: {Since: 5}}}}
+	json := o.ToJson()
+	ro := ExportJobFromJson(strings.NewReader(json))
+
+	if o.Id != ro.Id || ro.Options.Since != 10 || ro.Options.ChannelRanges[channelId].Since != 5 {
+		t.Fatal("export jobs do not match")
+	}
+}
+
+func TestExportOptionsGetRange(t *testing.T) {
+	channelId := NewId()
+
+	o := ExportOptions{Since: 10, Until: 20, ChannelRanges: map[string]*ExportRange{channelId: {Since: 5, Until: 15}}}
+
+	if since, until := o.GetRange(channelId); since != 5 || until != 15 {
+		t.Fatal("should've used the channel's range", since, until)
+	}
+
+	if since, until := o.GetRange(NewId()); since != 10 || until != 20 {
+		t.Fatal("should've used the export's range", since, until)
+	}
+}
+
+func TestExportJobIsValid(t *testing.T) {
+	o := ExportJob{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Status != EXPORT_JOB_STATUS_PENDING {
+		t.Fatal("should have been pending")
+	}
+
+	o.Status = "unknown"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Status = EXPORT_JOB_STATUS_RUNNING
+	o.Options.Since = 20
+	o.Options.Until = 10
+	if err := o.IsValid(); err == nil {
+		t.Fatal("range should be invalid")
+	}
+
+	o.Options.Until = 0
+	if err := o.IsValid(); err != nil {
+		t.Fatal("an open range should be valid", err)
+	}
+
+	o.Options.ChannelRanges = map[string]*ExportRange{"junk": {}}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("channel range should be invalid")
+	}
+}
@@ -9,35 +9,44 @@ import (
 	"image/gif"
 	"io"
 	"mime"
+	"net/url"
 	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	FILE_INFO_CONTENT_MAX_SIZE = 65535
 )
 
 type FileInfo struct {
+	Id              string `json:"id"`
+	CreatorId       string `json:"user_id"`
+	PostId          string `json:"post_id,omitempty"`
+	ChannelId       string `json:"channel_id"`
+	CreateAt        int64  `json:"create_at"`
+	UpdateAt        int64  `json:"update_at"`
+	DeleteAt        int64  `json:"delete_at"`
+	Path            string `json:"-"` // not sent back to the client
+	ThumbnailPath   string `json:"-"` // not sent back to the client
+	PreviewPath     string `json:"-"` // not sent back to the client
 	Filename        string `json:"filename"`
-	Size            int    `json:"size"`
+	Size            int64  `json:"size"`
+	Hash            string `json:"-"` // sha256 of the file's contents
 	Extension       string `json:"extension"`
 	MimeType        string `json:"mime_type"`
+	Width           int    `json:"width,omitempty"`
+	Height          int    `json:"height,omitempty"`
 	HasPreviewImage bool   `json:"has_preview_image"`
+	Content         string `json:"-"` // text extracted from the file for searching
 }
 
 func GetInfoForBytes(filename string, data []byte) (*FileInfo, *AppError) {
-	size := len(data)
-
-	var mimeType string
-	extension := filepath.Ext(filename)
-	isImage := IsFileExtImage(extension)
-	if isImage {
-		mimeType = GetImageMimeType(extension)
-	} else {
-		mimeType = mime.TypeByExtension(extension)
-	}
+	size := int64(len(data))
 
-	if extension != "" && extension[0] == '.' {
-		// the client expects a file extension without the leading period
-		extension = extension[1:]
-	}
+	extension, mimeType := getExtensionAndMimeType(filename)
 
-	hasPreviewImage := isImage
+	hasPreviewImage := IsFileExtImage(filepath.Ext(filename))
 	if mimeType == "image/gif" {
 		// just show the gif itself instead of a preview image for animated gifs
 		if gifImage, err := gif.DecodeAll(bytes.NewReader(data)); err != nil {
@@ -56,6 +65,170 @@ func GetInfoForBytes(filename string, data []byte) (*FileInfo, *AppError) {
 	}, nil
 }
 
+// GetInfoForPath builds the parts of a FileInfo that can be worked out from the file's name alone
+func GetInfoForPath(path string) *FileInfo {
+	filename := filepath.Base(path)
+	extension, mimeType := getExtensionAndMimeType(filename)
+
+	info := &FileInfo{
+		Path:            path,
+		Filename:        filename,
+		Extension:       extension,
+		MimeType:        mimeType,
+		HasPreviewImage: IsFileExtImage(filepath.Ext(filename)) && mimeType != "image/gif",
+	}
+
+	if IsFileExtImage(filepath.Ext(filename)) {
+		info.SetImagePaths()
+	}
+
+	return info
+}
+
+// GetInfoForFilename builds a FileInfo for an entry of Post.Filenames, which are of the form
+// /channel_id/user_id/file_id/name. The path is left empty when the team the file was uploaded to isn't known.
+func GetInfoForFilename(filename string, teamId string) *FileInfo {
+	if UrlRegex.MatchString(filename) {
+		return nil
+	}
+
+	matches := PartialUrlRegex.FindStringSubmatch(filename)
+	if len(matches) < 4 {
+		return nil
+	}
+
+	channelId := matches[1]
+	userId := matches[2]
+
+	fileId := ""
+	name := matches[3]
+	if split := strings.SplitN(name, "/", 2); len(split) == 2 {
+		fileId = split[0]
+		name = split[1]
+	}
+
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		name = unescaped
+	}
+
+	dir := "/channels/" + channelId + "/users/" + userId + "/"
+	if fileId != "" {
+		dir += fileId + "/"
+	}
+
+	info := GetInfoForPath("teams/" + teamId + dir + name)
+	if teamId == "" {
+		info.Path = ""
+		info.ThumbnailPath = ""
+		info.PreviewPath = ""
+	}
+
+	if len(fileId) == 26 {
+		info.Id = fileId
+	}
+	info.CreatorId = userId
+	info.ChannelId = channelId
+
+	return info
+}
+
+func getExtensionAndMimeType(filename string) (string, string) {
+	var mimeType string
+	extension := filepath.Ext(filename)
+	if IsFileExtImage(extension) {
+		mimeType = GetImageMimeType(extension)
+	} else {
+		mimeType = mime.TypeByExtension(extension)
+	}
+
+	if extension != "" && extension[0] == '.' {
+		// the client expects a file extension without the leading period
+		extension = extension[1:]
+	}
+
+	return extension, mimeType
+}
+
+// SetImagePaths sets the paths of the thumbnail and preview images generated next to an uploaded image
+func (info *FileInfo) SetImagePaths() {
+	name := info.Path
+	if index := strings.LastIndex(name, "."); index > strings.LastIndex(name, "/") {
+		name = name[:index]
+	}
+
+	info.ThumbnailPath = name + "_thumb.jpg"
+	info.PreviewPath = name + "_preview.jpg"
+}
+
+// SetContent sets the searchable text of the file, cutting it short at a character boundary if it's too long
+func (info *FileInfo) SetContent(content string) {
+	if len(content) > FILE_INFO_CONTENT_MAX_SIZE {
+		end := FILE_INFO_CONTENT_MAX_SIZE
+		for end > 0 && !utf8.RuneStart(content[end]) {
+			end--
+		}
+		content = content[:end]
+	}
+
+	info.Content = content
+}
+
+func (info *FileInfo) PreSave() {
+	if info.Id == "" {
+		info.Id = NewId()
+	}
+
+	if info.CreateAt == 0 {
+		info.CreateAt = GetMillis()
+	}
+
+	info.UpdateAt = info.CreateAt
+}
+
+func (info *FileInfo) IsValid() *AppError {
+	if len(info.Id) != 26 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.id.app_error", nil, "")
+	}
+
+	if len(info.CreatorId) != 26 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.user_id.app_error", nil, "id="+info.Id)
+	}
+
+	if len(info.ChannelId) != 26 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.channel_id.app_error", nil, "id="+info.Id)
+	}
+
+	if len(info.PostId) != 0 && len(info.PostId) != 26 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.post_id.app_error", nil, "id="+info.Id)
+	}
+
+	if info.CreateAt == 0 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.create_at.app_error", nil, "id="+info.Id)
+	}
+
+	if info.UpdateAt == 0 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.update_at.app_error", nil, "id="+info.Id)
+	}
+
+	if len(info.Filename) == 0 || len(info.Filename) > 256 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.filename.app_error", nil, "id="+info.Id)
+	}
+
+	if len(info.Path) > 512 {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.path.app_error", nil, "id="+info.Id)
+	}
+
+	if len(info.Content) > FILE_INFO_CONTENT_MAX_SIZE {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.content.app_error", nil, "id="+info.Id)
+	}
+
+	return nil
+}
+
+func (info *FileInfo) IsImage() bool {
+	return strings.HasPrefix(info.MimeType, "image")
+}
+
 func (info *FileInfo) ToJson() string {
 	b, err := json.Marshal(info)
 	if err != nil {
@@ -75,3 +248,23 @@ func FileInfoFromJson(data io.Reader) *FileInfo {
 		return &info
 	}
 }
+
+func FileInfosToJson(infos []*FileInfo) string {
+	b, err := json.Marshal(infos)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func FileInfosFromJson(data io.Reader) []*FileInfo {
+	decoder := json.NewDecoder(data)
+
+	var infos []*FileInfo
+	if err := decoder.Decode(&infos); err != nil {
+		return nil
+	} else {
+		return infos
+	}
+}
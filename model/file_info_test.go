@@ -6,7 +6,9 @@ package model
 import (
 	"encoding/base64"
 	"io/ioutil"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestGetInfoForBytes(t *testing.T) {
@@ -88,3 +90,71 @@ func TestGetInfoForBytes(t *testing.T) {
 		t.Fatalf("Got HasPreviewImage = true for non-image file")
 	}
 }
+
+func TestFileInfoIsValid(t *testing.T) {
+	info := &FileInfo{
+		CreatorId: NewId(),
+		ChannelId: NewId(),
+		Filename:  "file.txt",
+	}
+	info.PreSave()
+
+	if err := info.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	info.PostId = "junk"
+	if err := info.IsValid(); err == nil {
+		t.Fatal("should be invalid with a bad post id")
+	}
+
+	info.PostId = NewId()
+	info.Filename = ""
+	if err := info.IsValid(); err == nil {
+		t.Fatal("should be invalid without a filename")
+	}
+}
+
+func TestFileInfoSetContent(t *testing.T) {
+	info := &FileInfo{}
+
+	info.SetContent("some text")
+	if info.Content != "some text" {
+		t.Fatal("short content should be left alone")
+	}
+
+	info.SetContent(strings.Repeat("a", FILE_INFO_CONTENT_MAX_SIZE-1) + "é")
+	if len(info.Content) != FILE_INFO_CONTENT_MAX_SIZE-1 || !utf8.ValidString(info.Content) {
+		t.Fatal("long content should be cut short without splitting a character")
+	}
+}
+
+func TestGetInfoForFilename(t *testing.T) {
+	channelId := NewId()
+	userId := NewId()
+	fileId := NewId()
+	teamId := NewId()
+
+	info := GetInfoForFilename("/"+channelId+"/"+userId+"/"+fileId+"/my%20image.png", teamId)
+	if info == nil {
+		t.Fatal("should have parsed the filename")
+	} else if info.Id != fileId || info.ChannelId != channelId || info.CreatorId != userId {
+		t.Fatal("should have taken the ids from the filename")
+	} else if info.Filename != "my image.png" || info.Extension != "png" || info.MimeType != "image/png" {
+		t.Fatal("should have worked out the name and type of the file", info.Filename, info.Extension, info.MimeType)
+	} else if info.Path != "teams/"+teamId+"/channels/"+channelId+"/users/"+userId+"/"+fileId+"/my image.png" {
+		t.Fatal("bad path " + info.Path)
+	} else if info.ThumbnailPath != "teams/"+teamId+"/channels/"+channelId+"/users/"+userId+"/"+fileId+"/my image_thumb.jpg" {
+		t.Fatal("bad thumbnail path " + info.ThumbnailPath)
+	}
+
+	if info := GetInfoForFilename("/"+channelId+"/"+userId+"/"+fileId+"/file.txt", ""); info == nil {
+		t.Fatal("should have parsed the filename")
+	} else if info.Path != "" || info.ThumbnailPath != "" {
+		t.Fatal("shouldn't have a path without a team")
+	}
+
+	if info := GetInfoForFilename("http://example.com/file.txt", teamId); info != nil {
+		t.Fatal("shouldn't have parsed an external link")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	IMPORT_ITEM_TEAM     = "team"
+	IMPORT_ITEM_USER     = "user"
+	IMPORT_ITEM_CHANNEL  = "channel"
+	IMPORT_ITEM_POST     = "post"
+	IMPORT_ITEM_FILE     = "file"
+	IMPORT_ITEM_REACTION = "reaction"
+
+	IMPORT_RESULT_CREATED = "created"
+	IMPORT_RESULT_MERGED  = "merged"
+	IMPORT_RESULT_UPDATED = "updated"
+	IMPORT_RESULT_SKIPPED = "skipped"
+	IMPORT_RESULT_FAILED  = "failed"
+)
+
+// ImportReportItem describes what happened to one thing from the archive being imported
+type ImportReportItem struct {
+	Type     string `json:"type"`
+	SourceId string `json:"source_id,omitempty"` // the id of the item in the archive
+	Name     string `json:"name,omitempty"`
+	Result   string `json:"result"`
+	Id       string `json:"id,omitempty"` // the id of what the item was imported as
+	Message  string `json:"message,omitempty"`
+}
+
+// ImportReport is the outcome of an import. Every item is counted, but only the ones added with Add are listed so
+// that large imports don't have to list every post.
+type ImportReport struct {
+	Counts map[string]map[string]int `json:"counts"` // by item type and then by result
+	Items  []*ImportReportItem       `json:"items"`
+	Notes  []string                  `json:"notes"`
+}
+
+func NewImportReport() *ImportReport {
+	return &ImportReport{
+		Counts: map[string]map[string]int{},
+		Items:  []*ImportReportItem{},
+		Notes:  []string{},
+	}
+}
+
+// Add lists an item in the report and counts it
+func (o *ImportReport) Add(item *ImportReportItem) {
+	o.Items = append(o.Items, item)
+	o.Count(item.Type, item.Result)
+}
+
+// Count counts an item without listing it in the report
+func (o *ImportReport) Count(itemType string, result string) {
+	if o.Counts[itemType] == nil {
+		o.Counts[itemType] = map[string]int{}
+	}
+
+	o.Counts[itemType][result]++
+}
+
+func (o *ImportReport) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ImportReportFromJson(data io.Reader) *ImportReport {
+	decoder := json.NewDecoder(data)
+	var o ImportReport
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportReport(t *testing.T) {
+	report := NewImportReport()
+
+	report.Add(&ImportReportItem{Type: IMPORT_ITEM_USER, SourceId: "U1", Name: "alice", Result: IMPORT_RESULT_CREATED, Id: NewId()})
+	report.Add(&ImportReportItem{Type: IMPORT_ITEM_POST, SourceId: "1.2", Result: IMPORT_RESULT_SKIPPED, Message: "no user"})
+	report.Count(IMPORT_ITEM_POST, IMPORT_RESULT_CREATED)
+	report.Count(IMPORT_ITEM_POST, IMPORT_RESULT_CREATED)
+
+	if len(report.Items) != 2 {
+		t.Fatal("should only list the added items")
+	}
+
+	if report.Counts[IMPORT_ITEM_POST][IMPORT_RESULT_CREATED] != 2 || report.Counts[IMPORT_ITEM_POST][IMPORT_RESULT_SKIPPED] != 1 {
+		t.Fatal("should count every item", report.Counts)
+	}
+
+	ro := ImportReportFromJson(strings.NewReader(report.ToJson()))
+	if len(ro.Items) != 2 || ro.Items[0].Name != "alice" || ro.Counts[IMPORT_ITEM_USER][IMPORT_RESULT_CREATED] != 1 {
+		t.Fatal("reports do not match")
+	}
+}
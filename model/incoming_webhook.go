@@ -21,6 +21,7 @@ type IncomingWebhook struct {
 	UpdateAt    int64  `json:"update_at"`
 	DeleteAt    int64  `json:"delete_at"`
 	UserId      string `json:"user_id"`
+	BotUserId   string `json:"bot_user_id"`
 	ChannelId   string `json:"channel_id"`
 	TeamId      string `json:"team_id"`
 	DisplayName string `json:"display_name"`
@@ -95,6 +96,10 @@ func (o *IncomingWebhook) IsValid() *AppError {
 		return NewLocAppError("IncomingWebhook.IsValid", "model.incoming_hook.user_id.app_error", nil, "")
 	}
 
+	if len(o.BotUserId) != 0 && len(o.BotUserId) != 26 {
+		return NewLocAppError("IncomingWebhook.IsValid", "model.incoming_hook.bot_user_id.app_error", nil, "")
+	}
+
 	if len(o.ChannelId) != 26 {
 		return NewLocAppError("IncomingWebhook.IsValid", "model.incoming_hook.channel_id.app_error", nil, "")
 	}
@@ -21,6 +21,8 @@ const (
 	ACTION_USER_REMOVED       = "user_removed"
 	ACTION_PREFERENCE_CHANGED = "preference_changed"
 	ACTION_EPHEMERAL_MESSAGE  = "ephemeral_message"
+	ACTION_REACTION_ADDED     = "reaction_added"
+	ACTION_REACTION_REMOVED   = "reaction_removed"
 )
 
 type Message struct {
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -15,11 +16,19 @@ const (
 	OAUTH_ACTION_LOGIN        = "login"
 	OAUTH_ACTION_EMAIL_TO_SSO = "email_to_sso"
 	OAUTH_ACTION_SSO_TO_EMAIL = "sso_to_email"
+
+	OAUTH_SCOPE_READ  = "read"
+	OAUTH_SCOPE_WRITE = "write"
+	OAUTH_SCOPE_ADMIN = "admin"
+
+	// Apps that don't ask for any scopes can use the API like a regular user, but not as an admin
+	OAUTH_DEFAULT_SCOPE = OAUTH_SCOPE_READ + " " + OAUTH_SCOPE_WRITE
 )
 
 type OAuthApp struct {
 	Id           string      `json:"id"`
 	CreatorId    string      `json:"creator_id"`
+	BotUserId    string      `json:"bot_user_id"`
 	CreateAt     int64       `json:"create_at"`
 	UpdateAt     int64       `json:"update_at"`
 	ClientSecret string      `json:"client_secret"`
@@ -27,6 +36,7 @@ type OAuthApp struct {
 	Description  string      `json:"description"`
 	CallbackUrls StringArray `json:"callback_urls"`
 	Homepage     string      `json:"homepage"`
+	Scope        string      `json:"scope"`
 }
 
 // IsValid validates the app and returns an error if it isn't configured
@@ -49,6 +59,10 @@ func (a *OAuthApp) IsValid() *AppError {
 		return NewLocAppError("OAuthApp.IsValid", "model.oauth.is_valid.creator_id.app_error", nil, "app_id="+a.Id)
 	}
 
+	if len(a.BotUserId) != 0 && len(a.BotUserId) != 26 {
+		return NewLocAppError("OAuthApp.IsValid", "model.oauth.is_valid.bot_user_id.app_error", nil, "app_id="+a.Id)
+	}
+
 	if len(a.ClientSecret) == 0 || len(a.ClientSecret) > 128 {
 		return NewLocAppError("OAuthApp.IsValid", "model.oauth.is_valid.client_secret.app_error", nil, "app_id="+a.Id)
 	}
@@ -69,6 +83,10 @@ func (a *OAuthApp) IsValid() *AppError {
 		return NewLocAppError("OAuthApp.IsValid", "model.oauth.is_valid.description.app_error", nil, "app_id="+a.Id)
 	}
 
+	if len(a.Scope) > 128 || !IsValidOAuthScope(a.Scope) {
+		return NewLocAppError("OAuthApp.IsValid", "model.oauth.is_valid.scope.app_error", nil, "app_id="+a.Id)
+	}
+
 	return nil
 }
 
@@ -83,6 +101,10 @@ func (a *OAuthApp) PreSave() {
 		a.ClientSecret = NewId()
 	}
 
+	if a.Scope == "" {
+		a.Scope = OAUTH_DEFAULT_SCOPE
+	}
+
 	a.CreateAt = GetMillis()
 	a.UpdateAt = a.CreateAt
 
@@ -93,6 +115,10 @@ func (a *OAuthApp) PreSave() {
 
 // PreUpdate should be run before updating the app in the db.
 func (a *OAuthApp) PreUpdate() {
+	if a.Scope == "" {
+		a.Scope = OAUTH_DEFAULT_SCOPE
+	}
+
 	a.UpdateAt = GetMillis()
 }
 
@@ -157,3 +183,69 @@ func OAuthAppMapFromJson(data io.Reader) map[string]*OAuthApp {
 		return nil
 	}
 }
+
+func OAuthAppListToJson(l []*OAuthApp) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func OAuthAppListFromJson(data io.Reader) []*OAuthApp {
+	decoder := json.NewDecoder(data)
+	var apps []*OAuthApp
+	err := decoder.Decode(&apps)
+	if err == nil {
+		return apps
+	} else {
+		return nil
+	}
+}
+
+// IsValidOAuthScope checks that a space separated list of scopes only contains scopes that are known
+func IsValidOAuthScope(scope string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s != OAUTH_SCOPE_READ && s != OAUTH_SCOPE_WRITE && s != OAUTH_SCOPE_ADMIN {
+			return false
+		}
+	}
+
+	return true
+}
+
+// KnownOAuthScopes removes anything that isn't a known scope from a requested scope. Apps that ask for unknown
+// scopes, like the "all" scope that was used before scopes existed, are given the scope that they were registered with.
+func KnownOAuthScopes(scope string) string {
+	var known []string
+	for _, s := range strings.Fields(scope) {
+		if IsValidOAuthScope(s) && !OAuthScopeContains(strings.Join(known, " "), s) {
+			known = append(known, s)
+		}
+	}
+
+	return strings.Join(known, " ")
+}
+
+// OAuthScopeContains checks if a space separated list of scopes includes the given scope
+func OAuthScopeContains(scope string, s string) bool {
+	for _, field := range strings.Fields(scope) {
+		if field == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OAuthScopeIsSubset checks if every scope that's requested has been allowed
+func OAuthScopeIsSubset(requested string, allowed string) bool {
+	for _, s := range strings.Fields(requested) {
+		if !OAuthScopeContains(allowed, s) {
+			return false
+		}
+	}
+
+	return true
+}
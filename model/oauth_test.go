@@ -34,6 +34,10 @@ func TestOAuthAppPreSave(t *testing.T) {
 	a1.PreSave()
 	a1.Etag()
 	a1.Sanitize()
+
+	if a1.Scope != OAUTH_DEFAULT_SCOPE {
+		t.Fatal("should've been given the default scope")
+	}
 }
 
 func TestOAuthAppPreUpdate(t *testing.T) {
@@ -93,3 +97,41 @@ func TestOAuthAppIsValid(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestOAuthScopes(t *testing.T) {
+	if !IsValidOAuthScope("read write admin") || !IsValidOAuthScope("") {
+		t.Fatal("should be valid scopes")
+	}
+
+	if IsValidOAuthScope("read delete") {
+		t.Fatal("should be an invalid scope")
+	}
+
+	if !OAuthScopeContains("read write", OAUTH_SCOPE_WRITE) || OAuthScopeContains("read write", OAUTH_SCOPE_ADMIN) {
+		t.Fatal("failed to check if scope was included")
+	}
+
+	if KnownOAuthScopes("all read read admin") != "read admin" {
+		t.Fatal("should've removed unknown and repeated scopes")
+	}
+
+	if !OAuthScopeIsSubset("read", "read write") || OAuthScopeIsSubset("read admin", "read write") {
+		t.Fatal("failed to check requested scopes")
+	}
+
+	app := OAuthApp{Id: NewId(), CreateAt: 1, UpdateAt: 1, CreatorId: NewId(), ClientSecret: NewId(), Name: "app", CallbackUrls: []string{"https://nowhere.com"}, Homepage: "https://nowhere.com", Scope: "read everything"}
+	if err := app.IsValid(); err == nil {
+		t.Fatal("should've failed with an unknown scope")
+	}
+
+	session := Session{}
+	if !session.HasOAuthScope(OAUTH_SCOPE_ADMIN) {
+		t.Fatal("scopes shouldn't limit regular sessions")
+	}
+
+	session.IsOAuth = true
+	session.AddProp(SESSION_PROP_OAUTH_SCOPE, "read")
+	if !session.HasOAuthScope(OAUTH_SCOPE_READ) || session.HasOAuthScope(OAUTH_SCOPE_WRITE) {
+		t.Fatal("should've been limited to the session's scope")
+	}
+}
@@ -0,0 +1,505 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package oauthopenid
+
+import (
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	DISCOVERY_PATH = "/.well-known/openid-configuration"
+
+	// CLOCK_SKEW is how far the IdP's clock is allowed to be off when checking the times in an ID token
+	CLOCK_SKEW = 60 * time.Second
+
+	// KEY_REFRESH_INTERVAL limits how often the IdP's keys are fetched again when a token is signed with an unknown key
+	KEY_REFRESH_INTERVAL = 60 * time.Second
+
+	// STATE_COOKIE holds the nonce of a sign in so that it can only be completed in the browser that started it
+	STATE_COOKIE = "MMOPENIDSTATE"
+
+	// STATE_EXPIRY is how long a user has to sign in with the IdP before the state cookie expires
+	STATE_EXPIRY = 10 * time.Minute
+)
+
+// Discovery is the part of an IdP's discovery document that's needed to sign users in
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type JsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JsonWebKeySet struct {
+	Keys []JsonWebKey `json:"keys"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IdToken     string `json:"id_token"`
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Provider signs users in with an OpenID Connect IdP. The IdP's endpoints are found through its discovery document
+// and ID tokens are checked against the keys that it publishes, both of which are cached.
+type Provider struct {
+	Settings model.OpenIdSettings
+
+	client *http.Client
+
+	mutex         sync.Mutex
+	discovery     *Discovery
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(settings model.OpenIdSettings, client *http.Client) *Provider {
+	settings.SetDefaults()
+
+	return &Provider{
+		Settings: settings,
+		client:   client,
+	}
+}
+
+func (p *Provider) discoveryUrl() string {
+	endpoint := strings.TrimSuffix(p.Settings.DiscoveryEndpoint, "/")
+	if strings.HasSuffix(endpoint, DISCOVERY_PATH) {
+		return endpoint
+	}
+
+	return endpoint + DISCOVERY_PATH
+}
+
+func (p *Provider) getJson(endpoint string, accessToken string, v interface{}) error {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{endpoint, resp.StatusCode}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type httpStatusError struct {
+	endpoint   string
+	statusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return "endpoint=" + e.endpoint + " status=" + strconv.Itoa(e.statusCode)
+}
+
+// GetDiscovery returns the IdP's discovery document, fetching it the first time that it's needed
+func (p *Provider) GetDiscovery() (*Discovery, *model.AppError) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.getDiscovery()
+}
+
+func (p *Provider) getDiscovery() (*Discovery, *model.AppError) {
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery Discovery
+	if err := p.getJson(p.discoveryUrl(), "", &discovery); err != nil {
+		return nil, model.NewLocAppError("Provider.GetDiscovery", "model.openid.discovery.app_error", nil, "name="+p.Settings.Name+", "+err.Error())
+	}
+
+	if len(discovery.Issuer) == 0 || len(discovery.AuthorizationEndpoint) == 0 || len(discovery.TokenEndpoint) == 0 || len(discovery.JwksUri) == 0 {
+		return nil, model.NewLocAppError("Provider.GetDiscovery", "model.openid.discovery.app_error", nil, "name="+p.Settings.Name+", incomplete discovery document")
+	}
+
+	p.discovery = &discovery
+
+	return p.discovery, nil
+}
+
+// getKey returns the public key with the given id, fetching the IdP's keys again if it has rotated them since they
+// were last fetched. An empty id matches the only key that the IdP publishes.
+func (p *Provider) getKey(kid string) (*rsa.PublicKey, *model.AppError) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+
+	if p.keys != nil && time.Since(p.keysFetchedAt) < KEY_REFRESH_INTERVAL {
+		return nil, model.NewLocAppError("Provider.getKey", "model.openid.unknown_key.app_error", nil, "kid="+kid)
+	}
+
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	var keySet JsonWebKeySet
+	if err := p.getJson(discovery.JwksUri, "", &keySet); err != nil {
+		return nil, model.NewLocAppError("Provider.getKey", "model.openid.keys.app_error", nil, err.Error())
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range keySet.Keys {
+		if jwk.Kty != "RSA" || (len(jwk.Use) > 0 && jwk.Use != "sig") {
+			continue
+		}
+
+		if key := jwk.rsaPublicKey(); key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+
+	return nil, model.NewLocAppError("Provider.getKey", "model.openid.unknown_key.app_error", nil, "kid="+kid)
+}
+
+func (p *Provider) findKey(kid string) *rsa.PublicKey {
+	if len(kid) == 0 && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+
+	return p.keys[kid]
+}
+
+func (jwk *JsonWebKey) rsaPublicKey() *rsa.PublicKey {
+	n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.N, "="))
+	if err != nil || len(n) == 0 {
+		return nil
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.E, "="))
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil
+	}
+
+	exponent := 0
+	for _, b := range e {
+		exponent = exponent<<8 | int(b)
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}
+}
+
+// GetAuthorizationUrl returns the url that the user is sent to so that they can sign in with the IdP
+func (p *Provider) GetAuthorizationUrl(redirectUri, state, nonce, loginHint string) (string, *model.AppError) {
+	discovery, err := p.GetDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.Settings.ClientId)
+	query.Set("redirect_uri", redirectUri)
+	query.Set("scope", p.Settings.Scope)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	if len(loginHint) > 0 {
+		query.Set("login_hint", loginHint)
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Authorize exchanges an authorization code for the user's ID token and returns its claims as JSON so that they can
+// be passed to GetUserFromJson. Claims that aren't in the ID token are filled in from the IdP's user info endpoint.
+func (p *Provider) Authorize(code, redirectUri, nonce string) ([]byte, *model.AppError) {
+	if len(nonce) == 0 {
+		return nil, model.NewLocAppError("Provider.Authorize", "model.openid.invalid_id_token.app_error", nil, "missing nonce")
+	}
+
+	discovery, err := p.GetDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", model.ACCESS_TOKEN_GRANT_TYPE)
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectUri)
+	form.Set("client_id", p.Settings.ClientId)
+	form.Set("client_secret", p.Settings.Secret)
+
+	req, _ := http.NewRequest("POST", discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if resp, err := p.client.Do(req); err != nil {
+		return nil, model.NewLocAppError("Provider.Authorize", "model.openid.token.app_error", nil, err.Error())
+	} else {
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+			return nil, model.NewLocAppError("Provider.Authorize", "model.openid.token.app_error", nil, "status="+strconv.Itoa(resp.StatusCode)+", "+string(body))
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return nil, model.NewLocAppError("Provider.Authorize", "model.openid.token.app_error", nil, err.Error())
+		}
+	}
+
+	if len(token.IdToken) == 0 {
+		return nil, model.NewLocAppError("Provider.Authorize", "model.openid.missing_id_token.app_error", nil, "")
+	}
+
+	claims, err := p.VerifyIdToken(token.IdToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(discovery.UserInfoEndpoint) > 0 && len(token.AccessToken) > 0 && p.missingClaims(claims) {
+		userInfo := make(map[string]interface{})
+		if err := p.getJson(discovery.UserInfoEndpoint, token.AccessToken, &userInfo); err != nil {
+			return nil, model.NewLocAppError("Provider.Authorize", "model.openid.user_info.app_error", nil, err.Error())
+		}
+
+		// the user info response must be about the same user as the ID token
+		if userInfo["sub"] == claims["sub"] {
+			for name, value := range userInfo {
+				if _, ok := claims[name]; !ok {
+					claims[name] = value
+				}
+			}
+		}
+	}
+
+	b, _ := json.Marshal(claims)
+	return b, nil
+}
+
+func (p *Provider) missingClaims(claims map[string]interface{}) bool {
+	for _, name := range []string{p.Settings.IdClaim, p.Settings.UsernameClaim, p.Settings.EmailClaim, p.Settings.FirstNameClaim, p.Settings.LastNameClaim} {
+		if _, ok := claims[name]; !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+var signingHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+}
+
+// VerifyIdToken checks that an ID token was signed by the IdP for this client and hasn't expired, and returns its
+// claims. The nonce is only checked if one is given.
+func (p *Provider) VerifyIdToken(idToken string, nonce string) (map[string]interface{}, *model.AppError) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "malformed token")
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "header: "+err.Error())
+	}
+
+	hash, ok := signingHashes[header.Alg]
+	if !ok {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "alg="+header.Alg)
+	}
+
+	key, err := p.getKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, decodeErr := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if decodeErr != nil {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "signature: "+decodeErr.Error())
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, hash, hasher.Sum(nil), signature); err != nil {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "signature: "+err.Error())
+	}
+
+	claims := make(map[string]interface{})
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "claims: "+err.Error())
+	}
+
+	discovery, err := p.GetDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	if claims["iss"] != discovery.Issuer {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "wrong issuer")
+	}
+
+	if !p.hasAudience(claims) {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "wrong audience")
+	}
+
+	now := time.Now()
+	if exp, ok := claims["exp"].(float64); !ok || now.After(time.Unix(int64(exp), 0).Add(CLOCK_SKEW)) {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.expired_id_token.app_error", nil, "")
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(CLOCK_SKEW).Before(time.Unix(int64(nbf), 0)) {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "not valid yet")
+	}
+
+	if len(nonce) > 0 && claims["nonce"] != nonce {
+		return nil, model.NewLocAppError("Provider.VerifyIdToken", "model.openid.invalid_id_token.app_error", nil, "wrong nonce")
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) hasAudience(claims map[string]interface{}) bool {
+	// a token for several audiences must say that it was issued for this client
+	if azp, ok := claims["azp"]; ok && azp != p.Settings.ClientId {
+		return false
+	}
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == p.Settings.ClientId
+	case []interface{}:
+		for _, a := range aud {
+			if a == p.Settings.ClientId {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func claimsFromJson(data io.Reader) map[string]interface{} {
+	decoder := json.NewDecoder(data)
+	claims := make(map[string]interface{})
+	if err := decoder.Decode(&claims); err != nil {
+		return nil
+	}
+
+	return claims
+}
+
+// claimString returns a claim as a string. Some IdPs use numbers for their user ids.
+func claimString(claims map[string]interface{}, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+func (p *Provider) GetIdentifier() string {
+	return p.Settings.Name
+}
+
+// GetUserFromJson maps the claims returned by Authorize onto a user using the claims that are configured for the IdP
+func (p *Provider) GetUserFromJson(data io.Reader) *model.User {
+	claims := claimsFromJson(data)
+
+	authData := claimString(claims, p.Settings.IdClaim)
+	email := claimString(claims, p.Settings.EmailClaim)
+	if len(authData) == 0 || len(email) == 0 {
+		return &model.User{}
+	}
+
+	username := claimString(claims, p.Settings.UsernameClaim)
+	if len(username) == 0 {
+		username = strings.Split(email, "@")[0]
+	}
+
+	return &model.User{
+		Username:    model.CleanUsername(username),
+		Email:       strings.ToLower(email),
+		FirstName:   claimString(claims, p.Settings.FirstNameClaim),
+		LastName:    claimString(claims, p.Settings.LastNameClaim),
+		AuthData:    &authData,
+		AuthService: p.Settings.Name,
+	}
+}
+
+func (p *Provider) GetAuthDataFromJson(data io.Reader) string {
+	claims := claimsFromJson(data)
+
+	if len(claimString(claims, p.Settings.EmailClaim)) == 0 {
+		return ""
+	}
+
+	return claimString(claims, p.Settings.IdClaim)
+}
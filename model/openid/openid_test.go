@@ -0,0 +1,230 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package oauthopenid
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/model/openid/openidtest"
+)
+
+func newTestProvider(idp *openidtest.FakeIdP) *Provider {
+	return NewProvider(model.OpenIdSettings{
+		Name:              "keycloak",
+		Enable:            true,
+		DiscoveryEndpoint: idp.Issuer(),
+		ClientId:          idp.ClientId,
+		Secret:            idp.ClientSecret,
+	}, http.DefaultClient)
+}
+
+func TestGetAuthorizationUrl(t *testing.T) {
+	idp := openidtest.NewFakeIdP("mattermost", "secret")
+	defer idp.Close()
+
+	provider := newTestProvider(idp)
+
+	authUrl, err := provider.GetAuthorizationUrl("https://mattermost.example.com/signup/keycloak/complete", "state", "nonce", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(authUrl, idp.Issuer()+"/auth?") {
+		t.Fatal("should've used the discovered authorization endpoint", authUrl)
+	}
+
+	parsed, _ := url.Parse(authUrl)
+	query := parsed.Query()
+	if query.Get("client_id") != "mattermost" || query.Get("scope") != model.OPENID_SETTINGS_DEFAULT_SCOPE || query.Get("nonce") != "nonce" || query.Get("state") != "state" {
+		t.Fatal("bad authorization url", authUrl)
+	}
+
+	provider.Settings.DiscoveryEndpoint = "http://localhost:1"
+	if _, err := provider.GetAuthorizationUrl("", "", "", ""); err != nil {
+		t.Fatal("should've cached the discovery document")
+	}
+
+	if _, err := NewProvider(provider.Settings, http.DefaultClient).GetAuthorizationUrl("", "", "", ""); err == nil {
+		t.Fatal("should've failed to discover an unreachable IdP")
+	}
+}
+
+func TestVerifyIdToken(t *testing.T) {
+	idp := openidtest.NewFakeIdP("mattermost", "secret")
+	defer idp.Close()
+	idp.Claims["sub"] = "1234"
+
+	provider := newTestProvider(idp)
+
+	if claims, err := provider.VerifyIdToken(idp.IdToken("nonce", nil), "nonce"); err != nil {
+		t.Fatal(err)
+	} else if claims["sub"] != "1234" {
+		t.Fatal("should've returned the token's claims")
+	}
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("nonce", nil), "other"); err == nil {
+		t.Fatal("should've failed with the wrong nonce")
+	}
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("", map[string]interface{}{"aud": "someoneelse"}), ""); err == nil {
+		t.Fatal("should've failed for another client")
+	}
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("", map[string]interface{}{"aud": []string{"someoneelse", "mattermost"}}), ""); err != nil {
+		t.Fatal("should've allowed a token for several audiences", err)
+	}
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("", map[string]interface{}{"aud": []string{"someoneelse", "mattermost"}, "azp": "someoneelse"}), ""); err == nil {
+		t.Fatal("should've failed for a token that was issued to another client")
+	}
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("", map[string]interface{}{"iss": "https://evil.example.com"}), ""); err == nil {
+		t.Fatal("should've failed for another issuer")
+	}
+
+	expired := idp.IdToken("", map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})
+	if _, err := provider.VerifyIdToken(expired, ""); err == nil || err.Id != "model.openid.expired_id_token.app_error" {
+		t.Fatal("should've failed for an expired token")
+	}
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("", map[string]interface{}{"exp": nil}), ""); err == nil {
+		t.Fatal("should've failed for a token without an expiry")
+	}
+
+	token := idp.IdToken("", nil)
+	parts := strings.Split(token, ".")
+	tampered := idp.IdToken("", map[string]interface{}{"sub": "admin"})
+	if _, err := provider.VerifyIdToken(parts[0]+"."+strings.Split(tampered, ".")[1]+"."+parts[2], ""); err == nil {
+		t.Fatal("should've failed for a token with changed claims")
+	}
+
+	if _, err := provider.VerifyIdToken("eyJhbGciOiJub25lIn0."+parts[1]+".", ""); err == nil {
+		t.Fatal("should've failed for an unsigned token")
+	}
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	forged := openidtest.SignToken(key, "key1", map[string]interface{}{"iss": idp.Issuer(), "aud": "mattermost", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := provider.VerifyIdToken(forged, ""); err == nil {
+		t.Fatal("should've failed for a token signed with another key")
+	}
+
+	if _, err := provider.VerifyIdToken("garbage", ""); err == nil {
+		t.Fatal("should've failed for a malformed token")
+	}
+}
+
+func TestVerifyIdTokenKeyRotation(t *testing.T) {
+	idp := openidtest.NewFakeIdP("mattermost", "secret")
+	defer idp.Close()
+
+	provider := newTestProvider(idp)
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("", nil), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	idp.RotateKey()
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("", nil), ""); err == nil {
+		t.Fatal("shouldn't fetch the keys again right away")
+	}
+
+	provider.keysFetchedAt = time.Now().Add(-KEY_REFRESH_INTERVAL)
+
+	if _, err := provider.VerifyIdToken(idp.IdToken("", nil), ""); err != nil {
+		t.Fatal("should've fetched the new key", err)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	idp := openidtest.NewFakeIdP("mattermost", "secret")
+	defer idp.Close()
+	idp.Claims = map[string]interface{}{
+		"sub":                "f8e2d4c0",
+		"preferred_username": "Jane.Doe",
+		"email":              "Jane@Example.com",
+		"given_name":         "Jane",
+	}
+	idp.UserInfo = map[string]interface{}{
+		"family_name": "Doe",
+		"email":       "someone@else.com",
+	}
+
+	provider := newTestProvider(idp)
+
+	code := idp.IssueCode("nonce")
+	if _, err := provider.Authorize(code, "https://mattermost.example.com", "other"); err == nil {
+		t.Fatal("should've failed with the wrong nonce")
+	}
+
+	if _, err := provider.Authorize(code, "https://mattermost.example.com", "nonce"); err == nil {
+		t.Fatal("shouldn't be able to use a code twice")
+	}
+
+	if _, err := provider.Authorize(idp.IssueCode(""), "https://mattermost.example.com", ""); err == nil {
+		t.Fatal("should've required a nonce")
+	}
+
+	claims, err := provider.Authorize(idp.IssueCode("nonce"), "https://mattermost.example.com", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := provider.GetUserFromJson(bytes.NewReader(claims))
+	if user.Username != "jane.doe" || user.Email != "jane@example.com" || user.FirstName != "Jane" || user.LastName != "Doe" {
+		t.Fatal("should've mapped the claims onto the user", user)
+	}
+
+	if *user.AuthData != "f8e2d4c0" || user.AuthService != "keycloak" {
+		t.Fatal("should've signed in with the IdP's user id")
+	}
+
+	if provider.GetAuthDataFromJson(bytes.NewReader(claims)) != "f8e2d4c0" {
+		t.Fatal("should've returned the IdP's user id")
+	}
+
+	provider.Settings.Secret = "wrong"
+	if _, err := provider.Authorize(idp.IssueCode("nonce"), "https://mattermost.example.com", "nonce"); err == nil {
+		t.Fatal("should've failed with the wrong secret")
+	}
+}
+
+func TestGetUserFromJsonClaimMapping(t *testing.T) {
+	provider := NewProvider(model.OpenIdSettings{
+		Name:           "corp",
+		IdClaim:        "employee_number",
+		UsernameClaim:  "login",
+		EmailClaim:     "mail",
+		FirstNameClaim: "first",
+		LastNameClaim:  "last",
+	}, http.DefaultClient)
+
+	claims := `{"sub": "abc", "employee_number": 1234, "login": "jdoe", "mail": "jdoe@example.com", "first": "Jane", "last": "Doe"}`
+
+	user := provider.GetUserFromJson(strings.NewReader(claims))
+	if *user.AuthData != "1234" || user.Username != "jdoe" || user.Email != "jdoe@example.com" || user.FirstName != "Jane" || user.LastName != "Doe" {
+		t.Fatal("should've used the configured claims", user)
+	}
+
+	user = provider.GetUserFromJson(strings.NewReader(`{"employee_number": "1234", "mail": "jdoe@example.com"}`))
+	if user.Username != "jdoe" {
+		t.Fatal("should've fallen back to the email for the username")
+	}
+
+	if user := provider.GetUserFromJson(strings.NewReader(`{"employee_number": "1234"}`)); user.AuthData != nil {
+		t.Fatal("shouldn't create a user without an email")
+	}
+
+	if provider.GetAuthDataFromJson(strings.NewReader(`{"sub": "abc", "mail": "jdoe@example.com"}`)) != "" {
+		t.Fatal("should've required the configured id claim")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package openidtest runs a fake OpenID Connect IdP in process for tests of the OpenID Connect login provider
+package openidtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FakeIdP signs in whoever is in Claims without asking for a password. Every code that it hands out can be exchanged
+// once for an ID token for the client with ClientId and ClientSecret.
+type FakeIdP struct {
+	Server       *httptest.Server
+	ClientId     string
+	ClientSecret string
+
+	// Claims are added to the ID tokens that are issued
+	Claims map[string]interface{}
+
+	// UserInfo is returned from the user info endpoint
+	UserInfo map[string]interface{}
+
+	mutex   sync.Mutex
+	key     *rsa.PrivateKey
+	keyId   string
+	codes   map[string]string
+	counter int
+}
+
+func NewFakeIdP(clientId, clientSecret string) *FakeIdP {
+	idp := &FakeIdP{
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		Claims:       map[string]interface{}{},
+		UserInfo:     map[string]interface{}{},
+		codes:        map[string]string{},
+	}
+	idp.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/keys", idp.jwks)
+	mux.HandleFunc("/auth", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/userinfo", idp.userInfo)
+	idp.Server = httptest.NewServer(mux)
+
+	return idp
+}
+
+func (idp *FakeIdP) Close() {
+	idp.Server.Close()
+}
+
+func (idp *FakeIdP) Issuer() string {
+	return idp.Server.URL
+}
+
+// RotateKey replaces the key that tokens are signed with, like an IdP does from time to time
+func (idp *FakeIdP) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+
+	idp.counter++
+	idp.key = key
+	idp.keyId = "key" + strconv.Itoa(idp.counter)
+}
+
+// IssueCode returns an authorization code as if a user had signed in to the IdP
+func (idp *FakeIdP) IssueCode(nonce string) string {
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+
+	idp.counter++
+	code := "code" + strconv.Itoa(idp.counter)
+	idp.codes[code] = nonce
+
+	return code
+}
+
+// IdToken returns an ID token for the client with the default claims, the claims in Claims, and then the given claims
+func (idp *FakeIdP) IdToken(nonce string, claims map[string]interface{}) string {
+	all := map[string]interface{}{
+		"iss": idp.Issuer(),
+		"aud": idp.ClientId,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(5 * time.Minute).Unix(),
+	}
+	if len(nonce) > 0 {
+		all["nonce"] = nonce
+	}
+	for name, value := range idp.Claims {
+		all[name] = value
+	}
+	for name, value := range claims {
+		all[name] = value
+	}
+
+	idp.mutex.Lock()
+	key, keyId := idp.key, idp.keyId
+	idp.mutex.Unlock()
+
+	return SignToken(key, keyId, all)
+}
+
+// SignToken signs a token with RS256 using the given key
+func SignToken(key *rsa.PrivateKey, keyId string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyId})
+	payload, _ := json.Marshal(claims)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (idp *FakeIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, map[string]string{
+		"issuer":                 idp.Issuer(),
+		"authorization_endpoint": idp.Issuer() + "/auth",
+		"token_endpoint":         idp.Issuer() + "/token",
+		"userinfo_endpoint":      idp.Issuer() + "/userinfo",
+		"jwks_uri":               idp.Issuer() + "/keys",
+	})
+}
+
+func (idp *FakeIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	idp.mutex.Lock()
+	key, keyId := idp.key, idp.keyId
+	idp.mutex.Unlock()
+
+	writeJson(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyId,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize sends the user straight back with a code, as if they had signed in
+func (idp *FakeIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != idp.ClientId || query.Get("response_type") != "code" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	params := redirect.Query()
+	params.Set("code", idp.IssueCode(query.Get("nonce")))
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (idp *FakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("client_id") != idp.ClientId || r.FormValue("client_secret") != idp.ClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJson(w, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	idp.mutex.Lock()
+	nonce, ok := idp.codes[r.FormValue("code")]
+	delete(idp.codes, r.FormValue("code"))
+	idp.mutex.Unlock()
+
+	if !ok || r.FormValue("grant_type") != "authorization_code" {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJson(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJson(w, map[string]interface{}{
+		"access_token": "access" + r.FormValue("code"),
+		"token_type":   "bearer",
+		"expires_in":   300,
+		"id_token":     idp.IdToken(nonce, nil),
+	})
+}
+
+func (idp *FakeIdP) userInfo(w http.ResponseWriter, r *http.Request) {
+	if len(r.Header.Get("Authorization")) == 0 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	info := map[string]interface{}{"sub": idp.Claims["sub"]}
+	for name, value := range idp.UserInfo {
+		info[name] = value
+	}
+
+	writeJson(w, info)
+}
@@ -18,6 +18,7 @@ type OutgoingWebhook struct {
 	UpdateAt     int64       `json:"update_at"`
 	DeleteAt     int64       `json:"delete_at"`
 	CreatorId    string      `json:"creator_id"`
+	BotUserId    string      `json:"bot_user_id"`
 	ChannelId    string      `json:"channel_id"`
 	TeamId       string      `json:"team_id"`
 	TriggerWords StringArray `json:"trigger_words"`
@@ -129,6 +130,10 @@ func (o *OutgoingWebhook) IsValid() *AppError {
 		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.user_id.app_error", nil, "")
 	}
 
+	if len(o.BotUserId) != 0 && len(o.BotUserId) != 26 {
+		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.bot_user_id.app_error", nil, "")
+	}
+
 	if len(o.ChannelId) != 0 && len(o.ChannelId) != 26 {
 		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.channel_id.app_error", nil, "")
 	}
@@ -0,0 +1,229 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/url"
+)
+
+const (
+	OUTGOING_WEBHOOK_DELIVERY_PENDING = "pending"
+	OUTGOING_WEBHOOK_DELIVERY_SENDING = "sending"
+	OUTGOING_WEBHOOK_DELIVERY_SUCCESS = "success"
+	OUTGOING_WEBHOOK_DELIVERY_FAILED  = "failed"
+
+	OUTGOING_WEBHOOK_DELIVERY_MAX_ATTEMPTS  = 5
+	OUTGOING_WEBHOOK_DELIVERY_RETRY_BACKOFF = 30 * 1000 // milliseconds, doubled after every failed attempt
+
+	// OUTGOING_WEBHOOK_DELIVERY_PAYLOAD_MAX_SIZE fits the largest post message even when every character is escaped
+	OUTGOING_WEBHOOK_DELIVERY_PAYLOAD_MAX_SIZE = 65535
+)
+
+// OutgoingWebhookDelivery is a single attempt to send a payload to one of a hook's callback urls.
+// A failed attempt queues a new pending delivery for the next attempt until the attempts run out.
+type OutgoingWebhookDelivery struct {
+	Id            string `json:"id"`
+	HookId        string `json:"hook_id"`
+	TeamId        string `json:"team_id"`
+	PostId        string `json:"post_id"`
+	Url           string `json:"url"`
+	ContentType   string `json:"content_type"`
+	Payload       string `json:"payload"`
+	Attempt       int    `json:"attempt"`
+	Status        string `json:"status"`
+	StatusCode    int    `json:"status_code"`
+	Latency       int64  `json:"latency"`
+	Error         string `json:"error"`
+	CreateAt      int64  `json:"create_at"`
+	UpdateAt      int64  `json:"update_at"`
+	NextAttemptAt int64  `json:"next_attempt_at"`
+}
+
+func (o *OutgoingWebhookDelivery) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func OutgoingWebhookDeliveryFromJson(data io.Reader) *OutgoingWebhookDelivery {
+	decoder := json.NewDecoder(data)
+	var o OutgoingWebhookDelivery
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func OutgoingWebhookDeliveryListToJson(l []*OutgoingWebhookDelivery) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func OutgoingWebhookDeliveryListFromJson(data io.Reader) []*OutgoingWebhookDelivery {
+	decoder := json.NewDecoder(data)
+	var o []*OutgoingWebhookDelivery
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
+
+func (o *OutgoingWebhookDelivery) IsValid() *AppError {
+
+	if len(o.Id) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.HookId) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.hook_id.app_error", nil, "")
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.team_id.app_error", nil, "")
+	}
+
+	if len(o.PostId) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.post_id.app_error", nil, "")
+	}
+
+	if len(o.Url) == 0 || len(o.Url) > 1024 || !IsValidHttpUrl(o.Url) {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.url.app_error", nil, "")
+	}
+
+	if len(o.Payload) > OUTGOING_WEBHOOK_DELIVERY_PAYLOAD_MAX_SIZE {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.payload.app_error", nil, "")
+	}
+
+	if o.Attempt < 1 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.attempt.app_error", nil, "")
+	}
+
+	switch o.Status {
+	case OUTGOING_WEBHOOK_DELIVERY_PENDING, OUTGOING_WEBHOOK_DELIVERY_SENDING, OUTGOING_WEBHOOK_DELIVERY_SUCCESS, OUTGOING_WEBHOOK_DELIVERY_FAILED:
+	default:
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.status.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.create_at.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func (o *OutgoingWebhookDelivery) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+
+	if o.Attempt == 0 {
+		o.Attempt = 1
+	}
+
+	if o.Status == "" {
+		o.Status = OUTGOING_WEBHOOK_DELIVERY_PENDING
+	}
+
+	if o.NextAttemptAt == 0 {
+		o.NextAttemptAt = o.CreateAt
+	}
+
+	if len(o.Error) > 1024 {
+		o.Error = o.Error[:1024]
+	}
+}
+
+func (o *OutgoingWebhookDelivery) PreUpdate() {
+	o.UpdateAt = GetMillis()
+
+	if len(o.Error) > 1024 {
+		o.Error = o.Error[:1024]
+	}
+}
+
+// Sanitize removes the hook's token from the payload so that the delivery can be shown to users
+func (o *OutgoingWebhookDelivery) Sanitize() {
+	if o.ContentType == "application/json" {
+		payload := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(o.Payload), &payload); err != nil {
+			o.Payload = ""
+			return
+		}
+
+		delete(payload, "token")
+		b, _ := json.Marshal(payload)
+		o.Payload = string(b)
+	} else {
+		values, err := url.ParseQuery(o.Payload)
+		if err != nil {
+			o.Payload = ""
+			return
+		}
+
+		values.Del("token")
+		o.Payload = values.Encode()
+	}
+}
+
+func (o *OutgoingWebhookDelivery) IsSuccessStatusCode() bool {
+	return o.StatusCode >= 200 && o.StatusCode < 300
+}
+
+// Retry returns the delivery for the next attempt or nil if there are no attempts left
+func (o *OutgoingWebhookDelivery) Retry() *OutgoingWebhookDelivery {
+	if o.Attempt >= OUTGOING_WEBHOOK_DELIVERY_MAX_ATTEMPTS {
+		return nil
+	}
+
+	backoff := int64(OUTGOING_WEBHOOK_DELIVERY_RETRY_BACKOFF) << uint(o.Attempt-1)
+
+	return &OutgoingWebhookDelivery{
+		HookId:        o.HookId,
+		TeamId:        o.TeamId,
+		PostId:        o.PostId,
+		Url:           o.Url,
+		ContentType:   o.ContentType,
+		Payload:       o.Payload,
+		Attempt:       o.Attempt + 1,
+		NextAttemptAt: GetMillis() + backoff,
+	}
+}
+
+// Redeliver returns a new delivery of the same payload that is sent right away
+func (o *OutgoingWebhookDelivery) Redeliver() *OutgoingWebhookDelivery {
+	return &OutgoingWebhookDelivery{
+		HookId:      o.HookId,
+		TeamId:      o.TeamId,
+		PostId:      o.PostId,
+		Url:         o.Url,
+		ContentType: o.ContentType,
+		Payload:     o.Payload,
+	}
+}
+
+// SignOutgoingWebhookPayload returns the value of the HEADER_WEBHOOK_SIGNATURE header for a payload
+func SignOutgoingWebhookPayload(token, payload string) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
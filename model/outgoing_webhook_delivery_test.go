@@ -0,0 +1,118 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutgoingWebhookDeliveryJson(t *testing.T) {
+	o := OutgoingWebhookDelivery{Id: NewId(), Payload: "{\"text\":\"hello\"}"}
+	json := o.ToJson()
+	ro := OutgoingWebhookDeliveryFromJson(strings.NewReader(json))
+
+	if o.Id != ro.Id || o.Payload != ro.Payload {
+		t.Fatal("deliveries do not match")
+	}
+
+	list := OutgoingWebhookDeliveryListFromJson(strings.NewReader(OutgoingWebhookDeliveryListToJson([]*OutgoingWebhookDelivery{&o})))
+	if len(list) != 1 || list[0].Id != o.Id {
+		t.Fatal("delivery lists do not match")
+	}
+}
+
+func TestOutgoingWebhookDeliveryIsValid(t *testing.T) {
+	o := OutgoingWebhookDelivery{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.HookId = NewId()
+	o.TeamId = NewId()
+	o.PostId = NewId()
+	o.Url = "http://nowhere.com"
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Url = "nowhere.com"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Url = "http://nowhere.com"
+	o.Status = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Status = OUTGOING_WEBHOOK_DELIVERY_FAILED
+	o.Payload = strings.Repeat("1", OUTGOING_WEBHOOK_DELIVERY_PAYLOAD_MAX_SIZE)
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Payload = strings.Repeat("1", OUTGOING_WEBHOOK_DELIVERY_PAYLOAD_MAX_SIZE+1)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestOutgoingWebhookDeliveryRetry(t *testing.T) {
+	o := OutgoingWebhookDelivery{HookId: NewId(), Payload: "payload"}
+	o.PreSave()
+
+	retry := o.Retry()
+	if retry == nil || retry.Attempt != 2 || retry.Payload != o.Payload {
+		t.Fatal("should have queued the second attempt")
+	}
+
+	if retry.NextAttemptAt < o.CreateAt+OUTGOING_WEBHOOK_DELIVERY_RETRY_BACKOFF {
+		t.Fatal("second attempt should be delayed")
+	}
+
+	third := retry.Retry()
+	if third.NextAttemptAt-retry.NextAttemptAt < OUTGOING_WEBHOOK_DELIVERY_RETRY_BACKOFF {
+		t.Fatal("backoff should grow between attempts")
+	}
+
+	o.Attempt = OUTGOING_WEBHOOK_DELIVERY_MAX_ATTEMPTS
+	if o.Retry() != nil {
+		t.Fatal("should not retry after the last attempt")
+	}
+
+	if redeliver := o.Redeliver(); redeliver.Attempt != 0 || redeliver.Payload != o.Payload {
+		t.Fatal("redelivery should start over with the same payload")
+	}
+}
+
+func TestOutgoingWebhookDeliverySanitize(t *testing.T) {
+	payload := &OutgoingWebhookPayload{Token: "secret", TeamId: NewId(), Text: "hello"}
+
+	o := OutgoingWebhookDelivery{ContentType: "application/json", Payload: payload.ToJSON()}
+	o.Sanitize()
+	if strings.Contains(o.Payload, "secret") || !strings.Contains(o.Payload, "hello") {
+		t.Fatal("should've removed only the token from the json payload", o.Payload)
+	}
+
+	o = OutgoingWebhookDelivery{ContentType: "application/x-www-form-urlencoded", Payload: payload.ToFormValues()}
+	o.Sanitize()
+	if strings.Contains(o.Payload, "secret") || !strings.Contains(o.Payload, "hello") {
+		t.Fatal("should've removed only the token from the form payload", o.Payload)
+	}
+}
+
+func TestSignOutgoingWebhookPayload(t *testing.T) {
+	sig := SignOutgoingWebhookPayload("key", "The quick brown fox jumps over the lazy dog")
+	if sig != "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8" {
+		t.Fatal("bad signature " + sig)
+	}
+
+	if SignOutgoingWebhookPayload("other", "The quick brown fox jumps over the lazy dog") == sig {
+		t.Fatal("signature should depend on the token")
+	}
+}
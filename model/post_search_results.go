@@ -0,0 +1,155 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PostSearchHit is a post matched by a search engine along with its relevance score and the
+// words in the message that matched the search terms
+type PostSearchHit struct {
+	Id      string
+	Score   float64
+	Matches []string
+}
+
+// SearchMatch is the position of a matched word or phrase in a post's message, given as byte
+// offsets so that Message[Start:End] is the matched text
+type SearchMatch struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type PostSearchResults struct {
+	*PostList
+	Scores  map[string]float64       `json:"scores"`
+	Matches map[string][]SearchMatch `json:"matches"`
+}
+
+func NewPostSearchResults() *PostSearchResults {
+	return &PostSearchResults{
+		PostList: &PostList{},
+		Scores:   make(map[string]float64),
+		Matches:  make(map[string][]SearchMatch),
+	}
+}
+
+func (o *PostSearchResults) AddResult(post *Post, score float64, matches []SearchMatch) {
+	o.AddPost(post)
+	o.AddOrder(post.Id)
+	o.Scores[post.Id] = score
+	o.Matches[post.Id] = matches
+}
+
+func (o *PostSearchResults) Extend(other *PostSearchResults) {
+	o.PostList.Extend(other.PostList)
+
+	for postId, score := range other.Scores {
+		o.Scores[postId] = score
+	}
+
+	for postId, matches := range other.Matches {
+		o.Matches[postId] = matches
+	}
+}
+
+func (o *PostSearchResults) ToJson() string {
+	o.MakeNonNil()
+
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PostSearchResultsFromJson(data io.Reader) *PostSearchResults {
+	decoder := json.NewDecoder(data)
+	var o PostSearchResults
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+// FindSearchMatches returns where the terms appear in the text for highlighting. Terms are matched
+// as whole words ignoring case, quoted terms as phrases and terms ending in * as word prefixes.
+func FindSearchMatches(text string, terms []string) []SearchMatch {
+	matches := []SearchMatch{}
+
+	for _, term := range terms {
+		pattern := ""
+		if strings.HasPrefix(term, "\"") {
+			pattern = regexp.QuoteMeta(strings.Trim(term, "\""))
+		} else if strings.HasSuffix(term, "*") {
+			if prefix := strings.TrimRight(term, "*"); len(prefix) > 0 {
+				pattern = regexp.QuoteMeta(prefix) + `[\pL\d]*`
+			}
+		} else {
+			pattern = regexp.QuoteMeta(term)
+		}
+
+		if len(pattern) == 0 {
+			continue
+		}
+
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			continue
+		}
+
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if isSearchWordBoundary(text, loc[0]) && isSearchWordBoundary(text, loc[1]) {
+				matches = append(matches, SearchMatch{Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+
+	sort.Sort(searchMatchesByStart(matches))
+
+	// merge matches that overlap so every part of the text is only highlighted once
+	merged := []SearchMatch{}
+	for _, match := range matches {
+		if last := len(merged) - 1; last >= 0 && match.Start <= merged[last].End {
+			if match.End > merged[last].End {
+				merged[last].End = match.End
+			}
+		} else {
+			merged = append(merged, match)
+		}
+	}
+
+	return merged
+}
+
+func isSearchWordBoundary(text string, i int) bool {
+	if i == 0 || i == len(text) {
+		return true
+	}
+
+	before, _ := utf8.DecodeLastRuneInString(text[:i])
+	after, _ := utf8.DecodeRuneInString(text[i:])
+
+	return !isSearchWordRune(before) || !isSearchWordRune(after)
+}
+
+func isSearchWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+type searchMatchesByStart []SearchMatch
+
+func (s searchMatchesByStart) Len() int           { return len(s) }
+func (s searchMatchesByStart) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s searchMatchesByStart) Less(i, j int) bool { return s[i].Start < s[j].Start }
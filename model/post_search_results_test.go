@@ -0,0 +1,67 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostSearchResultsJson(t *testing.T) {
+	post := &Post{Id: NewId(), Message: "hello world"}
+
+	results := NewPostSearchResults()
+	results.AddResult(post, 1.5, []SearchMatch{{Start: 6, End: 11}})
+
+	other := NewPostSearchResults()
+	other.AddResult(post, 1.5, []SearchMatch{{Start: 6, End: 11}})
+	results.Extend(other)
+
+	if len(results.Order) != 1 {
+		t.Fatal("extending with the same post shouldn't add it twice")
+	}
+
+	json := results.ToJson()
+	rresults := PostSearchResultsFromJson(strings.NewReader(json))
+
+	if len(rresults.Order) != 1 || rresults.Posts[post.Id].Message != post.Message {
+		t.Fatal("posts don't match")
+	}
+
+	if rresults.Scores[post.Id] != 1.5 || len(rresults.Matches[post.Id]) != 1 || rresults.Matches[post.Id][0].Start != 6 || rresults.Matches[post.Id][0].End != 11 {
+		t.Fatal("scores or matches don't match")
+	}
+
+	// the results should still read as a plain post list for older clients
+	if list := PostListFromJson(strings.NewReader(json)); list == nil || list.Order[0] != post.Id {
+		t.Fatal("should be readable as a post list")
+	}
+}
+
+func TestFindSearchMatches(t *testing.T) {
+	text := "Foo bar, foobar #baz and \"qux quux\" héllo"
+
+	check := func(terms []string, expected ...string) {
+		matches := FindSearchMatches(text, terms)
+		if len(matches) != len(expected) {
+			t.Fatalf("wrong matches for %v: %v", terms, matches)
+		}
+
+		for i, match := range matches {
+			if text[match.Start:match.End] != expected[i] {
+				t.Fatalf("wrong match for %v: %v", terms, text[match.Start:match.End])
+			}
+		}
+	}
+
+	check([]string{"foo"}, "Foo")
+	check([]string{"foo*"}, "Foo", "foobar")
+	check([]string{"bar"}, "bar")
+	check([]string{"#baz"}, "#baz")
+	check([]string{"\"qux quux\""}, "qux quux")
+	check([]string{"\"bar foo\""})
+	check([]string{"HÉLLO"}, "héllo")
+	check([]string{"foo", "foo bar"}, "Foo bar")
+	check([]string{"*", "a"})
+}
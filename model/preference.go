@@ -19,6 +19,8 @@ const (
 
 	PREFERENCE_CATEGORY_LAST     = "last"
 	PREFERENCE_NAME_LAST_CHANNEL = "channel"
+
+	PREFERENCE_CATEGORY_FLAGGED_POST = "flagged_post"
 )
 
 type Preference struct {
@@ -0,0 +1,61 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReactionJson(t *testing.T) {
+	o := Reaction{UserId: NewId(), PostId: NewId(), EmojiName: "smile"}
+	ro := ReactionFromJson(strings.NewReader(o.ToJson()))
+
+	if o.UserId != ro.UserId || o.PostId != ro.PostId || o.EmojiName != ro.EmojiName {
+		t.Fatal("reactions do not match")
+	}
+
+	reactions := ReactionsFromJson(strings.NewReader(ReactionsToJson([]*Reaction{&o})))
+	if len(reactions) != 1 || reactions[0].EmojiName != o.EmojiName {
+		t.Fatal("reaction lists do not match")
+	}
+}
+
+func TestReactionIsValid(t *testing.T) {
+	o := Reaction{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PostId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.EmojiName = "+1"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.EmojiName = "not valid"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.EmojiName = strings.Repeat("a", 65)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
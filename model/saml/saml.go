@@ -0,0 +1,475 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	PROTOCOL_NAMESPACE  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	ASSERTION_NAMESPACE = "urn:oasis:names:tc:SAML:2.0:assertion"
+	METADATA_NAMESPACE  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	HTTP_POST_BINDING     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	HTTP_REDIRECT_BINDING = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+
+	STATUS_SUCCESS            = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	NAMEID_FORMAT_UNSPECIFIED = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	BEARER_CONFIRMATION       = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	// CLOCK_SKEW is how far the IdP's clock is allowed to be off when checking the times in an assertion
+	CLOCK_SKEW = 3 * time.Minute
+
+	// MAX_RESPONSE_SIZE limits how big a response is parsed
+	MAX_RESPONSE_SIZE = 1024 * 1024
+)
+
+// Assertion is what the IdP said about a user in a response that passed validation
+type Assertion struct {
+	Id           string
+	NameId       string
+	Attributes   map[string][]string
+	NotOnOrAfter time.Time
+}
+
+// ServiceProvider signs users in with a SAML 2.0 IdP. AuthnRequests are sent with the HTTP-Redirect binding and
+// signed with the SP's key, and responses are received with the HTTP-POST binding and must be signed with the IdP's
+// certificate.
+type ServiceProvider struct {
+	Settings model.SamlSettings
+
+	Certificate    *x509.Certificate
+	PrivateKey     *rsa.PrivateKey
+	IdpCertificate *x509.Certificate
+
+	mutex        sync.Mutex
+	usedIds      map[string]time.Time
+	usedIdsSweep time.Time
+}
+
+// NewServiceProvider sets up a service provider from the PEM encoded certificates and key named in the settings
+func NewServiceProvider(settings model.SamlSettings, idpCertificate, certificate, privateKey []byte) (*ServiceProvider, *model.AppError) {
+	sp := &ServiceProvider{
+		Settings: settings,
+		usedIds:  make(map[string]time.Time),
+	}
+
+	var err error
+	if sp.IdpCertificate, err = parseCertificate(idpCertificate); err != nil {
+		return nil, model.NewLocAppError("NewServiceProvider", "model.saml.idp_certificate.app_error", nil, err.Error())
+	}
+
+	if sp.Certificate, err = parseCertificate(certificate); err != nil {
+		return nil, model.NewLocAppError("NewServiceProvider", "model.saml.certificate.app_error", nil, err.Error())
+	}
+
+	if sp.PrivateKey, err = parsePrivateKey(privateKey); err != nil {
+		return nil, model.NewLocAppError("NewServiceProvider", "model.saml.private_key.app_error", nil, err.Error())
+	}
+
+	return sp, nil
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		// some IdPs hand out the bare base64 of the certificate
+		der, err := decodeBase64(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return x509.ParseCertificate(der)
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, x509.IncorrectPasswordError
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+		return rsaKey, nil
+	}
+
+	return nil, x509.ErrUnsupportedAlgorithm
+}
+
+// EntityId is how the SP identifies itself to the IdP, which is its assertion consumer service url
+func (sp *ServiceProvider) EntityId() string {
+	return *sp.Settings.AssertionConsumerServiceURL
+}
+
+// Metadata returns the SP's metadata for registering it with the IdP
+func (sp *ServiceProvider) Metadata() string {
+	acs := escapeXml(*sp.Settings.AssertionConsumerServiceURL)
+	certificate := base64.StdEncoding.EncodeToString(sp.Certificate.Raw)
+
+	return `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<md:EntityDescriptor xmlns:md="` + METADATA_NAMESPACE + `" entityID="` + escapeXml(sp.EntityId()) + `">` +
+		`<md:SPSSODescriptor AuthnRequestsSigned="true" WantAssertionsSigned="true" protocolSupportEnumeration="` + PROTOCOL_NAMESPACE + `">` +
+		`<md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="` + DSIG_NAMESPACE + `"><ds:X509Data><ds:X509Certificate>` + certificate + `</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>` +
+		`<md:NameIDFormat>` + NAMEID_FORMAT_UNSPECIFIED + `</md:NameIDFormat>` +
+		`<md:AssertionConsumerService Binding="` + HTTP_POST_BINDING + `" Location="` + acs + `" index="0" isDefault="true"></md:AssertionConsumerService>` +
+		`</md:SPSSODescriptor>` +
+		`</md:EntityDescriptor>`
+}
+
+// NewRequestId returns a new id for an AuthnRequest. It has to be kept, along with the relay state, so that the
+// response can be checked against the request.
+func NewRequestId() string {
+	return "_" + model.NewId()
+}
+
+// BuildRequestUrl returns the url that sends the user to the IdP with a signed AuthnRequest that has the given id.
+// The relay state comes back unchanged with the response.
+func (sp *ServiceProvider) BuildRequestUrl(requestId, relayState string) (string, *model.AppError) {
+	request := `<samlp:AuthnRequest xmlns:samlp="` + PROTOCOL_NAMESPACE + `" xmlns:saml="` + ASSERTION_NAMESPACE + `"` +
+		` AssertionConsumerServiceURL="` + escapeXml(*sp.Settings.AssertionConsumerServiceURL) + `"` +
+		` Destination="` + escapeXml(*sp.Settings.IdpUrl) + `"` +
+		` ID="` + escapeXml(requestId) + `"` +
+		` IssueInstant="` + time.Now().UTC().Format(time.RFC3339) + `"` +
+		` ProtocolBinding="` + HTTP_POST_BINDING + `" Version="2.0">` +
+		`<saml:Issuer>` + escapeXml(sp.EntityId()) + `</saml:Issuer>` +
+		`<samlp:NameIDPolicy AllowCreate="true" Format="` + NAMEID_FORMAT_UNSPECIFIED + `"></samlp:NameIDPolicy>` +
+		`</samlp:AuthnRequest>`
+
+	var deflated bytes.Buffer
+	writer, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	writer.Write([]byte(request))
+	writer.Close()
+
+	// the redirect binding signs the query string rather than the request itself
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if len(relayState) > 0 {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+	query += "&SigAlg=" + url.QueryEscape(RSA_SHA256_ALGORITHM)
+
+	hash := sha256.Sum256([]byte(query))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, sp.PrivateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", model.NewLocAppError("ServiceProvider.BuildRequestUrl", "model.saml.sign_request.app_error", nil, err.Error())
+	}
+	query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+
+	separator := "?"
+	if strings.Contains(*sp.Settings.IdpUrl, "?") {
+		separator = "&"
+	}
+
+	return *sp.Settings.IdpUrl + separator + query, nil
+}
+
+// ParseResponse checks a base64 encoded response that was posted to the assertion consumer service and returns its
+// assertion. The assertion, or the response that it's in, must be signed by the IdP for this SP, answer the request
+// with the given id and still be valid. Each assertion is only accepted once.
+func (sp *ServiceProvider) ParseResponse(encodedResponse, requestId string) (*Assertion, *model.AppError) {
+	if len(encodedResponse) > MAX_RESPONSE_SIZE*4/3 {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_response.app_error", nil, "response too large")
+	}
+
+	data, err := decodeBase64(encodedResponse)
+	if err != nil {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_response.app_error", nil, err.Error())
+	}
+
+	response, err := parseXml(data)
+	if err != nil {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_response.app_error", nil, err.Error())
+	}
+
+	if !response.Is(PROTOCOL_NAMESPACE, "Response") {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_response.app_error", nil, "not a response")
+	}
+
+	// duplicate ids are how signed elements get swapped for unsigned ones
+	if hasDuplicateIds(response) {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_response.app_error", nil, "duplicate ids")
+	}
+
+	if destination := response.Attr("Destination"); len(destination) > 0 && destination != *sp.Settings.AssertionConsumerServiceURL {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_response.app_error", nil, "destination="+destination)
+	}
+
+	if issuer := response.Child(ASSERTION_NAMESPACE, "Issuer"); issuer != nil && issuer.Text() != *sp.Settings.IdpDescriptorUrl {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_issuer.app_error", nil, "issuer="+issuer.Text())
+	}
+
+	// responses the IdP sends without being asked can't be tied to the browser that they're posted from
+	if inResponseTo := response.Attr("InResponseTo"); len(requestId) == 0 || inResponseTo != requestId {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.in_response_to.app_error", nil, "in_response_to="+inResponseTo)
+	}
+
+	statusCode := ""
+	if status := response.Child(PROTOCOL_NAMESPACE, "Status"); status != nil {
+		if code := status.Child(PROTOCOL_NAMESPACE, "StatusCode"); code != nil {
+			statusCode = code.Attr("Value")
+		}
+	}
+	if statusCode != STATUS_SUCCESS {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.status.app_error", nil, "status="+statusCode)
+	}
+
+	if len(response.ChildElements(ASSERTION_NAMESPACE, "EncryptedAssertion")) > 0 {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.encrypted_assertion.app_error", nil, "")
+	}
+
+	assertion := response.Child(ASSERTION_NAMESPACE, "Assertion")
+	if assertion == nil {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_response.app_error", nil, "expected exactly one assertion")
+	}
+
+	if !isSigned(response) && !isSigned(assertion) {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.not_signed.app_error", nil, "")
+	}
+
+	if isSigned(response) {
+		if err := verifySignature(response, sp.IdpCertificate); err != nil {
+			return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_signature.app_error", nil, "response: "+err.Error())
+		}
+	}
+
+	if isSigned(assertion) {
+		if err := verifySignature(assertion, sp.IdpCertificate); err != nil {
+			return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.invalid_signature.app_error", nil, "assertion: "+err.Error())
+		}
+	}
+
+	result, appErr := sp.checkAssertion(assertion, requestId, time.Now())
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if !sp.useAssertionId(result.Id, result.NotOnOrAfter) {
+		return nil, model.NewLocAppError("ServiceProvider.ParseResponse", "model.saml.replayed.app_error", nil, "id="+result.Id)
+	}
+
+	return result, nil
+}
+
+func hasDuplicateIds(root *xmlNode) bool {
+	ids := make(map[string]bool)
+	duplicate := false
+
+	root.walk(func(n *xmlNode) {
+		if id := n.Attr("ID"); len(id) > 0 {
+			if ids[id] {
+				duplicate = true
+			}
+			ids[id] = true
+		}
+	})
+
+	return duplicate
+}
+
+func parseTime(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, s)
+	return t, err == nil
+}
+
+func (sp *ServiceProvider) checkAssertion(assertion *xmlNode, requestId string, now time.Time) (*Assertion, *model.AppError) {
+	result := &Assertion{
+		Id:         assertion.Attr("ID"),
+		Attributes: make(map[string][]string),
+	}
+
+	if len(result.Id) == 0 {
+		return nil, model.NewLocAppError("ServiceProvider.checkAssertion", "model.saml.invalid_response.app_error", nil, "missing assertion id")
+	}
+
+	if issuer := assertion.Child(ASSERTION_NAMESPACE, "Issuer"); issuer == nil || issuer.Text() != *sp.Settings.IdpDescriptorUrl {
+		return nil, model.NewLocAppError("ServiceProvider.checkAssertion", "model.saml.invalid_issuer.app_error", nil, "")
+	}
+
+	subject := assertion.Child(ASSERTION_NAMESPACE, "Subject")
+	if subject == nil {
+		return nil, model.NewLocAppError("ServiceProvider.checkAssertion", "model.saml.invalid_response.app_error", nil, "missing subject")
+	}
+
+	if nameId := subject.Child(ASSERTION_NAMESPACE, "NameID"); nameId != nil {
+		result.NameId = nameId.Text()
+	}
+
+	// the user must be confirmed as the bearer of the assertion, at this SP, for our request and not too long ago
+	confirmed := false
+	for _, confirmation := range subject.ChildElements(ASSERTION_NAMESPACE, "SubjectConfirmation") {
+		if confirmation.Attr("Method") != BEARER_CONFIRMATION {
+			continue
+		}
+
+		data := confirmation.Child(ASSERTION_NAMESPACE, "SubjectConfirmationData")
+		if data == nil {
+			continue
+		}
+
+		if data.Attr("Recipient") != *sp.Settings.AssertionConsumerServiceURL {
+			continue
+		}
+
+		if inResponseTo := data.Attr("InResponseTo"); len(inResponseTo) > 0 && inResponseTo != requestId {
+			continue
+		}
+
+		notOnOrAfter, ok := parseTime(data.Attr("NotOnOrAfter"))
+		if !ok || !now.Before(notOnOrAfter.Add(CLOCK_SKEW)) {
+			continue
+		}
+
+		confirmed = true
+		result.NotOnOrAfter = notOnOrAfter
+	}
+
+	if !confirmed {
+		return nil, model.NewLocAppError("ServiceProvider.checkAssertion", "model.saml.expired.app_error", nil, "no valid subject confirmation")
+	}
+
+	// an assertion without an audience could have been issued to any SP that trusts the IdP
+	conditions := assertion.Child(ASSERTION_NAMESPACE, "Conditions")
+	if conditions == nil || len(conditions.ChildElements(ASSERTION_NAMESPACE, "AudienceRestriction")) == 0 {
+		return nil, model.NewLocAppError("ServiceProvider.checkAssertion", "model.saml.audience.app_error", nil, "no audience restriction")
+	}
+
+	if notBefore, ok := parseTime(conditions.Attr("NotBefore")); ok && now.Add(CLOCK_SKEW).Before(notBefore) {
+		return nil, model.NewLocAppError("ServiceProvider.checkAssertion", "model.saml.expired.app_error", nil, "not valid yet")
+	}
+
+	if value := conditions.Attr("NotOnOrAfter"); len(value) > 0 {
+		notOnOrAfter, ok := parseTime(value)
+		if !ok || !now.Before(notOnOrAfter.Add(CLOCK_SKEW)) {
+			return nil, model.NewLocAppError("ServiceProvider.checkAssertion", "model.saml.expired.app_error", nil, "conditions expired")
+		}
+	}
+
+	for _, restriction := range conditions.ChildElements(ASSERTION_NAMESPACE, "AudienceRestriction") {
+		found := false
+		for _, audience := range restriction.ChildElements(ASSERTION_NAMESPACE, "Audience") {
+			if audience.Text() == sp.EntityId() {
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, model.NewLocAppError("ServiceProvider.checkAssertion", "model.saml.audience.app_error", nil, "")
+		}
+	}
+
+	for _, statement := range assertion.ChildElements(ASSERTION_NAMESPACE, "AttributeStatement") {
+		for _, attribute := range statement.ChildElements(ASSERTION_NAMESPACE, "Attribute") {
+			var values []string
+			for _, value := range attribute.ChildElements(ASSERTION_NAMESPACE, "AttributeValue") {
+				values = append(values, value.Text())
+			}
+
+			for _, name := range []string{attribute.Attr("Name"), attribute.Attr("FriendlyName")} {
+				if len(name) > 0 {
+					result.Attributes[name] = append(result.Attributes[name], values...)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// useAssertionId remembers the ids of assertions until they expire so that a response can't be posted twice
+func (sp *ServiceProvider) useAssertionId(id string, notOnOrAfter time.Time) bool {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(sp.usedIdsSweep) > time.Minute {
+		for usedId, expiry := range sp.usedIds {
+			if now.After(expiry.Add(CLOCK_SKEW)) {
+				delete(sp.usedIds, usedId)
+			}
+		}
+		sp.usedIdsSweep = now
+	}
+
+	if _, ok := sp.usedIds[id]; ok {
+		return false
+	}
+
+	sp.usedIds[id] = notOnOrAfter
+	return true
+}
+
+func (a *Assertion) attribute(name string) string {
+	if len(name) == 0 {
+		return ""
+	}
+
+	if values := a.Attributes[name]; len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+
+	return ""
+}
+
+// GetUser maps an assertion onto a user using the attributes that are configured for the IdP. The user is
+// identified by the id attribute if there is one, or by the assertion's subject otherwise.
+func (sp *ServiceProvider) GetUser(assertion *Assertion) (*model.User, *model.AppError) {
+	authData := assertion.NameId
+	if len(*sp.Settings.IdAttribute) > 0 {
+		authData = assertion.attribute(*sp.Settings.IdAttribute)
+	}
+
+	if len(authData) == 0 {
+		return nil, model.NewLocAppError("ServiceProvider.GetUser", "model.saml.missing_id.app_error", nil, "")
+	}
+
+	email := assertion.attribute(*sp.Settings.EmailAttribute)
+	if len(email) == 0 {
+		return nil, model.NewLocAppError("ServiceProvider.GetUser", "model.saml.missing_email.app_error", nil, "")
+	}
+
+	username := assertion.attribute(*sp.Settings.UsernameAttribute)
+	if len(username) == 0 {
+		username = strings.Split(email, "@")[0]
+	}
+
+	user := &model.User{
+		Username:    model.CleanUsername(username),
+		Email:       strings.ToLower(email),
+		FirstName:   assertion.attribute(*sp.Settings.FirstNameAttribute),
+		LastName:    assertion.attribute(*sp.Settings.LastNameAttribute),
+		Nickname:    assertion.attribute(*sp.Settings.NicknameAttribute),
+		AuthData:    &authData,
+		AuthService: model.USER_AUTH_SERVICE_SAML,
+	}
+
+	if locale := assertion.attribute(*sp.Settings.LocaleAttribute); len(locale) > 0 {
+		user.Locale = locale
+	}
+
+	return user, nil
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/model/saml/samltest"
+)
+
+const (
+	testIdpEntityId = "https://idp.example.com/metadata"
+	testAcsUrl      = "https://mattermost.example.com/login/sso/saml"
+	testRequestId   = "_request"
+)
+
+// newTestIdP returns an IdP that answers the request with testRequestId
+func newTestIdP() *samltest.FakeIdP {
+	idp := samltest.NewFakeIdP(testIdpEntityId, testAcsUrl)
+	idp.InResponseTo = testRequestId
+	return idp
+}
+
+func newTestServiceProvider(t *testing.T, idp *samltest.FakeIdP) (*ServiceProvider, []byte) {
+	config := model.Config{}
+	config.SetDefaults()
+	settings := config.SamlSettings
+	*settings.Enable = true
+	*settings.IdpUrl = "https://idp.example.com/sso"
+	*settings.IdpDescriptorUrl = testIdpEntityId
+	*settings.AssertionConsumerServiceURL = testAcsUrl
+	*settings.EmailAttribute = "Email"
+	*settings.UsernameAttribute = "Username"
+	*settings.FirstNameAttribute = "FirstName"
+	*settings.LastNameAttribute = "LastName"
+
+	certificate, privateKey := samltest.GenerateCertificate("mattermost")
+
+	sp, err := NewServiceProvider(settings, idp.Certificate, certificate, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sp, certificate
+}
+
+func encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestMetadata(t *testing.T) {
+	idp := newTestIdP()
+	sp, _ := newTestServiceProvider(t, idp)
+
+	metadata, err := parseXml([]byte(sp.Metadata()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !metadata.Is(METADATA_NAMESPACE, "EntityDescriptor") || metadata.Attr("entityID") != testAcsUrl {
+		t.Fatal("should've described the SP")
+	}
+
+	descriptor := metadata.Child(METADATA_NAMESPACE, "SPSSODescriptor")
+	if descriptor == nil || descriptor.Attr("AuthnRequestsSigned") != "true" || descriptor.Attr("WantAssertionsSigned") != "true" {
+		t.Fatal("should've asked for signed assertions")
+	}
+
+	if acs := descriptor.Child(METADATA_NAMESPACE, "AssertionConsumerService"); acs == nil || acs.Attr("Location") != testAcsUrl || acs.Attr("Binding") != HTTP_POST_BINDING {
+		t.Fatal("should've included the assertion consumer service")
+	}
+
+	if key := descriptor.Child(METADATA_NAMESPACE, "KeyDescriptor"); key == nil || !strings.Contains(sp.Metadata(), base64.StdEncoding.EncodeToString(sp.Certificate.Raw)) {
+		t.Fatal("should've included the SP's certificate")
+	}
+}
+
+func TestBuildRequestUrl(t *testing.T) {
+	idp := newTestIdP()
+	sp, certificate := newTestServiceProvider(t, idp)
+
+	requestId := NewRequestId()
+	requestUrl, err := sp.BuildRequestUrl(requestId, "relay&state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(requestUrl, "https://idp.example.com/sso?") {
+		t.Fatal("should've sent the request to the IdP", requestUrl)
+	}
+
+	request, relayState, parseErr := idp.ParseRequest(requestUrl, certificate)
+	if parseErr != nil {
+		t.Fatal("should've signed the request", parseErr)
+	}
+
+	if relayState != "relay&state" {
+		t.Fatal("should've included the relay state")
+	}
+
+	parsed, parseErr := parseXml([]byte(request))
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	if !parsed.Is(PROTOCOL_NAMESPACE, "AuthnRequest") || parsed.Attr("AssertionConsumerServiceURL") != testAcsUrl || parsed.Attr("ID") != requestId {
+		t.Fatal("should've asked for a response at the assertion consumer service")
+	}
+
+	if issuer := parsed.Child(ASSERTION_NAMESPACE, "Issuer"); issuer == nil || issuer.Text() != testAcsUrl {
+		t.Fatal("should've said who the request is from")
+	}
+
+	otherCertificate, _ := samltest.GenerateCertificate("other")
+	if _, _, err := idp.ParseRequest(requestUrl, otherCertificate); err == nil {
+		t.Fatal("shouldn't have verified with another certificate")
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	idp := newTestIdP()
+	sp, _ := newTestServiceProvider(t, idp)
+
+	attributes := map[string]string{
+		"Email":     "Success+Saml@simulator.amazonses.com",
+		"Username":  "saml.user",
+		"FirstName": "Sam",
+		"LastName":  "L",
+	}
+
+	response := idp.Response("nameid", attributes)
+
+	assertion, err := sp.ParseResponse(response, testRequestId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assertion.NameId != "nameid" || assertion.attribute("Email") != attributes["Email"] {
+		t.Fatal("should've read the assertion")
+	}
+
+	if _, err := sp.ParseResponse(response, testRequestId); err == nil || err.Id != "model.saml.replayed.app_error" {
+		t.Fatal("shouldn't accept the same assertion twice", err)
+	}
+
+	idp.SignResponse = true
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), testRequestId); err != nil {
+		t.Fatal("should accept a signed response", err)
+	}
+	idp.SignResponse = false
+
+	tampered := strings.Replace(idp.ResponseXml("nameid", attributes), "saml.user", "admin", 1)
+	if _, err := sp.ParseResponse(encode(tampered), testRequestId); err == nil || err.Id != "model.saml.invalid_signature.app_error" {
+		t.Fatal("shouldn't accept a response that was changed after it was signed", err)
+	}
+
+	other := newTestIdP()
+	if _, err := sp.ParseResponse(other.Response("nameid", attributes), testRequestId); err == nil || err.Id != "model.saml.invalid_signature.app_error" {
+		t.Fatal("shouldn't accept a response signed by another IdP", err)
+	}
+
+	unsigned := idp.ResponseXml("nameid", attributes)
+	unsigned = unsigned[:strings.Index(unsigned, "<ds:Signature")] + unsigned[strings.Index(unsigned, "</ds:Signature>")+len("</ds:Signature>"):]
+	if _, err := sp.ParseResponse(encode(unsigned), testRequestId); err == nil || err.Id != "model.saml.not_signed.app_error" {
+		t.Fatal("shouldn't accept an unsigned response", err)
+	}
+
+	idp.Now = time.Now().Add(-time.Hour)
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), testRequestId); err == nil || err.Id != "model.saml.expired.app_error" {
+		t.Fatal("shouldn't accept an expired assertion", err)
+	}
+
+	idp.Now = time.Now().Add(time.Hour)
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), testRequestId); err == nil || err.Id != "model.saml.expired.app_error" {
+		t.Fatal("shouldn't accept an assertion that isn't valid yet", err)
+	}
+	idp.Now = time.Time{}
+
+	idp.Audience = "https://other.example.com"
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), testRequestId); err == nil || err.Id != "model.saml.audience.app_error" {
+		t.Fatal("shouldn't accept an assertion meant for another SP", err)
+	}
+	idp.Audience = ""
+
+	idp.OmitAudience = true
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), testRequestId); err == nil || err.Id != "model.saml.audience.app_error" {
+		t.Fatal("shouldn't accept an assertion without an audience", err)
+	}
+	idp.OmitAudience = false
+
+	idp.Recipient = "https://other.example.com/login/sso/saml"
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), testRequestId); err == nil || err.Id != "model.saml.expired.app_error" {
+		t.Fatal("shouldn't accept an assertion confirmed for another SP", err)
+	}
+	idp.Recipient = ""
+
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), "_other"); err == nil || err.Id != "model.saml.in_response_to.app_error" {
+		t.Fatal("shouldn't accept a response to another request", err)
+	}
+
+	idp.InResponseTo = ""
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), testRequestId); err == nil || err.Id != "model.saml.in_response_to.app_error" {
+		t.Fatal("shouldn't accept a response that wasn't asked for", err)
+	}
+	idp.InResponseTo = testRequestId
+
+	idp.EntityId = "https://other.example.com/metadata"
+	if _, err := sp.ParseResponse(idp.Response("nameid", attributes), testRequestId); err == nil || err.Id != "model.saml.invalid_issuer.app_error" {
+		t.Fatal("shouldn't accept a response from another issuer", err)
+	}
+}
+
+func TestParseResponseSignatureWrapping(t *testing.T) {
+	idp := newTestIdP()
+	sp, _ := newTestServiceProvider(t, idp)
+
+	signed := idp.ResponseXml("victim", map[string]string{"Email": "victim@example.com"})
+	start := strings.Index(signed, "<saml:Assertion")
+	end := strings.Index(signed, "</saml:Assertion>") + len("</saml:Assertion>")
+	assertion := signed[start:end]
+
+	// move the signed assertion somewhere it won't be looked at and put a forged one in its place
+	forged := strings.Replace(assertion, "victim", "attacker", -1)
+	forged = strings.Replace(forged, `ID="`, `ID="forged`, 1)
+	wrapped := signed[:start] + `<samlp:Extensions>` + assertion + `</samlp:Extensions>` + forged + signed[end:]
+
+	if _, err := sp.ParseResponse(encode(wrapped), testRequestId); err == nil {
+		t.Fatal("shouldn't accept a forged assertion next to a signed one")
+	}
+
+	// or keep the id of the signed assertion so that the signature points at the forged one
+	forged = strings.Replace(assertion, "victim", "attacker", -1)
+	wrapped = signed[:start] + forged + `<samlp:Extensions>` + assertion + `</samlp:Extensions>` + signed[end:]
+
+	if _, err := sp.ParseResponse(encode(wrapped), testRequestId); err == nil {
+		t.Fatal("shouldn't accept a forged assertion with the id of a signed one")
+	}
+
+	twice := signed[:end] + assertion + signed[end:]
+	if _, err := sp.ParseResponse(encode(twice), testRequestId); err == nil {
+		t.Fatal("shouldn't accept more than one assertion")
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	idp := newTestIdP()
+	sp, _ := newTestServiceProvider(t, idp)
+
+	assertion, err := sp.ParseResponse(idp.Response("nameid", map[string]string{
+		"Email":     "Success+Saml@simulator.amazonses.com",
+		"Username":  "Saml.User",
+		"FirstName": "Sam",
+		"LastName":  "L",
+	}), testRequestId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := sp.GetUser(assertion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Email != "success+saml@simulator.amazonses.com" || user.Username != "saml.user" || user.FirstName != "Sam" || user.LastName != "L" {
+		t.Fatal("should've mapped the attributes onto the user", user)
+	}
+
+	if *user.AuthData != "nameid" || user.AuthService != model.USER_AUTH_SERVICE_SAML {
+		t.Fatal("should've identified the user by the name id")
+	}
+
+	*sp.Settings.IdAttribute = "Username"
+	if user, err := sp.GetUser(assertion); err != nil || *user.AuthData != "Saml.User" {
+		t.Fatal("should've identified the user by the id attribute")
+	}
+	*sp.Settings.IdAttribute = ""
+
+	*sp.Settings.UsernameAttribute = ""
+	if user, err := sp.GetUser(assertion); err != nil || user.Username != "success-saml" {
+		t.Fatal("should've made a username from the email", user.Username)
+	}
+
+	*sp.Settings.EmailAttribute = "Missing"
+	if _, err := sp.GetUser(assertion); err == nil {
+		t.Fatal("shouldn't map a user without an email")
+	}
+}
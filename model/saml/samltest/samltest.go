@@ -0,0 +1,316 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package samltest plays the part of a SAML 2.0 IdP for tests of the SAML service provider
+package samltest
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PROTOCOL_NAMESPACE  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	ASSERTION_NAMESPACE = "urn:oasis:names:tc:SAML:2.0:assertion"
+	DSIG_NAMESPACE      = "http://www.w3.org/2000/09/xmldsig#"
+)
+
+// GenerateCertificate makes a self signed certificate and its key, both PEM encoded
+func GenerateCertificate(commonName string) ([]byte, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return certificate, privateKey
+}
+
+// FakeIdP vouches for whoever it's asked to without asking for a password. The responses that it builds are written
+// out already canonicalized so that they can be signed without a canonicalizer of its own.
+type FakeIdP struct {
+	EntityId string
+	AcsUrl   string
+
+	// Audience is who assertions are meant for, which is the AcsUrl unless it's set
+	Audience string
+
+	// OmitAudience leaves the audience restriction out of assertions
+	OmitAudience bool
+
+	// Recipient is where the subject is confirmed for, which is the AcsUrl unless it's set
+	Recipient string
+
+	// Now is when responses are issued, which is the current time unless it's set
+	Now time.Time
+
+	// Lifetime is how long assertions are valid for
+	Lifetime time.Duration
+
+	// SignResponse signs the whole response instead of the assertion in it
+	SignResponse bool
+
+	// InResponseTo is the id of the request that responses answer, which is set to the last request that was parsed
+	InResponseTo string
+
+	Certificate []byte
+
+	mutex   sync.Mutex
+	key     *rsa.PrivateKey
+	counter int
+}
+
+func NewFakeIdP(entityId, acsUrl string) *FakeIdP {
+	certificate, privateKey := GenerateCertificate("samltest")
+
+	block, _ := pem.Decode(privateKey)
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		panic(err)
+	}
+
+	return &FakeIdP{
+		EntityId:    entityId,
+		AcsUrl:      acsUrl,
+		Lifetime:    5 * time.Minute,
+		Certificate: certificate,
+		key:         key,
+	}
+}
+
+func (idp *FakeIdP) newId() string {
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+
+	idp.counter++
+	return "_samltest" + strconv.Itoa(idp.counter)
+}
+
+// ParseRequest checks the signature of an AuthnRequest sent with the HTTP-Redirect binding against the SP's
+// certificate and returns the request and its relay state. Responses answer the request from then on.
+func (idp *FakeIdP) ParseRequest(requestUrl string, spCertificate []byte) (string, string, error) {
+	parsed, err := url.Parse(requestUrl)
+	if err != nil {
+		return "", "", err
+	}
+
+	// the signature covers the parameters as they were sent, so they can't be taken from the parsed query
+	var signed []string
+	var signature string
+	for _, param := range strings.Split(parsed.RawQuery, "&") {
+		if strings.HasPrefix(param, "Signature=") {
+			signature = strings.TrimPrefix(param, "Signature=")
+		} else {
+			signed = append(signed, param)
+		}
+	}
+
+	query := parsed.Query()
+	if query.Get("SigAlg") != "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256" {
+		return "", "", errors.New("unexpected signature algorithm")
+	}
+
+	block, _ := pem.Decode(spCertificate)
+	if block == nil {
+		return "", "", errors.New("invalid certificate")
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	signature, err = url.QueryUnescape(signature)
+	if err != nil {
+		return "", "", err
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(signed, "&")))
+	if err := rsa.VerifyPKCS1v15(certificate.PublicKey.(*rsa.PublicKey), crypto.SHA256, hash[:], signatureBytes); err != nil {
+		return "", "", err
+	}
+
+	deflated, err := base64.StdEncoding.DecodeString(query.Get("SAMLRequest"))
+	if err != nil {
+		return "", "", err
+	}
+
+	request, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		return "", "", err
+	}
+
+	var parsedRequest struct {
+		Id string `xml:"ID,attr"`
+	}
+	if err := xml.Unmarshal(request, &parsedRequest); err != nil {
+		return "", "", err
+	}
+	idp.InResponseTo = parsedRequest.Id
+
+	return string(request), query.Get("RelayState"), nil
+}
+
+// ResponseXml returns a successful response that vouches for the user with the given name id and attributes
+func (idp *FakeIdP) ResponseXml(nameId string, attributes map[string]string) string {
+	now := idp.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	audience := idp.Audience
+	if len(audience) == 0 {
+		audience = idp.AcsUrl
+	}
+
+	audienceRestriction := `<saml:AudienceRestriction><saml:Audience>` + escapeText(audience) + `</saml:Audience></saml:AudienceRestriction>`
+	if idp.OmitAudience {
+		audienceRestriction = ""
+	}
+
+	recipient := idp.Recipient
+	if len(recipient) == 0 {
+		recipient = idp.AcsUrl
+	}
+
+	// attributes are written in the order that they're canonicalized in
+	inResponseTo := ""
+	if len(idp.InResponseTo) > 0 {
+		inResponseTo = ` InResponseTo="` + escapeAttr(idp.InResponseTo) + `"`
+	}
+
+	issueInstant := now.Format(time.RFC3339)
+	notOnOrAfter := now.Add(idp.Lifetime).Format(time.RFC3339)
+
+	var names []string
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statement := ""
+	for _, name := range names {
+		statement += `<saml:Attribute Name="` + escapeAttr(name) + `"><saml:AttributeValue>` + escapeText(attributes[name]) + `</saml:AttributeValue></saml:Attribute>`
+	}
+
+	assertionId := idp.newId()
+	assertionIssuer := `<saml:Issuer>` + escapeText(idp.EntityId) + `</saml:Issuer>`
+	assertionBody := `<saml:Subject>` +
+		`<saml:NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified">` + escapeText(nameId) + `</saml:NameID>` +
+		`<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">` +
+		`<saml:SubjectConfirmationData` + inResponseTo + ` NotOnOrAfter="` + notOnOrAfter + `" Recipient="` + escapeAttr(recipient) + `"></saml:SubjectConfirmationData>` +
+		`</saml:SubjectConfirmation>` +
+		`</saml:Subject>` +
+		`<saml:Conditions NotBefore="` + issueInstant + `" NotOnOrAfter="` + notOnOrAfter + `">` +
+		audienceRestriction +
+		`</saml:Conditions>` +
+		`<saml:AttributeStatement>` + statement + `</saml:AttributeStatement>` +
+		`</saml:Assertion>`
+	assertionStart := `<saml:Assertion xmlns:saml="` + ASSERTION_NAMESPACE + `" ID="` + assertionId + `" IssueInstant="` + issueInstant + `" Version="2.0">`
+
+	assertion := assertionStart + assertionIssuer + assertionBody
+	if !idp.SignResponse {
+		assertion = assertionStart + assertionIssuer + idp.Sign(assertionId, assertionStart+assertionIssuer+assertionBody) + assertionBody
+	}
+
+	responseId := idp.newId()
+	responseStart := `<samlp:Response xmlns:samlp="` + PROTOCOL_NAMESPACE + `" Destination="` + escapeAttr(idp.AcsUrl) + `" ID="` + responseId + `"` + inResponseTo + ` IssueInstant="` + issueInstant + `" Version="2.0">`
+	responseIssuer := `<saml:Issuer xmlns:saml="` + ASSERTION_NAMESPACE + `">` + escapeText(idp.EntityId) + `</saml:Issuer>`
+	responseBody := `<samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"></samlp:StatusCode></samlp:Status>` +
+		assertion +
+		`</samlp:Response>`
+
+	if idp.SignResponse {
+		return responseStart + responseIssuer + idp.Sign(responseId, responseStart+responseIssuer+responseBody) + responseBody
+	}
+
+	return responseStart + responseIssuer + responseBody
+}
+
+// Response returns a response like ResponseXml does, encoded to be posted to the SP
+func (idp *FakeIdP) Response(nameId string, attributes map[string]string) string {
+	return base64.StdEncoding.EncodeToString([]byte(idp.ResponseXml(nameId, attributes)))
+}
+
+// Sign returns an enveloped signature for the element with the given id, which must be passed in its canonical form
+// without the signature
+func (idp *FakeIdP) Sign(id, canonical string) string {
+	digest := sha256.Sum256([]byte(canonical))
+
+	signedInfo := `<ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"></ds:CanonicalizationMethod>` +
+		`<ds:SignatureMethod Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"></ds:SignatureMethod>` +
+		`<ds:Reference URI="#` + id + `">` +
+		`<ds:Transforms>` +
+		`<ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"></ds:Transform>` +
+		`<ds:Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"></ds:Transform>` +
+		`</ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"></ds:DigestMethod>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue>` +
+		`</ds:Reference>` +
+		`</ds:SignedInfo>`
+
+	// on its own the SignedInfo has to declare the namespace that it's in
+	canonicalSignedInfo := `<ds:SignedInfo xmlns:ds="` + DSIG_NAMESPACE + `">` + strings.TrimPrefix(signedInfo, `<ds:SignedInfo>`)
+	hash := sha256.Sum256([]byte(canonicalSignedInfo))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, hash[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return `<ds:Signature xmlns:ds="` + DSIG_NAMESPACE + `">` +
+		signedInfo +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(signature) + `</ds:SignatureValue>` +
+		`</ds:Signature>`
+}
+
+var textReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+var attrReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", "\"", "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escapeText(s string) string {
+	return textReplacer.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrReplacer.Replace(s)
+}
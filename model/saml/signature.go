@@ -0,0 +1,179 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const (
+	DSIG_NAMESPACE = "http://www.w3.org/2000/09/xmldsig#"
+
+	EXC_C14N_ALGORITHM  = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	ENVELOPED_ALGORITHM = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+
+	RSA_SHA1_ALGORITHM   = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	RSA_SHA256_ALGORITHM = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	RSA_SHA512_ALGORITHM = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+
+	SHA1_ALGORITHM   = "http://www.w3.org/2000/09/xmldsig#sha1"
+	SHA256_ALGORITHM = "http://www.w3.org/2001/04/xmlenc#sha256"
+	SHA512_ALGORITHM = "http://www.w3.org/2001/04/xmlenc#sha512"
+)
+
+var signatureHashes = map[string]crypto.Hash{
+	RSA_SHA1_ALGORITHM:   crypto.SHA1,
+	RSA_SHA256_ALGORITHM: crypto.SHA256,
+	RSA_SHA512_ALGORITHM: crypto.SHA512,
+}
+
+var digestHashes = map[string]crypto.Hash{
+	SHA1_ALGORITHM:   crypto.SHA1,
+	SHA256_ALGORITHM: crypto.SHA256,
+	SHA512_ALGORITHM: crypto.SHA512,
+}
+
+// isSigned checks if an element has an enveloped signature
+func isSigned(el *xmlNode) bool {
+	return len(el.ChildElements(DSIG_NAMESPACE, "Signature")) > 0
+}
+
+// verifySignature checks the enveloped signature of an element against the IdP's certificate. Only signatures that
+// cover exactly that element with Exclusive XML Canonicalization are accepted, and the key in the signature itself
+// is never trusted.
+func verifySignature(el *xmlNode, cert *x509.Certificate) error {
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("the IdP certificate doesn't have an RSA key")
+	}
+
+	signature := el.Child(DSIG_NAMESPACE, "Signature")
+	if signature == nil {
+		return errors.New("expected exactly one signature")
+	}
+
+	signedInfo := signature.Child(DSIG_NAMESPACE, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("missing SignedInfo")
+	}
+
+	canonicalizationMethod := signedInfo.Child(DSIG_NAMESPACE, "CanonicalizationMethod")
+	if canonicalizationMethod == nil || canonicalizationMethod.Attr("Algorithm") != EXC_C14N_ALGORITHM {
+		return errors.New("unsupported canonicalization method")
+	}
+
+	signatureMethod := signedInfo.Child(DSIG_NAMESPACE, "SignatureMethod")
+	if signatureMethod == nil {
+		return errors.New("missing SignatureMethod")
+	}
+
+	signatureHash, ok := signatureHashes[signatureMethod.Attr("Algorithm")]
+	if !ok {
+		return errors.New("unsupported signature method " + signatureMethod.Attr("Algorithm"))
+	}
+
+	reference := signedInfo.Child(DSIG_NAMESPACE, "Reference")
+	if reference == nil {
+		return errors.New("expected exactly one reference")
+	}
+
+	if id := el.Attr("ID"); len(id) == 0 || reference.Attr("URI") != "#"+id {
+		return errors.New("the signature doesn't reference the signed element")
+	}
+
+	var referencePrefixes []string
+	if transforms := reference.Child(DSIG_NAMESPACE, "Transforms"); transforms != nil {
+		canonicalized := false
+		for _, transform := range transforms.ChildElements(DSIG_NAMESPACE, "Transform") {
+			switch transform.Attr("Algorithm") {
+			case ENVELOPED_ALGORITHM:
+			case EXC_C14N_ALGORITHM:
+				canonicalized = true
+				referencePrefixes = inclusivePrefixes(transform)
+			default:
+				return errors.New("unsupported transform " + transform.Attr("Algorithm"))
+			}
+		}
+
+		if !canonicalized {
+			return errors.New("unsupported canonicalization method")
+		}
+	} else {
+		return errors.New("unsupported canonicalization method")
+	}
+
+	digestMethod := reference.Child(DSIG_NAMESPACE, "DigestMethod")
+	if digestMethod == nil {
+		return errors.New("missing DigestMethod")
+	}
+
+	digestHash, ok := digestHashes[digestMethod.Attr("Algorithm")]
+	if !ok {
+		return errors.New("unsupported digest method " + digestMethod.Attr("Algorithm"))
+	}
+
+	digestValue := reference.Child(DSIG_NAMESPACE, "DigestValue")
+	if digestValue == nil {
+		return errors.New("missing DigestValue")
+	}
+
+	expectedDigest, err := decodeBase64(digestValue.Text())
+	if err != nil {
+		return errors.New("invalid DigestValue")
+	}
+
+	hasher := digestHash.New()
+	hasher.Write(canonicalize(el, referencePrefixes, signature))
+	if subtle.ConstantTimeCompare(hasher.Sum(nil), expectedDigest) != 1 {
+		return errors.New("the digest doesn't match")
+	}
+
+	signatureValue := signature.Child(DSIG_NAMESPACE, "SignatureValue")
+	if signatureValue == nil {
+		return errors.New("missing SignatureValue")
+	}
+
+	signatureBytes, err := decodeBase64(signatureValue.Text())
+	if err != nil {
+		return errors.New("invalid SignatureValue")
+	}
+
+	hasher = signatureHash.New()
+	hasher.Write(canonicalize(signedInfo, inclusivePrefixes(canonicalizationMethod), nil))
+	if err := rsa.VerifyPKCS1v15(publicKey, signatureHash, hasher.Sum(nil), signatureBytes); err != nil {
+		return errors.New("the signature doesn't match")
+	}
+
+	return nil
+}
+
+// inclusivePrefixes returns the prefixes from an InclusiveNamespaces element under a canonicalization method
+func inclusivePrefixes(method *xmlNode) []string {
+	if inclusive := method.Child(EXC_C14N_ALGORITHM, "InclusiveNamespaces"); inclusive != nil {
+		var prefixes []string
+		for _, prefix := range strings.Fields(inclusive.Attr("PrefixList")) {
+			if prefix == "#default" {
+				prefix = ""
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		return prefixes
+	}
+
+	return nil
+}
+
+// decodeBase64 decodes base64 that may have been wrapped onto several lines
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
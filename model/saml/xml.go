@@ -0,0 +1,317 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	XML_NAMESPACE = "http://www.w3.org/XML/1998/namespace"
+)
+
+// xmlNode is an element of a parsed document. Unlike encoding/xml it keeps the prefixes that were used in the
+// document and the namespaces that were in scope for each element, which canonicalization needs.
+type xmlNode struct {
+	Prefix     string
+	Local      string
+	Attrs      []xmlAttr
+	Namespaces map[string]string
+	Children   []interface{}
+	Parent     *xmlNode
+}
+
+type xmlAttr struct {
+	Prefix string
+	Local  string
+	Value  string
+}
+
+// parseXml reads a document into a tree of xmlNodes. Comments and processing instructions are dropped and documents
+// with a DTD are refused.
+func parseXml(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root *xmlNode
+	var current *xmlNode
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := current
+			namespaces := map[string]string{"xml": XML_NAMESPACE}
+			if parent != nil {
+				namespaces = parent.Namespaces
+			}
+
+			node := &xmlNode{Prefix: t.Name.Space, Local: t.Name.Local, Parent: parent}
+
+			copied := false
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					if !copied {
+						namespaces = copyNamespaces(namespaces)
+						copied = true
+					}
+
+					if attr.Name.Space == "xmlns" {
+						namespaces[attr.Name.Local] = attr.Value
+					} else {
+						namespaces[""] = attr.Value
+					}
+				} else {
+					node.Attrs = append(node.Attrs, xmlAttr{attr.Name.Space, attr.Name.Local, attr.Value})
+				}
+			}
+			node.Namespaces = namespaces
+
+			if _, ok := namespaces[node.Prefix]; !ok && len(node.Prefix) > 0 {
+				return nil, errors.New("undeclared namespace prefix " + node.Prefix)
+			}
+
+			if parent == nil {
+				if root != nil {
+					return nil, errors.New("more than one root element")
+				}
+				root = node
+			} else {
+				parent.Children = append(parent.Children, node)
+			}
+			current = node
+		case xml.EndElement:
+			if current == nil || current.Prefix != t.Name.Space || current.Local != t.Name.Local {
+				return nil, errors.New("unexpected end element")
+			}
+			current = current.Parent
+		case xml.CharData:
+			if current != nil {
+				current.Children = append(current.Children, string(t))
+			}
+		case xml.Directive:
+			return nil, errors.New("DTDs aren't allowed")
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+
+	return root, nil
+}
+
+func copyNamespaces(namespaces map[string]string) map[string]string {
+	copied := make(map[string]string, len(namespaces)+1)
+	for prefix, uri := range namespaces {
+		copied[prefix] = uri
+	}
+	return copied
+}
+
+func (n *xmlNode) Namespace() string {
+	return n.Namespaces[n.Prefix]
+}
+
+func (n *xmlNode) Is(namespace, local string) bool {
+	return n.Local == local && n.Namespace() == namespace
+}
+
+func (n *xmlNode) Attr(local string) string {
+	for _, attr := range n.Attrs {
+		if attr.Prefix == "" && attr.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func (n *xmlNode) ChildElements(namespace, local string) []*xmlNode {
+	var children []*xmlNode
+	for _, child := range n.Children {
+		if node, ok := child.(*xmlNode); ok && node.Is(namespace, local) {
+			children = append(children, node)
+		}
+	}
+	return children
+}
+
+// Child returns the only child element with the given name, or nil if there isn't exactly one
+func (n *xmlNode) Child(namespace, local string) *xmlNode {
+	if children := n.ChildElements(namespace, local); len(children) == 1 {
+		return children[0]
+	}
+	return nil
+}
+
+func (n *xmlNode) Text() string {
+	text := ""
+	for _, child := range n.Children {
+		if s, ok := child.(string); ok {
+			text += s
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// walk calls f for the node and each of its descendants
+func (n *xmlNode) walk(f func(*xmlNode)) {
+	f(n)
+	for _, child := range n.Children {
+		if node, ok := child.(*xmlNode); ok {
+			node.walk(f)
+		}
+	}
+}
+
+// canonicalize serializes the subtree at n with Exclusive XML Canonicalization, leaving out the excluded element.
+// Namespaces in inclusivePrefixes are rendered where they're in scope, as if they were used.
+func canonicalize(n *xmlNode, inclusivePrefixes []string, exclude *xmlNode) []byte {
+	var buf bytes.Buffer
+	writeCanonical(&buf, n, map[string]string{}, inclusivePrefixes, exclude)
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, n *xmlNode, rendered map[string]string, inclusivePrefixes []string, exclude *xmlNode) {
+	if n == exclude {
+		return
+	}
+
+	used := []string{n.Prefix}
+	for _, attr := range n.Attrs {
+		if len(attr.Prefix) > 0 {
+			used = append(used, attr.Prefix)
+		}
+	}
+	for _, prefix := range inclusivePrefixes {
+		if _, ok := n.Namespaces[prefix]; ok {
+			used = append(used, prefix)
+		}
+	}
+
+	var decls []string
+	declared := make(map[string]bool)
+	for _, prefix := range used {
+		if prefix == "xml" || declared[prefix] {
+			continue
+		}
+		declared[prefix] = true
+
+		uri := n.Namespaces[prefix]
+		if last, ok := rendered[prefix]; (ok && last == uri) || (!ok && len(uri) == 0) {
+			continue
+		}
+
+		decls = append(decls, prefix)
+	}
+	sort.Strings(decls)
+
+	if len(decls) > 0 {
+		rendered = copyNamespaces(rendered)
+	}
+
+	buf.WriteString("<")
+	buf.WriteString(qualifiedName(n.Prefix, n.Local))
+
+	for _, prefix := range decls {
+		uri := n.Namespaces[prefix]
+		rendered[prefix] = uri
+
+		buf.WriteString(" ")
+		buf.WriteString(qualifiedName("xmlns", prefix))
+		buf.WriteString("=\"")
+		buf.WriteString(escapeCanonicalAttr(uri))
+		buf.WriteString("\"")
+	}
+
+	attrs := make([]xmlAttr, len(n.Attrs))
+	copy(attrs, n.Attrs)
+	sort.Sort(byNamespaceAndName{attrs, n.Namespaces})
+
+	for _, attr := range attrs {
+		buf.WriteString(" ")
+		buf.WriteString(qualifiedName(attr.Prefix, attr.Local))
+		buf.WriteString("=\"")
+		buf.WriteString(escapeCanonicalAttr(attr.Value))
+		buf.WriteString("\"")
+	}
+
+	buf.WriteString(">")
+
+	for _, child := range n.Children {
+		switch c := child.(type) {
+		case *xmlNode:
+			writeCanonical(buf, c, rendered, inclusivePrefixes, exclude)
+		case string:
+			buf.WriteString(escapeCanonicalText(c))
+		}
+	}
+
+	buf.WriteString("</")
+	buf.WriteString(qualifiedName(n.Prefix, n.Local))
+	buf.WriteString(">")
+}
+
+func qualifiedName(prefix, local string) string {
+	if len(prefix) == 0 {
+		return local
+	}
+
+	if prefix == "xmlns" && len(local) == 0 {
+		return "xmlns"
+	}
+
+	return prefix + ":" + local
+}
+
+type byNamespaceAndName struct {
+	attrs      []xmlAttr
+	namespaces map[string]string
+}
+
+func (a byNamespaceAndName) Len() int      { return len(a.attrs) }
+func (a byNamespaceAndName) Swap(i, j int) { a.attrs[i], a.attrs[j] = a.attrs[j], a.attrs[i] }
+func (a byNamespaceAndName) Less(i, j int) bool {
+	iNamespace, jNamespace := "", ""
+	if len(a.attrs[i].Prefix) > 0 {
+		iNamespace = a.namespaces[a.attrs[i].Prefix]
+	}
+	if len(a.attrs[j].Prefix) > 0 {
+		jNamespace = a.namespaces[a.attrs[j].Prefix]
+	}
+
+	if iNamespace != jNamespace {
+		return iNamespace < jNamespace
+	}
+
+	return a.attrs[i].Local < a.attrs[j].Local
+}
+
+var canonicalTextReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+var canonicalAttrReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", "\"", "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escapeCanonicalText(s string) string {
+	return canonicalTextReplacer.Replace(s)
+}
+
+func escapeCanonicalAttr(s string) string {
+	return canonicalAttrReplacer.Replace(s)
+}
+
+func escapeXml(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"testing"
+)
+
+func TestParseXml(t *testing.T) {
+	root, err := parseXml([]byte(`<?xml version="1.0"?><a:root xmlns:a="urn:a"><!-- comment --><a:child>text</a:child><child>other</child></a:root>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !root.Is("urn:a", "root") {
+		t.Fatal("should've parsed the root element")
+	}
+
+	if child := root.Child("urn:a", "child"); child == nil || child.Text() != "text" {
+		t.Fatal("should've found the child in the namespace")
+	}
+
+	if child := root.Child("", "child"); child == nil || child.Text() != "other" {
+		t.Fatal("should've found the child without a namespace")
+	}
+
+	if _, err := parseXml([]byte(`<!DOCTYPE root [<!ENTITY e "boom">]><root>&e;</root>`)); err == nil {
+		t.Fatal("shouldn't allow DTDs")
+	}
+
+	if _, err := parseXml([]byte(`<a:root></a:root>`)); err == nil {
+		t.Fatal("shouldn't allow undeclared prefixes")
+	}
+
+	if _, err := parseXml([]byte(`<root><child></root>`)); err == nil {
+		t.Fatal("shouldn't allow mismatched elements")
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	for _, test := range []struct {
+		input     string
+		prefixes  []string
+		subtree   string
+		canonical string
+	}{
+		{
+			input:     `<root b="2" a="1"><empty/></root>`,
+			canonical: `<root a="1" b="2"><empty></empty></root>`,
+		},
+		{
+			input:     "<root a='&quot;tab&#9;'>&lt;&amp;&gt;\"'</root>",
+			canonical: "<root a=\"&quot;tab&#x9;\">&lt;&amp;&gt;\"'</root>",
+		},
+		{
+			// namespaces that aren't used are dropped and the others are declared where they're first used
+			input:     `<p:root xmlns:p="urn:p" xmlns:q="urn:q" xmlns:unused="urn:unused"><q:child q:attr="x" attr="y"/></p:root>`,
+			canonical: `<p:root xmlns:p="urn:p"><q:child xmlns:q="urn:q" attr="y" q:attr="x"></q:child></p:root>`,
+		},
+		{
+			// a subtree declares the namespaces that it uses from its ancestors
+			input:     `<p:root xmlns:p="urn:p" xmlns:q="urn:q"><q:child ID="c"><p:leaf/></q:child></p:root>`,
+			subtree:   "child",
+			canonical: `<q:child xmlns:q="urn:q" ID="c"><p:leaf xmlns:p="urn:p"></p:leaf></q:child>`,
+		},
+		{
+			input:     `<p:root xmlns:p="urn:p" xmlns:q="urn:q"><p:child/></p:root>`,
+			prefixes:  []string{"q"},
+			canonical: `<p:root xmlns:p="urn:p" xmlns:q="urn:q"><p:child></p:child></p:root>`,
+		},
+		{
+			// declarations are sorted and the default namespace comes first
+			input:     `<root xmlns="urn:default" xmlns:b="urn:b" xmlns:a="urn:a" a:x="1" b:y="2"></root>`,
+			canonical: `<root xmlns="urn:default" xmlns:a="urn:a" xmlns:b="urn:b" a:x="1" b:y="2"></root>`,
+		},
+		{
+			// redeclaring a namespace with the same uri is dropped
+			input:     `<p:root xmlns:p="urn:p"><p:child xmlns:p="urn:p"/></p:root>`,
+			canonical: `<p:root xmlns:p="urn:p"><p:child></p:child></p:root>`,
+		},
+	} {
+		root, err := parseXml([]byte(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node := root
+		if len(test.subtree) > 0 {
+			root.walk(func(n *xmlNode) {
+				if n.Local == test.subtree {
+					node = n
+				}
+			})
+		}
+
+		if canonical := string(canonicalize(node, test.prefixes, nil)); canonical != test.canonical {
+			t.Fatalf("wrong canonical form of %v\n got: %v\nwant: %v", test.input, canonical, test.canonical)
+		}
+	}
+}
+
+func TestCanonicalizeExclude(t *testing.T) {
+	root, err := parseXml([]byte(`<root><keep/><drop><inside/></drop><keep/></root>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if canonical := string(canonicalize(root, nil, root.Child("", "drop"))); canonical != `<root><keep></keep><keep></keep></root>` {
+		t.Fatal("should've left out the excluded element", canonical)
+	}
+}
@@ -6,20 +6,72 @@ package model
 import (
 	"regexp"
 	"strings"
+	"time"
 )
 
+const SEARCH_DATE_FORMAT = "2006-01-02"
+
 var searchTermPuncStart = regexp.MustCompile(`^[^\pL\d\s#"]+`)
 var searchTermPuncEnd = regexp.MustCompile(`[^\pL\d\s*"]+$`)
+var searchTermsList = regexp.MustCompile(`"[^"]*"|\S+`)
 
 type SearchParams struct {
-	Terms      string
-	IsHashtag  bool
-	InChannels []string
-	FromUsers  []string
-	OrTerms    bool
+	Terms            string
+	ExcludedTerms    string
+	ExcludedHashtags string
+	IsHashtag        bool
+	InChannels       []string
+	FromUsers        []string
+	BeforeDate       string
+	AfterDate        string
+	OnDate           string
+	OrTerms          bool
+	FileNames        []string
+	Extensions       []string
+}
+
+var searchFlags = [...]string{"from", "channel", "in", "before", "after", "on", "file", "ext"}
+
+// HasFilters returns true if the search is narrowed down by something other than its terms
+func (p *SearchParams) HasFilters() bool {
+	return len(p.InChannels) != 0 || len(p.FromUsers) != 0 || p.BeforeDate != "" || p.AfterDate != "" || p.OnDate != "" || p.HasFileFilters()
 }
 
-var searchFlags = [...]string{"from", "channel", "in"}
+// HasFileFilters returns true if the search only wants posts with attachments that match the file or ext flags
+func (p *SearchParams) HasFileFilters() bool {
+	return len(p.FileNames) != 0 || len(p.Extensions) != 0
+}
+
+// GetTermsList splits the terms into single words and quoted phrases
+func (p *SearchParams) GetTermsList() []string {
+	return searchTermsList.FindAllString(p.Terms, -1)
+}
+
+// GetCreateAtRange returns the earliest and latest post creation times in milliseconds allowed by
+// the date flags. Either end is zero when it isn't limited. Dates are treated as UTC.
+func (p *SearchParams) GetCreateAtRange() (int64, int64) {
+	var start, end int64
+
+	if date, err := time.Parse(SEARCH_DATE_FORMAT, p.AfterDate); err == nil {
+		start = date.AddDate(0, 0, 1).UnixNano() / int64(time.Millisecond)
+	}
+
+	if date, err := time.Parse(SEARCH_DATE_FORMAT, p.BeforeDate); err == nil {
+		end = date.UnixNano()/int64(time.Millisecond) - 1
+	}
+
+	if date, err := time.Parse(SEARCH_DATE_FORMAT, p.OnDate); err == nil {
+		if dayStart := date.UnixNano() / int64(time.Millisecond); dayStart > start {
+			start = dayStart
+		}
+
+		if dayEnd := date.AddDate(0, 0, 1).UnixNano()/int64(time.Millisecond) - 1; end == 0 || dayEnd < end {
+			end = dayEnd
+		}
+	}
+
+	return start, end
+}
 
 func splitWordsNoQuotes(text string) []string {
 	words := []string{}
@@ -94,6 +146,8 @@ func parseSearchFlags(input []string) ([]string, [][2]string) {
 		}
 
 		if !isFlag {
+			excluded := strings.HasPrefix(word, "-")
+
 			// trim off surrounding punctuation (note that we leave trailing asterisks to allow wildcards)
 			word = searchTermPuncStart.ReplaceAllString(word, "")
 			word = searchTermPuncEnd.ReplaceAllString(word, "")
@@ -102,6 +156,11 @@ func parseSearchFlags(input []string) ([]string, [][2]string) {
 			word = hashtagStart.ReplaceAllString(word, "#")
 
 			if len(word) != 0 {
+				// excluded words keep their leading dash
+				if excluded {
+					word = "-" + word
+				}
+
 				words = append(words, word)
 			}
 		}
@@ -115,9 +174,17 @@ func ParseSearchParams(text string) []*SearchParams {
 
 	hashtagTermList := []string{}
 	plainTermList := []string{}
+	excludedHashtagList := []string{}
+	excludedTermList := []string{}
 
 	for _, word := range words {
-		if validHashtag.MatchString(word) {
+		if strings.HasPrefix(word, "-") {
+			if validHashtag.MatchString(word[1:]) {
+				excludedHashtagList = append(excludedHashtagList, word[1:])
+			} else {
+				excludedTermList = append(excludedTermList, word[1:])
+			}
+		} else if validHashtag.MatchString(word) {
 			hashtagTermList = append(hashtagTermList, word)
 		} else {
 			plainTermList = append(plainTermList, word)
@@ -127,48 +194,61 @@ func ParseSearchParams(text string) []*SearchParams {
 	hashtagTerms := strings.Join(hashtagTermList, " ")
 	plainTerms := strings.Join(plainTermList, " ")
 
-	inChannels := []string{}
-	fromUsers := []string{}
+	filters := SearchParams{
+		ExcludedTerms:    strings.Join(excludedTermList, " "),
+		ExcludedHashtags: strings.Join(excludedHashtagList, " "),
+		InChannels:       []string{},
+		FromUsers:        []string{},
+		FileNames:        []string{},
+		Extensions:       []string{},
+	}
 
 	for _, flagPair := range flags {
 		flag := flagPair[0]
 		value := flagPair[1]
 
 		if flag == "in" || flag == "channel" {
-			inChannels = append(inChannels, value)
+			filters.InChannels = append(filters.InChannels, value)
 		} else if flag == "from" {
-			fromUsers = append(fromUsers, value)
+			filters.FromUsers = append(filters.FromUsers, value)
+		} else if flag == "file" {
+			filters.FileNames = append(filters.FileNames, value)
+		} else if flag == "ext" {
+			filters.Extensions = append(filters.Extensions, strings.ToLower(strings.TrimPrefix(value, ".")))
+		} else if _, err := time.Parse(SEARCH_DATE_FORMAT, value); err != nil {
+			// ignore dates that we can't understand instead of searching all time
+			continue
+		} else if flag == "before" {
+			filters.BeforeDate = value
+		} else if flag == "after" {
+			filters.AfterDate = value
+		} else if flag == "on" {
+			filters.OnDate = value
 		}
 	}
 
 	paramsList := []*SearchParams{}
 
 	if len(plainTerms) > 0 {
-		paramsList = append(paramsList, &SearchParams{
-			Terms:      plainTerms,
-			IsHashtag:  false,
-			InChannels: inChannels,
-			FromUsers:  fromUsers,
-		})
+		params := filters
+		params.Terms = plainTerms
+		params.IsHashtag = false
+		paramsList = append(paramsList, &params)
 	}
 
 	if len(hashtagTerms) > 0 {
-		paramsList = append(paramsList, &SearchParams{
-			Terms:      hashtagTerms,
-			IsHashtag:  true,
-			InChannels: inChannels,
-			FromUsers:  fromUsers,
-		})
+		params := filters
+		params.Terms = hashtagTerms
+		params.IsHashtag = true
+		paramsList = append(paramsList, &params)
 	}
 
 	// special case for when no terms are specified but we still have a filter
-	if len(plainTerms) == 0 && len(hashtagTerms) == 0 && (len(inChannels) != 0 || len(fromUsers) != 0) {
-		paramsList = append(paramsList, &SearchParams{
-			Terms:      "",
-			IsHashtag:  true,
-			InChannels: inChannels,
-			FromUsers:  fromUsers,
-		})
+	if len(plainTerms) == 0 && len(hashtagTerms) == 0 && filters.HasFilters() {
+		params := filters
+		params.Terms = ""
+		params.IsHashtag = true
+		paramsList = append(paramsList, &params)
 	}
 
 	return paramsList
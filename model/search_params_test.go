@@ -220,4 +220,58 @@ func TestParseSearchParams(t *testing.T) {
 	if sp := ParseSearchParams("wildcar*"); len(sp) != 1 || sp[0].Terms != "wildcar*" || sp[0].IsHashtag != false || len(sp[0].InChannels) != 0 || len(sp[0].FromUsers) != 0 {
 		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
 	}
+
+	if sp := ParseSearchParams("apple -banana -#cherry"); len(sp) != 1 || sp[0].Terms != "apple" || sp[0].ExcludedTerms != "banana" || sp[0].ExcludedHashtags != "#cherry" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("#apple -banana"); len(sp) != 1 || sp[0].Terms != "#apple" || sp[0].IsHashtag != true || sp[0].ExcludedTerms != "banana" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("apple before:2016-09-01 after:2016-08-01"); len(sp) != 1 || sp[0].Terms != "apple" || sp[0].BeforeDate != "2016-09-01" || sp[0].AfterDate != "2016-08-01" || sp[0].OnDate != "" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("on:2016-08-15"); len(sp) != 1 || sp[0].Terms != "" || sp[0].OnDate != "2016-08-15" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
+
+	if sp := ParseSearchParams("on:yesterday"); len(sp) != 0 {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
+
+	if sp := ParseSearchParams("budget file:report.pdf ext:.PDF ext:docx"); len(sp) != 1 || sp[0].Terms != "budget" || len(sp[0].FileNames) != 1 || sp[0].FileNames[0] != "report.pdf" || len(sp[0].Extensions) != 2 || sp[0].Extensions[0] != "pdf" || sp[0].Extensions[1] != "docx" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("ext:txt"); len(sp) != 1 || sp[0].Terms != "" || !sp[0].HasFileFilters() || sp[0].Extensions[0] != "txt" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
+}
+
+func TestSearchParamsGetCreateAtRange(t *testing.T) {
+	if start, end := (&SearchParams{}).GetCreateAtRange(); start != 0 || end != 0 {
+		t.Fatal("should be unlimited without any dates")
+	}
+
+	// 2016-08-15T00:00:00Z
+	day := int64(1471219200000)
+	dayLength := int64(24 * 60 * 60 * 1000)
+
+	if start, end := (&SearchParams{OnDate: "2016-08-15"}).GetCreateAtRange(); start != day || end != day+dayLength-1 {
+		t.Fatal("should cover the whole day", start, end)
+	}
+
+	if start, end := (&SearchParams{AfterDate: "2016-08-15"}).GetCreateAtRange(); start != day+dayLength || end != 0 {
+		t.Fatal("should start after the day", start, end)
+	}
+
+	if start, end := (&SearchParams{BeforeDate: "2016-08-15"}).GetCreateAtRange(); start != 0 || end != day-1 {
+		t.Fatal("should end before the day", start, end)
+	}
+
+	if start, end := (&SearchParams{AfterDate: "2016-08-14", BeforeDate: "2016-08-20", OnDate: "2016-08-15"}).GetCreateAtRange(); start != day || end != day+dayLength-1 {
+		t.Fatal("should use the narrowest range", start, end)
+	}
 }
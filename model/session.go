@@ -14,6 +14,9 @@ const (
 	SESSION_PROP_PLATFORM = "platform"
 	SESSION_PROP_OS       = "os"
 	SESSION_PROP_BROWSER  = "browser"
+
+	SESSION_PROP_USER_ACCESS_TOKEN_ID = "user_access_token_id"
+	SESSION_PROP_OAUTH_SCOPE          = "oauth_scope"
 )
 
 type Session struct {
@@ -69,6 +72,25 @@ func (me *Session) Sanitize() {
 	me.Token = ""
 }
 
+// IsUserAccessToken checks if the session was created for a request authenticated with a user access token
+func (me *Session) IsUserAccessToken() bool {
+	return me.Props[SESSION_PROP_USER_ACCESS_TOKEN_ID] != ""
+}
+
+// HasOAuthScope checks if a session is allowed to do something that requires the given scope. Only OAuth sessions are
+// limited by scopes and ones that were created before scopes existed aren't limited either.
+func (me *Session) HasOAuthScope(scope string) bool {
+	if !me.IsOAuth {
+		return true
+	}
+
+	if allowed, ok := me.Props[SESSION_PROP_OAUTH_SCOPE]; ok {
+		return OAuthScopeContains(allowed, scope)
+	}
+
+	return true
+}
+
 func (me *Session) IsExpired() bool {
 
 	if me.ExpiresAt <= 0 {
@@ -0,0 +1,53 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+const (
+	STORAGE_RESERVATION_EXPIRY = 60 * 60 * 1000 // milliseconds before a reservation left behind by a failed upload stops counting
+)
+
+// StorageReservation is space set aside against a user's and team's storage quotas for a file that's still being
+// written
+type StorageReservation struct {
+	Id       string `json:"id"`
+	CreateAt int64  `json:"create_at"`
+	UserId   string `json:"user_id"`
+	TeamId   string `json:"team_id"`
+	Size     int64  `json:"size"`
+}
+
+func (o *StorageReservation) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *StorageReservation) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("StorageReservation.IsValid", "model.storage_reservation.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.UserId) != 26 {
+		return NewLocAppError("StorageReservation.IsValid", "model.storage_reservation.is_valid.user_id.app_error", nil, "id="+o.Id)
+	}
+
+	// files in direct message channels can be uploaded without a team
+	if len(o.TeamId) != 0 && len(o.TeamId) != 26 {
+		return NewLocAppError("StorageReservation.IsValid", "model.storage_reservation.is_valid.team_id.app_error", nil, "id="+o.Id)
+	}
+
+	if o.Size <= 0 {
+		return NewLocAppError("StorageReservation.IsValid", "model.storage_reservation.is_valid.size.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("StorageReservation.IsValid", "model.storage_reservation.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// StorageUsage is how many bytes of file storage a user has used on their own and within a team. A quota of 0
+// means that there is no limit.
+type StorageUsage struct {
+	UserUsed  int64 `json:"user_used"`
+	UserQuota int64 `json:"user_quota"`
+	TeamUsed  int64 `json:"team_used"`
+	TeamQuota int64 `json:"team_quota"`
+}
+
+func (o *StorageUsage) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func StorageUsageFromJson(data io.Reader) *StorageUsage {
+	decoder := json.NewDecoder(data)
+	var o StorageUsage
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStorageUsageJson(t *testing.T) {
+	o := StorageUsage{UserUsed: 100, UserQuota: 1000, TeamUsed: 500, TeamQuota: 0}
+	json := o.ToJson()
+	ro := StorageUsageFromJson(strings.NewReader(json))
+
+	if o != *ro {
+		t.Fatal("storage usage does not match")
+	}
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+// TaskLease records which server is running a background task that only one server in a cluster should run at once
+// and when it last ran
+type TaskLease struct {
+	Name        string `json:"name"`
+	WorkerId    string `json:"worker_id"`
+	HeartbeatAt int64  `json:"heartbeat_at"`
+	LastRunAt   int64  `json:"last_run_at"`
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ThreadMember tracks a user's relationship to the thread started by a root post
+type ThreadMember struct {
+	PostId       string `json:"post_id"`
+	UserId       string `json:"user_id"`
+	Following    bool   `json:"following"`
+	LastViewedAt int64  `json:"last_viewed_at"`
+	LastUpdateAt int64  `json:"last_update_at"`
+}
+
+// FollowedThread summarizes a thread that a user follows
+type FollowedThread struct {
+	PostId        string `json:"post_id"`
+	ChannelId     string `json:"channel_id"`
+	LastViewedAt  int64  `json:"last_viewed_at"`
+	LastReplyAt   int64  `json:"last_reply_at"`
+	ReplyCount    int64  `json:"reply_count"`
+	UnreadReplies int64  `json:"unread_replies"`
+}
+
+func (o *ThreadMember) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ThreadMemberFromJson(data io.Reader) *ThreadMember {
+	decoder := json.NewDecoder(data)
+	var o ThreadMember
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *ThreadMember) IsValid() *AppError {
+	if len(o.PostId) != 26 {
+		return NewLocAppError("ThreadMember.IsValid", "model.thread_member.is_valid.post_id.app_error", nil, "post_id="+o.PostId)
+	}
+
+	if len(o.UserId) != 26 {
+		return NewLocAppError("ThreadMember.IsValid", "model.thread_member.is_valid.user_id.app_error", nil, "user_id="+o.UserId)
+	}
+
+	return nil
+}
+
+func (o *ThreadMember) PreSave() {
+	o.LastUpdateAt = GetMillis()
+}
+
+func FollowedThreadsToJson(o []*FollowedThread) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func FollowedThreadsFromJson(data io.Reader) []*FollowedThread {
+	decoder := json.NewDecoder(data)
+	var o []*FollowedThread
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
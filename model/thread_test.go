@@ -0,0 +1,43 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThreadMemberJson(t *testing.T) {
+	o := ThreadMember{PostId: NewId(), UserId: NewId(), Following: true, LastViewedAt: GetMillis()}
+	ro := ThreadMemberFromJson(strings.NewReader(o.ToJson()))
+
+	if o != *ro {
+		t.Fatal("thread members do not match")
+	}
+
+	threads := []*FollowedThread{{PostId: NewId(), UnreadReplies: 3}}
+	rthreads := FollowedThreadsFromJson(strings.NewReader(FollowedThreadsToJson(threads)))
+
+	if len(rthreads) != 1 || *rthreads[0] != *threads[0] {
+		t.Fatal("followed threads do not match")
+	}
+}
+
+func TestThreadMemberIsValid(t *testing.T) {
+	o := ThreadMember{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PostId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	UPLOAD_SESSION_EXPIRY = 24 * 60 * 60 * 1000 // milliseconds without a chunk being uploaded before a session expires
+)
+
+// UploadSession tracks a file that's being uploaded in chunks so that the upload can be resumed after it's interrupted
+type UploadSession struct {
+	Id         string `json:"id"`
+	CreateAt   int64  `json:"create_at"`
+	ExpireAt   int64  `json:"expire_at"`
+	UserId     string `json:"user_id"`
+	TeamId     string `json:"team_id"`
+	ChannelId  string `json:"channel_id"`
+	Filename   string `json:"filename"`
+	FileSize   int64  `json:"file_size"`
+	FileOffset int64  `json:"file_offset"`
+}
+
+func (o *UploadSession) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UploadSessionFromJson(data io.Reader) *UploadSession {
+	decoder := json.NewDecoder(data)
+	var o UploadSession
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *UploadSession) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+
+	if o.ExpireAt == 0 {
+		o.ExpireAt = o.CreateAt + UPLOAD_SESSION_EXPIRY
+	}
+}
+
+func (o *UploadSession) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("UploadSession.IsValid", "model.upload_session.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.UserId) != 26 {
+		return NewLocAppError("UploadSession.IsValid", "model.upload_session.is_valid.user_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewLocAppError("UploadSession.IsValid", "model.upload_session.is_valid.team_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewLocAppError("UploadSession.IsValid", "model.upload_session.is_valid.channel_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.Filename) == 0 || len(o.Filename) > 256 {
+		return NewLocAppError("UploadSession.IsValid", "model.upload_session.is_valid.filename.app_error", nil, "id="+o.Id)
+	}
+
+	if o.FileSize <= 0 {
+		return NewLocAppError("UploadSession.IsValid", "model.upload_session.is_valid.file_size.app_error", nil, "id="+o.Id)
+	}
+
+	if o.FileOffset < 0 || o.FileOffset > o.FileSize {
+		return NewLocAppError("UploadSession.IsValid", "model.upload_session.is_valid.file_offset.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("UploadSession.IsValid", "model.upload_session.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *UploadSession) IsComplete() bool {
+	return o.FileOffset == o.FileSize
+}
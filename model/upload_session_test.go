@@ -0,0 +1,56 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUploadSessionJson(t *testing.T) {
+	o := UploadSession{Id: NewId(), Filename: "file.txt", FileSize: 100}
+	json := o.ToJson()
+	ro := UploadSessionFromJson(strings.NewReader(json))
+
+	if o.Id != ro.Id || o.Filename != ro.Filename || o.FileSize != ro.FileSize {
+		t.Fatal("upload sessions do not match")
+	}
+}
+
+func TestUploadSessionIsValid(t *testing.T) {
+	o := UploadSession{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	o.TeamId = NewId()
+	o.ChannelId = NewId()
+	o.Filename = "file.txt"
+	o.FileSize = 100
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.ExpireAt != o.CreateAt+UPLOAD_SESSION_EXPIRY {
+		t.Fatal("should have set when the session expires")
+	}
+
+	o.FileOffset = 101
+	if err := o.IsValid(); err == nil {
+		t.Fatal("offset shouldn't be past the end of the file")
+	}
+
+	o.FileOffset = 100
+	if !o.IsComplete() {
+		t.Fatal("should be complete")
+	}
+
+	o.FileSize = 0
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a size")
+	}
+}
@@ -28,6 +28,9 @@ const (
 	USER_AUTH_SERVICE_EMAIL    = "email"
 	USER_AUTH_SERVICE_USERNAME = "username"
 	MIN_PASSWORD_LENGTH        = 5
+
+	// Bots don't have an email address but emails have to be unique so they're given one that can't be delivered to
+	USER_BOT_EMAIL_DOMAIN = "bots.invalid"
 )
 
 type User struct {
@@ -57,6 +60,8 @@ type User struct {
 	Locale             string    `json:"locale"`
 	MfaActive          bool      `json:"mfa_active,omitempty"`
 	MfaSecret          string    `json:"mfa_secret,omitempty"`
+	IsBot              bool      `json:"is_bot,omitempty"`
+	BotOwnerId         string    `json:"bot_owner_id,omitempty"`
 }
 
 // IsValid validates the user and returns an error if it isn't configured
@@ -115,6 +120,18 @@ func (u *User) IsValid() *AppError {
 		return NewLocAppError("User.IsValid", "model.user.is_valid.theme.app_error", nil, "user_id="+u.Id)
 	}
 
+	if u.IsBot {
+		if len(u.BotOwnerId) != 26 {
+			return NewLocAppError("User.IsValid", "model.user.is_valid.bot_owner_id.app_error", nil, "user_id="+u.Id)
+		}
+
+		if len(u.Password) > 0 || (u.AuthData != nil && len(*u.AuthData) > 0) {
+			return NewLocAppError("User.IsValid", "model.user.is_valid.bot_credentials.app_error", nil, "user_id="+u.Id)
+		}
+	} else if len(u.BotOwnerId) > 0 {
+		return NewLocAppError("User.IsValid", "model.user.is_valid.bot_owner_id.app_error", nil, "user_id="+u.Id)
+	}
+
 	return nil
 }
 
@@ -159,6 +176,14 @@ func (u *User) PreSave() {
 	if len(u.Password) > 0 {
 		u.Password = HashPassword(u.Password)
 	}
+
+	if u.IsBot {
+		u.Email = u.Id + "@" + USER_BOT_EMAIL_DOMAIN
+		u.EmailVerified = true
+		u.NotifyProps["email"] = "false"
+		u.NotifyProps["push"] = USER_NOTIFY_NONE
+		u.NotifyProps["desktop"] = USER_NOTIFY_NONE
+	}
 }
 
 // PreUpdate should be run before updating the user in the db.
@@ -383,11 +408,14 @@ func IsInRole(userRoles string, inRole string) bool {
 	return false
 }
 
+// IsOAuthUser checks if the user signs in with an OAuth service. Each configured OpenID Connect IdP is its own auth
+// service so anything other than the built in ones counts.
 func (u *User) IsOAuthUser() bool {
-	if u.AuthService == USER_AUTH_SERVICE_GITLAB {
-		return true
+	switch u.AuthService {
+	case "", USER_AUTH_SERVICE_EMAIL, USER_AUTH_SERVICE_USERNAME, USER_AUTH_SERVICE_LDAP, USER_AUTH_SERVICE_SAML:
+		return false
 	}
-	return false
+	return true
 }
 
 func (u *User) IsLDAPUser() bool {
@@ -397,6 +425,13 @@ func (u *User) IsLDAPUser() bool {
 	return false
 }
 
+func (u *User) IsSAMLUser() bool {
+	if u.AuthService == USER_AUTH_SERVICE_SAML {
+		return true
+	}
+	return false
+}
+
 func (u *User) PreExport() {
 	u.Password = ""
 	u.AuthData = new(string)
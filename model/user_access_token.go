@@ -0,0 +1,115 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+)
+
+const (
+	// Access tokens are twice the length of a session token so they can be told apart without looking them up
+	USER_ACCESS_TOKEN_LENGTH = 52
+)
+
+// UserAccessToken is a long-lived, named token that a user can use to call the API from scripts. Only a hash of the
+// token is stored so the token itself is only available when it's created.
+type UserAccessToken struct {
+	Id          string `json:"id"`
+	Token       string `json:"token,omitempty" db:"-"`
+	TokenHash   string `json:"-"`
+	UserId      string `json:"user_id"`
+	Description string `json:"description"`
+	CreateAt    int64  `json:"create_at"`
+	LastUsedAt  int64  `json:"last_used_at"`
+}
+
+// HashUserAccessToken returns the hash of a token that's stored in place of it
+func HashUserAccessToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// IsUserAccessToken checks if a token from a request has the form of a user access token instead of a session token
+func IsUserAccessToken(token string) bool {
+	return len(token) == USER_ACCESS_TOKEN_LENGTH
+}
+
+func (t *UserAccessToken) IsValid() *AppError {
+	if len(t.Id) != 26 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.id.app_error", nil, "")
+	}
+
+	if len(t.TokenHash) != 64 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.token.app_error", nil, "id="+t.Id)
+	}
+
+	if len(t.UserId) != 26 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.user_id.app_error", nil, "id="+t.Id)
+	}
+
+	if len(t.Description) == 0 || len(t.Description) > 255 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.description.app_error", nil, "id="+t.Id)
+	}
+
+	if t.CreateAt == 0 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.create_at.app_error", nil, "id="+t.Id)
+	}
+
+	return nil
+}
+
+// PreSave generates the token and its hash. The token is left on the struct so it can be given to the user once.
+func (t *UserAccessToken) PreSave() {
+	if t.Id == "" {
+		t.Id = NewId()
+	}
+
+	t.Token = NewId() + NewId()
+	t.TokenHash = HashUserAccessToken(t.Token)
+
+	t.CreateAt = GetMillis()
+}
+
+func (t *UserAccessToken) ToJson() string {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UserAccessTokenFromJson(data io.Reader) *UserAccessToken {
+	decoder := json.NewDecoder(data)
+	var t UserAccessToken
+	err := decoder.Decode(&t)
+	if err == nil {
+		return &t
+	} else {
+		return nil
+	}
+}
+
+func UserAccessTokenListToJson(list []*UserAccessToken) string {
+	b, err := json.Marshal(list)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UserAccessTokenListFromJson(data io.Reader) []*UserAccessToken {
+	decoder := json.NewDecoder(data)
+	var list []*UserAccessToken
+	err := decoder.Decode(&list)
+	if err == nil {
+		return list
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserAccessTokenJson(t *testing.T) {
+	o := UserAccessToken{Id: NewId(), Token: NewId(), TokenHash: "hash", Description: "test"}
+	json := o.ToJson()
+
+	if strings.Contains(json, "hash") {
+		t.Fatal("shouldn't have included the hash")
+	}
+
+	ro := UserAccessTokenFromJson(strings.NewReader(json))
+	if o.Id != ro.Id || o.Token != ro.Token || ro.TokenHash != "" {
+		t.Fatal("Ids do not match")
+	}
+}
+
+func TestUserAccessTokenPreSave(t *testing.T) {
+	o := UserAccessToken{UserId: NewId(), Description: "test"}
+	o.PreSave()
+
+	if !IsUserAccessToken(o.Token) {
+		t.Fatal("should've generated a user access token")
+	} else if IsUserAccessToken(NewId()) {
+		t.Fatal("session tokens shouldn't look like user access tokens")
+	}
+
+	if o.TokenHash != HashUserAccessToken(o.Token) || o.TokenHash == o.Token {
+		t.Fatal("should've hashed the token")
+	}
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserAccessTokenIsValid(t *testing.T) {
+	o := UserAccessToken{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Id = NewId()
+	o.TokenHash = HashUserAccessToken(NewId() + NewId())
+	o.UserId = NewId()
+	o.CreateAt = GetMillis()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a description")
+	}
+
+	o.Description = strings.Repeat("a", 256)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid with a long description")
+	}
+
+	o.Description = "test"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -143,6 +143,48 @@ func TestUserIsValid(t *testing.T) {
 	}
 }
 
+func TestUserIsValidBot(t *testing.T) {
+	user := User{IsBot: true}
+	user.PreSave()
+
+	if err := user.IsValid(); err == nil {
+		t.Fatal("bot without an owner should be invalid")
+	}
+
+	user.BotOwnerId = NewId()
+	if err := user.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	user.Password = "passwd"
+	if err := user.IsValid(); err == nil {
+		t.Fatal("bot with a password should be invalid")
+	}
+
+	user.Password = ""
+	user.IsBot = false
+	if err := user.IsValid(); err == nil {
+		t.Fatal("user that isn't a bot shouldn't have an owner")
+	}
+}
+
+func TestUserPreSaveBot(t *testing.T) {
+	user := User{IsBot: true, Email: "bot@example.com"}
+	user.PreSave()
+
+	if user.Email != user.Id+"@"+USER_BOT_EMAIL_DOMAIN {
+		t.Fatal("bot should've been given a placeholder email")
+	}
+
+	if !user.EmailVerified {
+		t.Fatal("bot's email should be verified")
+	}
+
+	if user.NotifyProps["email"] != "false" || user.NotifyProps["push"] != USER_NOTIFY_NONE {
+		t.Fatal("bot shouldn't be sent notifications")
+	}
+}
+
 func TestUserGetFullName(t *testing.T) {
 	user := User{}
 
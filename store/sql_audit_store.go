@@ -5,6 +5,7 @@ package store
 
 import (
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
 )
 
 type SqlAuditStore struct {
@@ -112,3 +113,51 @@ func (s SqlAuditStore) PermanentDeleteByUser(userId string) StoreChannel {
 
 	return storeChannel
 }
+
+// PermanentDeleteBatch deletes up to limit audits created before endTime and returns how many were deleted
+func (s SqlAuditStore) PermanentDeleteBatch(endTime int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var query string
+		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+			query = "DELETE FROM Audits WHERE Id IN (SELECT Id FROM Audits WHERE CreateAt < :EndTime LIMIT :Limit)"
+		} else {
+			query = "DELETE FROM Audits WHERE CreateAt < :EndTime LIMIT :Limit"
+		}
+
+		if sqlResult, err := s.GetMaster().Exec(query, map[string]interface{}{"EndTime": endTime, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlAuditStore.PermanentDeleteBatch", "store.sql_audit.permanent_delete_batch.app_error", nil, err.Error())
+		} else if rowsAffected, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewLocAppError("SqlAuditStore.PermanentDeleteBatch", "store.sql_audit.permanent_delete_batch.app_error", nil, err.Error())
+		} else {
+			result.Data = rowsAffected
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlAuditStore) GetCountBefore(endTime int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if count, err := s.GetReplica().SelectInt("SELECT COUNT(*) FROM Audits WHERE CreateAt < :EndTime", map[string]interface{}{"EndTime": endTime}); err != nil {
+			result.Err = model.NewLocAppError("SqlAuditStore.GetCountBefore", "store.sql_audit.get_count_before.app_error", nil, err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -57,3 +57,31 @@ func TestSqlAuditStore(t *testing.T) {
 		t.Fatal(r2.Err)
 	}
 }
+
+func TestAuditStorePermanentDeleteBatch(t *testing.T) {
+	Setup()
+
+	// audits are given the current time when they're saved so insert some old ones directly
+	for i := 0; i < 3; i++ {
+		audit := &model.Audit{Id: model.NewId(), CreateAt: int64(i + 1), UserId: model.NewId(), Action: "Action"}
+		if err := store.(*SqlStore).GetMaster().Insert(audit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := (<-store.Audit().GetCountBefore(10)).Data.(int64); count != 3 {
+		t.Fatal("should have counted the old audits", count)
+	}
+
+	if deleted := (<-store.Audit().PermanentDeleteBatch(10, 2)).Data.(int64); deleted != 2 {
+		t.Fatal("should have deleted a batch", deleted)
+	}
+
+	if deleted := (<-store.Audit().PermanentDeleteBatch(10, 2)).Data.(int64); deleted != 1 {
+		t.Fatal("should have deleted the rest", deleted)
+	}
+
+	if count := (<-store.Audit().GetCountBefore(10)).Data.(int64); count != 0 {
+		t.Fatal("should have deleted all of the old audits", count)
+	}
+}
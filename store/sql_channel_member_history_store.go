@@ -0,0 +1,94 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/go-gorp/gorp"
+	"github.com/mattermost/platform/model"
+)
+
+type SqlChannelMemberHistoryStore struct {
+	*SqlStore
+}
+
+func NewSqlChannelMemberHistoryStore(sqlStore *SqlStore) ChannelMemberHistoryStore {
+	s := &SqlChannelMemberHistoryStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ChannelMemberHistory{}, "ChannelMemberHistory").SetKeys(false, "ChannelId", "UserId", "JoinTime")
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlChannelMemberHistoryStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlChannelMemberHistoryStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_channelmemberhistory_join_time", "ChannelMemberHistory", "JoinTime")
+	s.CreateIndexIfNotExists("idx_channelmemberhistory_leave_time", "ChannelMemberHistory", "LeaveTime")
+}
+
+// logJoinEventT records a user joining a channel as part of the transaction that adds them to it
+func logJoinEventT(transaction *gorp.Transaction, channelId string, userId string, joinTime int64) error {
+	return transaction.Insert(&model.ChannelMemberHistory{ChannelId: channelId, UserId: userId, JoinTime: joinTime})
+}
+
+// logLeaveEvents records that a user has left a channel, or every channel when channelId is empty
+func logLeaveEvents(db gorp.SqlExecutor, channelId string, userId string, leaveTime int64) error {
+	query := "UPDATE ChannelMemberHistory SET LeaveTime = :LeaveTime WHERE UserId = :UserId AND LeaveTime = 0"
+	if channelId != "" {
+		query += " AND ChannelId = :ChannelId"
+	}
+
+	_, err := db.Exec(query, map[string]interface{}{"LeaveTime": leaveTime, "UserId": userId, "ChannelId": channelId})
+	return err
+}
+
+// GetEventsDuring returns the history of every user who joined or left a channel after since and at or before until
+func (s SqlChannelMemberHistoryStore) GetEventsDuring(since int64, until int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var history []*model.ChannelMemberHistoryResult
+
+		if _, err := s.GetReplica().Select(&history,
+			`SELECT
+				ChannelMemberHistory.ChannelId AS ChannelId,
+				ChannelMemberHistory.UserId AS UserId,
+				ChannelMemberHistory.JoinTime AS JoinTime,
+				ChannelMemberHistory.LeaveTime AS LeaveTime,
+				COALESCE(Teams.Name, '') AS TeamName,
+				COALESCE(Teams.DisplayName, '') AS TeamDisplayName,
+				Channels.Name AS ChannelName,
+				Channels.DisplayName AS ChannelDisplayName,
+				Channels.Type AS ChannelType,
+				Users.Username AS UserUsername,
+				Users.Email AS UserEmail,
+				Users.Nickname AS UserNickname
+			FROM
+				ChannelMemberHistory
+				INNER JOIN Channels ON ChannelMemberHistory.ChannelId = Channels.Id
+				INNER JOIN Users ON ChannelMemberHistory.UserId = Users.Id
+				LEFT JOIN Teams ON Channels.TeamId = Teams.Id
+			WHERE
+				(ChannelMemberHistory.JoinTime > :Since AND ChannelMemberHistory.JoinTime <= :Until)
+				OR (ChannelMemberHistory.LeaveTime > :Since AND ChannelMemberHistory.LeaveTime <= :Until)
+			ORDER BY
+				ChannelMemberHistory.JoinTime`, map[string]interface{}{"Since": since, "Until": until}); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelMemberHistoryStore.GetEventsDuring", "store.sql_channel_member_history.get_events_during.app_error", nil, err.Error())
+		} else {
+			result.Data = history
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestChannelMemberHistoryStore(t *testing.T) {
+	Setup()
+
+	start := model.GetMillis()
+
+	t1 := &model.Team{DisplayName: "DisplayName", Name: "a" + model.NewId() + "b", Email: model.NewId() + "@nowhere.com", Type: model.TEAM_OPEN}
+	t1 = Must(store.Team().Save(t1)).(*model.Team)
+
+	c1 := &model.Channel{TeamId: t1.Id, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+
+	u1 := &model.User{Email: model.NewId(), Username: model.NewId()}
+	u1 = Must(store.User().Save(u1)).(*model.User)
+
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	time.Sleep(10 * time.Millisecond)
+	Must(store.Channel().RemoveMember(c1.Id, u1.Id))
+
+	history := Must(store.ChannelMemberHistory().GetEventsDuring(start, model.GetMillis())).([]*model.ChannelMemberHistoryResult)
+
+	var found *model.ChannelMemberHistoryResult
+	for _, entry := range history {
+		if entry.ChannelId == c1.Id && entry.UserId == u1.Id {
+			found = entry
+		}
+	}
+
+	if found == nil {
+		t.Fatal("should've recorded the user joining the channel")
+	} else if found.LeaveTime == 0 || found.LeaveTime < found.JoinTime {
+		t.Fatal("should've recorded the user leaving the channel")
+	} else if found.TeamName != t1.Name || found.ChannelName != c1.Name || found.UserUsername != u1.Username {
+		t.Fatal("should've included the details of the team, channel and user")
+	}
+
+	if history := Must(store.ChannelMemberHistory().GetEventsDuring(0, start)).([]*model.ChannelMemberHistoryResult); len(history) > 0 {
+		for _, entry := range history {
+			if entry.ChannelId == c1.Id {
+				t.Fatal("shouldn't have returned events from outside the range")
+			}
+		}
+	}
+}
@@ -513,6 +513,8 @@ func (s SqlChannelStore) saveMemberT(transaction *gorp.Transaction, member *mode
 		} else {
 			result.Err = model.NewLocAppError("SqlChannelStore.SaveMember", "store.sql_channel.save_member.save.app_error", nil, "channel_id="+member.ChannelId+", user_id="+member.UserId+", "+err.Error())
 		}
+	} else if err := logJoinEventT(transaction, member.ChannelId, member.UserId, model.GetMillis()); err != nil {
+		result.Err = model.NewLocAppError("SqlChannelStore.SaveMember", "store.sql_channel.save_member.history.app_error", nil, "channel_id="+member.ChannelId+", user_id="+member.UserId+", "+err.Error())
 	} else {
 		result.Data = member
 	}
@@ -696,6 +698,8 @@ func (s SqlChannelStore) RemoveMember(channelId string, userId string) StoreChan
 			_, err := s.GetMaster().Exec("DELETE FROM ChannelMembers WHERE ChannelId = :ChannelId AND UserId = :UserId", map[string]interface{}{"ChannelId": channelId, "UserId": userId})
 			if err != nil {
 				result.Err = model.NewLocAppError("SqlChannelStore.RemoveMember", "store.sql_channel.remove_member.app_error", nil, "channel_id="+channelId+", user_id="+userId+", "+err.Error())
+			} else if err := logLeaveEvents(s.GetMaster(), channelId, userId, model.GetMillis()); err != nil {
+				result.Err = model.NewLocAppError("SqlChannelStore.RemoveMember", "store.sql_channel.remove_member.history.app_error", nil, "channel_id="+channelId+", user_id="+userId+", "+err.Error())
 			} else {
 				// If sucessfull record members have changed in channel
 				if mu := <-s.extraUpdated(channel); mu.Err != nil {
@@ -719,6 +723,8 @@ func (s SqlChannelStore) PermanentDeleteMembersByUser(userId string) StoreChanne
 
 		if _, err := s.GetMaster().Exec("DELETE FROM ChannelMembers WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
 			result.Err = model.NewLocAppError("SqlChannelStore.RemoveMember", "store.sql_channel.permanent_delete_members_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else if err := logLeaveEvents(s.GetMaster(), "", userId, model.GetMillis()); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.RemoveMember", "store.sql_channel.permanent_delete_members_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
 		}
 
 		storeChannel <- result
@@ -926,6 +932,27 @@ func (s SqlChannelStore) IncrementMentionCount(channelId string, userId string)
 	return storeChannel
 }
 
+// GetAll returns every channel on the server, including direct message and deleted channels
+func (s SqlChannelStore) GetAll() StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var data []*model.Channel
+		if _, err := s.GetReplica().Select(&data, "SELECT * FROM Channels"); err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.GetAll", "store.sql_channel.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = data
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) GetForExport(teamId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -1007,3 +1007,30 @@ func TestUpdateExtrasByUser(t *testing.T) {
 		t.Fatal("got incorrect member count %v", len(result.Data.([]model.ExtraMember)))
 	}
 }
+
+func TestChannelStoreGetAll(t *testing.T) {
+	Setup()
+
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "Name"
+	o1.Name = "a" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	Must(store.Channel().Save(&o1))
+	Must(store.Channel().Delete(o1.Id, model.GetMillis()))
+
+	if r := <-store.Channel().GetAll(); r.Err != nil {
+		t.Fatal(r.Err)
+	} else {
+		found := false
+		for _, channel := range r.Data.([]*model.Channel) {
+			if channel.Id == o1.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the deleted channel")
+		}
+	}
+}
@@ -19,6 +19,7 @@ func NewSqlCommandStore(sqlStore *SqlStore) CommandStore {
 		tableo.ColMap("Id").SetMaxSize(26)
 		tableo.ColMap("Token").SetMaxSize(26)
 		tableo.ColMap("CreatorId").SetMaxSize(26)
+		tableo.ColMap("BotUserId").SetMaxSize(26)
 		tableo.ColMap("TeamId").SetMaxSize(26)
 		tableo.ColMap("Trigger").SetMaxSize(128)
 		tableo.ColMap("URL").SetMaxSize(1024)
@@ -36,6 +37,7 @@ func NewSqlCommandStore(sqlStore *SqlStore) CommandStore {
 
 func (s SqlCommandStore) UpgradeSchemaIfNeeded() {
 	s.CreateColumnIfNotExists("Commands", "Description", "varchar(128)", "varchar(128)", "")
+	s.CreateColumnIfNotExists("Commands", "BotUserId", "varchar(26)", "varchar(26)", "")
 }
 
 func (s SqlCommandStore) CreateIndexesIfNotExists() {
@@ -232,3 +232,74 @@ func (s SqlComplianceStore) ComplianceExport(job *model.Compliance) StoreChannel
 
 	return storeChannel
 }
+
+// MessageExport returns a page of the posts that were made, edited or deleted after since and at or before until,
+// including the earlier versions of edited posts and posts in direct channels. The posts are ordered by channel so
+// that each channel's posts come one after another, and the page starts after the post with afterId in the channel
+// with afterChannelId.
+func (s SqlComplianceStore) MessageExport(since int64, until int64, afterChannelId string, afterId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		query :=
+			`SELECT
+			    COALESCE(Teams.Name, '') AS TeamName,
+			    COALESCE(Teams.DisplayName, '') AS TeamDisplayName,
+			    Channels.Id AS ChannelId,
+			    Channels.Name AS ChannelName,
+			    Channels.DisplayName AS ChannelDisplayName,
+			    Channels.Type AS ChannelType,
+			    Users.Id AS UserId,
+			    Users.Username AS UserUsername,
+			    Users.Email AS UserEmail,
+			    Users.Nickname AS UserNickname,
+			    Posts.Id AS PostId,
+			    Posts.CreateAt AS PostCreateAt,
+			    Posts.UpdateAt AS PostUpdateAt,
+			    Posts.DeleteAt AS PostDeleteAt,
+			    Posts.RootId AS PostRootId,
+			    Posts.ParentId AS PostParentId,
+			    Posts.OriginalId AS PostOriginalId,
+			    Posts.Message AS PostMessage,
+			    Posts.Type AS PostType,
+			    Posts.Props AS PostProps,
+			    Posts.Hashtags AS PostHashtags,
+			    Posts.Filenames AS PostFilenames,
+			    Posts.FileIds AS PostFileIds
+			FROM
+			    Posts
+			        INNER JOIN Channels ON Posts.ChannelId = Channels.Id
+			        INNER JOIN Users ON Posts.UserId = Users.Id
+			        LEFT JOIN Teams ON Channels.TeamId = Teams.Id
+			WHERE
+			    ((Posts.CreateAt > :Since AND Posts.CreateAt <= :Until)
+			        OR (Posts.DeleteAt > :Since AND Posts.DeleteAt <= :Until)
+			        OR Posts.Id IN (
+			            SELECT
+			                Edits.OriginalId
+			            FROM
+			                Posts AS Edits
+			            WHERE
+			                Edits.OriginalId != ''
+			                    AND Edits.DeleteAt > :Since
+			                    AND Edits.DeleteAt <= :Until))
+			    AND (Posts.ChannelId > :AfterChannelId OR (Posts.ChannelId = :AfterChannelId AND Posts.Id > :AfterId))
+			ORDER BY Posts.ChannelId, Posts.Id
+			LIMIT :Limit`
+
+		var cposts []*model.CompliancePost
+
+		if _, err := s.GetReplica().Select(&cposts, query, map[string]interface{}{"Since": since, "Until": until, "AfterChannelId": afterChannelId, "AfterId": afterId, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlComplianceStore.MessageExport", "store.sql_compliance.message_export.app_error", nil, err.Error())
+		} else {
+			result.Data = cposts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
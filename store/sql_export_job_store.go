@@ -0,0 +1,250 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlExportJobStore struct {
+	*SqlStore
+}
+
+func NewSqlExportJobStore(sqlStore *SqlStore) ExportJobStore {
+	s := &SqlExportJobStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ExportJob{}, "ExportJobs").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("CreatorId").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("Status").SetMaxSize(32)
+		table.ColMap("Options").SetMaxSize(8000)
+		table.ColMap("Path").SetMaxSize(512)
+		table.ColMap("Error").SetMaxSize(1024)
+		table.ColMap("WorkerId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlExportJobStore) UpgradeSchemaIfNeeded() {
+	s.CreateColumnIfNotExists("ExportJobs", "WorkerId", "varchar(26)", "varchar(26)", "")
+	s.CreateColumnIfNotExists("ExportJobs", "HeartbeatAt", "bigint(20)", "bigint", "0")
+}
+
+func (s SqlExportJobStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_exportjobs_team_id", "ExportJobs", "TeamId")
+	s.CreateIndexIfNotExists("idx_exportjobs_status", "ExportJobs", "Status")
+}
+
+func (s SqlExportJobStore) Save(job *model.ExportJob) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		job.PreSave()
+		if result.Err = job.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(job); err != nil {
+			result.Err = model.NewLocAppError("SqlExportJobStore.Save", "store.sql_export_job.save.app_error", nil, "id="+job.Id+", "+err.Error())
+		} else {
+			result.Data = job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlExportJobStore) Update(job *model.ExportJob) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if result.Err = job.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(job); err != nil {
+			result.Err = model.NewLocAppError("SqlExportJobStore.Update", "store.sql_export_job.update.app_error", nil, "id="+job.Id+", "+err.Error())
+		} else {
+			result.Data = job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlExportJobStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		job := &model.ExportJob{}
+
+		if err := s.GetMaster().SelectOne(job, "SELECT * FROM ExportJobs WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlExportJobStore.Get", "store.sql_export_job.get.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			result.Data = job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Claim marks a job as being run by the given worker as long as it's still pending or the worker that was running it
+// hasn't sent a heartbeat since staleBefore. The result's data is true if the job was claimed, and only then is the
+// job updated to match.
+func (s SqlExportJobStore) Claim(job *model.ExportJob, workerId string, staleBefore int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		now := model.GetMillis()
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				ExportJobs
+			SET
+				Status = :Running,
+				WorkerId = :WorkerId,
+				HeartbeatAt = :HeartbeatAt
+			WHERE
+				Id = :Id
+				AND (Status = :Pending
+					OR (Status = :Running AND HeartbeatAt < :StaleBefore))`,
+			map[string]interface{}{
+				"Id":          job.Id,
+				"WorkerId":    workerId,
+				"HeartbeatAt": now,
+				"StaleBefore": staleBefore,
+				"Pending":     model.EXPORT_JOB_STATUS_PENDING,
+				"Running":     model.EXPORT_JOB_STATUS_RUNNING,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlExportJobStore.Claim", "store.sql_export_job.claim.app_error", nil, "id="+job.Id+", "+err.Error())
+		} else if count, _ := sqlResult.RowsAffected(); count != 1 {
+			result.Data = false
+		} else {
+			job.Status = model.EXPORT_JOB_STATUS_RUNNING
+			job.WorkerId = workerId
+			job.HeartbeatAt = now
+			result.Data = true
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Heartbeat lets the other servers know that the worker running a job is still going
+func (s SqlExportJobStore) Heartbeat(jobId string, workerId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				ExportJobs
+			SET
+				HeartbeatAt = :HeartbeatAt
+			WHERE
+				Id = :Id
+				AND WorkerId = :WorkerId
+				AND Status = :Running`,
+			map[string]interface{}{"Id": jobId, "WorkerId": workerId, "HeartbeatAt": model.GetMillis(), "Running": model.EXPORT_JOB_STATUS_RUNNING}); err != nil {
+			result.Err = model.NewLocAppError("SqlExportJobStore.Heartbeat", "store.sql_export_job.heartbeat.app_error", nil, "id="+jobId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetUnfinished returns the jobs that are waiting to be run or were interrupted while running, oldest first
+func (s SqlExportJobStore) GetUnfinished() StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var jobs []*model.ExportJob
+
+		if _, err := s.GetMaster().Select(&jobs,
+			`SELECT
+				*
+			FROM
+				ExportJobs
+			WHERE
+				Status = :Pending
+				OR Status = :Running
+			ORDER BY
+				CreateAt`, map[string]interface{}{"Pending": model.EXPORT_JOB_STATUS_PENDING, "Running": model.EXPORT_JOB_STATUS_RUNNING}); err != nil {
+			result.Err = model.NewLocAppError("SqlExportJobStore.GetUnfinished", "store.sql_export_job.get_unfinished.app_error", nil, err.Error())
+		} else {
+			result.Data = jobs
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetLastSuccessful returns the most recent job for a team that finished successfully. An empty teamId matches the
+// jobs that exported every team.
+func (s SqlExportJobStore) GetLastSuccessful(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		job := &model.ExportJob{}
+
+		if err := s.GetMaster().SelectOne(job,
+			`SELECT
+				*
+			FROM
+				ExportJobs
+			WHERE
+				TeamId = :TeamId
+				AND Status = :Status
+			ORDER BY
+				FinishAt DESC
+			LIMIT 1`, map[string]interface{}{"TeamId": teamId, "Status": model.EXPORT_JOB_STATUS_SUCCESS}); err != nil {
+			result.Err = model.NewLocAppError("SqlExportJobStore.GetLastSuccessful", "store.sql_export_job.get_last_successful.app_error", nil, "team_id="+teamId+", "+err.Error())
+		} else {
+			result.Data = job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestExportJobStore(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	channelId := model.NewId()
+
+	job := &model.ExportJob{
+		CreatorId: model.NewId(),
+		TeamId:    teamId,
+		Options: model.ExportOptions{
+			TeamsToExport: []string{teamId},
+			Since:         1000,
+			ChannelRanges: map[string]*model.ExportRange{channelId: {Since: 500, Until: 2000}},
+		},
+	}
+
+	if result := <-store.ExportJob().Save(job); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ExportJob().Get(job.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if returned := result.Data.(*model.ExportJob); returned.Status != model.EXPORT_JOB_STATUS_PENDING {
+		t.Fatal("should've saved the job as pending")
+	} else if returned.Options.Since != 1000 || returned.Options.ChannelRanges[channelId].Until != 2000 {
+		t.Fatal("should've stored the options")
+	}
+
+	found := false
+	for _, unfinished := range Must(store.ExportJob().GetUnfinished()).([]*model.ExportJob) {
+		if unfinished.Id == job.Id {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("should've returned the pending job")
+	}
+
+	if result := <-store.ExportJob().GetLastSuccessful(teamId); result.Err == nil {
+		t.Fatal("shouldn't have returned an unfinished job")
+	}
+
+	job.Status = model.EXPORT_JOB_STATUS_SUCCESS
+	job.FinishAt = model.GetMillis()
+	job.Path = "export/test.zip"
+	if result := <-store.ExportJob().Update(job); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ExportJob().GetLastSuccessful(teamId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.ExportJob).Id != job.Id {
+		t.Fatal("should've returned the finished job")
+	}
+
+	for _, unfinished := range Must(store.ExportJob().GetUnfinished()).([]*model.ExportJob) {
+		if unfinished.Id == job.Id {
+			t.Fatal("shouldn't have returned the finished job")
+		}
+	}
+}
+
+func TestExportJobStoreClaim(t *testing.T) {
+	Setup()
+
+	job := Must(store.ExportJob().Save(&model.ExportJob{})).(*model.ExportJob)
+
+	workerId := model.NewId()
+	if claimed := Must(store.ExportJob().Claim(job, workerId, 0)).(bool); !claimed {
+		t.Fatal("should've claimed the pending job")
+	} else if job.Status != model.EXPORT_JOB_STATUS_RUNNING || job.WorkerId != workerId {
+		t.Fatal("should've updated the job")
+	}
+
+	other := Must(store.ExportJob().Get(job.Id)).(*model.ExportJob)
+	if claimed := Must(store.ExportJob().Claim(other, model.NewId(), job.HeartbeatAt)).(bool); claimed {
+		t.Fatal("shouldn't have claimed a job with a recent heartbeat")
+	} else if other.WorkerId != workerId {
+		t.Fatal("shouldn't have changed the job")
+	}
+
+	Must(store.ExportJob().Heartbeat(job.Id, workerId))
+
+	if claimed := Must(store.ExportJob().Claim(other, model.NewId(), model.GetMillis()+1)).(bool); !claimed {
+		t.Fatal("should've claimed a job whose heartbeat timed out")
+	}
+
+	job.Status = model.EXPORT_JOB_STATUS_SUCCESS
+	Must(store.ExportJob().Update(job))
+
+	if claimed := Must(store.ExportJob().Claim(job, model.NewId(), model.GetMillis()+1)).(bool); claimed {
+		t.Fatal("shouldn't have claimed a finished job")
+	}
+}
@@ -0,0 +1,584 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"strconv"
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	MIGRATION_KEY_FILE_INFOS         = "MigrationFilenamesToFileInfos"
+	FILE_INFO_MIGRATION_BATCH_SIZE   = 1000
+	FILE_INFO_MIGRATION_SYSTEM_VALUE = "true"
+)
+
+type SqlFileInfoStore struct {
+	*SqlStore
+}
+
+func NewSqlFileInfoStore(sqlStore *SqlStore) FileInfoStore {
+	s := &SqlFileInfoStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.FileInfo{}, "FileInfos").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("CreatorId").SetMaxSize(26)
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("Path").SetMaxSize(512)
+		table.ColMap("ThumbnailPath").SetMaxSize(512)
+		table.ColMap("PreviewPath").SetMaxSize(512)
+		table.ColMap("Filename").SetMaxSize(256)
+		table.ColMap("Extension").SetMaxSize(64)
+		table.ColMap("MimeType").SetMaxSize(256)
+		table.ColMap("Hash").SetMaxSize(64)
+		table.ColMap("Content").SetMaxSize(model.FILE_INFO_CONTENT_MAX_SIZE)
+	}
+
+	return s
+}
+
+func (s SqlFileInfoStore) UpgradeSchemaIfNeeded() {
+	s.CreateColumnIfNotExists("FileInfos", "Hash", "varchar(64)", "varchar(64)", "")
+	s.CreateColumnIfNotExists("FileInfos", "Content", "text", "varchar(65535)", "")
+}
+
+func (s SqlFileInfoStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_fileinfo_update_at", "FileInfos", "UpdateAt")
+	s.CreateIndexIfNotExists("idx_fileinfo_create_at", "FileInfos", "CreateAt")
+	s.CreateIndexIfNotExists("idx_fileinfo_delete_at", "FileInfos", "DeleteAt")
+	s.CreateIndexIfNotExists("idx_fileinfo_post_id", "FileInfos", "PostId")
+	s.CreateIndexIfNotExists("idx_fileinfo_channel_id", "FileInfos", "ChannelId")
+	s.CreateIndexIfNotExists("idx_fileinfo_creator_id", "FileInfos", "CreatorId")
+
+	s.CreateFullTextIndexIfNotExists("idx_fileinfo_name_txt", "FileInfos", "Filename")
+	s.CreateFullTextIndexIfNotExists("idx_fileinfo_content_txt", "FileInfos", "Content")
+}
+
+func (s SqlFileInfoStore) Save(info *model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		info.PreSave()
+		if result.Err = info.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(info); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.Save", "store.sql_file_info.save.app_error", nil, "id="+info.Id+", "+err.Error())
+		} else {
+			result.Data = info
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlFileInfoStore) Update(info *model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		info.UpdateAt = model.GetMillis()
+		if result.Err = info.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(info); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.Update", "store.sql_file_info.update.app_error", nil, "id="+info.Id+", "+err.Error())
+		} else {
+			result.Data = info
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlFileInfoStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		info := &model.FileInfo{}
+
+		if err := s.GetReplica().SelectOne(info,
+			`SELECT
+				*
+			FROM
+				FileInfos
+			WHERE
+				Id = :Id
+				AND DeleteAt = 0`, map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.Get", "store.sql_file_info.get.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			result.Data = info
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlFileInfoStore) GetByPath(path string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		info := &model.FileInfo{}
+
+		if err := s.GetReplica().SelectOne(info,
+			`SELECT
+				*
+			FROM
+				FileInfos
+			WHERE
+				Path = :Path
+				AND DeleteAt = 0
+			LIMIT 1`, map[string]interface{}{"Path": path}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetByPath", "store.sql_file_info.get_by_path.app_error", nil, "path="+path+", "+err.Error())
+		} else {
+			result.Data = info
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlFileInfoStore) GetForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var infos []*model.FileInfo
+
+		if _, err := s.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfos
+			WHERE
+				PostId = :PostId
+				AND DeleteAt = 0
+			ORDER BY
+				CreateAt`, map[string]interface{}{"PostId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetForPost", "store.sql_file_info.get_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// AttachToPost links a file to the post it was uploaded for. Files that already belong to a post are left alone.
+func (s SqlFileInfoStore) AttachToPost(fileId string, postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				FileInfos
+			SET
+				PostId = :PostId,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id
+				AND PostId = ''`, map[string]interface{}{"PostId": postId, "UpdateAt": model.GetMillis(), "Id": fileId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.AttachToPost", "store.sql_file_info.attach_to_post.app_error", nil, "post_id="+postId+", file_id="+fileId+", "+err.Error())
+		} else if count, _ := sqlResult.RowsAffected(); count != 1 {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.AttachToPost", "store.sql_file_info.attach_to_post.app_error", nil, "post_id="+postId+", file_id="+fileId)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateContent sets the text extracted from a file without touching anything else about it
+func (s SqlFileInfoStore) UpdateContent(fileId string, content string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				FileInfos
+			SET
+				Content = :Content
+			WHERE
+				Id = :Id`, map[string]interface{}{"Content": content, "Id": fileId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdateContent", "store.sql_file_info.update_content.app_error", nil, "file_id="+fileId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlFileInfoStore) DeleteForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		time := model.GetMillis()
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				FileInfos
+			SET
+				DeleteAt = :DeleteAt,
+				UpdateAt = :UpdateAt
+			WHERE
+				PostId = :PostId
+				AND DeleteAt = 0`, map[string]interface{}{"DeleteAt": time, "UpdateAt": time, "PostId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.DeleteForPost", "store.sql_file_info.delete_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+		} else {
+			result.Data = postId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlFileInfoStore) PermanentDeleteByPosts(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			keys := make([]string, len(postIds))
+			params := make(map[string]interface{}, len(postIds))
+			for i, postId := range postIds {
+				key := "PostId" + strconv.Itoa(i)
+				keys[i] = ":" + key
+				params[key] = postId
+			}
+
+			if _, err := s.GetMaster().Exec("DELETE FROM FileInfos WHERE PostId IN ("+strings.Join(keys, ", ")+")", params); err != nil {
+				result.Err = model.NewLocAppError("SqlFileInfoStore.PermanentDeleteByPosts", "store.sql_file_info.permanent_delete_by_posts.app_error", nil, err.Error())
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetAllForPosts returns every FileInfo attached to the posts, including deleted ones. It reads from the master since
+// it's used to find the files to remove before the posts are permanently deleted.
+func (s SqlFileInfoStore) GetAllForPosts(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		infos := []*model.FileInfo{}
+
+		if len(postIds) > 0 {
+			keys := make([]string, len(postIds))
+			params := make(map[string]interface{}, len(postIds))
+			for i, postId := range postIds {
+				key := "PostId" + strconv.Itoa(i)
+				keys[i] = ":" + key
+				params[key] = postId
+			}
+
+			if _, err := s.GetMaster().Select(&infos, "SELECT * FROM FileInfos WHERE PostId IN ("+strings.Join(keys, ", ")+")", params); err != nil {
+				result.Err = model.NewLocAppError("SqlFileInfoStore.GetAllForPosts", "store.sql_file_info.get_all_for_posts.app_error", nil, err.Error())
+			}
+		}
+
+		result.Data = infos
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+type fileInfoMigrationPost struct {
+	Id        string
+	UserId    string
+	ChannelId string
+	CreateAt  int64
+	DeleteAt  int64
+	Filenames model.StringArray
+	TeamId    string
+}
+
+// PermanentDeleteOrphan deletes a file's FileInfo as long as it still hasn't been attached to a post. The result's
+// data is true if it was deleted so that the file itself can be removed from storage.
+func (s SqlFileInfoStore) PermanentDeleteOrphan(fileId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`DELETE FROM
+				FileInfos
+			WHERE
+				Id = :Id
+				AND PostId = ''`, map[string]interface{}{"Id": fileId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.PermanentDeleteOrphan", "store.sql_file_info.permanent_delete_orphan.app_error", nil, "file_id="+fileId+", "+err.Error())
+		} else {
+			count, _ := sqlResult.RowsAffected()
+			result.Data = count == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetStorageUsedByUser returns the number of bytes taken up by the files that a user has uploaded and not deleted
+func (s SqlFileInfoStore) GetStorageUsedByUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if v, err := s.GetReplica().SelectInt(
+			`SELECT
+				COALESCE(SUM(Size), 0)
+			FROM
+				FileInfos
+			WHERE
+				CreatorId = :UserId
+				AND DeleteAt = 0`, map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetStorageUsedByUser", "store.sql_file_info.get_storage_used_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = v
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// AnalyticsStorageUsed returns the number of bytes taken up by the files that haven't been deleted from a team's
+// channels, or from every channel if no team is given. Files in direct message channels only count towards the total.
+func (s SqlFileInfoStore) AnalyticsStorageUsed(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		query := "SELECT COALESCE(SUM(Size), 0) FROM FileInfos WHERE DeleteAt = 0"
+
+		if len(teamId) > 0 {
+			query += " AND ChannelId IN (SELECT Id FROM Channels WHERE TeamId = :TeamId)"
+		}
+
+		if v, err := s.GetReplica().SelectInt(query, map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.AnalyticsStorageUsed", "store.sql_file_info.analytics_storage_used.app_error", nil, err.Error())
+		} else {
+			result.Data = v
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// AnalyticsFileCount returns the number of files that haven't been deleted from a team's channels, or from every
+// channel if no team is given
+func (s SqlFileInfoStore) AnalyticsFileCount(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		query := "SELECT COUNT(Id) FROM FileInfos WHERE DeleteAt = 0"
+
+		if len(teamId) > 0 {
+			query += " AND ChannelId IN (SELECT Id FROM Channels WHERE TeamId = :TeamId)"
+		}
+
+		if v, err := s.GetReplica().SelectInt(query, map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.AnalyticsFileCount", "store.sql_file_info.analytics_file_count.app_error", nil, err.Error())
+		} else {
+			result.Data = v
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// MigrateFilenamesToFileInfos creates a FileInfo for every file attached to a post by its Filenames and fills in
+// the post's FileIds, then marks the migration as done. The files themselves aren't available to the store, so only
+// their sizes are looked up using sizeOf and the rest is filled in the first time each file is requested. Direct
+// channels don't belong to a team so the paths of their files are left empty until then as well. The result is the
+// number of posts that were migrated.
+func (s SqlFileInfoStore) MigrateFilenamesToFileInfos(sizeOf func(info *model.FileInfo) int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		l4g.Info(utils.T("store.sql_file_info.migrate.start.info"))
+
+		lastCreateAt := int64(-1)
+		lastId := ""
+		migrated := 0
+
+		for {
+			var posts []*fileInfoMigrationPost
+			if _, err := s.GetMaster().Select(&posts,
+				`SELECT
+					Posts.Id,
+					Posts.UserId,
+					Posts.ChannelId,
+					Posts.CreateAt,
+					Posts.DeleteAt,
+					Posts.Filenames,
+					Channels.TeamId
+				FROM
+					Posts
+				LEFT JOIN Channels ON Posts.ChannelId = Channels.Id
+				WHERE
+					Posts.Filenames != '[]'
+					AND (Posts.FileIds IS NULL OR Posts.FileIds = '' OR Posts.FileIds = '[]')
+					AND (Posts.CreateAt > :CreateAt OR (Posts.CreateAt = :CreateAt AND Posts.Id > :Id))
+				ORDER BY
+					Posts.CreateAt, Posts.Id
+				LIMIT :Limit`, map[string]interface{}{"CreateAt": lastCreateAt, "Id": lastId, "Limit": FILE_INFO_MIGRATION_BATCH_SIZE}); err != nil {
+				result.Err = model.NewLocAppError("SqlFileInfoStore.MigrateFilenamesToFileInfos", "store.sql_file_info.migrate.app_error", nil, err.Error())
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+
+			for _, post := range posts {
+				fileIds := []string{}
+
+				for _, filename := range post.Filenames {
+					if info := s.migrateFilename(post, filename, sizeOf); info != nil {
+						fileIds = append(fileIds, info.Id)
+					}
+				}
+
+				if len(fileIds) > 0 {
+					if _, err := s.GetMaster().Exec("UPDATE Posts SET FileIds = :FileIds WHERE Id = :Id",
+						map[string]interface{}{"FileIds": model.ArrayToJson(fileIds), "Id": post.Id}); err != nil {
+						l4g.Error(utils.T("store.sql_file_info.migrate.post.error"), post.Id, err)
+					} else {
+						migrated++
+					}
+				}
+
+				lastCreateAt = post.CreateAt
+				lastId = post.Id
+			}
+
+			if len(posts) < FILE_INFO_MIGRATION_BATCH_SIZE {
+				break
+			}
+		}
+
+		if err := s.GetMaster().Insert(&model.System{Name: MIGRATION_KEY_FILE_INFOS, Value: FILE_INFO_MIGRATION_SYSTEM_VALUE}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.MigrateFilenamesToFileInfos", "store.sql_file_info.migrate.app_error", nil, err.Error())
+		} else {
+			l4g.Info(utils.T("store.sql_file_info.migrate.end.info"), migrated)
+			result.Data = migrated
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlFileInfoStore) migrateFilename(post *fileInfoMigrationPost, filename string, sizeOf func(info *model.FileInfo) int64) *model.FileInfo {
+	info := model.GetInfoForFilename(filename, post.TeamId)
+	if info == nil {
+		l4g.Warn(utils.T("store.sql_file_info.migrate.filename.warn"), post.Id, filename)
+		return nil
+	}
+
+	if info.Id == "" {
+		info.Id = model.NewId()
+	}
+	info.PostId = post.Id
+	info.CreateAt = post.CreateAt
+	info.DeleteAt = post.DeleteAt
+
+	if count, err := s.GetMaster().SelectInt("SELECT COUNT(*) FROM FileInfos WHERE Id = :Id", map[string]interface{}{"Id": info.Id}); err != nil {
+		l4g.Error(utils.T("store.sql_file_info.migrate.file.error"), post.Id, filename, err)
+		return nil
+	} else if count > 0 {
+		return info
+	}
+
+	if info.Path != "" {
+		info.Size = sizeOf(info)
+	}
+
+	info.PreSave()
+	if err := info.IsValid(); err != nil {
+		l4g.Error(utils.T("store.sql_file_info.migrate.file.error"), post.Id, filename, err)
+		return nil
+	}
+
+	if err := s.GetMaster().Insert(info); err != nil {
+		l4g.Error(utils.T("store.sql_file_info.migrate.file.error"), post.Id, filename, err)
+		return nil
+	}
+
+	return info
+}
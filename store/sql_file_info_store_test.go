@@ -0,0 +1,244 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestFileInfoSaveGet(t *testing.T) {
+	Setup()
+
+	info := &model.FileInfo{
+		CreatorId: model.NewId(),
+		ChannelId: model.NewId(),
+		Path:      "file.txt",
+		Filename:  "file.txt",
+		Size:      10,
+	}
+
+	if result := <-store.FileInfo().Save(info); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if returned := result.Data.(*model.FileInfo); len(returned.Id) == 0 {
+		t.Fatal("should've assigned an id to FileInfo")
+	} else {
+		info = returned
+	}
+
+	if result := <-store.FileInfo().Get(info.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if returned := result.Data.(*model.FileInfo); returned.Id != info.Id || returned.Size != info.Size {
+		t.Log(info)
+		t.Log(returned)
+		t.Fatal("should've returned correct FileInfo")
+	}
+
+	if result := <-store.FileInfo().GetByPath(info.Path); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if returned := result.Data.(*model.FileInfo); returned.Id != info.Id {
+		t.Fatal("should've returned the FileInfo for the path")
+	}
+
+	info.Size = 20
+	if result := <-store.FileInfo().Update(info); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.FileInfo().Get(info.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.FileInfo).Size != 20 {
+		t.Fatal("should've updated the FileInfo")
+	}
+
+	if err := (<-store.FileInfo().Save(&model.FileInfo{ChannelId: info.ChannelId, Filename: "file.txt"})).Err; err == nil {
+		t.Fatal("shouldn't be able to save an invalid FileInfo")
+	}
+}
+
+func TestFileInfoAttachToPost(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	channelId := model.NewId()
+	postId := model.NewId()
+
+	info1 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, ChannelId: channelId, Filename: "file1.txt"})).(*model.FileInfo)
+	info2 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, ChannelId: channelId, Filename: "file2.txt"})).(*model.FileInfo)
+
+	if result := <-store.FileInfo().AttachToPost(info1.Id, postId); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.FileInfo().AttachToPost(info1.Id, model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't be able to attach a file to a second post")
+	}
+
+	if result := <-store.FileInfo().AttachToPost(info2.Id, postId); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.FileInfo().GetForPost(postId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if infos := result.Data.([]*model.FileInfo); len(infos) != 2 {
+		t.Fatal("should've returned both files for the post")
+	}
+
+	if result := <-store.FileInfo().DeleteForPost(postId); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.FileInfo().GetForPost(postId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if infos := result.Data.([]*model.FileInfo); len(infos) != 0 {
+		t.Fatal("shouldn't return deleted files")
+	}
+
+	if result := <-store.FileInfo().GetAllForPosts([]string{postId, model.NewId()}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if infos := result.Data.([]*model.FileInfo); len(infos) != 2 {
+		t.Fatal("should've returned deleted files when getting all of them")
+	}
+
+	if result := <-store.FileInfo().PermanentDeleteByPosts([]string{postId}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if count, err := store.(*SqlStore).GetMaster().SelectInt("SELECT COUNT(*) FROM FileInfos WHERE PostId = :PostId", map[string]interface{}{"PostId": postId}); err != nil {
+		t.Fatal(err)
+	} else if count != 0 {
+		t.Fatal("should've permanently deleted the files")
+	}
+}
+
+func TestFileInfoUpdateContent(t *testing.T) {
+	Setup()
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), ChannelId: model.NewId(), Filename: "file.txt"})).(*model.FileInfo)
+
+	postId := model.NewId()
+	Must(store.FileInfo().AttachToPost(info.Id, postId))
+
+	if result := <-store.FileInfo().UpdateContent(info.Id, "the contents of the file"); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.FileInfo().Get(info.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if updated := result.Data.(*model.FileInfo); updated.Content != "the contents of the file" {
+		t.Fatal("should've saved the content")
+	} else if updated.PostId != postId {
+		t.Fatal("shouldn't have changed anything else about the file")
+	}
+}
+
+func TestFileInfoStorageUsed(t *testing.T) {
+	Setup()
+
+	channel := Must(store.Channel().Save(&model.Channel{
+		TeamId:      model.NewId(),
+		DisplayName: "Storage",
+		Name:        "a" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	})).(*model.Channel)
+
+	userId := model.NewId()
+	postId := model.NewId()
+
+	info1 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, ChannelId: channel.Id, Filename: "file1.txt", Size: 100})).(*model.FileInfo)
+	Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, ChannelId: channel.Id, Filename: "file2.txt", Size: 50}))
+	Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), ChannelId: channel.Id, Filename: "file3.txt", Size: 25}))
+	Must(store.FileInfo().AttachToPost(info1.Id, postId))
+
+	if used := Must(store.FileInfo().GetStorageUsedByUser(userId)).(int64); used != 150 {
+		t.Fatal("should've counted the user's files", used)
+	}
+
+	if used := Must(store.FileInfo().AnalyticsStorageUsed(channel.TeamId)).(int64); used != 175 {
+		t.Fatal("should've counted the team's files", used)
+	}
+
+	if count := Must(store.FileInfo().AnalyticsFileCount(channel.TeamId)).(int64); count != 3 {
+		t.Fatal("should've counted the team's files", count)
+	}
+
+	Must(store.FileInfo().DeleteForPost(postId))
+
+	if used := Must(store.FileInfo().GetStorageUsedByUser(userId)).(int64); used != 50 {
+		t.Fatal("shouldn't count deleted files", used)
+	}
+
+	if used := Must(store.FileInfo().AnalyticsStorageUsed(channel.TeamId)).(int64); used != 75 {
+		t.Fatal("shouldn't count deleted files", used)
+	}
+
+	if used := Must(store.FileInfo().GetStorageUsedByUser(model.NewId())).(int64); used != 0 {
+		t.Fatal("should be zero for a user without any files", used)
+	}
+}
+
+func TestFileInfoOrphaned(t *testing.T) {
+	Setup()
+
+	orphan := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), ChannelId: model.NewId(), Filename: "file1.txt"})).(*model.FileInfo)
+	attached := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), ChannelId: model.NewId(), Filename: "file2.txt"})).(*model.FileInfo)
+	Must(store.FileInfo().AttachToPost(attached.Id, model.NewId()))
+
+	if deleted := Must(store.FileInfo().PermanentDeleteOrphan(attached.Id)).(bool); deleted {
+		t.Fatal("shouldn't have deleted a file attached to a post")
+	}
+
+	if deleted := Must(store.FileInfo().PermanentDeleteOrphan(orphan.Id)).(bool); !deleted {
+		t.Fatal("should've deleted the orphaned file")
+	}
+
+	if result := <-store.FileInfo().Get(orphan.Id); result.Err == nil {
+		t.Fatal("orphaned file should be gone")
+	}
+}
+
+func TestFileInfoMigrateFilenames(t *testing.T) {
+	Setup()
+
+	channel := Must(store.Channel().Save(&model.Channel{
+		TeamId:      model.NewId(),
+		DisplayName: "Name",
+		Name:        "a" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	})).(*model.Channel)
+
+	userId := model.NewId()
+	fileId := model.NewId()
+
+	post := Must(store.Post().Save(&model.Post{
+		ChannelId: channel.Id,
+		UserId:    userId,
+		Message:   "message",
+		Filenames: []string{"/" + channel.Id + "/" + userId + "/" + fileId + "/test.png"},
+	})).(*model.Post)
+
+	sqlStore := store.(*SqlStore)
+	if _, err := sqlStore.GetMaster().Exec("DELETE FROM Systems WHERE Name = :Name", map[string]interface{}{"Name": MIGRATION_KEY_FILE_INFOS}); err != nil {
+		t.Fatal(err)
+	}
+
+	Must(store.FileInfo().MigrateFilenamesToFileInfos(func(info *model.FileInfo) int64 {
+		return 123
+	}))
+
+	if result := <-store.FileInfo().Get(fileId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if info := result.Data.(*model.FileInfo); info.PostId != post.Id || info.CreatorId != userId || info.Filename != "test.png" || info.Size != 123 {
+		t.Fatal("should've migrated the file")
+	} else if info.Path != "teams/"+channel.TeamId+"/channels/"+channel.Id+"/users/"+userId+"/"+fileId+"/test.png" {
+		t.Fatal("should've worked out the path of the file " + info.Path)
+	}
+
+	if result := <-store.Post().Get(post.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if migrated := result.Data.(*model.PostList).Posts[post.Id]; len(migrated.FileIds) != 1 || migrated.FileIds[0] != fileId {
+		t.Fatal("should've set the file ids of the post")
+	}
+}
@@ -4,8 +4,11 @@
 package store
 
 import (
-	"github.com/mattermost/platform/model"
 	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
 )
 
 type SqlOAuthStore struct {
@@ -19,11 +22,13 @@ func NewSqlOAuthStore(sqlStore *SqlStore) OAuthStore {
 		table := db.AddTableWithName(model.OAuthApp{}, "OAuthApps").SetKeys(false, "Id")
 		table.ColMap("Id").SetMaxSize(26)
 		table.ColMap("CreatorId").SetMaxSize(26)
+		table.ColMap("BotUserId").SetMaxSize(26)
 		table.ColMap("ClientSecret").SetMaxSize(128)
 		table.ColMap("Name").SetMaxSize(64)
 		table.ColMap("Description").SetMaxSize(512)
 		table.ColMap("CallbackUrls").SetMaxSize(1024)
 		table.ColMap("Homepage").SetMaxSize(256)
+		table.ColMap("Scope").SetMaxSize(128)
 
 		tableAuth := db.AddTableWithName(model.AuthData{}, "OAuthAuthData").SetKeys(false, "Code")
 		tableAuth.ColMap("UserId").SetMaxSize(26)
@@ -34,21 +39,63 @@ func NewSqlOAuthStore(sqlStore *SqlStore) OAuthStore {
 		tableAuth.ColMap("Scope").SetMaxSize(128)
 
 		tableAccess := db.AddTableWithName(model.AccessData{}, "OAuthAccessData").SetKeys(false, "Token")
+		tableAccess.ColMap("ClientId").SetMaxSize(26)
+		tableAccess.ColMap("UserId").SetMaxSize(26)
 		tableAccess.ColMap("AuthCode").SetMaxSize(128)
 		tableAccess.ColMap("Token").SetMaxSize(26)
 		tableAccess.ColMap("RefreshToken").SetMaxSize(26)
 		tableAccess.ColMap("RedirectUri").SetMaxSize(256)
+		tableAccess.ColMap("Scope").SetMaxSize(128)
 	}
 
 	return as
 }
 
 func (as SqlOAuthStore) UpgradeSchemaIfNeeded() {
+	as.CreateColumnIfNotExists("OAuthApps", "BotUserId", "varchar(26)", "varchar(26)", "")
+	as.CreateColumnIfNotExists("OAuthApps", "Scope", "varchar(128)", "varchar(128)", model.OAUTH_DEFAULT_SCOPE)
+
+	as.CreateColumnIfNotExists("OAuthAccessData", "ClientId", "varchar(26)", "varchar(26)", "")
+	as.CreateColumnIfNotExists("OAuthAccessData", "UserId", "varchar(26)", "varchar(26)", "")
+	as.CreateColumnIfNotExists("OAuthAccessData", "Scope", "varchar(128)", "varchar(128)", "")
+
+	as.backfillAccessData()
+}
+
+// backfillAccessData fills in the app and user of tokens that were given out before they were stored with the token
+// so that revoking an app's or user's access still finds them. The auth data that a token was exchanged for is kept
+// until the token is revoked, and the token's session is used for the user when the auth data is missing.
+func (as SqlOAuthStore) backfillAccessData() {
+	if _, err := as.GetMaster().Exec(
+		`UPDATE
+			OAuthAccessData
+		SET
+			ClientId = (SELECT OAuthAuthData.ClientId FROM OAuthAuthData WHERE OAuthAuthData.Code = OAuthAccessData.AuthCode),
+			UserId = (SELECT OAuthAuthData.UserId FROM OAuthAuthData WHERE OAuthAuthData.Code = OAuthAccessData.AuthCode)
+		WHERE
+			ClientId = ''
+			AND AuthCode IN (SELECT Code FROM OAuthAuthData)`); err != nil {
+		l4g.Error(utils.T("store.sql_oauth.backfill_access_data.error"), err)
+	}
+
+	if _, err := as.GetMaster().Exec(
+		`UPDATE
+			OAuthAccessData
+		SET
+			UserId = (SELECT Sessions.UserId FROM Sessions WHERE Sessions.Token = OAuthAccessData.Token)
+		WHERE
+			UserId = ''
+			AND Token IN (SELECT Token FROM Sessions)`); err != nil {
+		l4g.Error(utils.T("store.sql_oauth.backfill_access_data.error"), err)
+	}
 }
 
 func (as SqlOAuthStore) CreateIndexesIfNotExists() {
 	as.CreateIndexIfNotExists("idx_oauthapps_creator_id", "OAuthApps", "CreatorId")
 	as.CreateIndexIfNotExists("idx_oauthaccessdata_auth_code", "OAuthAccessData", "AuthCode")
+	as.CreateIndexIfNotExists("idx_oauthaccessdata_client_id", "OAuthAccessData", "ClientId")
+	as.CreateIndexIfNotExists("idx_oauthaccessdata_user_id", "OAuthAccessData", "UserId")
+	as.CreateIndexIfNotExists("idx_oauthaccessdata_refresh_token", "OAuthAccessData", "RefreshToken")
 	as.CreateIndexIfNotExists("idx_oauthauthdata_client_id", "OAuthAuthData", "Code")
 }
 
@@ -172,6 +219,99 @@ func (as SqlOAuthStore) GetAppByUser(userId string) StoreChannel {
 	return storeChannel
 }
 
+func (as SqlOAuthStore) GetApps() StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var apps []*model.OAuthApp
+
+		if _, err := as.GetReplica().Select(&apps, "SELECT * FROM OAuthApps ORDER BY Name ASC"); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetApps", "store.sql_oauth.get_apps.find.app_error", nil, err.Error())
+		} else {
+			result.Data = apps
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetAuthorizedApps returns the apps that a user has given access to their account
+func (as SqlOAuthStore) GetAuthorizedApps(userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var apps []*model.OAuthApp
+
+		if _, err := as.GetReplica().Select(&apps,
+			`SELECT
+				*
+			FROM
+				OAuthApps
+			WHERE
+				Id IN (SELECT ClientId FROM OAuthAccessData WHERE UserId = :UserId)
+			ORDER BY Name ASC`, map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetAuthorizedApps", "store.sql_oauth.get_authorized_apps.find.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = apps
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (as SqlOAuthStore) UpdateAppSecret(id string, secret string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := as.GetMaster().Exec("UPDATE OAuthApps SET ClientSecret = :ClientSecret, UpdateAt = :UpdateAt WHERE Id = :Id",
+			map[string]interface{}{"ClientSecret": model.HashPassword(secret), "UpdateAt": model.GetMillis(), "Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.UpdateAppSecret", "store.sql_oauth.update_app_secret.app_error", nil, "app_id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteApp removes an app along with any auth codes that haven't been used yet. Access tokens should be revoked
+// first so that their sessions are removed too.
+func (as SqlOAuthStore) DeleteApp(id string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := as.GetMaster().Exec("DELETE FROM OAuthAuthData WHERE ClientId = :ClientId", map[string]interface{}{"ClientId": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.DeleteApp", "store.sql_oauth.delete_app.app_error", nil, "app_id="+id+", "+err.Error())
+		} else if _, err := as.GetMaster().Exec("DELETE FROM OAuthApps WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.DeleteApp", "store.sql_oauth.delete_app.app_error", nil, "app_id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (as SqlOAuthStore) SaveAccessData(accessData *model.AccessData) StoreChannel {
 
 	storeChannel := make(StoreChannel)
@@ -248,6 +388,56 @@ func (as SqlOAuthStore) GetAccessDataByAuthCode(authCode string) StoreChannel {
 	return storeChannel
 }
 
+func (as SqlOAuthStore) GetAccessDataByRefreshToken(refreshToken string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		accessData := model.AccessData{}
+
+		if err := as.GetReplica().SelectOne(&accessData, "SELECT * FROM OAuthAccessData WHERE RefreshToken = :RefreshToken", map[string]interface{}{"RefreshToken": refreshToken}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetAccessDataByRefreshToken", "store.sql_oauth.get_access_data_by_refresh_token.app_error", nil, err.Error())
+		} else {
+			result.Data = &accessData
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetAccessDataForApp returns the tokens that a user has given to an app, or every token for the app if userId is empty
+func (as SqlOAuthStore) GetAccessDataForApp(clientId string, userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		query := "SELECT * FROM OAuthAccessData WHERE ClientId = :ClientId"
+		if userId != "" {
+			query += " AND UserId = :UserId"
+		}
+
+		var accessData []*model.AccessData
+
+		if _, err := as.GetReplica().Select(&accessData, query, map[string]interface{}{"ClientId": clientId, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetAccessDataForApp", "store.sql_oauth.get_access_data_for_app.app_error", nil, "app_id="+clientId+", "+err.Error())
+		} else {
+			result.Data = accessData
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (as SqlOAuthStore) RemoveAccessData(token string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -265,6 +455,28 @@ func (as SqlOAuthStore) RemoveAccessData(token string) StoreChannel {
 	return storeChannel
 }
 
+// RemoveAccessDataByRefreshToken deletes the token that goes with a refresh token so that the refresh token can only be
+// used once. The result's data is true if this call was the one that deleted it.
+func (as SqlOAuthStore) RemoveAccessDataByRefreshToken(token string, refreshToken string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := as.GetMaster().Exec("DELETE FROM OAuthAccessData WHERE Token = :Token AND RefreshToken = :RefreshToken", map[string]interface{}{"Token": token, "RefreshToken": refreshToken}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.RemoveAccessDataByRefreshToken", "store.sql_oauth.remove_access_data.app_error", nil, "err="+err.Error())
+		} else {
+			count, _ := sqlResult.RowsAffected()
+			result.Data = count == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (as SqlOAuthStore) SaveAuthData(authData *model.AuthData) StoreChannel {
 
 	storeChannel := make(StoreChannel)
@@ -78,6 +78,8 @@ func TestOAuthStoreSaveAccessData(t *testing.T) {
 	Setup()
 
 	a1 := model.AccessData{}
+	a1.ClientId = model.NewId()
+	a1.UserId = model.NewId()
 	a1.AuthCode = model.NewId()
 	a1.Token = model.NewId()
 	a1.RefreshToken = model.NewId()
@@ -91,6 +93,8 @@ func TestOAuthStoreGetAccessData(t *testing.T) {
 	Setup()
 
 	a1 := model.AccessData{}
+	a1.ClientId = model.NewId()
+	a1.UserId = model.NewId()
 	a1.AuthCode = model.NewId()
 	a1.Token = model.NewId()
 	a1.RefreshToken = model.NewId()
@@ -118,6 +122,8 @@ func TestOAuthStoreRemoveAccessData(t *testing.T) {
 	Setup()
 
 	a1 := model.AccessData{}
+	a1.ClientId = model.NewId()
+	a1.UserId = model.NewId()
 	a1.AuthCode = model.NewId()
 	a1.Token = model.NewId()
 	a1.RefreshToken = model.NewId()
@@ -136,6 +142,52 @@ func TestOAuthStoreRemoveAccessData(t *testing.T) {
 	}
 }
 
+func TestOAuthStoreRemoveAccessDataByRefreshToken(t *testing.T) {
+	Setup()
+
+	a1 := model.AccessData{}
+	a1.ClientId = model.NewId()
+	a1.UserId = model.NewId()
+	a1.AuthCode = model.NewId()
+	a1.Token = model.NewId()
+	a1.RefreshToken = model.NewId()
+	Must(store.OAuth().SaveAccessData(&a1))
+
+	if removed := Must(store.OAuth().RemoveAccessDataByRefreshToken(a1.Token, model.NewId())).(bool); removed {
+		t.Fatal("shouldn't have removed the token with the wrong refresh token")
+	}
+
+	if removed := Must(store.OAuth().RemoveAccessDataByRefreshToken(a1.Token, a1.RefreshToken)).(bool); !removed {
+		t.Fatal("should've removed the token")
+	}
+
+	if removed := Must(store.OAuth().RemoveAccessDataByRefreshToken(a1.Token, a1.RefreshToken)).(bool); removed {
+		t.Fatal("refresh token should only be usable once")
+	}
+}
+
+func TestOAuthStoreBackfillAccessData(t *testing.T) {
+	Setup()
+
+	authData := model.AuthData{ClientId: model.NewId(), UserId: model.NewId(), Code: model.NewId()}
+	Must(store.OAuth().SaveAuthData(&authData))
+
+	a1 := model.AccessData{ClientId: authData.ClientId, UserId: authData.UserId, AuthCode: authData.Code, Token: model.NewId()}
+	Must(store.OAuth().SaveAccessData(&a1))
+
+	// tokens given out before the app and user were stored with them
+	sqlStore := store.(*SqlStore)
+	if _, err := sqlStore.GetMaster().Exec("UPDATE OAuthAccessData SET ClientId = '', UserId = '' WHERE Token = :Token", map[string]interface{}{"Token": a1.Token}); err != nil {
+		t.Fatal(err)
+	}
+
+	SqlOAuthStore{sqlStore}.backfillAccessData()
+
+	if accessData := Must(store.OAuth().GetAccessDataForApp(authData.ClientId, authData.UserId)).([]*model.AccessData); len(accessData) != 1 || accessData[0].Token != a1.Token {
+		t.Fatal("should've filled in the app and user from the auth data")
+	}
+}
+
 func TestOAuthStoreSaveAuthData(t *testing.T) {
 	Setup()
 
@@ -194,3 +246,77 @@ func TestOAuthStoreRemoveAuthDataByUser(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestOAuthStoreAuthorizedApps(t *testing.T) {
+	Setup()
+
+	app := model.OAuthApp{}
+	app.CreatorId = model.NewId()
+	app.Name = "TestApp" + model.NewId()
+	app.CallbackUrls = []string{"https://nowhere.com"}
+	app.Homepage = "https://nowhere.com"
+	Must(store.OAuth().SaveApp(&app))
+
+	a1 := model.AccessData{}
+	a1.ClientId = app.Id
+	a1.UserId = model.NewId()
+	a1.AuthCode = model.NewId()
+	a1.Token = model.NewId()
+	a1.RefreshToken = model.NewId()
+	Must(store.OAuth().SaveAccessData(&a1))
+
+	if result := <-store.OAuth().GetAuthorizedApps(a1.UserId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if apps := result.Data.([]*model.OAuthApp); len(apps) != 1 || apps[0].Id != app.Id {
+		t.Fatal("should've returned the authorized app")
+	}
+
+	if result := <-store.OAuth().GetAccessDataByRefreshToken(a1.RefreshToken); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.AccessData).Token != a1.Token {
+		t.Fatal("should've found the access data by its refresh token")
+	}
+
+	if result := <-store.OAuth().GetAccessDataForApp(app.Id, model.NewId()); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.AccessData)) != 0 {
+		t.Fatal("shouldn't have returned another user's access data")
+	}
+
+	if result := <-store.OAuth().GetAccessDataForApp(app.Id, ""); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.AccessData)) != 1 {
+		t.Fatal("should've returned the app's access data")
+	}
+}
+
+func TestOAuthStoreUpdateAppSecretAndDelete(t *testing.T) {
+	Setup()
+
+	app := model.OAuthApp{}
+	app.CreatorId = model.NewId()
+	app.Name = "TestApp" + model.NewId()
+	app.CallbackUrls = []string{"https://nowhere.com"}
+	app.Homepage = "https://nowhere.com"
+	Must(store.OAuth().SaveApp(&app))
+
+	Must(store.OAuth().UpdateAppSecret(app.Id, "newsecret"))
+
+	if result := <-store.OAuth().GetApp(app.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !model.ComparePassword(result.Data.(*model.OAuthApp).ClientSecret, "newsecret") {
+		t.Fatal("should've updated the secret")
+	}
+
+	if result := <-store.OAuth().GetApps(); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.OAuthApp)) == 0 {
+		t.Fatal("should've returned apps")
+	}
+
+	Must(store.OAuth().DeleteApp(app.Id))
+
+	if err := (<-store.OAuth().GetApp(app.Id)).Err; err == nil {
+		t.Fatal("should've deleted the app")
+	}
+}
@@ -32,12 +32,15 @@ func NewSqlPostStore(sqlStore *SqlStore) PostStore {
 		table.ColMap("Hashtags").SetMaxSize(1000)
 		table.ColMap("Props").SetMaxSize(8000)
 		table.ColMap("Filenames").SetMaxSize(4000)
+		table.ColMap("FileIds").SetMaxSize(150)
 	}
 
 	return s
 }
 
 func (s SqlPostStore) UpgradeSchemaIfNeeded() {
+	s.CreateColumnIfNotExists("Posts", "IsPinned", "boolean", "boolean", "0")
+	s.CreateColumnIfNotExists("Posts", "FileIds", "varchar(150)", "varchar(150)", "[]")
 }
 
 func (s SqlPostStore) CreateIndexesIfNotExists() {
@@ -45,6 +48,7 @@ func (s SqlPostStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_posts_create_at", "Posts", "CreateAt")
 	s.CreateIndexIfNotExists("idx_posts_channel_id", "Posts", "ChannelId")
 	s.CreateIndexIfNotExists("idx_posts_root_id", "Posts", "RootId")
+	s.CreateIndexIfNotExists("idx_posts_is_pinned", "Posts", "IsPinned")
 
 	s.CreateFullTextIndexIfNotExists("idx_posts_message_txt", "Posts", "Message")
 	s.CreateFullTextIndexIfNotExists("idx_posts_hashtags_txt", "Posts", "Hashtags")
@@ -142,6 +146,44 @@ func (s SqlPostStore) Update(oldPost *model.Post, newMessage string, newHashtags
 	return storeChannel
 }
 
+// UpdatePinned pins or unpins the post without keeping a copy of it like an edit would
+func (s SqlPostStore) UpdatePinned(postId string, isPinned bool) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		updateAt := model.GetMillis()
+
+		var post model.Post
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				Posts
+			SET
+				IsPinned = :IsPinned,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id
+				AND DeleteAt = 0`, map[string]interface{}{"IsPinned": isPinned, "UpdateAt": updateAt, "Id": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.UpdatePinned", "store.sql_post.update_pinned.app_error", nil, "id="+postId+", "+err.Error())
+		} else if err := s.GetMaster().SelectOne(&post, "SELECT * FROM Posts WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"Id": postId}); err != nil {
+			// the post doesn't exist or has been deleted
+			result.Err = model.NewLocAppError("SqlPostStore.UpdatePinned", "store.sql_post.update_pinned.app_error", nil, "id="+postId+", "+err.Error())
+		} else {
+			if len(post.RootId) > 0 {
+				s.GetMaster().Exec("UPDATE Posts SET UpdateAt = :UpdateAt WHERE Id = :RootId", map[string]interface{}{"UpdateAt": updateAt, "RootId": post.RootId})
+			}
+
+			result.Data = &post
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlPostStore) Get(id string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -164,9 +206,7 @@ func (s SqlPostStore) Get(id string) StoreChannel {
 			rootId = post.Id
 		}
 
-		var posts []*model.Post
-		_, err = s.GetReplica().Select(&posts, "SELECT * FROM Posts WHERE (Id = :Id OR RootId = :RootId) AND DeleteAt = 0", map[string]interface{}{"Id": rootId, "RootId": rootId})
-		if err != nil {
+		if posts, err := s.getThreadPosts(rootId, 0, 0); err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.GetPost", "store.sql_post.get.app_error", nil, "root_id="+rootId+err.Error())
 		} else {
 			for _, p := range posts {
@@ -601,7 +641,7 @@ var specialSearchChar = []string{
 	":",
 }
 
-func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchParams) StoreChannel {
+func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchParams, page, perPage int) StoreChannel {
 	storeChannel := make(StoreChannel)
 
 	go func() {
@@ -610,28 +650,30 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 		queryParams := map[string]interface{}{
 			"TeamId": teamId,
 			"UserId": userId,
+			"Offset": page * perPage,
+			"Limit":  perPage,
 		}
 
-		termMap := map[string]bool{}
 		terms := params.Terms
+		excludedTerms := params.ExcludedTerms
 
-		if terms == "" && len(params.InChannels) == 0 && len(params.FromUsers) == 0 {
+		if terms == "" && !params.HasFilters() {
 			result.Data = []*model.Post{}
 			storeChannel <- result
 			return
 		}
 
 		searchType := "Message"
+		hashtagFilter := ""
 		if params.IsHashtag {
 			searchType = "Hashtags"
-			for _, term := range strings.Split(terms, " ") {
-				termMap[strings.ToUpper(term)] = true
-			}
+			hashtagFilter = s.hashtagSearchFilter(strings.Fields(terms), queryParams)
 		}
 
 		// these chars have special meaning and can be treated as spaces
 		for _, c := range specialSearchChar {
 			terms = strings.Replace(terms, c, " ", -1)
+			excludedTerms = strings.Replace(excludedTerms, c, " ", -1)
 		}
 
 		var posts []*model.Post
@@ -645,6 +687,8 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 				DeleteAt = 0
 				AND Type NOT LIKE '` + model.POST_SYSTEM_MESSAGE_PREFIX + `%'
 				POST_FILTER
+				DATE_FILTER
+				FILE_FILTER
 				AND ChannelId IN (
 					SELECT
 						Id
@@ -658,8 +702,25 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 							AND DeleteAt = 0
 							CHANNEL_FILTER)
 				SEARCH_CLAUSE
+				HASHTAG_FILTER
+				EXCLUDED_CLAUSE
 				ORDER BY CreateAt DESC
-			LIMIT 100`
+			LIMIT :Limit OFFSET :Offset`
+
+		dateFilter := ""
+		if start, end := params.GetCreateAtRange(); start != 0 || end != 0 {
+			if start != 0 {
+				dateFilter += " AND CreateAt >= :StartTime"
+				queryParams["StartTime"] = start
+			}
+
+			if end != 0 {
+				dateFilter += " AND CreateAt <= :EndTime"
+				queryParams["EndTime"] = end
+			}
+		}
+		searchQuery = strings.Replace(searchQuery, "DATE_FILTER", dateFilter, 1)
+		searchQuery = strings.Replace(searchQuery, "FILE_FILTER", s.fileSearchFilter(params, queryParams), 1)
 
 		if len(params.InChannels) > 1 {
 			inClause := ":InChannel0"
@@ -733,9 +794,35 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 			}
 
 			searchClause := fmt.Sprintf("AND %s @@  to_tsquery(:Terms)", searchType)
+			if !params.IsHashtag {
+				searchClause = `
+				AND (Message @@ to_tsquery(:Terms)
+					OR Id IN (
+						SELECT
+							PostId
+						FROM
+							FileInfos
+						WHERE
+							PostId != ''
+							AND DeleteAt = 0
+							AND (Filename @@ to_tsquery(:Terms) OR Content @@ to_tsquery(:Terms))))`
+			}
 			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
 		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_MYSQL {
 			searchClause := fmt.Sprintf("AND MATCH (%s) AGAINST (:Terms IN BOOLEAN MODE)", searchType)
+			if !params.IsHashtag {
+				searchClause = `
+				AND (MATCH (Message) AGAINST (:Terms IN BOOLEAN MODE)
+					OR Id IN (
+						SELECT
+							PostId
+						FROM
+							FileInfos
+						WHERE
+							PostId != ''
+							AND DeleteAt = 0
+							AND (MATCH (Filename) AGAINST (:Terms IN BOOLEAN MODE) OR MATCH (Content) AGAINST (:Terms IN BOOLEAN MODE))))`
+			}
 			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
 
 			if !params.OrTerms {
@@ -750,6 +837,16 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 
 		queryParams["Terms"] = terms
 
+		excludedClause := ""
+		if excluded := strings.Fields(excludedTerms); len(excluded) > 0 {
+			excludedClause += s.excludedSearchClause("Message", "ExcludedTerms", excluded, queryParams)
+		}
+		if excluded := strings.Fields(params.ExcludedHashtags); len(excluded) > 0 {
+			excludedClause += s.excludedSearchClause("Hashtags", "ExcludedHashtags", excluded, queryParams)
+		}
+		searchQuery = strings.Replace(searchQuery, "EXCLUDED_CLAUSE", excludedClause, 1)
+		searchQuery = strings.Replace(searchQuery, "HASHTAG_FILTER", hashtagFilter, 1)
+
 		_, err := s.GetReplica().Select(&posts, searchQuery, queryParams)
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.Search", "store.sql_post.search.app_error", nil, "teamId="+teamId+", err="+err.Error())
@@ -758,17 +855,6 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 		list := &model.PostList{Order: make([]string, 0, len(posts))}
 
 		for _, p := range posts {
-			if searchType == "Hashtags" {
-				exactMatch := false
-				for _, tag := range strings.Split(p.Hashtags, " ") {
-					if termMap[strings.ToUpper(tag)] {
-						exactMatch = true
-					}
-				}
-				if !exactMatch {
-					continue
-				}
-			}
 			list.AddPost(p)
 			list.AddOrder(p.Id)
 		}
@@ -784,7 +870,94 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 	return storeChannel
 }
 
-func (s SqlPostStore) GetForExport(channelId string) StoreChannel {
+// fileSearchFilter limits the search to posts with an attachment matching the file name and extension flags
+func (s SqlPostStore) fileSearchFilter(params *model.SearchParams, queryParams map[string]interface{}) string {
+	if !params.HasFileFilters() {
+		return ""
+	}
+
+	conditions := []string{}
+
+	if len(params.FileNames) > 0 {
+		nameClauses := []string{}
+		for i, name := range params.FileNames {
+			paramName := "FileName" + strconv.FormatInt(int64(i), 10)
+			nameClauses = append(nameClauses, "LOWER(Filename) LIKE :"+paramName)
+
+			// names match anywhere in the file name unless a wildcard says otherwise
+			pattern := strings.ToLower(name)
+			pattern = strings.Replace(pattern, "\\", "\\\\", -1)
+			pattern = strings.Replace(pattern, "%", "\\%", -1)
+			pattern = strings.Replace(pattern, "_", "\\_", -1)
+			if strings.Contains(pattern, "*") {
+				pattern = strings.Replace(pattern, "*", "%", -1)
+			} else {
+				pattern = "%" + pattern + "%"
+			}
+			queryParams[paramName] = pattern
+		}
+		conditions = append(conditions, "("+strings.Join(nameClauses, " OR ")+")")
+	}
+
+	if len(params.Extensions) > 0 {
+		inClause := []string{}
+		for i, extension := range params.Extensions {
+			paramName := "Extension" + strconv.FormatInt(int64(i), 10)
+			inClause = append(inClause, ":"+paramName)
+			queryParams[paramName] = strings.ToLower(extension)
+		}
+		conditions = append(conditions, "LOWER(Extension) IN ("+strings.Join(inClause, ", ")+")")
+	}
+
+	return `
+				AND Id IN (
+					SELECT
+						PostId
+					FROM
+						FileInfos
+					WHERE
+						PostId != ''
+						AND DeleteAt = 0
+						AND ` + strings.Join(conditions, " AND ") + ")"
+}
+
+// hashtagSearchFilter limits a hashtag search to posts with one of the hashtags exactly since the full text search
+// also matches hashtags that only start with a term. It's done in the query so that paging isn't thrown off.
+func (s SqlPostStore) hashtagSearchFilter(hashtags []string, queryParams map[string]interface{}) string {
+	if len(hashtags) == 0 {
+		return ""
+	}
+
+	clauses := []string{}
+	for i, hashtag := range hashtags {
+		paramName := "Hashtag" + strconv.FormatInt(int64(i), 10)
+		clauses = append(clauses, "CONCAT(' ', LOWER(Hashtags), ' ') LIKE :"+paramName)
+
+		pattern := strings.ToLower(hashtag)
+		pattern = strings.Replace(pattern, "\\", "\\\\", -1)
+		pattern = strings.Replace(pattern, "%", "\\%", -1)
+		pattern = strings.Replace(pattern, "_", "\\_", -1)
+		queryParams[paramName] = "% " + pattern + " %"
+	}
+
+	return "AND (" + strings.Join(clauses, " OR ") + ")"
+}
+
+// excludedSearchClause filters out posts where the column matches any of the excluded words
+func (s SqlPostStore) excludedSearchClause(column string, paramName string, excluded []string, queryParams map[string]interface{}) string {
+	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+		queryParams[paramName] = strings.Join(excluded, " | ")
+		return fmt.Sprintf(" AND NOT %s @@ to_tsquery(:%s)", column, paramName)
+	} else {
+		queryParams[paramName] = strings.Join(excluded, " ")
+		return fmt.Sprintf(" AND NOT MATCH (%s) AGAINST (:%s IN BOOLEAN MODE)", column, paramName)
+	}
+}
+
+// GetForExport returns a page of the posts made in a channel at or after since and before until, ordered by when
+// they were made. Pages after the first are found by passing the CreateAt of the last post returned as since and
+// its id as afterId, so posts made while the export is running can't shift them.
+func (s SqlPostStore) GetForExport(channelId string, since int64, afterId string, until int64, limit int) StoreChannel {
 	storeChannel := make(StoreChannel)
 
 	go func() {
@@ -793,8 +966,20 @@ func (s SqlPostStore) GetForExport(channelId string) StoreChannel {
 		var posts []*model.Post
 		_, err := s.GetReplica().Select(
 			&posts,
-			"SELECT * FROM Posts WHERE ChannelId = :ChannelId AND DeleteAt = 0",
-			map[string]interface{}{"ChannelId": channelId})
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND CreateAt >= :Since
+				AND (CreateAt > :Since OR Id > :AfterId)
+				AND CreateAt < :Until
+				AND DeleteAt = 0
+			ORDER BY
+				CreateAt, Id
+			LIMIT :Limit`,
+			map[string]interface{}{"ChannelId": channelId, "Since": since, "AfterId": afterId, "Until": until, "Limit": limit})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.GetForExport", "store.sql_post.get_for_export.app_error", nil, "channelId="+channelId+err.Error())
 		} else {
@@ -808,6 +993,278 @@ func (s SqlPostStore) GetForExport(channelId string) StoreChannel {
 	return storeChannel
 }
 
+// GetPinnedPosts returns the posts pinned in the channel ordered from newest to oldest
+func (s SqlPostStore) GetPinnedPosts(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND IsPinned = :IsPinned
+				AND DeleteAt = 0
+			ORDER BY CreateAt DESC`,
+			map[string]interface{}{"ChannelId": channelId, "IsPinned": true}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPinnedPosts", "store.sql_post.get_pinned_posts.app_error", nil, "channel_id="+channelId+", "+err.Error())
+		} else {
+			list := &model.PostList{}
+			for _, p := range posts {
+				list.AddPost(p)
+				list.AddOrder(p.Id)
+			}
+
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetFlaggedPosts returns the posts flagged by the user, newest first, that are in channels the user still belongs to
+func (s SqlPostStore) GetFlaggedPosts(userId string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+				Posts.*
+			FROM
+				Posts, Preferences, Channels, ChannelMembers
+			WHERE
+				Preferences.UserId = :UserId
+				AND Preferences.Category = :Category
+				AND Preferences.Value = 'true'
+				AND Posts.Id = Preferences.Name
+				AND Posts.DeleteAt = 0
+				AND Channels.Id = Posts.ChannelId
+				AND Channels.DeleteAt = 0
+				AND ChannelMembers.ChannelId = Posts.ChannelId
+				AND ChannelMembers.UserId = :UserId
+			ORDER BY Posts.CreateAt DESC
+			LIMIT :Limit OFFSET :Offset`,
+			map[string]interface{}{"UserId": userId, "Category": model.PREFERENCE_CATEGORY_FLAGGED_POST, "Limit": limit, "Offset": offset}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetFlaggedPosts", "store.sql_post.get_flagged_posts.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			list := &model.PostList{}
+			for _, p := range posts {
+				list.AddPost(p)
+				list.AddOrder(p.Id)
+			}
+
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// getThreadPosts returns the undeleted posts in the thread with the given root, including the root itself, ordered from
+// newest to oldest. When limit is above zero only that many posts are returned after skipping the first offset posts.
+func (s SqlPostStore) getThreadPosts(rootId string, offset int, limit int) ([]*model.Post, error) {
+	query := "SELECT * FROM Posts WHERE (Id = :RootId OR RootId = :RootId) AND DeleteAt = 0 ORDER BY CreateAt DESC, Id DESC"
+	params := map[string]interface{}{"RootId": rootId}
+
+	if limit > 0 {
+		query += " LIMIT :Limit OFFSET :Offset"
+		params["Limit"] = limit
+		params["Offset"] = offset
+	}
+
+	var posts []*model.Post
+	if _, err := s.GetReplica().Select(&posts, query, params); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// GetPostThread returns a page of the posts in the thread with the given root ordered from newest to oldest, so the root
+// post is last in the order of the final page. The root post is always included in the list's posts so that the replies
+// can be shown with it.
+func (s SqlPostStore) GetPostThread(rootId string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var root model.Post
+		if err := s.GetReplica().SelectOne(&root, "SELECT * FROM Posts WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"Id": rootId}); err != nil || len(root.RootId) > 0 {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostThread", "store.sql_post.get_post_thread.root.app_error", nil, "root_id="+rootId)
+		} else if posts, err := s.getThreadPosts(rootId, offset, limit); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostThread", "store.sql_post.get_post_thread.app_error", nil, "root_id="+rootId+", "+err.Error())
+		} else {
+			list := &model.PostList{}
+			list.AddPost(&root)
+
+			for _, p := range posts {
+				list.AddPost(p)
+				list.AddOrder(p.Id)
+			}
+
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPostStore) GetPostsByIds(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		posts := []*model.Post{}
+
+		if len(postIds) > 0 {
+			keys := make([]string, len(postIds))
+			params := make(map[string]interface{}, len(postIds))
+			for i, postId := range postIds {
+				key := "PostId" + strconv.Itoa(i)
+				keys[i] = ":" + key
+				params[key] = postId
+			}
+
+			query := "SELECT * FROM Posts WHERE Id IN (" + strings.Join(keys, ", ") + ") AND DeleteAt = 0"
+			if _, err := s.GetReplica().Select(&posts, query, params); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.GetPostsByIds", "store.sql_post.get_posts_by_ids.app_error", nil, err.Error())
+			}
+		}
+
+		result.Data = posts
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPostsBatchForIndexing returns the next batch of posts, oldest first, that were created after the
+// post identified by startTime and startId
+func (s SqlPostStore) GetPostsBatchForIndexing(startTime int64, startId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		_, err := s.GetReplica().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				DeleteAt = 0
+				AND (CreateAt > :StartTime OR (CreateAt = :StartTime AND Id > :StartId))
+			ORDER BY CreateAt ASC, Id ASC
+			LIMIT :Limit`,
+			map[string]interface{}{"StartTime": startTime, "StartId": startId, "Limit": limit})
+
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostsBatchForIndexing", "store.sql_post.get_posts_batch_for_indexing.app_error", nil, err.Error())
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPostsBatchForRetention returns the oldest posts in the channel, including deleted ones, that were created before endTime
+// along with every reply to them. Replies come before root posts so that a root post is never deleted in an earlier batch
+// than its replies. It reads from the master so that a lagging replica can't hand back posts from a batch that was already
+// deleted.
+func (s SqlPostStore) GetPostsBatchForRetention(channelId string, endTime int64, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		_, err := s.GetMaster().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND (CreateAt < :EndTime
+					OR RootId IN (
+						SELECT
+							Roots.Id
+						FROM
+							Posts AS Roots
+						WHERE
+							Roots.ChannelId = :ChannelId
+							AND Roots.RootId = ''
+							AND Roots.CreateAt < :EndTime))
+			ORDER BY CASE WHEN RootId = '' THEN 1 ELSE 0 END, CreateAt ASC, Id ASC
+			LIMIT :Limit OFFSET :Offset`,
+			map[string]interface{}{"ChannelId": channelId, "EndTime": endTime, "Limit": limit, "Offset": offset})
+
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostsBatchForRetention", "store.sql_post.get_posts_batch_for_retention.app_error", nil, "channelId="+channelId+", err="+err.Error())
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPostStore) PermanentDeleteByIds(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			keys := make([]string, len(postIds))
+			params := make(map[string]interface{}, len(postIds))
+			for i, postId := range postIds {
+				key := "PostId" + strconv.Itoa(i)
+				keys[i] = ":" + key
+				params[key] = postId
+			}
+
+			if _, err := s.GetMaster().Exec("DELETE FROM Posts WHERE Id IN ("+strings.Join(keys, ", ")+")", params); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.PermanentDeleteByIds", "store.sql_post.permanent_delete_by_ids.app_error", nil, err.Error())
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlPostStore) AnalyticsUserCountsWithPostsByDay(teamId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -708,70 +708,138 @@ func TestPostStoreSearch(t *testing.T) {
 	o5.Hashtags = "#secret #howdy"
 	o5 = (<-store.Post().Save(o5)).Data.(*model.Post)
 
-	r1 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "corey", IsHashtag: false})).Data.(*model.PostList)
+	r1 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "corey", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r1.Order) != 1 || r1.Order[0] != o1.Id {
 		t.Fatal("returned wrong search result")
 	}
 
-	r3 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "new", IsHashtag: false})).Data.(*model.PostList)
+	r3 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "new", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r3.Order) != 2 || (r3.Order[0] != o1.Id && r3.Order[1] != o1.Id) {
 		t.Fatal("returned wrong search result")
 	}
 
-	r4 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "john", IsHashtag: false})).Data.(*model.PostList)
+	r4 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "john", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r4.Order) != 1 || r4.Order[0] != o2.Id {
 		t.Fatal("returned wrong search result")
 	}
 
-	r5 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "matter*", IsHashtag: false})).Data.(*model.PostList)
+	r5 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "matter*", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r5.Order) != 1 || r5.Order[0] != o1.Id {
 		t.Fatal("returned wrong search result")
 	}
 
-	r6 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "#hashtag", IsHashtag: true})).Data.(*model.PostList)
+	r6 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "#hashtag", IsHashtag: true}, 0, 100)).Data.(*model.PostList)
 	if len(r6.Order) != 1 || r6.Order[0] != o4.Id {
 		t.Fatal("returned wrong search result")
 	}
 
-	r7 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "#secret", IsHashtag: true})).Data.(*model.PostList)
+	r7 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "#secret", IsHashtag: true}, 0, 100)).Data.(*model.PostList)
 	if len(r7.Order) != 1 || r7.Order[0] != o5.Id {
 		t.Fatal("returned wrong search result")
 	}
 
-	r8 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "@thisshouldmatchnothing", IsHashtag: true})).Data.(*model.PostList)
+	r8 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "@thisshouldmatchnothing", IsHashtag: true}, 0, 100)).Data.(*model.PostList)
 	if len(r8.Order) != 0 {
 		t.Fatal("returned wrong search result")
 	}
 
-	r9 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "mattermost jersey", IsHashtag: false})).Data.(*model.PostList)
+	r9 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "mattermost jersey", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r9.Order) != 0 {
 		t.Fatal("returned wrong search result")
 	}
 
-	r9a := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "corey new york", IsHashtag: false})).Data.(*model.PostList)
+	r9a := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "corey new york", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r9a.Order) != 1 {
 		t.Fatal("returned wrong search result")
 	}
 
-	r10 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "matter* jer*", IsHashtag: false})).Data.(*model.PostList)
+	r10 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "matter* jer*", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r10.Order) != 0 {
 		t.Fatal("returned wrong search result")
 	}
 
-	r11 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "message blargh", IsHashtag: false})).Data.(*model.PostList)
+	r11 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "message blargh", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r11.Order) != 1 {
 		t.Fatal("returned wrong search result")
 	}
 
-	r12 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "blargh>", IsHashtag: false})).Data.(*model.PostList)
+	r12 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "blargh>", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
 	if len(r12.Order) != 1 {
 		t.Fatal("returned wrong search result")
 	}
 
-	r13 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "Jersey corey", IsHashtag: false, OrTerms: true})).Data.(*model.PostList)
+	r13 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "Jersey corey", IsHashtag: false, OrTerms: true}, 0, 100)).Data.(*model.PostList)
 	if len(r13.Order) != 2 {
 		t.Fatal("returned wrong search result")
 	}
+
+	r14 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "Jersey corey", ExcludedTerms: "john", IsHashtag: false, OrTerms: true}, 0, 100)).Data.(*model.PostList)
+	if len(r14.Order) != 1 || r14.Order[0] != o1.Id {
+		t.Fatal("returned wrong search result")
+	}
+
+	r15 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "#secret", ExcludedHashtags: "#howdy", IsHashtag: true}, 0, 100)).Data.(*model.PostList)
+	if len(r15.Order) != 0 {
+		t.Fatal("returned wrong search result")
+	}
+
+	o5a := &model.Post{}
+	o5a.ChannelId = c1.Id
+	o5a.UserId = model.NewId()
+	o5a.Hashtags = "#secretive"
+	o5a = (<-store.Post().Save(o5a)).Data.(*model.Post)
+
+	// posts with a hashtag that only starts with the term are filtered out before the page is taken
+	r15a := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "#secret", IsHashtag: true}, 0, 1)).Data.(*model.PostList)
+	if len(r15a.Order) != 1 || r15a.Order[0] != o5.Id {
+		t.Fatal("returned wrong search result")
+	}
+
+	page0 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "Jersey corey", IsHashtag: false, OrTerms: true}, 0, 1)).Data.(*model.PostList)
+	page1 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "Jersey corey", IsHashtag: false, OrTerms: true}, 1, 1)).Data.(*model.PostList)
+	if len(page0.Order) != 1 || len(page1.Order) != 1 || page0.Order[0] == page1.Order[0] {
+		t.Fatal("returned wrong search result")
+	}
+
+	r16 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "corey", BeforeDate: "2000-01-01", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
+	if len(r16.Order) != 0 {
+		t.Fatal("returned wrong search result")
+	}
+
+	r17 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "corey", AfterDate: "2000-01-01", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
+	if len(r17.Order) != 1 {
+		t.Fatal("returned wrong search result")
+	}
+
+	o6 := &model.Post{}
+	o6.ChannelId = c1.Id
+	o6.UserId = model.NewId()
+	o6.Message = "here are the numbers"
+	o6 = (<-store.Post().Save(o6)).Data.(*model.Post)
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: o6.UserId, ChannelId: c1.Id, Filename: "budget.PDF", Extension: "PDF"})).(*model.FileInfo)
+	Must(store.FileInfo().AttachToPost(info.Id, o6.Id))
+	Must(store.FileInfo().UpdateContent(info.Id, "quarterly spreadsheet totals"))
+
+	r18 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "spreadsheet", IsHashtag: false}, 0, 100)).Data.(*model.PostList)
+	if len(r18.Order) != 1 || r18.Order[0] != o6.Id {
+		t.Fatal("should've found the post by the contents of its attachment")
+	}
+
+	r19 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "", IsHashtag: true, Extensions: []string{"pdf"}}, 0, 100)).Data.(*model.PostList)
+	if len(r19.Order) != 1 || r19.Order[0] != o6.Id {
+		t.Fatal("should've found the post by the extension of its attachment")
+	}
+
+	r20 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "", IsHashtag: true, FileNames: []string{"budg"}}, 0, 100)).Data.(*model.PostList)
+	if len(r20.Order) != 1 || r20.Order[0] != o6.Id {
+		t.Fatal("should've found the post by the name of its attachment")
+	}
+
+	r21 := (<-store.Post().Search(teamId, userId, &model.SearchParams{Terms: "corey", IsHashtag: false, FileNames: []string{"budget"}}, 0, 100)).Data.(*model.PostList)
+	if len(r21.Order) != 0 {
+		t.Fatal("shouldn't have found posts without a matching attachment")
+	}
 }
 
 func TestUserCountsWithPostsByDay(t *testing.T) {
@@ -905,3 +973,364 @@ func TestPostCountsByDay(t *testing.T) {
 		}
 	}
 }
+
+func TestPostStorePinnedPosts(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = (<-store.Post().Save(o1)).Data.(*model.Post)
+
+	o2 := &model.Post{}
+	o2.ChannelId = o1.ChannelId
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2.CreateAt = o1.CreateAt + 1
+	o2 = (<-store.Post().Save(o2)).Data.(*model.Post)
+
+	o3 := &model.Post{}
+	o3.ChannelId = o1.ChannelId
+	o3.UserId = model.NewId()
+	o3.Message = "a" + model.NewId() + "b"
+	o3 = (<-store.Post().Save(o3)).Data.(*model.Post)
+
+	if r := <-store.Post().UpdatePinned(o1.Id, true); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if post := r.Data.(*model.Post); !post.IsPinned || post.UpdateAt <= o1.UpdateAt {
+		t.Fatal("should have pinned the post")
+	}
+
+	Must(store.Post().UpdatePinned(o2.Id, true))
+
+	if r := <-store.Post().GetPinnedPosts(o1.ChannelId); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if list := r.Data.(*model.PostList); len(list.Order) != 2 || list.Order[0] != o2.Id || list.Order[1] != o1.Id {
+		t.Fatal("should have returned the pinned posts newest first")
+	}
+
+	Must(store.Post().UpdatePinned(o2.Id, false))
+	Must(store.Post().Delete(o1.Id, model.GetMillis()))
+
+	if list := Must(store.Post().GetPinnedPosts(o1.ChannelId)).(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("shouldn't have returned unpinned or deleted posts")
+	}
+
+	if r := <-store.Post().UpdatePinned(o1.Id, true); r.Err == nil {
+		t.Fatal("shouldn't be able to pin a deleted post")
+	}
+}
+
+func TestPostStoreGetFlaggedPosts(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+
+	c1 := &model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	c1 = (<-store.Channel().Save(c1)).Data.(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	c2 := &model.Channel{}
+	c2.TeamId = c1.TeamId
+	c2.DisplayName = "Channel2"
+	c2.Name = "a" + model.NewId() + "b"
+	c2.Type = model.CHANNEL_OPEN
+	c2 = (<-store.Channel().Save(c2)).Data.(*model.Channel)
+
+	o1 := &model.Post{}
+	o1.ChannelId = c1.Id
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = (<-store.Post().Save(o1)).Data.(*model.Post)
+
+	o2 := &model.Post{}
+	o2.ChannelId = c1.Id
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2.CreateAt = o1.CreateAt + 1
+	o2 = (<-store.Post().Save(o2)).Data.(*model.Post)
+
+	o3 := &model.Post{}
+	o3.ChannelId = c2.Id
+	o3.UserId = model.NewId()
+	o3.Message = "a" + model.NewId() + "b"
+	o3 = (<-store.Post().Save(o3)).Data.(*model.Post)
+
+	o4 := &model.Post{}
+	o4.ChannelId = c1.Id
+	o4.UserId = model.NewId()
+	o4.Message = "a" + model.NewId() + "b"
+	o4 = (<-store.Post().Save(o4)).Data.(*model.Post)
+
+	preferences := model.Preferences{}
+	for _, post := range []*model.Post{o1, o2, o3, o4} {
+		preferences = append(preferences, model.Preference{
+			UserId:   userId,
+			Category: model.PREFERENCE_CATEGORY_FLAGGED_POST,
+			Name:     post.Id,
+			Value:    "true",
+		})
+	}
+	preferences[3].Value = "false"
+	Must(store.Preference().Save(&preferences))
+
+	if r := <-store.Post().GetFlaggedPosts(userId, 0, 10); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if list := r.Data.(*model.PostList); len(list.Order) != 2 || list.Order[0] != o2.Id || list.Order[1] != o1.Id {
+		t.Fatal("should only have returned the flagged posts in the user's channels")
+	}
+
+	if list := Must(store.Post().GetFlaggedPosts(userId, 1, 1)).(*model.PostList); len(list.Order) != 1 || list.Order[0] != o1.Id {
+		t.Fatal("should have returned the second page")
+	}
+
+	Must(store.Post().Delete(o2.Id, model.GetMillis()))
+
+	if list := Must(store.Post().GetFlaggedPosts(userId, 0, 10)).(*model.PostList); len(list.Order) != 1 {
+		t.Fatal("shouldn't have returned deleted posts")
+	}
+}
+
+func TestPostStoreGetPostThread(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = (<-store.Post().Save(o1)).Data.(*model.Post)
+
+	o2 := &model.Post{}
+	o2.ChannelId = o1.ChannelId
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2.RootId = o1.Id
+	o2.ParentId = o1.Id
+	o2.CreateAt = o1.CreateAt + 1
+	o2 = (<-store.Post().Save(o2)).Data.(*model.Post)
+
+	o3 := &model.Post{}
+	o3.ChannelId = o1.ChannelId
+	o3.UserId = model.NewId()
+	o3.Message = "a" + model.NewId() + "b"
+	o3.RootId = o1.Id
+	o3.ParentId = o1.Id
+	o3.CreateAt = o1.CreateAt + 2
+	o3 = (<-store.Post().Save(o3)).Data.(*model.Post)
+
+	o4 := &model.Post{}
+	o4.ChannelId = o1.ChannelId
+	o4.UserId = model.NewId()
+	o4.Message = "a" + model.NewId() + "b"
+	o4 = (<-store.Post().Save(o4)).Data.(*model.Post)
+
+	if r := <-store.Post().GetPostThread(o1.Id, 0, 60); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if list := r.Data.(*model.PostList); len(list.Order) != 3 || len(list.Posts) != 3 {
+		t.Fatal("should have returned the root post and its replies")
+	} else if list.Order[0] != o3.Id || list.Order[1] != o2.Id || list.Order[2] != o1.Id {
+		t.Fatal("posts should be ordered newest first")
+	}
+
+	if r := <-store.Post().GetPostThread(o1.Id, 0, 2); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if list := r.Data.(*model.PostList); len(list.Order) != 2 || list.Order[0] != o3.Id || list.Order[1] != o2.Id {
+		t.Fatal("should have returned the first page of the thread", list.Order)
+	} else if _, ok := list.Posts[o1.Id]; !ok {
+		t.Fatal("should have included the root post with every page")
+	}
+
+	if r := <-store.Post().GetPostThread(o1.Id, 2, 2); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if list := r.Data.(*model.PostList); len(list.Order) != 1 || list.Order[0] != o1.Id {
+		t.Fatal("should have returned the root post on the last page", list.Order)
+	}
+
+	if r := <-store.Post().GetPostThread(o2.Id, 0, 60); r.Err == nil {
+		t.Fatal("should have failed for a reply")
+	}
+
+	if r := <-store.Post().GetPostThread(model.NewId(), 0, 60); r.Err == nil {
+		t.Fatal("should have failed for a missing post")
+	}
+}
+
+func TestPostStoreGetPostsByIds(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = (<-store.Post().Save(o1)).Data.(*model.Post)
+
+	o2 := &model.Post{}
+	o2.ChannelId = o1.ChannelId
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2 = (<-store.Post().Save(o2)).Data.(*model.Post)
+
+	o3 := &model.Post{}
+	o3.ChannelId = o1.ChannelId
+	o3.UserId = model.NewId()
+	o3.Message = "a" + model.NewId() + "b"
+	o3 = (<-store.Post().Save(o3)).Data.(*model.Post)
+	Must(store.Post().Delete(o3.Id, model.GetMillis()))
+
+	if r := <-store.Post().GetPostsByIds([]string{o1.Id, o2.Id, o3.Id}); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if posts := r.Data.([]*model.Post); len(posts) != 2 {
+		t.Fatal("should have returned the two posts that weren't deleted")
+	}
+
+	if r := <-store.Post().GetPostsByIds([]string{}); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if len(r.Data.([]*model.Post)) != 0 {
+		t.Fatal("should have returned no posts")
+	}
+}
+
+func TestPostStoreGetPostsBatchForIndexing(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = (<-store.Post().Save(o1)).Data.(*model.Post)
+
+	o2 := &model.Post{}
+	o2.ChannelId = o1.ChannelId
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2.CreateAt = o1.CreateAt
+	o2 = (<-store.Post().Save(o2)).Data.(*model.Post)
+
+	first, second := o1, o2
+	if second.Id < first.Id {
+		first, second = second, first
+	}
+
+	if r := <-store.Post().GetPostsBatchForIndexing(first.CreateAt-1, "", 1000); r.Err != nil {
+		t.Fatal(r.Err)
+	} else {
+		found := 0
+		for _, post := range r.Data.([]*model.Post) {
+			if post.Id == first.Id && found == 0 {
+				found = 1
+			} else if post.Id == second.Id && found == 1 {
+				found = 2
+			}
+		}
+
+		if found != 2 {
+			t.Fatal("should have returned both posts in order")
+		}
+	}
+
+	if r := <-store.Post().GetPostsBatchForIndexing(first.CreateAt, first.Id, 1000); r.Err != nil {
+		t.Fatal(r.Err)
+	} else {
+		for _, post := range r.Data.([]*model.Post) {
+			if post.Id == first.Id {
+				t.Fatal("should have started after the first post")
+			}
+		}
+	}
+}
+
+func TestPostStoreRetention(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+
+	o1 := &model.Post{}
+	o1.ChannelId = channelId
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1.CreateAt = 1000
+	o1 = (<-store.Post().Save(o1)).Data.(*model.Post)
+
+	o2 := &model.Post{}
+	o2.ChannelId = channelId
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2.CreateAt = 2000
+	o2 = (<-store.Post().Save(o2)).Data.(*model.Post)
+	Must(store.Post().Delete(o2.Id, model.GetMillis()))
+
+	o3 := &model.Post{}
+	o3.ChannelId = channelId
+	o3.UserId = model.NewId()
+	o3.Message = "a" + model.NewId() + "b"
+	o3 = (<-store.Post().Save(o3)).Data.(*model.Post)
+
+	o4 := &model.Post{}
+	o4.ChannelId = channelId
+	o4.UserId = model.NewId()
+	o4.Message = "a" + model.NewId() + "b"
+	o4.RootId = o1.Id
+	o4.ParentId = o1.Id
+	o4 = (<-store.Post().Save(o4)).Data.(*model.Post)
+
+	if r := <-store.Post().GetPostsBatchForRetention(channelId, 3000, 0, 10); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if posts := r.Data.([]*model.Post); len(posts) != 3 || posts[0].Id != o4.Id || posts[1].Id != o1.Id || posts[2].Id != o2.Id {
+		t.Fatal("should have returned the old posts including deleted ones with the newer reply first")
+	}
+
+	if r := <-store.Post().GetPostsBatchForRetention(channelId, 3000, 1, 10); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if posts := r.Data.([]*model.Post); len(posts) != 2 || posts[0].Id != o1.Id {
+		t.Fatal("should have skipped the first post")
+	}
+
+	Must(store.Post().PermanentDeleteByIds([]string{o1.Id, o2.Id, o4.Id}))
+
+	if r := <-store.Post().GetPostsBatchForRetention(channelId, model.GetMillis()+1, 0, 10); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if posts := r.Data.([]*model.Post); len(posts) != 1 || posts[0].Id != o3.Id {
+		t.Fatal("should have permanently deleted the old posts")
+	}
+}
+
+func TestPostStoreGetForExport(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+
+	var posts []*model.Post
+	for i := 0; i < 3; i++ {
+		post := &model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: int64(1000 + i)}
+		posts = append(posts, Must(store.Post().Save(post)).(*model.Post))
+	}
+
+	if result := <-store.Post().GetForExport(channelId, 1000, "", 1002, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if exported := result.Data.([]*model.Post); len(exported) != 2 || exported[0].Id != posts[0].Id || exported[1].Id != posts[1].Id {
+		t.Fatal("should've returned the posts in the range in order")
+	}
+
+	if result := <-store.Post().GetForExport(channelId, posts[1].CreateAt, posts[1].Id, 2000, 2); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if exported := result.Data.([]*model.Post); len(exported) != 1 || exported[0].Id != posts[2].Id {
+		t.Fatal("should've returned the page after the given post")
+	}
+
+	sameTime := Must(store.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: posts[2].CreateAt})).(*model.Post)
+	first, second := posts[2], sameTime
+	if second.Id < first.Id {
+		first, second = second, first
+	}
+
+	if result := <-store.Post().GetForExport(channelId, first.CreateAt, first.Id, 2000, 2); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if exported := result.Data.([]*model.Post); len(exported) != 1 || exported[0].Id != second.Id {
+		t.Fatal("should've returned the post made at the same time as the last one")
+	}
+}
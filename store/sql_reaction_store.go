@@ -0,0 +1,202 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlReactionStore struct {
+	*SqlStore
+}
+
+func NewSqlReactionStore(sqlStore *SqlStore) ReactionStore {
+	s := &SqlReactionStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Reaction{}, "Reactions").SetKeys(false, "UserId", "PostId", "EmojiName")
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("EmojiName").SetMaxSize(64)
+	}
+
+	return s
+}
+
+func (s SqlReactionStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlReactionStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_reactions_post_id", "Reactions", "PostId")
+	s.CreateIndexIfNotExists("idx_reactions_user_id", "Reactions", "UserId")
+}
+
+func (s SqlReactionStore) Save(reaction *model.Reaction) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		reaction.PreSave()
+		if result.Err = reaction.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		// Reacting twice with the same emoji is a no-op
+		if count, err := s.GetMaster().SelectInt(
+			"SELECT COUNT(*) FROM Reactions WHERE UserId = :UserId AND PostId = :PostId AND EmojiName = :EmojiName",
+			map[string]interface{}{"UserId": reaction.UserId, "PostId": reaction.PostId, "EmojiName": reaction.EmojiName}); err != nil {
+			result.Err = model.NewLocAppError("SqlReactionStore.Save", "store.sql_reaction.save.app_error", nil, "post_id="+reaction.PostId+", "+err.Error())
+		} else if count > 0 {
+			result.Data = reaction
+		} else if err := s.GetMaster().Insert(reaction); err != nil {
+			result.Err = model.NewLocAppError("SqlReactionStore.Save", "store.sql_reaction.save.app_error", nil, "post_id="+reaction.PostId+", "+err.Error())
+		} else {
+			result.Data = reaction
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlReactionStore) Delete(reaction *model.Reaction) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`DELETE FROM
+				Reactions
+			WHERE
+				UserId = :UserId
+				AND PostId = :PostId
+				AND EmojiName = :EmojiName`,
+			map[string]interface{}{"UserId": reaction.UserId, "PostId": reaction.PostId, "EmojiName": reaction.EmojiName}); err != nil {
+			result.Err = model.NewLocAppError("SqlReactionStore.Delete", "store.sql_reaction.delete.app_error", nil, "post_id="+reaction.PostId+", "+err.Error())
+		} else {
+			result.Data = reaction
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlReactionStore) GetForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var reactions []*model.Reaction
+
+		if _, err := s.GetReplica().Select(&reactions,
+			`SELECT
+				*
+			FROM
+				Reactions
+			WHERE
+				PostId = :PostId
+			ORDER BY
+				CreateAt`, map[string]interface{}{"PostId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlReactionStore.GetForPost", "store.sql_reaction.get_for_post.app_error", nil, "post_id="+postId+", "+err.Error())
+		} else {
+			result.Data = reactions
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForExport returns the reactions to the posts made in a channel at or after since and before until
+func (s SqlReactionStore) GetForExport(channelId string, since int64, until int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var reactions []*model.Reaction
+
+		if _, err := s.GetReplica().Select(&reactions,
+			`SELECT
+				Reactions.*
+			FROM
+				Reactions, Posts
+			WHERE
+				Reactions.PostId = Posts.Id
+				AND Posts.ChannelId = :ChannelId
+				AND Posts.CreateAt >= :Since
+				AND Posts.CreateAt < :Until
+				AND Posts.DeleteAt = 0
+			ORDER BY
+				Reactions.CreateAt`, map[string]interface{}{"ChannelId": channelId, "Since": since, "Until": until}); err != nil {
+			result.Err = model.NewLocAppError("SqlReactionStore.GetForExport", "store.sql_reaction.get_for_export.app_error", nil, "channel_id="+channelId+", "+err.Error())
+		} else {
+			result.Data = reactions
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlReactionStore) PermanentDeleteByUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM Reactions WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlReactionStore.PermanentDeleteByUser", "store.sql_reaction.permanent_delete_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlReactionStore) PermanentDeleteByPosts(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			keys := make([]string, len(postIds))
+			params := make(map[string]interface{}, len(postIds))
+			for i, postId := range postIds {
+				key := "PostId" + strconv.Itoa(i)
+				keys[i] = ":" + key
+				params[key] = postId
+			}
+
+			if _, err := s.GetMaster().Exec("DELETE FROM Reactions WHERE PostId IN ("+strings.Join(keys, ", ")+")", params); err != nil {
+				result.Err = model.NewLocAppError("SqlReactionStore.PermanentDeleteByPosts", "store.sql_reaction.permanent_delete_by_posts.app_error", nil, err.Error())
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
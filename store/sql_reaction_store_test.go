@@ -0,0 +1,143 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestReactionSave(t *testing.T) {
+	Setup()
+
+	reaction1 := &model.Reaction{
+		UserId:    model.NewId(),
+		PostId:    model.NewId(),
+		EmojiName: model.NewId(),
+	}
+	if result := <-store.Reaction().Save(reaction1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if saved := result.Data.(*model.Reaction); saved.UserId != reaction1.UserId ||
+		saved.PostId != reaction1.PostId || saved.EmojiName != reaction1.EmojiName {
+		t.Fatal("should've saved reaction and returned it")
+	}
+
+	// saving the same reaction again is allowed
+	if result := <-store.Reaction().Save(reaction1); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Reaction().GetForPost(reaction1.PostId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.Reaction)) != 1 {
+		t.Fatal("should've only saved the reaction once")
+	}
+
+	// different user
+	reaction2 := &model.Reaction{
+		UserId:    model.NewId(),
+		PostId:    reaction1.PostId,
+		EmojiName: reaction1.EmojiName,
+	}
+	if result := <-store.Reaction().Save(reaction2); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	// different emoji
+	reaction3 := &model.Reaction{
+		UserId:    reaction1.UserId,
+		PostId:    reaction1.PostId,
+		EmojiName: model.NewId(),
+	}
+	if result := <-store.Reaction().Save(reaction3); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	// invalid reaction
+	reaction4 := &model.Reaction{
+		UserId: reaction1.UserId,
+		PostId: reaction1.PostId,
+	}
+	if result := <-store.Reaction().Save(reaction4); result.Err == nil {
+		t.Fatal("should've failed for invalid reaction")
+	}
+}
+
+func TestReactionDelete(t *testing.T) {
+	Setup()
+
+	reaction := &model.Reaction{
+		UserId:    model.NewId(),
+		PostId:    model.NewId(),
+		EmojiName: model.NewId(),
+	}
+	Must(store.Reaction().Save(reaction))
+
+	if result := <-store.Reaction().Delete(reaction); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Reaction().GetForPost(reaction.PostId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.Reaction)) != 0 {
+		t.Fatal("should've deleted reaction")
+	}
+}
+
+func TestReactionGetForExport(t *testing.T) {
+	Setup()
+
+	post := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a" + model.NewId() + "b"})).(*model.Post)
+
+	reaction := &model.Reaction{
+		UserId:    model.NewId(),
+		PostId:    post.Id,
+		EmojiName: "smile",
+	}
+	Must(store.Reaction().Save(reaction))
+	Must(store.Reaction().Save(&model.Reaction{UserId: model.NewId(), PostId: model.NewId(), EmojiName: "smile"}))
+
+	if result := <-store.Reaction().GetForExport(post.ChannelId, 0, post.CreateAt+1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if reactions := result.Data.([]*model.Reaction); len(reactions) != 1 || reactions[0].UserId != reaction.UserId {
+		t.Fatal("should've only returned reactions in the channel")
+	}
+
+	if result := <-store.Reaction().GetForExport(post.ChannelId, post.CreateAt+1, post.CreateAt+2); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.Reaction)) != 0 {
+		t.Fatal("shouldn't have returned reactions to posts outside of the range")
+	}
+
+	if result := <-store.Reaction().PermanentDeleteByUser(reaction.UserId); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Reaction().GetForPost(post.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.Reaction)) != 0 {
+		t.Fatal("should've deleted the user's reactions")
+	}
+}
+
+func TestReactionPermanentDeleteByPosts(t *testing.T) {
+	Setup()
+
+	postId := model.NewId()
+	otherPostId := model.NewId()
+
+	Must(store.Reaction().Save(&model.Reaction{UserId: model.NewId(), PostId: postId, EmojiName: "smile"}))
+	Must(store.Reaction().Save(&model.Reaction{UserId: model.NewId(), PostId: postId, EmojiName: "frowning"}))
+	Must(store.Reaction().Save(&model.Reaction{UserId: model.NewId(), PostId: otherPostId, EmojiName: "smile"}))
+
+	Must(store.Reaction().PermanentDeleteByPosts([]string{postId}))
+
+	if reactions := Must(store.Reaction().GetForPost(postId)).([]*model.Reaction); len(reactions) != 0 {
+		t.Fatal("should've deleted the post's reactions")
+	}
+
+	if reactions := Must(store.Reaction().GetForPost(otherPostId)).([]*model.Reaction); len(reactions) != 1 {
+		t.Fatal("shouldn't have deleted reactions to other posts")
+	}
+}
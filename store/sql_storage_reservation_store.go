@@ -0,0 +1,129 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlStorageReservationStore struct {
+	*SqlStore
+}
+
+func NewSqlStorageReservationStore(sqlStore *SqlStore) StorageReservationStore {
+	s := &SqlStorageReservationStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.StorageReservation{}, "StorageReservations").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlStorageReservationStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlStorageReservationStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_storagereservations_user_id", "StorageReservations", "UserId")
+	s.CreateIndexIfNotExists("idx_storagereservations_team_id", "StorageReservations", "TeamId")
+	s.CreateIndexIfNotExists("idx_storagereservations_create_at", "StorageReservations", "CreateAt")
+}
+
+func (s SqlStorageReservationStore) Save(reservation *model.StorageReservation) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		reservation.PreSave()
+		if result.Err = reservation.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(reservation); err != nil {
+			result.Err = model.NewLocAppError("SqlStorageReservationStore.Save", "store.sql_storage_reservation.save.app_error", nil, "id="+reservation.Id+", "+err.Error())
+		} else {
+			result.Data = reservation
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlStorageReservationStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM StorageReservations WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlStorageReservationStore.Delete", "store.sql_storage_reservation.delete.app_error", nil, "id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetReserved returns the number of bytes reserved since the given time by the user and for the team, in that order.
+// It reads from the master so that a reservation that was just saved is always counted.
+func (s SqlStorageReservationStore) GetReserved(userId string, teamId string, since int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var reserved struct {
+			UserReserved int64
+			TeamReserved int64
+		}
+
+		if err := s.GetMaster().SelectOne(&reserved,
+			`SELECT
+				COALESCE(SUM(CASE WHEN UserId = :UserId THEN Size ELSE 0 END), 0) AS UserReserved,
+				COALESCE(SUM(CASE WHEN TeamId = :TeamId THEN Size ELSE 0 END), 0) AS TeamReserved
+			FROM
+				StorageReservations
+			WHERE
+				(UserId = :UserId OR TeamId = :TeamId)
+				AND CreateAt > :Since`, map[string]interface{}{"UserId": userId, "TeamId": teamId, "Since": since}); err != nil {
+			result.Err = model.NewLocAppError("SqlStorageReservationStore.GetReserved", "store.sql_storage_reservation.get_reserved.app_error", nil, "user_id="+userId+", team_id="+teamId+", "+err.Error())
+		} else {
+			result.Data = []int64{reserved.UserReserved, reserved.TeamReserved}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteBefore deletes the reservations that were left behind by uploads that never finished
+func (s SqlStorageReservationStore) PermanentDeleteBefore(before int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM StorageReservations WHERE CreateAt < :Before", map[string]interface{}{"Before": before}); err != nil {
+			result.Err = model.NewLocAppError("SqlStorageReservationStore.PermanentDeleteBefore", "store.sql_storage_reservation.permanent_delete_before.app_error", nil, err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestStorageReservationStore(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	teamId := model.NewId()
+
+	r1 := Must(store.StorageReservation().Save(&model.StorageReservation{UserId: userId, TeamId: teamId, Size: 10})).(*model.StorageReservation)
+	Must(store.StorageReservation().Save(&model.StorageReservation{UserId: userId, TeamId: model.NewId(), Size: 20}))
+	Must(store.StorageReservation().Save(&model.StorageReservation{UserId: model.NewId(), TeamId: teamId, Size: 40}))
+
+	if reserved := Must(store.StorageReservation().GetReserved(userId, teamId, 0)).([]int64); reserved[0] != 30 || reserved[1] != 50 {
+		t.Fatal("should've counted the user's and the team's reservations", reserved)
+	}
+
+	if reserved := Must(store.StorageReservation().GetReserved(userId, teamId, model.GetMillis()+1)).([]int64); reserved[0] != 0 || reserved[1] != 0 {
+		t.Fatal("shouldn't have counted expired reservations", reserved)
+	}
+
+	Must(store.StorageReservation().Delete(r1.Id))
+
+	if reserved := Must(store.StorageReservation().GetReserved(userId, teamId, 0)).([]int64); reserved[0] != 20 || reserved[1] != 40 {
+		t.Fatal("shouldn't have counted the released reservation", reserved)
+	}
+
+	Must(store.StorageReservation().PermanentDeleteBefore(model.GetMillis() + 1))
+
+	if reserved := Must(store.StorageReservation().GetReserved(userId, teamId, 0)).([]int64); reserved[0] != 0 || reserved[1] != 0 {
+		t.Fatal("should've deleted the expired reservations", reserved)
+	}
+}
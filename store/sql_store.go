@@ -53,6 +53,15 @@ type SqlStore struct {
 	license       LicenseStore
 	recovery      PasswordRecoveryStore
 	emoji         EmojiStore
+	reaction      ReactionStore
+	thread        ThreadStore
+	fileInfo      FileInfoStore
+	uploadSession UploadSessionStore
+	exportJob     ExportJobStore
+	reservation   StorageReservationStore
+	taskLease     TaskLeaseStore
+	memberHistory ChannelMemberHistoryStore
+	accessToken   UserAccessTokenStore
 	SchemaVersion string
 }
 
@@ -129,6 +138,15 @@ func NewSqlStore() Store {
 	sqlStore.license = NewSqlLicenseStore(sqlStore)
 	sqlStore.recovery = NewSqlPasswordRecoveryStore(sqlStore)
 	sqlStore.emoji = NewSqlEmojiStore(sqlStore)
+	sqlStore.reaction = NewSqlReactionStore(sqlStore)
+	sqlStore.thread = NewSqlThreadStore(sqlStore)
+	sqlStore.fileInfo = NewSqlFileInfoStore(sqlStore)
+	sqlStore.uploadSession = NewSqlUploadSessionStore(sqlStore)
+	sqlStore.exportJob = NewSqlExportJobStore(sqlStore)
+	sqlStore.reservation = NewSqlStorageReservationStore(sqlStore)
+	sqlStore.taskLease = NewSqlTaskLeaseStore(sqlStore)
+	sqlStore.memberHistory = NewSqlChannelMemberHistoryStore(sqlStore)
+	sqlStore.accessToken = NewSqlUserAccessTokenStore(sqlStore)
 
 	err := sqlStore.master.CreateTablesIfNotExists()
 	if err != nil {
@@ -152,6 +170,15 @@ func NewSqlStore() Store {
 	sqlStore.license.(*SqlLicenseStore).UpgradeSchemaIfNeeded()
 	sqlStore.recovery.(*SqlPasswordRecoveryStore).UpgradeSchemaIfNeeded()
 	sqlStore.emoji.(*SqlEmojiStore).UpgradeSchemaIfNeeded()
+	sqlStore.reaction.(*SqlReactionStore).UpgradeSchemaIfNeeded()
+	sqlStore.thread.(*SqlThreadStore).UpgradeSchemaIfNeeded()
+	sqlStore.fileInfo.(*SqlFileInfoStore).UpgradeSchemaIfNeeded()
+	sqlStore.uploadSession.(*SqlUploadSessionStore).UpgradeSchemaIfNeeded()
+	sqlStore.exportJob.(*SqlExportJobStore).UpgradeSchemaIfNeeded()
+	sqlStore.reservation.(*SqlStorageReservationStore).UpgradeSchemaIfNeeded()
+	sqlStore.taskLease.(*SqlTaskLeaseStore).UpgradeSchemaIfNeeded()
+	sqlStore.memberHistory.(*SqlChannelMemberHistoryStore).UpgradeSchemaIfNeeded()
+	sqlStore.accessToken.(*SqlUserAccessTokenStore).UpgradeSchemaIfNeeded()
 
 	sqlStore.team.(*SqlTeamStore).CreateIndexesIfNotExists()
 	sqlStore.channel.(*SqlChannelStore).CreateIndexesIfNotExists()
@@ -168,6 +195,15 @@ func NewSqlStore() Store {
 	sqlStore.license.(*SqlLicenseStore).CreateIndexesIfNotExists()
 	sqlStore.recovery.(*SqlPasswordRecoveryStore).CreateIndexesIfNotExists()
 	sqlStore.emoji.(*SqlEmojiStore).CreateIndexesIfNotExists()
+	sqlStore.reaction.(*SqlReactionStore).CreateIndexesIfNotExists()
+	sqlStore.thread.(*SqlThreadStore).CreateIndexesIfNotExists()
+	sqlStore.fileInfo.(*SqlFileInfoStore).CreateIndexesIfNotExists()
+	sqlStore.uploadSession.(*SqlUploadSessionStore).CreateIndexesIfNotExists()
+	sqlStore.exportJob.(*SqlExportJobStore).CreateIndexesIfNotExists()
+	sqlStore.reservation.(*SqlStorageReservationStore).CreateIndexesIfNotExists()
+	sqlStore.taskLease.(*SqlTaskLeaseStore).CreateIndexesIfNotExists()
+	sqlStore.memberHistory.(*SqlChannelMemberHistoryStore).CreateIndexesIfNotExists()
+	sqlStore.accessToken.(*SqlUserAccessTokenStore).CreateIndexesIfNotExists()
 
 	sqlStore.preference.(*SqlPreferenceStore).DeleteUnusedFeatures()
 
@@ -696,6 +732,42 @@ func (ss SqlStore) Emoji() EmojiStore {
 	return ss.emoji
 }
 
+func (ss SqlStore) Reaction() ReactionStore {
+	return ss.reaction
+}
+
+func (ss SqlStore) Thread() ThreadStore {
+	return ss.thread
+}
+
+func (ss SqlStore) FileInfo() FileInfoStore {
+	return ss.fileInfo
+}
+
+func (ss SqlStore) UploadSession() UploadSessionStore {
+	return ss.uploadSession
+}
+
+func (ss SqlStore) ExportJob() ExportJobStore {
+	return ss.exportJob
+}
+
+func (ss SqlStore) StorageReservation() StorageReservationStore {
+	return ss.reservation
+}
+
+func (ss SqlStore) TaskLease() TaskLeaseStore {
+	return ss.taskLease
+}
+
+func (ss SqlStore) ChannelMemberHistory() ChannelMemberHistoryStore {
+	return ss.memberHistory
+}
+
+func (ss SqlStore) UserAccessToken() UserAccessTokenStore {
+	return ss.accessToken
+}
+
 func (ss SqlStore) DropAllTables() {
 	ss.master.TruncateTables()
 }
@@ -713,6 +785,8 @@ func (me mattermConverter) ToDb(val interface{}) (interface{}, error) {
 		return encrypt([]byte(utils.Cfg.SqlSettings.AtRestEncryptKey), model.MapToJson(t))
 	case model.StringInterface:
 		return model.StringInterfaceToJson(t), nil
+	case model.ExportOptions:
+		return t.ToJson(), nil
 	}
 
 	return val, nil
@@ -766,6 +840,16 @@ func (me mattermConverter) FromDb(target interface{}) (gorp.CustomScanner, bool)
 			return json.Unmarshal(b, target)
 		}
 		return gorp.CustomScanner{new(string), target, binder}, true
+	case *model.ExportOptions:
+		binder := func(holder, target interface{}) error {
+			s, ok := holder.(*string)
+			if !ok {
+				return errors.New(utils.T("store.sql.convert_export_options"))
+			}
+			b := []byte(*s)
+			return json.Unmarshal(b, target)
+		}
+		return gorp.CustomScanner{new(string), target, binder}, true
 	}
 
 	return gorp.CustomScanner{}, false
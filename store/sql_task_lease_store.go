@@ -0,0 +1,118 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlTaskLeaseStore struct {
+	*SqlStore
+}
+
+func NewSqlTaskLeaseStore(sqlStore *SqlStore) TaskLeaseStore {
+	s := &SqlTaskLeaseStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.TaskLease{}, "TaskLeases").SetKeys(false, "Name")
+		table.ColMap("Name").SetMaxSize(64)
+		table.ColMap("WorkerId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlTaskLeaseStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlTaskLeaseStore) CreateIndexesIfNotExists() {
+}
+
+// Claim marks a task as being run by the given worker as long as nobody is running it and it hasn't run since
+// ranBefore, or the worker that was running it hasn't sent a heartbeat since staleBefore. The result is true if the
+// worker now holds the lease.
+func (s SqlTaskLeaseStore) Claim(name string, workerId string, staleBefore int64, ranBefore int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		now := model.GetMillis()
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				TaskLeases
+			SET
+				WorkerId = :WorkerId,
+				HeartbeatAt = :Now,
+				LastRunAt = :Now
+			WHERE
+				Name = :Name
+				AND ((WorkerId = '' AND LastRunAt < :RanBefore)
+					OR (WorkerId != '' AND HeartbeatAt < :StaleBefore))`,
+			map[string]interface{}{
+				"Name":        name,
+				"WorkerId":    workerId,
+				"Now":         now,
+				"StaleBefore": staleBefore,
+				"RanBefore":   ranBefore,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlTaskLeaseStore.Claim", "store.sql_task_lease.claim.app_error", nil, "name="+name+", "+err.Error())
+		} else if count, _ := sqlResult.RowsAffected(); count == 1 {
+			result.Data = true
+		} else if exists, err := s.GetMaster().SelectInt("SELECT COUNT(*) FROM TaskLeases WHERE Name = :Name", map[string]interface{}{"Name": name}); err != nil {
+			result.Err = model.NewLocAppError("SqlTaskLeaseStore.Claim", "store.sql_task_lease.claim.app_error", nil, "name="+name+", "+err.Error())
+		} else if exists > 0 {
+			result.Data = false
+		} else if err := s.GetMaster().Insert(&model.TaskLease{Name: name, WorkerId: workerId, HeartbeatAt: now, LastRunAt: now}); err != nil {
+			// another server inserted the lease first
+			result.Data = false
+		} else {
+			result.Data = true
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Heartbeat lets the other servers know that the worker holding a lease is still running the task
+func (s SqlTaskLeaseStore) Heartbeat(name string, workerId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE TaskLeases SET HeartbeatAt = :HeartbeatAt WHERE Name = :Name AND WorkerId = :WorkerId",
+			map[string]interface{}{"Name": name, "WorkerId": workerId, "HeartbeatAt": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlTaskLeaseStore.Heartbeat", "store.sql_task_lease.heartbeat.app_error", nil, "name="+name+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Release gives up a lease once the task is done so that it can be claimed again the next time the task is due
+func (s SqlTaskLeaseStore) Release(name string, workerId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE TaskLeases SET WorkerId = '' WHERE Name = :Name AND WorkerId = :WorkerId",
+			map[string]interface{}{"Name": name, "WorkerId": workerId}); err != nil {
+			result.Err = model.NewLocAppError("SqlTaskLeaseStore.Release", "store.sql_task_lease.release.app_error", nil, "name="+name+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
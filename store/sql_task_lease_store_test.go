@@ -0,0 +1,48 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestTaskLeaseStore(t *testing.T) {
+	Setup()
+
+	name := "test" + model.NewId()
+	worker1 := model.NewId()
+	worker2 := model.NewId()
+
+	if !Must(store.TaskLease().Claim(name, worker1, 0, model.GetMillis()+1)).(bool) {
+		t.Fatal("should've claimed a new lease")
+	}
+
+	if Must(store.TaskLease().Claim(name, worker2, 0, model.GetMillis()+1)).(bool) {
+		t.Fatal("shouldn't have claimed a lease that's held by another worker")
+	}
+
+	Must(store.TaskLease().Heartbeat(name, worker1))
+
+	if !Must(store.TaskLease().Claim(name, worker2, model.GetMillis()+1, 0)).(bool) {
+		t.Fatal("should've taken over a lease with a stale heartbeat")
+	}
+
+	Must(store.TaskLease().Release(name, worker1))
+
+	if Must(store.TaskLease().Claim(name, worker1, 0, model.GetMillis()+1)).(bool) {
+		t.Fatal("shouldn't have been able to release a lease held by another worker")
+	}
+
+	Must(store.TaskLease().Release(name, worker2))
+
+	if Must(store.TaskLease().Claim(name, worker1, 0, 0)).(bool) {
+		t.Fatal("shouldn't have claimed a lease for a task that's run since")
+	}
+
+	if !Must(store.TaskLease().Claim(name, worker1, 0, model.GetMillis()+1)).(bool) {
+		t.Fatal("should've claimed a released lease")
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlThreadStore struct {
+	*SqlStore
+}
+
+func NewSqlThreadStore(sqlStore *SqlStore) ThreadStore {
+	s := &SqlThreadStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ThreadMember{}, "ThreadMembers").SetKeys(false, "PostId", "UserId")
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlThreadStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlThreadStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_threadmembers_user_id", "ThreadMembers", "UserId")
+}
+
+// SaveMember creates the thread member or replaces the existing one for the same post and user
+func (s SqlThreadStore) SaveMember(member *model.ThreadMember) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		member.PreSave()
+		if result.Err = member.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().SelectInt(
+			"SELECT COUNT(*) FROM ThreadMembers WHERE PostId = :PostId AND UserId = :UserId",
+			map[string]interface{}{"PostId": member.PostId, "UserId": member.UserId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.SaveMember", "store.sql_thread.save_member.app_error", nil, "post_id="+member.PostId+", user_id="+member.UserId+", "+err.Error())
+		} else if count > 0 {
+			if _, err := s.GetMaster().Update(member); err != nil {
+				result.Err = model.NewLocAppError("SqlThreadStore.SaveMember", "store.sql_thread.save_member.app_error", nil, "post_id="+member.PostId+", user_id="+member.UserId+", "+err.Error())
+			} else {
+				result.Data = member
+			}
+		} else if err := s.GetMaster().Insert(member); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.SaveMember", "store.sql_thread.save_member.app_error", nil, "post_id="+member.PostId+", user_id="+member.UserId+", "+err.Error())
+		} else {
+			result.Data = member
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) GetMember(postId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var member model.ThreadMember
+		if err := s.GetReplica().SelectOne(&member,
+			"SELECT * FROM ThreadMembers WHERE PostId = :PostId AND UserId = :UserId",
+			map[string]interface{}{"PostId": postId, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.GetMember", "store.sql_thread.get_member.app_error", nil, "post_id="+postId+", user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = &member
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetFollowers returns the ids of the users following the thread
+func (s SqlThreadStore) GetFollowers(postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var userIds []string
+		if _, err := s.GetReplica().Select(&userIds,
+			"SELECT UserId FROM ThreadMembers WHERE PostId = :PostId AND Following = :Following",
+			map[string]interface{}{"PostId": postId, "Following": true}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.GetFollowers", "store.sql_thread.get_followers.app_error", nil, "post_id="+postId+", "+err.Error())
+		} else {
+			result.Data = userIds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetFollowedThreads returns the threads on the team that the user follows and can still see, with the
+// most recently active threads first
+func (s SqlThreadStore) GetFollowedThreads(teamId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var threads []*model.FollowedThread
+		if _, err := s.GetReplica().Select(&threads,
+			`SELECT
+				ThreadMembers.PostId AS PostId,
+				Posts.ChannelId AS ChannelId,
+				ThreadMembers.LastViewedAt AS LastViewedAt,
+				(SELECT COALESCE(MAX(Replies.CreateAt), 0) FROM Posts Replies
+					WHERE Replies.RootId = ThreadMembers.PostId AND Replies.DeleteAt = 0) AS LastReplyAt,
+				(SELECT COUNT(*) FROM Posts Replies
+					WHERE Replies.RootId = ThreadMembers.PostId AND Replies.DeleteAt = 0) AS ReplyCount,
+				(SELECT COUNT(*) FROM Posts Replies
+					WHERE Replies.RootId = ThreadMembers.PostId AND Replies.DeleteAt = 0
+					AND Replies.CreateAt > ThreadMembers.LastViewedAt AND Replies.UserId != ThreadMembers.UserId) AS UnreadReplies
+			FROM
+				ThreadMembers, Posts, Channels, ChannelMembers
+			WHERE
+				ThreadMembers.UserId = :UserId
+				AND ThreadMembers.Following = :Following
+				AND Posts.Id = ThreadMembers.PostId
+				AND Posts.DeleteAt = 0
+				AND Channels.Id = Posts.ChannelId
+				AND (Channels.TeamId = :TeamId OR Channels.TeamId = '')
+				AND Channels.DeleteAt = 0
+				AND ChannelMembers.ChannelId = Channels.Id
+				AND ChannelMembers.UserId = ThreadMembers.UserId
+			ORDER BY LastReplyAt DESC`,
+			map[string]interface{}{"TeamId": teamId, "UserId": userId, "Following": true}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.GetFollowedThreads", "store.sql_thread.get_followed_threads.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = threads
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) UpdateLastViewedAt(postId string, userId string, time int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				ThreadMembers
+			SET
+				LastViewedAt = :LastViewedAt,
+				LastUpdateAt = :LastUpdateAt
+			WHERE
+				PostId = :PostId
+				AND UserId = :UserId`,
+			map[string]interface{}{"PostId": postId, "UserId": userId, "LastViewedAt": time, "LastUpdateAt": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.UpdateLastViewedAt", "store.sql_thread.update_last_viewed_at.app_error", nil, "post_id="+postId+", user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) PermanentDeleteByUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM ThreadMembers WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.PermanentDeleteByUser", "store.sql_thread.permanent_delete_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) PermanentDeleteByPosts(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			keys := make([]string, len(postIds))
+			params := make(map[string]interface{}, len(postIds))
+			for i, postId := range postIds {
+				key := "PostId" + strconv.Itoa(i)
+				keys[i] = ":" + key
+				params[key] = postId
+			}
+
+			if _, err := s.GetMaster().Exec("DELETE FROM ThreadMembers WHERE PostId IN ("+strings.Join(keys, ", ")+")", params); err != nil {
+				result.Err = model.NewLocAppError("SqlThreadStore.PermanentDeleteByPosts", "store.sql_thread.permanent_delete_by_posts.app_error", nil, err.Error())
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
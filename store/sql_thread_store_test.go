@@ -0,0 +1,110 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestThreadStoreSaveMember(t *testing.T) {
+	Setup()
+
+	member := &model.ThreadMember{PostId: model.NewId(), UserId: model.NewId(), Following: true}
+	if result := <-store.Thread().SaveMember(member); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	member.Following = false
+	if result := <-store.Thread().SaveMember(member); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Thread().GetMember(member.PostId, member.UserId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.ThreadMember).Following {
+		t.Fatal("should have updated the existing member")
+	}
+
+	if result := <-store.Thread().SaveMember(&model.ThreadMember{PostId: member.PostId}); result.Err == nil {
+		t.Fatal("should have failed on an invalid member")
+	}
+
+	if result := <-store.Thread().GetMember(member.PostId, model.NewId()); result.Err == nil {
+		t.Fatal("should have failed on a missing member")
+	}
+}
+
+func TestThreadStoreGetFollowers(t *testing.T) {
+	Setup()
+
+	postId := model.NewId()
+	follower := &model.ThreadMember{PostId: postId, UserId: model.NewId(), Following: true}
+	Must(store.Thread().SaveMember(follower))
+	Must(store.Thread().SaveMember(&model.ThreadMember{PostId: postId, UserId: model.NewId(), Following: false}))
+
+	if result := <-store.Thread().GetFollowers(postId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if userIds := result.Data.([]string); len(userIds) != 1 || userIds[0] != follower.UserId {
+		t.Fatal("should only have returned the user following the thread")
+	}
+
+	Must(store.Thread().PermanentDeleteByPosts([]string{postId}))
+
+	if userIds := Must(store.Thread().GetFollowers(postId)).([]string); len(userIds) != 0 {
+		t.Fatal("should have deleted the thread members")
+	}
+}
+
+func TestThreadStoreGetFollowedThreads(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	userId := model.NewId()
+	otherUserId := model.NewId()
+
+	channel := &model.Channel{TeamId: teamId, DisplayName: "Name", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	Must(store.Channel().Save(channel))
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: channel.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	root := Must(store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: userId, Message: "root"})).(*model.Post)
+	reply1 := Must(store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: otherUserId, RootId: root.Id, ParentId: root.Id, Message: "reply", CreateAt: root.CreateAt + 1})).(*model.Post)
+	Must(store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: otherUserId, RootId: root.Id, ParentId: root.Id, Message: "reply", CreateAt: root.CreateAt + 2}))
+	Must(store.Post().Save(&model.Post{ChannelId: channel.Id, UserId: userId, RootId: root.Id, ParentId: root.Id, Message: "own reply", CreateAt: root.CreateAt + 3}))
+
+	Must(store.Thread().SaveMember(&model.ThreadMember{PostId: root.Id, UserId: userId, Following: true, LastViewedAt: reply1.CreateAt}))
+
+	if result := <-store.Thread().GetFollowedThreads(teamId, userId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if threads := result.Data.([]*model.FollowedThread); len(threads) != 1 {
+		t.Fatal("should have returned the thread")
+	} else if thread := threads[0]; thread.PostId != root.Id || thread.ChannelId != channel.Id {
+		t.Fatal("returned the wrong thread")
+	} else if thread.ReplyCount != 3 || thread.UnreadReplies != 1 || thread.LastReplyAt != root.CreateAt+3 {
+		t.Fatal("wrong reply counts", thread.ReplyCount, thread.UnreadReplies, thread.LastReplyAt)
+	}
+
+	Must(store.Thread().UpdateLastViewedAt(root.Id, userId, root.CreateAt+3))
+
+	if threads := Must(store.Thread().GetFollowedThreads(teamId, userId)).([]*model.FollowedThread); threads[0].UnreadReplies != 0 {
+		t.Fatal("should have read all of the replies")
+	}
+
+	if threads := Must(store.Thread().GetFollowedThreads(model.NewId(), userId)).([]*model.FollowedThread); len(threads) != 0 {
+		t.Fatal("shouldn't have returned threads from another team")
+	}
+
+	Must(store.Thread().SaveMember(&model.ThreadMember{PostId: root.Id, UserId: otherUserId, Following: true}))
+
+	if threads := Must(store.Thread().GetFollowedThreads(teamId, otherUserId)).([]*model.FollowedThread); len(threads) != 0 {
+		t.Fatal("shouldn't have returned threads in channels the user isn't a member of")
+	}
+
+	Must(store.Thread().PermanentDeleteByUser(userId))
+
+	if result := <-store.Thread().GetMember(root.Id, userId); result.Err == nil {
+		t.Fatal("should have deleted the user's thread members")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlUploadSessionStore struct {
+	*SqlStore
+}
+
+func NewSqlUploadSessionStore(sqlStore *SqlStore) UploadSessionStore {
+	s := &SqlUploadSessionStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.UploadSession{}, "UploadSessions").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("Filename").SetMaxSize(256)
+	}
+
+	return s
+}
+
+func (s SqlUploadSessionStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlUploadSessionStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_uploadsessions_user_id", "UploadSessions", "UserId")
+	s.CreateIndexIfNotExists("idx_uploadsessions_expire_at", "UploadSessions", "ExpireAt")
+}
+
+func (s SqlUploadSessionStore) Save(session *model.UploadSession) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		session.PreSave()
+		if result.Err = session.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(session); err != nil {
+			result.Err = model.NewLocAppError("SqlUploadSessionStore.Save", "store.sql_upload_session.save.app_error", nil, "id="+session.Id+", "+err.Error())
+		} else {
+			result.Data = session
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlUploadSessionStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		session := &model.UploadSession{}
+
+		if err := s.GetMaster().SelectOne(session, "SELECT * FROM UploadSessions WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlUploadSessionStore.Get", "store.sql_upload_session.get.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			result.Data = session
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateOffset moves the session on to the given offset as long as no other chunk has been uploaded since it was at
+// oldOffset. The returned data is whether or not the session was updated.
+func (s SqlUploadSessionStore) UpdateOffset(id string, oldOffset int64, newOffset int64, expireAt int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				UploadSessions
+			SET
+				FileOffset = :NewOffset,
+				ExpireAt = :ExpireAt
+			WHERE
+				Id = :Id
+				AND FileOffset = :OldOffset`,
+			map[string]interface{}{"NewOffset": newOffset, "ExpireAt": expireAt, "Id": id, "OldOffset": oldOffset}); err != nil {
+			result.Err = model.NewLocAppError("SqlUploadSessionStore.UpdateOffset", "store.sql_upload_session.update_offset.app_error", nil, "id="+id+", "+err.Error())
+		} else if count, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewLocAppError("SqlUploadSessionStore.UpdateOffset", "store.sql_upload_session.update_offset.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			result.Data = count == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlUploadSessionStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM UploadSessions WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlUploadSessionStore.Delete", "store.sql_upload_session.delete.app_error", nil, "id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlUploadSessionStore) GetExpired(time int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var sessions []*model.UploadSession
+
+		if _, err := s.GetReplica().Select(&sessions,
+			`SELECT
+				*
+			FROM
+				UploadSessions
+			WHERE
+				ExpireAt < :Time
+			ORDER BY
+				ExpireAt
+			LIMIT :Limit`, map[string]interface{}{"Time": time, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlUploadSessionStore.GetExpired", "store.sql_upload_session.get_expired.app_error", nil, err.Error())
+		} else {
+			result.Data = sessions
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestUploadSessionStore(t *testing.T) {
+	Setup()
+
+	session := &model.UploadSession{
+		UserId:    model.NewId(),
+		TeamId:    model.NewId(),
+		ChannelId: model.NewId(),
+		Filename:  "file.txt",
+		FileSize:  100,
+	}
+
+	if result := <-store.UploadSession().Save(session); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.UploadSession().Get(session.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if returned := result.Data.(*model.UploadSession); returned.Filename != session.Filename || returned.FileSize != session.FileSize {
+		t.Fatal("should've returned the upload session")
+	}
+
+	if result := <-store.UploadSession().UpdateOffset(session.Id, 0, 50, session.ExpireAt+1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should've updated the offset")
+	}
+
+	if result := <-store.UploadSession().UpdateOffset(session.Id, 0, 60, session.ExpireAt+1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("shouldn't update the offset when it's moved on")
+	}
+
+	if returned := Must(store.UploadSession().Get(session.Id)).(*model.UploadSession); returned.FileOffset != 50 {
+		t.Fatal("should've stored the new offset")
+	}
+
+	if result := <-store.UploadSession().GetExpired(session.ExpireAt+2, 100); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, expired := range result.Data.([]*model.UploadSession) {
+			if expired.Id == session.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should've returned the expired session")
+		}
+	}
+
+	if result := <-store.UploadSession().Delete(session.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.UploadSession().Get(session.Id); result.Err == nil {
+		t.Fatal("should've deleted the upload session")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestUserAccessTokenStore(t *testing.T) {
+	Setup()
+
+	o1 := &model.UserAccessToken{UserId: model.NewId(), Description: "test"}
+	o1 = Must(store.UserAccessToken().Save(o1)).(*model.UserAccessToken)
+
+	if o1.Token == "" {
+		t.Fatal("should've returned the token")
+	}
+
+	if result := <-store.UserAccessToken().GetByToken(model.HashUserAccessToken(o1.Token)); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if token := result.Data.(*model.UserAccessToken); token.Id != o1.Id || token.Token != "" {
+		t.Fatal("should've found the token without returning it")
+	}
+
+	if result := <-store.UserAccessToken().GetByToken(o1.Token); result.Err == nil {
+		t.Fatal("shouldn't have stored the token itself")
+	}
+
+	Must(store.UserAccessToken().UpdateLastUsedAt(o1.Id, 1234))
+	if token := Must(store.UserAccessToken().Get(o1.Id)).(*model.UserAccessToken); token.LastUsedAt != 1234 {
+		t.Fatal("should've updated the last use")
+	}
+
+	o2 := Must(store.UserAccessToken().Save(&model.UserAccessToken{UserId: o1.UserId, Description: "test2"})).(*model.UserAccessToken)
+	if tokens := Must(store.UserAccessToken().GetByUser(o1.UserId)).([]*model.UserAccessToken); len(tokens) != 2 {
+		t.Fatal("should've returned both tokens")
+	}
+
+	Must(store.UserAccessToken().Delete(o2.Id))
+	if result := <-store.UserAccessToken().Get(o2.Id); result.Err == nil {
+		t.Fatal("should've deleted the token")
+	}
+
+	Must(store.UserAccessToken().PermanentDeleteByUser(o1.UserId))
+	if tokens := Must(store.UserAccessToken().GetByUser(o1.UserId)).([]*model.UserAccessToken); len(tokens) != 0 {
+		t.Fatal("should've deleted the user's tokens")
+	}
+}
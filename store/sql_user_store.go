@@ -42,6 +42,7 @@ func NewSqlUserStore(sqlStore *SqlStore) UserStore {
 		table.ColMap("ThemeProps").SetMaxSize(2000)
 		table.ColMap("Locale").SetMaxSize(5)
 		table.ColMap("MfaSecret").SetMaxSize(128)
+		table.ColMap("BotOwnerId").SetMaxSize(26)
 	}
 
 	return us
@@ -50,6 +51,9 @@ func NewSqlUserStore(sqlStore *SqlStore) UserStore {
 func (us SqlUserStore) UpgradeSchemaIfNeeded() {
 	// ADDED for 2.0 REMOVE for 2.4
 	us.CreateColumnIfNotExists("Users", "Locale", "varchar(5)", "character varying(5)", model.DEFAULT_LOCALE)
+
+	us.CreateColumnIfNotExists("Users", "IsBot", "boolean", "boolean", "0")
+	us.CreateColumnIfNotExists("Users", "BotOwnerId", "varchar(26)", "varchar(26)", "")
 }
 
 func (us SqlUserStore) CreateIndexesIfNotExists() {
@@ -129,13 +133,15 @@ func (us SqlUserStore) Update(user *model.User, trustedUpdateData bool) StoreCha
 			user.FailedAttempts = oldUser.FailedAttempts
 			user.MfaSecret = oldUser.MfaSecret
 			user.MfaActive = oldUser.MfaActive
+			user.IsBot = oldUser.IsBot
+			user.BotOwnerId = oldUser.BotOwnerId
 
 			if !trustedUpdateData {
 				user.Roles = oldUser.Roles
 				user.DeleteAt = oldUser.DeleteAt
 			}
 
-			if user.IsOAuthUser() {
+			if user.IsOAuthUser() || user.IsSAMLUser() || user.IsBot {
 				user.Email = oldUser.Email
 			} else if user.IsLDAPUser() && !trustedUpdateData {
 				if user.Username != oldUser.Username ||
@@ -741,6 +747,40 @@ func (us SqlUserStore) GetSystemAdminProfiles() StoreChannel {
 	return storeChannel
 }
 
+// GetBots returns the bots owned by a user, or every bot if ownerId is empty
+func (us SqlUserStore) GetBots(ownerId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		query := "SELECT * FROM Users WHERE IsBot = :IsBot"
+		if ownerId != "" {
+			query += " AND BotOwnerId = :BotOwnerId"
+		}
+		query += " ORDER BY Username ASC"
+
+		var users []*model.User
+
+		if _, err := us.GetReplica().Select(&users, query, map[string]interface{}{"IsBot": true, "BotOwnerId": ownerId}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.GetBots", "store.sql_user.get_bots.app_error", nil, "owner_id="+ownerId+", "+err.Error())
+		} else {
+			for _, u := range users {
+				u.Password = ""
+				u.AuthData = new(string)
+				*u.AuthData = ""
+			}
+
+			result.Data = users
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (us SqlUserStore) GetByEmail(email string) StoreChannel {
 
 	storeChannel := make(StoreChannel)
@@ -957,7 +997,7 @@ func (us SqlUserStore) GetTotalActiveUsersCount() StoreChannel {
 
 		time := model.GetMillis() - (1000 * 60 * 60 * 24)
 
-		if count, err := us.GetReplica().SelectInt("SELECT COUNT(Id) FROM Users WHERE LastActivityAt > :Time", map[string]interface{}{"Time": time}); err != nil {
+		if count, err := us.GetReplica().SelectInt("SELECT COUNT(Id) FROM Users WHERE LastActivityAt > :Time AND IsBot = :IsBot", map[string]interface{}{"Time": time, "IsBot": false}); err != nil {
 			result.Err = model.NewLocAppError("SqlUserStore.GetTotalActiveUsersCount", "store.sql_user.get_total_active_users_count.app_error", nil, err.Error())
 		} else {
 			result.Data = count
@@ -995,13 +1035,16 @@ func (us SqlUserStore) AnalyticsUniqueUserCount(teamId string) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
+		// bots don't take up a seat so they aren't counted
 		query := "SELECT COUNT(DISTINCT Email) FROM Users"
 
 		if len(teamId) > 0 {
-			query += ", TeamMembers WHERE TeamMembers.TeamId = :TeamId AND Users.Id = TeamMembers.UserId"
+			query += ", TeamMembers WHERE TeamMembers.TeamId = :TeamId AND Users.Id = TeamMembers.UserId AND Users.IsBot = :IsBot"
+		} else {
+			query += " WHERE IsBot = :IsBot"
 		}
 
-		v, err := us.GetReplica().SelectInt(query, map[string]interface{}{"TeamId": teamId})
+		v, err := us.GetReplica().SelectInt(query, map[string]interface{}{"TeamId": teamId, "IsBot": false})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlUserStore.AnalyticsUniqueUserCount", "store.sql_user.analytics_unique_user_count.app_error", nil, err.Error())
 		} else {
@@ -458,6 +458,46 @@ func TestUserStoreGetSystemAdminProfiles(t *testing.T) {
 	}
 }
 
+func TestUserStoreGetBots(t *testing.T) {
+	Setup()
+
+	owner := &model.User{}
+	owner.Email = model.NewId()
+	Must(store.User().Save(owner))
+
+	var count int64
+	if r1 := <-store.User().AnalyticsUniqueUserCount(""); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else {
+		count = r1.Data.(int64)
+	}
+
+	bot := &model.User{IsBot: true, BotOwnerId: owner.Id}
+	Must(store.User().Save(bot))
+
+	if r1 := <-store.User().GetBots(owner.Id); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if bots := r1.Data.([]*model.User); len(bots) != 1 || bots[0].Id != bot.Id {
+		t.Fatal("should've returned the owner's bot")
+	}
+
+	if r1 := <-store.User().GetBots(""); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else {
+		for _, u := range r1.Data.([]*model.User) {
+			if !u.IsBot {
+				t.Fatal("should only return bots")
+			}
+		}
+	}
+
+	if r1 := <-store.User().AnalyticsUniqueUserCount(""); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if r1.Data.(int64) != count {
+		t.Fatal("bots shouldn't be counted as users")
+	}
+}
+
 func TestUserStoreGetByEmail(t *testing.T) {
 	Setup()
 
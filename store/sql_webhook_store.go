@@ -18,6 +18,7 @@ func NewSqlWebhookStore(sqlStore *SqlStore) WebhookStore {
 		table := db.AddTableWithName(model.IncomingWebhook{}, "IncomingWebhooks").SetKeys(false, "Id")
 		table.ColMap("Id").SetMaxSize(26)
 		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("BotUserId").SetMaxSize(26)
 		table.ColMap("ChannelId").SetMaxSize(26)
 		table.ColMap("TeamId").SetMaxSize(26)
 		table.ColMap("DisplayName").SetMaxSize(64)
@@ -27,6 +28,7 @@ func NewSqlWebhookStore(sqlStore *SqlStore) WebhookStore {
 		tableo.ColMap("Id").SetMaxSize(26)
 		tableo.ColMap("Token").SetMaxSize(26)
 		tableo.ColMap("CreatorId").SetMaxSize(26)
+		tableo.ColMap("BotUserId").SetMaxSize(26)
 		tableo.ColMap("ChannelId").SetMaxSize(26)
 		tableo.ColMap("TeamId").SetMaxSize(26)
 		tableo.ColMap("TriggerWords").SetMaxSize(1024)
@@ -34,6 +36,17 @@ func NewSqlWebhookStore(sqlStore *SqlStore) WebhookStore {
 		tableo.ColMap("DisplayName").SetMaxSize(64)
 		tableo.ColMap("Description").SetMaxSize(128)
 		tableo.ColMap("ContentType").SetMaxSize(128)
+
+		tabled := db.AddTableWithName(model.OutgoingWebhookDelivery{}, "OutgoingWebhookDeliveries").SetKeys(false, "Id")
+		tabled.ColMap("Id").SetMaxSize(26)
+		tabled.ColMap("HookId").SetMaxSize(26)
+		tabled.ColMap("TeamId").SetMaxSize(26)
+		tabled.ColMap("PostId").SetMaxSize(26)
+		tabled.ColMap("Url").SetMaxSize(1024)
+		tabled.ColMap("ContentType").SetMaxSize(128)
+		tabled.ColMap("Payload").SetMaxSize(model.OUTGOING_WEBHOOK_DELIVERY_PAYLOAD_MAX_SIZE)
+		tabled.ColMap("Status").SetMaxSize(32)
+		tabled.ColMap("Error").SetMaxSize(1024)
 	}
 
 	return s
@@ -46,12 +59,20 @@ func (s SqlWebhookStore) UpgradeSchemaIfNeeded() {
 	s.CreateColumnIfNotExists("OutgoingWebhooks", "DisplayName", "varchar(64)", "varchar(64)", "")
 	s.CreateColumnIfNotExists("OutgoingWebhooks", "Description", "varchar(128)", "varchar(128)", "")
 	s.CreateColumnIfNotExists("OutgoingWebhooks", "ContentType", "varchar(128)", "varchar(128)", "")
+
+	s.CreateColumnIfNotExists("IncomingWebhooks", "BotUserId", "varchar(26)", "varchar(26)", "")
+	s.CreateColumnIfNotExists("OutgoingWebhooks", "BotUserId", "varchar(26)", "varchar(26)", "")
+
+	s.AlterColumnTypeIfExists("OutgoingWebhookDeliveries", "Payload", "text", "varchar(65535)")
 }
 
 func (s SqlWebhookStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_incoming_webhook_user_id", "IncomingWebhooks", "UserId")
 	s.CreateIndexIfNotExists("idx_incoming_webhook_team_id", "IncomingWebhooks", "TeamId")
 	s.CreateIndexIfNotExists("idx_outgoing_webhook_team_id", "OutgoingWebhooks", "TeamId")
+
+	s.CreateIndexIfNotExists("idx_outgoing_webhook_deliveries_hook_id", "OutgoingWebhookDeliveries", "HookId")
+	s.CreateIndexIfNotExists("idx_outgoing_webhook_deliveries_next_attempt_at", "OutgoingWebhookDeliveries", "NextAttemptAt")
 }
 
 func (s SqlWebhookStore) SaveIncoming(webhook *model.IncomingWebhook) StoreChannel {
@@ -402,3 +423,190 @@ func (s SqlWebhookStore) AnalyticsOutgoingCount(teamId string) StoreChannel {
 
 	return storeChannel
 }
+
+func (s SqlWebhookStore) SaveOutgoingDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(delivery.Id) > 0 {
+			result.Err = model.NewLocAppError("SqlWebhookStore.SaveOutgoingDelivery",
+				"store.sql_webhooks.save_outgoing_delivery.existing.app_error", nil, "id="+delivery.Id)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		delivery.PreSave()
+		if result.Err = delivery.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(delivery); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.SaveOutgoingDelivery", "store.sql_webhooks.save_outgoing_delivery.app_error", nil, "id="+delivery.Id+", "+err.Error())
+		} else {
+			result.Data = delivery
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) UpdateOutgoingDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		delivery.PreUpdate()
+		if result.Err = delivery.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(delivery); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.UpdateOutgoingDelivery", "store.sql_webhooks.update_outgoing_delivery.app_error", nil, "id="+delivery.Id+", "+err.Error())
+		} else {
+			result.Data = delivery
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// ClaimOutgoingDelivery marks a pending delivery as sending so that only one server attempts it. A delivery
+// left sending since before staleTime, because the server sending it went away, can be claimed again.
+func (s SqlWebhookStore) ClaimOutgoingDelivery(deliveryId string, staleTime int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				OutgoingWebhookDeliveries
+			SET
+				Status = :Sending, UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id
+				AND (Status = :Pending OR (Status = :Sending AND UpdateAt < :StaleTime))`,
+			map[string]interface{}{
+				"Id":        deliveryId,
+				"Sending":   model.OUTGOING_WEBHOOK_DELIVERY_SENDING,
+				"Pending":   model.OUTGOING_WEBHOOK_DELIVERY_PENDING,
+				"UpdateAt":  model.GetMillis(),
+				"StaleTime": staleTime,
+			})
+
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.ClaimOutgoingDelivery", "store.sql_webhooks.claim_outgoing_delivery.app_error", nil, "id="+deliveryId+", err="+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			result.Data = false
+		} else {
+			result.Data = true
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) GetOutgoingDelivery(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var delivery model.OutgoingWebhookDelivery
+
+		if err := s.GetReplica().SelectOne(&delivery, "SELECT * FROM OutgoingWebhookDeliveries WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.GetOutgoingDelivery", "store.sql_webhooks.get_outgoing_delivery.app_error", nil, "id="+id+", err="+err.Error())
+		}
+
+		result.Data = &delivery
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) GetOutgoingDeliveriesByHook(hookId string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var deliveries []*model.OutgoingWebhookDelivery
+
+		if _, err := s.GetReplica().Select(&deliveries,
+			`SELECT
+				*
+			FROM
+				OutgoingWebhookDeliveries
+			WHERE
+				HookId = :HookId
+			ORDER BY CreateAt DESC
+			LIMIT :Limit OFFSET :Offset`,
+			map[string]interface{}{"HookId": hookId, "Limit": limit, "Offset": offset}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.GetOutgoingDeliveriesByHook", "store.sql_webhooks.get_outgoing_deliveries_by_hook.app_error", nil, "hookId="+hookId+", err="+err.Error())
+		}
+
+		result.Data = deliveries
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) GetPendingOutgoingDeliveries(time int64, staleTime int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var deliveries []*model.OutgoingWebhookDelivery
+
+		if _, err := s.GetReplica().Select(&deliveries,
+			`SELECT
+				*
+			FROM
+				OutgoingWebhookDeliveries
+			WHERE
+				(Status = :Pending AND NextAttemptAt <= :Time)
+				OR (Status = :Sending AND UpdateAt < :StaleTime)
+			ORDER BY NextAttemptAt ASC
+			LIMIT :Limit`,
+			map[string]interface{}{
+				"Pending":   model.OUTGOING_WEBHOOK_DELIVERY_PENDING,
+				"Sending":   model.OUTGOING_WEBHOOK_DELIVERY_SENDING,
+				"Time":      time,
+				"StaleTime": staleTime,
+				"Limit":     limit,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.GetPendingOutgoingDeliveries", "store.sql_webhooks.get_pending_outgoing_deliveries.app_error", nil, "err="+err.Error())
+		}
+
+		result.Data = deliveries
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
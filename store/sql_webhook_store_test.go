@@ -343,3 +343,103 @@ func TestWebhookStoreCountOutgoing(t *testing.T) {
 		}
 	}
 }
+
+func TestWebhookStoreSaveOutgoingDelivery(t *testing.T) {
+	Setup()
+
+	d1 := model.OutgoingWebhookDelivery{}
+	d1.HookId = model.NewId()
+	d1.TeamId = model.NewId()
+	d1.PostId = model.NewId()
+	d1.Url = "http://nowhere.com/"
+
+	if err := (<-store.Webhook().SaveOutgoingDelivery(&d1)).Err; err != nil {
+		t.Fatal("couldn't save item", err)
+	}
+
+	if err := (<-store.Webhook().SaveOutgoingDelivery(&d1)).Err; err == nil {
+		t.Fatal("shouldn't be able to update from save")
+	}
+
+	if r1 := <-store.Webhook().GetOutgoingDelivery(d1.Id); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if r1.Data.(*model.OutgoingWebhookDelivery).Status != model.OUTGOING_WEBHOOK_DELIVERY_PENDING {
+		t.Fatal("new delivery should be pending")
+	}
+
+	d1.Status = model.OUTGOING_WEBHOOK_DELIVERY_FAILED
+	d1.StatusCode = 500
+	if err := (<-store.Webhook().UpdateOutgoingDelivery(&d1)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if r1 := <-store.Webhook().GetOutgoingDelivery(d1.Id); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if r1.Data.(*model.OutgoingWebhookDelivery).StatusCode != 500 {
+		t.Fatal("delivery should have been updated")
+	}
+}
+
+func TestWebhookStoreGetOutgoingDeliveriesByHook(t *testing.T) {
+	Setup()
+
+	hookId := model.NewId()
+
+	d1 := &model.OutgoingWebhookDelivery{HookId: hookId, TeamId: model.NewId(), PostId: model.NewId(), Url: "http://nowhere.com/"}
+	d1 = (<-store.Webhook().SaveOutgoingDelivery(d1)).Data.(*model.OutgoingWebhookDelivery)
+
+	d2 := d1.Retry()
+	d2 = (<-store.Webhook().SaveOutgoingDelivery(d2)).Data.(*model.OutgoingWebhookDelivery)
+
+	if r1 := <-store.Webhook().GetOutgoingDeliveriesByHook(hookId, 0, 10); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if len(r1.Data.([]*model.OutgoingWebhookDelivery)) != 2 {
+		t.Fatal("should have returned both deliveries")
+	}
+
+	if r1 := <-store.Webhook().GetOutgoingDeliveriesByHook(hookId, 0, 1); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if len(r1.Data.([]*model.OutgoingWebhookDelivery)) != 1 {
+		t.Fatal("should have respected the limit")
+	}
+}
+
+func TestWebhookStoreClaimOutgoingDelivery(t *testing.T) {
+	Setup()
+
+	d1 := &model.OutgoingWebhookDelivery{HookId: model.NewId(), TeamId: model.NewId(), PostId: model.NewId(), Url: "http://nowhere.com/"}
+	d1 = (<-store.Webhook().SaveOutgoingDelivery(d1)).Data.(*model.OutgoingWebhookDelivery)
+
+	found := false
+	if r1 := <-store.Webhook().GetPendingOutgoingDeliveries(model.GetMillis(), 0, 1000); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else {
+		for _, d := range r1.Data.([]*model.OutgoingWebhookDelivery) {
+			if d.Id == d1.Id {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		t.Fatal("delivery should be pending")
+	}
+
+	if r1 := <-store.Webhook().ClaimOutgoingDelivery(d1.Id, 0); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if !r1.Data.(bool) {
+		t.Fatal("should have claimed the delivery")
+	}
+
+	if r1 := <-store.Webhook().ClaimOutgoingDelivery(d1.Id, 0); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if r1.Data.(bool) {
+		t.Fatal("shouldn't be able to claim a delivery twice")
+	}
+
+	if r1 := <-store.Webhook().ClaimOutgoingDelivery(d1.Id, model.GetMillis()+1000); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if !r1.Data.(bool) {
+		t.Fatal("should be able to claim a stale delivery")
+	}
+}
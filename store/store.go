@@ -43,6 +43,15 @@ type Store interface {
 	License() LicenseStore
 	PasswordRecovery() PasswordRecoveryStore
 	Emoji() EmojiStore
+	Reaction() ReactionStore
+	Thread() ThreadStore
+	FileInfo() FileInfoStore
+	UploadSession() UploadSessionStore
+	ExportJob() ExportJobStore
+	StorageReservation() StorageReservationStore
+	TaskLease() TaskLeaseStore
+	ChannelMemberHistory() ChannelMemberHistoryStore
+	UserAccessToken() UserAccessTokenStore
 	MarkSystemRanUnitTests()
 	Close()
 	DropAllTables()
@@ -83,6 +92,7 @@ type ChannelStore interface {
 	GetMoreChannels(teamId string, userId string) StoreChannel
 	GetChannelCounts(teamId string, userId string) StoreChannel
 	GetForExport(teamId string) StoreChannel
+	GetAll() StoreChannel
 
 	SaveMember(member *model.ChannelMember) StoreChannel
 	UpdateMember(member *model.ChannelMember) StoreChannel
@@ -105,6 +115,7 @@ type ChannelStore interface {
 type PostStore interface {
 	Save(post *model.Post) StoreChannel
 	Update(post *model.Post, newMessage string, newHashtags string) StoreChannel
+	UpdatePinned(postId string, isPinned bool) StoreChannel
 	Get(id string) StoreChannel
 	Delete(postId string, time int64) StoreChannel
 	PermanentDeleteByUser(userId string) StoreChannel
@@ -113,8 +124,15 @@ type PostStore interface {
 	GetPostsAfter(channelId string, postId string, numPosts int, offset int) StoreChannel
 	GetPostsSince(channelId string, time int64) StoreChannel
 	GetEtag(channelId string) StoreChannel
-	Search(teamId string, userId string, params *model.SearchParams) StoreChannel
-	GetForExport(channelId string) StoreChannel
+	Search(teamId string, userId string, params *model.SearchParams, page, perPage int) StoreChannel
+	GetForExport(channelId string, since int64, afterId string, until int64, limit int) StoreChannel
+	GetPostThread(rootId string, offset int, limit int) StoreChannel
+	GetPinnedPosts(channelId string) StoreChannel
+	GetFlaggedPosts(userId string, offset int, limit int) StoreChannel
+	GetPostsByIds(postIds []string) StoreChannel
+	GetPostsBatchForIndexing(startTime int64, startId string, limit int) StoreChannel
+	GetPostsBatchForRetention(channelId string, endTime int64, offset int, limit int) StoreChannel
+	PermanentDeleteByIds(postIds []string) StoreChannel
 	AnalyticsUserCountsWithPostsByDay(teamId string) StoreChannel
 	AnalyticsPostCountsByDay(teamId string) StoreChannel
 	AnalyticsPostCount(teamId string, mustHaveFile bool, mustHaveHashtag bool) StoreChannel
@@ -152,6 +170,7 @@ type UserStore interface {
 	GetTotalUsersCount() StoreChannel
 	GetTotalActiveUsersCount() StoreChannel
 	GetSystemAdminProfiles() StoreChannel
+	GetBots(ownerId string) StoreChannel
 	PermanentDelete(userId string) StoreChannel
 	AnalyticsUniqueUserCount(teamId string) StoreChannel
 	GetUnreadCount(userId string) StoreChannel
@@ -174,6 +193,8 @@ type AuditStore interface {
 	Save(audit *model.Audit) StoreChannel
 	Get(user_id string, limit int) StoreChannel
 	PermanentDeleteByUser(userId string) StoreChannel
+	PermanentDeleteBatch(endTime int64, limit int) StoreChannel
+	GetCountBefore(endTime int64) StoreChannel
 }
 
 type ComplianceStore interface {
@@ -182,6 +203,7 @@ type ComplianceStore interface {
 	Get(id string) StoreChannel
 	GetAll() StoreChannel
 	ComplianceExport(compliance *model.Compliance) StoreChannel
+	MessageExport(since int64, until int64, afterChannelId string, afterId string, limit int) StoreChannel
 }
 
 type OAuthStore interface {
@@ -189,6 +211,10 @@ type OAuthStore interface {
 	UpdateApp(app *model.OAuthApp) StoreChannel
 	GetApp(id string) StoreChannel
 	GetAppByUser(userId string) StoreChannel
+	GetApps() StoreChannel
+	GetAuthorizedApps(userId string) StoreChannel
+	UpdateAppSecret(id string, secret string) StoreChannel
+	DeleteApp(id string) StoreChannel
 	SaveAuthData(authData *model.AuthData) StoreChannel
 	GetAuthData(code string) StoreChannel
 	RemoveAuthData(code string) StoreChannel
@@ -196,7 +222,10 @@ type OAuthStore interface {
 	SaveAccessData(accessData *model.AccessData) StoreChannel
 	GetAccessData(token string) StoreChannel
 	GetAccessDataByAuthCode(authCode string) StoreChannel
+	GetAccessDataByRefreshToken(refreshToken string) StoreChannel
+	GetAccessDataForApp(clientId string, userId string) StoreChannel
 	RemoveAccessData(token string) StoreChannel
+	RemoveAccessDataByRefreshToken(token string, refreshToken string) StoreChannel
 }
 
 type SystemStore interface {
@@ -221,6 +250,12 @@ type WebhookStore interface {
 	DeleteOutgoing(webhookId string, time int64) StoreChannel
 	PermanentDeleteOutgoingByUser(userId string) StoreChannel
 	UpdateOutgoing(hook *model.OutgoingWebhook) StoreChannel
+	SaveOutgoingDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel
+	UpdateOutgoingDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel
+	ClaimOutgoingDelivery(deliveryId string, staleTime int64) StoreChannel
+	GetOutgoingDelivery(id string) StoreChannel
+	GetOutgoingDeliveriesByHook(hookId string, offset int, limit int) StoreChannel
+	GetPendingOutgoingDeliveries(time int64, staleTime int64, limit int) StoreChannel
 	AnalyticsIncomingCount(teamId string) StoreChannel
 	AnalyticsOutgoingCount(teamId string) StoreChannel
 }
@@ -263,3 +298,85 @@ type EmojiStore interface {
 	GetAll() StoreChannel
 	Delete(id string, time int64) StoreChannel
 }
+
+type ReactionStore interface {
+	Save(reaction *model.Reaction) StoreChannel
+	Delete(reaction *model.Reaction) StoreChannel
+	GetForPost(postId string) StoreChannel
+	GetForExport(channelId string, since int64, until int64) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+	PermanentDeleteByPosts(postIds []string) StoreChannel
+}
+
+type ThreadStore interface {
+	SaveMember(member *model.ThreadMember) StoreChannel
+	GetMember(postId string, userId string) StoreChannel
+	GetFollowers(postId string) StoreChannel
+	GetFollowedThreads(teamId string, userId string) StoreChannel
+	UpdateLastViewedAt(postId string, userId string, time int64) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+	PermanentDeleteByPosts(postIds []string) StoreChannel
+}
+
+type FileInfoStore interface {
+	Save(info *model.FileInfo) StoreChannel
+	Update(info *model.FileInfo) StoreChannel
+	Get(id string) StoreChannel
+	GetByPath(path string) StoreChannel
+	GetForPost(postId string) StoreChannel
+	AttachToPost(fileId string, postId string) StoreChannel
+	UpdateContent(fileId string, content string) StoreChannel
+	DeleteForPost(postId string) StoreChannel
+	PermanentDeleteByPosts(postIds []string) StoreChannel
+	GetAllForPosts(postIds []string) StoreChannel
+	PermanentDeleteOrphan(fileId string) StoreChannel
+	GetStorageUsedByUser(userId string) StoreChannel
+	AnalyticsStorageUsed(teamId string) StoreChannel
+	AnalyticsFileCount(teamId string) StoreChannel
+	MigrateFilenamesToFileInfos(sizeOf func(info *model.FileInfo) int64) StoreChannel
+}
+
+type UploadSessionStore interface {
+	Save(session *model.UploadSession) StoreChannel
+	Get(id string) StoreChannel
+	UpdateOffset(id string, oldOffset int64, newOffset int64, expireAt int64) StoreChannel
+	Delete(id string) StoreChannel
+	GetExpired(time int64, limit int) StoreChannel
+}
+
+type ChannelMemberHistoryStore interface {
+	GetEventsDuring(since int64, until int64) StoreChannel
+}
+
+type ExportJobStore interface {
+	Save(job *model.ExportJob) StoreChannel
+	Update(job *model.ExportJob) StoreChannel
+	Get(id string) StoreChannel
+	GetUnfinished() StoreChannel
+	Claim(job *model.ExportJob, workerId string, staleBefore int64) StoreChannel
+	Heartbeat(jobId string, workerId string) StoreChannel
+	GetLastSuccessful(teamId string) StoreChannel
+}
+
+type StorageReservationStore interface {
+	Save(reservation *model.StorageReservation) StoreChannel
+	Delete(id string) StoreChannel
+	GetReserved(userId string, teamId string, since int64) StoreChannel
+	PermanentDeleteBefore(before int64) StoreChannel
+}
+
+type TaskLeaseStore interface {
+	Claim(name string, workerId string, staleBefore int64, ranBefore int64) StoreChannel
+	Heartbeat(name string, workerId string) StoreChannel
+	Release(name string, workerId string) StoreChannel
+}
+
+type UserAccessTokenStore interface {
+	Save(token *model.UserAccessToken) StoreChannel
+	Get(id string) StoreChannel
+	GetByToken(tokenHash string) StoreChannel
+	GetByUser(userId string) StoreChannel
+	UpdateLastUsedAt(id string, lastUsedAt int64) StoreChannel
+	Delete(id string) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
@@ -231,6 +231,19 @@ func getClientConfig(c *model.Config) map[string]string {
 	props["EnableSignUpWithGitLab"] = strconv.FormatBool(c.GitLabSettings.Enable)
 	props["EnableSignUpWithGoogle"] = strconv.FormatBool(c.GoogleSettings.Enable)
 
+	openIdProviders := []map[string]string{}
+	for _, settings := range c.OpenIdSettings {
+		if settings.Enable {
+			openIdProviders = append(openIdProviders, map[string]string{"name": settings.Name, "button_text": settings.ButtonText})
+		}
+	}
+	if b, err := json.Marshal(openIdProviders); err == nil {
+		props["OpenIdProviders"] = string(b)
+	}
+
+	props["EnableSaml"] = strconv.FormatBool(*c.SamlSettings.Enable)
+	props["SamlLoginButtonText"] = *c.SamlSettings.LoginButtonText
+
 	props["ShowEmailAddress"] = strconv.FormatBool(c.PrivacySettings.ShowEmailAddress)
 
 	props["TermsOfServiceLink"] = *c.SupportSettings.TermsOfServiceLink
@@ -253,6 +266,7 @@ func getClientConfig(c *model.Config) map[string]string {
 
 	props["EnableCustomEmoji"] = strconv.FormatBool(*c.ServiceSettings.EnableCustomEmoji)
 	props["RestrictCustomEmojiCreation"] = *c.ServiceSettings.RestrictCustomEmojiCreation
+	props["EnableUserAccessTokens"] = strconv.FormatBool(*c.ServiceSettings.EnableUserAccessTokens)
 
 	if IsLicensed {
 		if *License.Features.CustomBrand {
@@ -306,6 +320,9 @@ func Desanitize(cfg *model.Config) {
 	if cfg.EmailSettings.PasswordResetSalt == model.FAKE_SETTING {
 		cfg.EmailSettings.PasswordResetSalt = Cfg.EmailSettings.PasswordResetSalt
 	}
+	if cfg.SamlSettings.RelayStateSalt != nil && *cfg.SamlSettings.RelayStateSalt == model.FAKE_SETTING {
+		*cfg.SamlSettings.RelayStateSalt = *Cfg.SamlSettings.RelayStateSalt
+	}
 	if cfg.EmailSettings.SMTPPassword == model.FAKE_SETTING {
 		cfg.EmailSettings.SMTPPassword = Cfg.EmailSettings.SMTPPassword
 	}
@@ -314,6 +331,14 @@ func Desanitize(cfg *model.Config) {
 		cfg.GitLabSettings.Secret = Cfg.GitLabSettings.Secret
 	}
 
+	for _, settings := range cfg.OpenIdSettings {
+		if settings.Secret == model.FAKE_SETTING {
+			if old := Cfg.GetOpenIdService(settings.Name); old != nil {
+				settings.Secret = old.Secret
+			}
+		}
+	}
+
 	if cfg.SqlSettings.DataSource == model.FAKE_SETTING {
 		cfg.SqlSettings.DataSource = Cfg.SqlSettings.DataSource
 	}
@@ -324,4 +349,8 @@ func Desanitize(cfg *model.Config) {
 	for i := range cfg.SqlSettings.DataSourceReplicas {
 		cfg.SqlSettings.DataSourceReplicas[i] = Cfg.SqlSettings.DataSourceReplicas[i]
 	}
+
+	if cfg.ClusterSettings.InterNodeSecret != nil && *cfg.ClusterSettings.InterNodeSecret == model.FAKE_SETTING {
+		*cfg.ClusterSettings.InterNodeSecret = *Cfg.ClusterSettings.InterNodeSecret
+	}
 }
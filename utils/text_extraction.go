@@ -0,0 +1,384 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/platform/model"
+)
+
+var plainTextExtensions = map[string]bool{
+	".txt": true, ".text": true, ".log": true, ".csv": true, ".tsv": true,
+	".md": true, ".markdown": true, ".rst": true,
+	".go": true, ".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".cs": true, ".java": true,
+	".kt": true, ".scala": true, ".swift": true, ".m": true, ".rs": true, ".py": true, ".rb": true, ".php": true,
+	".pl": true, ".lua": true, ".r": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".sh": true,
+	".bat": true, ".ps1": true, ".sql": true, ".html": true, ".htm": true, ".css": true, ".scss": true, ".less": true,
+	".xml": true, ".json": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".cfg": true,
+}
+
+// CanExtractText returns true if ExtractText understands files with the given name
+func CanExtractText(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return plainTextExtensions[ext] || ext == ".pdf" || ext == ".docx"
+}
+
+// ExtractText returns the plain text contents of a text, markdown, source code, PDF or docx file so that it can
+// be searched. The file's extension is used to decide how it's read. No more than maxSize bytes are decompressed
+// from each part of a PDF or docx file, and the text is cut short once it's longer than a FileInfo can store.
+func ExtractText(filename string, data []byte, maxSize int64) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	var text string
+	var err error
+
+	switch {
+	case plainTextExtensions[ext]:
+		text = extractPlainText(data)
+	case ext == ".pdf":
+		text, err = extractPdfText(data, maxSize)
+	case ext == ".docx":
+		text, err = extractDocxText(data, maxSize)
+	default:
+		err = fmt.Errorf("unable to extract text from %v files", ext)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return truncateText(text, model.FILE_INFO_CONTENT_MAX_SIZE), nil
+}
+
+// truncateText cuts text down to at most maxSize bytes without splitting a character
+func truncateText(text string, maxSize int) string {
+	if len(text) <= maxSize {
+		return text
+	}
+
+	end := maxSize
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	return text[:end]
+}
+
+// readLimited reads everything from r, failing instead of reading more than maxSize bytes
+func readLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return data, err
+	} else if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("contents are larger than %v bytes", maxSize)
+	}
+
+	return data, nil
+}
+
+func extractPlainText(data []byte) string {
+	if len(data) > model.FILE_INFO_CONTENT_MAX_SIZE+utf8.UTFMax {
+		// nothing past what can be stored is needed
+		data = data[:model.FILE_INFO_CONTENT_MAX_SIZE+utf8.UTFMax]
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	if utf8.Valid(data) {
+		return string(data)
+	}
+
+	// drop anything that isn't valid utf8 instead of storing text that the database may reject
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, string(data))
+}
+
+// extractDocxText reads the paragraphs of a Word document out of the document.xml file that it contains
+func extractDocxText(data []byte, maxSize int64) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range archive.File {
+		if file.Name != "word/document.xml" {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+
+		document, err := readLimited(reader, maxSize)
+		reader.Close()
+		if err != nil {
+			return "", err
+		}
+
+		text := &bytes.Buffer{}
+		inText := false
+
+		decoder := xml.NewDecoder(bytes.NewReader(document))
+		for text.Len() < model.FILE_INFO_CONTENT_MAX_SIZE {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return "", err
+			}
+
+			switch t := token.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "t":
+					inText = true
+				case "tab":
+					text.WriteByte('\t')
+				case "br", "cr":
+					text.WriteByte('\n')
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "t":
+					inText = false
+				case "p":
+					text.WriteByte('\n')
+				}
+			case xml.CharData:
+				if inText {
+					text.Write(t)
+				}
+			}
+		}
+
+		return text.String(), nil
+	}
+
+	return "", fmt.Errorf("docx file is missing word/document.xml")
+}
+
+var pdfStreamStart = regexp.MustCompile(`>>\s*stream\r?\n`)
+
+// extractPdfText pulls the text drawn by the content streams of a PDF. This only understands text written with
+// simple font encodings, which covers most documents exported by word processors, but not ones that use
+// embedded CID fonts or that are scanned images.
+func extractPdfText(data []byte, maxSize int64) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", fmt.Errorf("file is not a pdf")
+	}
+
+	text := &bytes.Buffer{}
+
+	for _, match := range pdfStreamStart.FindAllIndex(data, -1) {
+		if text.Len() >= model.FILE_INFO_CONTENT_MAX_SIZE {
+			break
+		}
+
+		// the stream's dictionary is everything between the start of its object and the stream itself
+		dict := data[:match[0]]
+		if objStart := bytes.LastIndex(dict, []byte(" obj")); objStart != -1 {
+			dict = dict[objStart:]
+		}
+		start := match[1]
+
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end == -1 {
+			break
+		}
+		stream := data[start : start+end]
+
+		if bytes.Contains(dict, []byte("/Subtype")) || bytes.Contains(dict, []byte("/Length1")) {
+			// images and embedded fonts don't contain any text
+			continue
+		}
+
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			reader, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+
+			decoded, err := readLimited(reader, maxSize)
+			reader.Close()
+			if err == io.ErrUnexpectedEOF {
+				// some writers cut the checksum off the end of a stream, so keep whatever was decoded
+			} else if err != nil {
+				return "", err
+			}
+			stream = decoded
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			continue
+		}
+
+		extractPdfContentText(stream, text)
+	}
+
+	return strings.TrimSpace(text.String()), nil
+}
+
+// extractPdfContentText writes out the strings shown by the text operators of a PDF content stream
+func extractPdfContentText(stream []byte, text *bytes.Buffer) {
+	var strs [][]byte
+
+	for i := 0; i < len(stream); i++ {
+		switch c := stream[i]; {
+		case c == '(':
+			var str []byte
+			str, i = readPdfLiteralString(stream, i+1)
+			strs = append(strs, str)
+		case c == '<' && i+1 < len(stream) && stream[i+1] != '<':
+			end := bytes.IndexByte(stream[i:], '>')
+			if end == -1 {
+				return
+			}
+			strs = append(strs, decodePdfHexString(stream[i+1:i+end]))
+			i += end
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case c == '/':
+			// skip over names so that they aren't mistaken for operators
+			for i+1 < len(stream) && !isPdfDelimiter(stream[i+1]) {
+				i++
+			}
+		case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '\'' || c == '"' || c == '*':
+			start := i
+			for i+1 < len(stream) && isPdfOperatorChar(stream[i+1]) {
+				i++
+			}
+
+			switch string(stream[start : i+1]) {
+			case "Tj", "TJ":
+				for _, str := range strs {
+					text.Write(str)
+				}
+			case "'", "\"":
+				text.WriteByte('\n')
+				for _, str := range strs {
+					text.Write(str)
+				}
+			case "Td", "TD", "T*", "Tm", "ET":
+				if text.Len() > 0 && text.Bytes()[text.Len()-1] != '\n' {
+					text.WriteByte('\n')
+				}
+			}
+
+			strs = nil
+		}
+	}
+}
+
+func isPdfOperatorChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '*'
+}
+
+func isPdfDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n\f\x00()<>[]{}/%", c) != -1
+}
+
+// readPdfLiteralString reads a string in parentheses starting after the opening one, returning it and the index
+// of the closing parenthesis
+func readPdfLiteralString(stream []byte, i int) ([]byte, int) {
+	str := []byte{}
+	depth := 1
+
+	for ; i < len(stream); i++ {
+		c := stream[i]
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return str, i
+			}
+		case '\\':
+			i++
+			if i >= len(stream) {
+				return str, i
+			}
+
+			switch e := stream[i]; e {
+			case 'n':
+				str = append(str, '\n')
+			case 'r':
+				str = append(str, '\r')
+			case 't':
+				str = append(str, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// a backslash at the end of a line continues the string on the next one
+			default:
+				if e >= '0' && e <= '7' {
+					value := 0
+					for j := 0; j < 3 && i < len(stream) && stream[i] >= '0' && stream[i] <= '7'; j++ {
+						value = value*8 + int(stream[i]-'0')
+						i++
+					}
+					i--
+					str = appendPdfChar(str, byte(value))
+				} else {
+					str = append(str, e)
+				}
+			}
+			continue
+		}
+
+		str = appendPdfChar(str, c)
+	}
+
+	return str, i
+}
+
+func decodePdfHexString(hex []byte) []byte {
+	str := []byte{}
+
+	digits := []byte{}
+	for _, c := range hex {
+		if c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	for i := 0; i < len(digits); i += 2 {
+		var value byte
+		fmt.Sscanf(string(digits[i:i+2]), "%02x", &value)
+		str = appendPdfChar(str, value)
+	}
+
+	return str
+}
+
+// appendPdfChar appends a character from a PDF string, treating it as Latin-1 since that's close to the
+// encoding used by the standard fonts
+func appendPdfChar(str []byte, c byte) []byte {
+	if c < 0x20 && c != '\n' && c != '\t' {
+		return str
+	} else if c < utf8.RuneSelf {
+		return append(str, c)
+	} else {
+		return append(str, string(rune(c))...)
+	}
+}
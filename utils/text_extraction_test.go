@@ -0,0 +1,147 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestCanExtractText(t *testing.T) {
+	for _, filename := range []string{"notes.txt", "README.md", "main.go", "Report.PDF", "letter.docx"} {
+		if !CanExtractText(filename) {
+			t.Fatalf("should be able to extract text from %v", filename)
+		}
+	}
+
+	for _, filename := range []string{"image.png", "archive.zip", "noextension", "sheet.xlsx"} {
+		if CanExtractText(filename) {
+			t.Fatalf("shouldn't be able to extract text from %v", filename)
+		}
+	}
+}
+
+func TestExtractPlainText(t *testing.T) {
+	if text, err := ExtractText("file.txt", []byte("\xef\xbb\xbfhello world"), 1024*1024); err != nil {
+		t.Fatal(err)
+	} else if text != "hello world" {
+		t.Fatal("should've removed the byte order mark", text)
+	}
+
+	if text, err := ExtractText("file.go", []byte("package \xffmain"), 1024*1024); err != nil {
+		t.Fatal(err)
+	} else if text != "package main" {
+		t.Fatal("should've removed invalid utf8", text)
+	}
+
+	if _, err := ExtractText("file.png", []byte("data"), 1024*1024); err == nil {
+		t.Fatal("shouldn't extract text from an image")
+	}
+}
+
+func TestExtractDocxText(t *testing.T) {
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+	writer, _ := archive.Create("word/document.xml")
+	writer.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+	<w:body>
+		<w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>
+		<w:p><w:r><w:t>Revenue</w:t><w:tab/><w:t>grew &amp; grew</w:t></w:r></w:p>
+	</w:body>
+</w:document>`))
+	archive.Close()
+
+	if text, err := ExtractText("report.docx", buf.Bytes(), 1024*1024); err != nil {
+		t.Fatal(err)
+	} else if text != "Quarterly report\nRevenue\tgrew & grew\n" {
+		t.Fatalf("got the wrong text, %q", text)
+	}
+
+	if _, err := ExtractText("report.docx", []byte("not a zip file"), 1024*1024); err == nil {
+		t.Fatal("should've failed to read an invalid docx file")
+	}
+}
+
+func TestExtractPdfText(t *testing.T) {
+	content := "BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\) world) Tj 0 -14 Td [(Sec) -20 (ond)] TJ <6c696e65> Tj ET"
+
+	compressed := &bytes.Buffer{}
+	writer := zlib.NewWriter(compressed)
+	writer.Write([]byte("BT /F1 12 Tf (Compressed\\040text) Tj ET"))
+	writer.Close()
+
+	pdf := &bytes.Buffer{}
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString(fmt.Sprintf("4 0 obj << /Length %v >> stream\n%v\nendstream endobj\n", len(content), content))
+	pdf.WriteString(fmt.Sprintf("5 0 obj << /Length %v /Filter /FlateDecode >> stream\n", compressed.Len()))
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream endobj\n")
+	pdf.WriteString("6 0 obj << /Type /XObject /Subtype /Image /Length 4 >> stream\n(no) Tj\nendstream endobj\n")
+	pdf.WriteString("%%EOF\n")
+
+	text, err := ExtractText("file.pdf", pdf.Bytes(), 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"Hello (PDF) world", "Second", "line", "Compressed text"} {
+		if !strings.Contains(text, expected) {
+			t.Fatalf("should've found %q in %q", expected, text)
+		}
+	}
+
+	if strings.Contains(text, "no") {
+		t.Fatalf("shouldn't have read text out of an image, %q", text)
+	}
+
+	if _, err := ExtractText("file.pdf", []byte("not a pdf"), 1024*1024); err == nil {
+		t.Fatal("should've failed to read an invalid pdf")
+	}
+}
+
+func TestExtractTextLimits(t *testing.T) {
+	if text, err := ExtractText("file.txt", []byte(strings.Repeat("é", model.FILE_INFO_CONTENT_MAX_SIZE)), 1024); err != nil {
+		t.Fatal(err)
+	} else if len(text) > model.FILE_INFO_CONTENT_MAX_SIZE || !utf8.ValidString(text) {
+		t.Fatal("should've cut the text short at a character boundary", len(text))
+	}
+
+	compressed := &bytes.Buffer{}
+	writer := zlib.NewWriter(compressed)
+	writer.Write([]byte("BT (" + strings.Repeat("a", 4096) + ") Tj ET"))
+	writer.Close()
+
+	pdf := &bytes.Buffer{}
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString(fmt.Sprintf("5 0 obj << /Length %v /Filter /FlateDecode >> stream\n", compressed.Len()))
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream endobj\n%%EOF\n")
+
+	if _, err := ExtractText("file.pdf", pdf.Bytes(), 1024); err == nil {
+		t.Fatal("shouldn't decompress more than the limit")
+	}
+
+	if _, err := ExtractText("file.pdf", pdf.Bytes(), 8192); err != nil {
+		t.Fatal("should've read a stream under the limit", err)
+	}
+
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+	document, _ := archive.Create("word/document.xml")
+	document.Write([]byte(`<w:document><w:body><w:p><w:r><w:t>` + strings.Repeat("a", 4096) + `</w:t></w:r></w:p></w:body></w:document>`))
+	archive.Close()
+
+	if _, err := ExtractText("report.docx", buf.Bytes(), 1024); err == nil {
+		t.Fatal("shouldn't decompress more than the limit")
+	}
+}
@@ -176,7 +176,17 @@ export default class AdminSidebar extends React.Component {
 
     render() {
         let ldapSettings = null;
-        let complianceSettings = null;
+        const complianceSettings = (
+            <AdminSidebarSection
+                name='compliance'
+                title={
+                    <FormattedMessage
+                        id='admin.sidebar.compliance'
+                        defaultMessage='Compliance'
+                    />
+                }
+            />
+        );
 
         let license = null;
         let audits = null;
@@ -196,20 +206,6 @@ export default class AdminSidebar extends React.Component {
                         />
                     );
                 }
-
-                if (global.window.mm_license.Compliance === 'true') {
-                    complianceSettings = (
-                        <AdminSidebarSection
-                            name='compliance'
-                            title={
-                                <FormattedMessage
-                                    id='admin.sidebar.compliance'
-                                    defaultMessage='Compliance'
-                                />
-                            }
-                        />
-                    );
-                }
             }
 
             license = (
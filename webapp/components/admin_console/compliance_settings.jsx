@@ -7,7 +7,8 @@ import * as Utils from 'utils/utils.jsx';
 
 import AdminSettings from './admin_settings.jsx';
 import BooleanSetting from './boolean_setting.jsx';
-import {FormattedHTMLMessage, FormattedMessage} from 'react-intl';
+import DropdownSetting from './dropdown_setting.jsx';
+import {FormattedMessage} from 'react-intl';
 import SettingsGroup from './settings_group.jsx';
 import TextSetting from './text_setting.jsx';
 
@@ -22,7 +23,8 @@ export default class ComplianceSettings extends AdminSettings {
         this.state = Object.assign(this.state, {
             enable: props.config.ComplianceSettings.Enable,
             directory: props.config.ComplianceSettings.Directory,
-            enableDaily: props.config.ComplianceSettings.EnableDaily
+            enableDaily: props.config.ComplianceSettings.EnableDaily,
+            exportFormat: props.config.ComplianceSettings.ExportFormat
         });
     }
 
@@ -30,6 +32,7 @@ export default class ComplianceSettings extends AdminSettings {
         config.ComplianceSettings.Enable = this.state.enable;
         config.ComplianceSettings.Directory = this.state.directory;
         config.ComplianceSettings.EnableDaily = this.state.enableDaily;
+        config.ComplianceSettings.ExportFormat = this.state.exportFormat;
 
         return config;
     }
@@ -46,25 +49,8 @@ export default class ComplianceSettings extends AdminSettings {
     }
 
     renderSettings() {
-        const licenseEnabled = global.window.mm_license.IsLicensed === 'true' && global.window.mm_license.Compliance === 'true';
-
-        let bannerContent;
-        if (!licenseEnabled) {
-            bannerContent = (
-                <div className='banner warning'>
-                    <div className='banner__content'>
-                        <FormattedHTMLMessage
-                            id='admin.compliance.noLicense'
-                            defaultMessage='<h4 class="banner__heading">Note:</h4><p>Compliance is an enterprise feature. Your current license does not support Compliance. Click <a href="http://mattermost.com"target="_blank">here</a> for information and pricing on enterprise licenses.</p>'
-                        />
-                    </div>
-                </div>
-            );
-        }
-
         return (
             <SettingsGroup>
-                {bannerContent}
                 <BooleanSetting
                     id='enable'
                     label={
@@ -81,7 +67,6 @@ export default class ComplianceSettings extends AdminSettings {
                     }
                     value={this.state.enable}
                     onChange={this.handleChange}
-                    disabled={!licenseEnabled}
                 />
                 <TextSetting
                     id='directory'
@@ -100,7 +85,7 @@ export default class ComplianceSettings extends AdminSettings {
                     }
                     value={this.state.directory}
                     onChange={this.handleChange}
-                    disabled={!licenseEnabled || !this.state.enable}
+                    disabled={!this.state.enable}
                 />
                 <BooleanSetting
                     id='enableDaily'
@@ -118,7 +103,30 @@ export default class ComplianceSettings extends AdminSettings {
                     }
                     value={this.state.enableDaily}
                     onChange={this.handleChange}
-                    disabled={!licenseEnabled || !this.state.enable}
+                    disabled={!this.state.enable}
+                />
+                <DropdownSetting
+                    id='exportFormat'
+                    values={[
+                        {value: 'csv', text: Utils.localizeMessage('admin.compliance.exportFormatCsv', 'CSV')},
+                        {value: 'actiance', text: Utils.localizeMessage('admin.compliance.exportFormatActiance', 'Actiance XML')},
+                        {value: 'globalrelay', text: Utils.localizeMessage('admin.compliance.exportFormatGlobalRelay', 'GlobalRelay EML')}
+                    ]}
+                    label={
+                        <FormattedMessage
+                            id='admin.compliance.exportFormatTitle'
+                            defaultMessage='Export Format:'
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.compliance.exportFormatDesc'
+                            defaultMessage='Format of the compliance reports. CSV writes every event to a single file, Actiance XML writes a conversation for each channel and GlobalRelay EML writes an email for each channel. Reports include messages, edits, deletions and users joining or leaving channels.'
+                        />
+                    }
+                    value={this.state.exportFormat}
+                    onChange={this.handleChange}
+                    disabled={!this.state.enable}
                 />
             </SettingsGroup>
         );
@@ -22,7 +22,8 @@ export default class WebhookSettings extends AdminSettings {
             enableCommands: props.config.ServiceSettings.EnableCommands,
             enableOnlyAdminIntegrations: props.config.ServiceSettings.EnableOnlyAdminIntegrations,
             enablePostUsernameOverride: props.config.ServiceSettings.EnablePostUsernameOverride,
-            enablePostIconOverride: props.config.ServiceSettings.EnablePostIconOverride
+            enablePostIconOverride: props.config.ServiceSettings.EnablePostIconOverride,
+            enableUserAccessTokens: props.config.ServiceSettings.EnableUserAccessTokens
         });
     }
 
@@ -33,6 +34,7 @@ export default class WebhookSettings extends AdminSettings {
         config.ServiceSettings.EnableOnlyAdminIntegrations = this.state.enableOnlyAdminIntegrations;
         config.ServiceSettings.EnablePostUsernameOverride = this.state.enablePostUsernameOverride;
         config.ServiceSettings.EnablePostIconOverride = this.state.enablePostIconOverride;
+        config.ServiceSettings.EnableUserAccessTokens = this.state.enableUserAccessTokens;
 
         return config;
     }
@@ -153,6 +155,23 @@ export default class WebhookSettings extends AdminSettings {
                     value={this.state.enablePostIconOverride}
                     onChange={this.handleChange}
                 />
+                <BooleanSetting
+                    id='enableUserAccessTokens'
+                    label={
+                        <FormattedMessage
+                            id='admin.service.userAccessTokensTitle'
+                            defaultMessage='Enable Personal Access Tokens: '
+                        />
+                    }
+                    helpText={
+                        <FormattedMessage
+                            id='admin.service.userAccessTokensDescription'
+                            defaultMessage='When true, users can create long-lived personal access tokens for scripts and integrations to use with the REST API. Tokens do not expire and stay valid until they are revoked.'
+                        />
+                    }
+                    value={this.state.enableUserAccessTokens}
+                    onChange={this.handleChange}
+                />
             </SettingsGroup>
         );
     }
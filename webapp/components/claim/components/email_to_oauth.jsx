@@ -3,6 +3,7 @@
 
 import * as Utils from 'utils/utils.jsx';
 import Client from 'utils/web_client.jsx';
+import Constants from 'utils/constants.jsx';
 
 import React from 'react';
 import ReactDOM from 'react-dom';
@@ -30,19 +31,20 @@ export default class EmailToOAuth extends React.Component {
         state.error = null;
         this.setState(state);
 
-        Client.emailToOAuth(
-            this.props.email,
-            password,
-            this.props.newType,
-            (data) => {
-                if (data.follow_link) {
-                    window.location.href = data.follow_link;
-                }
-            },
-            (err) => {
-                this.setState({error: err.message});
+        const success = (data) => {
+            if (data.follow_link) {
+                window.location.href = data.follow_link;
             }
-        );
+        };
+        const error = (err) => {
+            this.setState({error: err.message});
+        };
+
+        if (this.props.newType === Constants.SAML_SERVICE) {
+            Client.emailToSaml(this.props.email, password, success, error);
+        } else {
+            Client.emailToOAuth(this.props.email, password, this.props.newType, success, error);
+        }
     }
     render() {
         var error = null;
@@ -55,7 +57,11 @@ export default class EmailToOAuth extends React.Component {
             formClass += ' has-error';
         }
 
-        const uiType = Utils.toTitleCase(this.props.newType) + ' SSO';
+        let type = Utils.toTitleCase(this.props.newType);
+        if (this.props.newType === Constants.SAML_SERVICE) {
+            type = 'SAML';
+        }
+        const uiType = type + ' SSO';
 
         return (
             <div>
@@ -74,7 +80,7 @@ export default class EmailToOAuth extends React.Component {
                             id='claim.email_to_oauth.ssoType'
                             defaultMessage='Upon claiming your account, you will only be able to login with {type} SSO'
                             values={{
-                                type: Utils.toTitleCase(this.props.newType)
+                                type
                             }}
                         />
                     </p>
@@ -83,7 +89,7 @@ export default class EmailToOAuth extends React.Component {
                             id='claim.email_to_oauth.ssoNote'
                             defaultMessage='You must already have a valid {type} account'
                             values={{
-                                type: Utils.toTitleCase(this.props.newType)
+                                type
                             }}
                         />
                     </p>
@@ -3,6 +3,7 @@
 
 import * as Utils from 'utils/utils.jsx';
 import Client from 'utils/web_client.jsx';
+import Constants from 'utils/constants.jsx';
 
 import React from 'react';
 import ReactDOM from 'react-dom';
@@ -38,18 +39,20 @@ export default class OAuthToEmail extends React.Component {
         state.error = null;
         this.setState(state);
 
-        Client.oauthToEmail(
-            this.props.email,
-            password,
-            (data) => {
-                if (data.follow_link) {
-                    browserHistory.push(data.follow_link);
-                }
-            },
-            (err) => {
-                this.setState({error: err.message});
+        const success = (data) => {
+            if (data.follow_link) {
+                browserHistory.push(data.follow_link);
             }
-        );
+        };
+        const error = (err) => {
+            this.setState({error: err.message});
+        };
+
+        if (this.props.currentType === Constants.SAML_SERVICE) {
+            Client.samlToEmail(this.props.email, password, success, error);
+        } else {
+            Client.oauthToEmail(this.props.email, password, success, error);
+        }
     }
     render() {
         var error = null;
@@ -62,7 +65,10 @@ export default class OAuthToEmail extends React.Component {
             formClass += ' has-error';
         }
 
-        const uiType = Utils.toTitleCase(this.props.currentType) + ' SSO';
+        let uiType = Utils.toTitleCase(this.props.currentType) + ' SSO';
+        if (this.props.currentType === Constants.SAML_SERVICE) {
+            uiType = 'SAML SSO';
+        }
 
         return (
             <div>
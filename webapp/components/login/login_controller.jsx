@@ -319,6 +319,8 @@ export default class LoginController extends React.Component {
         const ldapEnabled = this.state.ldapEnabled;
         const gitlabSigninEnabled = global.window.mm_config.EnableSignUpWithGitLab === 'true';
         const googleSigninEnabled = global.window.mm_config.EnableSignUpWithGoogle === 'true';
+        const openIdProviders = Utils.getOpenIdProviders();
+        const samlSigninEnabled = global.window.mm_config.EnableSaml === 'true';
         const usernameSigninEnabled = this.state.usernameSigninEnabled;
         const emailSigninEnabled = this.state.emailSigninEnabled;
 
@@ -416,7 +418,7 @@ export default class LoginController extends React.Component {
             );
         }
 
-        if ((emailSigninEnabled || usernameSigninEnabled || ldapEnabled) && (gitlabSigninEnabled || googleSigninEnabled)) {
+        if ((emailSigninEnabled || usernameSigninEnabled || ldapEnabled) && (gitlabSigninEnabled || googleSigninEnabled || openIdProviders.length > 0 || samlSigninEnabled)) {
             loginControls.push(
                 <div
                     key='divider'
@@ -475,6 +477,30 @@ export default class LoginController extends React.Component {
             );
         }
 
+        for (const provider of openIdProviders) {
+            loginControls.push(
+                <a
+                    className='btn btn-custom-login openid'
+                    key={'openid_' + provider.name}
+                    href={Client.getOAuthRoute() + '/' + provider.name + '/login' + this.props.location.search}
+                >
+                    <span>{provider.button_text}</span>
+                </a>
+            );
+        }
+
+        if (samlSigninEnabled) {
+            loginControls.push(
+                <a
+                    className='btn btn-custom-login saml'
+                    key='saml'
+                    href={'/login/sso/saml' + this.props.location.search}
+                >
+                    <span>{global.window.mm_config.SamlLoginButtonText}</span>
+                </a>
+            );
+        }
+
         return (
             <div>
                 {extraBox}
@@ -588,6 +588,36 @@ export default class SignupUserComplete extends React.Component {
            );
         }
 
+        for (const provider of Utils.getOpenIdProviders()) {
+            signupMessage.push(
+                <a
+                    className='btn btn-custom-login openid'
+                    key={'openid_' + provider.name}
+                    href={Client.getOAuthRoute() + '/' + provider.name + '/signup' + window.location.search}
+                >
+                    <span>
+                        <FormattedMessage
+                            id='signup_user_completed.openid'
+                            defaultMessage='with {provider}'
+                            values={{provider: provider.button_text}}
+                        />
+                    </span>
+                </a>
+            );
+        }
+
+        if (global.window.mm_config.EnableSaml === 'true') {
+            signupMessage.push(
+                <a
+                    className='btn btn-custom-login saml'
+                    key='saml'
+                    href={'/login/sso/saml' + window.location.search + (window.location.search ? '&' : '?') + 'action=signup'}
+                >
+                    <span>{global.window.mm_config.SamlLoginButtonText}</span>
+                </a>
+            );
+        }
+
         let ldapSignup;
         if (global.window.mm_config.EnableLdap === 'true' && global.window.mm_license.IsLicensed === 'true' && global.window.mm_license.LDAP) {
             ldapSignup = (
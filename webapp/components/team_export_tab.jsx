@@ -6,29 +6,46 @@ import Client from 'utils/web_client.jsx';
 import {FormattedMessage} from 'react-intl';
 
 import React from 'react';
-import {Link} from 'react-router';
+
+const POLL_INTERVAL = 2000;
 
 export default class TeamExportTab extends React.Component {
     constructor(props) {
         super(props);
         this.state = {status: 'request', link: '', err: ''};
 
-        this.onExportSuccess = this.onExportSuccess.bind(this);
+        this.onExportJob = this.onExportJob.bind(this);
         this.onExportFailure = this.onExportFailure.bind(this);
+        this.pollExportJob = this.pollExportJob.bind(this);
         this.doExport = this.doExport.bind(this);
     }
-    onExportSuccess(data) {
-        this.setState({status: 'ready', link: data.link, err: ''});
+    componentWillUnmount() {
+        clearTimeout(this.pollTimeout);
+    }
+    onExportJob(job) {
+        switch (job.status) {
+        case 'success':
+            this.setState({status: 'ready', link: Client.getExportDownloadLink(job.id), err: ''});
+            break;
+        case 'failed':
+            this.setState({status: 'failure', link: '', err: job.error});
+            break;
+        default:
+            this.pollTimeout = setTimeout(() => this.pollExportJob(job.id), POLL_INTERVAL);
+        }
     }
     onExportFailure(e) {
         this.setState({status: 'failure', link: '', err: e.message});
     }
+    pollExportJob(jobId) {
+        Client.getExportJob(jobId, this.onExportJob, this.onExportFailure);
+    }
     doExport() {
         if (this.state.status === 'in-progress') {
             return;
         }
         this.setState({status: 'in-progress'});
-        Client.exportTeam(this.onExportSuccess, this.onExportFailure);
+        Client.startExport({}, this.onExportJob, this.onExportFailure);
     }
     render() {
         var messageSection = '';
@@ -55,15 +72,15 @@ export default class TeamExportTab extends React.Component {
                         id='team_export_tab.ready'
                         defaultMessage=' Ready for '
                     />
-                    <Link
-                        to={this.state.link}
+                    <a
+                        href={this.state.link}
                         download={true}
                     >
                         <FormattedMessage
                             id='team_export_tab.download'
                             defaultMessage='download'
                         />
-                    </Link>
+                    </a>
                 </p>
             );
             break;
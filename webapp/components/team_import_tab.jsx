@@ -34,7 +34,7 @@ class TeamImportTab extends React.Component {
     }
 
     onImportSuccess(data, res) {
-        this.setState({status: 'done', link: 'data:application/octet-stream;charset=utf-8,' + encodeURIComponent(res.text)});
+        this.setState({status: 'done', link: 'data:application/json;charset=utf-8,' + encodeURIComponent(res.text)});
     }
 
     doImportSlack(file) {
@@ -48,7 +48,7 @@ class TeamImportTab extends React.Component {
             <div>
                 <FormattedHTMLMessage
                     id='team_import_tab.importHelp'
-                    defaultMessage="<p>To import a team from Slack go to Slack > Team Settings > Import/Export Data > Export > Start Export. Slack does not allow you to export files, images, private groups or direct messages stored in Slack. Therefore, Slack import to Mattermost only supports importing of text messages in your Slack team's public channels.</p><p>The Slack import to Mattermost is in 'Beta'. Slack bot posts do not yet import and Slack @mentions are not currently supported.</p>"
+                    defaultMessage="<p>To import a team from Slack go to Slack > Team Settings > Import/Export Data > Export > Start Export. Public channels, private groups and direct messages are imported along with their threads, reactions and shared files when they're included in the export.</p><p>The Slack import to Mattermost is in 'Beta'. Slack bot posts do not yet import.</p>"
                 />
             </div>
         );
@@ -88,7 +88,7 @@ class TeamImportTab extends React.Component {
                     />
                     <a
                         href={this.state.link}
-                        download='MattermostImportSummary.txt'
+                        download='SlackImportReport.json'
                     >
                         <FormattedMessage
                             id='team_import_tab.summary'
@@ -108,7 +108,7 @@ class TeamImportTab extends React.Component {
                     />
                     <a
                         href={this.state.link}
-                        download='MattermostImportSummary.txt'
+                        download='SlackImportReport.json'
                     >
                         <FormattedMessage
                             id='team_import_tab.summary'
@@ -1266,6 +1266,15 @@ export function isFeatureEnabled(feature) {
     return PreferenceStore.getBool(Constants.Preferences.CATEGORY_ADVANCED_SETTINGS, Constants.FeatureTogglePrefix + feature.label);
 }
 
+// getOpenIdProviders returns the name and button text of each enabled OpenID Connect provider
+export function getOpenIdProviders() {
+    try {
+        return JSON.parse(global.window.mm_config.OpenIdProviders || '[]');
+    } catch (e) {
+        return [];
+    }
+}
+
 export function fillArray(value, length) {
     const arr = [];
 
@@ -85,6 +85,49 @@ class WebClientClass extends Client {
         );
     }
 
+    emailToSaml(email, password, success, error) {
+        request.
+            post(`${this.getUsersRoute()}/claim/email_to_saml`).
+            set(this.defaultHeaders).
+            type('application/json').
+            accept('application/json').
+            send({email, password}).
+            end(this.handleResponse.bind(this, 'emailToSaml', success, error));
+    }
+
+    samlToEmail(email, password, success, error) {
+        request.
+            post(`${this.getUsersRoute()}/claim/saml_to_email`).
+            set(this.defaultHeaders).
+            type('application/json').
+            accept('application/json').
+            send({email, password}).
+            end(this.handleResponse.bind(this, 'samlToEmail', success, error));
+    }
+
+    startExport(options, success, error) {
+        request.
+            post(`${this.getTeamNeededRoute()}/files/export`).
+            set(this.defaultHeaders).
+            type('application/json').
+            accept('application/json').
+            send(options).
+            end(this.handleResponse.bind(this, 'startExport', success, error));
+    }
+
+    getExportJob(jobId, success, error) {
+        request.
+            get(`${this.getTeamNeededRoute()}/files/export/${jobId}`).
+            set(this.defaultHeaders).
+            type('application/json').
+            accept('application/json').
+            end(this.handleResponse.bind(this, 'getExportJob', success, error));
+    }
+
+    getExportDownloadLink(jobId) {
+        return `${this.getTeamNeededRoute()}/files/export/${jobId}/download`;
+    }
+
     getYoutubeVideoInfo(googleKey, videoId, success, error) {
         request.get('https://www.googleapis.com/youtube/v3/videos').
         query({part: 'snippet', id: videoId, key: googleKey}).